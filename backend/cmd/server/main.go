@@ -25,64 +25,273 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/kalshi-dcm-demo/backend/internal/api"
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
 	"github.com/kalshi-dcm-demo/backend/internal/compliance"
+	"github.com/kalshi-dcm-demo/backend/internal/config"
+	"github.com/kalshi-dcm-demo/backend/internal/events"
 	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/logging"
+	"github.com/kalshi-dcm-demo/backend/internal/metrics"
 	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/persistence"
+	"github.com/kalshi-dcm-demo/backend/internal/settlement"
+	"github.com/kalshi-dcm-demo/backend/internal/webhook"
 	"github.com/kalshi-dcm-demo/backend/internal/ws"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-func main() {
-	log.Println("===========================================")
-	log.Println("  Kalshi DCM Demo - CFTC Compliant Platform")
-	log.Println("===========================================")
-	log.Println("")
-	log.Println("Core Principles: 2, 3, 4, 5, 9, 11, 13, 17, 18")
-	log.Println("")
+// expirySweepInterval is how often orders past ORDER_TTL are expired and
+// lapsed timed halts are recorded as lifted.
+const expirySweepInterval = 10 * time.Second
 
+func main() {
 	// Configuration
-	port := getEnv("PORT", "8080")
+	cfg := config.Load()
+	port := cfg.Port
 	kalshiURL := getEnv("KALSHI_API_URL", kalshi.DefaultBaseURL)
-	dataDir := getEnv("DATA_DIR", "./data")
-	persistenceEnabled := getEnv("ENABLE_PERSISTENCE", "true") == "true"
 
-	log.Printf("Starting server on port %s", port)
-	log.Printf("Kalshi API: %s", kalshiURL)
-	log.Printf("Persistence: %v (dir: %s)", persistenceEnabled, dataDir)
+	// Structured logging; log.Printf elsewhere is routed through the same handler
+	logger := logging.New(cfg.LogFormat, cfg.LogLevel)
+	slog.SetDefault(logger)
+
+	logger.Info("starting Kalshi DCM Demo - CFTC Compliant Platform",
+		"core_principles", "2, 3, 4, 5, 9, 11, 13, 17, 18",
+		"port", port,
+		"kalshi_api", kalshiURL,
+		"persistence", cfg.EnablePersistence,
+		"persistence_backend", cfg.PersistenceBackend,
+		"data_dir", cfg.DataDir,
+	)
 
 	// Initialize components
 	// Persistent store for CP 18: 5-year recordkeeping
-	store := mock.NewStoreWithPersistence(mock.PersistenceConfig{
-		Enabled:          persistenceEnabled,
-		DataDir:          dataDir,
-		AutoSaveInterval: 5 * time.Minute,
-		RetentionYears:   5,
+	persistenceConfig := mock.PersistenceConfig{
+		Enabled:             cfg.EnablePersistence,
+		Backend:             cfg.PersistenceBackend,
+		DataDir:             cfg.DataDir,
+		AutoSaveInterval:    5 * time.Minute,
+		RetentionYears:      5,
+		AuditRetentionDays:  cfg.AuditRetentionDays,
+		SnapshotKeepDays:    cfg.SnapshotKeepDays,
+		MaintenanceInterval: cfg.MaintenanceInterval,
+		CompressSnapshots:   cfg.CompressSnapshots,
+	}
+	// Sensitive fields are only persisted encrypted; production refuses to
+	// start without a key rather than silently dropping them.
+	if cfg.FieldEncryptionKey != "" {
+		key, err := persistence.ParseFieldKey(cfg.FieldEncryptionKey)
+		if err == nil {
+			persistenceConfig.FieldCipher, err = persistence.NewFieldCipher(key)
+		}
+		if err != nil {
+			logger.Error("invalid FIELD_ENCRYPTION_KEY", "error", err)
+			os.Exit(1)
+		}
+	} else if cfg.Environment == "production" {
+		logger.Error("FIELD_ENCRYPTION_KEY is required in production")
+		os.Exit(1)
+	} else if persistenceConfig.Enabled {
+		logger.Warn("FIELD_ENCRYPTION_KEY not set; KYC document numbers will not be persisted")
+	}
+	var persister persistence.Persister
+	if persistenceConfig.Enabled {
+		var err error
+		persister, err = persistence.NewPersister(cfg.PersistenceBackend, cfg.DataDir, persistence.Options{
+			CompressSnapshots: cfg.CompressSnapshots,
+		})
+		if err != nil {
+			logger.Error("persistence backend unavailable", "error", err)
+			os.Exit(1)
+		}
+	}
+	store := mock.NewStoreWithPersister(persistenceConfig, persister)
+	logger.Info("persistent data store initialized")
+
+	// Domain events published by the store (Core Principle 18)
+	bus := events.NewBus(events.DefaultBuffer)
+	store.SetEventBus(bus)
+	go events.Log(bus.Subscribe(events.All), logger)
+	go store.AuditEvents(bus.Subscribe(events.All))
+	if cfg.WebhookURL != "" {
+		if cfg.WebhookSecret == "" {
+			logger.Warn("WEBHOOK_SECRET is empty; webhook payloads are signed with an empty key")
+		}
+		go webhook.NewNotifier(cfg.WebhookURL, cfg.WebhookSecret).Run(bus.Subscribe(webhook.Types...))
+		logger.Info("compliance webhooks enabled")
+	}
+
+	// Self-trade prevention (Core Principle 4)
+	store.SetSelfTradePolicy(mock.SelfTradePolicy(cfg.SelfTradePolicy))
+	store.SetOrderTTL(cfg.OrderTTL)
+	store.SetWashSetupThreshold(cfg.WashSetupContracts)
+	store.SetAlertDedupWindow(cfg.AlertDedupWindow)
+
+	// Realized P&L accounting for partial closes (Core Principle 11)
+	store.SetCostBasisMethod(mock.CostBasisMethod(cfg.CostBasisMethod))
+
+	// Fee schedule (Core Principle 11)
+	store.SetFeeSchedule(models.FeeSchedule{
+		TradeFeePerContractUSD: models.Dollars(cfg.TradeFeePerContract),
+		TradeFeePercent:        cfg.TradeFeePercent,
+		SettlementFeePercent:   cfg.SettlementFeePercent,
+	})
+
+	// Deposit methods, their clearing delays and the withdrawal hold
+	// (Core Principle 13)
+	clearingDelays := map[models.DepositMethod]time.Duration{
+		models.DepositMethodACH:  cfg.DepositClearingDelay,
+		models.DepositMethodWire: cfg.WireClearingDelay,
+		models.DepositMethodCard: cfg.CardClearingDelay,
+	}
+	for _, name := range cfg.DepositMethods {
+		if _, known := clearingDelays[models.DepositMethod(name)]; !known {
+			logger.Error("unknown deposit method in DEPOSIT_METHODS", "method", name)
+			os.Exit(1)
+		}
+	}
+	var depositMethods []models.DepositMethodConfig
+	for _, method := range mock.DefaultDepositMethods() {
+		if len(cfg.DepositMethods) == 0 || slices.Contains(cfg.DepositMethods, string(method.Method)) {
+			method.ClearingDelay = clearingDelays[method.Method]
+			depositMethods = append(depositMethods, method)
+		}
+	}
+	store.SetDepositMethods(depositMethods)
+	store.SetWithdrawalHold(cfg.WithdrawalHold)
+
+	// Demo accounts for a first run
+	if cfg.SeedDemo {
+		created, err := mock.SeedDemoData(store)
+		if err != nil {
+			logger.Error("demo data seeding failed", "error", err)
+			os.Exit(1)
+		}
+		if created > 0 {
+			logger.Info("demo data seeded", "users", created)
+		}
+	}
+
+	// Check loaded wallets against their ledgers (Core Principle 13)
+	mismatched := 0
+	results := store.ReconcileWallets()
+	for _, result := range results {
+		if !result.Balanced {
+			mismatched++
+			logger.Warn("wallet does not reconcile with its ledger",
+				"user_id", result.UserID, "wallet_id", result.WalletID, "discrepancies", result.Discrepancies)
+		}
+	}
+	logger.Info("wallet reconciliation complete", "wallets", len(results), "mismatched", mismatched)
+	stopSweeper := make(chan struct{})
+	if cfg.PendingSweepInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.PendingSweepInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if n := store.ReleasePendingTransactions(time.Now()); n > 0 {
+						logger.Info("pending transactions released", "count", n)
+					}
+				case <-stopSweeper:
+					return
+				}
+			}
+		}()
+	}
+	// Expiry runs on its own ticker so PENDING_SWEEP_INTERVAL=0 cannot turn it off
+	go func() {
+		ticker := time.NewTicker(expirySweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// Orders past ORDER_TTL release their collateral (Core Principle 11)
+				if n := store.ExpireOrders(time.Now()); n > 0 {
+					logger.Info("orders expired", "count", n)
+				}
+				// Timed halts lapse on their own; this records it (Core Principle 4)
+				if n := store.ExpireHalts(time.Now()); n > 0 {
+					logger.Info("timed halts expired", "count", n)
+				}
+			case <-stopSweeper:
+				return
+			}
+		}
+	}()
+
+	// Risk classification lists (Core Principle 3)
+	kalshi.SetRiskClassifier(kalshi.DefaultRiskClassifier().WithOverrides(
+		cfg.RiskLowCategories, cfg.RiskLowSeries, cfg.RiskMediumCategories,
+	))
+
+	// Operator accounts for admin routes (Core Principle 17)
+	auth.SetAdminUserIDs(cfg.AdminUserIDs)
+
+	// Verified-only routes check the store, not the login-time claims
+	auth.SetStatusLookup(func(userID string) (models.UserStatus, bool) {
+		user, err := store.GetUser(userID)
+		if err != nil {
+			return "", false
+		}
+		return user.Status, true
 	})
-	log.Println("✓ Persistent data store initialized")
+
+	// Session cookies need a CSRF check; bearer-only mode ignores them
+	auth.SetCookieAuth(cfg.AuthMode == "cookie")
+	auth.SetIdleTimeout(cfg.SessionIdleTimeout)
 
 	// Kalshi API client for real market data (Core Principle 3)
 	kalshiClient := kalshi.NewClient(kalshiURL, 30*time.Second)
-	log.Println("✓ Kalshi API client initialized")
+	logger.Info("Kalshi API client initialized")
 
 	// Surveillance engine (Core Principles 4, 5)
-	surveillance := compliance.NewSurveillanceEngine(store)
-	log.Println("✓ Surveillance engine initialized")
+	surveillance := compliance.NewSurveillanceEngine(store, compliance.SurveillanceConfig{
+		MaxOrdersPerMinute:    cfg.RateLimitPerUser,
+		SuspiciousVolumeRatio: cfg.AnomalyThreshold,
+		MaxCancelFillRatio:    cfg.CancelFillRatio,
+		OrderRatioWindow:      cfg.OrderRatioWindow,
+	})
+	surveillance.SetBreachSuspension(cfg.PositionBreachLimit, cfg.PositionBreachWindow)
+	surveillance.SetMarketSource(kalshiClient)
+	surveillance.SetCircuitBreaker(cfg.CircuitBreakerPct, cfg.CircuitBreakerWindow, cfg.CircuitBreakerHalt)
+	store.SetRateLimiter(surveillance) // rate windows survive restarts (Core Principle 4)
+	// Post-trade analysis of fills and settlements (Core Principle 4)
+	go surveillance.AnalyzeFills(bus.Subscribe(events.All), compliance.DefaultFillAnalysisDebounce)
+	logger.Info("surveillance engine initialized")
+
+	// Settlement poller (Core Principle 11)
+	var settlementPoller *settlement.Poller
+	if cfg.SettlementPollInterval > 0 {
+		settlementPoller = settlement.NewPoller(store, kalshiClient, cfg.SettlementPollInterval)
+		settlementPoller.Start()
+		logger.Info("settlement poller started", "interval", cfg.SettlementPollInterval)
+	}
 
 	// WebSocket hub for real-time updates (Core Principle 9)
 	wsHub := ws.NewHub(kalshiClient)
+	wsHub.SetPriceObserver(surveillance)
+	go wsHub.ForwardEvents(bus.Subscribe(events.All))
 	go wsHub.Run()
-	log.Println("✓ WebSocket hub started")
+	logger.Info("WebSocket hub started")
 
 	// API handlers
 	handler := api.NewHandler(store, kalshiClient, surveillance)
+	handler.SetMaxBodyBytes(cfg.MaxBodyBytes)
+	handler.SetHandlerTimeout(cfg.HandlerTimeout)
+	handler.SetIdempotencyTTL(cfg.IdempotencyTTL)
+	handler.SetMarketStream(wsHub)
 
 	// Create router with all routes
 	router := api.NewRouter(handler)
@@ -90,12 +299,25 @@ func main() {
 	// Create a new mux for WebSocket + API routes
 	mainRouter := mux.NewRouter()
 	mainRouter.HandleFunc("/ws", wsHub.ServeWS)
+
+	// Prometheus metrics (optional)
+	if cfg.MetricsEnabled {
+		err := metrics.Register(prometheus.DefaultRegisterer, func() int {
+			return len(store.GetActiveHalts())
+		})
+		if err != nil {
+			logger.Error("metrics registration failed", "error", err)
+			os.Exit(1)
+		}
+		mainRouter.Handle("/metrics", metrics.Handler())
+		logger.Info("metrics enabled", "path", "/metrics")
+	}
 	mainRouter.PathPrefix("/").Handler(router)
 
 	// Configure HTTP server
 	srv := &http.Server{
 		Addr:         ":" + port,
-		Handler:      mainRouter,
+		Handler:      logging.Middleware(mainRouter),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -103,24 +325,11 @@ func main() {
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("✓ Server listening on http://localhost:%s", port)
-		log.Println("")
-		log.Println("API Endpoints:")
-		log.Println("  POST /api/v1/auth/signup     - Register new user")
-		log.Println("  POST /api/v1/auth/login      - Authenticate user")
-		log.Println("  GET  /api/v1/markets         - List Kalshi markets")
-		log.Println("  GET  /api/v1/markets/{ticker} - Get market details")
-		log.Println("  POST /api/v1/kyc             - Submit KYC verification")
-		log.Println("  POST /api/v1/wallet/deposit  - Mock deposit funds")
-		log.Println("  POST /api/v1/orders          - Place trading order")
-		log.Println("  GET  /api/v1/positions       - View open positions")
-		log.Println("  GET  /api/v1/portfolio       - Portfolio summary")
-		log.Println("  WS   /ws                     - Real-time market data")
-		log.Println("")
-		log.Println("Press Ctrl+C to stop")
+		logger.Info("server listening", "addr", "http://localhost:"+port, "api", "/api/v1", "ws", "/ws")
 
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			logger.Error("server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -129,22 +338,28 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("")
-	log.Println("Shutting down server...")
+	logger.Info("shutting down server")
+
+	if settlementPoller != nil {
+		settlementPoller.Stop()
+	}
+	close(stopSweeper)
+	bus.Close()
 
 	// Save data before shutdown (CP 18: Recordkeeping)
 	store.Stop()
-	log.Println("✓ Data persisted")
+	logger.Info("data persisted")
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		logger.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Server stopped gracefully")
+	logger.Info("server stopped gracefully")
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,41 @@
+package api
+
+import "time"
+
+// =============================================================================
+// MOCK FILL SIMULATION
+// Core Principle 9: Orders are filled by a pluggable strategy so the demo can
+// simulate matching latency while tests fill deterministically.
+// =============================================================================
+
+// FillStrategy runs the mock fill of a newly placed order.
+type FillStrategy interface {
+	ScheduleFill(fill func())
+}
+
+// defaultFillDelay simulates the matching delay of a real venue.
+const defaultFillDelay = 500 * time.Millisecond
+
+// DelayedFills runs each fill asynchronously after Delay. AfterFunc is the
+// clock; nil uses time.AfterFunc.
+type DelayedFills struct {
+	Delay     time.Duration
+	AfterFunc func(d time.Duration, f func()) *time.Timer
+}
+
+// ScheduleFill implements FillStrategy.
+func (d DelayedFills) ScheduleFill(fill func()) {
+	after := d.AfterFunc
+	if after == nil {
+		after = time.AfterFunc
+	}
+	after(d.Delay, fill)
+}
+
+// ImmediateFills runs each fill synchronously before PlaceOrder responds.
+type ImmediateFills struct{}
+
+// ScheduleFill implements FillStrategy.
+func (ImmediateFills) ScheduleFill(fill func()) {
+	fill()
+}
@@ -3,10 +3,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -15,8 +21,12 @@ import (
 	"github.com/kalshi-dcm-demo/backend/internal/auth"
 	"github.com/kalshi-dcm-demo/backend/internal/compliance"
 	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/logging"
+	"github.com/kalshi-dcm-demo/backend/internal/metrics"
 	"github.com/kalshi-dcm-demo/backend/internal/mock"
 	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/redact"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
 )
 
 // =============================================================================
@@ -24,43 +34,76 @@ import (
 // =============================================================================
 
 type Handler struct {
-	store       *mock.Store
-	kalshi      *kalshi.Client
+	store        *mock.Store
+	kalshi       *kalshi.Client
 	surveillance *compliance.SurveillanceEngine
+	maxBodyBytes int64
+	fills        FillStrategy
+	idempotency  *idempotencyCache
+	stream       MarketStream           // nil disables /stream/markets
+	timeout      time.Duration          // per-request deadline; see withDeadline
+	openAPI      map[string]interface{} // set by NewRouter
 }
 
+// defaultMaxBodyBytes caps request bodies unless SetMaxBodyBytes overrides it.
+const defaultMaxBodyBytes = 1 << 20
+
+// defaultHandlerTimeout bounds a request unless SetHandlerTimeout overrides
+// it. It is below the server's 15s write timeout so the client gets a 504
+// rather than a dropped connection.
+const defaultHandlerTimeout = 10 * time.Second
+
 func NewHandler(store *mock.Store, kalshiClient *kalshi.Client, surveillance *compliance.SurveillanceEngine) *Handler {
 	return &Handler{
-		store:       store,
-		kalshi:      kalshiClient,
+		store:        store,
+		kalshi:       kalshiClient,
 		surveillance: surveillance,
+		maxBodyBytes: defaultMaxBodyBytes,
+		timeout:      defaultHandlerTimeout,
+		fills:        DelayedFills{Delay: defaultFillDelay},
+		idempotency:  newIdempotencyCache(defaultIdempotencyTTL),
+	}
+}
+
+// SetMaxBodyBytes sets the largest request body accepted. Values <= 0 keep
+// the default.
+func (h *Handler) SetMaxBodyBytes(n int64) {
+	if n > 0 {
+		h.maxBodyBytes = n
+	}
+}
+
+// SetHandlerTimeout sets how long a request may run before its Kalshi calls
+// are abandoned. Values <= 0 keep the default.
+func (h *Handler) SetHandlerTimeout(d time.Duration) {
+	if d > 0 {
+		h.timeout = d
 	}
 }
 
+// kalshiFor returns the Kalshi client bound to the request's context.
+func (h *Handler) kalshiFor(r *http.Request) *kalshi.Client {
+	return h.kalshi.WithContext(r.Context())
+}
+
+// SetFillStrategy replaces how placed orders are mock-filled.
+func (h *Handler) SetFillStrategy(f FillStrategy) {
+	h.fills = f
+}
+
 // =============================================================================
 // RESPONSE HELPERS
 // =============================================================================
 
-type APIResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Code    string      `json:"code,omitempty"`
-	Meta    interface{} `json:"meta,omitempty"`
-}
+// APIResponse is the envelope shared with the auth middleware.
+type APIResponse = response.APIResponse
 
 func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(payload)
+	response.JSON(w, status, payload)
 }
 
-func respondError(w http.ResponseWriter, status int, message, code string) {
-	respondJSON(w, status, APIResponse{
-		Success: false,
-		Error:   message,
-		Code:    code,
-	})
+func respondError(w http.ResponseWriter, message string, code response.ErrorCode) {
+	response.Error(w, message, code)
 }
 
 func respondSuccess(w http.ResponseWriter, data interface{}, meta interface{}) {
@@ -71,20 +114,129 @@ func respondSuccess(w http.ResponseWriter, data interface{}, meta interface{}) {
 	})
 }
 
+// =============================================================================
+// REQUEST HELPERS
+// =============================================================================
+
+var errTrailingData = errors.New("unexpected data after JSON body")
+
+// decodeJSON decodes a single JSON object from the body into v, capped at
+// maxBodyBytes and rejecting unknown fields.
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// decodeError maps a decodeJSON error to a message and code.
+func decodeError(err error) (message string, code response.ErrorCode) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit), response.CodeRequestTooLarge
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return "Invalid request body: " + strings.TrimPrefix(err.Error(), "json: "), response.CodeInvalidRequest
+	}
+	return "Invalid request body", response.CodeInvalidRequest
+}
+
+func respondDecodeError(w http.ResponseWriter, err error) {
+	message, code := decodeError(err)
+	respondError(w, message, code)
+}
+
 // =============================================================================
 // HEALTH CHECK
 // =============================================================================
 
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	respondSuccess(w, map[string]interface{}{
-		"status":     "healthy",
-		"service":    "kalshi-dcm-demo",
-		"version":    "1.0.0",
-		"timestamp":  time.Now().UTC(),
-		"compliance": "CFTC Core Principles compliant",
+		"status":      "healthy",
+		"service":     "kalshi-dcm-demo",
+		"version":     "1.0.0",
+		"timestamp":   time.Now().UTC(),
+		"compliance":  "CFTC Core Principles compliant",
+		"global_halt": h.store.IsGlobalHalt(),
+	}, nil)
+}
+
+// readinessTimeout bounds each dependency probe so a hung upstream cannot
+// stall the readiness check.
+const readinessTimeout = 5 * time.Second
+
+// ReadinessCheck is the result of probing one dependency.
+type ReadinessCheck struct {
+	Status    string `json:"status"` // ok, down, disabled
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// ReadyCheck probes Kalshi and the persistence directory. Unlike
+// HealthCheck (liveness), it returns 503 when a dependency is down.
+func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]ReadinessCheck{
+		"kalshi": probe(func() error {
+			_, err := h.kalshiFor(r).GetSeries("", 1)
+			return err
+		}),
+	}
+	if h.store.PersistenceEnabled() {
+		checks["persistence"] = probe(h.store.CheckPersistence)
+	} else {
+		checks["persistence"] = ReadinessCheck{Status: "disabled"}
+	}
+
+	ready := true
+	for _, check := range checks {
+		if check.Status == "down" {
+			ready = false
+		}
+	}
+
+	if !ready {
+		logging.FromContext(r.Context()).Warn("readiness check failed", "checks", checks)
+		respondJSON(w, response.CodeNotReady.Status(), APIResponse{
+			Success: false,
+			Data:    map[string]interface{}{"status": "not_ready", "checks": checks},
+			Error:   "One or more dependencies are unavailable",
+			Code:    response.CodeNotReady.String(),
+		})
+		return
+	}
+
+	respondSuccess(w, map[string]interface{}{
+		"status": "ready",
+		"checks": checks,
 	}, nil)
 }
 
+// probe runs check with readinessTimeout and reports its outcome.
+func probe(check func() error) ReadinessCheck {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(readinessTimeout):
+		err = errors.New("timed out")
+	}
+
+	result := ReadinessCheck{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+	return result
+}
+
 // =============================================================================
 // AUTHENTICATION HANDLERS
 // Core Principle 17: Fitness Standards - User eligibility
@@ -104,21 +256,21 @@ type SignupRequest struct {
 // Core Principle 17: Initial eligibility check for US residency.
 func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 	var req SignupRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
 
 	// Validate required fields
 	if req.Email == "" || req.Password == "" {
-		respondError(w, http.StatusBadRequest, "Email and password required", "MISSING_FIELDS")
+		respondError(w, "Email and password required", response.CodeMissingFields)
 		return
 	}
 
 	// Core Principle 17: Check US residency requirement
 	if !req.IsUSResident {
-		respondError(w, http.StatusForbidden,
-			"Trading is only available to US residents", "US_RESIDENCY_REQUIRED")
+		respondError(w,
+			"Trading is only available to US residents", response.CodeUSResidencyRequired)
 		return
 	}
 
@@ -127,29 +279,29 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 		// Example: Some prediction markets have state restrictions
 	}
 	if restrictedStates[req.StateCode] {
-		respondError(w, http.StatusForbidden,
-			"Trading is not available in your state", "STATE_RESTRICTED")
+		respondError(w,
+			"Trading is not available in your state", response.CodeStateRestricted)
 		return
 	}
 
 	// Parse date of birth
 	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid date of birth format", "INVALID_DOB")
+		respondError(w, "Invalid date of birth format", response.CodeInvalidDOB)
 		return
 	}
 
 	// Check age (must be 18+)
 	age := time.Now().Year() - dob.Year()
 	if age < 18 {
-		respondError(w, http.StatusForbidden, "Must be 18 or older to trade", "AGE_RESTRICTED")
+		respondError(w, "Must be 18 or older to trade", response.CodeAgeRestricted)
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Registration failed", "INTERNAL_ERROR")
+		respondError(w, "Registration failed", response.CodeInternalError)
 		return
 	}
 
@@ -167,30 +319,33 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 		ip,
 	)
 	if err != nil {
-		if err == mock.ErrUserExists {
-			respondError(w, http.StatusConflict, "Email already registered", "USER_EXISTS")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "Registration failed", "INTERNAL_ERROR")
+		h.respondStoreError(w, r, err, "Registration failed", response.CodeInternalError)
 		return
 	}
 
 	// Create wallet (Core Principle 13: Segregated funds)
 	h.store.CreateWallet(user.ID, ip)
 
+	// Start KYC (Core Principle 17)
+	h.store.StartKYCRecord(user.ID, ip)
+
 	// Generate JWT
 	token, err := auth.GenerateToken(user.ID, user.Email, string(user.Status), false)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Token generation failed", "INTERNAL_ERROR")
+		respondError(w, "Token generation failed", response.CodeInternalError)
 		return
 	}
 
-	respondSuccess(w, map[string]interface{}{
-		"user":  user,
+	data := map[string]interface{}{
+		"user":  redact.NewUser(user),
 		"token": token,
 		"next_step": "kyc_required",
 		"message": "Account created. Please complete KYC verification to start trading.",
-	}, nil)
+	}
+	if !startSession(w, r, token, data) {
+		return
+	}
+	respondSuccess(w, data, nil)
 }
 
 type LoginRequest struct {
@@ -202,31 +357,31 @@ type LoginRequest struct {
 // Core Principle 18: Logs authentication events for audit trail.
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
 
 	user, err := h.store.GetUserByEmail(req.Email)
 	if err != nil {
 		// Don't reveal if email exists or not
-		respondError(w, http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
+		respondError(w, "Invalid credentials", response.CodeInvalidCredentials)
 		return
 	}
 
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		respondError(w, http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
+		respondError(w, "Invalid credentials", response.CodeInvalidCredentials)
 		return
 	}
 
 	// Check if suspended/banned (Core Principle 17)
 	if user.Status == models.UserStatusSuspended {
-		respondError(w, http.StatusForbidden, "Account suspended", "ACCOUNT_SUSPENDED")
+		respondError(w, "Account suspended", response.CodeAccountSuspended)
 		return
 	}
 	if user.Status == models.UserStatusBanned {
-		respondError(w, http.StatusForbidden, "Account banned", "ACCOUNT_BANNED")
+		respondError(w, "Account banned", response.CodeAccountBanned)
 		return
 	}
 
@@ -238,27 +393,97 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	verified := user.Status == models.UserStatusVerified
 	token, err := auth.GenerateToken(user.ID, user.Email, string(user.Status), verified)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Token generation failed", "INTERNAL_ERROR")
+		respondError(w, "Token generation failed", response.CodeInternalError)
 		return
 	}
 
-	respondSuccess(w, map[string]interface{}{
-		"user":  user,
+	data := map[string]interface{}{
+		"user":  redact.NewUser(user),
 		"token": token,
-	}, nil)
+	}
+	if !startSession(w, r, token, data) {
+		return
+	}
+	respondSuccess(w, data, nil)
+}
+
+// RefreshClaims reissues the caller's token with their current status, so
+// a client can pick up KYC approval without logging in again.
+// Core Principle 18: The reissue is audited with the old and new status.
+func (h *Handler) RefreshClaims(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	user, err := h.store.GetUser(claims.UserID)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to fetch user", response.CodeInternalError)
+		return
+	}
+
+	// A suspended or banned account gets no new token (Core Principle 17)
+	if user.Status == models.UserStatusSuspended {
+		respondError(w, "Account suspended", response.CodeAccountSuspended)
+		return
+	}
+	if user.Status == models.UserStatusBanned {
+		respondError(w, "Account banned", response.CodeAccountBanned)
+		return
+	}
+
+	if err := h.store.RecordTokenRefresh(user.ID, claims.Status, auth.GetClientIP(r)); err != nil {
+		h.respondStoreError(w, r, err, "Token refresh failed", response.CodeInternalError)
+		return
+	}
+
+	verified := user.Status == models.UserStatusVerified
+	token, err := auth.GenerateToken(user.ID, user.Email, string(user.Status), verified)
+	if err != nil {
+		respondError(w, "Token generation failed", response.CodeInternalError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"user":  redact.NewUser(user),
+		"token": token,
+	}
+	if !startSession(w, r, token, data) {
+		return
+	}
+	respondSuccess(w, data, nil)
+}
+
+// startSession sets session cookies when cookie auth is enabled and adds the
+// CSRF token to data, since a cross-origin frontend cannot read the cookie.
+// Returns false after responding with an error.
+func startSession(w http.ResponseWriter, r *http.Request, token string, data map[string]interface{}) bool {
+	if !auth.CookieAuthEnabled() {
+		return true
+	}
+	csrf, err := auth.SetSessionCookies(w, r, token)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("session cookie failed", "error", err)
+		respondError(w, "Session creation failed", response.CodeInternalError)
+		return false
+	}
+	data["csrf_token"] = csrf
+	return true
 }
 
-// GetProfile returns current user profile.
+// GetProfile returns current user profile, with personal data masked
+// (see redact.User); compliance sees it in full through AdminGetUser.
 func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	user, err := h.store.GetUser(claims.UserID)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+		h.respondStoreError(w, r, err, "Failed to fetch user", response.CodeInternalError)
 		return
 	}
 
@@ -266,7 +491,7 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	wallet, _ := h.store.GetWallet(claims.UserID)
 
 	respondSuccess(w, map[string]interface{}{
-		"user":   user,
+		"user":   redact.NewUser(user),
 		"kyc":    kyc,
 		"wallet": wallet,
 	}, nil)
@@ -288,13 +513,13 @@ type KYCSubmitRequest struct {
 func (h *Handler) SubmitKYC(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	var req KYCSubmitRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
 
@@ -304,7 +529,7 @@ func (h *Handler) SubmitKYC(w http.ResponseWriter, r *http.Request) {
 		"state_id":        true,
 	}
 	if !validDocTypes[req.DocumentType] {
-		respondError(w, http.StatusBadRequest, "Invalid document type", "INVALID_DOC_TYPE")
+		respondError(w, "Invalid document type", response.CodeInvalidDocType)
 		return
 	}
 
@@ -312,7 +537,7 @@ func (h *Handler) SubmitKYC(w http.ResponseWriter, r *http.Request) {
 
 	record, err := h.store.CreateKYCRecord(claims.UserID, req.DocumentType, req.DocumentNumber, ip)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "KYC submission failed", "INTERNAL_ERROR")
+		h.respondStoreError(w, r, err, "KYC submission failed", response.CodeInternalError)
 		return
 	}
 
@@ -333,22 +558,19 @@ func (h *Handler) SubmitKYC(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetKYCStatus(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	record, err := h.store.GetKYCRecord(claims.UserID)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "KYC record not found", "KYC_NOT_FOUND")
+		respondError(w, "KYC record not found", response.CodeKYCNotFound)
 		return
 	}
 
+	// Users created before signup started a record have none yet
 	if record == nil {
-		respondSuccess(w, map[string]interface{}{
-			"status": "not_started",
-			"message": "Please submit KYC documents to start verification.",
-		}, nil)
-		return
+		record = &models.KYCRecord{UserID: claims.UserID, Status: models.KYCStatusNotStarted}
 	}
 
 	respondSuccess(w, record, nil)
@@ -361,69 +583,87 @@ func (h *Handler) GetKYCStatus(w http.ResponseWriter, r *http.Request) {
 // =============================================================================
 
 type DepositRequest struct {
-	AmountUSD float64 `json:"amount_usd"`
+	AmountUSD models.Money         `json:"amount_usd"` // dollars, rounded to the cent
+	Method    models.DepositMethod `json:"method"`     // ach (default), wire or card
+	Reference string               `json:"reference"`  // Trace number, IMAD or authorization code
 	// In production: Would include ACH details, bank info, etc.
 }
 
+type WithdrawRequest struct {
+	AmountUSD models.Money `json:"amount_usd"`
+}
+
 // GetWallet returns user's wallet balance.
 // Core Principle 13: Shows segregated funds status.
 func (h *Handler) GetWallet(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	wallet, err := h.store.GetWallet(claims.UserID)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Wallet not found", "WALLET_NOT_FOUND")
+		h.respondStoreError(w, r, err, "Failed to fetch wallet", response.CodeInternalError)
 		return
 	}
 
 	respondSuccess(w, wallet, nil)
 }
 
-// Deposit adds funds to wallet (mock ACH).
+// Deposit adds funds to wallet by ACH (the default), wire or card, within
+// the method's limits. Mock ACH pulls are initiated here, so an ACH deposit
+// without a reference gets a generated trace number. Retries carrying the
+// same Idempotency-Key return the original result.
 // Core Principle 13: Funds segregation tracking.
 func (h *Handler) Deposit(w http.ResponseWriter, r *http.Request) {
+	h.idempotent(w, r, h.deposit)
+}
+
+func (h *Handler) deposit(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	var req DepositRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
 
 	if req.AmountUSD <= 0 {
-		respondError(w, http.StatusBadRequest, "Amount must be positive", "INVALID_AMOUNT")
+		respondError(w, "Amount must be positive", response.CodeInvalidAmount)
 		return
 	}
 
-	// Demo limits
-	if req.AmountUSD > 10000 {
-		respondError(w, http.StatusBadRequest, "Maximum deposit is $10,000", "AMOUNT_EXCEEDED")
-		return
+	method := req.Method
+	if method == "" {
+		method = models.DepositMethodACH
+	}
+	reference := strings.ToUpper(strings.TrimSpace(req.Reference))
+	if reference == "" && method == models.DepositMethodACH {
+		reference = fmt.Sprintf("%015d", time.Now().UnixNano()%1e15)
 	}
 
 	ip := auth.GetClientIP(r)
-	reference := "MOCK_ACH_" + time.Now().Format("20060102150405")
-
-	tx, err := h.store.Deposit(claims.UserID, req.AmountUSD, reference, ip)
+	tx, err := h.store.DepositVia(claims.UserID, method, req.AmountUSD, reference, ip)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Deposit failed", "DEPOSIT_FAILED")
+		h.respondStoreError(w, r, err, "Deposit failed", response.CodeDepositFailed)
 		return
 	}
 
 	wallet, _ := h.store.GetWallet(claims.UserID)
 
+	message := "Deposit completed successfully"
+	if tx.Status == models.TxStatusPending {
+		message = "Deposit pending until " + tx.AvailableAt.Format(time.RFC3339)
+	}
 	respondSuccess(w, map[string]interface{}{
 		"transaction": tx,
 		"wallet":      wallet,
-		"message":     "Deposit completed successfully",
+		"message":     message,
 	}, nil)
 }
 
@@ -432,7 +672,7 @@ func (h *Handler) Deposit(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
@@ -445,13 +685,176 @@ func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 
 	transactions, err := h.store.GetTransactions(claims.UserID, limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch transactions", "INTERNAL_ERROR")
+		h.respondStoreError(w, r, err, "Failed to fetch transactions", response.CodeInternalError)
 		return
 	}
 
 	respondSuccess(w, transactions, nil)
 }
 
+// Withdraw requests a withdrawal. Funds are held as pending until the hold
+// period ends. Retries carrying the same Idempotency-Key return the original
+// result.
+// Core Principle 13: Segregated funds leave only through the hold process.
+func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.idempotent(w, r, h.withdraw)
+}
+
+func (h *Handler) withdraw(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	var req WithdrawRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	ip := auth.GetClientIP(r)
+	reference := "MOCK_ACH_" + time.Now().Format("20060102150405")
+
+	tx, err := h.store.Withdraw(claims.UserID, req.AmountUSD, reference, ip)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Withdrawal failed", response.CodeWithdrawalFailed)
+		return
+	}
+
+	wallet, _ := h.store.GetWallet(claims.UserID)
+
+	message := "Withdrawal completed successfully"
+	if tx.Status == models.TxStatusPending {
+		message = "Withdrawal pending until " + tx.AvailableAt.Format(time.RFC3339)
+	}
+	respondSuccess(w, map[string]interface{}{
+		"transaction": tx,
+		"wallet":      wallet,
+		"message":     message,
+	}, nil)
+}
+
+// GetPendingTransactions returns the user's in-flight fund movements.
+// Core Principle 18: Recordkeeping.
+func (h *Handler) GetPendingTransactions(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	transactions, err := h.store.GetPendingTransactions(claims.UserID)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to fetch transactions", response.CodeInternalError)
+		return
+	}
+	wallet, _ := h.store.GetWallet(claims.UserID)
+
+	respondSuccess(w, transactions, map[string]interface{}{"pending_usd": wallet.PendingUSD})
+}
+
+// respondKalshiError maps a Kalshi client error to a status: 404 only when
+// Kalshi itself returned 404, 429 when rate limited, 503 when Kalshi is down
+// or unreachable, 504 when the request's deadline passed and 502 for any
+// other upstream failure.
+func respondKalshiError(w http.ResponseWriter, r *http.Request, err error, message, notFoundMessage string, notFoundCode response.ErrorCode) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		respondError(w, "Market data request timed out", response.CodeGatewayTimeout)
+	case errors.Is(err, kalshi.ErrKalshiNotFound):
+		respondError(w, notFoundMessage, notFoundCode)
+		return
+	case errors.Is(err, kalshi.ErrKalshiRateLimited):
+		respondError(w, "Market data temporarily rate limited", response.CodeKalshiRateLimited)
+	case errors.Is(err, kalshi.ErrKalshiUnavailable):
+		respondError(w, message, response.CodeKalshiUnavailable)
+	default:
+		respondError(w, message, response.CodeKalshiError)
+	}
+	logging.FromContext(r.Context()).Warn("kalshi request failed", "path", r.URL.Path, "error", err)
+}
+
+// storeErrors maps the mock store's sentinel errors to responses; the status
+// is the code's registered status. An empty message reports the error's own
+// text, for errors wrapped with detail.
+// ErrTradingHalted is handled by storeError, which adds the halt reason.
+var storeErrors = []struct {
+	err     error
+	message string
+	code    response.ErrorCode
+}{
+	{mock.ErrUserNotFound, "User not found", response.CodeUserNotFound},
+	{mock.ErrUserExists, "Email already registered", response.CodeUserExists},
+	{mock.ErrWalletNotFound, "Wallet not found", response.CodeWalletNotFound},
+	{mock.ErrInsufficientFunds, "Insufficient funds", response.CodeInsufficientFunds},
+	{mock.ErrOrderNotFound, "Order not found", response.CodeOrderNotFound},
+	{mock.ErrPositionNotFound, "Open position not found", response.CodePositionNotFound},
+	{mock.ErrKYCRequired, "KYC verification required", response.CodeKYCRequired},
+	{mock.ErrUserSuspended, "Account suspended", response.CodeAccountSuspended},
+	{mock.ErrMarketClosed, "Market is closed", response.CodeMarketClosed},
+	{mock.ErrPositionLimitExceeded, "Position limit exceeded", response.CodePositionLimit},
+	{mock.ErrMarketAlreadySettled, "Market already settled", response.CodeMarketSettled},
+	{mock.ErrInvalidResult, "Invalid settlement result", response.CodeInvalidRequest},
+	{mock.ErrOrderNotFillable, "Order is not open for fills", response.CodeOrderNotFillable},
+	{mock.ErrInvalidFillQuantity, "Fill quantity exceeds unfilled quantity", response.CodeInvalidQuantity},
+	{mock.ErrOrderNotAmendable, "Only open or pending orders can be amended", response.CodeOrderNotAmendable},
+	{mock.ErrInvalidAmend, "Invalid amend price or quantity", response.CodeInvalidOrder},
+	{mock.ErrSelfTradePrevented, "Order would trade against your own resting order", response.CodeSelfTradePrevented},
+	{mock.ErrTooManyOpenOrders, "Open order limit reached", response.CodeTooManyOpenOrders},
+	{mock.ErrOrderNotCancellable, "Only resting orders can be cancelled", response.CodeOrderNotCancellable},
+	{mock.ErrInvalidAmount, "Amount must be positive", response.CodeInvalidAmount},
+	{mock.ErrTransactionNotFound, "Transaction not found", response.CodeTransactionNotFound},
+	{mock.ErrKYCAlreadySubmitted, "KYC already submitted", response.CodeKYCAlreadySubmitted},
+	{mock.ErrNotReversible, "Transaction cannot be reversed", response.CodeNotReversible},
+	{mock.ErrAlertNotFound, "Alert not found", response.CodeAlertNotFound},
+	{mock.ErrInvalidAlertStatus, "Invalid alert status transition", response.CodeInvalidAlertStatus},
+	{mock.ErrAlertClosed, "Resolved and escalated alerts cannot be assigned", response.CodeAlertClosed},
+	{mock.ErrInvalidLimits, "", response.CodeInvalidLimits},
+	{mock.ErrNothingToReduce, "Reduce-only order exceeds the opposite-side position", response.CodeNothingToReduce},
+	{mock.ErrInvalidClose, "Invalid close quantity", response.CodeInvalidQuantity},
+	{mock.ErrDepositMethod, "", response.CodeInvalidDepositMethod},
+	{mock.ErrDepositBelowMinimum, "", response.CodeAmountBelowMinimum},
+	{mock.ErrDepositAboveMaximum, "", response.CodeAmountExceeded},
+	{mock.ErrInvalidReference, "", response.CodeInvalidReference},
+	{mock.ErrDuplicateReference, "", response.CodeDuplicateReference},
+	{mock.ErrInvalidStatusChange, "", response.CodeInvalidStatusChange},
+	{mock.ErrKYCNotApproved, "", response.CodeInvalidStatusChange},
+}
+
+// storeError maps a mock store error to a message and code. Any other
+// error is reported as failure with failureCode, a 500 code.
+func (h *Handler) storeError(err error, failure string, failureCode response.ErrorCode) (message string, code response.ErrorCode) {
+	if errors.Is(err, mock.ErrTradingHalted) {
+		return h.haltMessage(), response.CodeTradingHalted
+	}
+	for _, e := range storeErrors {
+		if errors.Is(err, e.err) {
+			if e.message == "" {
+				return err.Error(), e.code
+			}
+			return e.message, e.code
+		}
+	}
+	return failure, failureCode
+}
+
+// respondStoreError responds to a mock store error via storeError, logging
+// the errors it does not recognize.
+func (h *Handler) respondStoreError(w http.ResponseWriter, r *http.Request, err error, failure string, failureCode response.ErrorCode) {
+	message, code := h.storeError(err, failure, failureCode)
+	if code.Status() == http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("store operation failed", "path", r.URL.Path, "error", err)
+	}
+	respondError(w, message, code)
+}
+
+// hasMore reports whether another page is worth requesting: Kalshi returned
+// a cursor and filled the page. A short page or empty cursor is the end.
+func hasMore(cursor string, count, limit int) bool {
+	return cursor != "" && count >= limit
+}
+
 // =============================================================================
 // MARKET HANDLERS (Real Kalshi API)
 // Core Principle 3: Contracts not readily susceptible to manipulation
@@ -460,11 +863,24 @@ func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 // GetMarkets fetches live markets from Kalshi.
 // Core Principle 3: Focus on economic binaries (low manipulation risk).
 func (h *Handler) GetMarkets(w http.ResponseWriter, r *http.Request) {
-	params := kalshi.MarketParams{
+	h.respondMarkets(w, r, kalshi.MarketParams{
 		Status:       r.URL.Query().Get("status"),
 		SeriesTicker: r.URL.Query().Get("series_ticker"),
 		EventTicker:  r.URL.Query().Get("event_ticker"),
 		Cursor:       r.URL.Query().Get("cursor"),
+		Category:     r.URL.Query().Get("category"),
+	})
+}
+
+// respondMarkets fetches a page of markets for params, classifies their risk
+// and applies the q, risk_category and limit query parameters.
+func (h *Handler) respondMarkets(w http.ResponseWriter, r *http.Request, params kalshi.MarketParams) {
+	// Server-side filters (CP 3: surface low manipulation risk markets)
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	riskCategory := strings.ToLower(r.URL.Query().Get("risk_category"))
+	if riskCategory != "" && !validRiskCategories[riskCategory] {
+		respondError(w, "risk_category must be low, medium or high", response.CodeInvalidRiskCategory)
+		return
 	}
 
 	if limit := r.URL.Query().Get("limit"); limit != "" {
@@ -476,44 +892,176 @@ func (h *Handler) GetMarkets(w http.ResponseWriter, r *http.Request) {
 		params.Limit = 20
 	}
 
-	response, err := h.kalshi.GetMarkets(params)
+	resp, err := h.kalshiFor(r).GetMarkets(params)
 	if err != nil {
-		respondError(w, http.StatusServiceUnavailable, "Failed to fetch markets", "KALSHI_ERROR")
+		respondKalshiError(w, r, err, "Failed to fetch markets", "Markets not found", response.CodeNotFound)
 		return
 	}
 
 	// Convert to internal models with risk classification
-	var markets []models.KalshiMarket
-	for _, m := range response.Markets {
+	markets := make([]models.KalshiMarket, 0, len(resp.Markets))
+	for _, m := range resp.Markets {
 		markets = append(markets, m.ToMarket())
 	}
+	markets = filterMarkets(markets, query, riskCategory)
 
+	// has_more reflects the upstream page; filters may leave a page short
 	respondSuccess(w, markets, map[string]interface{}{
-		"cursor":   response.Cursor,
-		"exchange": "kalshi",
+		"cursor":        resp.Cursor,
+		"has_more":      hasMore(resp.Cursor, len(resp.Markets), params.Limit),
+		"count":         len(markets),
+		"scanned_count": len(resp.Markets),
+		"exchange":      "kalshi",
 	})
 }
 
+var validRiskCategories = map[string]bool{"low": true, "medium": true, "high": true}
+
+// filterMarkets keeps markets whose title contains query (case-insensitive)
+// and whose risk classification matches riskCategory. Empty filters match all.
+func filterMarkets(markets []models.KalshiMarket, query, riskCategory string) []models.KalshiMarket {
+	if query == "" && riskCategory == "" {
+		return markets
+	}
+	query = strings.ToLower(query)
+	filtered := make([]models.KalshiMarket, 0, len(markets))
+	for _, m := range markets {
+		if query != "" && !strings.Contains(strings.ToLower(m.Title), query) {
+			continue
+		}
+		if riskCategory != "" && m.RiskCategory != riskCategory {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered
+}
+
 // GetMarket fetches a single market.
 func (h *Handler) GetMarket(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	ticker := vars["ticker"]
 
 	if ticker == "" {
-		respondError(w, http.StatusBadRequest, "Market ticker required", "MISSING_TICKER")
+		respondError(w, "Market ticker required", response.CodeMissingTicker)
 		return
 	}
 
-	market, err := h.kalshi.GetMarket(ticker)
+	market, err := h.kalshiFor(r).GetMarket(ticker)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Market not found", "MARKET_NOT_FOUND")
+		respondKalshiError(w, r, err, "Failed to fetch market", "Market not found", response.CodeMarketNotFound)
 		return
 	}
 
 	respondSuccess(w, market.ToMarket(), nil)
 }
 
-// GetOrderbook fetches market orderbook.
+// GetMarketRisk returns a market's manipulation risk classification and the
+// rule that produced it.
+// Core Principle 3: Contracts not readily susceptible to manipulation.
+func (h *Handler) GetMarketRisk(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+
+	market, err := h.kalshiFor(r).GetMarket(ticker)
+	if err != nil {
+		respondKalshiError(w, r, err, "Failed to fetch market", "Market not found", response.CodeMarketNotFound)
+		return
+	}
+
+	assessment := kalshi.ClassifyRisk(market.Category, market.SeriesTicker)
+	respondSuccess(w, map[string]interface{}{
+		"ticker":        market.Ticker,
+		"category":      market.Category,
+		"series_ticker": market.SeriesTicker,
+		"risk_category": assessment.Category,
+		"reason":        assessment.Reason,
+	}, nil)
+}
+
+// Orderbook liquidity states.
+const (
+	LiquidityNone     = "none"
+	LiquidityOneSided = "one_sided"
+	LiquidityTwoSided = "two_sided"
+)
+
+// OrderbookView is a market's book as served to clients. Each side lists
+// bids best first and is always an array, so an empty book is explicit
+// rather than null. Liquidity says which sides have resting bids.
+type OrderbookView struct {
+	Ticker    string                  `json:"ticker"`
+	YesBids   []kalshi.OrderbookLevel `json:"yes"`
+	NoBids    []kalshi.OrderbookLevel `json:"no"`
+	Liquidity string                  `json:"liquidity"`
+	Summary   OrderbookSummary        `json:"summary"`
+}
+
+// OrderbookSummary is the top of book derived from the returned levels. A
+// YES bid at p is a NO ask at 100-p and vice versa. Prices are in cents
+// and null when the side they come from is empty.
+type OrderbookSummary struct {
+	BestYesBid  *int `json:"best_yes_bid"`
+	BestYesAsk  *int `json:"best_yes_ask"` // 100 - best NO bid
+	BestNoBid   *int `json:"best_no_bid"`
+	BestNoAsk   *int `json:"best_no_ask"` // 100 - best YES bid
+	SpreadCents *int `json:"spread_cents"`
+	YesDepth    int  `json:"yes_depth"` // Contracts across the YES levels
+	NoDepth     int  `json:"no_depth"`
+}
+
+// newOrderbookView sorts each side best first, keeps at most depth levels
+// (all when depth <= 0) and derives the summary.
+func newOrderbookView(ticker string, book *kalshi.OrderbookResponse, depth int) OrderbookView {
+	view := OrderbookView{
+		Ticker:  ticker,
+		YesBids: bestLevels(book.Orderbook.YesBids, depth),
+		NoBids:  bestLevels(book.Orderbook.NoBids, depth),
+	}
+	summary := &view.Summary
+	if len(view.YesBids) > 0 {
+		bid, noAsk := view.YesBids[0].Price, 100-view.YesBids[0].Price
+		summary.BestYesBid, summary.BestNoAsk = &bid, &noAsk
+	}
+	if len(view.NoBids) > 0 {
+		bid, yesAsk := view.NoBids[0].Price, 100-view.NoBids[0].Price
+		summary.BestNoBid, summary.BestYesAsk = &bid, &yesAsk
+	}
+	if summary.BestYesBid != nil && summary.BestYesAsk != nil {
+		spread := *summary.BestYesAsk - *summary.BestYesBid
+		summary.SpreadCents = &spread
+	}
+	for _, level := range view.YesBids {
+		summary.YesDepth += level.Quantity
+	}
+	for _, level := range view.NoBids {
+		summary.NoDepth += level.Quantity
+	}
+
+	switch {
+	case len(view.YesBids) == 0 && len(view.NoBids) == 0:
+		view.Liquidity = LiquidityNone
+	case len(view.YesBids) == 0 || len(view.NoBids) == 0:
+		view.Liquidity = LiquidityOneSided
+	default:
+		view.Liquidity = LiquidityTwoSided
+	}
+	return view
+}
+
+// bestLevels returns a copy of levels sorted by price, highest first,
+// truncated to depth when depth > 0. It never returns nil.
+func bestLevels(levels []kalshi.OrderbookLevel, depth int) []kalshi.OrderbookLevel {
+	sorted := append([]kalshi.OrderbookLevel{}, levels...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Price > sorted[j].Price })
+	if depth > 0 && len(sorted) > depth {
+		sorted = sorted[:depth]
+	}
+	return sorted
+}
+
+// GetOrderbook fetches market orderbook. A market with no resting orders
+// is a 200 with empty sides and liquidity "none"; errors are reserved for
+// Kalshi failures (503 when it is down or unreachable).
 // Core Principle 9: Transparency in execution.
 func (h *Handler) GetOrderbook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -526,125 +1074,504 @@ func (h *Handler) GetOrderbook(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	orderbook, err := h.kalshi.GetOrderbook(ticker, depth)
+	orderbook, err := h.kalshiFor(r).GetOrderbook(ticker, depth)
 	if err != nil {
-		respondError(w, http.StatusServiceUnavailable, "Failed to fetch orderbook", "KALSHI_ERROR")
+		respondKalshiError(w, r, err, "Failed to fetch orderbook", "Market not found", response.CodeMarketNotFound)
 		return
 	}
 
-	respondSuccess(w, orderbook, nil)
+	respondSuccess(w, newOrderbookView(ticker, orderbook, depth), nil)
 }
 
-// GetEvents fetches Kalshi events.
-func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
-	status := r.URL.Query().Get("status")
-	limit := 20
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
-		}
-	}
-	cursor := r.URL.Query().Get("cursor")
-
-	response, err := h.kalshi.GetEvents(status, limit, cursor)
-	if err != nil {
-		respondError(w, http.StatusServiceUnavailable, "Failed to fetch events", "KALSHI_ERROR")
-		return
-	}
-
-	respondSuccess(w, response.Events, map[string]interface{}{
-		"cursor": response.Cursor,
-	})
-}
+// maxTradesPage caps ?limit= on the trades tape at Kalshi's own maximum.
+const maxTradesPage = 1000
 
-// GetSeries fetches Kalshi series.
-func (h *Handler) GetSeries(w http.ResponseWriter, r *http.Request) {
+// GetMarketTrades returns a market's recent trades, newest first, paged by
+// cursor. Live trades stream on the trades:{ticker} WebSocket channel.
+// Core Principle 9: Transparency in execution.
+func (h *Handler) GetMarketTrades(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
 	cursor := r.URL.Query().Get("cursor")
-	limit := 50
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
-		}
-	}
+	limit := min(parseLimit(r, 50), maxTradesPage)
 
-	response, err := h.kalshi.GetSeries(cursor, limit)
+	resp, err := h.kalshiFor(r).GetMarketTrades(ticker, limit, cursor)
 	if err != nil {
-		respondError(w, http.StatusServiceUnavailable, "Failed to fetch series", "KALSHI_ERROR")
+		respondKalshiError(w, r, err, "Failed to fetch trades", "Market not found", response.CodeMarketNotFound)
 		return
 	}
 
-	respondSuccess(w, response.Series, map[string]interface{}{
-		"cursor": response.Cursor,
+	respondSuccess(w, resp.Trades, map[string]interface{}{
+		"cursor":   resp.Cursor,
+		"has_more": hasMore(resp.Cursor, len(resp.Trades), limit),
 	})
 }
 
-// =============================================================================
-// TRADING HANDLERS (Mock)
-// Core Principle 9: Execution of Transactions
-// Core Principle 11: Financial Integrity
-// =============================================================================
+const (
+	snapshotCallTimeout = 3 * time.Second
+	snapshotDepth       = 10
+	snapshotTrades      = 20
+)
 
-type PlaceOrderRequest struct {
-	MarketTicker string `json:"market_ticker"`
-	Side         string `json:"side"`       // yes, no
-	Type         string `json:"type"`       // limit, market
-	Quantity     int    `json:"quantity"`   // Number of contracts
-	PriceCents   int    `json:"price_cents"` // 1-99
+var errSnapshotTimeout = errors.New("timed out")
+
+// MarketSnapshot combines a market, its risk classification, its orderbook
+// and recent trades. When some of the fetches fail, Partial is set and
+// Failed names them; their fields are null. Risk needs the market.
+type MarketSnapshot struct {
+	Ticker    string                 `json:"ticker"`
+	Market    *models.KalshiMarket   `json:"market"`
+	Risk      *kalshi.RiskAssessment `json:"risk"`
+	Orderbook *OrderbookView         `json:"orderbook"`
+	Trades    []kalshi.Trade         `json:"trades"`
+	Partial   bool                   `json:"partial"`
+	Failed    []string               `json:"failed,omitempty"` // market, orderbook, trades
 }
 
-// PreTradeCheck validates an order before placement.
-// Core Principle 11: Ensures 100% collateralization.
-// Core Principle 5: Checks position limits.
-func (h *Handler) PreTradeCheck(w http.ResponseWriter, r *http.Request) {
-	claims := auth.GetUserFromContext(r.Context())
-	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+// GetMarketSnapshot fetches a market, its orderbook and recent trades from
+// Kalshi concurrently, each with its own timeout, and returns what
+// succeeded. It fails only when the market is unknown or every fetch fails.
+// Core Principle 9: Transparency in execution.
+func (h *Handler) GetMarketSnapshot(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+
+	var (
+		wg                            sync.WaitGroup
+		market                        *kalshi.KalshiMarketResponse
+		book                          *kalshi.OrderbookResponse
+		trades                        *kalshi.TradesResponse
+		marketErr, bookErr, tradesErr error
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		market, marketErr = withTimeout(snapshotCallTimeout, func() (*kalshi.KalshiMarketResponse, error) {
+			return h.kalshiFor(r).GetMarket(ticker)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		book, bookErr = withTimeout(snapshotCallTimeout, func() (*kalshi.OrderbookResponse, error) {
+			return h.kalshiFor(r).GetOrderbook(ticker, snapshotDepth)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		trades, tradesErr = withTimeout(snapshotCallTimeout, func() (*kalshi.TradesResponse, error) {
+			return h.kalshiFor(r).GetMarketTrades(ticker, snapshotTrades, "")
+		})
+	}()
+	wg.Wait()
+
+	if errors.Is(marketErr, kalshi.ErrKalshiNotFound) || (marketErr != nil && bookErr != nil && tradesErr != nil) {
+		respondKalshiError(w, r, marketErr, "Failed to fetch market", "Market not found", response.CodeMarketNotFound)
 		return
 	}
 
-	var req PlaceOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
-		return
+	snapshot := MarketSnapshot{Ticker: ticker}
+	log := logging.FromContext(r.Context())
+	fail := func(part string, err error) {
+		snapshot.Partial = true
+		snapshot.Failed = append(snapshot.Failed, part)
+		log.Warn("market snapshot fetch failed", "ticker", ticker, "part", part, "error", err)
+	}
+	if marketErr != nil {
+		fail("market", marketErr)
+	} else {
+		m := market.ToMarket()
+		risk := kalshi.ClassifyRisk(market.Category, market.SeriesTicker)
+		snapshot.Market, snapshot.Risk = &m, &risk
+	}
+	if bookErr != nil {
+		fail("orderbook", bookErr)
+	} else {
+		view := newOrderbookView(ticker, book, snapshotDepth)
+		snapshot.Orderbook = &view
+	}
+	if tradesErr != nil {
+		fail("trades", tradesErr)
+	} else {
+		snapshot.Trades = trades.Trades
+		if snapshot.Trades == nil {
+			snapshot.Trades = []kalshi.Trade{}
+		}
 	}
 
-	side := models.OrderSide(req.Side)
+	respondSuccess(w, snapshot, nil)
+}
+
+// withTimeout runs fn and waits at most timeout for it. A call that times
+// out keeps running in the background and its result is discarded.
+func withTimeout[T any](timeout time.Duration, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-timer.C:
+		var zero T
+		return zero, errSnapshotTimeout
+	}
+}
+
+// historyIntervals are the candle lengths accepted by GetMarketHistory.
+var historyIntervals = map[string]time.Duration{"1m": time.Minute, "1h": time.Hour, "1d": 24 * time.Hour}
+
+const (
+	defaultHistoryCandles = 500
+	maxHistoryCandles     = 5000 // Kalshi's limit per request
+)
+
+// GetMarketHistory returns a market's price candles for charting. interval
+// is 1m, 1h (default) or 1d; start and end are RFC3339 or Unix seconds.
+// end defaults to now and start to 500 candles before end.
+func (h *Handler) GetMarketHistory(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+	query := r.URL.Query()
+
+	intervalName := query.Get("interval")
+	if intervalName == "" {
+		intervalName = "1h"
+	}
+	interval, ok := historyIntervals[intervalName]
+	if !ok {
+		respondError(w, "interval must be 1m, 1h or 1d", response.CodeInvalidInterval)
+		return
+	}
+
+	// Align the default end so repeated requests share a cache entry
+	end := time.Now().UTC().Truncate(interval).Add(interval)
+	if v := query.Get("end"); v != "" {
+		parsed, ok := parseHistoryTime(v)
+		if !ok {
+			respondError(w, "end must be RFC3339 or Unix seconds", response.CodeInvalidRequest)
+			return
+		}
+		end = parsed
+	}
+	start := end.Add(-defaultHistoryCandles * interval)
+	if v := query.Get("start"); v != "" {
+		parsed, ok := parseHistoryTime(v)
+		if !ok {
+			respondError(w, "start must be RFC3339 or Unix seconds", response.CodeInvalidRequest)
+			return
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		respondError(w, "start must be before end", response.CodeInvalidRequest)
+		return
+	}
+	if end.Sub(start) > maxHistoryCandles*interval {
+		respondError(w, fmt.Sprintf("At most %d candles per request", maxHistoryCandles), response.CodeInvalidRequest)
+		return
+	}
+
+	history, err := h.kalshiFor(r).GetMarketHistory(ticker, interval, start, end)
+	if err != nil {
+		respondKalshiError(w, r, err, "Failed to fetch price history", "Market not found", response.CodeMarketNotFound)
+		return
+	}
+	respondSuccess(w, history, map[string]interface{}{"count": len(history.Candles)})
+}
+
+// parseHistoryTime accepts RFC3339 or Unix seconds.
+func parseHistoryTime(v string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), true
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// GetEvents fetches Kalshi events.
+func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	cursor := r.URL.Query().Get("cursor")
+
+	resp, err := h.kalshiFor(r).GetEvents(status, limit, cursor)
+	if err != nil {
+		respondKalshiError(w, r, err, "Failed to fetch events", "Events not found", response.CodeNotFound)
+		return
+	}
+
+	respondSuccess(w, resp.Events, map[string]interface{}{
+		"cursor":   resp.Cursor,
+		"has_more": hasMore(resp.Cursor, len(resp.Events), limit),
+	})
+}
+
+// GetSeries fetches Kalshi series.
+func (h *Handler) GetSeries(w http.ResponseWriter, r *http.Request) {
+	cursor := r.URL.Query().Get("cursor")
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	resp, err := h.kalshiFor(r).GetSeries(cursor, limit)
+	if err != nil {
+		respondKalshiError(w, r, err, "Failed to fetch series", "Series not found", response.CodeNotFound)
+		return
+	}
+
+	respondSuccess(w, resp.Series, map[string]interface{}{
+		"cursor":   resp.Cursor,
+		"has_more": hasMore(resp.Cursor, len(resp.Series), limit),
+	})
+}
+
+// SeriesDetail is a Kalshi series with its manipulation risk classification.
+// Core Principle 3: Contracts not readily susceptible to manipulation.
+type SeriesDetail struct {
+	kalshi.SeriesItem
+	RiskCategory string `json:"risk_category"`
+	RiskReason   string `json:"risk_reason"`
+}
+
+// GetSeriesDetail fetches one Kalshi series.
+func (h *Handler) GetSeriesDetail(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+
+	series, err := h.kalshiFor(r).GetSeriesByTicker(ticker)
+	if err != nil {
+		respondKalshiError(w, r, err, "Failed to fetch series", "Series not found", response.CodeNotFound)
+		return
+	}
+
+	assessment := kalshi.ClassifyRisk(series.Category, series.SeriesTicker)
+	respondSuccess(w, SeriesDetail{
+		SeriesItem:   *series,
+		RiskCategory: assessment.Category,
+		RiskReason:   assessment.Reason,
+	}, nil)
+}
+
+// GetSeriesMarkets lists the markets in one series, with the same status,
+// cursor, limit, q and risk_category parameters as GetMarkets.
+// Core Principle 3: Each market carries its risk classification.
+func (h *Handler) GetSeriesMarkets(w http.ResponseWriter, r *http.Request) {
+	h.respondMarkets(w, r, kalshi.MarketParams{
+		Status:       r.URL.Query().Get("status"),
+		SeriesTicker: mux.Vars(r)["ticker"],
+		Cursor:       r.URL.Query().Get("cursor"),
+	})
+}
+
+// =============================================================================
+// TRADING HANDLERS (Mock)
+// Core Principle 9: Execution of Transactions
+// Core Principle 11: Financial Integrity
+// =============================================================================
+
+type PlaceOrderRequest struct {
+	MarketTicker string `json:"market_ticker"`
+	Side         string `json:"side"`        // yes, no
+	Type         string `json:"type"`        // limit, market
+	Quantity     int    `json:"quantity"`    // Number of contracts
+	PriceCents   int    `json:"price_cents"` // 1-99
+	ReduceOnly   bool   `json:"reduce_only"` // Only offset an existing opposite-side position
+}
+
+// FieldError describes one invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    response.ErrorCode `json:"code"`
+	Message string             `json:"message"`
+}
+
+// ValidationErrors collects every invalid field in a request.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the order fields shared by PreTradeCheck and PlaceOrder,
+// returning ValidationErrors listing every violation.
+func (r PlaceOrderRequest) Validate() error {
+	var errs ValidationErrors
+	if r.MarketTicker == "" {
+		errs = append(errs, FieldError{"market_ticker", response.CodeMissingTicker, "Market ticker required"})
+	}
+	if r.Side != "yes" && r.Side != "no" {
+		errs = append(errs, FieldError{"side", response.CodeInvalidSide, "Side must be 'yes' or 'no'"})
+	}
+	if r.Type != "" && r.Type != "limit" && r.Type != "market" {
+		errs = append(errs, FieldError{"type", response.CodeInvalidType, "Type must be 'limit' or 'market'"})
+	}
+	if r.Quantity <= 0 || r.Quantity > 1000 {
+		errs = append(errs, FieldError{"quantity", response.CodeInvalidQuantity, "Quantity must be 1-1000"})
+	}
+	if r.PriceCents < 1 || r.PriceCents > 99 {
+		errs = append(errs, FieldError{"price_cents", response.CodeInvalidPrice, "Price must be 1-99 cents"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validationCode is the top-level code for a Validate error. A single
+// violation keeps its own code; several report INVALID_ORDER.
+func validationCode(errs ValidationErrors) response.ErrorCode {
+	if len(errs) == 1 {
+		return errs[0].Code
+	}
+	return response.CodeInvalidOrder
+}
+
+// respondValidationError responds with every field error in meta.field_errors.
+func respondValidationError(w http.ResponseWriter, errs ValidationErrors) {
+	response.ErrorWithMeta(w, errs.Error(), validationCode(errs),
+		map[string]interface{}{"field_errors": errs})
+}
+
+// PreTradeCheck validates an order before placement.
+// Core Principle 11: Ensures 100% collateralization.
+// Core Principle 5: Checks position limits.
+func (h *Handler) PreTradeCheck(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	var req PlaceOrderRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, err.(ValidationErrors))
+		return
+	}
+
+	side := models.OrderSide(req.Side)
 	check := h.surveillance.ValidateOrder(claims.UserID, req.MarketTicker, side, req.Quantity, req.PriceCents)
 
 	respondSuccess(w, check, nil)
 }
 
-// PlaceOrder submits a trading order (mock).
-// Core Principle 9: Fair and equitable execution.
-// Core Principle 11: Pre-trade margin check.
-func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+// WhatIfResult projects a user's portfolio if an order were filled in full.
+type WhatIfResult struct {
+	Check                *compliance.PreTradeCheck `json:"check"`
+	FeeUSD               models.Money              `json:"fee_usd"`
+	CurrentExposureUSD   models.Money              `json:"current_exposure_usd"`
+	ProjectedExposureUSD models.Money              `json:"projected_exposure_usd"`
+	PositionLimitUSD     models.Money              `json:"position_limit_usd"`
+	Utilization          float64                   `json:"utilization"` // Percent of the limit in use after the fill
+	RemainingLimitUSD    models.Money              `json:"remaining_limit_usd"`
+	AvailableAfterUSD    models.Money              `json:"available_after_usd"`
+}
+
+// PortfolioWhatIf previews an order without placing it: the pre-trade
+// check plus the exposure, limit utilization and available balance the
+// user would have once it filled. Offsetting contracts are netted as in
+// live exposure. Nothing is changed.
+// Core Principles 5, 11: Traders see limit and margin impact up front.
+func (h *Handler) PortfolioWhatIf(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	var req PlaceOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
 		return
 	}
-
-	// Validate inputs
-	if req.MarketTicker == "" {
-		respondError(w, http.StatusBadRequest, "Market ticker required", "MISSING_TICKER")
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, err.(ValidationErrors))
 		return
 	}
-	if req.Side != "yes" && req.Side != "no" {
-		respondError(w, http.StatusBadRequest, "Side must be 'yes' or 'no'", "INVALID_SIDE")
+	user, err := h.store.GetUser(claims.UserID)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to fetch user", response.CodeInternalError)
 		return
 	}
-	if req.Quantity <= 0 || req.Quantity > 1000 {
-		respondError(w, http.StatusBadRequest, "Quantity must be 1-1000", "INVALID_QUANTITY")
+	wallet, err := h.store.GetWallet(claims.UserID)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to fetch wallet", response.CodeInternalError)
 		return
 	}
-	if req.PriceCents < 1 || req.PriceCents > 99 {
-		respondError(w, http.StatusBadRequest, "Price must be 1-99 cents", "INVALID_PRICE")
+
+	side := models.OrderSide(req.Side)
+	check := h.surveillance.ValidateOrder(claims.UserID, req.MarketTicker, side, req.Quantity, req.PriceCents)
+	fee, projected := h.store.ProjectFill(claims.UserID, req.MarketTicker, side, req.Quantity, check.RequiredMargin)
+
+	limit := user.PositionLimitUSD
+	result := WhatIfResult{
+		Check:                check,
+		FeeUSD:               fee,
+		CurrentExposureUSD:   h.store.GetUserExposure(claims.UserID),
+		ProjectedExposureUSD: projected,
+		PositionLimitUSD:     limit,
+		RemainingLimitUSD:    max(limit-projected, 0),
+		AvailableAfterUSD:    wallet.AvailableUSD - check.RequiredMargin - fee,
+	}
+	if limit > 0 {
+		result.Utilization = projected.Dollars() / limit.Dollars() * 100
+	}
+	respondSuccess(w, result, nil)
+}
+
+// rejectOrder responds with an order rejection and counts it by reason code.
+func rejectOrder(w http.ResponseWriter, message string, code response.ErrorCode) {
+	metrics.OrdersRejected.WithLabelValues(code.String()).Inc()
+	respondError(w, message, code)
+}
+
+// rejectInvalidOrder is rejectOrder for Validate failures.
+func rejectInvalidOrder(w http.ResponseWriter, errs ValidationErrors) {
+	metrics.OrdersRejected.WithLabelValues(validationCode(errs).String()).Inc()
+	respondValidationError(w, errs)
+}
+
+// PlaceOrder submits a trading order (mock).
+// Core Principle 9: Fair and equitable execution.
+// Core Principle 11: Pre-trade margin check.
+func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	var req PlaceOrderRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		message, code := decodeError(err)
+		rejectOrder(w, message, code)
+		return
+	}
+
+	// Validate inputs
+	if err := req.Validate(); err != nil {
+		rejectInvalidOrder(w, err.(ValidationErrors))
 		return
 	}
 
@@ -654,10 +1581,32 @@ func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 		orderType = models.OrderTypeMarket
 	}
 
+	// Rate limiting (Core Principle 4); only accepted orders count, so the
+	// reserved slot is released unless the order is created
+	releaseRate, allowed := h.surveillance.ReserveOrder(claims.UserID)
+	if !allowed {
+		h.surveillance.ReportRateLimited(claims.UserID, req.MarketTicker)
+		rejectOrder(w, "Order rate limit exceeded. Please wait.", response.CodeRateLimited)
+		return
+	}
+	accepted := false
+	defer func() {
+		if !accepted {
+			releaseRate()
+		}
+	}()
+
 	// Verify market exists and is open
-	market, err := h.kalshi.GetMarket(req.MarketTicker)
+	market, err := h.kalshiFor(r).GetMarket(req.MarketTicker)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Market not found", "MARKET_NOT_FOUND")
+		switch {
+		case errors.Is(err, kalshi.ErrKalshiNotFound):
+			rejectOrder(w, "Market not found", response.CodeMarketNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			rejectOrder(w, "Market data request timed out", response.CodeGatewayTimeout)
+		default:
+			respondKalshiError(w, r, err, "Unable to verify market", "Market not found", response.CodeMarketNotFound)
+		}
 		return
 	}
 	// Check for open/active status (Kalshi may use different values)
@@ -665,14 +1614,25 @@ func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	marketStatus := strings.ToLower(market.Status)
 	isOpen := marketStatus == "open" || marketStatus == "active" || marketStatus == "trading"
 	if !isOpen {
-		respondError(w, http.StatusBadRequest, "Market is not open for trading (status: "+market.Status+")", "MARKET_CLOSED")
+		rejectOrder(w, "Market is not open for trading (status: "+market.Status+")", response.CodeMarketClosed)
+		return
+	}
+
+	// The client stops waiting at the deadline; do not place an order it
+	// will never hear about
+	if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		rejectOrder(w, "Order request timed out", response.CodeGatewayTimeout)
 		return
 	}
 
 	ip := auth.GetClientIP(r)
 
 	// Create order (includes compliance checks)
-	order, err := h.store.CreateOrder(
+	create := h.store.CreateOrder
+	if req.ReduceOnly {
+		create = h.store.CreateReduceOnlyOrder
+	}
+	order, err := create(
 		claims.UserID,
 		req.MarketTicker,
 		market.EventTicker,
@@ -684,45 +1644,105 @@ func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err != nil {
-		switch err {
-		case mock.ErrInsufficientFunds:
-			respondError(w, http.StatusBadRequest, "Insufficient funds", "INSUFFICIENT_FUNDS")
-		case mock.ErrPositionLimitExceeded:
-			respondError(w, http.StatusBadRequest, "Position limit exceeded", "POSITION_LIMIT")
-		case mock.ErrKYCRequired:
-			respondError(w, http.StatusForbidden, "KYC verification required", "KYC_REQUIRED")
-		case mock.ErrTradingHalted:
-			respondError(w, http.StatusServiceUnavailable, "Trading is halted", "TRADING_HALTED")
-		case mock.ErrUserSuspended:
-			respondError(w, http.StatusForbidden, "Account suspended", "ACCOUNT_SUSPENDED")
-		default:
-			respondError(w, http.StatusInternalServerError, "Order failed", "ORDER_FAILED")
+		// CP 5: Repeated breaches suspend the account
+		if errors.Is(err, mock.ErrPositionLimitExceeded) && h.surveillance.RecordPositionLimitBreach(claims.UserID, req.MarketTicker) {
+			logging.FromContext(r.Context()).Warn("user suspended after repeated position limit breaches", "user_id", claims.UserID)
 		}
+		message, code := h.storeError(err, "Order failed", response.CodeOrderFailed)
+		if code.Status() == http.StatusInternalServerError {
+			logging.FromContext(r.Context()).Error("create order failed", "user_id", claims.UserID, "ticker", req.MarketTicker, "error", err)
+		}
+		rejectOrder(w, message, code)
 		return
 	}
 
+	accepted = true
+
 	// MOCK: Simulate fill for demo
 	// In production: Would route to Kalshi's authenticated API
-	go func() {
-		time.Sleep(500 * time.Millisecond) // Simulate matching delay
-		h.store.MockFillOrder(order.ID, req.PriceCents)
-	}()
+	placed := *order // as submitted; the fill may update the stored order
+	userID, orderID, ticker, price := claims.UserID, order.ID, req.MarketTicker, req.PriceCents
+	h.fills.ScheduleFill(func() {
+		if err := h.store.MockFillOrder(orderID, price); err == nil {
+			h.surveillance.CheckVolumeConcentration(userID, ticker)
+		}
+	})
 
+	metrics.OrdersPlaced.Inc()
 	wallet, _ := h.store.GetWallet(claims.UserID)
 
 	respondSuccess(w, map[string]interface{}{
-		"order":   order,
+		"order":   &placed,
 		"wallet":  wallet,
 		"message": "Order submitted successfully",
 	}, nil)
 }
 
+// AmendOrderRequest changes a resting order. Omitted fields keep their
+// current value.
+type AmendOrderRequest struct {
+	Quantity   int `json:"quantity"`
+	PriceCents int `json:"price_cents"`
+}
+
+// AmendOrder changes the price and/or quantity of an open order in place.
+// Core Principle 11: Collateral is adjusted to the amended size.
+func (h *Handler) AmendOrder(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	orderID := mux.Vars(r)["id"]
+	current, err := h.store.GetOrder(claims.UserID, orderID)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to fetch order", response.CodeInternalError)
+		return
+	}
+
+	var req AmendOrderRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = current.Quantity
+	}
+	if req.PriceCents == 0 {
+		req.PriceCents = current.PriceCents
+	}
+	if req.Quantity < 0 || req.Quantity > 1000 {
+		respondError(w, "Quantity must be 1-1000", response.CodeInvalidQuantity)
+		return
+	}
+	if req.PriceCents < 1 || req.PriceCents > 99 {
+		respondError(w, "Price must be 1-99 cents", response.CodeInvalidPrice)
+		return
+	}
+
+	order, err := h.store.AmendOrder(claims.UserID, orderID, req.PriceCents, req.Quantity, auth.GetClientIP(r))
+	if err != nil {
+		h.respondStoreError(w, r, err, "Amend failed", response.CodeAmendFailed)
+		return
+	}
+	// CP 4: An amend cancels and replaces the order
+	h.surveillance.CheckOrderRatio(claims.UserID, order.MarketTicker)
+
+	wallet, _ := h.store.GetWallet(claims.UserID)
+
+	respondSuccess(w, map[string]interface{}{
+		"order":  order,
+		"wallet": wallet,
+	}, nil)
+}
+
 // GetOrders returns user's order history.
 // Core Principle 18: Order recordkeeping.
 func (h *Handler) GetOrders(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
@@ -741,7 +1761,7 @@ func (h *Handler) GetOrders(w http.ResponseWriter, r *http.Request) {
 
 	orders, err := h.store.GetOrders(claims.UserID, status, limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch orders", "INTERNAL_ERROR")
+		h.respondStoreError(w, r, err, "Failed to fetch orders", response.CodeInternalError)
 		return
 	}
 
@@ -758,19 +1778,33 @@ func (h *Handler) GetOrders(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetPositions(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
 	positions, err := h.store.GetPositions(claims.UserID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch positions", "INTERNAL_ERROR")
+		h.respondStoreError(w, r, err, "Failed to fetch positions", response.CodeInternalError)
 		return
 	}
 
-	// Enrich with current market prices
+	totalValue, totalPnL := h.markToMarket(r.Context(), positions)
+
+	respondSuccess(w, map[string]interface{}{
+		"positions":      positions,
+		"total_value":    totalValue,
+		"total_pnl":      totalPnL,
+		"position_count": len(positions),
+	}, nil)
+}
+
+// markToMarket values positions at the current Kalshi bid for their side
+// and returns the totals. Positions whose market cannot be fetched keep
+// their stored values.
+func (h *Handler) markToMarket(ctx context.Context, positions []models.Position) (value, pnl models.Money) {
+	markets := h.kalshi.WithContext(ctx)
 	for i := range positions {
-		market, err := h.kalshi.GetMarket(positions[i].MarketTicker)
+		market, err := markets.GetMarket(positions[i].MarketTicker)
 		if err == nil {
 			var currentPrice int
 			if positions[i].Side == models.OrderSideYes {
@@ -778,31 +1812,124 @@ func (h *Handler) GetPositions(w http.ResponseWriter, r *http.Request) {
 			} else {
 				currentPrice = market.NoBid
 			}
-			positions[i].CurrentValue = float64(positions[i].Quantity*currentPrice) / 100.0
+			positions[i].CurrentValue = models.Cents(int64(positions[i].Quantity * currentPrice))
 			positions[i].UnrealizedPnL = positions[i].CurrentValue - positions[i].CostBasisUSD
 		}
+		value += positions[i].CurrentValue
+		pnl += positions[i].UnrealizedPnL
 	}
+	return value, pnl
+}
 
-	// Calculate totals
-	var totalValue, totalPnL float64
-	for _, pos := range positions {
-		totalValue += pos.CurrentValue
-		totalPnL += pos.UnrealizedPnL
+// ClosePositionRequest sells some or all of a position.
+type ClosePositionRequest struct {
+	Quantity int `json:"quantity"` // 0 closes the whole position
+}
+
+// ClosePosition sells contracts of an open position at the current Kalshi
+// bid for its side. Realized P&L follows the configured cost basis method.
+// Core Principle 9: Execution; Core Principle 11: Realized P&L.
+func (h *Handler) ClosePosition(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
 	}
 
-	respondSuccess(w, map[string]interface{}{
-		"positions":      positions,
-		"total_value":    totalValue,
-		"total_pnl":      totalPnL,
-		"position_count": len(positions),
-	}, nil)
+	var req ClosePositionRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	positionID := mux.Vars(r)["id"]
+	pos, err := h.store.GetPosition(claims.UserID, positionID)
+	if err != nil || pos.ClosedAt != nil {
+		respondError(w, "Open position not found", response.CodePositionNotFound)
+		return
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = pos.Quantity
+	}
+	if quantity < 0 || quantity > pos.Quantity {
+		respondError(w, fmt.Sprintf("quantity must be between 1 and %d", pos.Quantity), response.CodeInvalidQuantity)
+		return
+	}
+	if h.store.IsTradingHalted(pos.MarketTicker) {
+		respondError(w, h.haltMessage(), response.CodeTradingHalted)
+		return
+	}
+
+	market, err := h.kalshiFor(r).GetMarket(pos.MarketTicker)
+	if err != nil {
+		respondKalshiError(w, r, err, "Unable to price the position", "Market not found", response.CodeMarketNotFound)
+		return
+	}
+	mark := market.YesBid
+	if pos.Side == models.OrderSideNo {
+		mark = market.NoBid
+	}
+
+	closed, err := h.store.ClosePosition(claims.UserID, positionID, quantity, mark, auth.GetClientIP(r))
+	if err != nil {
+		h.respondStoreError(w, r, err, "Close failed", response.CodeCloseFailed)
+		return
+	}
+	respondSuccess(w, closed, map[string]interface{}{
+		"closed_quantity":   quantity,
+		"price_cents":       mark,
+		"cost_basis_method": h.store.GetCostBasisMethod(),
+	})
+}
+
+// CloseMarketPositions closes all of the user's positions in the market
+// named by ?market_ticker= at the current Kalshi bids, YES and NO legs
+// together. The response lists each leg with aggregate proceeds and
+// realized P&L.
+// Core Principle 9: Execution; Core Principle 11: Realized P&L.
+func (h *Handler) CloseMarketPositions(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	ticker := strings.TrimSpace(r.URL.Query().Get("market_ticker"))
+	if ticker == "" {
+		respondError(w, "market_ticker is required", response.CodeMissingTicker)
+		return
+	}
+	if h.store.IsTradingHalted(ticker) {
+		respondError(w, h.haltMessage(), response.CodeTradingHalted)
+		return
+	}
+	market, err := h.kalshiFor(r).GetMarket(ticker)
+	if err != nil {
+		respondKalshiError(w, r, err, "Unable to price the positions", "Market not found", response.CodeMarketNotFound)
+		return
+	}
+
+	result, err := h.store.CloseMarketPositions(claims.UserID, ticker, market.YesBid, market.NoBid, auth.GetClientIP(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, mock.ErrPositionNotFound):
+			respondError(w, "No open positions in this market", response.CodePositionNotFound)
+		case errors.Is(err, mock.ErrInvalidClose):
+			// The store only rejects the bids here, which came from Kalshi
+			respondError(w, "Market returned an invalid price", response.CodeKalshiError)
+		default:
+			h.respondStoreError(w, r, err, "Close failed", response.CodeCloseFailed)
+		}
+		return
+	}
+	respondSuccess(w, result, map[string]interface{}{"cost_basis_method": h.store.GetCostBasisMethod()})
 }
 
 // GetPortfolioSummary returns portfolio overview.
 func (h *Handler) GetPortfolioSummary(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
@@ -810,7 +1937,7 @@ func (h *Handler) GetPortfolioSummary(w http.ResponseWriter, r *http.Request) {
 	positions, _ := h.store.GetPositions(claims.UserID)
 	user, _ := h.store.GetUser(claims.UserID)
 
-	var positionValue, unrealizedPnL float64
+	var positionValue, unrealizedPnL models.Money
 	for _, pos := range positions {
 		positionValue += pos.CurrentValue
 		unrealizedPnL += pos.UnrealizedPnL
@@ -832,11 +1959,487 @@ func (h *Handler) GetPortfolioSummary(w http.ResponseWriter, r *http.Request) {
 		"limits": map[string]interface{}{
 			"position_limit":   user.PositionLimitUSD,
 			"current_exposure": exposure,
-			"utilization":      (exposure / user.PositionLimitUSD) * 100,
+			"utilization":      exposure.Dollars() / user.PositionLimitUSD.Dollars() * 100,
 		},
+		"cost_basis_method": h.store.GetCostBasisMethod(),
+	}, nil)
+}
+
+// GetFees returns the trading and settlement fee schedule.
+// Core Principle 11: Fees are disclosed before trading.
+func (h *Handler) GetFees(w http.ResponseWriter, r *http.Request) {
+	respondSuccess(w, h.store.GetFeeSchedule(), nil)
+}
+
+// =============================================================================
+// HALT STATUS HANDLERS
+// Core Principle 4: Traders can see halts before their orders are rejected
+// =============================================================================
+
+// HaltInfo describes the halt affecting a market or the whole exchange.
+type HaltInfo struct {
+	Scope     string    `json:"scope"` // market, global
+	Reason    string    `json:"reason"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// MarketTradingStatus is whether a single market accepts orders.
+type MarketTradingStatus struct {
+	Ticker string    `json:"ticker"`
+	Status string    `json:"status"` // open, halted
+	Halt   *HaltInfo `json:"halt,omitempty"`
+}
+
+// ExchangeStatus summarizes the active halts.
+type ExchangeStatus struct {
+	GlobalHalt    bool      `json:"global_halt"`
+	Halt          *HaltInfo `json:"halt,omitempty"`
+	HaltedMarkets []string  `json:"halted_markets"`
+}
+
+// haltMessage says whether an order was rejected by a global or market halt.
+func (h *Handler) haltMessage() string {
+	if h.store.IsGlobalHalt() {
+		return "Trading is halted on all markets"
+	}
+	return "Trading is halted for this market"
+}
+
+func haltInfo(halt *models.EmergencyHalt) *HaltInfo {
+	scope := "market"
+	if halt.Global {
+		scope = "global"
+	}
+	return &HaltInfo{Scope: scope, Reason: halt.Reason, StartedAt: halt.StartedAt}
+}
+
+// GetMarketStatus reports whether a market is open or halted. A global halt
+// takes precedence over a market-specific one.
+func (h *Handler) GetMarketStatus(w http.ResponseWriter, r *http.Request) {
+	ticker := mux.Vars(r)["ticker"]
+	status := MarketTradingStatus{Ticker: ticker, Status: "open"}
+	for _, halt := range h.store.GetActiveHalts() {
+		if halt.Global {
+			status.Halt = haltInfo(halt)
+			break
+		}
+		if halt.MarketTicker == ticker {
+			status.Halt = haltInfo(halt)
+		}
+	}
+	if status.Halt != nil {
+		status.Status = "halted"
+	}
+	respondSuccess(w, status, nil)
+}
+
+// GetExchangeStatus reports whether a global halt is in effect and which
+// markets are halted individually.
+func (h *Handler) GetExchangeStatus(w http.ResponseWriter, r *http.Request) {
+	status := ExchangeStatus{GlobalHalt: h.store.IsGlobalHalt(), HaltedMarkets: []string{}}
+	for _, halt := range h.store.GetActiveHalts() {
+		if halt.Global {
+			status.Halt = haltInfo(halt)
+			continue
+		}
+		status.HaltedMarkets = append(status.HaltedMarkets, halt.MarketTicker)
+	}
+	respondSuccess(w, status, nil)
+}
+
+// =============================================================================
+// SETTLEMENT HANDLERS
+// Core Principle 3: Objective resolution
+// Core Principle 11: Payout integrity
+// =============================================================================
+
+// GetSettlements returns how the user's positions resolved, newest first.
+// Optional ?ticker= limits results to one market.
+func (h *Handler) GetSettlements(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	settlements := h.store.GetSettlements(claims.UserID, r.URL.Query().Get("ticker"), parseLimit(r, 100))
+
+	respondSuccess(w, settlements, map[string]interface{}{"count": len(settlements)})
+}
+
+// AdminGetSettlements returns settlements across all users. Optional
+// ?ticker= and ?user_id= narrow the results.
+func (h *Handler) AdminGetSettlements(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := parseLimit(r, 100)
+
+	var settlements []models.Settlement
+	if userID := query.Get("user_id"); userID != "" {
+		settlements = h.store.GetSettlements(userID, query.Get("ticker"), limit)
+	} else {
+		settlements = h.store.GetAllSettlements(query.Get("ticker"), limit)
+	}
+
+	respondSuccess(w, settlements, map[string]interface{}{"count": len(settlements)})
+}
+
+// AdminReconcileWallets compares wallets with their transaction ledgers:
+// the ?user_id= wallet, or every wallet. ?mismatched=true returns only
+// wallets that do not reconcile.
+// Core Principle 13: Operators can show customer funds are accounted for.
+func (h *Handler) AdminReconcileWallets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var results []models.ReconResult
+	if userID := query.Get("user_id"); userID != "" {
+		result, err := h.store.ReconcileWallet(userID)
+		if err != nil {
+			h.respondStoreError(w, r, err, "Reconciliation failed", response.CodeInternalError)
+			return
+		}
+		results = []models.ReconResult{result}
+	} else {
+		results = h.store.ReconcileWallets()
+	}
+
+	mismatched := make([]models.ReconResult, 0)
+	for _, result := range results {
+		if !result.Balanced {
+			mismatched = append(mismatched, result)
+		}
+	}
+	meta := map[string]interface{}{"count": len(results), "mismatched": len(mismatched)}
+	if query.Get("mismatched") == "true" {
+		results = mismatched
+	}
+	respondSuccess(w, results, meta)
+}
+
+// AdminGetLedger returns double-entry journal entries, the ?user_id= user's
+// or all, with the account balances they sum to in meta.
+// Core Principle 11: Customer P&L and fees can be audited as a balanced ledger.
+func (h *Handler) AdminGetLedger(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	entries := h.store.Ledger(userID)
+	respondSuccess(w, entries, map[string]interface{}{
+		"count":    len(entries),
+		"balances": h.store.LedgerBalances(userID),
+	})
+}
+
+// ReverseTransactionRequest gives the reason recorded with a reversal.
+type ReverseTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AdminReverseTransaction reverses a deposit, withdrawal or fee.
+// Core Principle 13: Corrections to customer funds are operator actions.
+func (h *Handler) AdminReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	var req ReverseTransactionRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		respondError(w, "A reason is required", response.CodeInvalidRequest)
+		return
+	}
+
+	txID := mux.Vars(r)["id"]
+	refund, err := h.store.ReverseTransaction(txID, req.Reason, auth.GetClientIP(r))
+	if err != nil {
+		if errors.Is(err, mock.ErrInsufficientFunds) {
+			respondError(w, "Deposited funds are no longer available", response.CodeFundsUnavailable)
+			return
+		}
+		h.respondStoreError(w, r, err, "Reversal failed", response.CodeReversalFailed)
+		return
+	}
+
+	respondSuccess(w, refund, nil)
+}
+
+// validUserStatuses are the ?status= values accepted by AdminListUsers.
+var validUserStatuses = map[models.UserStatus]bool{
+	models.UserStatusPending: true, models.UserStatusKYCPending: true, models.UserStatusVerified: true,
+	models.UserStatusSuspended: true, models.UserStatusBanned: true,
+}
+
+// maxUserPage caps ?limit= on the admin user listing.
+const maxUserPage = 200
+
+// AdminListUsers lists accounts newest first with their exposure, open
+// positions and unresolved alerts. Optional ?status=, ?state= and ?q= (email
+// or name) filter; ?limit= and ?offset= page. X-Total-Count carries the
+// number of matches.
+// Core Principle 17: Operators can enumerate accounts under review.
+func (h *Handler) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := mock.UserFilter{
+		Status:    models.UserStatus(strings.ToLower(query.Get("status"))),
+		StateCode: strings.ToUpper(strings.TrimSpace(query.Get("state"))),
+		Query:     query.Get("q"),
+	}
+	if filter.Status != "" && !validUserStatuses[filter.Status] {
+		respondError(w, "Unknown user status", response.CodeInvalidRequest)
+		return
+	}
+	limit := min(parseLimit(r, 50), maxUserPage)
+	offset, ok := parseOffset(w, r)
+	if !ok {
+		return
+	}
+
+	users, total := h.store.ListUsers(filter, offset, limit)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	respondSuccess(w, users, map[string]interface{}{
+		"count":    len(users),
+		"total":    total,
+		"offset":   offset,
+		"has_more": offset+len(users) < total,
+	})
+}
+
+// UpdateUserStatusRequest suspends, bans or reinstates an account.
+type UpdateUserStatusRequest struct {
+	Status    string `json:"status"` // suspended, banned, or reinstated to restore the status held before
+	Reason    string `json:"reason"`
+	Liquidate bool   `json:"liquidate"` // On suspend or ban, cancel orders and close positions at market
+}
+
+// statusReinstated is the UpdateUserStatusRequest status that lifts a
+// suspension or ban.
+const statusReinstated = "reinstated"
+
+// AdminUpdateUserStatus suspends, bans or reinstates an account, auditing
+// the acting admin and reason. Reinstatement restores the status held
+// before the suspension or ban; no one becomes verified without an approved
+// KYC record. With liquidate set on a suspension or ban, the user's orders
+// are cancelled and positions closed at current marks once they can no
+// longer trade.
+// Core Principle 17: Removing unfit participants from the market.
+func (h *Handler) AdminUpdateUserStatus(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	var req UpdateUserStatusRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	requested := strings.ToLower(req.Status)
+	switch requested {
+	case string(models.UserStatusSuspended), string(models.UserStatusBanned), statusReinstated:
+	default:
+		respondError(w, "status must be suspended, banned or reinstated", response.CodeInvalidRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		respondError(w, "reason is required", response.CodeMissingFields)
+		return
+	}
+	if req.Liquidate && requested == statusReinstated {
+		respondError(w, "Only suspended or banned accounts can be liquidated", response.CodeInvalidRequest)
+		return
+	}
+
+	userID := mux.Vars(r)["id"]
+	ip := auth.GetClientIP(r)
+	status := models.UserStatus(requested)
+	var err error
+	if requested == statusReinstated {
+		status, err = h.store.ReinstateUser(userID, claims.UserID, req.Reason, ip)
+	} else {
+		err = h.store.RestrictUser(userID, status, claims.UserID, req.Reason, ip)
+	}
+	if err != nil {
+		h.respondStoreError(w, r, err, "Status update failed", response.CodeInternalError)
+		return
+	}
+
+	data := map[string]interface{}{"user_id": userID, "status": status}
+	if req.Liquidate {
+		marks := h.liquidationMarks(r, userID)
+		liquidation, err := h.store.LiquidateUser(userID, marks, req.Reason, claims.UserID, ip)
+		if err != nil {
+			h.respondStoreError(w, r, err, "Status updated but liquidation failed", response.CodeLiquidationFailed)
+			return
+		}
+		data["liquidation"] = liquidation
+	}
+	respondSuccess(w, data, nil)
+}
+
+// liquidationMarks fetches the current bids for each market the user holds
+// a position in. Markets Kalshi cannot price are left out and close at
+// their entry price.
+func (h *Handler) liquidationMarks(r *http.Request, userID string) map[string]mock.Mark {
+	positions, _ := h.store.GetPositions(userID)
+	markets := h.kalshiFor(r)
+	marks := make(map[string]mock.Mark)
+	for _, pos := range positions {
+		if _, seen := marks[pos.MarketTicker]; seen {
+			continue
+		}
+		if market, err := markets.GetMarket(pos.MarketTicker); err == nil {
+			marks[pos.MarketTicker] = mock.Mark{YesBid: market.YesBid, NoBid: market.NoBid}
+		}
+	}
+	return marks
+}
+
+// UserLimitStatus is a user's exposure against their position limit.
+type UserLimitStatus struct {
+	PositionLimitUSD models.Money `json:"position_limit_usd"`
+	ExposureUSD      models.Money `json:"exposure_usd"`
+	Utilization      float64      `json:"utilization"` // Percent of the limit in use
+}
+
+// AdminUserDetail is the operator drill-down for one account.
+type AdminUserDetail struct {
+	User          *models.User             `json:"user"`
+	KYC           *models.KYCRecord        `json:"kyc"`
+	Wallet        *models.Wallet           `json:"wallet"`
+	Positions     []models.Position        `json:"positions"`
+	PositionValue models.Money             `json:"position_value"`
+	UnrealizedPnL models.Money             `json:"unrealized_pnl"`
+	Limits        UserLimitStatus          `json:"limits"`
+	RecentOrders  []models.Order           `json:"recent_orders"`
+	RecentAlerts  []models.ComplianceAlert `json:"recent_alerts"`
+}
+
+// recentDetailItems caps the orders and alerts in AdminUserDetail.
+const recentDetailItems = 20
+
+// AdminGetUser returns one account's profile, KYC record, wallet, marked
+// positions, exposure against its limit, and recent orders and alerts.
+// Core Principles 5, 17: The compliance drill-down behind AdminListUsers.
+func (h *Handler) AdminGetUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	user, err := h.store.GetUser(userID)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to fetch user", response.CodeInternalError)
+		return
+	}
+
+	kyc, _ := h.store.GetKYCRecord(userID)
+	wallet, _ := h.store.GetWallet(userID)
+	positions, _ := h.store.GetPositions(userID)
+	if positions == nil {
+		positions = []models.Position{}
+	}
+	value, pnl := h.markToMarket(r.Context(), positions)
+	orders, _ := h.store.GetOrders(userID, nil, recentDetailItems)
+	if orders == nil {
+		orders = []models.Order{}
+	}
+	alerts := h.store.GetUserAlerts(userID, recentDetailItems)
+	if alerts == nil {
+		alerts = []models.ComplianceAlert{}
+	}
+
+	exposure := h.store.GetUserExposure(userID)
+	limits := UserLimitStatus{PositionLimitUSD: user.PositionLimitUSD, ExposureUSD: exposure}
+	if user.PositionLimitUSD > 0 {
+		limits.Utilization = exposure.Dollars() / user.PositionLimitUSD.Dollars() * 100
+	}
+
+	respondSuccess(w, AdminUserDetail{
+		User:          user,
+		KYC:           kyc,
+		Wallet:        wallet,
+		Positions:     positions,
+		PositionValue: value,
+		UnrealizedPnL: pnl,
+		Limits:        limits,
+		RecentOrders:  orders,
+		RecentAlerts:  alerts,
 	}, nil)
 }
 
+// PositionLimitsRequest replaces the position limit tier table.
+type PositionLimitsRequest struct {
+	Tiers []models.PositionLimitConfig `json:"tiers"`
+}
+
+// AdminGetPositionLimits returns the live position limit tier table.
+// Core Principle 5: Position limits are visible to compliance.
+func (h *Handler) AdminGetPositionLimits(w http.ResponseWriter, r *http.Request) {
+	respondSuccess(w, h.store.GetPositionLimits(), nil)
+}
+
+// AdminUpdatePositionLimits replaces the tier table. Pre-trade checks use
+// the new limits from the next order on, without a redeploy.
+// Core Principle 5: Compliance adjusts speculative limits at runtime.
+func (h *Handler) AdminUpdatePositionLimits(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	var req PositionLimitsRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	updated, err := h.store.SetPositionLimits(req.Tiers, claims.UserID, auth.GetClientIP(r))
+	if err != nil {
+		h.respondStoreError(w, r, err, "Limit update failed", response.CodeInternalError)
+		return
+	}
+
+	respondSuccess(w, map[string]interface{}{
+		"tiers":         h.store.GetPositionLimits(),
+		"users_updated": updated,
+	}, nil)
+}
+
+// parseLimit reads ?limit=, falling back to def when absent or invalid.
+func parseLimit(r *http.Request, def int) int {
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return def
+}
+
+// parseOffset reads ?offset=, defaulting to 0. It responds 400 and returns
+// false when the offset is not a non-negative integer.
+func parseOffset(w http.ResponseWriter, r *http.Request) (int, bool) {
+	o := r.URL.Query().Get("offset")
+	if o == "" {
+		return 0, true
+	}
+	offset, err := strconv.Atoi(o)
+	if err != nil || offset < 0 {
+		respondError(w, "offset must be a non-negative integer", response.CodeInvalidRequest)
+		return 0, false
+	}
+	return offset, true
+}
+
+// parseTimeParam reads an optional RFC3339 or Unix seconds query parameter,
+// returning the zero time when absent. It responds 400 and returns false
+// when the value does not parse.
+func parseTimeParam(w http.ResponseWriter, r *http.Request, name string) (time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, true
+	}
+	t, ok := parseHistoryTime(v)
+	if !ok {
+		respondError(w, name+" must be RFC3339 or Unix seconds", response.CodeInvalidRequest)
+		return time.Time{}, false
+	}
+	return t, true
+}
+
 // =============================================================================
 // COMPLIANCE HANDLERS
 // Core Principle 4: Market surveillance
@@ -848,7 +2451,7 @@ func (h *Handler) GetPortfolioSummary(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
 	claims := auth.GetUserFromContext(r.Context())
 	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
 		return
 	}
 
@@ -870,3 +2473,155 @@ func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
 
 	respondSuccess(w, entries, nil)
 }
+
+// maxAlertPage caps ?limit= on the admin alert listing.
+const maxAlertPage = 500
+
+// AdminGetAlerts searches compliance alerts. Optional ?status=, ?severity=,
+// ?type=, ?user_id= and ?market_ticker= filter exactly; ?since= and ?until=
+// (RFC3339 or Unix seconds) bound the creation time. ?sort=time (the
+// default) lists newest first; ?sort=severity lists critical first, then
+// high, medium and low, newest first within each. ?limit= and ?offset= page
+// and X-Total-Count carries the number of matches.
+// Core Principle 4: Operator view of surveillance output.
+func (h *Handler) AdminGetAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := mock.AlertFilter{
+		Status:       query.Get("status"),
+		Severity:     query.Get("severity"),
+		Type:         query.Get("type"),
+		UserID:       query.Get("user_id"),
+		MarketTicker: query.Get("market_ticker"),
+	}
+	switch query.Get("sort") {
+	case "", "time":
+	case "severity":
+		filter.BySeverity = true
+	default:
+		respondError(w, "sort must be time or severity", response.CodeInvalidRequest)
+		return
+	}
+	var ok bool
+	if filter.Since, ok = parseTimeParam(w, r, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = parseTimeParam(w, r, "until"); !ok {
+		return
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		respondError(w, "until must be after since", response.CodeInvalidRequest)
+		return
+	}
+	offset, ok := parseOffset(w, r)
+	if !ok {
+		return
+	}
+
+	alerts, total := h.store.SearchAlerts(filter, offset, min(parseLimit(r, 100), maxAlertPage))
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	respondSuccess(w, alerts, map[string]interface{}{
+		"count":    len(alerts),
+		"total":    total,
+		"offset":   offset,
+		"has_more": offset+len(alerts) < total,
+	})
+}
+
+// AdminGetStats returns dashboard figures computed from the live store.
+// Core Principle 4: Operators see authoritative exchange-wide counts.
+func (h *Handler) AdminGetStats(w http.ResponseWriter, r *http.Request) {
+	respondSuccess(w, h.store.AdminStats(time.Now().UTC()), nil)
+}
+
+// AdminAssignAlert makes the calling operator the alert's owner, claiming it
+// or taking it over from another operator.
+// Core Principle 4: Operators see who is reviewing each alert.
+func (h *Handler) AdminAssignAlert(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	alert, err := h.store.AssignAlert(mux.Vars(r)["id"], claims.UserID, auth.GetClientIP(r))
+	if err != nil {
+		h.respondStoreError(w, r, err, "Alert assignment failed", response.CodeAlertUpdateFailed)
+		return
+	}
+
+	respondSuccess(w, alert, nil)
+}
+
+// UpdateAlertStatusRequest moves an alert to a new review status.
+type UpdateAlertStatusRequest struct {
+	Status      string `json:"status"`
+	Notes       string `json:"notes"`
+	SuggestHalt bool   `json:"suggest_halt"` // On escalation, raise a halt suggestion for the alert's market
+}
+
+// AdminUpdateAlertStatus drives the alert review workflow.
+// Core Principle 4: Escalated alerts can prompt an emergency halt review.
+func (h *Handler) AdminUpdateAlertStatus(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	var req UpdateAlertStatusRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	alertID := mux.Vars(r)["id"]
+	alert, err := h.store.UpdateAlertStatus(alertID, req.Status, claims.UserID, req.Notes)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Alert update failed", response.CodeAlertUpdateFailed)
+		return
+	}
+
+	data := map[string]interface{}{"alert": alert}
+	if req.SuggestHalt && alert.Status == models.AlertStatusEscalated && alert.MarketTicker != "" {
+		data["halt_suggestion"] = h.store.CreateComplianceAlert(alert.UserID, alert.MarketTicker, "halt_suggested", "critical",
+			fmt.Sprintf("Escalated alert %s (%s) suggests halting %s: %s", alert.ID, alert.Type, alert.MarketTicker, req.Notes))
+	}
+
+	respondSuccess(w, data, nil)
+}
+
+// AnalyzeTradingRequest selects whose orders the trade-pattern detectors
+// run over.
+type AnalyzeTradingRequest struct {
+	UserID       string `json:"user_id"`
+	MarketTicker string `json:"market_ticker,omitempty"` // Empty analyzes every market the user has ordered in
+}
+
+// AdminAnalyzeTrading runs the wash trading, spoofing and layering detectors
+// over a user's recent orders on demand and returns the alerts raised. The
+// alerts are stored like any other and appear in GET /admin/alerts.
+// Core Principle 4: Operators can re-check a participant they suspect.
+func (h *Handler) AdminAnalyzeTrading(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeTradingRequest
+	if err := h.decodeJSON(w, r, &req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	if req.UserID == "" {
+		respondError(w, "user_id is required", response.CodeMissingFields)
+		return
+	}
+	if _, err := h.store.GetUser(req.UserID); err != nil {
+		h.respondStoreError(w, r, err, "Analysis failed", response.CodeInternalError)
+		return
+	}
+
+	alerts, err := h.surveillance.AnalyzeUser(req.UserID, req.MarketTicker)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Analysis failed", response.CodeInternalError)
+		return
+	}
+
+	respondSuccess(w, alerts, map[string]interface{}{"count": len(alerts)})
+}
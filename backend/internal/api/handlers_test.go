@@ -0,0 +1,1872 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/compliance"
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
+)
+
+func TestReadyCheck_KalshiDown(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	})
+
+	rec := httptest.NewRecorder()
+	h.ReadyCheck(rec, httptest.NewRequest("GET", "/api/v1/health/ready", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	var resp struct {
+		Code string `json:"code"`
+		Data struct {
+			Checks map[string]ReadinessCheck `json:"checks"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != "NOT_READY" {
+		t.Errorf("expected NOT_READY, got %q", resp.Code)
+	}
+	if resp.Data.Checks["kalshi"].Status != "down" || resp.Data.Checks["kalshi"].Error == "" {
+		t.Errorf("expected kalshi check down with error, got %+v", resp.Data.Checks["kalshi"])
+	}
+	if resp.Data.Checks["persistence"].Status != "disabled" {
+		t.Errorf("expected persistence disabled, got %+v", resp.Data.Checks["persistence"])
+	}
+}
+
+func TestReadyCheck_AllHealthy(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"series": [], "cursor": ""}`))
+	})
+
+	rec := httptest.NewRecorder()
+	h.ReadyCheck(rec, httptest.NewRequest("GET", "/api/v1/health/ready", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGetMarket_MapsKalshiStatus(t *testing.T) {
+	tests := []struct {
+		upstream int
+		want     int
+		code     string
+	}{
+		{http.StatusNotFound, http.StatusNotFound, "MARKET_NOT_FOUND"},
+		{http.StatusTooManyRequests, http.StatusTooManyRequests, "KALSHI_RATE_LIMITED"},
+		{http.StatusServiceUnavailable, http.StatusServiceUnavailable, "KALSHI_UNAVAILABLE"},
+		{http.StatusInternalServerError, http.StatusServiceUnavailable, "KALSHI_UNAVAILABLE"},
+		{http.StatusBadRequest, http.StatusBadGateway, "KALSHI_ERROR"},
+	}
+	for _, tt := range tests {
+		h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "upstream", tt.upstream)
+		})
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/FED-TEST", nil), map[string]string{"ticker": "FED-TEST"})
+		rec := httptest.NewRecorder()
+		h.GetMarket(rec, req)
+
+		var resp APIResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != tt.want || resp.Code != tt.code {
+			t.Errorf("upstream %d: expected %d %s, got %d %s", tt.upstream, tt.want, tt.code, rec.Code, resp.Code)
+		}
+	}
+}
+
+func TestGetMarkets_HasMore(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		want   bool
+	}{
+		{"full page with cursor", "next-page", true},
+		{"final page with empty cursor", "", false},
+	}
+	for _, tt := range tests {
+		h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"markets": []map[string]string{{"ticker": "A"}, {"ticker": "B"}},
+				"cursor":  tt.cursor,
+			})
+		})
+		rec := httptest.NewRecorder()
+		h.GetMarkets(rec, httptest.NewRequest("GET", "/api/v1/markets?limit=2", nil))
+
+		var resp struct {
+			Meta struct {
+				HasMore bool `json:"has_more"`
+			} `json:"meta"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp.Meta.HasMore != tt.want {
+			t.Errorf("%s: expected has_more=%v", tt.name, tt.want)
+		}
+	}
+}
+
+func TestGetSeries_ShortPageHasNoMore(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"series": [{"ticker": "FED"}], "cursor": "stale"}`))
+	})
+	rec := httptest.NewRecorder()
+	h.GetSeries(rec, httptest.NewRequest("GET", "/api/v1/series?limit=5", nil))
+
+	var resp struct {
+		Meta struct {
+			HasMore bool `json:"has_more"`
+		} `json:"meta"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Meta.HasMore {
+		t.Error("a short page must report has_more=false even with a cursor")
+	}
+}
+
+func TestGetMarkets_RiskAndKeywordFilters(t *testing.T) {
+	var gotCategory string
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		gotCategory = r.URL.Query().Get("category")
+		w.Write([]byte(`{"markets": [
+			{"ticker": "FED-1", "title": "Fed rate cut in March?", "category": "Economics"},
+			{"ticker": "ELEC-1", "title": "Election winner", "category": "Politics"},
+			{"ticker": "NBA-1", "title": "Finals champion", "category": "Sports"},
+			{"ticker": "CPI-1", "title": "CPI above 3%?", "series_ticker": "CPI"}
+		], "cursor": ""}`))
+	})
+
+	get := func(query string) []string {
+		rec := httptest.NewRecorder()
+		h.GetMarkets(rec, httptest.NewRequest("GET", "/api/v1/markets?"+query, nil))
+		var resp struct {
+			Data []struct {
+				Ticker string `json:"ticker"`
+			} `json:"data"`
+			Meta struct {
+				Count int `json:"count"`
+			} `json:"meta"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		var tickers []string
+		for _, m := range resp.Data {
+			tickers = append(tickers, m.Ticker)
+		}
+		if resp.Meta.Count != len(tickers) {
+			t.Errorf("%s: meta count %d does not match %d results", query, resp.Meta.Count, len(tickers))
+		}
+		return tickers
+	}
+
+	if got := get("risk_category=low"); len(got) != 2 || got[0] != "FED-1" || got[1] != "CPI-1" {
+		t.Errorf("risk_category=low: got %v", got)
+	}
+	if got := get("risk_category=medium"); len(got) != 1 || got[0] != "ELEC-1" {
+		t.Errorf("risk_category=medium: got %v", got)
+	}
+	if got := get("risk_category=low&q=FED"); len(got) != 1 || got[0] != "FED-1" {
+		t.Errorf("risk_category=low&q=FED: got %v", got)
+	}
+	get("category=Economics")
+	if gotCategory != "Economics" {
+		t.Errorf("category not passed through to Kalshi, got %q", gotCategory)
+	}
+
+	rec := httptest.NewRecorder()
+	h.GetMarkets(rec, httptest.NewRequest("GET", "/api/v1/markets?risk_category=extreme", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid risk_category: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestSeriesEndpoints_FilterBySeries(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/series/CPI":
+			w.Write([]byte(`{"series": {"series_ticker": "CPI", "title": "Inflation", "category": "Economics", "frequency": "monthly"}}`))
+		case r.URL.Path == "/series/NOPE":
+			http.Error(w, "not found", http.StatusNotFound)
+		case r.URL.Path == "/markets" && r.URL.Query().Get("series_ticker") == "CPI":
+			w.Write([]byte(`{"markets": [
+				{"ticker": "CPI-24NOV-T3.0", "series_ticker": "CPI", "title": "CPI above 3.0%?"},
+				{"ticker": "CPI-24NOV-T3.5", "series_ticker": "CPI", "title": "CPI above 3.5%?"}
+			], "cursor": ""}`))
+		default:
+			t.Errorf("unexpected Kalshi request %s", r.URL)
+			w.Write([]byte(`{"markets": []}`))
+		}
+	})
+	get := func(handler http.HandlerFunc, path, ticker string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest("GET", path, nil), map[string]string{"ticker": ticker})
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := get(h.GetSeriesDetail, "/api/v1/series/CPI", "CPI")
+	var detail struct {
+		Data SeriesDetail `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&detail)
+	if rec.Code != http.StatusOK || detail.Data.Title != "Inflation" || detail.Data.RiskCategory != kalshi.RiskLow {
+		t.Errorf("series detail %d: %+v, want low-risk Inflation", rec.Code, detail.Data)
+	}
+	if rec := get(h.GetSeriesDetail, "/api/v1/series/NOPE", "NOPE"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown series: status = %d, want 404", rec.Code)
+	}
+
+	rec = get(h.GetSeriesMarkets, "/api/v1/series/CPI/markets", "CPI")
+	var markets struct {
+		Data []models.KalshiMarket `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&markets)
+	if rec.Code != http.StatusOK || len(markets.Data) != 2 {
+		t.Fatalf("series markets %d: %+v, want 2", rec.Code, markets.Data)
+	}
+	for _, m := range markets.Data {
+		if m.SeriesTicker != "CPI" || m.RiskCategory != kalshi.RiskLow {
+			t.Errorf("market %s: series %q risk %q, want CPI and low", m.Ticker, m.SeriesTicker, m.RiskCategory)
+		}
+	}
+	rec = get(h.GetSeriesMarkets, "/api/v1/series/CPI/markets?q=3.5", "CPI")
+	markets.Data = nil
+	json.NewDecoder(rec.Body).Decode(&markets)
+	if len(markets.Data) != 1 || markets.Data[0].Ticker != "CPI-24NOV-T3.5" {
+		t.Errorf("q=3.5: %+v, want CPI-24NOV-T3.5", markets.Data)
+	}
+}
+
+func TestGetMarketHistory_Candles(t *testing.T) {
+	var gotQuery string
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/markets/FED-24DEC" {
+			w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "series_ticker": "FED"}}`))
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		w.Write([]byte(`{"candlesticks": [{"end_period_ts": 1731542400, "yes_bid": {"close": 60}, "yes_ask": {"close": 62},
+			"price": {"open": 58, "high": 63, "low": 57, "close": 61}, "volume": 40}]}`))
+	})
+	get := func(query string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/FED-24DEC/history"+query, nil), map[string]string{"ticker": "FED-24DEC"})
+		rec := httptest.NewRecorder()
+		h.GetMarketHistory(rec, req)
+		return rec
+	}
+
+	rec := get("?interval=1d&start=2024-11-01T00:00:00-04:00&end=1731628800")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if gotQuery != "end_ts=1731628800&period_interval=1440&start_ts=1730433600" {
+		t.Errorf("Kalshi query = %s", gotQuery)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`"period_end":"2024-11-14T00:00:00Z"`, `"start":"2024-11-01T04:00:00Z"`, `"close":61`, `"interval":"1d"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("response missing %s: %s", want, body)
+		}
+	}
+
+	for _, query := range []string{"?interval=5m", "?start=yesterday", "?start=1731628800&end=1731542400", "?interval=1m&start=0&end=1731628800"} {
+		if rec := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestGetMarketTrades_Pagination(t *testing.T) {
+	var gotQuery url.Values
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		if gotQuery.Get("cursor") == "" {
+			w.Write([]byte(`{"trades": [{"trade_id": "t3", "ticker": "FED-24DEC", "count": 5, "yes_price": 61},
+				{"trade_id": "t2", "ticker": "FED-24DEC", "count": 1, "yes_price": 60}], "cursor": "page2"}`))
+			return
+		}
+		w.Write([]byte(`{"trades": [{"trade_id": "t1", "ticker": "FED-24DEC", "count": 2, "yes_price": 58}], "cursor": ""}`))
+	})
+	get := func(query string) (ids []string, meta map[string]interface{}) {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/FED-24DEC/trades"+query, nil), map[string]string{"ticker": "FED-24DEC"})
+		rec := httptest.NewRecorder()
+		h.GetMarketTrades(rec, req)
+		var resp struct {
+			Data []kalshi.Trade         `json:"data"`
+			Meta map[string]interface{} `json:"meta"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		for _, trade := range resp.Data {
+			ids = append(ids, trade.TradeID)
+		}
+		return ids, resp.Meta
+	}
+
+	ids, meta := get("?limit=2")
+	if len(ids) != 2 || ids[0] != "t3" || meta["cursor"] != "page2" || meta["has_more"] != true {
+		t.Errorf("first page = %v %v, want t3, t2 with more", ids, meta)
+	}
+	if gotQuery.Get("ticker") != "FED-24DEC" || gotQuery.Get("limit") != "2" {
+		t.Errorf("Kalshi query = %v", gotQuery)
+	}
+	ids, meta = get("?limit=2&cursor=page2")
+	if len(ids) != 1 || ids[0] != "t1" || meta["has_more"] != false || gotQuery.Get("cursor") != "page2" {
+		t.Errorf("second page = %v %v, want t1 and no more", ids, meta)
+	}
+
+	// Out-of-range limits are clamped before reaching Kalshi
+	for query, want := range map[string]string{"?limit=-5": "50", "?limit=0": "50", "?limit=100000": "1000"} {
+		if get(query); gotQuery.Get("limit") != want {
+			t.Errorf("%s: Kalshi limit = %q, want %s", query, gotQuery.Get("limit"), want)
+		}
+	}
+}
+
+func TestGetOrderbook_EmptyBookIsNotAnError(t *testing.T) {
+	tests := []struct {
+		name, body, liquidity string
+		yes, no               int
+	}{
+		{"null sides", `{"orderbook": {"yes": null, "no": null}}`, LiquidityNone, 0, 0},
+		{"no book", `{"orderbook": {}}`, LiquidityNone, 0, 0},
+		{"one side", `{"orderbook": {"yes": [[40, 100], [39, 50]]}}`, LiquidityOneSided, 2, 0},
+		{"both sides", `{"orderbook": {"yes": [[40, 100]], "no": [[58, 20]]}}`, LiquidityTwoSided, 1, 1},
+	}
+	for _, tt := range tests {
+		h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(tt.body)) })
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/FED-24DEC/orderbook", nil), map[string]string{"ticker": "FED-24DEC"})
+		rec := httptest.NewRecorder()
+		h.GetOrderbook(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", tt.name, rec.Code)
+			continue
+		}
+		if tt.yes == 0 && !strings.Contains(rec.Body.String(), `"yes":[]`) {
+			t.Errorf("%s: empty side not an explicit array: %s", tt.name, rec.Body.String())
+		}
+		var resp struct {
+			Data OrderbookView `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp.Data.Liquidity != tt.liquidity || len(resp.Data.YesBids) != tt.yes || len(resp.Data.NoBids) != tt.no ||
+			resp.Data.Ticker != "FED-24DEC" {
+			t.Errorf("%s: book = %+v, want %s with %d/%d levels", tt.name, resp.Data, tt.liquidity, tt.yes, tt.no)
+		}
+	}
+}
+
+func TestGetOrderbook_SummaryAndDepth(t *testing.T) {
+	var gotDepth string
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		gotDepth = r.URL.Query().Get("depth")
+		// Kalshi lists levels lowest price first and may return extra levels
+		w.Write([]byte(`{"orderbook": {"yes": [[38, 10], [39, 20], [41, 5], [40, 15]], "no": [[55, 30], [57, 12]]}}`))
+	})
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/FED-24DEC/orderbook?depth=3", nil), map[string]string{"ticker": "FED-24DEC"})
+	rec := httptest.NewRecorder()
+	h.GetOrderbook(rec, req)
+	var resp struct {
+		Data OrderbookView `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	book := resp.Data
+
+	if gotDepth != "3" {
+		t.Errorf("depth sent to Kalshi = %q, want 3", gotDepth)
+	}
+	want := []kalshi.OrderbookLevel{{Price: 41, Quantity: 5}, {Price: 40, Quantity: 15}, {Price: 39, Quantity: 20}}
+	if !reflect.DeepEqual(book.YesBids, want) || len(book.NoBids) != 2 || book.NoBids[0].Price != 57 {
+		t.Fatalf("levels = %+v / %+v, want the best 3 YES and 2 NO, best first", book.YesBids, book.NoBids)
+	}
+	s := book.Summary
+	// YES 41 bid / 43 ask (100 - 57); NO 57 bid / 59 ask (100 - 41)
+	if s.BestYesBid == nil || *s.BestYesBid != 41 || s.BestYesAsk == nil || *s.BestYesAsk != 43 ||
+		s.BestNoBid == nil || *s.BestNoBid != 57 || s.BestNoAsk == nil || *s.BestNoAsk != 59 ||
+		s.SpreadCents == nil || *s.SpreadCents != 2 || s.YesDepth != 40 || s.NoDepth != 42 {
+		b, _ := json.Marshal(s)
+		t.Errorf("summary = %s", b)
+	}
+
+	one := newOrderbookView("X", &kalshi.OrderbookResponse{}, 0)
+	if one.Summary.BestYesBid != nil || one.Summary.SpreadCents != nil || one.Summary.YesDepth != 0 {
+		b, _ := json.Marshal(one.Summary)
+		t.Errorf("empty book summary = %s, want nulls", b)
+	}
+}
+
+func TestGetOrderbook_TransportFailureIsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	h := NewHandler(mock.NewStore(), kalshi.NewClient(server.URL, time.Second), compliance.NewSurveillanceEngine(mock.NewStore(), compliance.SurveillanceConfig{}))
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/FED-24DEC/orderbook", nil), map[string]string{"ticker": "FED-24DEC"})
+	rec := httptest.NewRecorder()
+	h.GetOrderbook(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "KALSHI_UNAVAILABLE") {
+		t.Errorf("unreachable Kalshi: %d %s, want 503 KALSHI_UNAVAILABLE", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGetMarketSnapshot_AllAndPartial(t *testing.T) {
+	failTrades := false
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/markets/FED-24DEC":
+			w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "series_ticker": "FED", "title": "Fed cut?", "yes_bid": 40, "yes_ask": 42}}`))
+		case "/markets/FED-24DEC/orderbook":
+			w.Write([]byte(`{"orderbook": {"yes": [[40, 10]], "no": [[58, 5]]}}`))
+		case "/markets/trades":
+			if failTrades {
+				http.Error(w, "down", http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"trades": [{"trade_id": "t1", "ticker": "FED-24DEC", "count": 3, "yes_price": 41}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	get := func(ticker string) (*httptest.ResponseRecorder, MarketSnapshot) {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/"+ticker+"/snapshot", nil), map[string]string{"ticker": ticker})
+		rec := httptest.NewRecorder()
+		h.GetMarketSnapshot(rec, req)
+		var resp struct {
+			Data MarketSnapshot `json:"data"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp.Data
+	}
+
+	rec, snap := get("FED-24DEC")
+	if rec.Code != http.StatusOK || snap.Partial || snap.Market == nil || snap.Market.Title != "Fed cut?" ||
+		snap.Orderbook == nil || snap.Orderbook.Liquidity != LiquidityTwoSided || len(snap.Trades) != 1 {
+		t.Errorf("full snapshot %d: %+v", rec.Code, snap)
+	}
+	if snap.Risk == nil || snap.Risk.Category != "low" || snap.Risk.Reason == "" || snap.Market.RiskCategory != "low" {
+		t.Errorf("snapshot risk = %+v, want low with a reason", snap.Risk)
+	}
+
+	failTrades = true
+	rec, snap = get("FED-24DEC")
+	if rec.Code != http.StatusOK || !snap.Partial || len(snap.Failed) != 1 || snap.Failed[0] != "trades" ||
+		snap.Trades != nil || snap.Market == nil || snap.Orderbook == nil {
+		t.Errorf("snapshot without trades %d: %+v, want partial with trades failed", rec.Code, snap)
+	}
+
+	if rec, _ := get("NOPE"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown market: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := withTimeout(10*time.Millisecond, func() (int, error) { <-release; return 1, nil }); err != errSnapshotTimeout {
+		t.Errorf("slow call: err = %v, want a timeout", err)
+	}
+	if v, err := withTimeout(time.Second, func() (int, error) { return 7, nil }); v != 7 || err != nil {
+		t.Errorf("fast call = %d, %v", v, err)
+	}
+}
+
+func TestGetMarketRisk_UsesConfiguredClassifier(t *testing.T) {
+	defaults := kalshi.DefaultRiskClassifier()
+	kalshi.SetRiskClassifier(defaults.WithOverrides(nil, []string{"KXHIGHNY"}, nil))
+	t.Cleanup(func() { kalshi.SetRiskClassifier(defaults) })
+
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "KXHIGHNY-25JAN01", "series_ticker": "KXHIGHNY", "category": "Climate"}}`))
+	})
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/KXHIGHNY-25JAN01/risk", nil), map[string]string{"ticker": "KXHIGHNY-25JAN01"})
+	rec := httptest.NewRecorder()
+	h.GetMarketRisk(rec, req)
+
+	var resp struct {
+		Data struct {
+			RiskCategory string `json:"risk_category"`
+			Reason       string `json:"reason"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Data.RiskCategory != kalshi.RiskLow || resp.Data.Reason == "" {
+		t.Errorf("expected low risk with reason, got %+v", resp.Data)
+	}
+}
+
+func TestGetSettlements_ScopedToUser(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	alice := tradingUser(t, h.store, "alice@example.com", "FED-24DEC")
+	bob := tradingUser(t, h.store, "bob@example.com", "FED-24DEC")
+	tradingUser(t, h.store, "alice2@example.com", "CPI-24NOV")
+	h.store.SettleMarket("FED-24DEC", "yes", "Fed announcement")
+	h.store.SettleMarket("CPI-24NOV", "no", "BLS release")
+
+	req := httptest.NewRequest("GET", "/api/v1/settlements?ticker=FED-24DEC", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: alice}))
+	rec := httptest.NewRecorder()
+	h.GetSettlements(rec, req)
+
+	var resp struct {
+		Data []models.Settlement `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if len(resp.Data) != 1 {
+		t.Fatalf("expected 1 settlement for alice, got %d", len(resp.Data))
+	}
+	got := resp.Data[0]
+	if got.UserID != alice || got.Result != "yes" || got.PayoutUSD != models.Dollars(10) || got.Reason != "Fed announcement" {
+		t.Errorf("unexpected settlement: %+v", got)
+	}
+
+	// Bob's settlement in the same market must not leak to alice.
+	if s := h.store.GetSettlements(bob, "", 10); len(s) != 1 || s[0].UserID != bob {
+		t.Errorf("expected bob's own settlement only, got %+v", s)
+	}
+}
+
+func TestAdminSettlements_RequiresAdmin(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	tradingUser(t, h.store, "alice@example.com", "FED-24DEC")
+	tradingUser(t, h.store, "bob@example.com", "FED-24DEC")
+	h.store.SettleMarket("FED-24DEC", "no", "Fed announcement")
+
+	auth.SetAdminUserIDs([]string{"user_ops"})
+	t.Cleanup(func() { auth.SetAdminUserIDs(nil) })
+	router := NewRouter(h)
+
+	tests := []struct {
+		userID     string
+		email      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"user_alice", "alice@example.com", http.StatusForbidden, 0},
+		{"user_signup", "ops@example.com", http.StatusForbidden, 0}, // email alone grants nothing
+		{"user_ops", "ops@example.com", http.StatusOK, 2},
+	}
+	for _, tt := range tests {
+		token, err := auth.GenerateToken(tt.userID, tt.email, "verified", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest("GET", "/api/v1/admin/settlements", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.userID, rec.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			Data []models.Settlement `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if len(resp.Data) != tt.wantCount {
+			t.Errorf("%s: got %d settlements, want %d", tt.userID, len(resp.Data), tt.wantCount)
+		}
+	}
+}
+
+func TestPlaceOrder_RateLimitCountsOnlyAcceptedOrders(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "event_ticker": "FED", "status": "open"}}`))
+	})
+	userID := tradingUser(t, h.store, "rate@example.com", "OTHER-MKT")
+	claims := &auth.Claims{UserID: userID}
+
+	placeOrder := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+		rec := httptest.NewRecorder()
+		h.PlaceOrder(rec, req)
+		return rec
+	}
+
+	// Pre-trade checks and rejected orders do not use the budget.
+	for i := 0; i < 100; i++ {
+		h.surveillance.ValidateOrder(userID, "FED-24DEC", models.OrderSideYes, 1, 50)
+		placeOrder(`{"market_ticker": "FED-24DEC", "side": "maybe", "quantity": 1, "price_cents": 50}`)
+	}
+
+	for i := 1; i <= 60; i++ {
+		if rec := placeOrder(`{"market_ticker": "FED-24DEC", "side": "yes", "quantity": 1, "price_cents": 50}`); rec.Code != http.StatusOK {
+			t.Fatalf("order %d rejected with %d: %s", i, rec.Code, rec.Body.String())
+		}
+	}
+	rec := placeOrder(`{"market_ticker": "FED-24DEC", "side": "yes", "quantity": 1, "price_cents": 50}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("61st order: status = %d, want 429", rec.Code)
+	}
+}
+
+func TestPlaceOrder_FillsThroughStrategy(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "event_ticker": "FED", "status": "open"}}`))
+	})
+	userID := tradingUser(t, h.store, "fill@example.com", "OTHER-MKT")
+
+	var scheduled []func()
+	h.SetFillStrategy(fillFunc(func(fill func()) { scheduled = append(scheduled, fill) }))
+
+	req := httptest.NewRequest("POST", "/api/v1/orders",
+		strings.NewReader(`{"market_ticker": "FED-24DEC", "side": "yes", "quantity": 5, "price_cents": 45}`))
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+	rec := httptest.NewRecorder()
+	h.PlaceOrder(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	pending := models.OrderStatusPending
+	if orders, _ := h.store.GetOrders(userID, &pending, 10); len(orders) != 1 || len(scheduled) != 1 {
+		t.Fatalf("before fill: %d pending orders, %d scheduled fills", len(orders), len(scheduled))
+	}
+
+	scheduled[0]()
+	filled := models.OrderStatusFilled
+	orders, _ := h.store.GetOrders(userID, &filled, 10)
+	var order *models.Order
+	for i := range orders {
+		if orders[i].MarketTicker == "FED-24DEC" {
+			order = &orders[i]
+		}
+	}
+	if order == nil || order.FilledQuantity != 5 || order.FilledPriceCents != 45 {
+		t.Fatalf("order after fill = %+v", order)
+	}
+}
+
+// fillFunc adapts a function to FillStrategy.
+type fillFunc func(fill func())
+
+func (f fillFunc) ScheduleFill(fill func()) { f(fill) }
+
+func TestDelayedFills_UsesClock(t *testing.T) {
+	var gotDelay time.Duration
+	var fire func()
+	d := DelayedFills{Delay: time.Second, AfterFunc: func(delay time.Duration, f func()) *time.Timer {
+		gotDelay, fire = delay, f
+		return nil
+	}}
+	ran := false
+	d.ScheduleFill(func() { ran = true })
+	if gotDelay != time.Second || ran {
+		t.Fatalf("delay = %v, ran early = %v", gotDelay, ran)
+	}
+	fire()
+	if !ran {
+		t.Error("fill did not run when the clock fired")
+	}
+}
+
+func TestAmendOrder_KeepsOmittedFields(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "amend@example.com", "OTHER-MKT")
+	order, err := h.store.CreateOrder(userID, "FED-24DEC", "FED", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("PATCH", "/api/v1/orders/"+order.ID, strings.NewReader(`{"quantity": 25}`))
+	req = mux.SetURLVars(req, map[string]string{"id": order.ID})
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+	rec := httptest.NewRecorder()
+	h.AmendOrder(rec, req)
+
+	var resp struct {
+		Data struct {
+			Order models.Order `json:"order"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if resp.Data.Order.Quantity != 25 || resp.Data.Order.PriceCents != 40 {
+		t.Errorf("amended order = %d @ %d¢, want 25 @ 40¢", resp.Data.Order.Quantity, resp.Data.Order.PriceCents)
+	}
+}
+
+func TestAdminUpdateAlertStatus_EscalationSuggestsHalt(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	alert := h.store.CreateComplianceAlert("user_1", "FED-24DEC", "wash_trade", "high", "test alert")
+	claims := &auth.Claims{UserID: "ops_1"}
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/admin/alerts/"+alert.ID+"/status", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": alert.ID})
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+		rec := httptest.NewRecorder()
+		h.AdminUpdateAlertStatus(rec, req)
+		return rec
+	}
+
+	if rec := update(`{"status": "escalated"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("open -> escalated: status = %d, want 409", rec.Code)
+	}
+	if rec := update(`{"status": "investigating"}`); rec.Code != http.StatusOK {
+		t.Fatalf("open -> investigating: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := update(`{"status": "escalated", "notes": "coordinated", "suggest_halt": true}`); rec.Code != http.StatusOK {
+		t.Fatalf("investigating -> escalated: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	suggestions := h.store.GetComplianceAlerts("open", "critical", 10)
+	if len(suggestions) != 1 || suggestions[0].Type != "halt_suggested" || suggestions[0].MarketTicker != "FED-24DEC" {
+		t.Errorf("expected one halt_suggested alert for FED-24DEC, got %+v", suggestions)
+	}
+}
+
+func TestAdminGetAlerts_SeveritySortSurfacesCritical(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.store.CreateComplianceAlert("user_1", "FED-24DEC", "position_limit_suspension", "critical", "oldest but critical")
+	for i, severity := range []string{"low", "high", "medium", "low"} {
+		h.store.CreateComplianceAlert(fmt.Sprintf("user_%d", i+2), "FED-24DEC", "rate_limit", severity, "noise")
+	}
+
+	list := func(query string) (int, []models.ComplianceAlert) {
+		rec := httptest.NewRecorder()
+		h.AdminGetAlerts(rec, httptest.NewRequest("GET", "/api/v1/admin/alerts"+query, nil))
+		var resp struct {
+			Data []models.ComplianceAlert `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp.Data
+	}
+
+	_, alerts := list("?limit=3")
+	if len(alerts) != 3 || alerts[0].Severity != "low" {
+		t.Fatalf("time sort = %+v, want the newest low alert first", alerts)
+	}
+
+	_, alerts = list("?sort=severity&limit=3")
+	var got []string
+	for _, alert := range alerts {
+		got = append(got, alert.Severity)
+	}
+	if strings.Join(got, ",") != "critical,high,medium" {
+		t.Errorf("severity sort = %v, want critical,high,medium", got)
+	}
+
+	if code, _ := list("?sort=loudest"); code != http.StatusBadRequest {
+		t.Errorf("unknown sort: status = %d, want 400", code)
+	}
+}
+
+func TestGetPendingTransactions_ShowsHeldWithdrawal(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.store.SetWithdrawalHold(time.Hour)
+	userID := tradingUser(t, h.store, "pending@example.com", "OTHER-MKT")
+	claims := &auth.Claims{UserID: userID}
+
+	req := httptest.NewRequest("POST", "/api/v1/wallet/withdraw", strings.NewReader(`{"amount_usd": 20}`))
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+	rec := httptest.NewRecorder()
+	h.Withdraw(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("withdraw status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/wallet/pending", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+	rec = httptest.NewRecorder()
+	h.GetPendingTransactions(rec, req)
+
+	var resp struct {
+		Data []models.Transaction `json:"data"`
+		Meta struct {
+			PendingUSD float64 `json:"pending_usd"`
+		} `json:"meta"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if len(resp.Data) != 1 || resp.Data[0].Type != models.TxTypeWithdrawal || resp.Meta.PendingUSD != 20 {
+		t.Errorf("pending = %+v, pending_usd = %.2f", resp.Data, resp.Meta.PendingUSD)
+	}
+}
+
+func TestSignup_CreatesNotStartedKYCRecord(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	body := `{"email": "new@example.com", "password": "secret123", "first_name": "N", "last_name": "User",
+		"date_of_birth": "1990-01-01", "state_code": "NY", "is_us_resident": true}`
+	rec := httptest.NewRecorder()
+	h.Signup(rec, httptest.NewRequest("POST", "/api/v1/auth/signup", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("signup status = %d: %s", rec.Code, rec.Body.String())
+	}
+	user, err := h.store.GetUserByEmail("new@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/kyc", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: user.ID}))
+	rec = httptest.NewRecorder()
+	h.GetKYCStatus(rec, req)
+
+	var resp struct {
+		Data models.KYCRecord `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Data.ID == "" || resp.Data.UserID != user.ID || resp.Data.Status != models.KYCStatusNotStarted {
+		t.Errorf("KYC status = %+v, want a stored not_started record", resp.Data)
+	}
+}
+
+func TestLogin_CookieModeReturnsCSRFToken(t *testing.T) {
+	auth.SetCookieAuth(true)
+	defer auth.SetCookieAuth(false)
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	body := `{"email": "new@example.com", "password": "secret123", "first_name": "N", "last_name": "User",
+		"date_of_birth": "1990-01-01", "state_code": "NY", "is_us_resident": true}`
+	h.Signup(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/auth/signup", strings.NewReader(body)))
+
+	rec := httptest.NewRecorder()
+	h.Login(rec, httptest.NewRequest("POST", "/api/v1/auth/login",
+		strings.NewReader(`{"email": "new@example.com", "password": "secret123"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			CSRFToken string `json:"csrf_token"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	var csrfCookie string
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == auth.CSRFCookieName {
+			csrfCookie = c.Value
+		}
+	}
+	if resp.Data.CSRFToken == "" || resp.Data.CSRFToken != csrfCookie {
+		t.Errorf("csrf_token = %q, cookie = %q", resp.Data.CSRFToken, csrfCookie)
+	}
+}
+
+func TestGetProfile_MasksPersonalData(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	user, err := h.store.CreateUser("jane.doe@example.com", "hash", "J", "Doe", "NY", time.Date(1987, 6, 15, 0, 0, 0, 0, time.UTC), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.store.RecordLogin(user.ID, "203.0.113.7")
+
+	req := httptest.NewRequest("GET", "/api/v1/auth/profile", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: user.ID}))
+	rec := httptest.NewRecorder()
+	h.GetProfile(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("profile = %d: %s", rec.Code, body)
+	}
+	for _, leaked := range []string{"1987-06-15", "jane.doe@", "203.0.113.7"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("profile leaks %q: %s", leaked, body)
+		}
+	}
+	var resp struct {
+		Data struct {
+			User struct {
+				ID          string `json:"id"`
+				Email       string `json:"email"`
+				DateOfBirth string `json:"date_of_birth"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &resp)
+	if got := resp.Data.User; got.ID != user.ID || got.Email != "j***@example.com" || got.DateOfBirth != "1987" {
+		t.Errorf("profile user = %+v, want masked email and birth year", got)
+	}
+
+	// Compliance still sees the full record
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/admin/users/"+user.ID, nil), map[string]string{"id": user.ID})
+	rec = httptest.NewRecorder()
+	h.AdminGetUser(rec, req)
+	if !strings.Contains(rec.Body.String(), "1987-06-15") || !strings.Contains(rec.Body.String(), "jane.doe@example.com") {
+		t.Errorf("admin view is masked: %s", rec.Body.String())
+	}
+
+	for _, entry := range h.store.GetAuditLog(user.ID, time.Time{}, 100) {
+		if strings.Contains(entry.NewValue, "1987-06-15") || strings.Contains(entry.OldValue, "1987-06-15") {
+			t.Errorf("audit %s %s leaks the date of birth: %s", entry.Action, entry.EntityType, entry.NewValue)
+		}
+	}
+}
+
+func TestAdminReconcileWallets_FlagsDiscrepancy(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	good := tradingUser(t, h.store, "recon-good@example.com", "FED-24DEC")
+	bad := tradingUser(t, h.store, "recon-bad@example.com", "FED-24DEC")
+	// Credit funds without a transaction or journal entry
+	wallet, err := h.store.GetWallet(bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet.AvailableUSD += models.Dollars(500)
+
+	get := func(query string) ([]models.ReconResult, map[string]float64) {
+		rec := serveAs(h.AdminReconcileWallets, "GET", "/api/v1/admin/reconciliation?"+query, "", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Data []models.ReconResult `json:"data"`
+			Meta map[string]float64   `json:"meta"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp.Data, resp.Meta
+	}
+
+	results, meta := get("")
+	if len(results) != 2 || meta["count"] != 2 || meta["mismatched"] != 1 {
+		t.Fatalf("all wallets = %+v meta %v, want 2 with 1 mismatched", results, meta)
+	}
+	results, _ = get("mismatched=true")
+	if len(results) != 1 || results[0].UserID != bad || results[0].Balanced || len(results[0].Discrepancies) == 0 {
+		t.Errorf("mismatched = %+v, want only %s", results, bad)
+	}
+	if results, _ = get("user_id=" + good); len(results) != 1 || !results[0].Balanced {
+		t.Errorf("good wallet = %+v, want balanced", results)
+	}
+
+	if rec := serveAs(h.AdminReconcileWallets, "GET", "/api/v1/admin/reconciliation?user_id=user_missing", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user = %d, want 404", rec.Code)
+	}
+}
+
+func TestAdminGetLedger_BalancesToZero(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "ledger@example.com", "FED-24DEC")
+	tradingUser(t, h.store, "other@example.com", "FED-24DEC")
+
+	rec := serveAs(h.AdminGetLedger, "GET", "/api/v1/admin/ledger?user_id="+userID, "", "")
+	var resp struct {
+		Data []models.JournalEntry `json:"data"`
+		Meta struct {
+			Balances map[models.LedgerAccount]models.Money `json:"balances"`
+		} `json:"meta"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK || len(resp.Data) == 0 {
+		t.Fatalf("ledger = %d with %d entries", rec.Code, len(resp.Data))
+	}
+	var total models.Money
+	for _, entry := range resp.Data {
+		if entry.UserID != userID {
+			t.Errorf("entry %s belongs to %s", entry.ID, entry.UserID)
+		}
+		for _, posting := range entry.Postings {
+			total += posting.AmountUSD
+		}
+	}
+	wallet, _ := h.store.GetWallet(userID)
+	if total != 0 || resp.Meta.Balances[models.AccountCustomerAvailable] != wallet.AvailableUSD ||
+		resp.Meta.Balances[models.AccountCustomerLocked] != wallet.LockedUSD {
+		t.Errorf("postings net %s, balances %v; wallet %s available, %s locked",
+			total, resp.Meta.Balances, wallet.AvailableUSD, wallet.LockedUSD)
+	}
+}
+
+func TestRefreshClaims_PicksUpKYCApproval(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	user, err := h.store.CreateUser("pending@example.com", "hash", "P", "User", "NY", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.store.StartKYCRecord(user.ID, "127.0.0.1")
+	h.store.UpdateUserStatus(user.ID, models.UserStatusKYCPending, "system")
+	token, _ := auth.GenerateToken(user.ID, user.Email, string(models.UserStatusKYCPending), false)
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.store.MockKYCApproval(user.ID, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/api/v1/auth/refresh-claims", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+	rec := httptest.NewRecorder()
+	h.RefreshClaims(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	refreshed, err := auth.ValidateToken(resp.Data.Token)
+	if err != nil {
+		t.Fatalf("reissued token invalid: %v", err)
+	}
+	if !refreshed.Verified || refreshed.Status != string(models.UserStatusVerified) || refreshed.UserID != user.ID {
+		t.Errorf("reissued claims = %+v, want a verified token for %s", refreshed, user.ID)
+	}
+
+	var audited bool
+	for _, entry := range h.store.GetAuditLog(user.ID, time.Time{}, 10) {
+		if strings.Contains(entry.Description, "Token reissued: status kyc_pending -> verified") {
+			audited = entry.Action == models.AuditActionRefresh
+		}
+	}
+	if !audited {
+		t.Error("reissue not in the audit log")
+	}
+}
+
+func TestDecodeJSON_RejectsOversizedBody(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.SetMaxBodyBytes(64)
+
+	body := `{"email": "new@example.com", "password": "` + strings.Repeat("x", 100) + `"}`
+	rec := httptest.NewRecorder()
+	h.Login(rec, httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(body)))
+
+	var resp APIResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusRequestEntityTooLarge || resp.Code != "REQUEST_TOO_LARGE" {
+		t.Errorf("status = %d, code = %q, want 413 REQUEST_TOO_LARGE", rec.Code, resp.Code)
+	}
+}
+
+func TestDecodeJSON_RejectsUnknownField(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "strict@example.com", "KXTEST")
+
+	body := `{"market_ticker": "KXTEST", "side": "yes", "type": "limit", "quantity": 1, "price_cents": 50, "leverage": 10}`
+	req := httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+	rec := httptest.NewRecorder()
+	h.PlaceOrder(rec, req)
+
+	var resp APIResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusBadRequest || resp.Code != "INVALID_REQUEST" || !strings.Contains(resp.Error, "leverage") {
+		t.Errorf("status = %d, resp = %+v, want 400 INVALID_REQUEST naming the field", rec.Code, resp)
+	}
+	if orders, _ := h.store.GetOrders(userID, nil, 0); len(orders) != 0 {
+		t.Errorf("orders = %d, want none placed", len(orders))
+	}
+}
+
+func TestPlaceOrderRequest_ValidateReportsAllFields(t *testing.T) {
+	err := PlaceOrderRequest{Side: "maybe", Type: "stop", Quantity: 0, PriceCents: 100}.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() = %v, want ValidationErrors", err)
+	}
+	want := []string{"market_ticker", "side", "type", "quantity", "price_cents"}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %+v, want %d", errs, len(want))
+	}
+	for i, field := range want {
+		if errs[i].Field != field {
+			t.Errorf("errs[%d].Field = %q, want %q", i, errs[i].Field, field)
+		}
+	}
+
+	valid := PlaceOrderRequest{MarketTicker: "KXTEST", Side: "yes", Quantity: 1, PriceCents: 50}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid order: %v", err)
+	}
+}
+
+func TestPreTradeCheckAndPlaceOrder_SameValidation(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "rules@example.com", "KXTEST")
+	body := `{"market_ticker": "KXTEST", "side": "yes", "quantity": 5000, "price_cents": 0}`
+
+	for name, handle := range map[string]http.HandlerFunc{
+		"check": h.PreTradeCheck,
+		"place": h.PlaceOrder,
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+
+		var resp struct {
+			Code string `json:"code"`
+			Meta struct {
+				FieldErrors []FieldError `json:"field_errors"`
+			} `json:"meta"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusBadRequest || resp.Code != "INVALID_ORDER" || len(resp.Meta.FieldErrors) != 2 {
+			t.Errorf("%s: status = %d, resp = %+v, want 400 INVALID_ORDER with 2 field errors", name, rec.Code, resp)
+		}
+	}
+}
+
+func TestGetMarketStatus_MarketAndGlobalHalts(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	status := func(ticker string) MarketTradingStatus {
+		t.Helper()
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/markets/"+ticker+"/status", nil), map[string]string{"ticker": ticker})
+		rec := httptest.NewRecorder()
+		h.GetMarketStatus(rec, req)
+		var resp struct {
+			Data MarketTradingStatus `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	if got := status("KXA"); got.Status != "open" || got.Halt != nil {
+		t.Errorf("before halt: %+v, want open", got)
+	}
+
+	h.store.InitiateEmergencyHalt("KXA", "price spike", "ops", 0)
+	if got := status("KXA"); got.Status != "halted" || got.Halt == nil || got.Halt.Scope != "market" || got.Halt.Reason != "price spike" {
+		t.Errorf("market halt: %+v", got)
+	}
+	if got := status("KXB"); got.Status != "open" {
+		t.Errorf("other market: %+v, want open", got)
+	}
+
+	h.store.InitiateEmergencyHalt("", "exchange outage", "ops", 0)
+	if got := status("KXB"); got.Status != "halted" || got.Halt.Scope != "global" || got.Halt.StartedAt.IsZero() {
+		t.Errorf("global halt: %+v", got)
+	}
+}
+
+func TestGetExchangeStatus_ReportsGlobalHalt(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.store.InitiateEmergencyHalt("KXA", "price spike", "ops", 0)
+
+	get := func() ExchangeStatus {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.GetExchangeStatus(rec, httptest.NewRequest("GET", "/api/v1/status", nil))
+		var resp struct {
+			Data ExchangeStatus `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	if got := get(); got.GlobalHalt || len(got.HaltedMarkets) != 1 || got.HaltedMarkets[0] != "KXA" {
+		t.Errorf("market halt only: %+v", got)
+	}
+
+	h.store.InitiateEmergencyHalt("", "exchange outage", "ops", 0)
+	if got := get(); !got.GlobalHalt || got.Halt == nil || got.Halt.Reason != "exchange outage" {
+		t.Errorf("global halt: %+v", got)
+	}
+
+	h.store.LiftEmergencyHalt("", "ops")
+	if got := get(); got.GlobalHalt {
+		t.Errorf("after lift: %+v, want no global halt", got)
+	}
+}
+
+func TestAdminPositionLimits_RaisedLimitAppliesImmediately(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "limits@example.com", "FED-24DEC") // $4.00 locked
+
+	call := func(handler http.HandlerFunc, method, path, body, asUser string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: asUser}))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	setBasic := func(maxUSD float64) *httptest.ResponseRecorder {
+		tiers := h.store.GetPositionLimits()
+		for i := range tiers {
+			if tiers[i].Tier == models.DefaultTier {
+				tiers[i].MaxPositionUSD = models.Dollars(maxUSD)
+			}
+		}
+		body, _ := json.Marshal(PositionLimitsRequest{Tiers: tiers})
+		return call(h.AdminUpdatePositionLimits, "PUT", "/api/v1/admin/limits", string(body), "ops_1")
+	}
+	passes := func() bool {
+		rec := call(h.PreTradeCheck, "POST", "/api/v1/orders/check",
+			`{"market_ticker": "FED-24DEC", "side": "yes", "type": "limit", "quantity": 10, "price_cents": 40}`, userID)
+		var resp struct {
+			Data compliance.PreTradeCheck `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp.Data.Passed
+	}
+
+	if rec := setBasic(5); rec.Code != http.StatusOK {
+		t.Fatalf("lowering limit: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if passes() {
+		t.Fatal("$4 order passed with $4 locked against a $5 limit")
+	}
+	if rec := setBasic(20); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"users_updated":1`) {
+		t.Fatalf("raising limit: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if !passes() {
+		t.Error("raised limit did not take effect for the next check")
+	}
+
+	rec := call(h.AdminUpdatePositionLimits, "PUT", "/api/v1/admin/limits",
+		`{"tiers": [{"tier": "pro", "max_position_usd": 1, "max_order_size": 1, "daily_volume_usd": 1, "max_open_orders": 1}]}`, "ops_1")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "INVALID_LIMITS") {
+		t.Errorf("table without the basic tier: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = call(h.AdminGetPositionLimits, "GET", "/api/v1/admin/limits", "", "ops_1")
+	var resp struct {
+		Data []models.PositionLimitConfig `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if basic := models.TierLimits(resp.Data, models.DefaultTier); basic.MaxPositionUSD != models.Dollars(20) {
+		t.Errorf("live basic limit = %s, want $20", basic.MaxPositionUSD)
+	}
+}
+
+func TestAdminListUsers_FiltersByStatusAndState(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	tradingUser(t, h.store, "alice@example.com", "FED-24DEC")
+	bob := tradingUser(t, h.store, "bob@example.com", "FED-24DEC")
+	if err := h.store.UpdateUserStatus(bob, models.UserStatusSuspended, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(query string) (*httptest.ResponseRecorder, []models.UserSummary) {
+		req := httptest.NewRequest("GET", "/api/v1/admin/users"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: "ops_1"}))
+		rec := httptest.NewRecorder()
+		h.AdminListUsers(rec, req)
+		var resp struct {
+			Data []models.UserSummary `json:"data"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp.Data
+	}
+
+	rec, users := list("?status=suspended")
+	if rec.Code != http.StatusOK || len(users) != 1 || users[0].ID != bob {
+		t.Fatalf("status=suspended: %d %+v", rec.Code, users)
+	}
+	if rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("X-Total-Count = %q, want 1", rec.Header().Get("X-Total-Count"))
+	}
+	if users[0].ExposureUSD != models.Dollars(4) || users[0].OpenPositions != 1 {
+		t.Errorf("bob summary = %+v, want $4.00 exposure on 1 position", users[0])
+	}
+
+	if _, users := list("?state=ny&limit=1"); len(users) != 1 {
+		t.Errorf("state=ny&limit=1 returned %d users", len(users))
+	}
+	if rec, _ := list("?state=ny&limit=1&offset=1"); rec.Header().Get("X-Total-Count") != "2" ||
+		!strings.Contains(rec.Body.String(), `"has_more":false`) {
+		t.Errorf("second page: %s %s", rec.Header().Get("X-Total-Count"), rec.Body.String())
+	}
+	if _, users := list("?state=CA"); len(users) != 0 {
+		t.Errorf("state=CA returned %+v", users)
+	}
+	if rec, _ := list("?status=zombie"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown status: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestAdminGetUser_AggregatesAccountView(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "event_ticker": "FED", "status": "open", "yes_bid": 55, "no_bid": 43}}`))
+	})
+	userID := tradingUser(t, h.store, "detail@example.com", "FED-24DEC") // 10 YES @ 40
+	h.store.CreateComplianceAlert(userID, "FED-24DEC", "unusual_volume", "medium", "test")
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/v1/admin/users/"+id, nil), map[string]string{"id": id})
+		rec := httptest.NewRecorder()
+		h.AdminGetUser(rec, req)
+		return rec
+	}
+
+	rec := get(userID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data AdminUserDetail `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	d := resp.Data
+	if d.User == nil || d.User.ID != userID || d.KYC == nil || d.Wallet == nil {
+		t.Fatalf("missing profile sections: %+v", d)
+	}
+	if len(d.Positions) != 1 || d.Positions[0].CurrentValue != models.Dollars(5.50) || d.UnrealizedPnL != models.Dollars(1.50) {
+		t.Errorf("positions = %+v, pnl %s; want 10 YES marked at 55c for +$1.50", d.Positions, d.UnrealizedPnL)
+	}
+	if d.Limits.ExposureUSD != models.Dollars(4) || d.Limits.PositionLimitUSD != d.User.PositionLimitUSD || d.Limits.Utilization <= 0 {
+		t.Errorf("limits = %+v, want $4.00 exposure against the user's limit", d.Limits)
+	}
+	if len(d.RecentOrders) != 1 || d.RecentOrders[0].Status != models.OrderStatusFilled {
+		t.Errorf("recent orders = %+v, want the one fill", d.RecentOrders)
+	}
+	if len(d.RecentAlerts) != 1 || d.RecentAlerts[0].Type != "unusual_volume" {
+		t.Errorf("recent alerts = %+v, want the one alert", d.RecentAlerts)
+	}
+
+	if rec := get("user_missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestAdminUpdateUserStatus_SuspendAndLiquidate(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "suspend@example.com", "FED-24DEC") // $4.00 locked
+	admin := &auth.Claims{UserID: "admin_1"}
+
+	post := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/admin/users/"+id+"/status", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, admin))
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rec := httptest.NewRecorder()
+		h.AdminUpdateUserStatus(rec, req)
+		return rec
+	}
+
+	if rec := post(userID, `{"status": "reinstated", "reason": "appeal", "liquidate": true}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("liquidating a reinstatement: status = %d, want 400", rec.Code)
+	}
+	if rec := post(userID, `{"status": "verified", "reason": "appeal"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("setting verified directly: status = %d, want 400", rec.Code)
+	}
+	if rec := post(userID, `{"status": "suspended"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing reason: status = %d, want 400", rec.Code)
+	}
+
+	rec := post(userID, `{"status": "suspended", "reason": "spoofing review", "liquidate": true}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"positions_closed":1`) {
+		t.Fatalf("suspend with liquidation: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	user, _ := h.store.GetUser(userID)
+	wallet, _ := h.store.GetWallet(userID)
+	positions, _ := h.store.GetPositions(userID)
+	if user.Status != models.UserStatusSuspended || wallet.LockedUSD != 0 || len(positions) != 0 {
+		t.Errorf("after liquidation: status %s, locked %s, %d positions; want suspended and flat",
+			user.Status, wallet.LockedUSD, len(positions))
+	}
+	audit := h.store.GetAuditLog("admin_1", time.Time{}, 10)
+	if len(audit) == 0 || !strings.Contains(audit[len(audit)-1].Description, "by admin_1: spoofing review") {
+		t.Errorf("admin audit = %+v, want the suspension by admin_1 with its reason", audit)
+	}
+
+	// A user who never passed KYC is reinstated to where they were, not verified
+	pending, err := h.store.CreateUser("pending@example.com", "hash", "Pat", "Pending", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	post(pending.ID, `{"status": "banned", "reason": "synthetic identity"}`)
+	if rec := post(pending.ID, `{"status": "reinstated", "reason": "appeal"}`); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"status":"`+string(pending.Status)+`"`) {
+		t.Errorf("reinstate pending user: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(pending.ID, `{"status": "reinstated", "reason": "again"}`); rec.Code != http.StatusConflict {
+		t.Errorf("reinstating an active user: status = %d, want 409", rec.Code)
+	}
+}
+
+func TestPortfolioWhatIf_MatchesActualPlacement(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "event_ticker": "FED", "status": "open"}}`))
+	})
+	h.store.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.01)})
+	userID := tradingUser(t, h.store, "whatif@example.com", "FED-24DEC") // 10 YES @ 40, $4.00 locked
+	claims := &auth.Claims{UserID: userID}
+
+	call := func(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, claims))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	orders := []struct {
+		name, body string
+		exposure   float64
+	}{
+		{"adds to the YES position", `{"market_ticker": "FED-24DEC", "side": "yes", "type": "limit", "quantity": 20, "price_cents": 50}`, 14},
+		{"hedges with NO", `{"market_ticker": "FED-24DEC", "side": "no", "type": "limit", "quantity": 10, "price_cents": 45}`, 9.50},
+	}
+	for _, tt := range orders {
+		wallet, _ := h.store.GetWallet(userID)
+		availableBefore, lockedBefore := wallet.AvailableUSD, wallet.LockedUSD
+
+		rec := call(h.PortfolioWhatIf, "/api/v1/portfolio/whatif", tt.body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: whatif status = %d: %s", tt.name, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Data WhatIfResult `json:"data"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		got := resp.Data
+		if got.Check == nil || !got.Check.Passed || got.ProjectedExposureUSD != models.Dollars(tt.exposure) {
+			t.Errorf("%s: projection = %+v, want $%.2f exposure", tt.name, got, tt.exposure)
+		}
+		if wantRemaining := got.PositionLimitUSD - got.ProjectedExposureUSD; got.RemainingLimitUSD != wantRemaining {
+			t.Errorf("%s: remaining = %s, want %s", tt.name, got.RemainingLimitUSD, wantRemaining)
+		}
+		if wallet, _ := h.store.GetWallet(userID); wallet.AvailableUSD != availableBefore || wallet.LockedUSD != lockedBefore {
+			t.Fatalf("%s: whatif changed the wallet", tt.name)
+		}
+
+		if rec := call(h.PlaceOrder, "/api/v1/orders", tt.body); rec.Code != http.StatusOK {
+			t.Fatalf("%s: place status = %d: %s", tt.name, rec.Code, rec.Body.String())
+		}
+		wallet, _ = h.store.GetWallet(userID)
+		if actual := h.store.GetUserExposure(userID); actual != got.ProjectedExposureUSD {
+			t.Errorf("%s: actual exposure %s, projected %s", tt.name, actual, got.ProjectedExposureUSD)
+		}
+		if wallet.AvailableUSD != got.AvailableAfterUSD {
+			t.Errorf("%s: actual available %s, projected %s", tt.name, wallet.AvailableUSD, got.AvailableAfterUSD)
+		}
+	}
+}
+
+func TestClosePosition_AtBidWithCostBasisMethod(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "event_ticker": "FED", "status": "open", "yes_bid": 55, "no_bid": 43}}`))
+	})
+	h.store.SetCostBasisMethod(mock.CostBasisFIFO)
+	userID := tradingUser(t, h.store, "close@example.com", "FED-24DEC") // 10 YES @ 40
+	positions, _ := h.store.GetPositions(userID)
+	posID := positions[0].ID
+
+	call := func(handler http.HandlerFunc, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": posID})
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	if rec := call(h.ClosePosition, "POST", "/api/v1/positions/"+posID+"/close", `{"quantity": 11}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("closing 11 of 10: status = %d, want 400", rec.Code)
+	}
+	rec := call(h.ClosePosition, "POST", "/api/v1/positions/"+posID+"/close", `{"quantity": 4}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data models.Position        `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Data.Quantity != 6 || resp.Data.RealizedPnL != models.Dollars(0.60) || resp.Meta["cost_basis_method"] != "fifo" {
+		t.Errorf("close = %+v meta %v, want 6 left, $0.60 realized at 55¢ under fifo", resp.Data, resp.Meta)
+	}
+
+	rec = call(h.GetPortfolioSummary, "GET", "/api/v1/portfolio", "")
+	if !strings.Contains(rec.Body.String(), `"cost_basis_method":"fifo"`) {
+		t.Errorf("portfolio does not report the method: %s", rec.Body.String())
+	}
+}
+
+func TestCloseMarketPositions_AggregatesLegs(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"market": {"ticker": "FED-24DEC", "event_ticker": "FED", "status": "open", "yes_bid": 55, "no_bid": 43}}`))
+	})
+	userID := tradingUser(t, h.store, "closeall@example.com", "FED-24DEC") // 10 YES @ 40
+	order, err := h.store.CreateOrder(userID, "FED-24DEC", "FED", models.OrderSideNo, models.OrderTypeLimit, 10, 30, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.store.MockFillOrder(order.ID, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/positions/close"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+		rec := httptest.NewRecorder()
+		h.CloseMarketPositions(rec, req)
+		return rec
+	}
+
+	if rec := call(""); rec.Code != http.StatusBadRequest {
+		t.Errorf("without market_ticker: status = %d, want 400", rec.Code)
+	}
+	rec := call("?market_ticker=FED-24DEC")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data mock.MarketClose `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Data.Legs) != 2 || resp.Data.ProceedsUSD != models.Dollars(9.80) || resp.Data.RealizedPnLUSD != models.Dollars(-1.20) {
+		t.Errorf("close = %+v, want two legs, $9.80 proceeds and -$1.20 realized", resp.Data)
+	}
+	if rec := call("?market_ticker=FED-24DEC"); rec.Code != http.StatusNotFound {
+		t.Errorf("nothing left to close: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestStoreError_MapsEverySentinel(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{mock.ErrUserNotFound, http.StatusNotFound, "USER_NOT_FOUND"},
+		{mock.ErrUserExists, http.StatusConflict, "USER_EXISTS"},
+		{mock.ErrWalletNotFound, http.StatusNotFound, "WALLET_NOT_FOUND"},
+		{mock.ErrInsufficientFunds, http.StatusBadRequest, "INSUFFICIENT_FUNDS"},
+		{mock.ErrOrderNotFound, http.StatusNotFound, "ORDER_NOT_FOUND"},
+		{mock.ErrPositionNotFound, http.StatusNotFound, "POSITION_NOT_FOUND"},
+		{mock.ErrKYCRequired, http.StatusForbidden, "KYC_REQUIRED"},
+		{mock.ErrUserSuspended, http.StatusForbidden, "ACCOUNT_SUSPENDED"},
+		{mock.ErrMarketClosed, http.StatusBadRequest, "MARKET_CLOSED"},
+		{mock.ErrPositionLimitExceeded, http.StatusBadRequest, "POSITION_LIMIT"},
+		{mock.ErrTradingHalted, http.StatusServiceUnavailable, "TRADING_HALTED"},
+		{mock.ErrMarketAlreadySettled, http.StatusConflict, "MARKET_SETTLED"},
+		{mock.ErrInvalidResult, http.StatusBadRequest, "INVALID_REQUEST"},
+		{mock.ErrOrderNotFillable, http.StatusConflict, "ORDER_NOT_FILLABLE"},
+		{mock.ErrInvalidFillQuantity, http.StatusBadRequest, "INVALID_QUANTITY"},
+		{mock.ErrOrderNotAmendable, http.StatusConflict, "ORDER_NOT_AMENDABLE"},
+		{mock.ErrInvalidAmend, http.StatusBadRequest, "INVALID_ORDER"},
+		{mock.ErrSelfTradePrevented, http.StatusConflict, "SELF_TRADE_PREVENTED"},
+		{mock.ErrTooManyOpenOrders, http.StatusBadRequest, "TOO_MANY_OPEN_ORDERS"},
+		{mock.ErrOrderNotCancellable, http.StatusConflict, "ORDER_NOT_CANCELLABLE"},
+		{mock.ErrInvalidAmount, http.StatusBadRequest, "INVALID_AMOUNT"},
+		{mock.ErrTransactionNotFound, http.StatusNotFound, "TRANSACTION_NOT_FOUND"},
+		{mock.ErrKYCAlreadySubmitted, http.StatusConflict, "KYC_ALREADY_SUBMITTED"},
+		{mock.ErrNotReversible, http.StatusConflict, "NOT_REVERSIBLE"},
+		{mock.ErrAlertNotFound, http.StatusNotFound, "ALERT_NOT_FOUND"},
+		{mock.ErrInvalidAlertStatus, http.StatusConflict, "INVALID_ALERT_STATUS"},
+		{mock.ErrInvalidLimits, http.StatusBadRequest, "INVALID_LIMITS"},
+		{mock.ErrNothingToReduce, http.StatusConflict, "NOTHING_TO_REDUCE"},
+		{mock.ErrInvalidClose, http.StatusBadRequest, "INVALID_QUANTITY"},
+		{errors.New("disk full"), http.StatusInternalServerError, "ORDER_FAILED"},
+	}
+	for _, tt := range tests {
+		message, code := h.storeError(tt.err, "Order failed", response.CodeOrderFailed)
+		if code.Status() != tt.status || code.String() != tt.code || message == "" {
+			t.Errorf("%v: got %d %s %q, want %d %s", tt.err, code.Status(), code, message, tt.status, tt.code)
+		}
+	}
+
+	wrapped := fmt.Errorf("%w: tier 2 limit below tier 1", mock.ErrInvalidLimits)
+	if message, code := h.storeError(wrapped, "Limit update failed", response.CodeInternalError); code.Status() != http.StatusBadRequest || message != wrapped.Error() {
+		t.Errorf("wrapped limits error = %d %q, want 400 with its detail", code.Status(), message)
+	}
+}
+
+func TestHandlerTimeout_SlowKalshiReturnsGatewayTimeout(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	h.SetHandlerTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	newMux(h).ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/markets/FED-TEST", nil))
+
+	var resp APIResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusGatewayTimeout || resp.Code != response.CodeGatewayTimeout.String() {
+		t.Errorf("got %d %s, want 504 GATEWAY_TIMEOUT", rec.Code, resp.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v, want it cut off at the handler timeout", elapsed)
+	}
+}
+
+func TestPlaceOrder_SlowKalshiReturnsGatewayTimeout(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	userID := tradingUser(t, h.store, "slow@example.com", "OTHER-MKT")
+
+	req := httptest.NewRequest("POST", "/api/v1/orders",
+		strings.NewReader(`{"market_ticker": "FED-24DEC", "side": "yes", "quantity": 1, "price_cents": 40}`))
+	ctx, cancel := context.WithTimeout(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}), 50*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	h.PlaceOrder(rec, req.WithContext(ctx))
+
+	var resp APIResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusGatewayTimeout || resp.Code != response.CodeGatewayTimeout.String() {
+		t.Errorf("got %d %s, want 504 GATEWAY_TIMEOUT", rec.Code, resp.Code)
+	}
+	pending := models.OrderStatusPending
+	if orders, _ := h.store.GetOrders(userID, &pending, 10); len(orders) != 0 {
+		t.Errorf("%d orders placed after the deadline", len(orders))
+	}
+}
+
+func TestUnmatchedAPIRoutes_ReturnErrorEnvelope(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		method, path string
+		wantStatus   int
+		wantCode     response.ErrorCode
+	}{
+		{"GET", "/api/v1/ordrs", http.StatusNotFound, response.CodeNotFound},
+		{"GET", "/api/v2/markets", http.StatusNotFound, response.CodeNotFound},
+		{"PUT", "/api/v1/health", http.StatusMethodNotAllowed, response.CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		newMux(h).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		var resp APIResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Errorf("%s %s: body is not JSON: %v", tt.method, tt.path, err)
+			continue
+		}
+		if rec.Code != tt.wantStatus || resp.Success || resp.Code != tt.wantCode.String() {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.path, rec.Code, resp.Code, tt.wantStatus, tt.wantCode)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+			t.Errorf("%s %s: Content-Type = %q, want application/json", tt.method, tt.path, got)
+		}
+		if tt.wantStatus == http.StatusMethodNotAllowed && rec.Header().Get("Allow") != "GET" {
+			t.Errorf("%s %s: Allow = %q, want GET", tt.method, tt.path, rec.Header().Get("Allow"))
+		}
+	}
+
+	// Paths outside the API keep the router's default response
+	rec := httptest.NewRecorder()
+	newMux(h).ServeHTTP(rec, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if rec.Code != http.StatusNotFound || strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("non-API path = %d %q, want plain 404", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestAdminGetStats_CountsFromStore(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	tradingUser(t, h.store, "a@test.com", "FED-24DEC")
+	tradingUser(t, h.store, "b@test.com", "CPI-24NOV")
+	if _, err := h.store.CreateUser("pending@test.com", "hash", "P", "User", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	h.store.CreateComplianceAlert("user_1", "FED-24DEC", "spoofing", "high", "test alert")
+	h.store.CreateComplianceAlert("user_1", "FED-24DEC", "layering", "low", "test alert")
+	resolved := h.store.CreateComplianceAlert("user_2", "CPI-24NOV", "halt_suggested", "critical", "test alert")
+	// Escalated alerts are closed; alerts under investigation are still open
+	escalated := h.store.CreateComplianceAlert("user_2", "CPI-24NOV", "spoofing", "critical", "test alert")
+	investigating := h.store.CreateComplianceAlert("user_2", "GDP-Q4", "wash_trade", "critical", "test alert")
+	for _, step := range []struct{ id, status string }{
+		{resolved.ID, models.AlertStatusInvestigating},
+		{resolved.ID, models.AlertStatusResolved},
+		{escalated.ID, models.AlertStatusInvestigating},
+		{escalated.ID, models.AlertStatusEscalated},
+		{investigating.ID, models.AlertStatusInvestigating},
+	} {
+		if _, err := h.store.UpdateAlertStatus(step.id, step.status, "ops_1", "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.store.InitiateEmergencyHalt("CPI-24NOV", "test halt", "ops_1", 0)
+
+	stats := func() models.AdminStats {
+		rec := httptest.NewRecorder()
+		h.AdminGetStats(rec, httptest.NewRequest("GET", "/api/v1/admin/stats", nil))
+		var resp struct {
+			Data models.AdminStats `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("stats = %d %s", rec.Code, rec.Body.String())
+		}
+		return resp.Data
+	}
+
+	got := stats()
+	want := models.AdminStats{ActiveUsers: 2, OpenPositions: 2, TotalVolume24h: 20, OpenAlerts: 3, CriticalAlerts: 1, HaltedMarkets: 1, SystemStatus: models.SystemStatusWarning}
+	got.LastUpdated = time.Time{}
+	if got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+
+	h.store.InitiateEmergencyHalt("", "global halt", "ops_1", 0)
+	if got := stats(); got.SystemStatus != models.SystemStatusHalted || got.HaltedMarkets != 1 {
+		t.Errorf("under a global halt status = %q with %d halted markets, want halted with 1", got.SystemStatus, got.HaltedMarkets)
+	}
+}
+
+func TestAdminAssignAlert_ClaimsForCaller(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	alert := h.store.CreateComplianceAlert("user_1", "FED-24DEC", "wash_trade", "high", "test alert")
+
+	assign := func(operator string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/admin/alerts/"+alert.ID+"/assign", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": alert.ID})
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: operator}))
+		rec := httptest.NewRecorder()
+		h.AdminAssignAlert(rec, req)
+		return rec
+	}
+
+	for _, operator := range []string{"ops_1", "ops_2"} {
+		rec := assign(operator)
+		var resp struct {
+			Data models.ComplianceAlert `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusOK || resp.Data.AssignedTo != operator {
+			t.Errorf("%s claim = %d, assigned to %q", operator, rec.Code, resp.Data.AssignedTo)
+		}
+	}
+
+	if _, err := h.store.UpdateAlertStatus(alert.ID, models.AlertStatusInvestigating, "ops_2", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.store.ResolveAlert(alert.ID, "ops_2", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if rec := assign("ops_1"); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "ALERT_CLOSED") {
+		t.Errorf("claiming a resolved alert = %d %s, want 409 ALERT_CLOSED", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAdminAnalyzeTrading_StoresWashTradeAlert(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "wash@test.com", "FED-24DEC")
+	if _, err := h.store.CreateOrder(userID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 60, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	analyze := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.AdminAnalyzeTrading(rec, httptest.NewRequest("POST", "/api/v1/admin/surveillance/analyze", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := analyze(`{"user_id":"` + userID + `"}`)
+	var resp struct {
+		Data []models.ComplianceAlert `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK || len(resp.Data) != 1 || resp.Data[0].Type != "wash_trade" {
+		t.Fatalf("analyze = %d %+v, want one wash_trade alert", rec.Code, resp.Data)
+	}
+	stored, _ := h.store.SearchAlerts(mock.AlertFilter{Type: "wash_trade", UserID: userID}, 0, 10)
+	if len(stored) != 1 || stored[0].ID != resp.Data[0].ID || stored[0].MarketTicker != "FED-24DEC" {
+		t.Errorf("stored alerts = %+v, want the returned wash_trade alert on FED-24DEC", stored)
+	}
+
+	if rec := analyze(`{"user_id":"` + userID + `","market_ticker":"CPI-24NOV"}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"data":[]`) {
+		t.Errorf("analyzing an untraded market = %d %s, want no alerts", rec.Code, rec.Body.String())
+	}
+	if rec := analyze(`{"user_id":"nobody"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown user = %d, want 404", rec.Code)
+	}
+	if rec := analyze(`{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing user_id = %d, want 400", rec.Code)
+	}
+}
+
+func TestAnalyzeFills_FilledWashPatternRaisesAlert(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	bus := events.NewBus(events.DefaultBuffer)
+	h.store.SetEventBus(bus)
+	go h.surveillance.AnalyzeFills(bus.Subscribe(events.All), 20*time.Millisecond)
+	defer bus.Close()
+
+	userID := tradingUser(t, h.store, "autowash@test.com", "FED-24DEC")
+	order, err := h.store.CreateOrder(userID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 60, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.store.MockFillOrder(order.ID, 60); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		alerts, _ := h.store.SearchAlerts(mock.AlertFilter{Type: "wash_trade", UserID: userID}, 0, 10)
+		if len(alerts) == 1 && alerts[0].MarketTicker == "FED-24DEC" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("wash_trade alerts = %+v, want one on FED-24DEC after the fills", alerts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAdminGetAlerts_SearchFilters(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.store.SetAlertDedupWindow(0)
+	since := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	for _, a := range []struct{ user, market, alertType string }{
+		{"user_x", "FED-24DEC", "spoofing"},
+		{"user_x", "CPI-24NOV", "spoofing"},
+		{"user_x", "FED-24DEC", "wash_trade"},
+		{"user_y", "FED-24DEC", "spoofing"},
+		{"user_x", "FED-24DEC", "spoofing"},
+	} {
+		h.store.CreateComplianceAlert(a.user, a.market, a.alertType, "medium", "test")
+	}
+
+	search := func(query string) (*httptest.ResponseRecorder, []models.ComplianceAlert) {
+		rec := httptest.NewRecorder()
+		h.AdminGetAlerts(rec, httptest.NewRequest("GET", "/api/v1/admin/alerts?"+query, nil))
+		var resp struct {
+			Data []models.ComplianceAlert `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec, resp.Data
+	}
+
+	rec, alerts := search("type=spoofing&user_id=user_x&market_ticker=FED-24DEC&since=" + since + "&limit=1")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Total-Count") != "2" || len(alerts) != 1 {
+		t.Fatalf("search = %d total %s %+v, want 1 of 2", rec.Code, rec.Header().Get("X-Total-Count"), alerts)
+	}
+	if a := alerts[0]; a.Type != "spoofing" || a.UserID != "user_x" || a.MarketTicker != "FED-24DEC" {
+		t.Errorf("matched %+v", a)
+	}
+
+	if _, alerts := search("type=spoofing&until=" + since); len(alerts) != 0 {
+		t.Errorf("until before every alert matched %d", len(alerts))
+	}
+	for _, bad := range []string{"since=last-week", "since=200&until=100", "offset=-1"} {
+		if rec, _ := search(bad); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", bad, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/compliance"
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// newTestHandler wires a handler to an in-memory store and a stub Kalshi API.
+func newTestHandler(t *testing.T, kalshiAPI http.HandlerFunc) *Handler {
+	t.Helper()
+	server := httptest.NewServer(kalshiAPI)
+	t.Cleanup(server.Close)
+	store := mock.NewStore()
+	h := NewHandler(store, kalshi.NewClient(server.URL, time.Second), compliance.NewSurveillanceEngine(store, compliance.SurveillanceConfig{}))
+	h.SetFillStrategy(ImmediateFills{})
+	return h
+}
+
+// tradingUser creates a verified, funded user holding a filled YES position
+// in ticker.
+func tradingUser(t *testing.T, store *mock.Store, email, ticker string) string {
+	t.Helper()
+	user, err := store.CreateUser(email, "hash", "S", "User", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.CreateKYCRecord(user.ID, "passport", "P1", "127.0.0.1")
+	store.MockKYCApproval(user.ID, true, "")
+	store.CreateWallet(user.ID, "127.0.0.1")
+	store.Deposit(user.ID, models.Dollars(100), "test", "127.0.0.1")
+	order, err := store.CreateOrder(user.ID, ticker, "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.MockFillOrder(order.ID, 40)
+	return user.ID
+}
+
+// serveAs calls handler with a request authenticated as userID, or an
+// unauthenticated one when userID is empty.
+func serveAs(handler http.HandlerFunc, method, target, body, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
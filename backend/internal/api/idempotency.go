@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/logging"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
+)
+
+// =============================================================================
+// IDEMPOTENCY KEYS
+// Core Principle 13: A retried funds movement must not credit or debit twice.
+// =============================================================================
+
+const (
+	// IdempotencyKeyHeader names the client-chosen key for a request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses replayed from the cache.
+	IdempotentReplayHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL = 24 * time.Hour
+	maxIdempotencyKeyLen  = 255
+)
+
+// idempotencyCache remembers the response to each key for ttl. Entries are
+// scoped by user and path, so keys only need to be unique per client. The
+// cache lives in memory only: keys are forgotten on restart, so a retry that
+// spans a restart runs again.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotentEntry
+	expiry  []expiringScope // completed entries, in completion order
+	now     func() time.Time
+}
+
+type expiringScope struct {
+	scope string
+	entry *idempotentEntry
+}
+
+type idempotentEntry struct {
+	fingerprint [sha256.Size]byte // hash of the request body
+	done        chan struct{}     // closed once the response is recorded or released
+	released    bool              // the first attempt failed; the key may be retried
+	expires     time.Time
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, entries: make(map[string]*idempotentEntry), now: time.Now}
+}
+
+// claim returns the entry for scope and whether the caller owns it and must
+// run the request. Expired entries are dropped first.
+func (c *idempotencyCache) claim(scope string, fingerprint [sha256.Size]byte) (*idempotentEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.prune(now)
+	if entry, ok := c.entries[scope]; ok {
+		if !entry.expired(now) {
+			return entry, false
+		}
+		delete(c.entries, scope)
+	}
+	entry := &idempotentEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	c.entries[scope] = entry
+	return entry, true
+}
+
+// prune drops expired entries from the front of the expiry queue. The queue
+// is in completion order, so it stops at the first live entry; an entry left
+// behind by a shortened TTL is caught by claim's own check instead.
+// Callers hold c.mu.
+func (c *idempotencyCache) prune(now time.Time) {
+	n := 0
+	for ; n < len(c.expiry) && c.expiry[n].entry.expired(now); n++ {
+		if e := c.expiry[n]; c.entries[e.scope] == e.entry {
+			delete(c.entries, e.scope)
+		}
+	}
+	c.expiry = c.expiry[n:]
+}
+
+func (e *idempotentEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// complete records the owner's response and wakes waiting replays.
+func (c *idempotencyCache) complete(scope string, entry *idempotentEntry, rec *responseCapture) {
+	c.mu.Lock()
+	entry.status, entry.header, entry.body = rec.status, rec.header, rec.body.Bytes()
+	entry.expires = c.now().Add(c.ttl)
+	c.expiry = append(c.expiry, expiringScope{scope, entry})
+	c.mu.Unlock()
+	close(entry.done)
+}
+
+// release forgets the key after a server error or panic so a retry runs
+// again.
+func (c *idempotencyCache) release(scope string, entry *idempotentEntry) {
+	c.mu.Lock()
+	entry.released = true
+	if c.entries[scope] == entry {
+		delete(c.entries, scope)
+	}
+	c.mu.Unlock()
+	close(entry.done)
+}
+
+// SetIdempotencyTTL sets how long idempotency keys are remembered. Values
+// <= 0 keep the default.
+func (h *Handler) SetIdempotencyTTL(ttl time.Duration) {
+	if ttl > 0 {
+		h.idempotency.mu.Lock()
+		h.idempotency.ttl = ttl
+		h.idempotency.mu.Unlock()
+	}
+}
+
+// idempotent runs next at most once per Idempotency-Key. Replays, including
+// ones that arrive while the first request is in flight, get the original
+// response. Reusing a key with a different body is rejected. Requests
+// without the header run normally.
+func (h *Handler) idempotent(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	claims := auth.GetUserFromContext(r.Context())
+	if key == "" || claims == nil {
+		next(w, r)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		respondError(w, "Idempotency-Key must be at most 255 characters", response.CodeInvalidIdempotencyKey)
+		return
+	}
+
+	// Buffer the body so it can be fingerprinted; decodeJSON still enforces
+	// the size limit on the replayed reader.
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.maxBodyBytes+1))
+	if err != nil {
+		respondError(w, "Invalid request body", response.CodeInvalidRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	fingerprint := sha256.Sum256(body)
+	scope := claims.UserID + " " + r.URL.Path + " " + key
+
+	for {
+		entry, owner := h.idempotency.claim(scope, fingerprint)
+		if entry.fingerprint != fingerprint {
+			respondError(w, "Idempotency-Key was already used with a different request", response.CodeIdempotencyKeyReused)
+			return
+		}
+		if owner {
+			h.runIdempotent(w, r, next, scope, entry)
+			return
+		}
+
+		select {
+		case <-entry.done:
+		case <-r.Context().Done():
+			return
+		}
+		if entry.released {
+			continue
+		}
+		(&responseCapture{status: entry.status, header: entry.header, body: bytes.NewBuffer(entry.body)}).writeTo(w, true)
+		return
+	}
+}
+
+// runIdempotent runs next as the owner of entry and resolves it. If next
+// panics, the entry is released before the panic continues so waiting
+// replays retry instead of blocking forever.
+func (h *Handler) runIdempotent(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, scope string, entry *idempotentEntry) {
+	resolved := false
+	defer func() {
+		if !resolved {
+			h.idempotency.release(scope, entry)
+		}
+	}()
+	rec := newResponseCapture(w.Header())
+	next(rec, r)
+	resolved = true
+	if rec.status >= http.StatusInternalServerError {
+		h.idempotency.release(scope, entry)
+	} else {
+		h.idempotency.complete(scope, entry, rec)
+	}
+	rec.writeTo(w, false)
+}
+
+// responseCapture buffers a handler's response so it can be cached.
+type responseCapture struct {
+	status int
+	header http.Header
+	body   *bytes.Buffer
+}
+
+func newResponseCapture(base http.Header) *responseCapture {
+	return &responseCapture{status: http.StatusOK, header: base.Clone(), body: &bytes.Buffer{}}
+}
+
+func (c *responseCapture) Header() http.Header         { return c.header }
+func (c *responseCapture) WriteHeader(status int)      { c.status = status }
+func (c *responseCapture) Write(b []byte) (int, error) { return c.body.Write(b) }
+
+// writeTo copies the captured response to w.
+func (c *responseCapture) writeTo(w http.ResponseWriter, replayed bool) {
+	for k, v := range c.header {
+		if replayed && k == logging.RequestIDHeader {
+			continue // keep this request's ID
+		}
+		w.Header()[k] = v
+	}
+	if replayed {
+		w.Header().Set(IdempotentReplayHeader, "true")
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body.Bytes())
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func walletRequest(userID, path, body, key string) *http.Request {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{UserID: userID}))
+}
+
+func txCount(t *testing.T, h *Handler, userID string, txType models.TransactionType) int {
+	t.Helper()
+	txs, err := h.store.GetTransactions(userID, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, tx := range txs {
+		if tx.Type == txType {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDeposit_ConcurrentReplaysCreditOnce(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "idem@example.com", "FED-24DEC")
+	before := txCount(t, h, userID, models.TxTypeDeposit)
+	wallet, _ := h.store.GetWallet(userID)
+	balance := wallet.AvailableUSD
+
+	const replays = 20
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, replays)
+	for i := range recs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recs[i] = httptest.NewRecorder()
+			h.Deposit(recs[i], walletRequest(userID, "/api/v1/wallet/deposit", `{"amount_usd": 25}`, "dep-1"))
+		}(i)
+	}
+	wg.Wait()
+
+	if got := txCount(t, h, userID, models.TxTypeDeposit) - before; got != 1 {
+		t.Fatalf("deposits recorded = %d, want 1", got)
+	}
+	wallet, _ = h.store.GetWallet(userID)
+	if wallet.AvailableUSD != balance+models.Dollars(25) {
+		t.Errorf("balance = %s, want %s", wallet.AvailableUSD, balance+models.Dollars(25))
+	}
+	replayed := 0
+	for _, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != recs[0].Body.String() {
+			t.Fatalf("replay differs: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get(IdempotentReplayHeader) == "true" {
+			replayed++
+		}
+	}
+	if replayed != replays-1 {
+		t.Errorf("replayed responses = %d, want %d", replayed, replays-1)
+	}
+}
+
+func TestWithdraw_ReplayDebitsOnce(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "idemw@example.com", "FED-24DEC")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.Withdraw(rec, walletRequest(userID, "/api/v1/wallet/withdraw", `{"amount_usd": 5}`, "wd-1"))
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+	if got := txCount(t, h, userID, models.TxTypeWithdrawal); got != 1 {
+		t.Errorf("withdrawals recorded = %d, want 1", got)
+	}
+}
+
+func TestIdempotency_KeyRules(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	alice := tradingUser(t, h.store, "alice-idem@example.com", "FED-24DEC")
+	bob := tradingUser(t, h.store, "bob-idem@example.com", "FED-24DEC")
+	deposit := func(userID, body, key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.Deposit(rec, walletRequest(userID, "/api/v1/wallet/deposit", body, key))
+		return rec
+	}
+
+	if rec := deposit(alice, `{"amount_usd": 10}`, "k"); rec.Code != http.StatusOK {
+		t.Fatalf("first deposit: %d", rec.Code)
+	}
+	if rec := deposit(alice, `{"amount_usd": 99}`, "k"); rec.Code != http.StatusUnprocessableEntity ||
+		!strings.Contains(rec.Body.String(), "IDEMPOTENCY_KEY_REUSED") {
+		t.Errorf("different body with same key: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := deposit(bob, `{"amount_usd": 10}`, "k"); rec.Header().Get(IdempotentReplayHeader) != "" {
+		t.Error("keys must be scoped per user")
+	}
+	if rec := deposit(alice, `{"amount_usd": 10}`, strings.Repeat("x", 256)); rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized key: status = %d, want 400", rec.Code)
+	}
+
+	// Validation failures are replayed too; they never moved funds
+	if rec := deposit(alice, `{"amount_usd": -1}`, "bad"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("negative deposit: %d", rec.Code)
+	}
+	if rec := deposit(alice, `{"amount_usd": -1}`, "bad"); rec.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Error("client error was not replayed")
+	}
+
+	// Without a key every request runs
+	before := txCount(t, h, alice, models.TxTypeDeposit)
+	deposit(alice, `{"amount_usd": 1}`, "")
+	deposit(alice, `{"amount_usd": 1}`, "")
+	if got := txCount(t, h, alice, models.TxTypeDeposit) - before; got != 2 {
+		t.Errorf("unkeyed deposits = %d, want 2", got)
+	}
+}
+
+func TestIdempotency_KeysExpire(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "ttl@example.com", "FED-24DEC")
+	now := time.Now()
+	h.idempotency.now = func() time.Time { return now }
+	h.SetIdempotencyTTL(time.Hour)
+
+	before := txCount(t, h, userID, models.TxTypeDeposit)
+	h.Deposit(httptest.NewRecorder(), walletRequest(userID, "/api/v1/wallet/deposit", `{"amount_usd": 10}`, "k"))
+	now = now.Add(59 * time.Minute)
+	h.Deposit(httptest.NewRecorder(), walletRequest(userID, "/api/v1/wallet/deposit", `{"amount_usd": 10}`, "k"))
+	if got := txCount(t, h, userID, models.TxTypeDeposit) - before; got != 1 {
+		t.Fatalf("deposits within TTL = %d, want 1", got)
+	}
+	now = now.Add(2 * time.Minute)
+	h.Deposit(httptest.NewRecorder(), walletRequest(userID, "/api/v1/wallet/deposit", `{"amount_usd": 10}`, "k"))
+	if got := txCount(t, h, userID, models.TxTypeDeposit) - before; got != 2 {
+		t.Errorf("deposits after TTL = %d, want 2", got)
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	started, proceed := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		h.idempotent(httptest.NewRecorder(), walletRequest("u1", "/p", `{}`, "k"), func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-proceed
+			panic("handler failed")
+		})
+	}()
+	<-started
+
+	ran := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.idempotent(rec, walletRequest("u1", "/p", `{}`, "k"), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+		ran <- rec.Code
+	}()
+	close(proceed)
+
+	select {
+	case code := <-ran:
+		if code != http.StatusCreated {
+			t.Errorf("retry after panic: status = %d, want 201", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter blocked after the owner panicked")
+	}
+}
+
+func TestDeposit_MethodLimitsAndReferences(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	userID := tradingUser(t, h.store, "methods@example.com", "FED-24DEC")
+
+	deposit := func(body string) (*httptest.ResponseRecorder, models.Transaction, string) {
+		rec := httptest.NewRecorder()
+		h.Deposit(rec, walletRequest(userID, "/api/v1/wallet/deposit", body, ""))
+		var resp struct {
+			Data struct {
+				Transaction models.Transaction `json:"transaction"`
+			} `json:"data"`
+			Code string `json:"code"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec, resp.Data.Transaction, resp.Code
+	}
+
+	// ACH is the default, and a mock ACH pull gets a generated trace number
+	rec, tx, _ := deposit(`{"amount_usd": 25}`)
+	if rec.Code != http.StatusOK || tx.Method != models.DepositMethodACH || len(tx.Reference) != 15 {
+		t.Fatalf("default deposit = %d via %q ref %q", rec.Code, tx.Method, tx.Reference)
+	}
+	rec, tx, _ = deposit(`{"amount_usd": 500, "method": "wire", "reference": " 20240115abcd1234000042 "}`)
+	if rec.Code != http.StatusOK || tx.Method != models.DepositMethodWire || tx.Reference != "20240115ABCD1234000042" {
+		t.Fatalf("wire deposit = %d via %q ref %q", rec.Code, tx.Method, tx.Reference)
+	}
+
+	for body, want := range map[string]string{
+		`{"amount_usd": 15000}`: "AMOUNT_EXCEEDED",
+		`{"amount_usd": 3000, "method": "card", "reference": "A1B2C3"}`:               "AMOUNT_EXCEEDED",
+		`{"amount_usd": 50, "method": "wire", "reference": "20240115ABCD1234000042"}`: "AMOUNT_BELOW_MINIMUM",
+		`{"amount_usd": 100, "method": "wire"}`:                                       "INVALID_REFERENCE",
+		`{"amount_usd": 100, "method": "card", "reference": "not-a-code"}`:            "INVALID_REFERENCE",
+		`{"amount_usd": 100, "method": "ach", "reference": "MOCK_ACH_20240115"}`:      "INVALID_REFERENCE",
+		`{"amount_usd": 100, "method": "check", "reference": "1001"}`:                 "INVALID_DEPOSIT_METHOD",
+	} {
+		if rec, _, code := deposit(body); rec.Code != http.StatusBadRequest || code != want {
+			t.Errorf("%s = %d %s, want 400 %s", body, rec.Code, code, want)
+		}
+	}
+}
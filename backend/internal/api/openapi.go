@@ -0,0 +1,269 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/response"
+)
+
+// =============================================================================
+// OPENAPI SPEC
+// Core Principle 18: A machine-readable API description for client generation
+// and regulator documentation, built from the same route table as NewRouter.
+// =============================================================================
+
+// OpenAPI serves the OpenAPI 3 description of the API. NewRouter builds the
+// spec; routes cannot reference it directly without an initialization cycle.
+func (h *Handler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if h.openAPI == nil {
+		respondError(w, "OpenAPI spec not available", response.CodeNotFound)
+		return
+	}
+	respondJSON(w, http.StatusOK, h.openAPI)
+}
+
+// ErrorCodeInfo is one entry of the error code registry.
+type ErrorCodeInfo struct {
+	Code   response.ErrorCode `json:"code"`
+	Status int                `json:"status"`
+}
+
+// ListErrorCodes returns every error code with the HTTP status it is
+// registered with, so clients can enumerate them.
+func (h *Handler) ListErrorCodes(w http.ResponseWriter, r *http.Request) {
+	codes := response.ErrorCodes()
+	infos := make([]ErrorCodeInfo, len(codes))
+	for i, code := range codes {
+		infos[i] = ErrorCodeInfo{Code: code, Status: code.Status()}
+	}
+	respondSuccess(w, infos, nil)
+}
+
+// OpenAPISpec returns the OpenAPI 3 document for the route table.
+func OpenAPISpec() map[string]interface{} {
+	return buildOpenAPISpec(routes)
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+func buildOpenAPISpec(table []route) map[string]interface{} {
+	schemas := map[string]interface{}{
+		"APIResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"success": map[string]interface{}{"type": "boolean"},
+				"data":    map[string]interface{}{},
+				"meta":    map[string]interface{}{"type": "object"},
+			},
+			"required": []string{"success"},
+		},
+		"ErrorResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"success": map[string]interface{}{"type": "boolean", "enum": []bool{false}},
+				"error":   map[string]interface{}{"type": "string"},
+				"code":    map[string]interface{}{"type": "string", "enum": response.ErrorCodes()},
+				"meta":    map[string]interface{}{"type": "object"},
+			},
+			"required": []string{"success", "error", "code"},
+		},
+	}
+
+	paths := map[string]interface{}{}
+	for _, rt := range table {
+		op := map[string]interface{}{
+			"summary":     rt.Summary,
+			"operationId": operationID(rt),
+			"tags":        []string{rt.Tag},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Success",
+					"content":     jsonContent(successSchema(rt.Response, schemas)),
+				},
+				"default": map[string]interface{}{
+					"description": "Error; see code for the reason",
+					"content":     jsonContent(map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}),
+				},
+			},
+		}
+		if rt.Access != accessPublic {
+			op["security"] = []map[string][]string{{"bearerAuth": {}}}
+		}
+		if rt.Access == accessAdmin {
+			op["description"] = "Requires an operator account listed in ADMIN_USER_IDS."
+		}
+		if rt.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaFor(reflect.TypeOf(rt.Request), schemas)),
+			}
+		}
+
+		var params []map[string]interface{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(rt.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		if rt.Idempotent {
+			params = append(params, map[string]interface{}{
+				"name":        IdempotencyKeyHeader,
+				"in":          "header",
+				"description": "Replays of the same key within the TTL return the original response",
+				"schema":      map[string]interface{}{"type": "string", "maxLength": maxIdempotencyKeyLen},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		path := openAPIPath(rt.Path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Kalshi DCM Demo API",
+			"version":     "1.0.0",
+			"description": "Mock designated contract market API annotated with CFTC Core Principles.",
+		},
+		"servers": []map[string]string{{"url": "/api/v1"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// openAPIPath strips mux regexp constraints from path variables.
+func openAPIPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+// operationID derives a stable ID from the handler's method name.
+func operationID(rt route) string {
+	name := runtimeFuncName(rt.Handler)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "-fm")
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// successSchema wraps the data schema in the APIResponse envelope.
+func successSchema(data interface{}, schemas map[string]interface{}) map[string]interface{} {
+	dataSchema := map[string]interface{}{"type": "object"}
+	if data != nil {
+		dataSchema = schemaFor(reflect.TypeOf(data), schemas)
+	}
+	return map[string]interface{}{
+		"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/APIResponse"},
+			map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"data": dataSchema},
+			},
+		},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor derives a JSON schema from a Go type using its json tags. Named
+// structs are added to schemas and referenced.
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := t.Name()
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = map[string]interface{}{} // placeholder for recursive types
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	addStructFields(t, props, schemas)
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func addStructFields(t reflect.Type, props, schemas map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, props, schemas)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = schemaFor(f.Type, schemas)
+	}
+}
+
+func runtimeFuncName(fn interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
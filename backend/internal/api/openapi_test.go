@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPI_ListsEveryRegisteredRoute(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	NewRouter(h).ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	var spec struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec does not parse: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+
+	registered := 0
+	err := newMux(h).Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // the /api/v1 prefix itself
+		}
+		path = openAPIPath(strings.TrimPrefix(path, "/api/v1"))
+		for _, method := range methods {
+			if method == "OPTIONS" {
+				continue
+			}
+			registered++
+			if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+				t.Errorf("%s %s missing from spec", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered != len(routes) {
+		t.Errorf("walked %d routes, table has %d", registered, len(routes))
+	}
+}
+
+func TestOpenAPI_SchemasFromModels(t *testing.T) {
+	spec := OpenAPISpec()
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	order, ok := schemas["Order"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Order schema missing")
+	}
+	props := order["properties"].(map[string]interface{})
+	for _, field := range []string{"id", "market_ticker", "side", "price_cents", "created_at"} {
+		if _, ok := props[field]; !ok {
+			t.Errorf("Order schema missing %q", field)
+		}
+	}
+
+	place := spec["paths"].(map[string]interface{})["/orders"].(map[string]interface{})["post"].(map[string]interface{})
+	if _, ok := place["requestBody"]; !ok {
+		t.Error("POST /orders has no request body")
+	}
+	if _, ok := place["security"]; !ok {
+		t.Error("POST /orders should require bearer auth")
+	}
+}
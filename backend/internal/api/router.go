@@ -2,60 +2,108 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 
 	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/compliance"
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/logging"
+	"github.com/kalshi-dcm-demo/backend/internal/metrics"
+	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
 )
 
-// NewRouter creates and configures the API router.
-func NewRouter(h *Handler) http.Handler {
-	r := mux.NewRouter()
+// routeAccess is the authentication a route requires.
+type routeAccess int
 
-	// API versioning
-	api := r.PathPrefix("/api/v1").Subrouter()
+const (
+	accessPublic        routeAccess = iota
+	accessAuthenticated             // valid JWT
+	accessAdmin                     // valid JWT for an operator account
+)
+
+// route describes one API endpoint. NewRouter registers routes from this
+// table and OpenAPISpec documents them, so the two cannot drift.
+type route struct {
+	Method     string
+	Path       string // relative to /api/v1, in mux syntax
+	Access     routeAccess
+	Tag        string
+	Summary    string
+	Handler    func(*Handler, http.ResponseWriter, *http.Request)
+	Request    interface{} // JSON body type, nil when there is none
+	Response   interface{} // type of the envelope's data field, nil for ad-hoc objects
+	Idempotent bool        // honors the Idempotency-Key header
+	Streaming  bool        // long-lived response, exempt from the handler timeout
+}
 
+// routes is the API route table, in registration order.
+var routes = []route{
 	// ==========================================================================
 	// PUBLIC ROUTES (No authentication required)
 	// ==========================================================================
 
 	// Health check
-	api.HandleFunc("/health", h.HealthCheck).Methods("GET", "OPTIONS")
+	{Method: "GET", Path: "/health", Tag: "health", Summary: "Liveness check", Handler: (*Handler).HealthCheck},
+	{Method: "GET", Path: "/health/ready", Tag: "health", Summary: "Readiness check of Kalshi and persistence", Handler: (*Handler).ReadyCheck},
+	{Method: "GET", Path: "/openapi.json", Tag: "health", Summary: "This OpenAPI description", Handler: (*Handler).OpenAPI},
+	{Method: "GET", Path: "/error-codes", Tag: "health", Summary: "List error codes and their HTTP statuses", Handler: (*Handler).ListErrorCodes, Response: []ErrorCodeInfo{}},
 
 	// Authentication
-	api.HandleFunc("/auth/signup", h.Signup).Methods("POST", "OPTIONS")
-	api.HandleFunc("/auth/login", h.Login).Methods("POST", "OPTIONS")
+	{Method: "POST", Path: "/auth/signup", Tag: "auth", Summary: "Register a new account", Handler: (*Handler).Signup, Request: SignupRequest{}},
+	{Method: "POST", Path: "/auth/login", Tag: "auth", Summary: "Log in and receive a JWT", Handler: (*Handler).Login, Request: LoginRequest{}},
+	{Method: "POST", Path: "/auth/refresh-claims", Access: accessAuthenticated, Tag: "auth", Summary: "Reissue the JWT with the account's current status", Handler: (*Handler).RefreshClaims},
 
 	// Public market data (from Kalshi)
-	api.HandleFunc("/markets", h.GetMarkets).Methods("GET", "OPTIONS")
-	api.HandleFunc("/markets/{ticker}", h.GetMarket).Methods("GET", "OPTIONS")
-	api.HandleFunc("/markets/{ticker}/orderbook", h.GetOrderbook).Methods("GET", "OPTIONS")
-	api.HandleFunc("/events", h.GetEvents).Methods("GET", "OPTIONS")
-	api.HandleFunc("/series", h.GetSeries).Methods("GET", "OPTIONS")
+	{Method: "GET", Path: "/markets", Tag: "markets", Summary: "List markets", Handler: (*Handler).GetMarkets, Response: []models.KalshiMarket{}},
+	{Method: "GET", Path: "/markets/{ticker}", Tag: "markets", Summary: "Get a market", Handler: (*Handler).GetMarket, Response: models.KalshiMarket{}},
+	{Method: "GET", Path: "/markets/{ticker}/orderbook", Tag: "markets", Summary: "Get a market's orderbook", Handler: (*Handler).GetOrderbook, Response: OrderbookView{}},
+	{Method: "GET", Path: "/markets/{ticker}/history", Tag: "markets", Summary: "Get a market's price candles", Handler: (*Handler).GetMarketHistory, Response: kalshi.MarketHistory{}},
+	{Method: "GET", Path: "/markets/{ticker}/snapshot", Tag: "markets", Summary: "Get a market, its orderbook and recent trades in one call", Handler: (*Handler).GetMarketSnapshot, Response: MarketSnapshot{}},
+	{Method: "GET", Path: "/markets/{ticker}/trades", Tag: "markets", Summary: "List a market's recent trades", Handler: (*Handler).GetMarketTrades, Response: []kalshi.Trade{}},
+	{Method: "GET", Path: "/stream/markets", Tag: "markets", Summary: "Stream market updates as server-sent events", Handler: (*Handler).StreamMarkets, Streaming: true},
+	{Method: "GET", Path: "/markets/{ticker}/risk", Tag: "markets", Summary: "Get a market's risk classification", Handler: (*Handler).GetMarketRisk},
+	{Method: "GET", Path: "/events", Tag: "markets", Summary: "List events", Handler: (*Handler).GetEvents, Response: []kalshi.EventResponse{}},
+	{Method: "GET", Path: "/series", Tag: "markets", Summary: "List series", Handler: (*Handler).GetSeries, Response: []kalshi.SeriesItem{}},
+	{Method: "GET", Path: "/series/{ticker}", Tag: "markets", Summary: "Get a series", Handler: (*Handler).GetSeriesDetail, Response: SeriesDetail{}},
+	{Method: "GET", Path: "/series/{ticker}/markets", Tag: "markets", Summary: "List the markets in a series", Handler: (*Handler).GetSeriesMarkets, Response: []models.KalshiMarket{}},
+
+	// Halt status (Core Principle 4)
+	{Method: "GET", Path: "/status", Tag: "markets", Summary: "Get global and per-market halt status", Handler: (*Handler).GetExchangeStatus, Response: ExchangeStatus{}},
+	{Method: "GET", Path: "/markets/{ticker}/status", Tag: "markets", Summary: "Get whether a market is open or halted", Handler: (*Handler).GetMarketStatus, Response: MarketTradingStatus{}},
+
+	// Fee schedule (Core Principle 11)
+	{Method: "GET", Path: "/fees", Tag: "markets", Summary: "Get the fee schedule", Handler: (*Handler).GetFees, Response: models.FeeSchedule{}},
 
 	// ==========================================================================
 	// AUTHENTICATED ROUTES (Requires valid JWT)
 	// ==========================================================================
 
-	authenticated := api.PathPrefix("").Subrouter()
-	authenticated.Use(auth.AuthMiddleware)
-
 	// User profile
-	authenticated.HandleFunc("/profile", h.GetProfile).Methods("GET", "OPTIONS")
+	{Method: "GET", Path: "/profile", Access: accessAuthenticated, Tag: "account", Summary: "Get the user, KYC record and wallet", Handler: (*Handler).GetProfile},
 
 	// KYC
-	authenticated.HandleFunc("/kyc", h.GetKYCStatus).Methods("GET", "OPTIONS")
-	authenticated.HandleFunc("/kyc", h.SubmitKYC).Methods("POST", "OPTIONS")
+	{Method: "GET", Path: "/kyc", Access: accessAuthenticated, Tag: "account", Summary: "Get KYC status", Handler: (*Handler).GetKYCStatus, Response: models.KYCRecord{}},
+	{Method: "POST", Path: "/kyc", Access: accessAuthenticated, Tag: "account", Summary: "Submit KYC documents", Handler: (*Handler).SubmitKYC, Request: KYCSubmitRequest{}},
 
 	// Wallet
-	authenticated.HandleFunc("/wallet", h.GetWallet).Methods("GET", "OPTIONS")
-	authenticated.HandleFunc("/wallet/deposit", h.Deposit).Methods("POST", "OPTIONS")
-	authenticated.HandleFunc("/wallet/transactions", h.GetTransactions).Methods("GET", "OPTIONS")
+	{Method: "GET", Path: "/wallet", Access: accessAuthenticated, Tag: "wallet", Summary: "Get wallet balances", Handler: (*Handler).GetWallet, Response: models.Wallet{}},
+	{Method: "POST", Path: "/wallet/deposit", Access: accessAuthenticated, Tag: "wallet", Summary: "Deposit funds (mock ACH)", Handler: (*Handler).Deposit, Request: DepositRequest{}, Idempotent: true},
+	{Method: "POST", Path: "/wallet/withdraw", Access: accessAuthenticated, Tag: "wallet", Summary: "Withdraw funds", Handler: (*Handler).Withdraw, Request: WithdrawRequest{}, Idempotent: true},
+	{Method: "GET", Path: "/wallet/transactions", Access: accessAuthenticated, Tag: "wallet", Summary: "List transactions", Handler: (*Handler).GetTransactions, Response: []models.Transaction{}},
+	{Method: "GET", Path: "/wallet/pending", Access: accessAuthenticated, Tag: "wallet", Summary: "List pending deposits and withdrawals", Handler: (*Handler).GetPendingTransactions, Response: []models.Transaction{}},
+	{Method: "GET", Path: "/statements", Access: accessAuthenticated, Tag: "wallet", Summary: "Get a monthly statement (month=YYYY-MM, format=csv)", Handler: (*Handler).GetStatement, Response: models.Statement{}},
 
 	// Audit trail
-	authenticated.HandleFunc("/audit", h.GetAuditLog).Methods("GET", "OPTIONS")
+	{Method: "GET", Path: "/audit", Access: accessAuthenticated, Tag: "account", Summary: "Get the user's audit trail", Handler: (*Handler).GetAuditLog, Response: []models.AuditEntry{}},
 
 	// ==========================================================================
 	// TRADING ROUTES (Requires authentication; KYC checked in handlers)
@@ -65,20 +113,67 @@ func NewRouter(h *Handler) http.Handler {
 	// ==========================================================================
 
 	// Pre-trade check (Core Principle 11)
-	authenticated.HandleFunc("/orders/check", h.PreTradeCheck).Methods("POST", "OPTIONS")
+	{Method: "POST", Path: "/orders/check", Access: accessAuthenticated, Tag: "trading", Summary: "Check an order without placing it", Handler: (*Handler).PreTradeCheck, Request: PlaceOrderRequest{}, Response: compliance.PreTradeCheck{}},
 
 	// Trading (Core Principle 9)
-	authenticated.HandleFunc("/orders", h.PlaceOrder).Methods("POST", "OPTIONS")
-	authenticated.HandleFunc("/orders", h.GetOrders).Methods("GET", "OPTIONS")
+	{Method: "POST", Path: "/orders", Access: accessAuthenticated, Tag: "trading", Summary: "Place an order", Handler: (*Handler).PlaceOrder, Request: PlaceOrderRequest{}},
+	{Method: "GET", Path: "/orders", Access: accessAuthenticated, Tag: "trading", Summary: "List orders", Handler: (*Handler).GetOrders, Response: []models.Order{}},
+	{Method: "PATCH", Path: "/orders/{id}", Access: accessAuthenticated, Tag: "trading", Summary: "Amend a resting order", Handler: (*Handler).AmendOrder, Request: AmendOrderRequest{}},
 
 	// Portfolio (Core Principle 5)
-	authenticated.HandleFunc("/positions", h.GetPositions).Methods("GET", "OPTIONS")
-	authenticated.HandleFunc("/portfolio", h.GetPortfolioSummary).Methods("GET", "OPTIONS")
+	{Method: "GET", Path: "/positions", Access: accessAuthenticated, Tag: "trading", Summary: "List positions", Handler: (*Handler).GetPositions},
+	{Method: "POST", Path: "/positions/close", Access: accessAuthenticated, Tag: "trading", Summary: "Close all positions in one market at the current bids", Handler: (*Handler).CloseMarketPositions, Response: mock.MarketClose{}},
+	{Method: "POST", Path: "/positions/{id}/close", Access: accessAuthenticated, Tag: "trading", Summary: "Close some or all of a position at the current bid", Handler: (*Handler).ClosePosition, Request: ClosePositionRequest{}, Response: models.Position{}},
+	{Method: "GET", Path: "/portfolio", Access: accessAuthenticated, Tag: "trading", Summary: "Get the portfolio summary", Handler: (*Handler).GetPortfolioSummary},
+	{Method: "POST", Path: "/portfolio/whatif", Access: accessAuthenticated, Tag: "trading", Summary: "Preview an order's effect on exposure and balance", Handler: (*Handler).PortfolioWhatIf, Request: PlaceOrderRequest{}, Response: WhatIfResult{}},
+
+	// Settlements (Core Principles 3, 11)
+	{Method: "GET", Path: "/settlements", Access: accessAuthenticated, Tag: "trading", Summary: "List the user's settlements", Handler: (*Handler).GetSettlements, Response: []models.Settlement{}},
+
+	// ==========================================================================
+	// ADMIN ROUTES (Requires an operator account)
+	// Core Principle 17: Compliance views restricted to designated operators
+	// ==========================================================================
+
+	{Method: "GET", Path: "/admin/settlements", Access: accessAdmin, Tag: "admin", Summary: "List settlements for any user", Handler: (*Handler).AdminGetSettlements, Response: []models.Settlement{}},
+	{Method: "GET", Path: "/admin/stats", Access: accessAdmin, Tag: "admin", Summary: "Get dashboard counts of users, positions, volume, alerts and halts", Handler: (*Handler).AdminGetStats, Response: models.AdminStats{}},
+	{Method: "GET", Path: "/admin/alerts", Access: accessAdmin, Tag: "admin", Summary: "List compliance alerts", Handler: (*Handler).AdminGetAlerts, Response: []models.ComplianceAlert{}},
+	{Method: "POST", Path: "/admin/alerts/{id}/assign", Access: accessAdmin, Tag: "admin", Summary: "Claim an alert for the calling operator", Handler: (*Handler).AdminAssignAlert, Response: models.ComplianceAlert{}},
+	{Method: "POST", Path: "/admin/alerts/{id}/status", Access: accessAdmin, Tag: "admin", Summary: "Move an alert through its workflow", Handler: (*Handler).AdminUpdateAlertStatus, Request: UpdateAlertStatusRequest{}},
+	{Method: "POST", Path: "/admin/surveillance/analyze", Access: accessAdmin, Tag: "admin", Summary: "Run trade-pattern analysis over a user's recent orders", Handler: (*Handler).AdminAnalyzeTrading, Request: AnalyzeTradingRequest{}, Response: []models.ComplianceAlert{}},
+	{Method: "GET", Path: "/admin/users", Access: accessAdmin, Tag: "admin", Summary: "List users with exposure and alert counts", Handler: (*Handler).AdminListUsers, Response: []models.UserSummary{}},
+	{Method: "GET", Path: "/admin/users/{id}", Access: accessAdmin, Tag: "admin", Summary: "Get a user's profile, positions, exposure, orders and alerts", Handler: (*Handler).AdminGetUser, Response: AdminUserDetail{}},
+	{Method: "POST", Path: "/admin/users/{id}/status", Access: accessAdmin, Tag: "admin", Summary: "Suspend, ban or reinstate a user, optionally liquidating", Handler: (*Handler).AdminUpdateUserStatus, Request: UpdateUserStatusRequest{}},
+	{Method: "GET", Path: "/admin/limits", Access: accessAdmin, Tag: "admin", Summary: "Get the position limit tier table", Handler: (*Handler).AdminGetPositionLimits, Response: []models.PositionLimitConfig{}},
+	{Method: "PUT", Path: "/admin/limits", Access: accessAdmin, Tag: "admin", Summary: "Replace the position limit tier table", Handler: (*Handler).AdminUpdatePositionLimits, Request: PositionLimitsRequest{}},
+	{Method: "GET", Path: "/admin/ledger", Access: accessAdmin, Tag: "admin", Summary: "List double-entry journal entries and account balances", Handler: (*Handler).AdminGetLedger, Response: []models.JournalEntry{}},
+	{Method: "GET", Path: "/admin/reconciliation", Access: accessAdmin, Tag: "admin", Summary: "Compare wallets with their transaction ledgers", Handler: (*Handler).AdminReconcileWallets, Response: []models.ReconResult{}},
+	{Method: "POST", Path: "/admin/transactions/{id}/reverse", Access: accessAdmin, Tag: "admin", Summary: "Reverse a transaction", Handler: (*Handler).AdminReverseTransaction, Request: ReverseTransactionRequest{}, Response: models.Transaction{}},
+}
+
+// NewRouter creates and configures the API router.
+func NewRouter(h *Handler) http.Handler {
+	r := newMux(h)
 
 	// ==========================================================================
 	// CORS CONFIGURATION
 	// ==========================================================================
 
+	// Credentials and the CSRF header are only needed for cookie sessions;
+	// bearer-only mode keeps browsers from attaching cookies cross-origin.
+	allowedHeaders := []string{
+		"Accept",
+		"Authorization",
+		"Content-Type",
+		"X-Requested-With",
+		IdempotencyKeyHeader,
+		logging.RequestIDHeader,
+	}
+	cookieAuth := auth.CookieAuthEnabled()
+	if cookieAuth {
+		allowedHeaders = append(allowedHeaders, auth.CSRFHeaderName)
+	}
+
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{
 			"http://localhost:3000",
@@ -89,20 +184,101 @@ func NewRouter(h *Handler) http.Handler {
 		AllowedMethods: []string{
 			"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS",
 		},
-		AllowedHeaders: []string{
-			"Accept",
-			"Authorization",
-			"Content-Type",
-			"X-Requested-With",
-			"X-CSRF-Token",
-		},
+		AllowedHeaders: allowedHeaders,
 		ExposedHeaders: []string{
 			"Link",
 			"X-Total-Count",
+			IdempotentReplayHeader,
+			logging.RequestIDHeader,
 		},
-		AllowCredentials: true,
+		AllowCredentials: cookieAuth,
 		MaxAge:           300,
 	})
 
 	return c.Handler(r)
 }
+
+// newMux registers the route table under /api/v1.
+func newMux(h *Handler) *mux.Router {
+	r := mux.NewRouter()
+
+	// API versioning
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(metrics.Middleware)
+
+	h.openAPI = OpenAPISpec()
+	for _, rt := range routes {
+		rt := rt
+		var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rt.Handler(h, w, r)
+		})
+		if !rt.Streaming {
+			handler = h.withDeadline(handler)
+		}
+		switch rt.Access {
+		case accessAdmin:
+			handler = auth.AuthMiddleware(auth.RequireAdmin(handler))
+		case accessAuthenticated:
+			handler = auth.AuthMiddleware(handler)
+		}
+		api.Handle(rt.Path, handler).Methods(rt.Method, "OPTIONS")
+	}
+
+	// Unmatched API requests get the error envelope, not mux's plain text.
+	// Other paths keep the default 404.
+	unmatched := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowed := allowedMethods(api, r); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			respondError(w, fmt.Sprintf("%s is not allowed on %s", r.Method, r.URL.Path), response.CodeMethodNotAllowed)
+			return
+		}
+		respondError(w, fmt.Sprintf("No endpoint at %s", r.URL.Path), response.CodeNotFound)
+	})
+	api.NotFoundHandler = unmatched
+	api.MethodNotAllowedHandler = unmatched
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			unmatched(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	return r
+}
+
+// allowedMethods returns the methods, other than OPTIONS, that router serves
+// at the request's path. mux itself reports a method mismatch only when the
+// mismatched route is the last one tried, so it is checked here instead.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method == "OPTIONS" {
+				continue
+			}
+			probe := *r
+			probe.Method = method
+			if route.Match(&probe, &mux.RouteMatch{}) {
+				allowed = append(allowed, method)
+			}
+		}
+		return nil
+	})
+	sort.Strings(allowed)
+	return allowed
+}
+
+// withDeadline bounds the request context by the handler timeout, so
+// Kalshi calls made with it are abandoned before the server's write
+// timeout and answered with 504.
+func (h *Handler) withDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
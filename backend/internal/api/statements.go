@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
+)
+
+// =============================================================================
+// ACCOUNT STATEMENTS
+// Core Principle 18: Monthly statements of deposits, withdrawals, trades
+// and settlements for users and regulators. Months are calendar months in
+// UTC, as the audit logs are bucketed.
+// =============================================================================
+
+// statementCSVHeader is the first row of a CSV statement. Line items come
+// first, oldest first within each category, then summary rows.
+var statementCSVHeader = []string{"date", "category", "id", "description", "amount_usd", "status"}
+
+// GetStatement returns the caller's statement for month (YYYY-MM, default
+// the current month) as JSON, or as CSV with format=csv.
+func (h *Handler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, "Unauthorized", response.CodeUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	month := time.Now().UTC()
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if m := query.Get("month"); m != "" {
+		parsed, err := time.ParseInLocation("2006-01", m, time.UTC)
+		if err != nil {
+			respondError(w, "month must be YYYY-MM", response.CodeInvalidRequest)
+			return
+		}
+		month = parsed
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		respondError(w, "format must be json or csv", response.CodeInvalidRequest)
+		return
+	}
+
+	stmt, err := h.store.Statement(claims.UserID, month)
+	if err != nil {
+		h.respondStoreError(w, r, err, "Failed to build statement", response.CodeInternalError)
+		return
+	}
+
+	if format == "csv" {
+		writeStatementCSV(w, stmt)
+		return
+	}
+	respondSuccess(w, stmt, nil)
+}
+
+// writeStatementCSV writes stmt as a CSV attachment.
+func writeStatementCSV(w http.ResponseWriter, stmt *models.Statement) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s.csv"`, stmt.Month))
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	out.Write(statementCSVHeader)
+	for _, tx := range stmt.Deposits {
+		out.Write(transactionRow(tx))
+	}
+	for _, tx := range stmt.Withdrawals {
+		out.Write(transactionRow(tx))
+	}
+	for _, order := range stmt.Trades {
+		filledAt := order.UpdatedAt
+		if order.FilledAt != nil {
+			filledAt = *order.FilledAt
+		}
+		cost := models.Cents(int64(order.FilledQuantity * order.FilledPriceCents))
+		out.Write([]string{csvTime(filledAt), "trade", order.ID, tradeDescription(order), csvDollars(-cost), string(order.Status)})
+	}
+	for _, tx := range stmt.Closes {
+		out.Write([]string{csvTime(tx.CreatedAt), "close", tx.ID, tx.Description, csvDollars(tx.AmountUSD), string(tx.Status)})
+	}
+	for _, settlement := range stmt.Settlements {
+		out.Write([]string{
+			csvTime(settlement.SettledAt), "settlement", settlement.ID,
+			fmt.Sprintf("%d %s %s settled %s, P&L %s", settlement.Quantity, settlement.Side, settlement.MarketTicker, settlement.Result, settlement.PnLUSD),
+			csvDollars(settlement.PayoutUSD), "",
+		})
+	}
+
+	end := csvTime(stmt.PeriodEnd)
+	for _, row := range []struct {
+		name   string
+		amount models.Money
+	}{
+		{"Opening balance", stmt.OpeningBalanceUSD},
+		{"Deposits", stmt.DepositsUSD},
+		{"Withdrawals", -stmt.WithdrawalsUSD},
+		{"Fees", -stmt.FeesUSD},
+		{"Realized P&L", stmt.RealizedPnLUSD},
+		{"Closing balance", stmt.ClosingBalanceUSD},
+	} {
+		out.Write([]string{end, "summary", "", row.name, csvDollars(row.amount), ""})
+	}
+	out.Flush()
+}
+
+// tradeDescription describes a filled order. A reduce-only order buys the
+// other side to offset a position, so it is described as a reduction.
+func tradeDescription(order models.Order) string {
+	if order.ReduceOnly {
+		held := models.OrderSideYes
+		if order.Side == models.OrderSideYes {
+			held = models.OrderSideNo
+		}
+		return fmt.Sprintf("Reduced %s %s by %d: bought %s at %d¢",
+			held, order.MarketTicker, order.FilledQuantity, order.Side, order.FilledPriceCents)
+	}
+	return fmt.Sprintf("Bought %d %s %s at %d¢", order.FilledQuantity, order.Side, order.MarketTicker, order.FilledPriceCents)
+}
+
+// transactionRow is a deposit or withdrawal line; withdrawals are negative.
+func transactionRow(tx models.Transaction) []string {
+	amount := tx.AmountUSD
+	if tx.Type == models.TxTypeWithdrawal {
+		amount = -amount
+	}
+	return []string{csvTime(tx.CreatedAt), string(tx.Type), tx.ID, tx.Description, csvDollars(amount), string(tx.Status)}
+}
+
+func csvTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvDollars formats m as a plain dollar amount, e.g. "12.30" or "-0.05".
+func csvDollars(m models.Money) string {
+	c := m.Cents()
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestGetStatement_MonthOfActivity(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.store.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.01)})
+	userID := tradingUser(t, h.store, "statement@test.com", "FED-24DEC") // $100 in, 10 YES at 40¢
+	if _, err := h.store.Withdraw(userID, models.Dollars(20), "ach", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	positions, _ := h.store.GetPositions(userID)
+	if _, err := h.store.ClosePosition(userID, positions[0].ID, 5, 50, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.store.SettleMarket("FED-24DEC", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+	// Collateral locked after the last transaction still moves the balance
+	if _, err := h.store.CreateOrder(userID, "CPI-24NOV", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	txs, _ := h.store.GetTransactions(userID, 1)
+	month := txs[0].CreatedAt.Format("2006-01")
+	wallet, _ := h.store.GetWallet(userID)
+
+	rec := serveAs(h.GetStatement, "GET", "/api/v1/statements?month="+month, "", userID)
+	var resp struct {
+		Data models.Statement `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	stmt := resp.Data
+	if rec.Code != http.StatusOK || stmt.Month != month {
+		t.Fatalf("statement = %d for %q, want 200 for %s", rec.Code, stmt.Month, month)
+	}
+	if len(stmt.Deposits) != 1 || stmt.DepositsUSD != models.Dollars(100) ||
+		len(stmt.Withdrawals) != 1 || stmt.WithdrawalsUSD != models.Dollars(20) {
+		t.Errorf("deposits %d (%s), withdrawals %d (%s), want 1 ($100) and 1 ($20)",
+			len(stmt.Deposits), stmt.DepositsUSD, len(stmt.Withdrawals), stmt.WithdrawalsUSD)
+	}
+	if len(stmt.Trades) != 1 || len(stmt.Closes) != 1 || len(stmt.Settlements) != 1 {
+		t.Errorf("%d trades, %d closes and %d settlements, want 1 of each", len(stmt.Trades), len(stmt.Closes), len(stmt.Settlements))
+	}
+	if len(stmt.Closes) == 1 && stmt.Closes[0].AmountUSD != models.Dollars(2.50) {
+		t.Errorf("close proceeds = %s, want $2.50", stmt.Closes[0].AmountUSD)
+	}
+	// Close 5 at 50¢ on a 40¢ cost (+$0.50), settle 5 YES (+$3.00), less $0.10 trading fee
+	if stmt.FeesUSD != models.Cents(10) || stmt.RealizedPnLUSD != models.Cents(340) {
+		t.Errorf("fees %s, realized %s, want $0.10 and $3.40", stmt.FeesUSD, stmt.RealizedPnLUSD)
+	}
+	if stmt.OpeningBalanceUSD != 0 || stmt.ClosingBalanceUSD != wallet.AvailableUSD {
+		t.Errorf("balances %s to %s, want $0.00 to %s", stmt.OpeningBalanceUSD, stmt.ClosingBalanceUSD, wallet.AvailableUSD)
+	}
+
+	rec = serveAs(h.GetStatement, "GET", "/api/v1/statements?month="+month+"&format=csv", "", userID)
+	if got := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || got != "text/csv; charset=utf-8" {
+		t.Fatalf("csv = %d %q", rec.Code, got)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	categories := make(map[string]int)
+	for _, row := range rows[1:] {
+		categories[row[1]]++
+	}
+	want := map[string]int{"deposit": 1, "withdrawal": 1, "trade": 1, "close": 1, "settlement": 1, "summary": 6}
+	for category, n := range want {
+		if categories[category] != n {
+			t.Errorf("%d %s rows, want %d; rows %v", categories[category], category, n, rows)
+		}
+	}
+	if last := rows[len(rows)-1]; last[3] != "Closing balance" || last[4] != csvDollars(wallet.AvailableUSD) {
+		t.Errorf("last row = %v, want closing balance %s", last, csvDollars(wallet.AvailableUSD))
+	}
+
+	for _, row := range rows[1:] {
+		if row[1] == "trade" && row[3] != "Bought 10 yes FED-24DEC at 40¢" {
+			t.Errorf("trade row = %v", row)
+		}
+	}
+
+	before := txs[0].CreatedAt.AddDate(0, -1, 0).Format("2006-01")
+	json.NewDecoder(serveAs(h.GetStatement, "GET", "/api/v1/statements?month="+before, "", userID).Body).Decode(&resp)
+	if s := resp.Data; len(s.Deposits)+len(s.Withdrawals)+len(s.Trades)+len(s.Settlements) != 0 || s.ClosingBalanceUSD != 0 {
+		t.Errorf("previous month = %+v, want no activity", s)
+	}
+
+	for _, query := range []string{"month=2024-13", "month=March", "format=pdf"} {
+		if rec := serveAs(h.GetStatement, "GET", "/api/v1/statements?"+query, "", userID); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestTradeDescription_ReduceOnly(t *testing.T) {
+	order := models.Order{MarketTicker: "FED-24DEC", Side: models.OrderSideNo, FilledQuantity: 5, FilledPriceCents: 60, ReduceOnly: true}
+	if got, want := tradeDescription(order), "Reduced yes FED-24DEC by 5: bought no at 60¢"; got != want {
+		t.Errorf("description = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/logging"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
+)
+
+// =============================================================================
+// SERVER-SENT EVENTS
+// Core Principle 9: Market updates for clients that cannot hold a
+// WebSocket open. The stream carries the same messages as the WebSocket
+// hub's market channels.
+// =============================================================================
+
+// MarketStream delivers market update messages from the market data poller.
+// ws.Hub implements it.
+type MarketStream interface {
+	Subscribe(channels ...string) (<-chan []byte, func())
+}
+
+const (
+	// maxStreamTickers caps the markets one stream may follow.
+	maxStreamTickers = 50
+	// streamKeepAlive is how often an idle stream sends a comment so
+	// proxies do not time it out.
+	streamKeepAlive = 15 * time.Second
+)
+
+// SetMarketStream enables GET /stream/markets. Without a stream the
+// endpoint responds 503.
+func (h *Handler) SetMarketStream(s MarketStream) {
+	h.stream = s
+}
+
+// StreamMarkets streams market updates as server-sent events until the
+// client disconnects. tickers is a comma-separated list of markets; without
+// it every polled market is streamed. Each event is named after the message
+// type and its data is the WebSocket message JSON.
+func (h *Handler) StreamMarkets(w http.ResponseWriter, r *http.Request) {
+	if h.stream == nil {
+		respondError(w, "Market streaming is not available", response.CodeNotReady)
+		return
+	}
+
+	var channels []string
+	seen := make(map[string]bool)
+	for _, ticker := range strings.Split(r.URL.Query().Get("tickers"), ",") {
+		ticker = strings.TrimSpace(ticker)
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+		seen[ticker] = true
+		channels = append(channels, "market:"+ticker)
+	}
+	if len(channels) > maxStreamTickers {
+		respondError(w, fmt.Sprintf("At most %d tickers per stream", maxStreamTickers), response.CodeInvalidRequest)
+		return
+	}
+	if len(channels) == 0 {
+		channels = []string{"market:*"}
+	}
+
+	messages, unsubscribe := h.stream.Subscribe(channels...)
+	defer unsubscribe()
+
+	// The server's write timeout would otherwise end the stream.
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		logging.FromContext(r.Context()).Error("market stream cannot flush", "error", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var envelope struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal(msg, &envelope)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", envelope.Type, msg)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
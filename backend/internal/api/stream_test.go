@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeMarketStream struct {
+	messages     chan []byte
+	channels     chan []string
+	unsubscribed chan struct{}
+}
+
+func (f *fakeMarketStream) Subscribe(channels ...string) (<-chan []byte, func()) {
+	f.channels <- channels
+	return f.messages, func() { close(f.unsubscribed) }
+}
+
+// sseEvent is one event read from a stream; comments are skipped.
+type sseEvent struct {
+	name, data string
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.data != "":
+			return event
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamMarkets_StreamsUntilClientDisconnects(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	stream := &fakeMarketStream{
+		messages:     make(chan []byte, 2),
+		channels:     make(chan []string, 1),
+		unsubscribed: make(chan struct{}),
+	}
+	h.SetMarketStream(stream)
+	server := httptest.NewServer(newMux(h))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/stream/markets?tickers=FED-24DEC,CPI-24NOV,FED-24DEC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	if got, want := <-stream.channels, []string{"market:FED-24DEC", "market:CPI-24NOV"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subscribed to %v, want %v", got, want)
+	}
+
+	first := `{"type":"market_data","channel":"market:FED-24DEC","data":{"ticker":"FED-24DEC"}}`
+	second := `{"type":"market_data","channel":"market:CPI-24NOV","data":{"ticker":"CPI-24NOV"}}`
+	stream.messages <- []byte(first)
+	stream.messages <- []byte(second)
+
+	body := bufio.NewReader(resp.Body)
+	for _, want := range []string{first, second} {
+		if got := readEvent(t, body); got.name != "market_data" || got.data != want {
+			t.Errorf("event = %+v, want market_data with %s", got, want)
+		}
+	}
+
+	resp.Body.Close()
+	select {
+	case <-stream.unsubscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream was not unsubscribed after the client disconnected")
+	}
+}
+
+func TestStreamMarkets_Unavailable(t *testing.T) {
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	h.StreamMarkets(rec, httptest.NewRequest("GET", "/api/v1/stream/markets", nil))
+
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "NOT_READY") {
+		t.Errorf("got %d %s, want 503 NOT_READY", rec.Code, rec.Body.String())
+	}
+}
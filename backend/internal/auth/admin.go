@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kalshi-dcm-demo/backend/internal/response"
+)
+
+// =============================================================================
+// OPERATOR ACCESS
+// Core Principle 17: Compliance views are restricted to designated operators.
+// =============================================================================
+
+var (
+	adminUserIDs   = map[string]bool{}
+	adminUserIDsMu sync.RWMutex
+)
+
+// SetAdminUserIDs replaces the list of operator accounts. Called at startup
+// with the configured list. Operators are named by user ID, which the store
+// assigns, rather than by email, which anyone can claim at signup.
+func SetAdminUserIDs(userIDs []string) {
+	set := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			set[id] = true
+		}
+	}
+	adminUserIDsMu.Lock()
+	adminUserIDs = set
+	adminUserIDsMu.Unlock()
+}
+
+// IsAdmin reports whether the claims belong to an operator account.
+func IsAdmin(claims *Claims) bool {
+	if claims == nil {
+		return false
+	}
+	adminUserIDsMu.RLock()
+	defer adminUserIDsMu.RUnlock()
+	return adminUserIDs[claims.UserID]
+}
+
+// RequireAdmin restricts a route to operator accounts. It must run after
+// AuthMiddleware.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := GetUserFromContext(r.Context())
+		if claims == nil {
+			response.Error(w, "Unauthorized", response.CodeUnauthorized)
+			return
+		}
+
+		if !IsAdmin(claims) {
+			response.Error(w, "Admin access required", response.CodeForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
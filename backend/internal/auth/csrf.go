@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// COOKIE SESSIONS & CSRF
+// Core Principle 17: Browser sessions must not be usable cross-site.
+// =============================================================================
+
+const (
+	// SessionCookieName holds the JWT when cookie auth is enabled.
+	SessionCookieName = "dcm_session"
+	// CSRFCookieName holds the double-submit token readable by the frontend.
+	CSRFCookieName = "dcm_csrf"
+	// CSRFHeaderName must echo the CSRF cookie on state-changing requests.
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// cookieAuth is off by default: only bearer tokens are accepted and any
+// session cookie is ignored.
+var cookieAuth atomic.Bool
+
+// SetCookieAuth enables or disables cookie-based sessions. Called at startup
+// before the router is built.
+func SetCookieAuth(enabled bool) {
+	cookieAuth.Store(enabled)
+}
+
+// CookieAuthEnabled reports whether session cookies are accepted.
+func CookieAuthEnabled() bool {
+	return cookieAuth.Load()
+}
+
+// SetSessionCookies issues the session cookie and a fresh CSRF token for
+// the double-submit check. Returns the CSRF token.
+func SetSessionCookies(w http.ResponseWriter, r *http.Request, token string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	csrf := hex.EncodeToString(buf)
+	expires := time.Now().Add(24 * time.Hour)
+	secure := r.TLS != nil
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	// Readable by scripts so the frontend can echo it in CSRFHeaderName
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    csrf,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return csrf, nil
+}
+
+// sessionFromCookie returns the JWT from the session cookie, or "" when
+// cookie auth is disabled or no cookie was sent.
+func sessionFromCookie(r *http.Request) string {
+	if !CookieAuthEnabled() {
+		return ""
+	}
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// validCSRF checks the double-submit token. Safe methods are exempt.
+func validCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeaderName)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) == 1
+}
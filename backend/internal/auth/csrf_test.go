@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cookieSession logs in through SetSessionCookies and returns the cookies.
+func cookieSession(t *testing.T) (session, csrf *http.Cookie) {
+	t.Helper()
+	token, err := GenerateToken("user-1", "a@example.com", "active", true)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	value, err := SetSessionCookies(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil), token)
+	if err != nil {
+		t.Fatalf("SetSessionCookies: %v", err)
+	}
+	for _, c := range rec.Result().Cookies() {
+		switch c.Name {
+		case SessionCookieName:
+			session = c
+			if !c.HttpOnly {
+				t.Error("session cookie should be HttpOnly")
+			}
+		case CSRFCookieName:
+			csrf = c
+			if c.Value != value {
+				t.Errorf("csrf cookie = %q, returned %q", c.Value, value)
+			}
+		}
+	}
+	if session == nil || csrf == nil {
+		t.Fatal("expected session and csrf cookies")
+	}
+	return session, csrf
+}
+
+func TestAuthMiddleware_CookieCSRF(t *testing.T) {
+	SetCookieAuth(true)
+	defer SetCookieAuth(false)
+	session, csrf := cookieSession(t)
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		status int
+		code   string
+	}{
+		{"safe method needs no token", http.MethodGet, "", http.StatusOK, ""},
+		{"post without token", http.MethodPost, "", http.StatusForbidden, "CSRF_INVALID"},
+		{"post with wrong token", http.MethodPost, "forged", http.StatusForbidden, "CSRF_INVALID"},
+		{"post with matching token", http.MethodPost, csrf.Value, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/orders", nil)
+			req.AddCookie(session)
+			req.AddCookie(csrf)
+			if tt.header != "" {
+				req.Header.Set(CSRFHeaderName, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			AuthMiddleware(okHandler).ServeHTTP(rec, req)
+			if tt.code != "" {
+				assertErrorEnvelope(t, rec, tt.status, tt.code)
+			} else if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_BearerOnlyIgnoresCookies(t *testing.T) {
+	SetCookieAuth(true)
+	session, csrf := cookieSession(t)
+	SetCookieAuth(false)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	req.AddCookie(session)
+	req.AddCookie(csrf)
+	req.Header.Set(CSRFHeaderName, csrf.Value)
+	rec := httptest.NewRecorder()
+	AuthMiddleware(okHandler).ServeHTTP(rec, req)
+	assertErrorEnvelope(t, rec, http.StatusUnauthorized, "MISSING_TOKEN")
+}
+
+func TestAuthMiddleware_BearerSkipsCSRF(t *testing.T) {
+	SetCookieAuth(true)
+	defer SetCookieAuth(false)
+
+	token, err := GenerateToken("user-1", "a@example.com", "active", true)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	AuthMiddleware(okHandler).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// SESSION IDLE TIMEOUT
+// Core Principle 17: A token left unused on a shared machine should stop
+// working well before it expires. When an idle timeout is set, a token
+// unused for that long is rejected with SESSION_EXPIRED.
+// =============================================================================
+
+var (
+	idleTimeout time.Duration // 0 disables the check
+	lastSeen    = make(map[string]time.Time)
+	lastPrune   time.Time
+	idleMu      sync.Mutex
+
+	// idleNow is the idle check's clock, replaced in tests.
+	idleNow = time.Now
+)
+
+// SetIdleTimeout sets how long a token may go unused before it is
+// rejected. Called at startup; 0 disables the check.
+func SetIdleTimeout(timeout time.Duration) {
+	idleMu.Lock()
+	defer idleMu.Unlock()
+	idleTimeout = timeout
+	lastSeen = make(map[string]time.Time)
+}
+
+// touchSession records a request made with the token and reports whether
+// the token was still active: used within the idle timeout, or issued
+// within it for a token not seen before. Activity is kept in memory, so
+// after a restart a token is judged by when it was issued. Rejected
+// requests do not count as activity.
+func touchSession(claims *Claims) bool {
+	idleMu.Lock()
+	defer idleMu.Unlock()
+	if idleTimeout <= 0 {
+		return true
+	}
+
+	now := idleNow()
+	cutoff := now.Add(-idleTimeout)
+	if now.Sub(lastPrune) >= idleTimeout {
+		// An entry past the cutoff means a rejected token; so does its
+		// issue time, which is earlier, once the entry is gone.
+		for key, seen := range lastSeen {
+			if seen.Before(cutoff) {
+				delete(lastSeen, key)
+			}
+		}
+		lastPrune = now
+	}
+
+	key := sessionKey(claims)
+	last, seen := lastSeen[key]
+	if !seen {
+		last = now
+		if claims.IssuedAt != nil {
+			last = claims.IssuedAt.Time
+		}
+	}
+	if last.Before(cutoff) {
+		return false
+	}
+	lastSeen[key] = now
+	return true
+}
+
+// sessionKey identifies the login a token belongs to: its token ID, or the
+// user and issue time for tokens issued without one.
+func sessionKey(claims *Claims) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+	issued := int64(0)
+	if claims.IssuedAt != nil {
+		issued = claims.IssuedAt.Unix()
+	}
+	return claims.UserID + "@" + strconv.FormatInt(issued, 10)
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeIdleClock enables the idle timeout with a clock the test advances.
+func fakeIdleClock(t *testing.T, timeout time.Duration) *time.Time {
+	t.Helper()
+	now := time.Now()
+	idleNow = func() time.Time { return now }
+	SetIdleTimeout(timeout)
+	t.Cleanup(func() {
+		SetIdleTimeout(0)
+		idleNow = time.Now
+	})
+	return &now
+}
+
+func authRequest(token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/v1/portfolio", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	AuthMiddleware(okHandler).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdleTimeout_ActivityKeepsSessionAlive(t *testing.T) {
+	now := fakeIdleClock(t, 15*time.Minute)
+	token, err := GenerateToken("user_1", "a@test.com", "verified", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each request lands within the window of the one before
+	for i := 0; i < 4; i++ {
+		*now = now.Add(10 * time.Minute)
+		if rec := authRequest(token); rec.Code != 200 {
+			t.Fatalf("request %d after 10 idle minutes = %d, want 200", i+1, rec.Code)
+		}
+	}
+
+	*now = now.Add(16 * time.Minute)
+	assertErrorEnvelope(t, authRequest(token), 401, "SESSION_EXPIRED")
+	*now = now.Add(time.Minute)
+	assertErrorEnvelope(t, authRequest(token), 401, "SESSION_EXPIRED")
+}
+
+func TestIdleTimeout_UnusedTokenExpiresFromIssue(t *testing.T) {
+	now := fakeIdleClock(t, 15*time.Minute)
+	token, _ := GenerateToken("user_1", "a@test.com", "verified", true)
+
+	*now = now.Add(20 * time.Minute)
+	assertErrorEnvelope(t, authRequest(token), 401, "SESSION_EXPIRED")
+
+	SetIdleTimeout(0)
+	if rec := authRequest(token); rec.Code != 200 {
+		t.Errorf("with the timeout disabled = %d, want 200", rec.Code)
+	}
+}
@@ -4,12 +4,17 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
 )
 
 // =============================================================================
@@ -23,6 +28,7 @@ var (
 
 	ErrInvalidToken = errors.New("invalid or expired token")
 	ErrMissingToken = errors.New("missing authorization token")
+	ErrIdleSession  = errors.New("session expired after inactivity")
 )
 
 // Claims represents JWT claims for user sessions.
@@ -48,6 +54,10 @@ const (
 // GenerateToken creates a new JWT for authenticated users.
 // Core Principle 17: Authenticates participants.
 func GenerateToken(userID, email, status string, verified bool) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
 	now := time.Now()
 	claims := &Claims{
 		UserID:   userID,
@@ -55,6 +65,7 @@ func GenerateToken(userID, email, status string, verified bool) (string, error)
 		Status:   status,
 		Verified: verified,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(id), // Keys idle tracking per login
 			Issuer:    jwtIssuer,
 			Subject:   userID,
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -88,29 +99,56 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// ValidateSession validates a token as AuthMiddleware does, including the
+// idle timeout, for connections that do not pass through the middleware
+// such as WebSocket upgrades.
+func ValidateSession(tokenString string) (*Claims, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !touchSession(claims) {
+		return nil, ErrIdleSession
+	}
+	return claims, nil
+}
+
 // =============================================================================
 // MIDDLEWARE
 // =============================================================================
 
-// AuthMiddleware validates JWT and adds user context.
+// AuthMiddleware validates JWT and adds user context. Bearer tokens are
+// always accepted; session cookies only when cookie auth is enabled. With
+// an idle timeout set, tokens left unused too long are rejected.
 // Core Principle 17: Enforces access controls.
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"success":false,"error":"missing authorization header"}`, http.StatusUnauthorized)
+		var token string
+		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+				response.Error(w, "Invalid authorization format", response.CodeInvalidToken)
+				return
+			}
+			token = parts[1]
+		} else if token = sessionFromCookie(r); token != "" {
+			// Browsers attach cookies cross-site, so require the double-submit token
+			if !validCSRF(r) {
+				response.Error(w, "Missing or invalid CSRF token", response.CodeCSRFInvalid)
+				return
+			}
+		} else {
+			response.Error(w, "Missing authorization header", response.CodeMissingToken)
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, `{"success":false,"error":"invalid authorization format"}`, http.StatusUnauthorized)
+		claims, err := ValidateToken(token)
+		if err != nil {
+			response.Error(w, "Invalid or expired token", response.CodeInvalidToken)
 			return
 		}
-
-		claims, err := ValidateToken(parts[1])
-		if err != nil {
-			http.Error(w, `{"success":false,"error":"invalid or expired token"}`, http.StatusUnauthorized)
+		if !touchSession(claims) {
+			response.Error(w, "Session expired after inactivity", response.CodeSessionExpired)
 			return
 		}
 
@@ -120,22 +158,38 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// RequireVerified ensures user has completed KYC.
+// RequireVerified ensures user has completed KYC. With a status lookup
+// set, the current account status is used instead of the token's, and the
+// refreshed claims are passed on.
 // Core Principle 17: Fitness standards for trading.
 func RequireVerified(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := GetUserFromContext(r.Context())
 		if claims == nil {
-			http.Error(w, `{"success":false,"error":"unauthorized"}`, http.StatusUnauthorized)
+			response.Error(w, "Unauthorized", response.CodeUnauthorized)
 			return
 		}
 
+		claims, ok := currentClaims(claims)
+		if !ok {
+			response.Error(w, "Account not found", response.CodeUnauthorized)
+			return
+		}
+		switch models.UserStatus(claims.Status) {
+		case models.UserStatusSuspended:
+			response.Error(w, "Account suspended", response.CodeAccountSuspended)
+			return
+		case models.UserStatusBanned:
+			response.Error(w, "Account banned", response.CodeAccountBanned)
+			return
+		}
 		if !claims.Verified {
-			http.Error(w, `{"success":false,"error":"KYC verification required","code":"KYC_REQUIRED"}`, http.StatusForbidden)
+			response.Error(w, "KYC verification required", response.CodeKYCRequired)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), UserContextKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
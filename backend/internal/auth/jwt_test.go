@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// assertErrorEnvelope checks the response matches the API error shape.
+func assertErrorEnvelope(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d", rec.Code, status)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not JSON: %v (%s)", err, rec.Body.String())
+	}
+	if body["success"] != false {
+		t.Errorf("success = %v, want false", body["success"])
+	}
+	if msg, _ := body["error"].(string); msg == "" {
+		t.Error("error message missing")
+	}
+	if body["code"] != code {
+		t.Errorf("code = %v, want %s", body["code"], code)
+	}
+}
+
+func TestAuthMiddleware_ErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		code   string
+	}{
+		{"missing header", "", "MISSING_TOKEN"},
+		{"wrong scheme", "Basic abc123", "INVALID_TOKEN"},
+		{"bad token", "Bearer not-a-jwt", "INVALID_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/portfolio", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			AuthMiddleware(okHandler).ServeHTTP(rec, req)
+			assertErrorEnvelope(t, rec, http.StatusUnauthorized, tt.code)
+		})
+	}
+}
+
+func TestAuthMiddleware_ValidToken(t *testing.T) {
+	token, err := GenerateToken("user-1", "a@example.com", "active", true)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/portfolio", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	AuthMiddleware(okHandler).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestRequireVerified_ErrorEnvelope(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserContextKey, &Claims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+	RequireVerified(okHandler).ServeHTTP(rec, req)
+	assertErrorEnvelope(t, rec, http.StatusForbidden, "KYC_REQUIRED")
+}
+
+func TestRequireAdmin_ErrorEnvelope(t *testing.T) {
+	SetAdminUserIDs([]string{"user-ops"})
+	defer SetAdminUserIDs(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/alerts", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserContextKey, &Claims{UserID: "user-1", Email: "a@example.com"}))
+	rec := httptest.NewRecorder()
+	RequireAdmin(okHandler).ServeHTTP(rec, req)
+	assertErrorEnvelope(t, rec, http.StatusForbidden, "FORBIDDEN")
+}
+
+// withStatuses makes RequireVerified look up account status in statuses.
+func withStatuses(t *testing.T, statuses map[string]models.UserStatus) {
+	t.Helper()
+	SetStatusLookup(func(userID string) (models.UserStatus, bool) {
+		status, ok := statuses[userID]
+		return status, ok
+	})
+	t.Cleanup(func() { SetStatusLookup(nil) })
+}
+
+func requireVerified(claims *Claims, next http.Handler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserContextKey, claims))
+	rec := httptest.NewRecorder()
+	RequireVerified(next).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireVerified_ApprovedAfterLogin(t *testing.T) {
+	withStatuses(t, map[string]models.UserStatus{"user-1": models.UserStatusVerified})
+
+	var seen *Claims
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetUserFromContext(r.Context())
+	})
+	rec := requireVerified(&Claims{UserID: "user-1", Status: string(models.UserStatusKYCPending)}, next)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for a user approved since login", rec.Code)
+	}
+	if seen == nil || !seen.Verified || seen.Status != string(models.UserStatusVerified) {
+		t.Errorf("handler saw claims %+v, want the current verified status", seen)
+	}
+}
+
+func TestRequireVerified_SuspendedAfterLogin(t *testing.T) {
+	statuses := map[string]models.UserStatus{"user-1": models.UserStatusSuspended}
+	withStatuses(t, statuses)
+	claims := &Claims{UserID: "user-1", Status: string(models.UserStatusVerified), Verified: true}
+
+	assertErrorEnvelope(t, requireVerified(claims, okHandler), http.StatusForbidden, "ACCOUNT_SUSPENDED")
+
+	statuses["user-1"] = models.UserStatusBanned
+	assertErrorEnvelope(t, requireVerified(claims, okHandler), http.StatusForbidden, "ACCOUNT_BANNED")
+
+	delete(statuses, "user-1")
+	assertErrorEnvelope(t, requireVerified(claims, okHandler), http.StatusUnauthorized, "UNAUTHORIZED")
+}
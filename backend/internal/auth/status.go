@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"sync"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// =============================================================================
+// ACCOUNT STATUS
+// Core Principle 17: Token claims are a snapshot from login. KYC approval
+// or a suspension after that must take effect without a new login, so
+// RequireVerified checks the current status when a lookup is configured.
+// =============================================================================
+
+// StatusLookup returns a user's current account status, and false if the
+// user no longer exists.
+type StatusLookup func(userID string) (models.UserStatus, bool)
+
+var (
+	statusLookup   StatusLookup
+	statusLookupMu sync.RWMutex
+)
+
+// SetStatusLookup sets where RequireVerified reads account status. Called
+// at startup with the user store; nil makes it trust the token claims.
+func SetStatusLookup(lookup StatusLookup) {
+	statusLookupMu.Lock()
+	statusLookup = lookup
+	statusLookupMu.Unlock()
+}
+
+// currentClaims returns claims with Status and Verified refreshed from the
+// status lookup, or the claims unchanged when no lookup is set. ok is false
+// if the user no longer exists.
+func currentClaims(claims *Claims) (current *Claims, ok bool) {
+	statusLookupMu.RLock()
+	lookup := statusLookup
+	statusLookupMu.RUnlock()
+	if lookup == nil {
+		return claims, true
+	}
+
+	status, ok := lookup(claims.UserID)
+	if !ok {
+		return nil, false
+	}
+	refreshed := *claims
+	refreshed.Status = string(status)
+	refreshed.Verified = status == models.UserStatusVerified
+	return &refreshed, true
+}
@@ -4,13 +4,67 @@ package compliance
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
 	"github.com/kalshi-dcm-demo/backend/internal/models"
 )
 
+// =============================================================================
+// STORE INTERFACES
+// The engine depends only on the store methods it actually uses, so it can be
+// exercised against lightweight fakes and is not tied to a concrete backend.
+// =============================================================================
+
+// StoreReader provides the read-only store queries used by surveillance.
+type StoreReader interface {
+	GetWallet(userID string) (*models.Wallet, error)
+	GetUser(userID string) (*models.User, error)
+	GetUserExposure(userID string) models.Money
+	IsTradingHalted(marketTicker string) bool
+	IsMarketSettled(marketTicker string) bool
+	GetAuditLog(userID string, since time.Time, limit int) []models.AuditEntry
+	GetOrders(userID string, status *models.OrderStatus, limit int) ([]models.Order, error)
+	MarketFills(marketTicker string, from, to time.Time) []models.Order
+}
+
+// StoreWriter provides the store mutations performed by surveillance.
+type StoreWriter interface {
+	CreateComplianceAlert(userID, marketTicker, alertType, severity, description string) *models.ComplianceAlert
+	InitiateEmergencyHalt(marketTicker, reason, initiatedBy string, duration time.Duration) *models.EmergencyHalt
+	LiftEmergencyHalt(marketTicker, liftedBy string) error
+	UpdateUserStatus(userID string, status models.UserStatus, ip string) error
+}
+
+// Store is the full store contract required by the surveillance engine.
+type Store interface {
+	StoreReader
+	StoreWriter
+}
+
+// MarketSource provides Kalshi market data for volume checks.
+type MarketSource interface {
+	GetMarket(ticker string) (*kalshi.KalshiMarketResponse, error)
+}
+
+// MarketSnapshot is a market's last price at a point in time.
+type MarketSnapshot struct {
+	At         time.Time
+	PriceCents int
+}
+
+// SnapshotSource provides the price history spoofing is confirmed against.
+// The engine is one, combining the prices passed to ObservePrices with the
+// store's own fills.
+type SnapshotSource interface {
+	// Snapshots returns the market's snapshots taken from from to to
+	// inclusive, oldest first.
+	Snapshots(marketTicker string, from, to time.Time) []MarketSnapshot
+}
+
 // =============================================================================
 // SURVEILLANCE ENGINE
 // Core Principle 4: Prevention of Market Disruption
@@ -19,29 +73,94 @@ import (
 
 // SurveillanceEngine monitors trading activity for manipulation patterns.
 type SurveillanceEngine struct {
-	store *mock.Store
+	store     Store
+	markets   MarketSource   // nil disables volume concentration checks
+	snapshots SnapshotSource // prices spoofing is confirmed against
 
-	// Thresholds (configurable per Core Principle 5)
-	maxPositionUSD        float64
+	// Thresholds (configurable per Core Principle 5). Position limits are
+	// per user, kept in line with the store's live tier table.
 	maxOrdersPerMinute    int
 	suspiciousVolumeRatio float64
+	maxCancelFillRatio    float64       // cancels and amends per fill before an excessive_order_ratio alert
+	ratioWindow           time.Duration // window cancels, amends and fills are counted over
+	breachThreshold       int           // position_limit alerts that trigger suspension
+	breachWindow          time.Duration // window the breaches must fall within
+	breakerMovePct        float64       // price move that trips the circuit breaker (0 disables)
+	breakerWindow         time.Duration // window the move must happen within
+	breakerHalt           time.Duration // how long a tripped market stays halted
 
 	// Tracking
-	orderCounts map[string][]time.Time // userID -> order timestamps
-	mu          sync.RWMutex
+	orderCounts  map[string][]time.Time      // userID -> order timestamps within rateWindow
+	breaches     map[string][]time.Time      // userID -> position limit breaches within breachWindow
+	rateAlerts   map[string]time.Time        // userID -> last rate_limit alert
+	prices       map[string][]pricePoint     // ticker -> last prices within breakerWindow
+	history      map[string][]MarketSnapshot // ticker -> observed prices within snapshotRetention
+	breakers     map[string]time.Time        // ticker -> when its circuit breaker halt ends
+	ratioAlerts  map[string]time.Time        // userID -> last excessive_order_ratio alert
+	volumeAlerts map[string]time.Time        // userID + " " + ticker -> last unusual_activity alert
+	lastPrune    time.Time
+	ratioPrune   time.Time
+	volumePrune  time.Time
+	now          func() time.Time
+	mu           sync.RWMutex
+}
+
+// Default surveillance thresholds.
+const (
+	DefaultMaxOrdersPerMinute    = 60
+	DefaultSuspiciousVolumeRatio = 0.10 // 10% of market volume
+	DefaultMaxCancelFillRatio    = 20
+	DefaultOrderRatioWindow      = 10 * time.Minute
+)
+
+// SurveillanceConfig holds the engine's thresholds, populated from the
+// server config. Zero or negative fields take the defaults.
+type SurveillanceConfig struct {
+	MaxOrdersPerMinute    int           // Accepted orders per user per minute
+	SuspiciousVolumeRatio float64       // Share of a market's 24h volume one user may fill
+	MaxCancelFillRatio    float64       // Cancels and amends per fill within OrderRatioWindow
+	OrderRatioWindow      time.Duration // Window the order-to-trade ratio is measured over
 }
 
-// NewSurveillanceEngine creates a new surveillance engine.
-func NewSurveillanceEngine(store *mock.Store) *SurveillanceEngine {
-	return &SurveillanceEngine{
+// NewSurveillanceEngine creates a surveillance engine with cfg's thresholds.
+func NewSurveillanceEngine(store Store, cfg SurveillanceConfig) *SurveillanceEngine {
+	if cfg.MaxOrdersPerMinute <= 0 {
+		cfg.MaxOrdersPerMinute = DefaultMaxOrdersPerMinute
+	}
+	if cfg.SuspiciousVolumeRatio <= 0 {
+		cfg.SuspiciousVolumeRatio = DefaultSuspiciousVolumeRatio
+	}
+	if cfg.MaxCancelFillRatio <= 0 {
+		cfg.MaxCancelFillRatio = DefaultMaxCancelFillRatio
+	}
+	if cfg.OrderRatioWindow <= 0 {
+		cfg.OrderRatioWindow = DefaultOrderRatioWindow
+	}
+	s := &SurveillanceEngine{
 		store:                 store,
-		maxPositionUSD:        25000.00, // Default per-user limit
-		maxOrdersPerMinute:    60,       // Rate limiting
-		suspiciousVolumeRatio: 0.10,     // 10% of market volume
+		maxOrdersPerMinute:    cfg.MaxOrdersPerMinute,
+		suspiciousVolumeRatio: cfg.SuspiciousVolumeRatio,
+		maxCancelFillRatio:    cfg.MaxCancelFillRatio,
+		ratioWindow:           cfg.OrderRatioWindow,
+		breachThreshold:       3,
+		breachWindow:          time.Hour,
 		orderCounts:           make(map[string][]time.Time),
+		breaches:              make(map[string][]time.Time),
+		rateAlerts:            make(map[string]time.Time),
+		prices:                make(map[string][]pricePoint),
+		breakers:              make(map[string]time.Time),
+		ratioAlerts:           make(map[string]time.Time),
+		volumeAlerts:          make(map[string]time.Time),
+		history:               make(map[string][]MarketSnapshot),
+		now:                   time.Now,
 	}
+	s.snapshots = s
+	return s
 }
 
+// maxOrderQuantity mirrors the per-order contract cap enforced at placement.
+const maxOrderQuantity = 1000
+
 // =============================================================================
 // PRE-TRADE CHECKS
 // Core Principle 11: Financial Integrity - 100% collateralization
@@ -49,11 +168,11 @@ func NewSurveillanceEngine(store *mock.Store) *SurveillanceEngine {
 
 // PreTradeCheck validates an order before submission.
 type PreTradeCheck struct {
-	Passed          bool     `json:"passed"`
-	Errors          []string `json:"errors,omitempty"`
-	Warnings        []string `json:"warnings,omitempty"`
-	RequiredMargin  float64  `json:"required_margin_usd"`
-	AvailableMargin float64  `json:"available_margin_usd"`
+	Passed          bool         `json:"passed"`
+	Errors          []string     `json:"errors,omitempty"`
+	Warnings        []string     `json:"warnings,omitempty"`
+	RequiredMargin  models.Money `json:"required_margin_usd"`
+	AvailableMargin models.Money `json:"available_margin_usd"`
 }
 
 // ValidateOrder performs comprehensive pre-trade compliance checks.
@@ -66,15 +185,22 @@ func (s *SurveillanceEngine) ValidateOrder(userID, marketTicker string, side mod
 		Warnings: make([]string, 0),
 	}
 
+	// Check 0: Order parameters (binary contracts trade at 1-99 cents)
+	if quantity <= 0 {
+		check.Passed = false
+		check.Errors = append(check.Errors, "Quantity must be positive")
+	} else if quantity > maxOrderQuantity {
+		check.Passed = false
+		check.Errors = append(check.Errors, fmt.Sprintf("Quantity exceeds maximum allowed (%d)", maxOrderQuantity))
+	}
+	if priceCents < 1 || priceCents > 99 {
+		check.Passed = false
+		check.Errors = append(check.Errors, "Price must be between 1 and 99 cents")
+	}
+
 	// Calculate required margin (100% collateralization)
 	// Core Principle 11: Binary contracts require full collateral
-	var marginCents int
-	if side == models.OrderSideYes {
-		marginCents = quantity * priceCents
-	} else {
-		marginCents = quantity * (100 - priceCents)
-	}
-	check.RequiredMargin = float64(marginCents) / 100.0
+	check.RequiredMargin = models.Cents(int64(models.CollateralCents(side, quantity, priceCents)))
 
 	// Get user wallet
 	wallet, err := s.store.GetWallet(userID)
@@ -89,7 +215,7 @@ func (s *SurveillanceEngine) ValidateOrder(userID, marketTicker string, side mod
 	if wallet.AvailableUSD < check.RequiredMargin {
 		check.Passed = false
 		check.Errors = append(check.Errors, fmt.Sprintf(
-			"Insufficient funds: need $%.2f, available $%.2f",
+			"Insufficient funds: need %s, available %s",
 			check.RequiredMargin, wallet.AvailableUSD))
 	}
 
@@ -101,17 +227,19 @@ func (s *SurveillanceEngine) ValidateOrder(userID, marketTicker string, side mod
 		return check
 	}
 
+	// Compared in whole cents so an order landing exactly on the limit passes
 	currentExposure := s.store.GetUserExposure(userID)
 	newExposure := currentExposure + check.RequiredMargin
-	if newExposure > user.PositionLimitUSD {
+	limit := user.PositionLimitUSD
+	if newExposure > limit {
 		check.Passed = false
 		check.Errors = append(check.Errors, fmt.Sprintf(
-			"Position limit exceeded: current $%.2f + order $%.2f > limit $%.2f",
-			currentExposure, check.RequiredMargin, user.PositionLimitUSD))
+			"Position limit exceeded: current %s + order %s > limit %s",
+			currentExposure, check.RequiredMargin, limit))
 	}
 
 	// Check 3: Rate limiting (Core Principle 4)
-	if s.isRateLimited(userID) {
+	if s.IsRateLimited(userID) {
 		check.Passed = false
 		check.Errors = append(check.Errors, "Order rate limit exceeded. Please wait.")
 	}
@@ -123,40 +251,277 @@ func (s *SurveillanceEngine) ValidateOrder(userID, marketTicker string, side mod
 	}
 
 	// Warning: Approaching position limit
-	if newExposure > user.PositionLimitUSD*0.8 {
+	if newExposure > limit.Percent(80) {
 		check.Warnings = append(check.Warnings, fmt.Sprintf(
 			"Approaching position limit (%.0f%% utilized)",
-			(newExposure/user.PositionLimitUSD)*100))
+			newExposure.Dollars()/limit.Dollars()*100))
 	}
 
 	return check
 }
 
-// isRateLimited checks if user is submitting orders too quickly.
+// rateWindow is the sliding window for order rate limiting.
+const rateWindow = time.Minute
+
+// IsRateLimited reports whether another order from the user would exceed
+// the per-minute limit. It only reads: pre-trade checks and rejected orders
+// do not consume the rate budget.
 // Core Principle 4: Prevents potential manipulation through rapid-fire orders.
-func (s *SurveillanceEngine) isRateLimited(userID string) bool {
+func (s *SurveillanceEngine) IsRateLimited(userID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := s.now().Add(-rateWindow)
+	recent := 0
+	for _, ts := range s.orderCounts[userID] {
+		if ts.After(cutoff) {
+			recent++
+		}
+	}
+	return recent >= s.maxOrdersPerMinute
+}
+
+// ReserveOrder checks the user's per-minute limit and, if there is room,
+// counts the order in the same critical section, so concurrent requests
+// cannot all pass the check before any of them is recorded. The returned
+// release gives the slot back when the order is then rejected, keeping the
+// rule that only accepted orders consume the rate budget.
+// Core Principle 4: Prevents potential manipulation through rapid-fire orders.
+func (s *SurveillanceEngine) ReserveOrder(userID string) (release func(), ok bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := time.Now()
-	cutoff := now.Add(-time.Minute)
+	now := s.now()
+	if len(s.recentOrders(userID, now)) >= s.maxOrdersPerMinute {
+		return nil, false
+	}
+	s.appendOrder(userID, now)
 
-	// Get order timestamps for this user
-	timestamps := s.orderCounts[userID]
+	var once sync.Once
+	return func() {
+		once.Do(func() { s.releaseOrder(userID, now) })
+	}, true
+}
+
+// RecordOrder counts an accepted order toward the user's rate budget
+// without checking the limit.
+func (s *SurveillanceEngine) RecordOrder(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.recentOrders(userID, now)
+	s.appendOrder(userID, now)
+}
 
-	// Filter to only recent orders
-	var recent []time.Time
+// recentOrders drops the user's timestamps outside the window and returns
+// the rest. Users with no orders inside the window are evicted once per
+// window. Callers must hold s.mu.
+func (s *SurveillanceEngine) recentOrders(userID string, now time.Time) []time.Time {
+	cutoff := now.Add(-rateWindow)
+	if now.Sub(s.lastPrune) >= rateWindow {
+		s.pruneOrderCounts(cutoff)
+		s.lastPrune = now
+	}
+
+	// Filter to only recent orders, reusing the slice's backing array
+	timestamps, ok := s.orderCounts[userID]
+	if !ok {
+		return nil
+	}
+	recent := timestamps[:0]
 	for _, ts := range timestamps {
 		if ts.After(cutoff) {
 			recent = append(recent, ts)
 		}
 	}
+	s.orderCounts[userID] = recent
+	return recent
+}
 
-	// Add current timestamp
-	recent = append(recent, now)
+// appendOrder records an order at now. Each user keeps at most
+// maxOrdersPerMinute timestamps; older entries beyond the limit cannot
+// matter. Callers must hold s.mu.
+func (s *SurveillanceEngine) appendOrder(userID string, now time.Time) {
+	recent := append(s.orderCounts[userID], now)
+	if keep := s.maxOrdersPerMinute; len(recent) > keep {
+		recent = append(recent[:0], recent[len(recent)-keep:]...)
+	}
 	s.orderCounts[userID] = recent
+}
+
+// releaseOrder removes one order recorded at ts, if it is still held.
+func (s *SurveillanceEngine) releaseOrder(userID string, ts time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timestamps := s.orderCounts[userID]
+	for i, t := range timestamps {
+		if t.Equal(ts) {
+			s.orderCounts[userID] = append(timestamps[:i], timestamps[i+1:]...)
+			return
+		}
+	}
+}
+
+// ReportRateLimited raises a rate_limit alert for a user whose order was
+// rejected by the rate limit. Alerts are debounced to one per user per
+// rateWindow so a burst of rejections produces a single alert. It reports
+// whether an alert was created.
+// Core Principle 4: Rapid-fire order abuse is surfaced to operators.
+func (s *SurveillanceEngine) ReportRateLimited(userID, marketTicker string) bool {
+	s.mu.Lock()
+	now := s.now()
+	cutoff := now.Add(-rateWindow)
+	for id, last := range s.rateAlerts {
+		if !last.After(cutoff) {
+			delete(s.rateAlerts, id)
+		}
+	}
+	if _, recent := s.rateAlerts[userID]; recent {
+		s.mu.Unlock()
+		return false
+	}
+	s.rateAlerts[userID] = now
+	s.mu.Unlock()
+
+	s.store.CreateComplianceAlert(userID, marketTicker, "rate_limit", "medium",
+		fmt.Sprintf("Order rate limit exceeded: more than %d orders per minute", s.maxOrdersPerMinute))
+	return true
+}
+
+// OrderRates returns each user's order times within the rate window, oldest
+// first, for saving with store snapshots.
+func (s *SurveillanceEngine) OrderRates() map[string][]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := s.now().Add(-rateWindow)
+	rates := make(map[string][]time.Time)
+	for userID, timestamps := range s.orderCounts {
+		var recent []time.Time
+		for _, ts := range timestamps {
+			if ts.After(cutoff) {
+				recent = append(recent, ts)
+			}
+		}
+		if len(recent) > 0 {
+			rates[userID] = recent
+		}
+	}
+	return rates
+}
+
+// RestoreOrderRates merges order times saved by OrderRates, so users near
+// the cap before a restart stay rate limited after it. Times outside the
+// rate window are dropped.
+func (s *SurveillanceEngine) RestoreOrderRates(rates map[string][]time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return len(recent) > s.maxOrdersPerMinute
+	cutoff := s.now().Add(-rateWindow)
+	for userID, timestamps := range rates {
+		merged := append([]time.Time{}, s.orderCounts[userID]...)
+		for _, ts := range timestamps {
+			if ts.After(cutoff) {
+				merged = append(merged, ts)
+			}
+		}
+		if len(merged) == 0 {
+			continue
+		}
+		sort.Slice(merged, func(i, j int) bool { return merged[i].Before(merged[j]) })
+		if keep := s.maxOrdersPerMinute; len(merged) > keep {
+			merged = merged[len(merged)-keep:]
+		}
+		s.orderCounts[userID] = merged
+	}
+}
+
+// pruneOrderCounts drops users whose latest order is older than cutoff.
+// Timestamps are appended in order, so the last one is the newest.
+func (s *SurveillanceEngine) pruneOrderCounts(cutoff time.Time) {
+	for userID, timestamps := range s.orderCounts {
+		if len(timestamps) == 0 || !timestamps[len(timestamps)-1].After(cutoff) {
+			delete(s.orderCounts, userID)
+		}
+	}
+}
+
+// =============================================================================
+// ORDER-TO-TRADE RATIO
+// Core Principle 4: Orders placed to be cancelled rather than traded
+// mislead other participants about depth.
+// =============================================================================
+
+// CheckOrderRatio raises an excessive_order_ratio alert when the user's
+// cancels and amends within the ratio window exceed maxCancelFillRatio
+// times their fills, counting no fills as one. Everything is counted from
+// the user's orders in the store: each cancelled order when it was
+// cancelled, except in settled markets, where settlement cancelled what
+// was resting; each amend, a cancel and replace, when it was made; and
+// each order that traded when it last filled. Alerts are debounced to one
+// per user per window. It reports whether an alert was created.
+func (s *SurveillanceEngine) CheckOrderRatio(userID, marketTicker string) bool {
+	s.mu.RLock()
+	now, ratio, window := s.now(), s.maxCancelFillRatio, s.ratioWindow
+	s.mu.RUnlock()
+	cutoff := now.Add(-window)
+
+	orders, err := s.store.GetOrders(userID, nil, analysisOrderLimit)
+	if err != nil {
+		return false
+	}
+	cancels, fills := 0, 0
+	settled := make(map[string]bool)
+	for _, order := range orders {
+		if order.Status == models.OrderStatusCancelled && order.CancelledAt != nil && order.CancelledAt.After(cutoff) {
+			if _, known := settled[order.MarketTicker]; !known {
+				settled[order.MarketTicker] = s.store.IsMarketSettled(order.MarketTicker)
+			}
+			if !settled[order.MarketTicker] {
+				cancels++
+			}
+		}
+		for _, at := range order.AmendedAt {
+			if at.After(cutoff) {
+				cancels++
+			}
+		}
+		if order.FilledQuantity > 0 && filledAt(order).After(cutoff) {
+			fills++
+		}
+	}
+	if float64(cancels) <= ratio*float64(max(fills, 1)) {
+		return false
+	}
+
+	s.mu.Lock()
+	s.pruneRatioAlerts(now, cutoff)
+	if last, alerted := s.ratioAlerts[userID]; alerted && last.After(cutoff) {
+		s.mu.Unlock()
+		return false
+	}
+	s.ratioAlerts[userID] = now
+	s.mu.Unlock()
+
+	s.store.CreateComplianceAlert(userID, marketTicker, "excessive_order_ratio", "medium",
+		fmt.Sprintf("%d cancels and amends to %d fills in %s exceeds the %g:1 order-to-trade limit", cancels, fills, window, ratio))
+	return true
+}
+
+// pruneRatioAlerts evicts alert debounce entries older than cutoff, at most
+// once per ratio window. The caller holds s.mu.
+func (s *SurveillanceEngine) pruneRatioAlerts(now, cutoff time.Time) {
+	if now.Sub(s.ratioPrune) < s.ratioWindow {
+		return
+	}
+	s.ratioPrune = now
+	for userID, last := range s.ratioAlerts {
+		if !last.After(cutoff) {
+			delete(s.ratioAlerts, userID)
+		}
+	}
 }
 
 // =============================================================================
@@ -167,35 +532,335 @@ func (s *SurveillanceEngine) isRateLimited(userID string) bool {
 // AnalyzeTradePattern checks for suspicious trading patterns.
 // This is a stub - production would use ML/statistical analysis.
 func (s *SurveillanceEngine) AnalyzeTradePattern(userID, marketTicker string, orders []models.Order) []models.ComplianceAlert {
+	return s.tradePatternAlerts(userID, marketTicker, orders, nil)
+}
+
+// tradePatternAlerts is AnalyzeTradePattern for orders of which seen were
+// analyzed before. Patterns seen already showed were raised then and are
+// not raised again.
+func (s *SurveillanceEngine) tradePatternAlerts(userID, marketTicker string, orders, seen []models.Order) []models.ComplianceAlert {
 	var alerts []models.ComplianceAlert
+	known := make(map[string]bool, len(seen))
+	for _, order := range seen {
+		known[order.ID] = true
+	}
+	var unseen []models.Order
+	for _, order := range orders {
+		if !known[order.ID] {
+			unseen = append(unseen, order)
+		}
+	}
 
 	// Pattern 1: Wash trading detection (stub)
 	// Core Principle 4: Same user buying/selling to create false volume
-	if s.detectWashTrading(orders) {
+	if s.detectWashTrading(orders) && !s.detectWashTrading(seen) {
 		alert := s.store.CreateComplianceAlert(userID, marketTicker, "wash_trade", "high",
-			"Potential wash trading detected: rapid buy/sell pattern")
+			"Potential wash trading detected: opposing positions within 60 seconds")
 		alerts = append(alerts, *alert)
 	}
 
-	// Pattern 2: Spoofing detection (stub)
+	// Pattern 2: Spoofing detection, confirmed by the price move after
+	// the cancel
 	// Core Principle 4: Placing orders with intent to cancel
-	if s.detectSpoofing(orders) {
+	if spoof := s.detectSpoofing(unseen); spoof != nil {
 		alert := s.store.CreateComplianceAlert(userID, marketTicker, "spoofing", "high",
-			"Potential spoofing detected: large orders placed and cancelled")
+			fmt.Sprintf("Potential spoofing: %d-contract %s order cancelled within 10 seconds, then price moved %d¢ to %d¢",
+				spoof.order.Quantity, spoof.order.Side, spoof.from, spoof.to))
 		alerts = append(alerts, *alert)
 	}
 
 	// Pattern 3: Layering detection (stub)
 	// Core Principle 4: Multiple orders at different prices to influence
-	if s.detectLayering(orders) {
+	if levels := s.detectLayering(orders); levels > 0 && s.detectLayering(seen) == 0 {
 		alert := s.store.CreateComplianceAlert(userID, marketTicker, "layering", "medium",
-			"Potential layering detected: stacked orders at multiple price levels")
+			fmt.Sprintf("Potential layering: %d open orders at different price levels", levels))
 		alerts = append(alerts, *alert)
 	}
 
 	return alerts
 }
 
+// analysisOrderLimit bounds how many of a user's most recent orders
+// AnalyzeUser reads from the store.
+const analysisOrderLimit = 500
+
+// AnalyzeUser runs AnalyzeTradePattern over the user's recent orders, one
+// market at a time, or over marketTicker alone when it is set. Orders are
+// analyzed oldest first so the detectors see them in the order placed.
+// Alerts raised are stored and returned.
+func (s *SurveillanceEngine) AnalyzeUser(userID, marketTicker string) ([]models.ComplianceAlert, error) {
+	return s.analyzeOrders(userID, marketTicker, time.Time{})
+}
+
+// analyzeOrders is AnalyzeUser limited to orders created at or after since.
+func (s *SurveillanceEngine) analyzeOrders(userID, marketTicker string, since time.Time) ([]models.ComplianceAlert, error) {
+	orders, err := s.store.GetOrders(userID, nil, analysisOrderLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	byMarket := make(map[string][]models.Order)
+	for _, order := range orders {
+		if (marketTicker != "" && order.MarketTicker != marketTicker) || order.CreatedAt.Before(since) {
+			continue
+		}
+		byMarket[order.MarketTicker] = append(byMarket[order.MarketTicker], order)
+	}
+	tickers := make([]string, 0, len(byMarket))
+	for ticker := range byMarket {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	alerts := []models.ComplianceAlert{}
+	for _, ticker := range tickers {
+		marketOrders := byMarket[ticker]
+		sort.SliceStable(marketOrders, func(i, j int) bool {
+			return marketOrders[i].CreatedAt.Before(marketOrders[j].CreatedAt)
+		})
+		alerts = append(alerts, s.AnalyzeTradePattern(userID, ticker, marketOrders)...)
+	}
+	return alerts, nil
+}
+
+// analyzeNewOrders runs the trade-pattern detectors over the user's orders
+// in one market created at or after since, raising only patterns the
+// orders analyzed before did not already show. analyzed maps each order
+// analyzed before to its status then, so an order that has since filled or
+// been cancelled counts as new; it is replaced by the orders analyzed now.
+func (s *SurveillanceEngine) analyzeNewOrders(userID, marketTicker string, since time.Time, analyzed map[string]models.OrderStatus) {
+	orders, err := s.store.GetOrders(userID, nil, analysisOrderLimit)
+	if err != nil {
+		return
+	}
+	var current, seen []models.Order
+	for _, order := range orders {
+		if order.MarketTicker != marketTicker || order.CreatedAt.Before(since) {
+			continue
+		}
+		current = append(current, order)
+		if status, ok := analyzed[order.ID]; ok && status == order.Status {
+			seen = append(seen, order)
+		}
+	}
+	for _, list := range [][]models.Order{current, seen} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	}
+	s.tradePatternAlerts(userID, marketTicker, current, seen)
+
+	clear(analyzed)
+	for _, order := range current {
+		analyzed[order.ID] = order.Status
+	}
+}
+
+const (
+	// DefaultFillAnalysisDebounce is how long AnalyzeFills waits after a
+	// user's first fill before analyzing, so a burst of partial fills is
+	// analyzed once.
+	DefaultFillAnalysisDebounce = 2 * time.Second
+	// fillAnalysisLookback limits automatic analysis to recently placed
+	// orders. It covers every detector's window and is shorter than the
+	// store's alert dedup window, so old patterns are not flagged again.
+	fillAnalysisLookback = 5 * time.Minute
+)
+
+// AnalyzeFills runs trade-pattern analysis in the background for users
+// whose orders fill or positions settle, from the fill and settlement
+// events on ch; other events are ignored. Analysis waits debounce after a
+// user's first event and then covers every market the user traded
+// meanwhile. It runs on a worker goroutine so a slow analysis never holds
+// up reading ch, and only patterns involving orders not analyzed before are
+// raised, so repeated fills do not raise the same pattern again. Alerts are stored, and so
+// published, by the store. Pending analyses run when ch is closed, then
+// AnalyzeFills returns. Run it in its own goroutine.
+// Core Principle 4: Every fill is checked for manipulation, off the order
+// path.
+func (s *SurveillanceEngine) AnalyzeFills(ch <-chan events.Event, debounce time.Duration) {
+	pending := make(map[string]map[string]bool) // userID -> markets to analyze
+	due := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+
+	// Due analyses are handed to the worker through ready; wake has room
+	// for one signal, so handing over never blocks.
+	var readyMu sync.Mutex
+	ready := make(map[string]map[string]bool)
+	wake := make(chan struct{}, 1)
+	handOver := func(userID string) {
+		readyMu.Lock()
+		if ready[userID] == nil {
+			ready[userID] = make(map[string]bool)
+		}
+		for ticker := range pending[userID] {
+			ready[userID][ticker] = true
+		}
+		readyMu.Unlock()
+		delete(pending, userID)
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		analyzed := make(map[string]map[string]models.OrderStatus) // userID|market -> orders analyzed
+		drain := func() {
+			readyMu.Lock()
+			batch := ready
+			ready = make(map[string]map[string]bool)
+			readyMu.Unlock()
+			for userID, markets := range batch {
+				for ticker := range markets {
+					key := userID + "|" + ticker
+					if analyzed[key] == nil {
+						analyzed[key] = make(map[string]models.OrderStatus)
+					}
+					s.analyzeNewOrders(userID, ticker, s.now().Add(-fillAnalysisLookback), analyzed[key])
+					if len(analyzed[key]) == 0 {
+						delete(analyzed, key)
+					}
+				}
+			}
+		}
+		for range wake {
+			drain()
+		}
+		drain()
+	}()
+
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				for userID := range pending {
+					handOver(userID)
+				}
+				close(wake)
+				<-workerDone
+				return
+			}
+			ticker := eventMarket(e)
+			if e.UserID == "" || ticker == "" {
+				continue
+			}
+			markets, scheduled := pending[e.UserID]
+			if !scheduled {
+				markets = make(map[string]bool)
+				pending[e.UserID] = markets
+				userID := e.UserID
+				time.AfterFunc(debounce, func() {
+					select {
+					case due <- userID:
+					case <-done:
+					}
+				})
+			}
+			markets[ticker] = true
+		case userID := <-due:
+			handOver(userID)
+		}
+	}
+}
+
+// eventMarket returns the market a fill or settlement event belongs to, or
+// "" for other events.
+func eventMarket(e events.Event) string {
+	switch data := e.Data.(type) {
+	case models.Order:
+		if e.Type == events.TypeFill {
+			return data.MarketTicker
+		}
+	case models.Settlement:
+		if e.Type == events.TypeSettlement {
+			return data.MarketTicker
+		}
+	}
+	return ""
+}
+
+// SetMarketSource enables volume concentration checks against Kalshi market
+// data.
+func (s *SurveillanceEngine) SetMarketSource(markets MarketSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markets = markets
+}
+
+// volumeWindow is the window a user's filled volume is measured over, and
+// how long a volume concentration alert suppresses repeats.
+const volumeWindow = 24 * time.Hour
+
+// CheckVolumeConcentration compares the contracts a user filled in a market
+// over the last 24 hours with the market's Volume24H and raises an
+// unusual_activity alert when the user's share exceeds suspiciousVolumeRatio.
+// Alerts are debounced to one per user and market per volumeWindow, since
+// every further fill keeps the share above the threshold. It returns the
+// alert, or nil when the share is within the threshold, an alert was already
+// raised, or market data is unavailable.
+// Core Principle 4: A single participant dominating volume may be moving
+// the market.
+func (s *SurveillanceEngine) CheckVolumeConcentration(userID, marketTicker string) *models.ComplianceAlert {
+	s.mu.RLock()
+	markets, ratio, now := s.markets, s.suspiciousVolumeRatio, s.now()
+	s.mu.RUnlock()
+	if markets == nil || ratio <= 0 {
+		return nil
+	}
+
+	market, err := markets.GetMarket(marketTicker)
+	if err != nil || market.Volume24H <= 0 {
+		return nil
+	}
+	orders, err := s.store.GetOrders(userID, nil, 10000)
+	if err != nil {
+		return nil
+	}
+
+	cutoff := now.Add(-volumeWindow)
+	var userVolume int64
+	for _, order := range orders {
+		if order.MarketTicker == marketTicker && order.FilledQuantity > 0 && filledAt(order).After(cutoff) {
+			userVolume += int64(order.FilledQuantity)
+		}
+	}
+
+	share := float64(userVolume) / float64(market.Volume24H)
+	if share <= ratio {
+		return nil
+	}
+
+	key := userID + " " + marketTicker
+	s.mu.Lock()
+	s.pruneVolumeAlerts(now, cutoff)
+	if last, alerted := s.volumeAlerts[key]; alerted && last.After(cutoff) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.volumeAlerts[key] = now
+	s.mu.Unlock()
+
+	return s.store.CreateComplianceAlert(userID, marketTicker, "unusual_activity", "medium",
+		fmt.Sprintf("User filled %d of %d contracts traded in 24h (%.1f%% > %.1f%% threshold)",
+			userVolume, market.Volume24H, share*100, ratio*100))
+}
+
+// pruneVolumeAlerts evicts volume alert debounce entries older than cutoff,
+// at most once an hour. The caller holds s.mu.
+func (s *SurveillanceEngine) pruneVolumeAlerts(now, cutoff time.Time) {
+	if now.Sub(s.volumePrune) < time.Hour {
+		return
+	}
+	s.volumePrune = now
+	for key, last := range s.volumeAlerts {
+		if !last.After(cutoff) {
+			delete(s.volumeAlerts, key)
+		}
+	}
+}
+
 // detectWashTrading identifies potential wash trades.
 // Stub implementation - production uses statistical analysis.
 func (s *SurveillanceEngine) detectWashTrading(orders []models.Order) bool {
@@ -218,23 +883,113 @@ func (s *SurveillanceEngine) detectWashTrading(orders []models.Order) bool {
 	return false
 }
 
-// detectSpoofing identifies potential spoofing behavior.
-// Stub implementation.
-func (s *SurveillanceEngine) detectSpoofing(orders []models.Order) bool {
-	// In production: Check for large orders that get cancelled
-	// before execution, especially when price moves
-	cancelledLarge := 0
+const (
+	// spoofConfirmWindow is how long after a large cancel the price must
+	// move, and how far back the price before the cancel is looked for.
+	spoofConfirmWindow = 30 * time.Second
+	// spoofMinMoveCents is the smallest move that confirms a spoof.
+	spoofMinMoveCents = 3
+	// snapshotRetention is how long observed prices are kept for
+	// confirming spoofs. It covers fillAnalysisLookback.
+	snapshotRetention = 10 * time.Minute
+)
+
+// spoofMove is a large cancelled order and the price move that followed it.
+type spoofMove struct {
+	order    models.Order
+	from, to int
+}
+
+// detectSpoofing finds a large order cancelled within 10 seconds of
+// placement after which the price moved at least spoofMinMoveCents against
+// the order's side within spoofConfirmWindow: down after a YES order, up
+// after a NO order. The pull of resting size the market was leaning on is
+// what a spoofer profits from; a large cancel the price ignored is benign.
+// Cancels without snapshots on both sides are not confirmed. Returns nil
+// when no cancel is confirmed.
+func (s *SurveillanceEngine) detectSpoofing(orders []models.Order) *spoofMove {
+	s.mu.RLock()
+	source := s.snapshots
+	s.mu.RUnlock()
+
 	for _, order := range orders {
-		if order.Status == models.OrderStatusCancelled && order.Quantity > 100 {
-			cancelledLarge++
+		if order.Status != models.OrderStatusCancelled || order.Quantity <= 100 || order.CancelledAt == nil {
+			continue
+		}
+		if order.CancelledAt.Sub(order.CreatedAt) >= 10*time.Second {
+			continue
+		}
+
+		cancelled := *order.CancelledAt
+		before := -1
+		for _, snap := range source.Snapshots(order.MarketTicker, cancelled.Add(-spoofConfirmWindow), cancelled.Add(spoofConfirmWindow)) {
+			if !snap.At.After(cancelled) {
+				before = snap.PriceCents
+				continue
+			}
+			if before < 0 {
+				break
+			}
+			move := snap.PriceCents - before
+			if order.Side == models.OrderSideYes {
+				move = -move
+			}
+			if move >= spoofMinMoveCents {
+				return &spoofMove{order: order, from: before, to: snap.PriceCents}
+			}
 		}
 	}
-	return cancelledLarge > 3
+	return nil
 }
 
-// detectLayering identifies potential layering behavior.
+// SetSnapshotSource replaces the price history spoofing is confirmed
+// against, which by default is the engine's own Snapshots.
+func (s *SurveillanceEngine) SetSnapshotSource(source SnapshotSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = source
+}
+
+// Snapshots returns the market's prices from from to to, oldest first:
+// those ObservePrices recorded, kept for snapshotRetention, and the prices
+// of the store's fills, as YES prices. The market data poller only observes
+// the busiest Kalshi markets, so fills are what confirm spoofs elsewhere.
+func (s *SurveillanceEngine) Snapshots(marketTicker string, from, to time.Time) []MarketSnapshot {
+	s.mu.RLock()
+	var result []MarketSnapshot
+	for _, snap := range s.history[marketTicker] {
+		if !snap.At.Before(from) && !snap.At.After(to) {
+			result = append(result, snap)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, order := range s.store.MarketFills(marketTicker, from, to) {
+		price := order.FilledPriceCents
+		if price == 0 {
+			price = order.PriceCents
+		}
+		if order.Side == models.OrderSideNo {
+			price = 100 - price
+		}
+		result = append(result, MarketSnapshot{At: filledAt(order), PriceCents: price})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].At.Before(result[j].At) })
+	return result
+}
+
+// filledAt is when an order last filled: FilledAt once set, else UpdatedAt.
+func filledAt(order models.Order) time.Time {
+	if order.FilledAt != nil {
+		return *order.FilledAt
+	}
+	return order.UpdatedAt
+}
+
+// detectLayering identifies potential layering behavior and returns the number
+// of distinct open price levels when the pattern is present (0 otherwise).
 // Stub implementation.
-func (s *SurveillanceEngine) detectLayering(orders []models.Order) bool {
+func (s *SurveillanceEngine) detectLayering(orders []models.Order) int {
 	// In production: Check for multiple orders at incrementing prices
 	// that get cancelled after price moves
 	priceCount := make(map[int]int)
@@ -243,7 +998,10 @@ func (s *SurveillanceEngine) detectLayering(orders []models.Order) bool {
 			priceCount[order.PriceCents]++
 		}
 	}
-	return len(priceCount) > 5
+	if len(priceCount) > 5 {
+		return len(priceCount)
+	}
+	return 0
 }
 
 // =============================================================================
@@ -251,27 +1009,9 @@ func (s *SurveillanceEngine) detectLayering(orders []models.Order) bool {
 // Core Principle 5: Position Limits
 // =============================================================================
 
-// PositionLimitConfig defines limits per user tier.
-type PositionLimitConfig struct {
-	Tier         string  `json:"tier"`
-	MaxPositionUSD float64 `json:"max_position_usd"`
-	MaxOrderSize   int     `json:"max_order_size"`
-	DailyVolumeUSD float64 `json:"daily_volume_usd"`
-}
-
-// DefaultPositionLimits returns tiered limits.
-// Core Principle 5: Speculative position limits.
-func DefaultPositionLimits() []PositionLimitConfig {
-	return []PositionLimitConfig{
-		{Tier: "basic", MaxPositionUSD: 25000, MaxOrderSize: 500, DailyVolumeUSD: 10000},
-		{Tier: "standard", MaxPositionUSD: 100000, MaxOrderSize: 2000, DailyVolumeUSD: 50000},
-		{Tier: "professional", MaxPositionUSD: 500000, MaxOrderSize: 10000, DailyVolumeUSD: 250000},
-	}
-}
-
 // CheckPositionLimit validates against configured limits.
 // Core Principle 5: Prevents excessive concentration.
-func (s *SurveillanceEngine) CheckPositionLimit(userID, marketTicker string, additionalExposure float64) error {
+func (s *SurveillanceEngine) CheckPositionLimit(userID, marketTicker string, additionalExposure models.Money) error {
 	user, err := s.store.GetUser(userID)
 	if err != nil {
 		return err
@@ -281,13 +1021,192 @@ func (s *SurveillanceEngine) CheckPositionLimit(userID, marketTicker string, add
 	totalExposure := currentExposure + additionalExposure
 
 	if totalExposure > user.PositionLimitUSD {
-		return fmt.Errorf("position limit exceeded: $%.2f > $%.2f",
+		return fmt.Errorf("position limit exceeded: %s > %s",
 			totalExposure, user.PositionLimitUSD)
 	}
 
 	return nil
 }
 
+// SetBreachSuspension configures automatic suspension: a user who trips the
+// position limit threshold times within window is suspended. A threshold of
+// zero disables the rule.
+func (s *SurveillanceEngine) SetBreachSuspension(threshold int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breachThreshold = threshold
+	s.breachWindow = window
+}
+
+// RecordPositionLimitBreach counts a rejected order that would have exceeded
+// the user's position limit. Once the user reaches the configured number of
+// breaches within the window they are suspended and a critical alert is
+// raised. It reports whether the user was suspended.
+// Core Principles 4 and 5: Repeated limit violations are acted on, not just
+// logged.
+func (s *SurveillanceEngine) RecordPositionLimitBreach(userID, marketTicker string) bool {
+	s.mu.Lock()
+	if s.breachThreshold <= 0 {
+		s.mu.Unlock()
+		return false
+	}
+	now := s.now()
+	cutoff := now.Add(-s.breachWindow)
+	for id, timestamps := range s.breaches {
+		if !timestamps[len(timestamps)-1].After(cutoff) {
+			delete(s.breaches, id)
+		}
+	}
+	recent := s.breaches[userID][:0]
+	for _, ts := range s.breaches[userID] {
+		if ts.After(cutoff) {
+			recent = append(recent, ts)
+		}
+	}
+	recent = append(recent, now)
+	count, threshold, window := len(recent), s.breachThreshold, s.breachWindow
+	if count >= threshold {
+		delete(s.breaches, userID)
+	} else {
+		s.breaches[userID] = recent
+	}
+	s.mu.Unlock()
+
+	if count < threshold {
+		return false
+	}
+	if err := s.store.UpdateUserStatus(userID, models.UserStatusSuspended, ""); err != nil {
+		return false
+	}
+	s.store.CreateComplianceAlert(userID, marketTicker, "position_limit_suspension", "critical",
+		fmt.Sprintf("User suspended after %d position limit breaches within %s", count, window))
+	return true
+}
+
+// =============================================================================
+// CIRCUIT BREAKER
+// Core Principle 4: Automated halts on disorderly price moves
+// =============================================================================
+
+// recordSnapshots adds prices to the snapshot history and drops snapshots
+// older than snapshotRetention. The caller holds s.mu.
+func (s *SurveillanceEngine) recordSnapshots(prices map[string]int, now time.Time) {
+	cutoff := now.Add(-snapshotRetention)
+	for ticker, history := range s.history {
+		recent := history[:0]
+		for _, snap := range history {
+			if snap.At.After(cutoff) {
+				recent = append(recent, snap)
+			}
+		}
+		if len(recent) == 0 {
+			delete(s.history, ticker)
+			continue
+		}
+		s.history[ticker] = recent
+	}
+	for ticker, cents := range prices {
+		if cents > 0 {
+			s.history[ticker] = append(s.history[ticker], MarketSnapshot{At: now, PriceCents: cents})
+		}
+	}
+}
+
+type pricePoint struct {
+	at    time.Time
+	cents int
+}
+
+// SetCircuitBreaker halts a market for haltFor when its price moves more than
+// movePct percent away from its reference price, the oldest price observed
+// within window. A movePct of 0 disables the breaker.
+func (s *SurveillanceEngine) SetCircuitBreaker(movePct float64, window, haltFor time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakerMovePct = movePct
+	s.breakerWindow = window
+	s.breakerHalt = haltFor
+}
+
+// ObservePrices records the latest price for each market and trips the
+// breaker on markets that moved too far. Breaker halts carry an EndsAt, so
+// trading resumes on its own once the timer elapses. Markets already halted
+// by an operator are left alone, and their price history restarts once the
+// halt is lifted. Returns the halts initiated.
+func (s *SurveillanceEngine) ObservePrices(prices map[string]int) []*models.EmergencyHalt {
+	type trip struct {
+		ticker   string
+		from     int
+		to       int
+		duration time.Duration
+	}
+
+	halted := make(map[string]bool)
+	for ticker := range prices {
+		if s.store.IsTradingHalted(ticker) {
+			halted[ticker] = true
+		}
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	s.recordSnapshots(prices, now)
+	for ticker, endsAt := range s.breakers {
+		if !now.Before(endsAt) {
+			delete(s.breakers, ticker)
+		}
+	}
+
+	var trips []trip
+	if s.breakerMovePct > 0 {
+		cutoff := now.Add(-s.breakerWindow)
+		for ticker, cents := range prices {
+			if cents <= 0 {
+				continue
+			}
+			if _, tripped := s.breakers[ticker]; tripped {
+				continue
+			}
+			if halted[ticker] {
+				delete(s.prices, ticker)
+				continue
+			}
+			recent := s.prices[ticker][:0]
+			for _, p := range s.prices[ticker] {
+				if p.at.After(cutoff) {
+					recent = append(recent, p)
+				}
+			}
+			if len(recent) > 0 {
+				reference := recent[0].cents
+				move := cents - reference
+				if move < 0 {
+					move = -move
+				}
+				if float64(move)/float64(reference)*100 > s.breakerMovePct {
+					trips = append(trips, trip{ticker, reference, cents, s.breakerHalt})
+					s.breakers[ticker] = now.Add(s.breakerHalt)
+					delete(s.prices, ticker)
+					continue
+				}
+			}
+			s.prices[ticker] = append(recent, pricePoint{at: now, cents: cents})
+		}
+	}
+	movePct, window := s.breakerMovePct, s.breakerWindow
+	s.mu.Unlock()
+
+	var halts []*models.EmergencyHalt
+	for _, t := range trips {
+		reason := fmt.Sprintf("Circuit breaker: price moved %d¢ to %d¢ (over %.0f%%) within %s",
+			t.from, t.to, movePct, window)
+		halts = append(halts, s.store.InitiateEmergencyHalt(t.ticker, reason, "circuit_breaker", t.duration))
+		s.store.CreateComplianceAlert("", t.ticker, "circuit_breaker", "high",
+			fmt.Sprintf("%s; halted for %s", reason, t.duration))
+	}
+	return halts
+}
+
 // =============================================================================
 // EMERGENCY CONTROLS
 // Core Principle 4: Emergency authority
@@ -296,12 +1215,17 @@ func (s *SurveillanceEngine) CheckPositionLimit(userID, marketTicker string, add
 // HaltTrading initiates an emergency trading halt.
 // Core Principle 4: DCM must have emergency authority.
 func (s *SurveillanceEngine) HaltTrading(marketTicker, reason, initiatedBy string) *models.EmergencyHalt {
-	return s.store.InitiateEmergencyHalt(marketTicker, reason, initiatedBy)
+	return s.store.InitiateEmergencyHalt(marketTicker, reason, initiatedBy, 0)
+}
+
+// ResumeTrading lifts an emergency halt on behalf of liftedBy.
+func (s *SurveillanceEngine) ResumeTrading(marketTicker, liftedBy string) error {
+	return s.store.LiftEmergencyHalt(marketTicker, liftedBy)
 }
 
-// ResumeTrading lifts an emergency halt.
-func (s *SurveillanceEngine) ResumeTrading(marketTicker string) error {
-	return s.store.LiftEmergencyHalt(marketTicker)
+// IsHalted reports whether trading is halted for a market (or globally).
+func (s *SurveillanceEngine) IsHalted(marketTicker string) bool {
+	return s.store.IsTradingHalted(marketTicker)
 }
 
 // =============================================================================
@@ -2,10 +2,14 @@
 package compliance
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
 	"github.com/kalshi-dcm-demo/backend/internal/models"
 )
 
@@ -13,9 +17,164 @@ import (
 // TEST FIXTURES
 // =============================================================================
 
+// fakeStore is an in-memory Store used to exercise the engine in isolation.
+type fakeStore struct {
+	users   map[string]*models.User
+	wallets map[string]*models.Wallet
+	alerts  []models.ComplianceAlert
+	halts   map[string]*models.EmergencyHalt
+	orders  map[string][]models.Order
+	settled map[string]bool
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:   make(map[string]*models.User),
+		wallets: make(map[string]*models.Wallet),
+		halts:   make(map[string]*models.EmergencyHalt),
+		orders:  make(map[string][]models.Order),
+		settled: make(map[string]bool),
+		now:     time.Now,
+	}
+}
+
+// halted mirrors the store: a halt with a past EndsAt has lapsed.
+func (f *fakeStore) halted(key string) bool {
+	h, ok := f.halts[key]
+	return ok && h.IsActive && (h.EndsAt == nil || f.now().Before(*h.EndsAt))
+}
+
+// addUser registers a verified user with the given available balance.
+func (f *fakeStore) addUser(userID string, availableUSD, limitUSD float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[userID] = &models.User{ID: userID, Status: models.UserStatusVerified, PositionLimitUSD: models.Dollars(limitUSD)}
+	f.wallets[userID] = &models.Wallet{ID: "wallet_" + userID, UserID: userID, AvailableUSD: models.Dollars(availableUSD)}
+}
+
+func (f *fakeStore) GetWallet(userID string) (*models.Wallet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if w, ok := f.wallets[userID]; ok {
+		return w, nil
+	}
+	return nil, errors.New("wallet not found")
+}
+
+func (f *fakeStore) GetUser(userID string) (*models.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if u, ok := f.users[userID]; ok {
+		return u, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func (f *fakeStore) GetUserExposure(userID string) models.Money {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if w, ok := f.wallets[userID]; ok {
+		return w.LockedUSD
+	}
+	return 0
+}
+
+func (f *fakeStore) IsTradingHalted(marketTicker string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.halted("GLOBAL") || f.halted(marketTicker)
+}
+
+func (f *fakeStore) IsMarketSettled(marketTicker string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.settled[marketTicker]
+}
+
+func (f *fakeStore) GetAuditLog(userID string, since time.Time, limit int) []models.AuditEntry {
+	return nil
+}
+
+func (f *fakeStore) GetOrders(userID string, status *models.OrderStatus, limit int) ([]models.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.orders[userID], nil
+}
+
+func (f *fakeStore) MarketFills(marketTicker string, from, to time.Time) []models.Order {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []models.Order
+	for _, orders := range f.orders {
+		for _, order := range orders {
+			if at := filledAt(order); order.MarketTicker == marketTicker && order.FilledQuantity > 0 && !at.Before(from) && !at.After(to) {
+				result = append(result, order)
+			}
+		}
+	}
+	return result
+}
+
+func (f *fakeStore) CreateComplianceAlert(userID, marketTicker, alertType, severity, description string) *models.ComplianceAlert {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	alert := models.ComplianceAlert{
+		ID: "alert_test", Type: alertType, Severity: severity, UserID: userID,
+		MarketTicker: marketTicker, Description: description, Status: "open", CreatedAt: time.Now().UTC(),
+	}
+	f.alerts = append(f.alerts, alert)
+	return &alert
+}
+
+func (f *fakeStore) InitiateEmergencyHalt(marketTicker, reason, initiatedBy string, duration time.Duration) *models.EmergencyHalt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := marketTicker
+	if key == "" {
+		key = "GLOBAL"
+	}
+	halt := &models.EmergencyHalt{
+		ID: "halt_test", MarketTicker: marketTicker, Reason: reason,
+		InitiatedBy: initiatedBy, StartedAt: f.now().UTC(), IsActive: true,
+	}
+	if duration > 0 {
+		endsAt := halt.StartedAt.Add(duration)
+		halt.EndsAt = &endsAt
+	}
+	f.halts[key] = halt
+	return halt
+}
+
+func (f *fakeStore) LiftEmergencyHalt(marketTicker, liftedBy string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := marketTicker
+	if key == "" {
+		key = "GLOBAL"
+	}
+	if h, ok := f.halts[key]; ok {
+		h.IsActive = false
+	}
+	return nil
+}
+
+func (f *fakeStore) UpdateUserStatus(userID string, status models.UserStatus, ip string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[userID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	u.Status = status
+	return nil
+}
+
 func setupTestEngine() *SurveillanceEngine {
-	store := mock.NewStore()
-	return NewSurveillanceEngine(store)
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	return NewSurveillanceEngine(store, SurveillanceConfig{})
 }
 
 func createTestOrder(side models.OrderSide, qty, price int, createdAt time.Time) models.Order {
@@ -36,21 +195,9 @@ func createTestOrder(side models.OrderSide, qty, price int, createdAt time.Time)
 // =============================================================================
 
 func TestValidateOrder_PassesWithinLimits(t *testing.T) {
+	// Fixture user has a $25,000 position limit and $10,000 available
 	engine := setupTestEngine()
 
-	// Create user with position limit
-	store := engine.store
-	store.CreateUser(
-		"test@example.com",
-		"password",
-		"Test",
-		"User",
-		"NY",
-		time.Now().AddDate(-30, 0, 0),
-		true,
-		"127.0.0.1",
-	)
-
 	// Validate a small order
 	check := engine.ValidateOrder("user_123", "FED-RATE-MAR", models.OrderSideYes, 10, 50)
 
@@ -64,16 +211,37 @@ func TestValidateOrder_CalculatesCorrectMargin(t *testing.T) {
 
 	// YES side: margin = quantity * price
 	checkYes := engine.ValidateOrder("user_123", "FED-RATE-MAR", models.OrderSideYes, 100, 65)
-	expectedYesMargin := float64(100*65) / 100.0 // 65.00 USD
+	expectedYesMargin := models.Cents(100 * 65) // 65.00 USD
 	if checkYes.RequiredMargin != expectedYesMargin {
-		t.Errorf("YES margin: expected %.2f, got %.2f", expectedYesMargin, checkYes.RequiredMargin)
+		t.Errorf("YES margin: expected %s, got %s", expectedYesMargin, checkYes.RequiredMargin)
 	}
 
 	// NO side: margin = quantity * (100 - price)
 	checkNo := engine.ValidateOrder("user_123", "FED-RATE-MAR", models.OrderSideNo, 100, 65)
-	expectedNoMargin := float64(100*35) / 100.0 // 35.00 USD
+	expectedNoMargin := models.Cents(100 * 35) // 35.00 USD
 	if checkNo.RequiredMargin != expectedNoMargin {
-		t.Errorf("NO margin: expected %.2f, got %.2f", expectedNoMargin, checkNo.RequiredMargin)
+		t.Errorf("NO margin: expected %s, got %s", expectedNoMargin, checkNo.RequiredMargin)
+	}
+}
+
+// $0.10 locked plus a $0.20 order is exactly a $0.30 limit; as float64
+// dollars 0.1+0.2 > 0.3 and the order was wrongly rejected.
+func TestValidateOrder_ExposureExactlyAtLimitPasses(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 100, 0.30)
+	store.wallets["user_123"].LockedUSD = models.Cents(10)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+
+	check := engine.ValidateOrder("user_123", "FED-RATE-MAR", models.OrderSideYes, 1, 20)
+	if !check.Passed {
+		t.Errorf("order landing exactly on the limit rejected: %v", check.Errors)
+	}
+	check = engine.ValidateOrder("user_123", "FED-RATE-MAR", models.OrderSideYes, 1, 21)
+	if check.Passed {
+		t.Error("order one cent over the limit passed")
+	}
+	if err := engine.CheckPositionLimit("user_123", "FED-RATE-MAR", models.Cents(20)); err != nil {
+		t.Errorf("CheckPositionLimit at the limit: %v", err)
 	}
 }
 
@@ -142,7 +310,7 @@ func TestDetectWashTrading_IdentifiesOpposingTrades(t *testing.T) {
 
 	foundWashAlert := false
 	for _, alert := range alerts {
-		if alert == "Potential wash trading detected: opposing positions within 60 seconds" {
+		if alert.Description == "Potential wash trading detected: opposing positions within 60 seconds" {
 			foundWashAlert = true
 			break
 		}
@@ -153,6 +321,57 @@ func TestDetectWashTrading_IdentifiesOpposingTrades(t *testing.T) {
 	}
 }
 
+// Fills after a pattern was raised do not raise it again, even with the
+// store's alert dedup disabled, as the fake store's is.
+func TestAnalyzeFills_RaisesPatternOnce(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := time.Now()
+	fill := func(id string, side models.OrderSide, at time.Time) events.Event {
+		order := createTestOrder(side, 10, 50, at)
+		order.ID, order.Status = id, models.OrderStatusFilled
+		store.mu.Lock()
+		store.orders["user_123"] = append(store.orders["user_123"], order)
+		store.mu.Unlock()
+		return events.Event{Type: events.TypeFill, EntityID: id, UserID: "user_123", Data: order}
+	}
+	washAlerts := func() int {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		count := 0
+		for _, alert := range store.alerts {
+			if alert.Type == "wash_trade" {
+				count++
+			}
+		}
+		return count
+	}
+
+	ch := make(chan events.Event, 4)
+	done := make(chan struct{})
+	go func() {
+		engine.AnalyzeFills(ch, 0)
+		close(done)
+	}()
+	ch <- fill("order_1", models.OrderSideYes, now)
+	ch <- fill("order_2", models.OrderSideNo, now.Add(10*time.Second))
+	deadline := time.Now().Add(2 * time.Second)
+	for washAlerts() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no wash_trade alert after opposing fills")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ch <- fill("order_3", models.OrderSideYes, now.Add(20*time.Second))
+	close(ch)
+	<-done
+	if n := washAlerts(); n != 1 {
+		t.Errorf("%d wash_trade alerts, want 1", n)
+	}
+}
+
 func TestDetectWashTrading_IgnoresLegitimateHedges(t *testing.T) {
 	engine := setupTestEngine()
 	now := time.Now()
@@ -166,7 +385,7 @@ func TestDetectWashTrading_IgnoresLegitimateHedges(t *testing.T) {
 	alerts := engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", orders)
 
 	for _, alert := range alerts {
-		if alert == "Potential wash trading detected: opposing positions within 60 seconds" {
+		if alert.Description == "Potential wash trading detected: opposing positions within 60 seconds" {
 			t.Error("Should not detect wash trading for trades 5 minutes apart")
 		}
 	}
@@ -177,6 +396,29 @@ func TestDetectWashTrading_IgnoresLegitimateHedges(t *testing.T) {
 // Core Principle 4: Prevention of Market Disruption
 // =============================================================================
 
+// fakeSnapshots is an injected price history keyed by ticker.
+type fakeSnapshots map[string][]MarketSnapshot
+
+func (f fakeSnapshots) Snapshots(ticker string, from, to time.Time) []MarketSnapshot {
+	var result []MarketSnapshot
+	for _, snap := range f[ticker] {
+		if !snap.At.Before(from) && !snap.At.After(to) {
+			result = append(result, snap)
+		}
+	}
+	return result
+}
+
+func spoofAlerts(alerts []models.ComplianceAlert) int {
+	n := 0
+	for _, alert := range alerts {
+		if alert.Type == "spoofing" {
+			n++
+		}
+	}
+	return n
+}
+
 func TestDetectSpoofing_IdentifiesLargeCancelledOrders(t *testing.T) {
 	engine := setupTestEngine()
 	now := time.Now()
@@ -187,12 +429,18 @@ func TestDetectSpoofing_IdentifiesLargeCancelledOrders(t *testing.T) {
 	cancelTime := now.Add(5 * time.Second)
 	order.CancelledAt = &cancelTime
 
+	// The price the order was propping up falls once it is pulled
+	engine.SetSnapshotSource(fakeSnapshots{"FED-RATE-MAR": {
+		{At: now, PriceCents: 50},
+		{At: cancelTime.Add(10 * time.Second), PriceCents: 44},
+	}})
+
 	orders := []models.Order{order}
 	alerts := engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", orders)
 
 	foundSpoofAlert := false
 	for _, alert := range alerts {
-		if alert == "Potential spoofing: large order cancelled within 10 seconds" {
+		if alert.Type == "spoofing" && alert.Description == "Potential spoofing: 500-contract yes order cancelled within 10 seconds, then price moved 50¢ to 44¢" {
 			foundSpoofAlert = true
 			break
 		}
@@ -203,6 +451,37 @@ func TestDetectSpoofing_IdentifiesLargeCancelledOrders(t *testing.T) {
 	}
 }
 
+func TestDetectSpoofing_ConfirmedByStoreFills(t *testing.T) {
+	engine := setupTestEngine()
+	store := engine.store.(*fakeStore)
+	now := time.Now()
+
+	order := createTestOrder(models.OrderSideYes, 500, 50, now)
+	order.Status = models.OrderStatusCancelled
+	cancelTime := now.Add(5 * time.Second)
+	order.CancelledAt = &cancelTime
+
+	// No prices were observed for the market, but other users traded it
+	// before and after the cancel: a YES fill at 50¢, then a NO fill at
+	// 56¢, a YES price of 44¢.
+	fill := func(userID string, side models.OrderSide, price int, at time.Time) {
+		filled := createTestOrder(side, 10, price, at)
+		filled.UserID = userID
+		filled.Status = models.OrderStatusFilled
+		filled.FilledQuantity = 10
+		filled.FilledPriceCents = price
+		filled.FilledAt = &at
+		store.orders[userID] = append(store.orders[userID], filled)
+	}
+	fill("user_a", models.OrderSideYes, 50, now)
+	fill("user_b", models.OrderSideNo, 56, cancelTime.Add(10*time.Second))
+
+	alerts := engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", []models.Order{order})
+	if spoofAlerts(alerts) != 1 {
+		t.Fatalf("%d spoofing alerts, want 1", spoofAlerts(alerts))
+	}
+}
+
 func TestDetectSpoofing_IgnoresNormalCancellations(t *testing.T) {
 	engine := setupTestEngine()
 	now := time.Now()
@@ -217,12 +496,52 @@ func TestDetectSpoofing_IgnoresNormalCancellations(t *testing.T) {
 	alerts := engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", orders)
 
 	for _, alert := range alerts {
-		if alert == "Potential spoofing: large order cancelled within 10 seconds" {
+		if alert.Type == "spoofing" {
 			t.Error("Should not detect spoofing for small orders")
 		}
 	}
 }
 
+func TestDetectSpoofing_IgnoresLargeCancelWithoutPriceMove(t *testing.T) {
+	now := time.Now()
+	order := createTestOrder(models.OrderSideYes, 500, 50, now)
+	order.Status = models.OrderStatusCancelled
+	cancelTime := now.Add(5 * time.Second)
+	order.CancelledAt = &cancelTime
+
+	for name, history := range map[string][]MarketSnapshot{
+		"flat":          {{At: now, PriceCents: 50}, {At: cancelTime.Add(10 * time.Second), PriceCents: 49}},
+		"with the side": {{At: now, PriceCents: 50}, {At: cancelTime.Add(10 * time.Second), PriceCents: 60}},
+		"too late":      {{At: now, PriceCents: 50}, {At: cancelTime.Add(time.Minute), PriceCents: 40}},
+		"no snapshots":  nil,
+	} {
+		engine := setupTestEngine()
+		engine.SetSnapshotSource(fakeSnapshots{"FED-RATE-MAR": history})
+		if n := spoofAlerts(engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", []models.Order{order})); n != 0 {
+			t.Errorf("%s: %d spoofing alerts, want none for a cancel the price ignored", name, n)
+		}
+	}
+}
+
+func TestDetectSpoofing_ConfirmedByObservedPrices(t *testing.T) {
+	engine := setupTestEngine()
+	now := fakeClock(engine)
+
+	// A large NO order holds the YES price down until it is pulled
+	order := createTestOrder(models.OrderSideNo, 500, 60, *now)
+	order.Status = models.OrderStatusCancelled
+	engine.ObservePrices(map[string]int{"FED-RATE-MAR": 40})
+	*now = now.Add(5 * time.Second)
+	cancelTime := *now
+	order.CancelledAt = &cancelTime
+	*now = now.Add(5 * time.Second)
+	engine.ObservePrices(map[string]int{"FED-RATE-MAR": 47})
+
+	if n := spoofAlerts(engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", []models.Order{order})); n != 1 {
+		t.Errorf("%d spoofing alerts, want 1 after YES rose 40¢ to 47¢ on the NO cancel", n)
+	}
+}
+
 // =============================================================================
 // LAYERING DETECTION TESTS
 // Core Principle 4: Prevention of Market Disruption
@@ -246,7 +565,7 @@ func TestDetectLayering_IdentifiesStackedOrders(t *testing.T) {
 
 	foundLayeringAlert := false
 	for _, alert := range alerts {
-		if alert == "Potential layering: 6 open orders at different price levels" {
+		if alert.Description == "Potential layering: 6 open orders at different price levels" {
 			foundLayeringAlert = true
 			break
 		}
@@ -257,6 +576,172 @@ func TestDetectLayering_IdentifiesStackedOrders(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// RATE LIMIT TESTS
+// Core Principle 4: Order rate tracking must stay bounded
+// =============================================================================
+
+// fakeClock returns an engine clock that the test advances manually.
+func fakeClock(engine *SurveillanceEngine) *time.Time {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	return &now
+}
+
+func TestRateLimit_EvictsIdleUsers(t *testing.T) {
+	engine := setupTestEngine()
+	now := fakeClock(engine)
+
+	engine.RecordOrder("idle_user")
+	*now = now.Add(30 * time.Second)
+	engine.RecordOrder("active_user")
+	if len(engine.orderCounts) != 2 {
+		t.Fatalf("expected 2 tracked users, got %d", len(engine.orderCounts))
+	}
+
+	// After a full window without orders, idle_user is evicted on the next call.
+	*now = now.Add(rateWindow)
+	engine.RecordOrder("active_user")
+	if _, tracked := engine.orderCounts["idle_user"]; tracked {
+		t.Error("idle user was not evicted after the rate window")
+	}
+	if _, tracked := engine.orderCounts["active_user"]; !tracked {
+		t.Error("active user was evicted")
+	}
+}
+
+func TestRateLimit_BoundedUnderChurn(t *testing.T) {
+	engine := setupTestEngine()
+	now := fakeClock(engine)
+
+	// 10,000 one-off users spread over ten minutes.
+	for i := 0; i < 10000; i++ {
+		engine.RecordOrder(fmt.Sprintf("user_%d", i))
+		*now = now.Add(60 * time.Millisecond)
+	}
+	// Only users seen within roughly the last two windows can remain.
+	if n := len(engine.orderCounts); n > 2000 {
+		t.Errorf("orderCounts grew to %d users under churn", n)
+	}
+}
+
+func TestRateLimit_CapsTimestampsPerUser(t *testing.T) {
+	engine := setupTestEngine()
+	fakeClock(engine)
+
+	for i := 0; i < 1000; i++ {
+		engine.RecordOrder("user_123")
+	}
+	if !engine.IsRateLimited("user_123") {
+		t.Error("expected user to be rate limited")
+	}
+	if n := len(engine.orderCounts["user_123"]); n != engine.maxOrdersPerMinute {
+		t.Errorf("stored %d timestamps, want %d", n, engine.maxOrdersPerMinute)
+	}
+}
+
+func TestRateLimit_ReserveOrderIsAtomic(t *testing.T) {
+	engine := setupTestEngine()
+	fakeClock(engine)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	admitted := 0
+	for i := 0; i < 10*engine.maxOrdersPerMinute; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := engine.ReserveOrder("user_123"); ok {
+				mu.Lock()
+				admitted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if admitted != engine.maxOrdersPerMinute {
+		t.Errorf("admitted %d concurrent orders, want %d", admitted, engine.maxOrdersPerMinute)
+	}
+}
+
+func TestRateLimit_ReleasedReservationFreesSlot(t *testing.T) {
+	engine := setupTestEngine()
+	fakeClock(engine)
+
+	var release func()
+	for i := 0; i < engine.maxOrdersPerMinute; i++ {
+		r, ok := engine.ReserveOrder("user_123")
+		if !ok {
+			t.Fatalf("reservation %d refused", i+1)
+		}
+		release = r
+	}
+	if _, ok := engine.ReserveOrder("user_123"); ok {
+		t.Fatal("reservation over the limit was admitted")
+	}
+
+	// A rejected order gives its slot back; releasing twice is a no-op.
+	release()
+	release()
+	if _, ok := engine.ReserveOrder("user_123"); !ok {
+		t.Error("released slot was not reusable")
+	}
+	if _, ok := engine.ReserveOrder("user_123"); ok {
+		t.Error("double release freed a second slot")
+	}
+}
+
+func TestRateLimit_ValidateOrderDoesNotConsumeBudget(t *testing.T) {
+	engine := setupTestEngine()
+
+	for i := 0; i < 100; i++ {
+		check := engine.ValidateOrder("user_123", "FED-RATE-MAR", models.OrderSideYes, 1, 50)
+		if !check.Passed {
+			t.Fatalf("pre-trade check %d failed: %v", i+1, check.Errors)
+		}
+	}
+	if engine.IsRateLimited("user_123") {
+		t.Error("pre-trade checks tripped the rate limiter")
+	}
+}
+
+func TestRateLimit_BurstRaisesOneAlert(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+
+	for i := 0; i < engine.maxOrdersPerMinute; i++ {
+		engine.RecordOrder("user_123")
+	}
+	// A burst of rejected orders within the same minute.
+	for i := 0; i < 200; i++ {
+		if engine.IsRateLimited("user_123") {
+			engine.ReportRateLimited("user_123", "FED-24DEC")
+		}
+	}
+	countRateAlerts := func() int {
+		n := 0
+		for _, alert := range store.alerts {
+			if alert.Type == "rate_limit" && alert.Severity == "medium" && alert.UserID == "user_123" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := countRateAlerts(); n != 1 {
+		t.Fatalf("expected 1 rate_limit alert for the burst, got %d", n)
+	}
+
+	// A new burst after the debounce window alerts again.
+	*now = now.Add(rateWindow + time.Second)
+	engine.ReportRateLimited("user_123", "FED-24DEC")
+	if n := countRateAlerts(); n != 2 {
+		t.Errorf("expected a second alert after the window, got %d", n)
+	}
+}
+
 // =============================================================================
 // EMERGENCY HALT TESTS
 // Core Principle 4: Prevention of Market Disruption
@@ -311,7 +796,7 @@ func TestEmergencyHalt_ResumeTrading(t *testing.T) {
 		t.Error("Trading should be halted")
 	}
 
-	engine.ResumeTrading("FED-RATE-MAR")
+	engine.ResumeTrading("FED-RATE-MAR", "ops")
 
 	if engine.IsHalted("FED-RATE-MAR") {
 		t.Error("Trading should be resumed")
@@ -334,9 +819,9 @@ func TestPreTradeCheck_VerifiesCollateral(t *testing.T) {
 
 	// Verify margin is 100% collateralized
 	// 10 contracts * 50 cents = 500 cents = $5.00
-	expectedMargin := 5.0
+	expectedMargin := models.Dollars(5)
 	if smallCheck.RequiredMargin != expectedMargin {
-		t.Errorf("Expected margin $%.2f, got $%.2f", expectedMargin, smallCheck.RequiredMargin)
+		t.Errorf("Expected margin %s, got %s", expectedMargin, smallCheck.RequiredMargin)
 	}
 }
 
@@ -390,3 +875,365 @@ func BenchmarkAnalyzeTradePattern(b *testing.B) {
 		engine.AnalyzeTradePattern("user_123", "FED-RATE-MAR", orders)
 	}
 }
+
+// fakeMarkets serves fixed 24h volumes per ticker.
+type fakeMarkets map[string]int64
+
+func (f fakeMarkets) GetMarket(ticker string) (*kalshi.KalshiMarketResponse, error) {
+	volume, ok := f[ticker]
+	if !ok {
+		return nil, kalshi.ErrKalshiNotFound
+	}
+	return &kalshi.KalshiMarketResponse{Ticker: ticker, Volume24H: volume}, nil
+}
+
+func TestVolumeConcentration_AlertsAboveRatio(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+	engine.SetMarketSource(fakeMarkets{"FED-24DEC": 1000})
+
+	fill := func(qty int) {
+		store.orders["user_123"] = append(store.orders["user_123"], models.Order{
+			UserID: "user_123", MarketTicker: "FED-24DEC", Status: models.OrderStatusFilled,
+			Quantity: qty, FilledQuantity: qty, UpdatedAt: *now,
+		})
+	}
+
+	// 100 of 1000 contracts is exactly 10%: not above the threshold.
+	fill(100)
+	if alert := engine.CheckVolumeConcentration("user_123", "FED-24DEC"); alert != nil {
+		t.Fatalf("alerted at exactly the threshold: %s", alert.Description)
+	}
+
+	fill(1)
+	alert := engine.CheckVolumeConcentration("user_123", "FED-24DEC")
+	if alert == nil || alert.Type != "unusual_activity" {
+		t.Fatalf("expected unusual_activity alert above 10%%, got %+v", alert)
+	}
+
+	// Further fills within the window do not raise fresh alerts.
+	fill(50)
+	*now = now.Add(time.Hour)
+	if alert := engine.CheckVolumeConcentration("user_123", "FED-24DEC"); alert != nil {
+		t.Errorf("repeat alert within the window: %s", alert.Description)
+	}
+	if got := len(store.alerts); got != 1 {
+		t.Errorf("alerts = %d, want 1", got)
+	}
+
+	// Fills older than 24 hours do not count.
+	*now = now.Add(25 * time.Hour)
+	if alert := engine.CheckVolumeConcentration("user_123", "FED-24DEC"); alert != nil {
+		t.Errorf("stale fills counted: %s", alert.Description)
+	}
+
+	// Once the window has passed, a new concentration alerts again.
+	fill(200)
+	if engine.CheckVolumeConcentration("user_123", "FED-24DEC") == nil {
+		t.Error("no alert after the debounce window")
+	}
+}
+
+func TestVolumeConcentration_ConfigurableRatio(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	store.orders["user_123"] = []models.Order{{
+		UserID: "user_123", MarketTicker: "FED-24DEC", FilledQuantity: 150, UpdatedAt: time.Now(),
+	}}
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{SuspiciousVolumeRatio: 0.20})
+	engine.SetMarketSource(fakeMarkets{"FED-24DEC": 1000})
+	if engine.CheckVolumeConcentration("user_123", "FED-24DEC") != nil {
+		t.Error("15% share alerted with a 20% ratio")
+	}
+
+	engine = NewSurveillanceEngine(store, SurveillanceConfig{SuspiciousVolumeRatio: 0.05})
+	engine.SetMarketSource(fakeMarkets{"FED-24DEC": 1000})
+	if engine.CheckVolumeConcentration("user_123", "FED-24DEC") == nil {
+		t.Error("15% share did not alert with a 5% ratio")
+	}
+	if engine.CheckVolumeConcentration("user_123", "UNKNOWN") != nil {
+		t.Error("alerted without market data")
+	}
+}
+
+func TestVolumeConcentration_WindowsByFillTime(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+	engine.SetMarketSource(fakeMarkets{"FED-24DEC": 1000})
+
+	// Filled two days ago but touched since: the fill is outside the window.
+	filled := now.Add(-48 * time.Hour)
+	store.orders["user_123"] = []models.Order{{
+		UserID: "user_123", MarketTicker: "FED-24DEC", Status: models.OrderStatusFilled,
+		Quantity: 500, FilledQuantity: 500, FilledAt: &filled, UpdatedAt: *now,
+	}}
+	if alert := engine.CheckVolumeConcentration("user_123", "FED-24DEC"); alert != nil {
+		t.Fatalf("stale fill counted by its update time: %s", alert.Description)
+	}
+
+	recent := now.Add(-time.Hour)
+	store.orders["user_123"][0].FilledAt = &recent
+	if engine.CheckVolumeConcentration("user_123", "FED-24DEC") == nil {
+		t.Error("recent fill not counted")
+	}
+}
+
+// =============================================================================
+// POSITION LIMIT BREACH TESTS
+// Core Principles 4 and 5: Repeated breaches lead to suspension
+// =============================================================================
+
+func TestPositionLimitBreach_SuspendsRepeatOffender(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	engine.SetBreachSuspension(3, 10*time.Minute)
+	now := fakeClock(engine)
+
+	// Two breaches, then the window passes: the count starts over.
+	engine.RecordPositionLimitBreach("user_123", "FED-24DEC")
+	engine.RecordPositionLimitBreach("user_123", "FED-24DEC")
+	*now = now.Add(11 * time.Minute)
+	if engine.RecordPositionLimitBreach("user_123", "FED-24DEC") {
+		t.Fatal("suspended for breaches spread beyond the window")
+	}
+
+	*now = now.Add(time.Minute)
+	engine.RecordPositionLimitBreach("user_123", "FED-24DEC")
+	*now = now.Add(time.Minute)
+	if !engine.RecordPositionLimitBreach("user_123", "FED-24DEC") {
+		t.Fatal("expected suspension on the third breach within the window")
+	}
+
+	user, _ := store.GetUser("user_123")
+	if user.Status != models.UserStatusSuspended {
+		t.Errorf("user status = %s, want suspended", user.Status)
+	}
+	critical := 0
+	for _, alert := range store.alerts {
+		if alert.Type == "position_limit_suspension" && alert.Severity == "critical" {
+			critical++
+		}
+	}
+	if critical != 1 {
+		t.Errorf("expected 1 critical suspension alert, got %d", critical)
+	}
+}
+
+func TestPositionLimitBreach_DisabledWithZeroThreshold(t *testing.T) {
+	engine := setupTestEngine()
+	engine.SetBreachSuspension(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		if engine.RecordPositionLimitBreach("user_123", "FED-24DEC") {
+			t.Fatal("suspended with the rule disabled")
+		}
+	}
+}
+
+func TestCircuitBreaker_HaltsOnPriceSpikeAndLifts(t *testing.T) {
+	store := newFakeStore()
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+	store.now = engine.now
+	engine.SetCircuitBreaker(25, 5*time.Minute, 10*time.Minute)
+
+	// Gradual drift stays under the threshold
+	for _, cents := range []int{40, 42, 45} {
+		if halts := engine.ObservePrices(map[string]int{"FED-24DEC": cents, "CPI-24DEC": 60}); len(halts) != 0 {
+			t.Fatalf("halted at %d¢", cents)
+		}
+		*now = now.Add(time.Minute)
+	}
+
+	// 40¢ -> 55¢ within the window is a 37.5% move
+	halts := engine.ObservePrices(map[string]int{"FED-24DEC": 55, "CPI-24DEC": 60})
+	if len(halts) != 1 || halts[0].MarketTicker != "FED-24DEC" || halts[0].EndsAt == nil {
+		t.Fatalf("halts = %+v, want one timed halt on FED-24DEC", halts)
+	}
+	if !store.IsTradingHalted("FED-24DEC") || store.IsTradingHalted("CPI-24DEC") {
+		t.Error("only FED-24DEC should be halted")
+	}
+	if len(store.alerts) != 1 || store.alerts[0].Type != "circuit_breaker" || store.alerts[0].MarketTicker != "FED-24DEC" {
+		t.Errorf("alerts = %+v, want one circuit_breaker alert", store.alerts)
+	}
+
+	*now = now.Add(9 * time.Minute)
+	engine.ObservePrices(map[string]int{"FED-24DEC": 20})
+	if !store.IsTradingHalted("FED-24DEC") {
+		t.Error("halt lifted before its timer elapsed")
+	}
+
+	*now = now.Add(time.Minute)
+	engine.ObservePrices(map[string]int{"FED-24DEC": 55})
+	if store.IsTradingHalted("FED-24DEC") {
+		t.Error("halt should lift once its timer elapses")
+	}
+}
+
+func TestCircuitBreaker_LeavesManualHaltInPlace(t *testing.T) {
+	store := newFakeStore()
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+	store.now = engine.now
+	engine.SetCircuitBreaker(25, 5*time.Minute, 10*time.Minute)
+
+	engine.ObservePrices(map[string]int{"FED-24DEC": 40})
+	engine.HaltTrading("FED-24DEC", "operator review", "ops")
+	*now = now.Add(time.Minute)
+	if halts := engine.ObservePrices(map[string]int{"FED-24DEC": 80}); len(halts) != 0 {
+		t.Fatalf("breaker tripped on a manually halted market: %+v", halts)
+	}
+	if h := store.halts["FED-24DEC"]; h.InitiatedBy != "ops" || h.EndsAt != nil {
+		t.Errorf("halt = %+v, want the open-ended operator halt", h)
+	}
+
+	*now = now.Add(time.Hour)
+	if !store.IsTradingHalted("FED-24DEC") {
+		t.Error("operator halt lapsed without being lifted")
+	}
+}
+
+func TestCircuitBreaker_IgnoresMovesOutsideWindow(t *testing.T) {
+	store := newFakeStore()
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+	engine.SetCircuitBreaker(25, 5*time.Minute, 10*time.Minute)
+
+	engine.ObservePrices(map[string]int{"FED-24DEC": 40})
+	*now = now.Add(6 * time.Minute)
+	if halts := engine.ObservePrices(map[string]int{"FED-24DEC": 60}); len(halts) != 0 {
+		t.Errorf("halted on a move spread over longer than the window: %+v", halts)
+	}
+}
+
+func TestCircuitBreaker_MeasuresFromReferencePrice(t *testing.T) {
+	store := newFakeStore()
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{})
+	now := fakeClock(engine)
+	store.now = engine.now
+	engine.SetCircuitBreaker(20, 5*time.Minute, 10*time.Minute)
+
+	// 60¢ -> 48¢ is 20% of the 60¢ reference, though 25% of the low
+	engine.ObservePrices(map[string]int{"FED-24DEC": 60})
+	*now = now.Add(time.Minute)
+	if halts := engine.ObservePrices(map[string]int{"FED-24DEC": 48}); len(halts) != 0 {
+		t.Fatalf("halted on a 20%% move: %+v", halts)
+	}
+
+	// 60¢ -> 47¢ is past it
+	*now = now.Add(time.Minute)
+	halts := engine.ObservePrices(map[string]int{"FED-24DEC": 47})
+	if len(halts) != 1 || halts[0].Reason != "Circuit breaker: price moved 60¢ to 47¢ (over 20%) within 5m0s" {
+		t.Fatalf("halts = %+v, want one from 60¢ to 47¢", halts)
+	}
+}
+
+func TestRateLimit_RestoredEngineStillLimits(t *testing.T) {
+	engine := setupTestEngine()
+	now := fakeClock(engine)
+
+	engine.RecordOrder("user_stale")
+	*now = now.Add(rateWindow + time.Second)
+	for i := 0; i < engine.maxOrdersPerMinute-1; i++ {
+		engine.RecordOrder("user_123")
+	}
+
+	rates := engine.OrderRates()
+	if _, ok := rates["user_stale"]; ok || len(rates["user_123"]) != engine.maxOrdersPerMinute-1 {
+		t.Fatalf("saved rates hold %d users, %d for user_123; want only the active window", len(rates), len(rates["user_123"]))
+	}
+
+	restored := setupTestEngine()
+	restored.now = engine.now
+	restored.RestoreOrderRates(rates)
+	if restored.IsRateLimited("user_123") {
+		t.Fatal("one order below the cap should not be limited yet")
+	}
+	restored.RecordOrder("user_123")
+	if !restored.IsRateLimited("user_123") {
+		t.Error("restored engine forgot the user's recent orders")
+	}
+
+	// Saved times that have aged out of the window are not restored
+	later := setupTestEngine()
+	clock := fakeClock(later)
+	*clock = now.Add(rateWindow)
+	later.RestoreOrderRates(rates)
+	if len(later.orderCounts) != 0 {
+		t.Errorf("restored %d users from an expired window", len(later.orderCounts))
+	}
+}
+
+func TestRateLimit_ConfiguredTripPoint(t *testing.T) {
+	store := newFakeStore()
+	store.addUser("user_123", 10000, 25000)
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{MaxOrdersPerMinute: 5})
+	fakeClock(engine)
+
+	for i := 0; i < 4; i++ {
+		engine.RecordOrder("user_123")
+	}
+	if engine.IsRateLimited("user_123") {
+		t.Fatal("limited after 4 of 5 orders")
+	}
+	engine.RecordOrder("user_123")
+	if !engine.IsRateLimited("user_123") {
+		t.Error("not limited after the configured 5 orders")
+	}
+
+	if got := NewSurveillanceEngine(store, SurveillanceConfig{}).maxOrdersPerMinute; got != DefaultMaxOrdersPerMinute {
+		t.Errorf("zero config limit = %d, want the default %d", got, DefaultMaxOrdersPerMinute)
+	}
+}
+
+func TestOrderRatio_ManyCancelsFewFillsRaisesAlert(t *testing.T) {
+	store := newFakeStore()
+	engine := NewSurveillanceEngine(store, SurveillanceConfig{MaxCancelFillRatio: 5, OrderRatioWindow: 10 * time.Minute})
+	now := fakeClock(engine)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	addOrder := func(order models.Order) {
+		order.ID = fmt.Sprintf("order_%d", len(store.orders["user_123"]))
+		order.UserID, order.UpdatedAt = "user_123", now.Add(-time.Minute)
+		store.orders["user_123"] = append(store.orders["user_123"], order)
+	}
+	addOrder(models.Order{MarketTicker: "FED-24DEC", Status: models.OrderStatusFilled, FilledQuantity: 1, FilledAt: at(-time.Minute)})
+	addOrder(models.Order{MarketTicker: "FED-24DEC", Status: models.OrderStatusPartial, FilledQuantity: 1})
+	// 6 amends and 4 cancels are 10 cancels to 2 fills, within 5:1
+	addOrder(models.Order{MarketTicker: "FED-24DEC", Status: models.OrderStatusPending,
+		AmendedAt: []time.Time{*at(-5 * time.Minute), *at(-4 * time.Minute), *at(-3 * time.Minute), *at(-2 * time.Minute), *at(-time.Minute), *at(0)}})
+	for i := 0; i < 4; i++ {
+		addOrder(models.Order{MarketTicker: "FED-24DEC", Status: models.OrderStatusCancelled, CancelledAt: at(-time.Minute)})
+	}
+	// Cancels outside the window or by a market's settlement do not count
+	addOrder(models.Order{MarketTicker: "FED-24DEC", Status: models.OrderStatusCancelled, CancelledAt: at(-11 * time.Minute)})
+	store.settled["CPI-24NOV"] = true
+	addOrder(models.Order{MarketTicker: "CPI-24NOV", Status: models.OrderStatusCancelled, CancelledAt: at(-time.Minute)})
+	if engine.CheckOrderRatio("user_123", "FED-24DEC") {
+		t.Fatal("alert at 10 cancels and amends to 2 fills, limit is 5:1")
+	}
+
+	addOrder(models.Order{MarketTicker: "FED-24DEC", Status: models.OrderStatusCancelled, CancelledAt: at(0)})
+	if !engine.CheckOrderRatio("user_123", "FED-24DEC") {
+		t.Fatal("no alert at 11 cancels and amends to 2 fills")
+	}
+	if engine.CheckOrderRatio("user_123", "FED-24DEC") {
+		t.Error("second alert within the window, want one per window")
+	}
+	if len(store.alerts) != 1 || store.alerts[0].Type != "excessive_order_ratio" || store.alerts[0].MarketTicker != "FED-24DEC" ||
+		store.alerts[0].Description != "11 cancels and amends to 2 fills in 10m0s exceeds the 5:1 order-to-trade limit" {
+		t.Fatalf("alerts = %+v, want one excessive_order_ratio on FED-24DEC", store.alerts)
+	}
+
+	// Past the window nothing counts
+	*now = now.Add(11 * time.Minute)
+	if engine.CheckOrderRatio("user_123", "FED-24DEC") {
+		t.Error("alert with no activity in the window")
+	}
+}
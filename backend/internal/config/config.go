@@ -5,6 +5,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -24,6 +25,12 @@ type Config struct {
 	TLSEnabled      bool
 	TLSCertFile     string
 	TLSKeyFile      string
+	LogFormat       string // json (default) or text
+	LogLevel        string // debug, info, warn, error
+	MetricsEnabled  bool   // Expose Prometheus metrics at /metrics
+	MaxBodyBytes    int64  // Largest accepted request body
+	HandlerTimeout  time.Duration // Deadline for each API request's upstream calls
+	SeedDemo        bool   // Create demo accounts when the store has no users
 
 	// Active exchange configuration
 	ActiveExchange  Exchange
@@ -49,7 +56,12 @@ type Config struct {
 	// CP 18: Recordkeeping - 5-year retention simulation
 	DataDir             string
 	EnablePersistence   bool
+	PersistenceBackend  string // file, sqlite (requires -tags sqlite)
 	AuditRetentionDays  int
+	SnapshotKeepDays    int
+	MaintenanceInterval time.Duration
+	CompressSnapshots   bool
+	FieldEncryptionKey  string // base64 AES-256 key sealing KYC document numbers at rest
 
 	// WebSocket settings
 	WSPingInterval      time.Duration
@@ -58,27 +70,80 @@ type Config struct {
 
 	// Compliance settings
 	// CP 5: Position Limits
-	DefaultPositionLimit float64
 	MaxPositionLimit     float64
+	PositionBreachLimit  int           // Limit breaches within the window before suspension (0 disables)
+	PositionBreachWindow time.Duration
 	// CP 11: Financial Integrity
 	MinCollateralRatio   float64 // 1.0 = 100%
+	TradeFeePerContract  float64 // USD per contract filled
+	TradeFeePercent      float64 // Percent of order collateral
+	SettlementFeePercent float64 // Percent of settlement profit
 	// CP 4: Market Disruption Prevention
-	RateLimitPerUser     int // Orders per minute
+	RateLimitPerUser     int    // Orders per minute
+	SelfTradePolicy      string // cancel-newest, cancel-oldest, decrement-both
+	OrderTTL             time.Duration // Resting orders expire this long after placement; 0 never
+	WashSetupContracts   int    // Contracts held on both sides of one market before a wash_setup alert (0 disables)
+	AlertDedupWindow     time.Duration // Repeats of an active alert within this fold into it (0 disables)
+	CostBasisMethod      string // average or fifo; cost relieved when part of a position is closed
 	AnomalyThreshold     float64
+	CancelFillRatio      float64       // Cancels and amends per fill before an excessive_order_ratio alert
+	OrderRatioWindow     time.Duration // Window the order-to-trade ratio is measured over
+	CircuitBreakerPct    float64       // Price move (percent of the window's first price) that halts a market; 0 disables
+	CircuitBreakerWindow time.Duration // Window the move must happen within
+	CircuitBreakerHalt   time.Duration // How long the market stays halted
+	// CP 3: Risk classification lists (empty = built-in economic binary lists)
+	RiskLowCategories    []string
+	RiskLowSeries        []string
+	RiskMediumCategories []string
+
+	// Settlement settings
+	// CP 11: Positions settle automatically once Kalshi reports a result
+	SettlementPollInterval time.Duration // 0 disables the poller
+
+	// Funds movement settings
+	// CP 13: Deposits clear and withdrawals are held as pending
+	DepositClearingDelay time.Duration // ACH; 0 credits deposits immediately
+	WireClearingDelay    time.Duration
+	CardClearingDelay    time.Duration
+	DepositMethods       []string      // Accepted deposit methods; empty accepts ach, wire and card
+	WithdrawalHold       time.Duration // 0 completes withdrawals immediately
+	PendingSweepInterval time.Duration // Falls back to the older WITHDRAWAL_SWEEP_INTERVAL
+	IdempotencyTTL       time.Duration // How long deposit/withdrawal Idempotency-Keys are remembered
+
+	// Operator access
+	// CP 17: Accounts allowed to use /api/v1/admin routes
+	AdminUserIDs []string
+
+	// Session mode
+	// CP 17: bearer (Authorization header only) or cookie (session cookie + CSRF)
+	AuthMode string
+	// CP 17: Tokens unused this long are rejected before they expire (0 disables)
+	SessionIdleTimeout time.Duration
 
 	// CORS
 	AllowedOrigins []string
+
+	// Outbound notifications
+	// CP 4: High-severity alerts and halts are pushed to operators
+	WebhookURL    string // empty disables webhooks
+	WebhookSecret string // HMAC-SHA256 key for the X-DCM-Signature header
 }
 
 // Load creates configuration from environment variables with defaults
 func Load() *Config {
 	return &Config{
 		// Server
-		Port:        getEnv("PORT", "8080"),
-		Environment: getEnv("ENVIRONMENT", "development"),
-		TLSEnabled:  getEnvBool("TLS_ENABLED", false),
-		TLSCertFile: getEnv("TLS_CERT_FILE", ""),
-		TLSKeyFile:  getEnv("TLS_KEY_FILE", ""),
+		Port:           getEnv("PORT", "8080"),
+		Environment:    getEnv("ENVIRONMENT", "development"),
+		TLSEnabled:     getEnvBool("TLS_ENABLED", false),
+		TLSCertFile:    getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:     getEnv("TLS_KEY_FILE", ""),
+		LogFormat:      getEnv("LOG_FORMAT", "json"),
+		LogLevel:       getEnv("LOG_LEVEL", "info"),
+		MetricsEnabled: getEnvBool("METRICS_ENABLED", false),
+		SeedDemo:       getEnvBool("SEED_DEMO", false),
+		MaxBodyBytes:   int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		HandlerTimeout: getEnvDuration("HANDLER_TIMEOUT", 10*time.Second),
 
 		// Exchange selection
 		ActiveExchange: Exchange(getEnv("ACTIVE_EXCHANGE", "kalshi")),
@@ -100,9 +165,14 @@ func Load() *Config {
 		CryptoComTimeout:   getEnvDuration("CRYPTOCOM_TIMEOUT", 30*time.Second),
 
 		// Persistence
-		DataDir:            getEnv("DATA_DIR", "./data"),
-		EnablePersistence:  getEnvBool("ENABLE_PERSISTENCE", true),
-		AuditRetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 1825), // 5 years
+		DataDir:             getEnv("DATA_DIR", "./data"),
+		EnablePersistence:   getEnvBool("ENABLE_PERSISTENCE", true),
+		PersistenceBackend:  getEnv("PERSISTENCE_BACKEND", "file"),
+		AuditRetentionDays:  getEnvInt("AUDIT_RETENTION_DAYS", 1825), // 5 years
+		SnapshotKeepDays:    getEnvInt("SNAPSHOT_KEEP_DAYS", 30),
+		MaintenanceInterval: getEnvDuration("PERSISTENCE_MAINTENANCE_INTERVAL", 1*time.Hour),
+		CompressSnapshots:   getEnvBool("COMPRESS_SNAPSHOTS", false),
+		FieldEncryptionKey:  getEnv("FIELD_ENCRYPTION_KEY", ""),
 
 		// WebSocket
 		WSPingInterval:   getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
@@ -110,11 +180,51 @@ func Load() *Config {
 		WSMaxMessageSize: int64(getEnvInt("WS_MAX_MESSAGE_SIZE", 512*1024)),
 
 		// Compliance
-		DefaultPositionLimit: getEnvFloat("DEFAULT_POSITION_LIMIT", 25000.0),
 		MaxPositionLimit:     getEnvFloat("MAX_POSITION_LIMIT", 250000.0),
+		PositionBreachLimit:  getEnvInt("POSITION_BREACH_LIMIT", 3),
+		PositionBreachWindow: getEnvDuration("POSITION_BREACH_WINDOW", 1*time.Hour),
 		MinCollateralRatio:   getEnvFloat("MIN_COLLATERAL_RATIO", 1.0),
+		TradeFeePerContract:  getEnvFloat("TRADE_FEE_PER_CONTRACT", 0),
+		TradeFeePercent:      getEnvFloat("TRADE_FEE_PERCENT", 0),
+		SettlementFeePercent: getEnvFloat("SETTLEMENT_FEE_PERCENT", 0),
 		RateLimitPerUser:     getEnvInt("RATE_LIMIT_PER_USER", 60),
+		SelfTradePolicy:      getEnv("SELF_TRADE_POLICY", "cancel-newest"),
+		OrderTTL:             getEnvDuration("ORDER_TTL", 0),
+		WashSetupContracts:   getEnvInt("WASH_SETUP_CONTRACTS", 100),
+		AlertDedupWindow:     getEnvDuration("ALERT_DEDUP_WINDOW", 15*time.Minute),
+		CostBasisMethod:      getEnv("COST_BASIS_METHOD", "average"),
 		AnomalyThreshold:     getEnvFloat("ANOMALY_THRESHOLD", 0.1),
+		CancelFillRatio:      getEnvFloat("CANCEL_FILL_RATIO", 20),
+		OrderRatioWindow:     getEnvDuration("ORDER_RATIO_WINDOW", 10*time.Minute),
+		CircuitBreakerPct:    getEnvFloat("CIRCUIT_BREAKER_PCT", 20),
+		CircuitBreakerWindow: getEnvDuration("CIRCUIT_BREAKER_WINDOW", 5*time.Minute),
+		CircuitBreakerHalt:   getEnvDuration("CIRCUIT_BREAKER_HALT", 5*time.Minute),
+		RiskLowCategories:    getEnvList("RISK_LOW_CATEGORIES"),
+		RiskLowSeries:        getEnvList("RISK_LOW_SERIES"),
+		RiskMediumCategories: getEnvList("RISK_MEDIUM_CATEGORIES"),
+
+		// Settlement
+		SettlementPollInterval: getEnvDuration("SETTLEMENT_POLL_INTERVAL", 1*time.Minute),
+
+		// Funds movement
+		DepositClearingDelay: getEnvDuration("DEPOSIT_CLEARING_DELAY", 72*time.Hour),
+		WireClearingDelay:    getEnvDuration("WIRE_CLEARING_DELAY", 0),
+		CardClearingDelay:    getEnvDuration("CARD_CLEARING_DELAY", 72*time.Hour),
+		DepositMethods:       getEnvList("DEPOSIT_METHODS"),
+		WithdrawalHold:       getEnvDuration("WITHDRAWAL_HOLD", 24*time.Hour),
+		PendingSweepInterval: getEnvDuration("PENDING_SWEEP_INTERVAL", getEnvDuration("WITHDRAWAL_SWEEP_INTERVAL", 10*time.Second)),
+		IdempotencyTTL:       getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		// Operator access
+		AdminUserIDs: getEnvList("ADMIN_USER_IDS"),
+
+		// Session mode
+		AuthMode:           getEnv("AUTH_MODE", "bearer"),
+		SessionIdleTimeout: getEnvDuration("SESSION_IDLE_TIMEOUT", 0),
+
+		// Outbound notifications
+		WebhookURL:    getEnv("WEBHOOK_URL", ""),
+		WebhookSecret: getEnv("WEBHOOK_SECRET", ""),
 
 		// CORS
 		AllowedOrigins: []string{
@@ -180,3 +290,14 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvList parses a comma-separated list, returning nil when unset.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,140 @@
+// Package events provides an in-process bus for domain events published by
+// the store. Consumers such as the WebSocket hub and the event log subscribe
+// to the types they care about.
+//
+// Core Principle 18: Every published event can be logged; delivery never
+// blocks the publisher.
+package events
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type identifies a kind of domain event.
+type Type string
+
+const (
+	TypeFill          Type = "fill"
+	TypeSettlement    Type = "settlement"
+	TypeAlert         Type = "alert"
+	TypeAlertAssigned Type = "alert_assigned"
+	TypeHalt          Type = "halt"
+	TypeKYC           Type = "kyc"
+
+	// All subscribes to every event type.
+	All Type = "*"
+)
+
+// Event is one domain event. Data is a copy of the affected entity.
+type Event struct {
+	Type     Type        `json:"type"`
+	EntityID string      `json:"entity_id"`
+	UserID   string      `json:"user_id,omitempty"`
+	Data     interface{} `json:"data"`
+	At       time.Time   `json:"at"`
+}
+
+// DefaultBuffer is the per-subscriber queue length used by NewBus when
+// buffer <= 0.
+const DefaultBuffer = 64
+
+// Bus fans events out to subscribers. A subscriber whose queue is full misses
+// the event rather than stalling the publisher.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[Type][]chan Event
+	buffer  int
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// NewBus creates a bus whose subscriber channels hold buffer events.
+func NewBus(buffer int) *Bus {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Bus{subs: make(map[Type][]chan Event), buffer: buffer}
+}
+
+// Subscribe returns a channel receiving events of the given types, or every
+// event when one of them is All. The channel is closed by Close.
+func (b *Bus) Subscribe(types ...Type) <-chan Event {
+	ch := make(chan Event, b.buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	for _, t := range types {
+		if t == All {
+			types = []Type{All}
+			break
+		}
+	}
+	for _, t := range types {
+		b.subs[t] = append(b.subs[t], ch)
+	}
+	return ch
+}
+
+// Publish delivers e to matching subscribers without blocking. A nil bus
+// discards the event.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	for _, subs := range [][]chan Event{b.subs[e.Type], b.subs[All]} {
+		for _, ch := range subs {
+			select {
+			case ch <- e:
+			default:
+				b.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// Dropped returns how many deliveries were skipped because a subscriber's
+// queue was full.
+func (b *Bus) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// Close closes every subscriber channel. Later publishes are discarded.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	closed := make(map[chan Event]bool)
+	for _, subs := range b.subs {
+		for _, ch := range subs {
+			if !closed[ch] {
+				close(ch)
+				closed[ch] = true
+			}
+		}
+	}
+}
+
+// Log writes each event from ch to logger until ch is closed. Run it in its
+// own goroutine.
+func Log(ch <-chan Event, logger *slog.Logger) {
+	for e := range ch {
+		logger.Info("domain event", "type", e.Type, "entity_id", e.EntityID, "user_id", e.UserID)
+	}
+}
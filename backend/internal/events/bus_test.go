@@ -0,0 +1,95 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestBus_DeliversByType(t *testing.T) {
+	bus := NewBus(4)
+	halts := bus.Subscribe(TypeHalt)
+	all := bus.Subscribe(All)
+
+	bus.Publish(Event{Type: TypeFill, EntityID: "order-1"})
+	bus.Publish(Event{Type: TypeHalt, EntityID: "halt-1"})
+
+	if e := receive(t, halts); e.EntityID != "halt-1" || e.At.IsZero() {
+		t.Errorf("halt subscriber got %+v", e)
+	}
+	if len(halts) != 0 {
+		t.Errorf("halt subscriber received %d other events", len(halts))
+	}
+	if a, b := receive(t, all), receive(t, all); a.EntityID != "order-1" || b.EntityID != "halt-1" {
+		t.Errorf("All subscriber got %s, %s", a.EntityID, b.EntityID)
+	}
+}
+
+func TestBus_SubscribeToSeveralTypes(t *testing.T) {
+	bus := NewBus(4)
+	ch := bus.Subscribe(TypeAlert, TypeHalt)
+
+	bus.Publish(Event{Type: TypeFill, EntityID: "order-1"})
+	bus.Publish(Event{Type: TypeAlert, EntityID: "alert-1"})
+	bus.Publish(Event{Type: TypeHalt, EntityID: "halt-1"})
+
+	if a, b := receive(t, ch), receive(t, ch); a.EntityID != "alert-1" || b.EntityID != "halt-1" {
+		t.Errorf("got %s, %s; want alert-1, halt-1", a.EntityID, b.EntityID)
+	}
+	if len(ch) != 0 {
+		t.Errorf("received %d other events", len(ch))
+	}
+	bus.Close() // closes the channel once
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after Close")
+	}
+}
+
+func TestBus_FullSubscriberDoesNotBlockPublisher(t *testing.T) {
+	bus := NewBus(1)
+	slow := bus.Subscribe(TypeAlert)
+	fast := bus.Subscribe(TypeAlert)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			bus.Publish(Event{Type: TypeAlert})
+			<-fast
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+	if len(slow) != 1 || bus.Dropped() != 9 {
+		t.Errorf("slow queue = %d, dropped = %d; want 1 and 9", len(slow), bus.Dropped())
+	}
+}
+
+func TestBus_CloseEndsSubscriptions(t *testing.T) {
+	bus := NewBus(1)
+	ch := bus.Subscribe(All)
+	bus.Close()
+	bus.Publish(Event{Type: TypeKYC})
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after Close")
+	}
+	if _, ok := <-bus.Subscribe(TypeKYC); ok {
+		t.Error("subscription after Close should be closed")
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: TypeKYC}) // must not panic
+}
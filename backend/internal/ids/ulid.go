@@ -0,0 +1,117 @@
+// Package ids generates prefixed, lexicographically sortable identifiers.
+//
+// An ID is a prefix, an underscore and a ULID: 48 bits of millisecond Unix
+// time followed by 80 random bits, written as 26 Crockford base32
+// characters. IDs from one Generator are strictly increasing, so records
+// sort by creation order and two IDs never collide within a millisecond.
+//
+// Core Principle 18: Record identifiers are unique and ordered in time.
+package ids
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+	"time"
+)
+
+// encoding is Crockford's base32 alphabet, which sorts in byte order.
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Len is the length of the ULID part of an ID.
+const Len = 26
+
+// ulid is a 128-bit identifier: 6 bytes of time, 10 of entropy.
+type ulid [16]byte
+
+// Generator issues monotonic ULIDs. It is safe for concurrent use.
+type Generator struct {
+	mu      sync.Mutex
+	entropy io.Reader
+	now     func() time.Time
+	lastMs  uint64
+	last    ulid
+}
+
+// NewGenerator returns a Generator drawing randomness from entropy, or from
+// crypto/rand when entropy is nil.
+func NewGenerator(entropy io.Reader) *Generator {
+	if entropy == nil {
+		entropy = rand.Reader
+	}
+	return &Generator{entropy: entropy, now: time.Now}
+}
+
+// New returns prefix + "_" + a new ULID.
+func (g *Generator) New(prefix string) string {
+	id := g.next()
+	buf := make([]byte, 0, len(prefix)+1+Len)
+	buf = append(buf, prefix...)
+	buf = append(buf, '_')
+	return string(id.appendString(buf))
+}
+
+// next returns an ID greater than every ID returned before. Within one
+// millisecond, or if the clock steps back, the previous entropy is
+// incremented instead of drawn fresh; on overflow the time part advances.
+func (g *Generator) next() ulid {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms > g.lastMs {
+		var id ulid
+		putTime(&id, ms)
+		if _, err := io.ReadFull(g.entropy, id[6:]); err == nil {
+			g.lastMs, g.last = ms, id
+			return id
+		}
+		// Without entropy, count up from zero in this millisecond
+		g.lastMs, g.last = ms, ulid{}
+		putTime(&g.last, ms)
+	}
+
+	id := g.last
+	if !incrementEntropy(&id) {
+		g.lastMs++
+		putTime(&id, g.lastMs)
+	}
+	g.last = id
+	return id
+}
+
+func putTime(id *ulid, ms uint64) {
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// incrementEntropy adds one to the 80-bit entropy, reporting false if it
+// wrapped to zero.
+func incrementEntropy(id *ulid) bool {
+	for i := 15; i >= 6; i-- {
+		id[i]++
+		if id[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// appendString appends the 26-character base32 form of id: 130 bits, the
+// top two always zero, five bits per character.
+func (id ulid) appendString(dst []byte) []byte {
+	var hi, lo uint64 // 128 bits, big-endian
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(id[i])
+		lo = lo<<8 | uint64(id[8+i])
+	}
+	var out [Len]byte
+	for i := Len - 1; i >= 0; i-- {
+		out[i] = encoding[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return append(dst, out[:]...)
+}
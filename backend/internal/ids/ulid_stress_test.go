@@ -0,0 +1,11 @@
+//go:build stress
+
+package ids
+
+import "testing"
+
+// Millions of IDs across goroutines are all distinct. Run with
+// go test -tags stress ./internal/ids.
+func TestNext_UniqueUnderConcurrencyStress(t *testing.T) {
+	assertUniqueUnderConcurrency(t, 16, 125000) // 2,000,000 IDs
+}
@@ -0,0 +1,120 @@
+package ids
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNew_FormatAndOrder(t *testing.T) {
+	g := NewGenerator(nil)
+	a := g.New("order")
+	b := g.New("order")
+	if !strings.HasPrefix(a, "order_") || len(a) != len("order_")+Len {
+		t.Fatalf("id = %q, want order_ and %d characters", a, Len)
+	}
+	if strings.Trim(a[len("order_"):], encoding) != "" {
+		t.Errorf("id %q has characters outside the base32 alphabet", a)
+	}
+	if a >= b {
+		t.Errorf("ids not increasing: %s then %s", a, b)
+	}
+}
+
+func TestNew_EncodesTimeFirst(t *testing.T) {
+	g := NewGenerator(bytes.NewReader(make([]byte, 20)))
+	g.now = func() time.Time { return time.UnixMilli(1) }
+	if got := g.New("x"); got != "x_00000000010000000000000000" {
+		t.Errorf("id at t=1ms with zero entropy = %s", got)
+	}
+	g.now = func() time.Time { return time.UnixMilli(1<<48 - 1) }
+	if got := g.New("x"); got != "x_7ZZZZZZZZZ0000000000000000" {
+		t.Errorf("id at the largest time = %s", got)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("no entropy") }
+
+// Within one millisecond, or when the clock steps back, IDs keep increasing.
+func TestNext_MonotonicWithinMillisecondAndClockSkew(t *testing.T) {
+	clock := time.UnixMilli(5000)
+	g := NewGenerator(nil)
+	g.now = func() time.Time { return clock }
+
+	prev := g.New("x")
+	for i := 0; i < 1000; i++ {
+		if i == 500 {
+			clock = clock.Add(-time.Second)
+		}
+		id := g.New("x")
+		if id <= prev {
+			t.Fatalf("id %d: %s not after %s", i, id, prev)
+		}
+		prev = id
+	}
+
+	g = NewGenerator(failingReader{})
+	g.now = func() time.Time { return clock }
+	if a, b := g.New("x"), g.New("x"); a >= b {
+		t.Errorf("without entropy: %s then %s", a, b)
+	}
+}
+
+func TestNext_EntropyOverflowAdvancesTime(t *testing.T) {
+	g := NewGenerator(bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+	g.now = func() time.Time { return time.UnixMilli(7) }
+	max := g.next()
+	next := g.next()
+	if bytes.Compare(next[:], max[:]) <= 0 || g.lastMs != 8 {
+		t.Errorf("after max entropy: %x then %x (time %d)", max, next, g.lastMs)
+	}
+}
+
+// IDs from many goroutines are all distinct. The 2,000,000-ID version runs
+// with -tags stress.
+func TestNext_UniqueUnderConcurrency(t *testing.T) {
+	assertUniqueUnderConcurrency(t, 16, 5000)
+}
+
+// assertUniqueUnderConcurrency draws perWorker IDs on each of workers
+// goroutines and checks each goroutine's IDs increase and none repeat.
+func assertUniqueUnderConcurrency(t *testing.T, workers, perWorker int) {
+	t.Helper()
+	g := NewGenerator(nil)
+	results := make([][]ulid, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			out := make([]ulid, perWorker)
+			for i := range out {
+				out[i] = g.next()
+			}
+			results[w] = out
+		}(w)
+	}
+	wg.Wait()
+
+	all := make([]ulid, 0, workers*perWorker)
+	for _, r := range results {
+		for i := 1; i < len(r); i++ {
+			if bytes.Compare(r[i][:], r[i-1][:]) <= 0 {
+				t.Fatalf("goroutine saw %x after %x", r[i], r[i-1])
+			}
+		}
+		all = append(all, r...)
+	}
+	sort.Slice(all, func(i, j int) bool { return bytes.Compare(all[i][:], all[j][:]) < 0 })
+	for i := 1; i < len(all); i++ {
+		if all[i] == all[i-1] {
+			t.Fatalf("duplicate id %x", all[i])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package kalshi
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache is a bounded in-memory cache whose entries expire individually.
+// It is safe for concurrent use.
+type ttlCache[V any] struct {
+	mu         sync.Mutex
+	entries    map[string]ttlEntry[V]
+	maxEntries int
+	now        func() time.Time
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func newTTLCache[V any](maxEntries int) *ttlCache[V] {
+	return &ttlCache[V]{entries: make(map[string]ttlEntry[V]), maxEntries: maxEntries, now: time.Now}
+}
+
+// get returns the value for key if it has not expired.
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// set stores value under key for ttl. When the cache is full, expired
+// entries are dropped first, then an arbitrary one.
+func (c *ttlCache[V]) set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = ttlEntry[V]{value: value, expires: now.Add(ttl)}
+}
@@ -4,13 +4,18 @@
 package kalshi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/kalshi-dcm-demo/backend/internal/metrics"
 	"github.com/kalshi-dcm-demo/backend/internal/models"
 )
 
@@ -25,10 +30,53 @@ const (
 	TradingBaseURL = "https://trading-api.kalshi.com/trade-api/v2"
 )
 
+// =============================================================================
+// ERRORS
+// =============================================================================
+
+var (
+	ErrKalshiNotFound    = errors.New("kalshi: resource not found")
+	ErrKalshiUnavailable = errors.New("kalshi: service unavailable")
+	ErrKalshiRateLimited = errors.New("kalshi: rate limited")
+)
+
+// APIError is returned for non-200 responses. It unwraps to one of the
+// ErrKalshi* sentinels when the status code has a specific meaning.
+type APIError struct {
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// errorForStatus maps an upstream status code to a sentinel error.
+func errorForStatus(status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrKalshiNotFound
+	case status == http.StatusTooManyRequests:
+		return ErrKalshiRateLimited
+	case status >= 500:
+		return ErrKalshiUnavailable
+	default:
+		return nil
+	}
+}
+
 // Client handles communication with Kalshi's public API.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	history    *ttlCache[*MarketHistory]
+	series     *ttlCache[string] // market ticker -> series ticker
+	ctx        context.Context   // nil means context.Background; see WithContext
 }
 
 // NewClient creates a new Kalshi API client.
@@ -41,9 +89,20 @@ func NewClient(baseURL string, timeout time.Duration) *Client {
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		history: newTTLCache[*MarketHistory](historyCacheSize),
+		series:  newTTLCache[string](historyCacheSize),
 	}
 }
 
+// WithContext returns a client whose requests are bound to ctx, so they are
+// abandoned once ctx is cancelled or its deadline passes. The copy shares
+// the HTTP client and caches with c.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
 // =============================================================================
 // API RESPONSE TYPES
 // =============================================================================
@@ -73,6 +132,7 @@ type KalshiMarketResponse struct {
 	CloseTime      string `json:"close_time"`
 	ExpirationTime string `json:"expiration_time"`
 	SettlementValue *int  `json:"settlement_value,omitempty"`
+	SettlementTime string `json:"settlement_ts,omitempty"` // When Kalshi settled the market
 	Result         string `json:"result,omitempty"`
 }
 
@@ -103,6 +163,37 @@ type OrderbookLevel struct {
 	Quantity int `json:"quantity"`
 }
 
+// UnmarshalJSON accepts Kalshi's [price, quantity] pairs as well as the
+// object form.
+func (l *OrderbookLevel) UnmarshalJSON(data []byte) error {
+	var pair []int
+	if err := json.Unmarshal(data, &pair); err == nil {
+		if len(pair) != 2 {
+			return fmt.Errorf("orderbook level has %d values, want 2", len(pair))
+		}
+		l.Price, l.Quantity = pair[0], pair[1]
+		return nil
+	}
+	type object OrderbookLevel
+	return json.Unmarshal(data, (*object)(l))
+}
+
+type TradesResponse struct {
+	Trades []Trade `json:"trades"`
+	Cursor string  `json:"cursor"`
+}
+
+// Trade is one print on a market. Prices are in cents.
+type Trade struct {
+	TradeID     string `json:"trade_id"`
+	Ticker      string `json:"ticker"`
+	Count       int    `json:"count"`
+	YesPrice    int    `json:"yes_price"`
+	NoPrice     int    `json:"no_price"`
+	TakerSide   string `json:"taker_side"`
+	CreatedTime string `json:"created_time"`
+}
+
 type SeriesResponse struct {
 	Series []SeriesItem `json:"series"`
 	Cursor string       `json:"cursor"`
@@ -192,6 +283,26 @@ func (c *Client) GetOrderbook(ticker string, depth int) (*OrderbookResponse, err
 	return &response, nil
 }
 
+// GetMarketTrades fetches a market's trades, newest first.
+// Core Principle 9: Transparency in order execution.
+func (c *Client) GetMarketTrades(ticker string, limit int, cursor string) (*TradesResponse, error) {
+	params := url.Values{}
+	params.Set("ticker", ticker)
+	if limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", limit))
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	var response TradesResponse
+	if err := c.doRequest("GET", "/markets/trades?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
 // GetSeries fetches series list.
 func (c *Client) GetSeries(cursor string, limit int) (*SeriesResponse, error) {
 	params := url.Values{}
@@ -215,14 +326,32 @@ func (c *Client) GetSeries(cursor string, limit int) (*SeriesResponse, error) {
 	return &response, nil
 }
 
+// GetSeriesByTicker fetches a single series by ticker.
+func (c *Client) GetSeriesByTicker(ticker string) (*SeriesItem, error) {
+	endpoint := fmt.Sprintf("/series/%s", url.PathEscape(ticker))
+
+	var response struct {
+		Series SeriesItem `json:"series"`
+	}
+	if err := c.doRequest("GET", endpoint, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Series, nil
+}
+
 // =============================================================================
 // HELPER METHODS
 // =============================================================================
 
 func (c *Client) doRequest(method, endpoint string, result interface{}) error {
 	reqURL := c.baseURL + endpoint
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-	req, err := http.NewRequest(method, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, nil)
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
@@ -230,15 +359,22 @@ func (c *Client) doRequest(method, endpoint string, result interface{}) error {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("executing request: %w", err)
+		metrics.KalshiRequestDuration.WithLabelValues(endpointLabel(endpoint), "error").Observe(time.Since(start).Seconds())
+		// The caller gave up; Kalshi is not necessarily down
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("executing request: %w", ctxErr)
+		}
+		return fmt.Errorf("executing request: %w: %w", ErrKalshiUnavailable, err)
 	}
 	defer resp.Body.Close()
+	metrics.KalshiRequestDuration.WithLabelValues(endpointLabel(endpoint), strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body), Err: errorForStatus(resp.StatusCode)}
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
@@ -248,6 +384,28 @@ func (c *Client) doRequest(method, endpoint string, result interface{}) error {
 	return nil
 }
 
+// fixedSegments are path segments that name an endpoint rather than an
+// identifier, keyed by the collection they sit under.
+var fixedSegments = map[string]string{
+	"markets": "trades", // /markets/trades
+}
+
+// endpointLabel collapses an endpoint to a low-cardinality metric label:
+// the query is dropped and identifiers are replaced, e.g.
+// /markets/FED-24DEC/orderbook?depth=10 becomes /markets/{id}/orderbook.
+func endpointLabel(endpoint string) string {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	for i := 1; i < len(segments); i += 2 {
+		if fixedSegments[segments[i-1]] != segments[i] {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
 // ToMarket converts API response to internal model.
 // Core Principle 3: Classify risk category for economic binaries.
 func (m *KalshiMarketResponse) ToMarket() models.KalshiMarket {
@@ -283,46 +441,11 @@ func (m *KalshiMarketResponse) ToMarket() models.KalshiMarket {
 	}
 
 	// Core Principle 3: Classify risk based on category
-	market.RiskCategory = classifyRisk(m.Category, m.SeriesTicker)
+	market.RiskCategory = ClassifyRisk(m.Category, m.SeriesTicker).Category
 
 	return market
 }
 
-// classifyRisk determines manipulation risk for a market.
-// Core Principle 3: Economic binaries (Fed, GDP, CPI) are low-risk.
-func classifyRisk(category, seriesTicker string) string {
-	lowRiskCategories := map[string]bool{
-		"Economics":    true,
-		"Fed":          true,
-		"Interest":     true,
-		"Inflation":    true,
-		"GDP":          true,
-		"Unemployment": true,
-		"CPI":          true,
-	}
-
-	lowRiskSeries := map[string]bool{
-		"FED":       true,
-		"FOMC":      true,
-		"CPI":       true,
-		"GDP":       true,
-		"UNEMP":     true,
-		"INFLATION": true,
-	}
-
-	if lowRiskCategories[category] || lowRiskSeries[seriesTicker] {
-		return "low"
-	}
-
-	// Political events have medium risk
-	if category == "Politics" || category == "Elections" {
-		return "medium"
-	}
-
-	// Entertainment, sports have higher risk
-	return "high"
-}
-
 // =============================================================================
 // QUERY PARAMETERS
 // =============================================================================
@@ -354,6 +477,9 @@ func (p MarketParams) ToQueryParams() string {
 	if p.EventTicker != "" {
 		params.Set("event_ticker", p.EventTicker)
 	}
+	if p.Category != "" {
+		params.Set("category", p.Category)
+	}
 
 	return params.Encode()
 }
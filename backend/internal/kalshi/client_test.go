@@ -0,0 +1,81 @@
+package kalshi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoRequest_TypedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrKalshiNotFound},
+		{http.StatusTooManyRequests, ErrKalshiRateLimited},
+		{http.StatusServiceUnavailable, ErrKalshiUnavailable},
+		{http.StatusInternalServerError, ErrKalshiUnavailable},
+		{http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", tt.status)
+		}))
+		_, err := NewClient(server.URL, time.Second).GetMarket("FED-TEST")
+		server.Close()
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+			t.Errorf("status %d: expected *APIError, got %v", tt.status, err)
+			continue
+		}
+		for _, sentinel := range []error{ErrKalshiNotFound, ErrKalshiRateLimited, ErrKalshiUnavailable} {
+			if errors.Is(err, sentinel) != (sentinel == tt.want) {
+				t.Errorf("status %d: errors.Is(%v) = %v", tt.status, sentinel, !(sentinel == tt.want))
+			}
+		}
+	}
+}
+
+func TestDoRequest_UnreachableIsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	_, err := NewClient(server.URL, time.Second).GetMarket("FED-TEST")
+	if !errors.Is(err, ErrKalshiUnavailable) {
+		t.Errorf("expected ErrKalshiUnavailable, got %v", err)
+	}
+}
+
+func TestEndpointLabel(t *testing.T) {
+	tests := map[string]string{
+		"/markets?limit=50":                          "/markets",
+		"/markets/FED-24DEC":                         "/markets/{id}",
+		"/markets/FED-24DEC/orderbook?depth=10":      "/markets/{id}/orderbook",
+		"/markets/trades?ticker=FED-24DEC&limit=100": "/markets/trades",
+		"/series/FED":                                "/series/{id}",
+		"/series/FED/markets/FED-24DEC/candlesticks": "/series/{id}/markets/{id}/candlesticks",
+	}
+	for endpoint, want := range tests {
+		if got := endpointLabel(endpoint); got != want {
+			t.Errorf("endpointLabel(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestOrderbookLevel_DecodesPairsAndObjects(t *testing.T) {
+	var book OrderbookResponse
+	body := `{"orderbook": {"yes": [[40, 100]], "no": [{"price": 58, "quantity": 20}]}}`
+	if err := json.Unmarshal([]byte(body), &book); err != nil {
+		t.Fatal(err)
+	}
+	if book.Orderbook.YesBids[0] != (OrderbookLevel{40, 100}) || book.Orderbook.NoBids[0] != (OrderbookLevel{58, 20}) {
+		t.Errorf("book = %+v", book.Orderbook)
+	}
+	if err := json.Unmarshal([]byte(`{"orderbook": {"yes": [[40]]}}`), &book); err == nil {
+		t.Error("a one-value level decoded without error")
+	}
+}
@@ -0,0 +1,158 @@
+package kalshi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// =============================================================================
+// PRICE HISTORY
+// Candlesticks from Kalshi for charting. Past periods never change, so
+// responses are cached: briefly while the window includes the current
+// period, and for longer once it is closed.
+// =============================================================================
+
+var (
+	ErrInvalidHistoryInterval = errors.New("kalshi: history interval must be 1m, 1h or 1d")
+	ErrInvalidHistoryRange    = errors.New("kalshi: history start must be before end")
+)
+
+// historyIntervals maps supported candle lengths to Kalshi's period_interval
+// in minutes.
+var historyIntervals = map[time.Duration]int{
+	time.Minute:    1,
+	time.Hour:      60,
+	24 * time.Hour: 1440,
+}
+
+const (
+	historyCacheSize = 1000
+	historyOpenTTL   = time.Minute // window includes the current period
+	historyClosedTTL = time.Hour   // window entirely in the past
+	seriesLookupTTL  = 24 * time.Hour
+)
+
+// Candle is one period of a market's price history. Prices are in cents;
+// the trade prices are null for a period with no trades.
+type Candle struct {
+	PeriodEnd    time.Time `json:"period_end"`
+	Open         *int      `json:"open"`
+	High         *int      `json:"high"`
+	Low          *int      `json:"low"`
+	Close        *int      `json:"close"`
+	YesBid       int       `json:"yes_bid"` // At period end
+	YesAsk       int       `json:"yes_ask"` // At period end
+	Volume       int64     `json:"volume"`
+	OpenInterest int64     `json:"open_interest"`
+}
+
+// MarketHistory is a market's candles over [Start, End], oldest first.
+type MarketHistory struct {
+	Ticker   string    `json:"ticker"`
+	Interval string    `json:"interval"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Candles  []Candle  `json:"candles"`
+}
+
+type candlesticksResponse struct {
+	Candlesticks []struct {
+		EndPeriodTS int64 `json:"end_period_ts"`
+		YesBid      struct {
+			Close int `json:"close"`
+		} `json:"yes_bid"`
+		YesAsk struct {
+			Close int `json:"close"`
+		} `json:"yes_ask"`
+		Price struct {
+			Open  *int `json:"open"`
+			High  *int `json:"high"`
+			Low   *int `json:"low"`
+			Close *int `json:"close"`
+		} `json:"price"`
+		Volume       int64 `json:"volume"`
+		OpenInterest int64 `json:"open_interest"`
+	} `json:"candlesticks"`
+}
+
+// FormatInterval returns the short form of a history interval: 1m, 1h or 1d.
+func FormatInterval(interval time.Duration) string {
+	switch interval {
+	case time.Minute:
+		return "1m"
+	case time.Hour:
+		return "1h"
+	case 24 * time.Hour:
+		return "1d"
+	}
+	return interval.String()
+}
+
+// GetMarketHistory fetches a market's candlesticks between start and end
+// with interval one minute, one hour or one day. Times are returned in UTC.
+func (c *Client) GetMarketHistory(ticker string, interval time.Duration, start, end time.Time) (*MarketHistory, error) {
+	minutes, ok := historyIntervals[interval]
+	if !ok {
+		return nil, ErrInvalidHistoryInterval
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidHistoryRange
+	}
+	start, end = start.UTC(), end.UTC()
+
+	key := fmt.Sprintf("%s|%d|%d|%d", ticker, minutes, start.Unix(), end.Unix())
+	if history, ok := c.history.get(key); ok {
+		return history, nil
+	}
+
+	series, err := c.seriesFor(ticker)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("start_ts", fmt.Sprintf("%d", start.Unix()))
+	params.Set("end_ts", fmt.Sprintf("%d", end.Unix()))
+	params.Set("period_interval", fmt.Sprintf("%d", minutes))
+	endpoint := fmt.Sprintf("/series/%s/markets/%s/candlesticks?%s", url.PathEscape(series), url.PathEscape(ticker), params.Encode())
+
+	var response candlesticksResponse
+	if err := c.doRequest("GET", endpoint, &response); err != nil {
+		return nil, err
+	}
+
+	history := &MarketHistory{
+		Ticker: ticker, Interval: FormatInterval(interval), Start: start, End: end,
+		Candles: make([]Candle, 0, len(response.Candlesticks)),
+	}
+	for _, cs := range response.Candlesticks {
+		history.Candles = append(history.Candles, Candle{
+			PeriodEnd: time.Unix(cs.EndPeriodTS, 0).UTC(),
+			Open:      cs.Price.Open, High: cs.Price.High, Low: cs.Price.Low, Close: cs.Price.Close,
+			YesBid: cs.YesBid.Close, YesAsk: cs.YesAsk.Close,
+			Volume: cs.Volume, OpenInterest: cs.OpenInterest,
+		})
+	}
+
+	ttl := historyOpenTTL
+	if end.Add(interval).Before(time.Now()) {
+		ttl = historyClosedTTL
+	}
+	c.history.set(key, history, ttl)
+	return history, nil
+}
+
+// seriesFor returns a market's series ticker, which the candlestick
+// endpoint requires. A market's series never changes.
+func (c *Client) seriesFor(ticker string) (string, error) {
+	if series, ok := c.series.get(ticker); ok {
+		return series, nil
+	}
+	market, err := c.GetMarket(ticker)
+	if err != nil {
+		return "", err
+	}
+	c.series.set(ticker, market.SeriesTicker, seriesLookupTTL)
+	return market.SeriesTicker, nil
+}
@@ -0,0 +1,100 @@
+package kalshi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetMarketHistory_NormalizesAndCaches(t *testing.T) {
+	var marketFetches, candleFetches atomic.Int32
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/markets/CPI-24NOV-T3.0":
+			marketFetches.Add(1)
+			w.Write([]byte(`{"market": {"ticker": "CPI-24NOV-T3.0", "series_ticker": "CPI"}}`))
+		case "/series/CPI/markets/CPI-24NOV-T3.0/candlesticks":
+			candleFetches.Add(1)
+			query = r.URL.RawQuery
+			w.Write([]byte(`{"ticker": "CPI-24NOV-T3.0", "candlesticks": [
+				{"end_period_ts": 1731502800, "yes_bid": {"close": 41}, "yes_ask": {"close": 44},
+				 "price": {"open": 40, "high": 45, "low": 39, "close": 43}, "volume": 120, "open_interest": 900},
+				{"end_period_ts": 1731506400, "yes_bid": {"close": 42}, "yes_ask": {"close": 45},
+				 "price": {"open": null, "high": null, "low": null, "close": null}, "volume": 0, "open_interest": 900}
+			]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	start := time.Date(2024, 11, 13, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	end := start.Add(2 * time.Hour)
+	history, err := client.GetMarketHistory("CPI-24NOV-T3.0", time.Hour, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "end_ts=1731524400&period_interval=60&start_ts=1731517200" {
+		t.Errorf("candlestick query = %s", query)
+	}
+	if history.Interval != "1h" || history.Start.Location() != time.UTC || len(history.Candles) != 2 {
+		t.Fatalf("history = %+v, want two 1h candles in UTC", history)
+	}
+	first, second := history.Candles[0], history.Candles[1]
+	if got := first.PeriodEnd.Format(time.RFC3339); got != "2024-11-13T13:00:00Z" {
+		t.Errorf("period end = %s, want 2024-11-13T13:00:00Z", got)
+	}
+	if first.Close == nil || *first.Close != 43 || first.YesBid != 41 || first.Volume != 120 {
+		t.Errorf("first candle = %+v", first)
+	}
+	if second.Open != nil || second.Close != nil || second.YesAsk != 45 {
+		t.Errorf("candle without trades = %+v, want null prices", second)
+	}
+
+	if _, err := client.GetMarketHistory("CPI-24NOV-T3.0", time.Hour, start, end); err != nil {
+		t.Fatal(err)
+	}
+	if candleFetches.Load() != 1 || marketFetches.Load() != 1 {
+		t.Errorf("fetches = %d candles, %d markets; want the repeat served from cache", candleFetches.Load(), marketFetches.Load())
+	}
+	if _, err := client.GetMarketHistory("CPI-24NOV-T3.0", 24*time.Hour, start, end.Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if candleFetches.Load() != 2 || marketFetches.Load() != 1 {
+		t.Errorf("new window: %d candle and %d market fetches, want 2 and the series reused", candleFetches.Load(), marketFetches.Load())
+	}
+
+	if _, err := client.GetMarketHistory("CPI-24NOV-T3.0", 5*time.Minute, start, end); !errors.Is(err, ErrInvalidHistoryInterval) {
+		t.Errorf("5m interval: err = %v", err)
+	}
+	if _, err := client.GetMarketHistory("CPI-24NOV-T3.0", time.Hour, end, start); !errors.Is(err, ErrInvalidHistoryRange) {
+		t.Errorf("reversed range: err = %v", err)
+	}
+}
+
+func TestTTLCache_ExpiresAndStaysBounded(t *testing.T) {
+	c := newTTLCache[int](2)
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+
+	c.set("a", 1, time.Minute)
+	c.set("b", 2, time.Hour)
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.get("a"); ok {
+		t.Error("expired entry returned")
+	}
+	c.set("c", 3, time.Minute) // drops the expired a
+	if v, ok := c.get("b"); !ok || v != 2 {
+		t.Errorf("b = %d, %v; want the live entry kept", v, ok)
+	}
+	c.set("d", 4, time.Minute)
+	if len(c.entries) != 2 {
+		t.Errorf("cache holds %d entries, want at most 2", len(c.entries))
+	}
+}
@@ -10,8 +10,12 @@ package kalshi
 import (
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
 )
 
 // =============================================================================
@@ -72,15 +76,16 @@ type MockPosition struct {
 // MockSettlement represents a contract settlement
 // CP 3: Objective resolution based on verifiable outcomes
 type MockSettlement struct {
-	SettlementID   string    `json:"settlement_id"`
-	Ticker         string    `json:"ticker"`
-	EventTicker    string    `json:"event_ticker"`
-	MarketTitle    string    `json:"market_title"`
-	Result         string    `json:"result"` // yes, no
-	SettlementValue int      `json:"settlement_value"` // 0 or 100
-	SettledAt      time.Time `json:"settled_at"`
-	PayoutCents    int       `json:"payout_cents"`
-	Reason         string    `json:"reason"` // Objective resolution source
+	SettlementID    string                   `json:"settlement_id"`
+	Ticker          string                   `json:"ticker"`
+	EventTicker     string                   `json:"event_ticker"`
+	MarketTitle     string                   `json:"market_title"`
+	Result          string                   `json:"result"`           // yes, no
+	SettlementValue int                      `json:"settlement_value"` // 0 or 100
+	SettledAt       time.Time                `json:"settled_at"`
+	PayoutCents     int                      `json:"payout_cents"`
+	Reason          string                   `json:"reason"` // Objective resolution source
+	Source          *models.SettlementSource `json:"source,omitempty"`
 }
 
 // MockBalance represents account balance
@@ -93,6 +98,32 @@ type MockBalance struct {
 	PendingWithdrawalsCents int `json:"pending_withdrawals_cents"`
 }
 
+// =============================================================================
+// RANDOMNESS
+// Seeded once per process; tests reseed for reproducible simulations
+// =============================================================================
+
+var (
+	mockRNG   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	mockRNGMu sync.Mutex
+)
+
+// SetMockSeed reseeds the package RNG used by SimulateResolution and to
+// seed new executors.
+func SetMockSeed(seed int64) {
+	mockRNGMu.Lock()
+	defer mockRNGMu.Unlock()
+	mockRNG = rand.New(rand.NewSource(seed))
+}
+
+// newMockRand returns an RNG seeded from the package RNG, so executors
+// created together do not share a sequence.
+func newMockRand() *rand.Rand {
+	mockRNGMu.Lock()
+	defer mockRNGMu.Unlock()
+	return rand.New(rand.NewSource(mockRNG.Int63()))
+}
+
 // =============================================================================
 // MOCK ORDER EXECUTOR
 // Simulates order matching and execution
@@ -100,19 +131,28 @@ type MockBalance struct {
 
 // MockOrderExecutor simulates order execution
 type MockOrderExecutor struct {
-	orders     map[string]*MockOrderResponse
-	positions  map[string]map[string]*MockPosition // userID -> ticker -> position
-	settlements []MockSettlement
-	mu         sync.RWMutex
+	orders         map[string]*MockOrderResponse
+	positions      map[string]map[string]*MockPosition // userID -> ticker -> position
+	settlements    []MockSettlement
+	owners         map[string]string   // orderID -> userID
+	book           map[string][]string // ticker -> resting order IDs in arrival order
+	maker          MarketMakerConfig
+	rng            *rand.Rand
+	mu             sync.RWMutex
 	orderIDCounter int64
+	fillIDCounter  int64
 }
 
 // NewMockOrderExecutor creates a new mock executor
 func NewMockOrderExecutor() *MockOrderExecutor {
 	return &MockOrderExecutor{
-		orders:     make(map[string]*MockOrderResponse),
-		positions:  make(map[string]map[string]*MockPosition),
+		orders:      make(map[string]*MockOrderResponse),
+		positions:   make(map[string]map[string]*MockPosition),
 		settlements: make([]MockSettlement, 0),
+		owners:      make(map[string]string),
+		book:        make(map[string][]string),
+		maker:       DefaultMarketMakerConfig(),
+		rng:         newMockRand(),
 	}
 }
 
@@ -186,6 +226,10 @@ func (e *MockOrderExecutor) PlaceOrder(userID string, req MockOrderRequest, mark
 	}
 
 	e.orders[orderID] = order
+	e.owners[orderID] = userID
+	if status == "open" {
+		e.book[req.Ticker] = append(e.book[req.Ticker], orderID)
+	}
 
 	// Update position if filled
 	if filledCount > 0 {
@@ -211,14 +255,14 @@ func (e *MockOrderExecutor) updatePosition(userID, ticker, side string, contract
 			Side:              side,
 			Contracts:         contracts,
 			AveragePriceCents: priceCents,
-			TotalCostCents:    contracts * priceCents,
+			TotalCostCents:    fillCostCents(side, contracts, priceCents),
 			CurrentPriceCents: priceCents,
 			CurrentValueCents: contracts * priceCents,
 		}
 	} else {
 		// Average in the new position
 		totalContracts := existing.Contracts + contracts
-		totalCost := existing.TotalCostCents + (contracts * priceCents)
+		totalCost := existing.TotalCostCents + fillCostCents(side, contracts, priceCents)
 		existing.Contracts = totalContracts
 		existing.TotalCostCents = totalCost
 		existing.AveragePriceCents = totalCost / totalContracts
@@ -226,6 +270,16 @@ func (e *MockOrderExecutor) updatePosition(userID, ticker, side string, contract
 	}
 }
 
+// fillCostCents is the collateral for a fill. Executor prices are quoted in
+// the side's own price, so NO prices are converted to the YES price first.
+func fillCostCents(side string, contracts, sidePriceCents int) int {
+	yesPrice := sidePriceCents
+	if side == string(models.OrderSideNo) {
+		yesPrice = 100 - sidePriceCents
+	}
+	return models.CollateralCents(models.OrderSide(side), contracts, yesPrice)
+}
+
 // GetPositions returns user's positions
 func (e *MockOrderExecutor) GetPositions(userID string) []MockPosition {
 	e.mu.RLock()
@@ -256,9 +310,147 @@ func (e *MockOrderExecutor) GetOrders(userID string, status string) []MockOrderR
 	return orders
 }
 
+// =============================================================================
+// SIMULATED MARKET MAKER
+// CP 9: Resting limit orders trade when the simulated market moves through them
+// =============================================================================
+
+// MarketMakerConfig controls the simulated liquidity provider.
+type MarketMakerConfig struct {
+	HalfSpread int // cents either side of the simulated mid
+	Depth      int // contracts quoted on each side per tick
+	Jitter     int // max cents the simulated mid strays from the Kalshi mid
+}
+
+// DefaultMarketMakerConfig returns the demo's market maker settings.
+func DefaultMarketMakerConfig() MarketMakerConfig {
+	return MarketMakerConfig{HalfSpread: 2, Depth: 100, Jitter: 3}
+}
+
+// MockFill is one execution of a resting order against the market maker.
+type MockFill struct {
+	FillID     string    `json:"fill_id"`
+	OrderID    string    `json:"order_id"`
+	Ticker     string    `json:"ticker"`
+	Side       string    `json:"side"`
+	Count      int       `json:"count"`
+	PriceCents int       `json:"price_cents"` // in the side's own price
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SetSeed reseeds the market maker's RNG so ticks are reproducible.
+func (e *MockOrderExecutor) SetSeed(seed int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// Tick moves the simulated market for ticker around the Kalshi mid and fills
+// resting limit orders the market maker's quotes cross. Orders fill at their
+// own limit price in price-time priority, up to the quoted depth per side.
+func (e *MockOrderExecutor) Tick(ticker string, marketBid, marketAsk int) []MockFill {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	resting := e.book[ticker]
+	if len(resting) == 0 {
+		return nil
+	}
+
+	mid := (marketBid + marketAsk) / 2
+	if e.maker.Jitter > 0 {
+		mid += e.rng.Intn(2*e.maker.Jitter+1) - e.maker.Jitter
+	}
+	yesAsk := clampPrice(mid + e.maker.HalfSpread)
+	noAsk := clampPrice(100 - (mid - e.maker.HalfSpread))
+
+	// Best price first; the stable sort keeps arrival order within a level
+	queue := make([]*MockOrderResponse, 0, len(resting))
+	for _, id := range resting {
+		queue = append(queue, e.orders[id])
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return limitPrice(queue[i]) > limitPrice(queue[j])
+	})
+
+	now := time.Now().UTC()
+	depth := map[string]int{"yes": e.maker.Depth, "no": e.maker.Depth}
+	var fills []MockFill
+	for _, order := range queue {
+		ask := yesAsk
+		if order.Side == "no" {
+			ask = noAsk
+		}
+		if limitPrice(order) < ask || depth[order.Side] == 0 {
+			continue
+		}
+		qty := order.RemainingCount
+		if qty > depth[order.Side] {
+			qty = depth[order.Side]
+		}
+		depth[order.Side] -= qty
+
+		price := limitPrice(order)
+		prevFilled := order.FilledCount
+		order.FilledCount += qty
+		order.RemainingCount -= qty
+		order.FilledAvgPrice = (order.FilledAvgPrice*prevFilled + price*qty) / order.FilledCount
+		order.UpdatedAt = now
+		if order.RemainingCount == 0 {
+			order.Status = "filled"
+		}
+		e.updatePosition(e.owners[order.OrderID], ticker, order.Side, qty, price)
+
+		e.fillIDCounter++
+		fills = append(fills, MockFill{
+			FillID:     fmt.Sprintf("FILL_%d", e.fillIDCounter),
+			OrderID:    order.OrderID,
+			Ticker:     ticker,
+			Side:       order.Side,
+			Count:      qty,
+			PriceCents: price,
+			CreatedAt:  now,
+		})
+	}
+
+	// Drop fully filled orders from the book
+	kept := resting[:0]
+	for _, id := range resting {
+		if e.orders[id].RemainingCount > 0 {
+			kept = append(kept, id)
+		}
+	}
+	e.book[ticker] = kept
+	return fills
+}
+
+// limitPrice is a resting order's price on its own side.
+func limitPrice(order *MockOrderResponse) int {
+	if order.Side == "no" {
+		return order.NoPrice
+	}
+	return order.YesPrice
+}
+
+func clampPrice(cents int) int {
+	if cents < 1 {
+		return 1
+	}
+	if cents > 99 {
+		return 99
+	}
+	return cents
+}
+
 // SimulateSettlement simulates market settlement
 // CP 3: Objective resolution with verifiable outcomes
 func (e *MockOrderExecutor) SimulateSettlement(ticker, result, reason string) *MockSettlement {
+	return e.SimulateSettlementFromSource(ticker, result, reason, nil)
+}
+
+// SimulateSettlementFromSource settles like SimulateSettlement and records
+// the resolution source that decided the result.
+func (e *MockOrderExecutor) SimulateSettlementFromSource(ticker, result, reason string, source *models.SettlementSource) *MockSettlement {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -274,10 +466,17 @@ func (e *MockOrderExecutor) SimulateSettlement(ticker, result, reason string) *M
 		SettlementValue: settlementValue,
 		SettledAt:       time.Now().UTC(),
 		Reason:          reason,
+		Source:          source,
 	}
 
 	e.settlements = append(e.settlements, settlement)
 
+	// Resting orders cannot trade once the market has resolved
+	for _, id := range e.book[ticker] {
+		e.orders[id].Status = "cancelled"
+	}
+	delete(e.book, ticker)
+
 	// Close out positions for this ticker
 	for userID, positions := range e.positions {
 		for key, pos := range positions {
@@ -384,13 +583,101 @@ func DefaultSettlementRules() map[string]SettlementRule {
 	}
 }
 
+// DefaultSettlementRule applies to markets outside the built-in
+// categories: Kalshi's own determination under the standard delay and
+// extension, with no fallback sources.
+func DefaultSettlementRule() SettlementRule {
+	return SettlementRule{
+		Category:        "General",
+		ResolutionDelay: 30 * time.Minute,
+		ExtensionWindow: 24 * time.Hour,
+		Sources:         ResolutionSource{Primary: "kalshi.com"},
+	}
+}
+
+// ResolveRuleForTicker returns the settlement rule for a market, matching
+// the category codes of DefaultSettlementRules as prefixes of seriesTicker,
+// or of ticker when the series is unknown. Matching is case-insensitive,
+// ignores Kalshi's "KX" series prefix and prefers the longest code.
+// Unmatched markets get DefaultSettlementRule and false.
+func ResolveRuleForTicker(ticker, seriesTicker string) (SettlementRule, bool) {
+	name := seriesTicker
+	if name == "" {
+		name = ticker
+	}
+	name = strings.TrimPrefix(strings.ToUpper(name), "KX")
+
+	var match string
+	rules := DefaultSettlementRules()
+	for code := range rules {
+		if strings.HasPrefix(name, code) && len(code) > len(match) {
+			match = code
+		}
+	}
+	if match == "" {
+		return DefaultSettlementRule(), false
+	}
+	return rules[match], true
+}
+
+// SelectSource picks the resolution source for a market that closed at
+// closedAt, given when each source published its result (sources missing
+// from published have not reported). The primary source is used if it
+// reports within ResolutionDelay, or late but within the ExtensionWindow
+// after it. Once the window has passed, the secondary and then the tertiary
+// source are used. Reports after now are ignored. Returns false while the
+// market is still waiting on a source.
+// CP 3: Objective resolution with fallback mechanisms
+func (r SettlementRule) SelectSource(closedAt, now time.Time, published map[string]time.Time) (*models.SettlementSource, bool) {
+	due := closedAt.Add(r.ResolutionDelay)
+	extendedDue := due.Add(r.ExtensionWindow)
+	reported := func(name string) (time.Time, bool) {
+		at, ok := published[name]
+		if name == "" || !ok || at.After(now) {
+			return time.Time{}, false
+		}
+		return at, true
+	}
+
+	if at, ok := reported(r.Sources.Primary); ok && !at.After(extendedDue) {
+		source := &models.SettlementSource{
+			Name: r.Sources.Primary, Tier: models.SourceTierPrimary, ReportedAt: at, VerifiedAt: now,
+		}
+		if at.After(due) {
+			source.Extended = true
+			source.DelayReason = fmt.Sprintf("%s missed the %s resolution delay and reported %s after close, within the %s extension",
+				r.Sources.Primary, r.ResolutionDelay, at.Sub(closedAt), r.ExtensionWindow)
+		}
+		return source, true
+	}
+	if now.Before(extendedDue) {
+		return nil, false
+	}
+	fallbacks := []struct{ name, tier string }{
+		{r.Sources.Secondary, models.SourceTierSecondary},
+		{r.Sources.Tertiary, models.SourceTierTertiary},
+	}
+	for _, f := range fallbacks {
+		if at, ok := reported(f.name); ok {
+			return &models.SettlementSource{
+				Name: f.name, Tier: f.tier, ReportedAt: at, VerifiedAt: now, Extended: true,
+				DelayReason: fmt.Sprintf("%s did not report within the %s resolution delay and %s extension; fell back to the %s source",
+					r.Sources.Primary, r.ResolutionDelay, r.ExtensionWindow, f.tier),
+			}, true
+		}
+	}
+	return nil, false
+}
+
 // SimulateResolution simulates objective resolution
 // Returns result based on random simulation for demo
 func SimulateResolution(ticker string, yesProbability float64) (string, string) {
-	rand.Seed(time.Now().UnixNano())
+	mockRNGMu.Lock()
+	draw := mockRNG.Float64()
+	mockRNGMu.Unlock()
 
 	var result string
-	if rand.Float64() < yesProbability {
+	if draw < yesProbability {
 		result = "yes"
 	} else {
 		result = "no"
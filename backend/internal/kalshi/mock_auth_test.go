@@ -0,0 +1,230 @@
+package kalshi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func restingYes(t *testing.T, e *MockOrderExecutor, userID string, count, price int) *MockOrderResponse {
+	t.Helper()
+	order, err := e.PlaceOrder(userID, MockOrderRequest{
+		Ticker: "FED-24DEC", Side: "yes", Action: "buy", Type: "limit", Count: count, YesPrice: price,
+	}, 48, 52)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if order.Status != "open" {
+		t.Fatalf("status = %s, want open", order.Status)
+	}
+	return order
+}
+
+func TestTick_FillsRestingOrderWhenMarketMovesThrough(t *testing.T) {
+	e := NewMockOrderExecutor()
+	e.SetSeed(1)
+	order := restingYes(t, e, "user-1", 10, 40)
+
+	// Market still above the limit: maker's ask is at least 50-3+2 = 49
+	if fills := e.Tick("FED-24DEC", 48, 52); len(fills) != 0 {
+		t.Fatalf("unexpected fills above the limit: %+v", fills)
+	}
+
+	// Market drops through the limit: maker's ask is at most 32+3+2 = 37
+	fills := e.Tick("FED-24DEC", 30, 34)
+	if len(fills) != 1 {
+		t.Fatalf("fills = %d, want 1", len(fills))
+	}
+	fill := fills[0]
+	if fill.OrderID != order.OrderID || fill.Count != 10 || fill.PriceCents != 40 {
+		t.Errorf("fill = %+v, want 10 @ 40 for %s", fill, order.OrderID)
+	}
+
+	orders := e.GetOrders("user-1", "filled")
+	if len(orders) != 1 || orders[0].FilledAvgPrice != 40 || orders[0].RemainingCount != 0 {
+		t.Errorf("filled orders = %+v", orders)
+	}
+	positions := e.GetPositions("user-1")
+	if len(positions) != 1 || positions[0].Contracts != 10 {
+		t.Errorf("positions = %+v, want 10 contracts", positions)
+	}
+
+	if fills := e.Tick("FED-24DEC", 30, 34); len(fills) != 0 {
+		t.Errorf("filled order traded again: %+v", fills)
+	}
+}
+
+func TestTick_DepthAndPriceTimePriority(t *testing.T) {
+	e := NewMockOrderExecutor()
+	e.SetSeed(1)
+	e.maker = MarketMakerConfig{HalfSpread: 1, Depth: 15, Jitter: 0}
+	first := restingYes(t, e, "user-1", 10, 40)
+	better := restingYes(t, e, "user-2", 10, 45)
+	later := restingYes(t, e, "user-3", 10, 40)
+
+	fills := e.Tick("FED-24DEC", 20, 20)
+	if len(fills) != 2 {
+		t.Fatalf("fills = %+v, want 2", fills)
+	}
+	if fills[0].OrderID != better.OrderID || fills[0].Count != 10 {
+		t.Errorf("first fill = %+v, want the 45c order in full", fills[0])
+	}
+	if fills[1].OrderID != first.OrderID || fills[1].Count != 5 {
+		t.Errorf("second fill = %+v, want 5 of the earlier 40c order", fills[1])
+	}
+
+	// The partially filled order keeps its place ahead of later arrivals
+	fills = e.Tick("FED-24DEC", 20, 20)
+	if len(fills) != 2 || fills[0].OrderID != first.OrderID || fills[0].Count != 5 ||
+		fills[1].OrderID != later.OrderID || fills[1].Count != 10 {
+		t.Errorf("second tick fills = %+v", fills)
+	}
+}
+
+func TestTick_SeededRunsAreDeterministic(t *testing.T) {
+	run := func() []MockFill {
+		e := NewMockOrderExecutor()
+		e.SetSeed(42)
+		restingYes(t, e, "user-1", 500, 44)
+		// Timestamps differ between runs; compare what traded
+		var all []MockFill
+		for i := 0; i < 20; i++ {
+			for _, f := range e.Tick("FED-24DEC", 40, 42) {
+				all = append(all, MockFill{FillID: f.FillID, Count: f.Count, PriceCents: f.PriceCents})
+			}
+		}
+		return all
+	}
+	a, b := run(), run()
+	if len(a) == 0 {
+		t.Fatal("expected the jittered market to cross the limit at least once")
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed produced different fills:\n%v\n%v", a, b)
+	}
+}
+
+func TestSimulateSettlement_CancelsRestingOrders(t *testing.T) {
+	e := NewMockOrderExecutor()
+	restingYes(t, e, "user-1", 10, 40)
+	e.SimulateSettlement("FED-24DEC", "no", "test")
+	if fills := e.Tick("FED-24DEC", 10, 12); len(fills) != 0 {
+		t.Errorf("settled market produced fills: %+v", fills)
+	}
+	if orders := e.GetOrders("user-1", "cancelled"); len(orders) != 1 {
+		t.Errorf("cancelled orders = %d, want 1", len(orders))
+	}
+}
+
+func TestSimulateResolution_ReproducibleWithSeed(t *testing.T) {
+	run := func() []string {
+		SetMockSeed(7)
+		results := make([]string, 50)
+		for i := range results {
+			results[i], _ = SimulateResolution("FED-24DEC", 0.5)
+		}
+		return results
+	}
+	a, b := run(), run()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed produced different resolutions:\n%v\n%v", a, b)
+	}
+	yes := 0
+	for _, r := range a {
+		if r == "yes" {
+			yes++
+		}
+	}
+	if yes == 0 || yes == len(a) {
+		t.Errorf("50 draws at p=0.5 all resolved the same way (%d yes)", yes)
+	}
+}
+
+func TestNewMockOrderExecutor_SeededFromPackageRNG(t *testing.T) {
+	SetMockSeed(7)
+	a, b := NewMockOrderExecutor(), NewMockOrderExecutor()
+	if a.rng.Int63() == b.rng.Int63() {
+		t.Error("executors created back to back share an RNG sequence")
+	}
+	SetMockSeed(7)
+	c := NewMockOrderExecutor()
+	SetMockSeed(7)
+	d := NewMockOrderExecutor()
+	if c.rng.Int63() != d.rng.Int63() {
+		t.Error("executors created after the same seed differ")
+	}
+}
+
+func TestSettlementRule_SelectSource(t *testing.T) {
+	rule := DefaultSettlementRules()["CPI"] // 30m delay, 24h extension
+	closed := time.Date(2024, 11, 13, 13, 30, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return closed.Add(d) }
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		published map[string]time.Time
+		wantName  string // "" means still waiting
+		wantTier  string
+		extended  bool
+	}{
+		{"primary on time", at(time.Hour), map[string]time.Time{"bls.gov": at(10 * time.Minute)}, "bls.gov", models.SourceTierPrimary, false},
+		{"primary late within extension", at(3 * time.Hour), map[string]time.Time{"bls.gov": at(2 * time.Hour)}, "bls.gov", models.SourceTierPrimary, true},
+		{"primary delayed, window open", at(3 * time.Hour), map[string]time.Time{"reuters.com": at(20 * time.Minute)}, "", "", false},
+		{"primary delayed past window", at(25 * time.Hour), map[string]time.Time{"reuters.com": at(20 * time.Minute)}, "reuters.com", models.SourceTierSecondary, true},
+		{"primary after window", at(26 * time.Hour), map[string]time.Time{"bls.gov": at(25 * time.Hour), "reuters.com": at(time.Hour)}, "reuters.com", models.SourceTierSecondary, true},
+		{"only tertiary", at(25 * time.Hour), map[string]time.Time{"Trading Economics": at(time.Hour)}, "Trading Economics", models.SourceTierTertiary, true},
+		{"reports in the future ignored", at(time.Hour), map[string]time.Time{"bls.gov": at(2 * time.Hour)}, "", "", false},
+		{"no source after window", at(25 * time.Hour), nil, "", "", false},
+	}
+	for _, tt := range tests {
+		source, ok := rule.SelectSource(closed, tt.now, tt.published)
+		if tt.wantName == "" {
+			if ok {
+				t.Errorf("%s: selected %+v, want to keep waiting", tt.name, source)
+			}
+			continue
+		}
+		if !ok || source.Name != tt.wantName || source.Tier != tt.wantTier || source.Extended != tt.extended || (source.DelayReason != "") != tt.extended ||
+			!source.ReportedAt.Equal(tt.published[tt.wantName]) || !source.VerifiedAt.Equal(tt.now) {
+			t.Errorf("%s: source = %+v (%v), want %s %s extended=%v", tt.name, source, ok, tt.wantTier, tt.wantName, tt.extended)
+		}
+	}
+}
+
+func TestSimulateSettlementFromSource_RecordsSource(t *testing.T) {
+	e := NewMockOrderExecutor()
+	source := &models.SettlementSource{Name: "reuters.com", Tier: models.SourceTierSecondary, Extended: true}
+	e.SimulateSettlementFromSource("CPI-24NOV", "yes", "CPI above 3%", source)
+	settlements := e.GetSettlements("CPI-24NOV")
+	if len(settlements) != 1 || settlements[0].Source == nil || settlements[0].Source.Name != "reuters.com" {
+		t.Errorf("settlements = %+v, want one from reuters.com", settlements)
+	}
+}
+
+func TestResolveRuleForTicker(t *testing.T) {
+	tests := []struct {
+		ticker, series string
+		wantCategory   string
+		wantMatched    bool
+	}{
+		{"FED-24DEC", "", "Federal Reserve", true},
+		{"CPI-24NOV-T3.0", "CPI", "Consumer Price Index", true},
+		{"KXUNEMP-25JAN", "KXUNEMP", "Unemployment Rate", true},
+		{"gdp-q4", "", "Gross Domestic Product", true},
+		{"FED-24DEC", "KXHIGHNY", "General", false}, // series wins over ticker
+		{"KXHIGHNY-25JAN01", "KXHIGHNY", "General", false},
+		{"", "", "General", false},
+	}
+	for _, tt := range tests {
+		rule, ok := ResolveRuleForTicker(tt.ticker, tt.series)
+		if rule.Category != tt.wantCategory || ok != tt.wantMatched {
+			t.Errorf("ResolveRuleForTicker(%q, %q) = %s, %v; want %s, %v", tt.ticker, tt.series, rule.Category, ok, tt.wantCategory, tt.wantMatched)
+		}
+	}
+	if rule, _ := ResolveRuleForTicker("KXHIGHNY-25JAN01", ""); rule.Sources.Primary != "kalshi.com" || rule.ExtensionWindow != 24*time.Hour {
+		t.Errorf("default rule = %+v, want kalshi.com with the 24h extension", rule)
+	}
+}
@@ -0,0 +1,102 @@
+package kalshi
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// =============================================================================
+// RISK CLASSIFICATION
+// Core Principle 3: Contracts not readily susceptible to manipulation.
+// Economic binaries settle on official statistics and are low risk;
+// politics is medium; everything else (sports, entertainment) is high.
+// =============================================================================
+
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// RiskClassifier holds the operator-tunable category and series lists.
+type RiskClassifier struct {
+	LowRiskCategories    []string
+	LowRiskSeries        []string
+	MediumRiskCategories []string
+}
+
+// RiskAssessment is a classification with the rule that produced it.
+type RiskAssessment struct {
+	Category string `json:"risk_category"`
+	Reason   string `json:"reason"`
+}
+
+// DefaultRiskClassifier returns the built-in economic binary lists.
+func DefaultRiskClassifier() RiskClassifier {
+	return RiskClassifier{
+		LowRiskCategories:    []string{"Economics", "Fed", "Interest", "Inflation", "GDP", "Unemployment", "CPI"},
+		LowRiskSeries:        []string{"FED", "FOMC", "CPI", "GDP", "UNEMP", "INFLATION"},
+		MediumRiskCategories: []string{"Politics", "Elections"},
+	}
+}
+
+// WithOverrides returns a copy where each non-empty list replaces the
+// corresponding built-in list.
+func (c RiskClassifier) WithOverrides(lowCategories, lowSeries, mediumCategories []string) RiskClassifier {
+	if len(lowCategories) > 0 {
+		c.LowRiskCategories = lowCategories
+	}
+	if len(lowSeries) > 0 {
+		c.LowRiskSeries = lowSeries
+	}
+	if len(mediumCategories) > 0 {
+		c.MediumRiskCategories = mediumCategories
+	}
+	return c
+}
+
+// Classify determines manipulation risk for a market. Category and series
+// matches are case-insensitive.
+func (c RiskClassifier) Classify(category, seriesTicker string) RiskAssessment {
+	if containsFold(c.LowRiskSeries, seriesTicker) {
+		return RiskAssessment{RiskLow, fmt.Sprintf("series %s is a low-risk economic binary", strings.ToUpper(seriesTicker))}
+	}
+	if containsFold(c.LowRiskCategories, category) {
+		return RiskAssessment{RiskLow, fmt.Sprintf("category %q is a low-risk economic category", category)}
+	}
+	if containsFold(c.MediumRiskCategories, category) {
+		return RiskAssessment{RiskMedium, fmt.Sprintf("category %q is classified as medium risk", category)}
+	}
+	return RiskAssessment{RiskHigh, "category and series are not on the low or medium risk lists"}
+}
+
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+var activeClassifier atomic.Pointer[RiskClassifier]
+
+func init() {
+	c := DefaultRiskClassifier()
+	activeClassifier.Store(&c)
+}
+
+// SetRiskClassifier replaces the classifier used by ClassifyRisk and ToMarket.
+// Called at startup with the configured lists.
+func SetRiskClassifier(c RiskClassifier) {
+	activeClassifier.Store(&c)
+}
+
+// ClassifyRisk classifies a market with the active classifier.
+func ClassifyRisk(category, seriesTicker string) RiskAssessment {
+	return activeClassifier.Load().Classify(category, seriesTicker)
+}
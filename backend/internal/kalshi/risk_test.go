@@ -0,0 +1,35 @@
+package kalshi
+
+import "testing"
+
+func TestRiskClassifier_Defaults(t *testing.T) {
+	c := DefaultRiskClassifier()
+	tests := []struct {
+		category, series, want string
+	}{
+		{"Economics", "", RiskLow},
+		{"", "FED", RiskLow},
+		{"Politics", "", RiskMedium},
+		{"Sports", "NBA", RiskHigh},
+	}
+	for _, tt := range tests {
+		if got := c.Classify(tt.category, tt.series); got.Category != tt.want || got.Reason == "" {
+			t.Errorf("Classify(%q, %q) = %+v, want %s with reason", tt.category, tt.series, got, tt.want)
+		}
+	}
+}
+
+func TestRiskClassifier_ConfigAddedLowRiskSeries(t *testing.T) {
+	defaults := DefaultRiskClassifier()
+	c := defaults.WithOverrides(nil, append(defaults.LowRiskSeries, "KXHIGHNY"), nil)
+
+	if got := c.Classify("Climate", "KXHIGHNY"); got.Category != RiskLow {
+		t.Errorf("configured series should be low risk, got %+v", got)
+	}
+	if got := c.Classify("Politics", ""); got.Category != RiskMedium {
+		t.Errorf("unconfigured lists must keep defaults, got %+v", got)
+	}
+	if got := defaults.Classify("Climate", "KXHIGHNY"); got.Category != RiskHigh {
+		t.Errorf("defaults must be unchanged, got %+v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_AssignsAndPropagatesRequestID(t *testing.T) {
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/health", nil))
+	if seen == "" || rec.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("expected generated ID in context and header, got %q / %q", seen, rec.Header().Get(RequestIDHeader))
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	req.Header.Set(RequestIDHeader, "client-abc_123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "client-abc_123" {
+		t.Errorf("expected incoming request ID to be reused, got %q", seen)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/health", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "bad id\nwith newline" {
+		t.Error("unsafe incoming request ID must be replaced")
+	}
+}
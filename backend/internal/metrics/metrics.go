@@ -0,0 +1,122 @@
+// Package metrics exposes Prometheus metrics for the DCM demo.
+// CP 4: Order, alert and halt activity is observable in real time.
+//
+// Metric names:
+//
+//	dcm_orders_placed_total                      counter
+//	dcm_orders_rejected_total{reason}            counter
+//	dcm_compliance_alerts_total{type}            counter
+//	dcm_trading_halts_active                     gauge
+//	dcm_http_request_duration_seconds{method,route,status}  histogram
+//	dcm_kalshi_request_duration_seconds{endpoint,status}    histogram
+//
+// Collectors are always updated; they are only exported once Register is
+// called, so instrumented code does not need to know whether metrics are on.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	OrdersPlaced = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dcm_orders_placed_total",
+		Help: "Orders accepted by the platform.",
+	})
+
+	OrdersRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dcm_orders_rejected_total",
+		Help: "Orders rejected, by reason code.",
+	}, []string{"reason"})
+
+	AlertsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dcm_compliance_alerts_total",
+		Help: "Compliance alerts raised, by alert type.",
+	}, []string{"type"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dcm_http_request_duration_seconds",
+		Help:    "API handler latency.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	KalshiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dcm_kalshi_request_duration_seconds",
+		Help:    "Kalshi API request duration; status is the HTTP code or \"error\".",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint", "status"})
+)
+
+// Register adds all collectors to reg. activeHalts reports the number of
+// active trading halts at scrape time.
+func Register(reg prometheus.Registerer, activeHalts func() int) error {
+	collectors := []prometheus.Collector{
+		OrdersPlaced,
+		OrdersRejected,
+		AlertsCreated,
+		HTTPRequestDuration,
+		KalshiRequestDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "dcm_trading_halts_active",
+			Help: "Trading halts currently in effect (market and global).",
+		}, func() float64 { return float64(activeHalts()) }),
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics registered on the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records handler latency labelled by the mux route template, so
+// /markets/{ticker} is one series rather than one per ticker.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+		HTTPRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder captures the response status. Flush and Unwrap keep
+// streaming responses working through the middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
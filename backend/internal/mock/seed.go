@@ -0,0 +1,112 @@
+package mock
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// =============================================================================
+// DEMO DATA
+// Gives a first run verified traders with funded wallets and open positions,
+// matching the accounts the surveillance app shows.
+// =============================================================================
+
+// DemoPassword is the login password of every seeded account.
+const DemoPassword = "demo-password"
+
+type demoFill struct {
+	ticker     string
+	event      string
+	side       models.OrderSide
+	quantity   int
+	priceCents int
+}
+
+type demoUser struct {
+	email, firstName, lastName, state string
+	verified                          bool
+	depositUSD                        float64
+	fills                             []demoFill
+}
+
+var demoUsers = []demoUser{
+	{
+		email: "trader1@example.com", firstName: "Alex", lastName: "Rivera", state: "NY",
+		verified: true, depositUSD: 10000,
+		fills: []demoFill{
+			{"FED-RATE-MAR", "FED-RATE", models.OrderSideYes, 100, 65},
+			{"CPI-FEB", "CPI", models.OrderSideNo, 50, 48},
+		},
+	},
+	{
+		email: "trader2@example.com", firstName: "Jordan", lastName: "Lee", state: "IL",
+		verified: true, depositUSD: 5000,
+		fills: []demoFill{
+			{"CPI-FEB", "CPI", models.OrderSideYes, 40, 48},
+		},
+	},
+	{
+		email: "trader3@example.com", firstName: "Sam", lastName: "Patel", state: "TX",
+	},
+}
+
+// SeedDemoData creates demo accounts in an empty store: verified traders
+// with cleared deposits and filled positions, and one account awaiting KYC.
+// Every account logs in with DemoPassword. A store that already has users
+// is left alone. Returns the number of accounts created.
+func SeedDemoData(s *Store) (int, error) {
+	s.usersMu.RLock()
+	hasUsers := len(s.users) > 0
+	s.usersMu.RUnlock()
+	if hasUsers {
+		return 0, nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(DemoPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	dob := time.Now().UTC().AddDate(-35, 0, 0)
+	for _, demo := range demoUsers {
+		user, err := s.CreateUser(demo.email, string(hash), demo.firstName, demo.lastName, demo.state, dob, true, "seed")
+		if err != nil {
+			return 0, fmt.Errorf("seed %s: %w", demo.email, err)
+		}
+		if _, err := s.CreateKYCRecord(user.ID, "drivers_license", "DEMO-"+user.ID, "seed"); err != nil {
+			return 0, fmt.Errorf("seed %s: %w", demo.email, err)
+		}
+		if _, err := s.CreateWallet(user.ID, "seed"); err != nil {
+			return 0, fmt.Errorf("seed %s: %w", demo.email, err)
+		}
+		if !demo.verified {
+			continue
+		}
+		if err := s.MockKYCApproval(user.ID, true, ""); err != nil {
+			return 0, fmt.Errorf("seed %s: %w", demo.email, err)
+		}
+		tx, err := s.Deposit(user.ID, models.Dollars(demo.depositUSD), "demo-seed", "seed")
+		if err != nil {
+			return 0, fmt.Errorf("seed %s: %w", demo.email, err)
+		}
+		// Demo funds clear at once whatever the clearing delay
+		if tx.AvailableAt != nil {
+			s.ReleasePendingTransactions(*tx.AvailableAt)
+		}
+
+		for _, fill := range demo.fills {
+			order, err := s.CreateOrder(user.ID, fill.ticker, fill.event, fill.side, models.OrderTypeLimit,
+				fill.quantity, fill.priceCents, "seed")
+			if err != nil {
+				return 0, fmt.Errorf("seed %s order in %s: %w", demo.email, fill.ticker, err)
+			}
+			if err := s.MockFillOrder(order.ID, fill.priceCents); err != nil {
+				return 0, fmt.Errorf("seed %s fill in %s: %w", demo.email, fill.ticker, err)
+			}
+		}
+	}
+	return len(demoUsers), nil
+}
@@ -6,12 +6,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/ids"
+	"github.com/kalshi-dcm-demo/backend/internal/metrics"
 	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/persistence"
+	"github.com/kalshi-dcm-demo/backend/internal/redact"
 )
 
 // =============================================================================
@@ -30,6 +39,33 @@ var (
 	ErrMarketClosed          = errors.New("market is closed")
 	ErrPositionLimitExceeded = errors.New("position limit exceeded")
 	ErrTradingHalted         = errors.New("trading is currently halted")
+	ErrMarketAlreadySettled  = errors.New("market already settled")
+	ErrInvalidResult         = errors.New("invalid settlement result")
+	ErrOrderNotFillable      = errors.New("order is not open for fills")
+	ErrInvalidFillQuantity   = errors.New("fill quantity exceeds unfilled quantity")
+	ErrOrderNotAmendable     = errors.New("only open or pending orders can be amended")
+	ErrInvalidAmend          = errors.New("invalid amend price or quantity")
+	ErrSelfTradePrevented    = errors.New("order would trade against your own resting order")
+	ErrTooManyOpenOrders     = errors.New("too many open orders")
+	ErrOrderNotCancellable   = errors.New("only resting orders can be cancelled")
+	ErrInvalidAmount         = errors.New("amount must be positive")
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrKYCAlreadySubmitted   = errors.New("KYC already submitted")
+	ErrNotReversible         = errors.New("transaction cannot be reversed")
+	ErrAlertNotFound         = errors.New("alert not found")
+	ErrInvalidAlertStatus    = errors.New("invalid alert status transition")
+	ErrAlertClosed           = errors.New("alert is resolved or escalated")
+	ErrInvalidLimits         = errors.New("invalid position limit table")
+	ErrNothingToReduce       = errors.New("reduce-only order exceeds the position it can reduce")
+	ErrInvalidClose          = errors.New("invalid close quantity or price")
+	ErrDepositMethod         = errors.New("deposit method not accepted")
+	ErrDepositBelowMinimum   = errors.New("deposit below the method minimum")
+	ErrDepositAboveMaximum   = errors.New("deposit above the method maximum")
+	ErrInvalidReference      = errors.New("invalid deposit reference")
+	ErrDuplicateReference    = errors.New("deposit reference already used")
+	ErrInvalidStatusChange   = errors.New("invalid user status change")
+	ErrKYCNotApproved        = errors.New("user has no approved KYC record")
+	ErrUnlockExceedsLocked   = errors.New("unlock exceeds locked funds")
 )
 
 // =============================================================================
@@ -37,10 +73,19 @@ var (
 // =============================================================================
 
 type PersistenceConfig struct {
-	Enabled          bool
-	DataDir          string
-	AutoSaveInterval time.Duration
-	RetentionYears   int
+	Enabled             bool
+	Backend             string // "file" (default) or "sqlite"
+	DataDir             string
+	AutoSaveInterval    time.Duration
+	RetentionYears      int
+	AuditRetentionDays  int           // Overrides RetentionYears when set
+	SnapshotKeepDays    int           // Timestamped snapshots older than this are deleted
+	MaintenanceInterval time.Duration // 0 disables snapshot/audit cleanup
+	CompressSnapshots   bool          // gzip snapshots (file backend only)
+
+	// FieldCipher seals sensitive fields such as KYC document numbers in
+	// snapshots. Without one those fields are not persisted.
+	FieldCipher *persistence.FieldCipher
 }
 
 // =============================================================================
@@ -52,51 +97,57 @@ type Store struct {
 	usersByEmail    map[string]string
 	usersMu         sync.RWMutex
 	kycRecords      map[string]*models.KYCRecord
+	sealedKYC       map[string]string // loaded document numbers that could not be opened, by record ID (guarded by kycRecordsMu)
 	kycRecordsMu    sync.RWMutex
 	wallets         map[string]*models.Wallet
+	withdrawalHold  time.Duration                                       // guarded by walletsMu
+	depositMethods  map[models.DepositMethod]models.DepositMethodConfig // accepted deposit methods (guarded by walletsMu)
 	walletsMu       sync.RWMutex
 	transactions    map[string]*models.Transaction
 	txByWallet      map[string][]string
+	depositRefs     map[string]string // deposit transaction IDs by method and external reference (guarded by transactionsMu)
 	transactionsMu  sync.RWMutex
+	ledger          []models.JournalEntry
+	ledgerMu        sync.RWMutex
 	orders          map[string]*models.Order
+	orderTTL        time.Duration // resting orders expire this long after placement; 0 never (guarded by ordersMu)
 	ordersByUser    map[string][]string
 	ordersMu        sync.RWMutex
 	positions       map[string]*models.Position
 	positionsByUser map[string][]string
+	settledMarkets  map[string]time.Time // guarded by positionsMu
+	washSetupAt     int                  // hedged contracts per market that raise a wash_setup alert (guarded by positionsMu)
+	costBasis       CostBasisMethod      // cost relieved by partial closes (guarded by positionsMu)
 	positionsMu     sync.RWMutex
+	settlements     []models.Settlement
+	settlementsMu   sync.RWMutex
+	fees            models.FeeSchedule
+	feesMu          sync.RWMutex
+	limits          []models.PositionLimitConfig
+	limitsMu        sync.RWMutex
+	selfTradePolicy SelfTradePolicy
+	selfTradeMu     sync.RWMutex
 	auditLog        []models.AuditEntry
 	auditLogMu      sync.RWMutex
 	alerts          []models.ComplianceAlert
+	alertDedup      time.Duration // repeats within this of an active alert fold into it (guarded by alertsMu)
 	alertsMu        sync.RWMutex
 	halts           map[string]*models.EmergencyHalt
+	haltClock       func() time.Time // when halts start and lapse; tests replace it
 	haltsMu         sync.RWMutex
-	idCounter       int64
-	idCounterMu     sync.Mutex
+	events          *events.Bus // nil discards domain events
+	ids             *ids.Generator
 	persistence     PersistenceConfig
+	persister       persistence.Persister
+	auditPersisted  int                    // audit entries already handed to the persister (guarded by saveMu)
+	rateLimiter     RateLimiter            // order rate state saved with snapshots (guarded by saveMu)
+	loadedRates     map[string][]time.Time // rates loaded before a rate limiter was set (guarded by saveMu)
 	stopChan        chan struct{}
+	stopped         chan struct{}
+	stopOnce        sync.Once
 	saveMu          sync.Mutex
 }
 
-// PersistentData - JSON serialization structure for CP 18 compliance
-type PersistentData struct {
-	Version         string                           `json:"version"`
-	SavedAt         time.Time                        `json:"saved_at"`
-	Users           map[string]*models.User          `json:"users"`
-	UsersByEmail    map[string]string                `json:"users_by_email"`
-	KYCRecords      map[string]*models.KYCRecord     `json:"kyc_records"`
-	Wallets         map[string]*models.Wallet        `json:"wallets"`
-	Transactions    map[string]*models.Transaction   `json:"transactions"`
-	TxByWallet      map[string][]string              `json:"tx_by_wallet"`
-	Orders          map[string]*models.Order         `json:"orders"`
-	OrdersByUser    map[string][]string              `json:"orders_by_user"`
-	Positions       map[string]*models.Position      `json:"positions"`
-	PositionsByUser map[string][]string              `json:"positions_by_user"`
-	AuditLog        []models.AuditEntry              `json:"audit_log"`
-	Alerts          []models.ComplianceAlert         `json:"alerts"`
-	Halts           map[string]*models.EmergencyHalt `json:"halts"`
-	IDCounter       int64                            `json:"id_counter"`
-}
-
 func NewStore() *Store {
 	return NewStoreWithPersistence(PersistenceConfig{
 		Enabled:          false,
@@ -106,7 +157,28 @@ func NewStore() *Store {
 	})
 }
 
+// NewStoreWithPersistence creates a store using the backend named in config.
+// An unavailable backend falls back to JSON files so records are never dropped.
 func NewStoreWithPersistence(config PersistenceConfig) *Store {
+	var persister persistence.Persister
+	if config.Enabled {
+		opts := persistence.Options{CompressSnapshots: config.CompressSnapshots}
+		p, err := persistence.NewPersister(config.Backend, config.DataDir, opts)
+		if err != nil {
+			log.Printf("Persistence backend %q unavailable (%v), falling back to JSON files", config.Backend, err)
+			p, err = persistence.NewPersister(persistence.BackendFile, config.DataDir, opts)
+		}
+		if err != nil {
+			log.Printf("Persistence disabled: %v", err)
+		} else {
+			persister = p
+		}
+	}
+	return NewStoreWithPersister(config, persister)
+}
+
+// NewStoreWithPersister creates a store backed by an explicit Persister.
+func NewStoreWithPersister(config PersistenceConfig, persister persistence.Persister) *Store {
 	s := &Store{
 		users:           make(map[string]*models.User),
 		usersByEmail:    make(map[string]string),
@@ -114,38 +186,61 @@ func NewStoreWithPersistence(config PersistenceConfig) *Store {
 		wallets:         make(map[string]*models.Wallet),
 		transactions:    make(map[string]*models.Transaction),
 		txByWallet:      make(map[string][]string),
+		depositRefs:     make(map[string]string),
 		orders:          make(map[string]*models.Order),
 		ordersByUser:    make(map[string][]string),
 		positions:       make(map[string]*models.Position),
 		positionsByUser: make(map[string][]string),
+		settledMarkets:  make(map[string]time.Time),
+		depositMethods:  depositMethodMap(DefaultDepositMethods()),
+		washSetupAt:     DefaultWashSetupContracts,
+		alertDedup:      DefaultAlertDedupWindow,
+		settlements:     make([]models.Settlement, 0),
 		auditLog:        make([]models.AuditEntry, 0),
 		alerts:          make([]models.ComplianceAlert, 0),
 		halts:           make(map[string]*models.EmergencyHalt),
+		haltClock:       time.Now,
+		limits:          models.DefaultPositionLimits(),
+		ids:             ids.NewGenerator(nil),
 		persistence:     config,
+		persister:       persister,
 		stopChan:        make(chan struct{}),
+		stopped:         make(chan struct{}),
 	}
-	if config.Enabled {
+	if config.Enabled && persister != nil {
 		s.initPersistence()
 	}
 	return s
 }
 
 func (s *Store) initPersistence() {
-	dirs := []string{s.persistence.DataDir, filepath.Join(s.persistence.DataDir, "snapshots"), filepath.Join(s.persistence.DataDir, "audit")}
-	for _, dir := range dirs {
-		os.MkdirAll(dir, 0755)
+	if err := s.Load(); err != nil {
+		log.Printf("Failed to load persisted data: %v", err)
 	}
-	s.Load()
 	go s.autoSaveLoop()
 }
 
 func (s *Store) autoSaveLoop() {
+	defer close(s.stopped)
 	ticker := time.NewTicker(s.persistence.AutoSaveInterval)
 	defer ticker.Stop()
+
+	// Retention cleanup only runs for backends that support it
+	var maintenance <-chan time.Time
+	if _, ok := s.persister.(persistence.Maintainer); ok && s.persistence.MaintenanceInterval > 0 {
+		maintenanceTicker := time.NewTicker(s.persistence.MaintenanceInterval)
+		defer maintenanceTicker.Stop()
+		maintenance = maintenanceTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
 			s.Save()
+		case <-maintenance:
+			if err := s.RunMaintenance(); err != nil {
+				log.Printf("Persistence maintenance failed: %v", err)
+			}
 		case <-s.stopChan:
 			s.Save()
 			return
@@ -153,31 +248,109 @@ func (s *Store) autoSaveLoop() {
 	}
 }
 
+// RunMaintenance applies the configured retention policy to the backend.
+// CP 18: Snapshots are pruned while audit records move to the archive.
+func (s *Store) RunMaintenance() error {
+	maintainer, ok := s.persister.(persistence.Maintainer)
+	if !ok {
+		return nil
+	}
+	auditDays := s.persistence.AuditRetentionDays
+	if auditDays <= 0 {
+		auditDays = s.persistence.RetentionYears * 365
+	}
+	return maintainer.RunMaintenance(persistence.RetentionPolicy{
+		SnapshotKeepDays:   s.persistence.SnapshotKeepDays,
+		AuditRetentionDays: auditDays,
+	})
+}
+
+// CheckPersistence reports whether the data directory is writable. It
+// returns nil when persistence is disabled.
+func (s *Store) CheckPersistence() error {
+	if !s.persistence.Enabled {
+		return nil
+	}
+	return persistence.CheckWritable(s.persistence.DataDir)
+}
+
+// PersistenceEnabled reports whether the store persists to disk.
+func (s *Store) PersistenceEnabled() bool {
+	return s.persistence.Enabled && s.persister != nil
+}
+
+// Stop performs a final save and waits for it to complete.
 func (s *Store) Stop() {
-	if s.persistence.Enabled {
+	if !s.persistence.Enabled || s.persister == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
 		close(s.stopChan)
+		<-s.stopped
+	})
+}
+
+// RateLimiter is the surveillance order rate state saved with snapshots, so
+// a restart does not reset every user's rate limit window.
+// CP 4: The limiter cannot be dodged by timing a deploy.
+type RateLimiter interface {
+	OrderRates() map[string][]time.Time
+	RestoreOrderRates(rates map[string][]time.Time)
+}
+
+// SetRateLimiter saves limiter's state with each snapshot and restores into
+// it any state loaded from the latest one.
+func (s *Store) SetRateLimiter(limiter RateLimiter) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.rateLimiter = limiter
+	if s.loadedRates != nil {
+		limiter.RestoreOrderRates(s.loadedRates)
+		s.loadedRates = nil
 	}
 }
 
 func (s *Store) Save() error {
-	if !s.persistence.Enabled {
+	if !s.persistence.Enabled || s.persister == nil {
 		return nil
 	}
 	s.saveMu.Lock()
 	defer s.saveMu.Unlock()
 
 	data := s.collectData()
-	snapshotPath := filepath.Join(s.persistence.DataDir, "snapshots", "latest.json")
-	if err := s.writeJSON(snapshotPath, data); err != nil {
+	if err := s.persister.SaveSnapshot(data); err != nil {
+		return err
+	}
+	return s.flushAuditLog()
+}
+
+// flushAuditLog hands audit entries recorded since the last save to the
+// persister. Caller must hold saveMu.
+func (s *Store) flushAuditLog() error {
+	s.auditLogMu.RLock()
+	pending := append([]models.AuditEntry{}, s.auditLog[s.auditPersisted:]...)
+	s.auditLogMu.RUnlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := s.persister.SaveAuditEntries(pending); err != nil {
 		return err
 	}
-	backupPath := filepath.Join(s.persistence.DataDir, "snapshots", fmt.Sprintf("snapshot_%s.json", time.Now().Format("20060102_150405")))
-	s.writeJSON(backupPath, data)
-	s.saveAuditLog()
+	s.auditPersisted += len(pending)
 	return nil
 }
 
-func (s *Store) collectData() *PersistentData {
+// QueryAuditArchive searches the persisted audit archive, which may extend
+// beyond what is held in memory.
+// CP 18: Supports queries across the full retention period.
+func (s *Store) QueryAuditArchive(userID string, since, until time.Time) ([]models.AuditEntry, error) {
+	if s.persister == nil {
+		return nil, nil
+	}
+	return s.persister.QueryAudit(userID, since, until)
+}
+
+func (s *Store) collectData() *persistence.DataSnapshot {
 	s.usersMu.RLock()
 	users := make(map[string]*models.User)
 	for k, v := range s.users {
@@ -194,6 +367,7 @@ func (s *Store) collectData() *PersistentData {
 	for k, v := range s.kycRecords {
 		kycRecords[k] = v
 	}
+	kycDocuments := s.sealKYCDocuments()
 	s.kycRecordsMu.RUnlock()
 
 	s.walletsMu.RLock()
@@ -214,6 +388,10 @@ func (s *Store) collectData() *PersistentData {
 	}
 	s.transactionsMu.RUnlock()
 
+	s.ledgerMu.RLock()
+	ledger := append([]models.JournalEntry{}, s.ledger...)
+	s.ledgerMu.RUnlock()
+
 	s.ordersMu.RLock()
 	orders := make(map[string]*models.Order)
 	for k, v := range s.orders {
@@ -234,12 +412,20 @@ func (s *Store) collectData() *PersistentData {
 	for k, v := range s.positionsByUser {
 		positionsByUser[k] = append([]string{}, v...)
 	}
+	settledMarkets := make(map[string]time.Time)
+	for k, v := range s.settledMarkets {
+		settledMarkets[k] = v
+	}
 	s.positionsMu.RUnlock()
 
 	s.auditLogMu.RLock()
 	auditLog := append([]models.AuditEntry{}, s.auditLog...)
 	s.auditLogMu.RUnlock()
 
+	s.settlementsMu.RLock()
+	settlements := append([]models.Settlement{}, s.settlements...)
+	s.settlementsMu.RUnlock()
+
 	s.alertsMu.RLock()
 	alerts := append([]models.ComplianceAlert{}, s.alerts...)
 	s.alertsMu.RUnlock()
@@ -251,56 +437,41 @@ func (s *Store) collectData() *PersistentData {
 	}
 	s.haltsMu.RUnlock()
 
-	s.idCounterMu.Lock()
-	idCounter := s.idCounter
-	s.idCounterMu.Unlock()
+	limits := s.GetPositionLimits()
 
-	return &PersistentData{
-		Version: "2.0", SavedAt: time.Now().UTC(), Users: users, UsersByEmail: usersByEmail,
-		KYCRecords: kycRecords, Wallets: wallets, Transactions: transactions, TxByWallet: txByWallet,
-		Orders: orders, OrdersByUser: ordersByUser, Positions: positions, PositionsByUser: positionsByUser,
-		AuditLog: auditLog, Alerts: alerts, Halts: halts, IDCounter: idCounter,
+	orderRates := s.loadedRates
+	if s.rateLimiter != nil {
+		orderRates = s.rateLimiter.OrderRates()
 	}
-}
 
-func (s *Store) saveAuditLog() error {
-	s.auditLogMu.RLock()
-	entries := append([]models.AuditEntry{}, s.auditLog...)
-	s.auditLogMu.RUnlock()
-	byMonth := make(map[string][]models.AuditEntry)
-	for _, entry := range entries {
-		month := entry.Timestamp.Format("2006-01")
-		byMonth[month] = append(byMonth[month], entry)
-	}
-	for month, monthEntries := range byMonth {
-		path := filepath.Join(s.persistence.DataDir, "audit", fmt.Sprintf("audit_%s.json", month))
-		s.writeJSON(path, monthEntries)
+	return &persistence.DataSnapshot{
+		Version: persistence.SnapshotVersion, SavedAt: time.Now().UTC(), Users: users, UsersByEmail: usersByEmail,
+		KYCRecords: kycRecords, KYCDocuments: kycDocuments, Wallets: wallets, Transactions: transactions, TxByWallet: txByWallet, Ledger: ledger,
+		Orders: orders, OrdersByUser: ordersByUser, Positions: positions, PositionsByUser: positionsByUser,
+		AuditLog: auditLog, Alerts: alerts, Halts: halts, SettledMarkets: settledMarkets, Settlements: settlements,
+		PositionLimits: limits, OrderRates: orderRates,
 	}
-	return nil
 }
 
 func (s *Store) Load() error {
-	if !s.persistence.Enabled {
+	if !s.persistence.Enabled || s.persister == nil {
 		return nil
 	}
-	snapshotPath := filepath.Join(s.persistence.DataDir, "snapshots", "latest.json")
-	file, err := os.Open(snapshotPath)
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	data, err := s.persister.LoadLatestSnapshot()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
 		return err
 	}
-	defer file.Close()
-	var data PersistentData
-	if err := json.NewDecoder(file).Decode(&data); err != nil {
-		return err
+	if data == nil {
+		return nil
 	}
-	s.restoreData(&data)
+	s.restoreData(data)
+	s.auditPersisted = len(data.AuditLog)
 	return nil
 }
 
-func (s *Store) restoreData(data *PersistentData) {
+func (s *Store) restoreData(data *persistence.DataSnapshot) {
 	s.usersMu.Lock()
 	s.users = data.Users
 	s.usersByEmail = data.UsersByEmail
@@ -317,6 +488,7 @@ func (s *Store) restoreData(data *PersistentData) {
 	if s.kycRecords == nil {
 		s.kycRecords = make(map[string]*models.KYCRecord)
 	}
+	s.openKYCDocuments(data.KYCDocuments)
 	s.kycRecordsMu.Unlock()
 
 	s.walletsMu.Lock()
@@ -335,8 +507,22 @@ func (s *Store) restoreData(data *PersistentData) {
 	if s.txByWallet == nil {
 		s.txByWallet = make(map[string][]string)
 	}
+	s.depositRefs = make(map[string]string)
+	for _, tx := range s.transactions {
+		if tx.Type == models.TxTypeDeposit && tx.Reference != "" {
+			s.depositRefs[depositRefKey(tx.Method, tx.Reference)] = tx.ID
+		}
+	}
 	s.transactionsMu.Unlock()
 
+	s.ledgerMu.Lock()
+	s.ledger = data.Ledger
+	s.ledgerMu.Unlock()
+	if data.Ledger == nil {
+		s.openingBalances()
+	}
+	s.linkJournal()
+
 	s.ordersMu.Lock()
 	s.orders = data.Orders
 	s.ordersByUser = data.OrdersByUser
@@ -357,6 +543,10 @@ func (s *Store) restoreData(data *PersistentData) {
 	if s.positionsByUser == nil {
 		s.positionsByUser = make(map[string][]string)
 	}
+	s.settledMarkets = data.SettledMarkets
+	if s.settledMarkets == nil {
+		s.settledMarkets = make(map[string]time.Time)
+	}
 	s.positionsMu.Unlock()
 
 	s.auditLogMu.Lock()
@@ -366,6 +556,13 @@ func (s *Store) restoreData(data *PersistentData) {
 	}
 	s.auditLogMu.Unlock()
 
+	s.settlementsMu.Lock()
+	s.settlements = data.Settlements
+	if s.settlements == nil {
+		s.settlements = make([]models.Settlement, 0)
+	}
+	s.settlementsMu.Unlock()
+
 	s.alertsMu.Lock()
 	s.alerts = data.Alerts
 	if s.alerts == nil {
@@ -378,35 +575,77 @@ func (s *Store) restoreData(data *PersistentData) {
 	if s.halts == nil {
 		s.halts = make(map[string]*models.EmergencyHalt)
 	}
+	if halt, ok := s.halts[globalHaltKey]; ok {
+		halt.Global = true // snapshots written before the flag existed
+	}
 	s.haltsMu.Unlock()
 
-	s.idCounterMu.Lock()
-	s.idCounter = data.IDCounter
-	s.idCounterMu.Unlock()
+	// Snapshots written before limits were configurable keep the defaults
+	if len(data.PositionLimits) > 0 {
+		s.limitsMu.Lock()
+		s.limits = data.PositionLimits
+		s.limitsMu.Unlock()
+	}
+
+	s.loadedRates = data.OrderRates
+	if s.rateLimiter != nil {
+		s.rateLimiter.RestoreOrderRates(s.loadedRates)
+		s.loadedRates = nil
+	}
 }
 
-func (s *Store) writeJSON(path string, data interface{}) error {
-	tempPath := path + ".tmp"
-	file, err := os.Create(tempPath)
-	if err != nil {
-		return err
+// sealKYCDocuments seals each record's document number for a snapshot.
+// Numbers loaded without a usable key are written back as they were read,
+// so a restart without the key does not lose them. Caller holds
+// kycRecordsMu.
+func (s *Store) sealKYCDocuments() map[string]string {
+	sealed := make(map[string]string, len(s.sealedKYC))
+	for id, doc := range s.sealedKYC {
+		sealed[id] = doc
+	}
+	cipher := s.persistence.FieldCipher
+	if cipher == nil {
+		return sealed
+	}
+	for _, record := range s.kycRecords {
+		if record.DocumentNumber == "" {
+			continue
+		}
+		doc, err := cipher.Seal(record.DocumentNumber, record.ID)
+		if err != nil {
+			log.Printf("Failed to seal document number for %s: %v", record.ID, err)
+			continue
+		}
+		sealed[record.ID] = doc
 	}
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
-		file.Close()
-		os.Remove(tempPath)
-		return err
+	return sealed
+}
+
+// openKYCDocuments restores document numbers from a snapshot. Caller holds
+// kycRecordsMu.
+func (s *Store) openKYCDocuments(sealed map[string]string) {
+	s.sealedKYC = make(map[string]string)
+	cipher := s.persistence.FieldCipher
+	for _, record := range s.kycRecords {
+		doc, ok := sealed[record.ID]
+		if !ok {
+			continue
+		}
+		if cipher != nil {
+			if plain, err := cipher.Open(doc, record.ID); err == nil {
+				record.DocumentNumber = plain
+				continue
+			}
+			log.Printf("Cannot decrypt document number for %s; keeping it sealed", record.ID)
+		}
+		s.sealedKYC[record.ID] = doc
 	}
-	file.Close()
-	return os.Rename(tempPath, path)
 }
 
+// generateID returns prefix_ULID. IDs sort by creation time and stay
+// unique across goroutines.
 func (s *Store) generateID(prefix string) string {
-	s.idCounterMu.Lock()
-	defer s.idCounterMu.Unlock()
-	s.idCounter++
-	return fmt.Sprintf("%s_%d_%d", prefix, time.Now().UnixNano(), s.idCounter)
+	return s.ids.New(prefix)
 }
 
 // =============================================================================
@@ -419,12 +658,12 @@ func (s *Store) LogAudit(userID string, action models.AuditAction, entityType, e
 	var oldJSON, newJSON string
 	if oldVal != nil {
 		if b, err := json.Marshal(oldVal); err == nil {
-			oldJSON = string(b)
+			oldJSON = redact.AuditValue(string(b))
 		}
 	}
 	if newVal != nil {
 		if b, err := json.Marshal(newVal); err == nil {
-			newJSON = string(b)
+			newJSON = redact.AuditValue(string(b))
 		}
 	}
 	entry := models.AuditEntry{
@@ -435,6 +674,32 @@ func (s *Store) LogAudit(userID string, action models.AuditAction, entityType, e
 	s.auditLog = append(s.auditLog, entry)
 }
 
+// SetEventBus sets the bus domain events are published to. Call before the
+// store is in use.
+func (s *Store) SetEventBus(bus *events.Bus) {
+	s.events = bus
+}
+
+// AuditEvents records each domain event from ch in the audit trail until ch
+// is closed, next to the entries written when the change itself was made.
+// Run it in its own goroutine.
+// Core Principle 18: The trail shows what was announced to subscribers.
+func (s *Store) AuditEvents(ch <-chan events.Event) {
+	for e := range ch {
+		userID := e.UserID
+		if userID == "" {
+			userID = "system"
+		}
+		s.LogAudit(userID, models.AuditActionEvent, string(e.Type), e.EntityID, nil, e.Data, "", "",
+			fmt.Sprintf("Domain event published: %s", e.Type))
+	}
+}
+
+// publish sends a domain event. data must be a copy, not shared state.
+func (s *Store) publish(t events.Type, entityID, userID string, data interface{}) {
+	s.events.Publish(events.Event{Type: t, EntityID: entityID, UserID: userID, Data: data})
+}
+
 func (s *Store) GetAuditLog(userID string, since time.Time, limit int) []models.AuditEntry {
 	s.auditLogMu.RLock()
 	defer s.auditLogMu.RUnlock()
@@ -467,11 +732,12 @@ func (s *Store) CreateUser(email, passwordHash, firstName, lastName, stateCode s
 		return nil, ErrUserExists
 	}
 	now := time.Now().UTC()
+	limits := s.TierLimits(models.DefaultTier)
 	user := &models.User{
 		ID: s.generateID("user"), Email: email, PasswordHash: passwordHash, FirstName: firstName,
 		LastName: lastName, Status: models.UserStatusKYCPending, IsUSResident: isUSResident,
 		StateCode: stateCode, DateOfBirth: dob, CreatedAt: now, UpdatedAt: now,
-		PositionLimitUSD: 25000.00, LastLoginIP: ip,
+		PositionLimitUSD: limits.MaxPositionUSD, MaxOpenOrders: limits.MaxOpenOrders, LimitTier: limits.Tier, LastLoginIP: ip,
 	}
 	s.users[user.ID] = user
 	s.usersByEmail[email] = user.ID
@@ -509,24 +775,229 @@ func (s *Store) GetAllUsers() []*models.User {
 	return users
 }
 
+// UserFilter selects accounts for operator listings. Empty fields match all.
+type UserFilter struct {
+	Status    models.UserStatus
+	StateCode string
+	Query     string // Case-insensitive substring of email or name
+}
+
+// ListUsers returns summaries of the users matching filter, newest first,
+// skipping offset and returning at most limit, along with the total number
+// of matches.
+// CP 17: Operators can enumerate and review accounts.
+func (s *Store) ListUsers(filter UserFilter, offset, limit int) ([]models.UserSummary, int) {
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	var matched []models.User
+	s.usersMu.RLock()
+	for _, u := range s.users {
+		if filter.Status != "" && u.Status != filter.Status {
+			continue
+		}
+		if filter.StateCode != "" && !strings.EqualFold(u.StateCode, filter.StateCode) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(u.Email+" "+u.FirstName+" "+u.LastName), query) {
+			continue
+		}
+		matched = append(matched, *u)
+	}
+	s.usersMu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	total := len(matched)
+	if offset >= total {
+		return []models.UserSummary{}, total
+	}
+	matched = matched[offset:]
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+
+	openAlerts := make(map[string]int)
+	s.alertsMu.RLock()
+	for _, a := range s.alerts {
+		if models.AlertActive(a.Status) {
+			openAlerts[a.UserID]++
+		}
+	}
+	s.alertsMu.RUnlock()
+
+	summaries := make([]models.UserSummary, 0, len(matched))
+	for _, u := range matched {
+		positions, _ := s.GetPositions(u.ID)
+		summaries = append(summaries, models.UserSummary{
+			ID: u.ID, Email: u.Email, FirstName: u.FirstName, LastName: u.LastName,
+			Status: u.Status, StateCode: u.StateCode, PositionLimitUSD: u.PositionLimitUSD,
+			ExposureUSD: s.GetUserExposure(u.ID), OpenPositions: len(positions),
+			OpenAlerts: openAlerts[u.ID], CreatedAt: u.CreatedAt, LastLoginAt: u.LastLoginAt,
+		})
+	}
+	return summaries, total
+}
+
+// AdminStats summarizes accounts, positions, 24h fill volume, open alerts
+// (open or investigating) and halts as of now. The system is halted under
+// a global halt and in warning while high or critical alerts are open.
+// CP 4, CP 9: Operator dashboard figures from the live store.
+func (s *Store) AdminStats(now time.Time) models.AdminStats {
+	stats := models.AdminStats{SystemStatus: models.SystemStatusOperational, LastUpdated: now}
+
+	s.usersMu.RLock()
+	for _, u := range s.users {
+		if u.Status == models.UserStatusVerified {
+			stats.ActiveUsers++
+		}
+	}
+	s.usersMu.RUnlock()
+
+	stats.OpenPositions = len(s.GetAllPositions())
+
+	cutoff := now.Add(-24 * time.Hour)
+	s.ordersMu.RLock()
+	for _, order := range s.orders {
+		if order.FilledQuantity > 0 && order.UpdatedAt.After(cutoff) {
+			stats.TotalVolume24h += int64(order.FilledQuantity)
+		}
+	}
+	s.ordersMu.RUnlock()
+
+	urgent := 0
+	s.alertsMu.RLock()
+	for _, a := range s.alerts {
+		if !models.AlertActive(a.Status) {
+			continue
+		}
+		stats.OpenAlerts++
+		if a.Severity == models.AlertSeverityCritical {
+			stats.CriticalAlerts++
+		}
+		if models.AlertSeverityRank(a.Severity) >= models.AlertSeverityRank(models.AlertSeverityHigh) {
+			urgent++
+		}
+	}
+	s.alertsMu.RUnlock()
+
+	for _, halt := range s.GetActiveHalts() {
+		if halt.Global {
+			stats.SystemStatus = models.SystemStatusHalted
+		} else {
+			stats.HaltedMarkets++
+		}
+	}
+	if stats.SystemStatus != models.SystemStatusHalted && urgent > 0 {
+		stats.SystemStatus = models.SystemStatusWarning
+	}
+	return stats
+}
+
+// UpdateUserStatus moves a user to status, audited as the user's own
+// change. A user becomes verified only with an approved KYC record.
 func (s *Store) UpdateUserStatus(userID string, status models.UserStatus, ip string) error {
+	if status == models.UserStatusVerified && !s.kycApproved(userID) {
+		return ErrKYCNotApproved
+	}
+	_, err := s.setUserStatus(userID, func(*models.User) (models.UserStatus, error) { return status, nil }, userID, "", ip)
+	return err
+}
+
+// RestrictUser suspends or bans a user on behalf of adminID, auditing the
+// reason. A banned user can only be reinstated, not suspended.
+// CP 17: Removing unfit participants from the market.
+func (s *Store) RestrictUser(userID string, status models.UserStatus, adminID, reason, ip string) error {
+	if status != models.UserStatusSuspended && status != models.UserStatusBanned {
+		return ErrInvalidStatusChange
+	}
+	_, err := s.setUserStatus(userID, func(user *models.User) (models.UserStatus, error) {
+		if user.Status == models.UserStatusBanned && status == models.UserStatusSuspended {
+			return "", ErrInvalidStatusChange
+		}
+		return status, nil
+	}, adminID, reason, ip)
+	return err
+}
+
+// ReinstateUser lifts a suspension or ban on behalf of adminID, returning
+// the user to the status held before it, and returns that status. Users
+// restricted before the previous status was recorded go back to verified
+// with an approved KYC record and to pending otherwise.
+func (s *Store) ReinstateUser(userID, adminID, reason, ip string) (models.UserStatus, error) {
+	approved := s.kycApproved(userID)
+	return s.setUserStatus(userID, func(user *models.User) (models.UserStatus, error) {
+		if !restricted(user.Status) {
+			return "", ErrInvalidStatusChange
+		}
+		previous := user.PreviousStatus
+		if previous == "" {
+			previous = models.UserStatusPending
+			if approved {
+				previous = models.UserStatusVerified
+			}
+		}
+		if previous == models.UserStatusVerified && !approved {
+			return "", ErrKYCNotApproved
+		}
+		return previous, nil
+	}, adminID, reason, ip)
+}
+
+// restricted reports whether a status bars the user from trading.
+func restricted(status models.UserStatus) bool {
+	return status == models.UserStatusSuspended || status == models.UserStatusBanned
+}
+
+// kycApproved reports whether the user's KYC record is approved.
+func (s *Store) kycApproved(userID string) bool {
+	s.kycRecordsMu.RLock()
+	defer s.kycRecordsMu.RUnlock()
+	record, exists := s.kycRecords[userID]
+	return exists && record.Status == models.KYCStatusApproved
+}
+
+// setUserStatus moves a user to the status next picks for it and audits
+// the change as actor with reason. Entering a suspension or ban remembers
+// the status to reinstate; leaving one clears it. Returns the new status.
+func (s *Store) setUserStatus(userID string, next func(*models.User) (models.UserStatus, error), actor, reason, ip string) (models.UserStatus, error) {
 	s.usersMu.Lock()
 	defer s.usersMu.Unlock()
 	user, exists := s.users[userID]
 	if !exists {
-		return ErrUserNotFound
+		return "", ErrUserNotFound
+	}
+	status, err := next(user)
+	if err != nil {
+		return "", err
 	}
 	oldStatus := user.Status
+	now := time.Now().UTC()
+	switch {
+	case restricted(status) && !restricted(oldStatus):
+		user.PreviousStatus = oldStatus
+	case !restricted(status):
+		user.PreviousStatus = ""
+	}
 	user.Status = status
-	user.UpdatedAt = time.Now().UTC()
-	if status == models.UserStatusVerified {
-		now := time.Now().UTC()
+	user.UpdatedAt = now
+	if status == models.UserStatusVerified && oldStatus != models.UserStatusVerified {
 		user.KYCVerifiedAt = &now
 	}
-	s.LogAudit(userID, models.AuditActionUpdate, "user", userID,
-		map[string]interface{}{"status": oldStatus}, map[string]interface{}{"status": status},
-		ip, "", fmt.Sprintf("User status changed from %s to %s", oldStatus, status))
-	return nil
+	newVal := map[string]interface{}{"status": status}
+	desc := fmt.Sprintf("User status changed from %s to %s", oldStatus, status)
+	if actor != userID {
+		desc += " by " + actor
+	}
+	if reason != "" {
+		newVal["reason"] = reason
+		desc += ": " + reason
+	}
+	s.LogAudit(actor, models.AuditActionUpdate, "user", userID,
+		map[string]interface{}{"status": oldStatus}, newVal, ip, "", desc)
+	return status, nil
 }
 
 func (s *Store) RecordLogin(userID, ip string) error {
@@ -543,20 +1014,67 @@ func (s *Store) RecordLogin(userID, ip string) error {
 	return nil
 }
 
+// RecordTokenRefresh audits a token reissued with the user's current
+// status in place of tokenStatus, the status in the old token's claims.
+func (s *Store) RecordTokenRefresh(userID, tokenStatus, ip string) error {
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	desc := fmt.Sprintf("Token reissued: status %s -> %s", tokenStatus, user.Status)
+	s.LogAudit(userID, models.AuditActionRefresh, "user", userID,
+		map[string]string{"status": tokenStatus}, map[string]string{"status": string(user.Status)}, ip, "", desc)
+	return nil
+}
+
 // =============================================================================
 // KYC OPERATIONS - CP 17: Fitness Standards
 // =============================================================================
 
+// StartKYCRecord creates the user's KYC record in the not_started state.
+// Called at signup so every user has a record to progress.
+func (s *Store) StartKYCRecord(userID, ip string) (*models.KYCRecord, error) {
+	s.kycRecordsMu.Lock()
+	defer s.kycRecordsMu.Unlock()
+	if record, exists := s.kycRecords[userID]; exists {
+		return record, nil
+	}
+	record := &models.KYCRecord{ID: s.generateID("kyc"), UserID: userID, Status: models.KYCStatusNotStarted}
+	s.kycRecords[userID] = record
+	s.LogAudit(userID, models.AuditActionKYC, "kyc", record.ID, nil, record, ip, "", "KYC record created")
+	return record, nil
+}
+
+// CreateKYCRecord submits KYC documents. A not_started or rejected record
+// moves to pending; users without a record (created before signup started
+// one) get a new pending record. A pending or approved record cannot be
+// resubmitted.
 func (s *Store) CreateKYCRecord(userID, docType, docNumber, ip string) (*models.KYCRecord, error) {
 	s.kycRecordsMu.Lock()
 	defer s.kycRecordsMu.Unlock()
 	now := time.Now().UTC()
-	record := &models.KYCRecord{
-		ID: s.generateID("kyc"), UserID: userID, Status: models.KYCStatusPending,
-		DocumentType: docType, DocumentNumber: docNumber, SubmittedAt: now,
+	record, exists := s.kycRecords[userID]
+	if !exists {
+		record = &models.KYCRecord{ID: s.generateID("kyc"), UserID: userID}
+		s.kycRecords[userID] = record
 	}
-	s.kycRecords[userID] = record
-	s.LogAudit(userID, models.AuditActionKYC, "kyc", record.ID, nil, record, ip, "", "KYC verification submitted")
+	var old interface{}
+	if exists {
+		if record.Status == models.KYCStatusPending || record.Status == models.KYCStatusApproved {
+			return nil, ErrKYCAlreadySubmitted
+		}
+		old = *record
+	}
+	record.Status = models.KYCStatusPending
+	record.DocumentType = docType
+	record.DocumentNumber = docNumber
+	delete(s.sealedKYC, record.ID)
+	record.SubmittedAt = &now
+	record.ReviewedAt = nil
+	record.RejectionReason = ""
+	s.LogAudit(userID, models.AuditActionKYC, "kyc", record.ID, old, record, ip, "", "KYC verification submitted")
 	return record, nil
 }
 
@@ -573,11 +1091,15 @@ func (s *Store) MockKYCApproval(userID string, approved bool, reason string) err
 		record.Status = models.KYCStatusApproved
 		expiry := now.AddDate(2, 0, 0)
 		record.ExpiresAt = &expiry
-		s.UpdateUserStatus(userID, models.UserStatusVerified, "system")
+		// The record is approved above; UpdateUserStatus would re-take kycRecordsMu
+		s.setUserStatus(userID, func(*models.User) (models.UserStatus, error) {
+			return models.UserStatusVerified, nil
+		}, userID, "", "system")
 	} else {
 		record.Status = models.KYCStatusRejected
 		record.RejectionReason = reason
 	}
+	s.publish(events.TypeKYC, record.ID, userID, *record)
 	return nil
 }
 
@@ -618,85 +1140,854 @@ func (s *Store) GetWallet(userID string) (*models.Wallet, error) {
 	return wallet, nil
 }
 
-func (s *Store) Deposit(userID string, amountUSD float64, reference, ip string) (*models.Transaction, error) {
-	s.walletsMu.Lock()
-	defer s.walletsMu.Unlock()
-	wallet, exists := s.wallets[userID]
-	if !exists {
-		return nil, ErrWalletNotFound
+// DefaultDepositMethods are the deposit methods accepted unless
+// SetDepositMethods replaces them. None has a clearing delay.
+func DefaultDepositMethods() []models.DepositMethodConfig {
+	return []models.DepositMethodConfig{
+		{Method: models.DepositMethodACH, MinUSD: models.Dollars(1), MaxUSD: models.Dollars(10000)},
+		{Method: models.DepositMethodWire, MinUSD: models.Dollars(100), MaxUSD: models.Dollars(100000)},
+		{Method: models.DepositMethodCard, MinUSD: models.Dollars(10), MaxUSD: models.Dollars(2500)},
 	}
-	balanceBefore := wallet.AvailableUSD
-	wallet.AvailableUSD += amountUSD
-	wallet.TotalDeposited += amountUSD
-	wallet.UpdatedAt = time.Now().UTC()
+}
 
-	s.transactionsMu.Lock()
-	defer s.transactionsMu.Unlock()
-	now := time.Now().UTC()
-	tx := &models.Transaction{
-		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: userID, Type: models.TxTypeDeposit,
-		Status: models.TxStatusCompleted, AmountUSD: amountUSD, BalanceBefore: balanceBefore,
-		BalanceAfter: wallet.AvailableUSD, Reference: reference,
-		Description: fmt.Sprintf("ACH Deposit: $%.2f", amountUSD), CreatedAt: now, CompletedAt: &now, IPAddress: ip,
+// depositReferences are the external reference formats of each method.
+var depositReferences = map[models.DepositMethod]struct {
+	pattern *regexp.Regexp
+	format  string
+}{
+	models.DepositMethodACH:  {regexp.MustCompile(`^[0-9]{15}$`), "a 15-digit ACH trace number"},
+	models.DepositMethodWire: {regexp.MustCompile(`^[0-9]{8}[A-Z0-9]{8}[0-9]{6}$`), "a 22-character Fedwire IMAD"},
+	models.DepositMethodCard: {regexp.MustCompile(`^[A-Z0-9]{6}$`), "a 6-character card authorization code"},
+}
+
+// depositMethodLabels name each method in transaction descriptions.
+var depositMethodLabels = map[models.DepositMethod]string{
+	models.DepositMethodACH:  "ACH",
+	models.DepositMethodWire: "Wire",
+	models.DepositMethodCard: "Card",
+}
+
+// depositRefKey indexes a deposit by its method and external reference.
+func depositRefKey(method models.DepositMethod, reference string) string {
+	return string(method) + ":" + reference
+}
+
+func depositMethodMap(methods []models.DepositMethodConfig) map[models.DepositMethod]models.DepositMethodConfig {
+	m := make(map[models.DepositMethod]models.DepositMethodConfig, len(methods))
+	for _, method := range methods {
+		m[method.Method] = method
 	}
-	s.transactions[tx.ID] = tx
-	s.txByWallet[wallet.ID] = append(s.txByWallet[wallet.ID], tx.ID)
-	s.LogAudit(userID, models.AuditActionDeposit, "transaction", tx.ID, nil, tx, ip, "", fmt.Sprintf("Deposited $%.2f", amountUSD))
-	return tx, nil
+	return m
 }
 
-func (s *Store) LockFunds(userID string, amountUSD float64, orderID string) error {
+// SetDepositMethods replaces the accepted deposit methods. Methods without
+// a known reference format are ignored.
+func (s *Store) SetDepositMethods(methods []models.DepositMethodConfig) {
+	accepted := make([]models.DepositMethodConfig, 0, len(methods))
+	for _, method := range methods {
+		if _, known := depositReferences[method.Method]; known {
+			accepted = append(accepted, method)
+		}
+	}
 	s.walletsMu.Lock()
 	defer s.walletsMu.Unlock()
-	wallet, exists := s.wallets[userID]
-	if !exists {
-		return ErrWalletNotFound
-	}
-	if wallet.AvailableUSD < amountUSD {
-		return ErrInsufficientFunds
+	s.depositMethods = depositMethodMap(accepted)
+}
+
+// DepositMethods returns the accepted deposit methods, sorted by name.
+func (s *Store) DepositMethods() []models.DepositMethodConfig {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	methods := make([]models.DepositMethodConfig, 0, len(s.depositMethods))
+	for _, method := range s.depositMethods {
+		methods = append(methods, method)
 	}
-	wallet.AvailableUSD -= amountUSD
-	wallet.LockedUSD += amountUSD
-	wallet.UpdatedAt = time.Now().UTC()
-	return nil
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Method < methods[j].Method })
+	return methods
 }
 
-func (s *Store) UnlockFunds(userID string, amountUSD float64, orderID string) error {
+// SetDepositClearingDelay sets how long ACH deposits stay pending before
+// the sweeper credits them. Zero (the default) credits deposits instantly.
+// Only the delay changes: ACH stays disabled if SetDepositMethods left it
+// out.
+func (s *Store) SetDepositClearingDelay(delay time.Duration) {
 	s.walletsMu.Lock()
 	defer s.walletsMu.Unlock()
-	wallet, exists := s.wallets[userID]
-	if !exists {
-		return ErrWalletNotFound
+	ach, accepted := s.depositMethods[models.DepositMethodACH]
+	if !accepted {
+		return
 	}
-	wallet.LockedUSD -= amountUSD
-	wallet.AvailableUSD += amountUSD
-	wallet.UpdatedAt = time.Now().UTC()
-	return nil
+	ach.ClearingDelay = delay
+	s.depositMethods[models.DepositMethodACH] = ach
+}
+
+// Deposit credits an ACH deposit without checking the method's limits or
+// reference format, for seeding and internal credits. Customer deposits go
+// through DepositVia.
+func (s *Store) Deposit(userID string, amountUSD models.Money, reference, ip string) (*models.Transaction, error) {
+	return s.deposit(userID, models.DepositMethodACH, amountUSD, reference, ip, false)
+}
+
+// DepositVia credits a customer deposit made by method. The amount must be
+// within the method's limits and reference in its format, and no earlier
+// deposit by the method may have used the reference.
+func (s *Store) DepositVia(userID string, method models.DepositMethod, amountUSD models.Money, reference, ip string) (*models.Transaction, error) {
+	return s.deposit(userID, method, amountUSD, reference, ip, true)
 }
 
-func (s *Store) SettleFunds(userID string, lockedAmount, settlementAmount float64, orderID, ip string) error {
+// deposit credits funds to a wallet. With the method's clearing delay the
+// amount sits in PendingUSD with a pending transaction until
+// ReleasePendingTransactions moves it to AvailableUSD; pending funds cannot
+// be traded.
+// CP 13: Funds are only usable once they have cleared.
+func (s *Store) deposit(userID string, method models.DepositMethod, amountUSD models.Money, reference, ip string, check bool) (*models.Transaction, error) {
 	s.walletsMu.Lock()
 	defer s.walletsMu.Unlock()
+	config, accepted := s.depositMethods[method]
+	if check {
+		switch {
+		case !accepted:
+			return nil, fmt.Errorf("%w: %q", ErrDepositMethod, method)
+		case amountUSD <= 0:
+			return nil, ErrInvalidAmount
+		case amountUSD < config.MinUSD:
+			return nil, fmt.Errorf("%w (%s minimum %s)", ErrDepositBelowMinimum, method, config.MinUSD)
+		case config.MaxUSD > 0 && amountUSD > config.MaxUSD:
+			return nil, fmt.Errorf("%w (%s maximum %s)", ErrDepositAboveMaximum, method, config.MaxUSD)
+		case !depositReferences[method].pattern.MatchString(reference):
+			return nil, fmt.Errorf("%w: %s deposits need %s", ErrInvalidReference, method, depositReferences[method].format)
+		}
+		// Deposits hold walletsMu, so no other deposit can claim the
+		// reference between this check and recording the transaction
+		s.transactionsMu.RLock()
+		_, used := s.depositRefs[depositRefKey(method, reference)]
+		s.transactionsMu.RUnlock()
+		if used {
+			return nil, fmt.Errorf("%w: %s %s", ErrDuplicateReference, method, reference)
+		}
+	}
 	wallet, exists := s.wallets[userID]
 	if !exists {
-		return ErrWalletNotFound
+		return nil, ErrWalletNotFound
 	}
-	wallet.LockedUSD -= lockedAmount
-	wallet.AvailableUSD += settlementAmount
-	wallet.UpdatedAt = time.Now().UTC()
-
-	s.transactionsMu.Lock()
-	defer s.transactionsMu.Unlock()
 	now := time.Now().UTC()
-	pnl := settlementAmount - lockedAmount
+	balanceBefore := wallet.AvailableUSD
 	tx := &models.Transaction{
-		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: userID, Type: models.TxTypeSettlement,
-		Status: models.TxStatusCompleted, AmountUSD: settlementAmount, BalanceAfter: wallet.AvailableUSD,
-		Reference: orderID, Description: fmt.Sprintf("Settlement: P&L $%.2f", pnl), CreatedAt: now, CompletedAt: &now,
+		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: userID, Type: models.TxTypeDeposit,
+		AmountUSD: amountUSD, BalanceBefore: balanceBefore, Reference: reference, Method: method,
+		Description: fmt.Sprintf("%s Deposit: %s", depositMethodLabels[method], amountUSD), CreatedAt: now, IPAddress: ip,
+	}
+	if config.ClearingDelay > 0 {
+		availableAt := now.Add(config.ClearingDelay)
+		tx.Status = models.TxStatusPending
+		tx.AvailableAt = &availableAt
+		s.post(wallet, tx, tx.Description, move(models.AccountExternal, models.AccountCustomerPending, amountUSD))
+	} else {
+		tx.Status = models.TxStatusCompleted
+		tx.CompletedAt = &now
+		s.post(wallet, tx, tx.Description, move(models.AccountExternal, models.AccountCustomerAvailable, amountUSD))
+		wallet.TotalDeposited += amountUSD
 	}
+	tx.BalanceAfter = wallet.AvailableUSD
+	wallet.UpdatedAt = now
+
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
 	s.transactions[tx.ID] = tx
 	s.txByWallet[wallet.ID] = append(s.txByWallet[wallet.ID], tx.ID)
-	return nil
+	if reference != "" {
+		s.depositRefs[depositRefKey(method, reference)] = tx.ID
+	}
+	s.LogAudit(userID, models.AuditActionDeposit, "transaction", tx.ID, nil, tx, ip, "",
+		fmt.Sprintf("Deposit of %s %s", amountUSD, tx.Status))
+	return tx, nil
+}
+
+// SetWithdrawalHold sets how long withdrawals stay pending before the
+// sweeper releases them. Zero completes withdrawals immediately.
+func (s *Store) SetWithdrawalHold(hold time.Duration) {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	s.withdrawalHold = hold
+}
+
+// Withdraw moves funds out of the available balance. During the hold period
+// the amount sits in PendingUSD with a pending transaction;
+// ReleasePendingTransactions completes it once AvailableAt has passed.
+// CP 13: Customer funds leave the segregated account only after the hold.
+func (s *Store) Withdraw(userID string, amountUSD models.Money, reference, ip string) (*models.Transaction, error) {
+	if amountUSD <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+	if wallet.AvailableUSD < amountUSD {
+		return nil, ErrInsufficientFunds
+	}
+	now := time.Now().UTC()
+	wallet.UpdatedAt = now
+
+	tx := &models.Transaction{
+		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: userID, Type: models.TxTypeWithdrawal,
+		AmountUSD: amountUSD, BalanceBefore: wallet.AvailableUSD, Reference: reference,
+		Description: fmt.Sprintf("ACH Withdrawal: %s", amountUSD), CreatedAt: now, IPAddress: ip,
+	}
+	if s.withdrawalHold > 0 {
+		availableAt := now.Add(s.withdrawalHold)
+		tx.Status = models.TxStatusPending
+		tx.AvailableAt = &availableAt
+		s.post(wallet, tx, tx.Description, move(models.AccountCustomerAvailable, models.AccountCustomerPending, amountUSD))
+	} else {
+		tx.Status = models.TxStatusCompleted
+		tx.CompletedAt = &now
+		s.post(wallet, tx, tx.Description, move(models.AccountCustomerAvailable, models.AccountExternal, amountUSD))
+		wallet.TotalWithdrawn += amountUSD
+	}
+	tx.BalanceAfter = wallet.AvailableUSD
+
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
+	s.transactions[tx.ID] = tx
+	s.txByWallet[wallet.ID] = append(s.txByWallet[wallet.ID], tx.ID)
+	s.LogAudit(userID, models.AuditActionWithdraw, "transaction", tx.ID, nil, tx, ip, "",
+		fmt.Sprintf("Withdrawal of %s %s", amountUSD, tx.Status))
+	return tx, nil
+}
+
+// ReleasePendingTransactions completes pending deposits and withdrawals whose
+// AvailableAt is at or before now and returns how many were released.
+// Deposits move from PendingUSD to AvailableUSD; withdrawals leave
+// PendingUSD.
+func (s *Store) ReleasePendingTransactions(now time.Time) int {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
+
+	released := 0
+	for _, tx := range s.transactions {
+		if tx.Status != models.TxStatusPending || tx.AvailableAt == nil || tx.AvailableAt.After(now) {
+			continue
+		}
+		if tx.Type != models.TxTypeDeposit && tx.Type != models.TxTypeWithdrawal {
+			continue
+		}
+		wallet, exists := s.wallets[tx.UserID]
+		if !exists {
+			continue
+		}
+		old := *tx
+		completedAt := now.UTC()
+		wallet.UpdatedAt = completedAt
+		tx.Status = models.TxStatusCompleted
+		tx.CompletedAt = &completedAt
+		if tx.Type == models.TxTypeDeposit {
+			tx.BalanceBefore = wallet.AvailableUSD
+			s.post(wallet, tx, "Deposit cleared", move(models.AccountCustomerPending, models.AccountCustomerAvailable, tx.AmountUSD))
+			wallet.TotalDeposited += tx.AmountUSD
+			tx.BalanceAfter = wallet.AvailableUSD
+			s.LogAudit(tx.UserID, models.AuditActionDeposit, "transaction", tx.ID, old, *tx, "", "",
+				fmt.Sprintf("Deposit of %s cleared", tx.AmountUSD))
+		} else {
+			s.post(wallet, tx, "Withdrawal released", move(models.AccountCustomerPending, models.AccountExternal, tx.AmountUSD))
+			wallet.TotalWithdrawn += tx.AmountUSD
+			s.LogAudit(tx.UserID, models.AuditActionWithdraw, "transaction", tx.ID, old, *tx, "", "",
+				fmt.Sprintf("Withdrawal of %s released after hold", tx.AmountUSD))
+		}
+		released++
+	}
+	return released
+}
+
+// GetPendingTransactions returns the user's pending transactions, oldest
+// first.
+func (s *Store) GetPendingTransactions(userID string) ([]models.Transaction, error) {
+	wallet, err := s.GetWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+	s.transactionsMu.RLock()
+	defer s.transactionsMu.RUnlock()
+	result := make([]models.Transaction, 0)
+	for _, id := range s.txByWallet[wallet.ID] {
+		if tx, exists := s.transactions[id]; exists && tx.Status == models.TxStatusPending {
+			result = append(result, *tx)
+		}
+	}
+	return result, nil
+}
+
+// ReverseTransaction undoes a completed deposit, withdrawal or fee with a
+// compensating refund transaction and marks the original reversed. A
+// reversed deposit debits the available balance, so it fails if those funds
+// have already been used. Settlements, refunds and pending transactions
+// cannot be reversed.
+// CP 13, CP 18: Corrections are recorded as new transactions.
+func (s *Store) ReverseTransaction(txID, reason, ip string) (*models.Transaction, error) {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
+
+	original, exists := s.transactions[txID]
+	if !exists {
+		return nil, ErrTransactionNotFound
+	}
+	if original.Status != models.TxStatusCompleted {
+		return nil, ErrNotReversible
+	}
+	wallet, exists := s.wallets[original.UserID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+
+	var reversal ledgerMove
+	switch original.Type {
+	case models.TxTypeDeposit:
+		if wallet.AvailableUSD < original.AmountUSD {
+			return nil, ErrInsufficientFunds
+		}
+		reversal = move(models.AccountCustomerAvailable, models.AccountExternal, original.AmountUSD)
+		wallet.TotalDeposited -= original.AmountUSD
+	case models.TxTypeWithdrawal:
+		reversal = move(models.AccountExternal, models.AccountCustomerAvailable, original.AmountUSD)
+		wallet.TotalWithdrawn -= original.AmountUSD
+	case models.TxTypeFee:
+		reversal = move(models.AccountHouseFees, models.AccountCustomerAvailable, original.AmountUSD)
+	default:
+		return nil, ErrNotReversible
+	}
+	now := time.Now().UTC()
+	wallet.UpdatedAt = now
+
+	old := *original
+	original.Status = models.TxStatusReversed
+	refund := &models.Transaction{
+		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: original.UserID, Type: models.TxTypeRefund,
+		Status: models.TxStatusCompleted, AmountUSD: original.AmountUSD, BalanceBefore: wallet.AvailableUSD,
+		Reference: original.ID, Description: fmt.Sprintf("Reversal of %s %s: %s", original.Type, original.ID, reason),
+		CreatedAt: now, CompletedAt: &now, IPAddress: ip,
+	}
+	s.post(wallet, refund, refund.Description, reversal)
+	refund.BalanceAfter = wallet.AvailableUSD
+	s.transactions[refund.ID] = refund
+	s.txByWallet[wallet.ID] = append(s.txByWallet[wallet.ID], refund.ID)
+
+	s.LogAudit(original.UserID, models.AuditActionUpdate, "transaction", original.ID, old, *original, ip, "",
+		fmt.Sprintf("Transaction reversed: %s", reason))
+	s.LogAudit(original.UserID, models.AuditActionCreate, "transaction", refund.ID, nil, refund, ip, "",
+		fmt.Sprintf("Refund of %s for %s %s", refund.AmountUSD, original.Type, original.ID))
+	created := *refund
+	return &created, nil
+}
+
+// ReconcileWallet recomputes the user's balances from the transaction
+// ledger and compares them with the stored wallet. Every movement of funds
+// into or out of the wallet is a transaction: deposits and withdrawals,
+// settlement and close P&L, fees, and refunds reversing one of those. Locks
+// only move funds between available and locked, so they leave the total
+// unchanged. The balances must also match the customer accounts in the
+// journal, and each transaction must list the journal entries that posted
+// it, which must move its amount.
+// CP 13: Detects drift between customer funds and their records.
+func (s *Store) ReconcileWallet(userID string) (models.ReconResult, error) {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return models.ReconResult{}, ErrWalletNotFound
+	}
+	s.transactionsMu.RLock()
+	defer s.transactionsMu.RUnlock()
+	return s.reconcile(wallet, s.indexLedger()), nil
+}
+
+// ReconcileWallets reconciles every wallet, ordered by user ID.
+func (s *Store) ReconcileWallets() []models.ReconResult {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	s.transactionsMu.RLock()
+	defer s.transactionsMu.RUnlock()
+	index := s.indexLedger()
+	results := make([]models.ReconResult, 0, len(s.wallets))
+	for _, wallet := range s.wallets {
+		results = append(results, s.reconcile(wallet, index))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].UserID < results[j].UserID })
+	return results
+}
+
+// ledgerIndex is the journal entries of each transaction, when each user's
+// opening balance was journaled, and each user's account balances.
+type ledgerIndex struct {
+	byTransaction map[string][]models.JournalEntry
+	opened        map[string]time.Time
+	balances      map[string]map[models.LedgerAccount]models.Money
+}
+
+// indexLedger indexes the ledger for reconcile.
+func (s *Store) indexLedger() ledgerIndex {
+	index := ledgerIndex{
+		byTransaction: make(map[string][]models.JournalEntry),
+		opened:        make(map[string]time.Time),
+		balances:      make(map[string]map[models.LedgerAccount]models.Money),
+	}
+	s.ledgerMu.RLock()
+	defer s.ledgerMu.RUnlock()
+	for _, entry := range s.ledger {
+		balances := index.balances[entry.UserID]
+		if balances == nil {
+			balances = make(map[models.LedgerAccount]models.Money)
+			index.balances[entry.UserID] = balances
+		}
+		for _, posting := range entry.Postings {
+			balances[posting.Account] += posting.AmountUSD
+		}
+		switch {
+		case entry.TransactionID != "":
+			index.byTransaction[entry.TransactionID] = append(index.byTransaction[entry.TransactionID], entry)
+		case !isLockEntry(entry):
+			index.opened[entry.UserID] = entry.CreatedAt
+		}
+	}
+	return index
+}
+
+// checkJournal compares a transaction with the journal entries that posted
+// it: they must be the ones it lists and move delta into the customer's
+// accounts. Transactions from before an opening balance are carried by it.
+func checkJournal(result *models.ReconResult, tx *models.Transaction, delta models.Money, index ledgerIndex) {
+	if tx.CreatedAt.Before(index.opened[tx.UserID]) {
+		return
+	}
+	entries := index.byTransaction[tx.ID]
+	ids := make([]string, 0, len(entries))
+	var moved models.Money
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+		for _, posting := range entry.Postings {
+			switch posting.Account {
+			case models.AccountCustomerAvailable, models.AccountCustomerLocked, models.AccountCustomerPending:
+				moved += posting.AmountUSD
+			}
+		}
+	}
+	if !slices.Equal(ids, tx.JournalIDs) {
+		result.Discrepancies = append(result.Discrepancies,
+			fmt.Sprintf("transaction %s lists journal entries %v, ledger has %v", tx.ID, tx.JournalIDs, ids))
+	}
+	if moved != delta {
+		result.Discrepancies = append(result.Discrepancies,
+			fmt.Sprintf("transaction %s moved %s in the ledger, %s expected", tx.ID, moved, delta))
+	}
+}
+
+// reconcile compares wallet with its ledger. Caller holds walletsMu and
+// transactionsMu.
+func (s *Store) reconcile(wallet *models.Wallet, index ledgerIndex) models.ReconResult {
+	result := models.ReconResult{
+		UserID: wallet.UserID, WalletID: wallet.ID,
+		ActualTotalUSD:     wallet.AvailableUSD + wallet.LockedUSD + wallet.PendingUSD,
+		ActualPendingUSD:   wallet.PendingUSD,
+		ActualDepositedUSD: wallet.TotalDeposited, ActualWithdrawnUSD: wallet.TotalWithdrawn,
+		CheckedAt: time.Now().UTC(),
+	}
+	for _, id := range s.txByWallet[wallet.ID] {
+		tx, exists := s.transactions[id]
+		if !exists || tx.Status == models.TxStatusFailed {
+			continue
+		}
+		pending := tx.Status == models.TxStatusPending
+		var delta models.Money // change in the customer's funds
+		switch tx.Type {
+		case models.TxTypeDeposit:
+			// Pending deposits already count in PendingUSD
+			delta = tx.AmountUSD
+			if pending {
+				result.ExpectedPendingUSD += tx.AmountUSD
+			} else if tx.Status == models.TxStatusCompleted {
+				result.ExpectedDepositedUSD += tx.AmountUSD
+			}
+		case models.TxTypeWithdrawal:
+			// Held withdrawals stay in PendingUSD until released
+			if pending {
+				result.ExpectedPendingUSD += tx.AmountUSD
+			} else {
+				delta = -tx.AmountUSD
+				if tx.Status == models.TxStatusCompleted {
+					result.ExpectedWithdrawnUSD += tx.AmountUSD
+				}
+			}
+		case models.TxTypeSettlement, models.TxTypeTrade:
+			delta = tx.PnLUSD
+		case models.TxTypeFee:
+			delta = -tx.AmountUSD
+		case models.TxTypeRefund:
+			original, exists := s.transactions[tx.Reference]
+			if !exists {
+				result.Discrepancies = append(result.Discrepancies,
+					fmt.Sprintf("refund %s reverses unknown transaction %s", tx.ID, tx.Reference))
+				continue
+			}
+			delta = tx.AmountUSD
+			if original.Type == models.TxTypeDeposit {
+				delta = -tx.AmountUSD
+			}
+		}
+		result.ExpectedTotalUSD += delta
+		checkJournal(&result, tx, delta, index)
+	}
+
+	// The wallet's balances are its customer accounts in the journal
+	ledger := index.balances[wallet.UserID]
+	for _, check := range []struct {
+		name             string
+		actual, expected models.Money
+	}{
+		{"total", result.ActualTotalUSD, result.ExpectedTotalUSD},
+		{"pending", result.ActualPendingUSD, result.ExpectedPendingUSD},
+		{"total deposited", result.ActualDepositedUSD, result.ExpectedDepositedUSD},
+		{"total withdrawn", result.ActualWithdrawnUSD, result.ExpectedWithdrawnUSD},
+		{"available", wallet.AvailableUSD, ledger[models.AccountCustomerAvailable]},
+		{"locked", wallet.LockedUSD, ledger[models.AccountCustomerLocked]},
+		{"pending", wallet.PendingUSD, ledger[models.AccountCustomerPending]},
+	} {
+		if check.actual != check.expected {
+			result.Discrepancies = append(result.Discrepancies,
+				fmt.Sprintf("%s is %s, ledger implies %s", check.name, check.actual, check.expected))
+		}
+	}
+	if wallet.AvailableUSD < 0 || wallet.LockedUSD < 0 {
+		result.Discrepancies = append(result.Discrepancies,
+			fmt.Sprintf("negative balance: available %s, locked %s", wallet.AvailableUSD, wallet.LockedUSD))
+	}
+	result.Balanced = len(result.Discrepancies) == 0
+	return result
+}
+
+// =============================================================================
+// DOUBLE-ENTRY LEDGER - CP 11: Financial Integrity
+// Wallet balances are the customer accounts of a double-entry ledger and
+// change only by posting a balanced journal entry. Transactions remain the
+// customer-facing record; their balances are read after posting.
+// =============================================================================
+
+// ledgerMove moves funds from one account to another. An entry built from
+// moves always balances.
+type ledgerMove struct {
+	from, to models.LedgerAccount
+	amount   models.Money
+}
+
+func move(from, to models.LedgerAccount, amount models.Money) ledgerMove {
+	return ledgerMove{from: from, to: to, amount: amount}
+}
+
+// post records moves as one journal entry and applies its postings to the
+// wallet's balances. tx is the transaction the entry records, nil for locks
+// and unlocks; the entry is added to its JournalIDs. Caller holds
+// walletsMu, and transactionsMu once tx is stored.
+func (s *Store) post(wallet *models.Wallet, tx *models.Transaction, description string, moves ...ledgerMove) {
+	txID := ""
+	if tx != nil {
+		txID = tx.ID
+	}
+	entry := s.journal(wallet.UserID, txID, description, moves...)
+	if tx != nil && entry.ID != "" {
+		tx.JournalIDs = append(tx.JournalIDs, entry.ID)
+	}
+	for _, posting := range entry.Postings {
+		switch posting.Account {
+		case models.AccountCustomerAvailable:
+			wallet.AvailableUSD += posting.AmountUSD
+		case models.AccountCustomerLocked:
+			wallet.LockedUSD += posting.AmountUSD
+		case models.AccountCustomerPending:
+			wallet.PendingUSD += posting.AmountUSD
+		}
+	}
+}
+
+// journal appends a journal entry for moves to the ledger without touching
+// any wallet. Zero moves are left out, and an entry with nothing left is
+// not recorded.
+func (s *Store) journal(userID, txID, description string, moves ...ledgerMove) models.JournalEntry {
+	entry := models.JournalEntry{UserID: userID, TransactionID: txID, Description: description, CreatedAt: time.Now().UTC()}
+	for _, m := range moves {
+		if m.amount != 0 {
+			entry.Postings = append(entry.Postings,
+				models.Posting{Account: m.from, AmountUSD: -m.amount},
+				models.Posting{Account: m.to, AmountUSD: m.amount})
+		}
+	}
+	if len(entry.Postings) == 0 {
+		return entry
+	}
+	entry.ID = s.generateID("je")
+	s.ledgerMu.Lock()
+	s.ledger = append(s.ledger, entry)
+	s.ledgerMu.Unlock()
+	return entry
+}
+
+// isLockEntry reports whether entry only moves funds between a customer's
+// available and locked accounts, as order locks and unlocks do.
+func isLockEntry(entry models.JournalEntry) bool {
+	if entry.TransactionID != "" {
+		return false
+	}
+	for _, posting := range entry.Postings {
+		if posting.Account != models.AccountCustomerAvailable && posting.Account != models.AccountCustomerLocked {
+			return false
+		}
+	}
+	return true
+}
+
+// openingBalances journals the balances of wallets restored from a snapshot
+// written before the ledger existed, as funds in from outside.
+func (s *Store) openingBalances() {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	for _, wallet := range s.wallets {
+		s.journal(wallet.UserID, "", "Opening balance",
+			move(models.AccountExternal, models.AccountCustomerAvailable, wallet.AvailableUSD),
+			move(models.AccountExternal, models.AccountCustomerLocked, wallet.LockedUSD),
+			move(models.AccountExternal, models.AccountCustomerPending, wallet.PendingUSD))
+	}
+}
+
+// linkJournal fills in the JournalIDs of transactions restored from a
+// snapshot written before transactions listed their entries.
+func (s *Store) linkJournal() {
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
+	s.ledgerMu.RLock()
+	defer s.ledgerMu.RUnlock()
+	for _, entry := range s.ledger {
+		if tx, exists := s.transactions[entry.TransactionID]; exists && !slices.Contains(tx.JournalIDs, entry.ID) {
+			tx.JournalIDs = append(tx.JournalIDs, entry.ID)
+		}
+	}
+}
+
+// Ledger returns the user's journal entries, or every entry for an empty
+// userID, oldest first.
+func (s *Store) Ledger(userID string) []models.JournalEntry {
+	s.ledgerMu.RLock()
+	defer s.ledgerMu.RUnlock()
+	entries := make([]models.JournalEntry, 0)
+	for _, entry := range s.ledger {
+		if userID == "" || entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// LedgerBalances sums the postings of Ledger(userID) by account. Across the
+// whole ledger the balances sum to zero.
+func (s *Store) LedgerBalances(userID string) map[models.LedgerAccount]models.Money {
+	balances := make(map[models.LedgerAccount]models.Money)
+	for _, entry := range s.Ledger(userID) {
+		for _, posting := range entry.Postings {
+			balances[posting.Account] += posting.AmountUSD
+		}
+	}
+	return balances
+}
+
+func (s *Store) LockFunds(userID string, amountUSD models.Money, orderID string) error {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return ErrWalletNotFound
+	}
+	if wallet.AvailableUSD < amountUSD {
+		return ErrInsufficientFunds
+	}
+	s.lockLocked(wallet, amountUSD, orderID)
+	return nil
+}
+
+// lockLocked reserves funds for an order. Caller holds walletsMu and has
+// checked the available balance.
+func (s *Store) lockLocked(wallet *models.Wallet, amountUSD models.Money, orderID string) {
+	s.post(wallet, nil, "Funds locked: order "+orderID, move(models.AccountCustomerAvailable, models.AccountCustomerLocked, amountUSD))
+	wallet.UpdatedAt = time.Now().UTC()
+}
+
+func (s *Store) UnlockFunds(userID string, amountUSD models.Money, orderID string) error {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return ErrWalletNotFound
+	}
+	if amountUSD < 0 {
+		return ErrInvalidAmount
+	}
+	if amountUSD > wallet.LockedUSD {
+		return ErrUnlockExceedsLocked
+	}
+	s.unlockLocked(wallet, amountUSD, orderID)
+	return nil
+}
+
+// unlockLocked releases an order's locked funds. Caller holds walletsMu and
+// has checked the locked balance covers amountUSD, with checkRelease when
+// releasing for several orders.
+func (s *Store) unlockLocked(wallet *models.Wallet, amountUSD models.Money, orderID string) {
+	s.post(wallet, nil, "Funds unlocked: order "+orderID, move(models.AccountCustomerLocked, models.AccountCustomerAvailable, amountUSD))
+	wallet.UpdatedAt = time.Now().UTC()
+}
+
+// checkRelease returns ErrUnlockExceedsLocked unless each user's locked
+// funds cover what is about to leave them, by user ID. Callers check before
+// changing anything, so a wallet out of step with its orders and positions
+// is refused rather than driven negative. Caller holds walletsMu.
+func (s *Store) checkRelease(releases map[string]models.Money) error {
+	for userID, amount := range releases {
+		if wallet, exists := s.wallets[userID]; exists && amount > wallet.LockedUSD {
+			return fmt.Errorf("%w: user %s releases %s of %s locked", ErrUnlockExceedsLocked, userID, amount, wallet.LockedUSD)
+		}
+	}
+	return nil
+}
+
+func (s *Store) SettleFunds(userID string, lockedAmount, settlementAmount models.Money, orderID, ip string) error {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return ErrWalletNotFound
+	}
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
+	s.settleLocked(wallet, models.TxTypeSettlement, lockedAmount, settlementAmount, orderID)
+	return nil
+}
+
+// settleLocked releases lockedAmount and credits settlementAmount as a
+// transaction of txType: a settlement, or a trade for a position closed
+// before its market settled. Caller holds walletsMu and transactionsMu.
+func (s *Store) settleLocked(wallet *models.Wallet, txType models.TransactionType, lockedAmount, settlementAmount models.Money, reference string) {
+	now := time.Now().UTC()
+	wallet.UpdatedAt = now
+	pnl := settlementAmount - lockedAmount
+	label := "Settlement"
+	if txType == models.TxTypeTrade {
+		label = "Position closed"
+	}
+	tx := &models.Transaction{
+		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: wallet.UserID, Type: txType,
+		Status: models.TxStatusCompleted, AmountUSD: settlementAmount, PnLUSD: pnl,
+		Reference: reference, Description: fmt.Sprintf("%s: P&L %s", label, pnl), CreatedAt: now, CompletedAt: &now,
+	}
+	// The cost leaves the customer's locked funds for the settlement
+	// account, which pays the proceeds to available funds.
+	s.post(wallet, tx, tx.Description,
+		move(models.AccountCustomerLocked, models.AccountSettlement, lockedAmount),
+		move(models.AccountSettlement, models.AccountCustomerAvailable, settlementAmount))
+	tx.BalanceAfter = wallet.AvailableUSD
+	s.transactions[tx.ID] = tx
+	s.txByWallet[wallet.ID] = append(s.txByWallet[wallet.ID], tx.ID)
+}
+
+// Statement assembles the user's statement for the calendar month starting
+// at month, which must be the first instant of a month in UTC. Balances are
+// the customer's available funds in the ledger when the period starts and
+// ends, so locks and releases of order collateral count. Realized P&L is
+// the P&L of closes and settlements less fees charged in the month.
+// CP 18: Statements are built from the same records as the audit trail.
+func (s *Store) Statement(userID string, month time.Time) (*models.Statement, error) {
+	wallet, err := s.GetWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+	start, end := month, month.AddDate(0, 1, 0)
+	stmt := &models.Statement{
+		UserID: userID, Month: start.Format("2006-01"), PeriodStart: start, PeriodEnd: end,
+		Deposits: []models.Transaction{}, Withdrawals: []models.Transaction{},
+		Trades: []models.Order{}, Closes: []models.Transaction{}, Settlements: []models.Settlement{},
+	}
+	inPeriod := func(t time.Time) bool { return !t.Before(start) && t.Before(end) }
+
+	s.transactionsMu.RLock()
+	for _, id := range s.txByWallet[wallet.ID] {
+		tx := s.transactions[id]
+		if !inPeriod(tx.CreatedAt) {
+			continue
+		}
+		completed := tx.Status == models.TxStatusCompleted
+		switch tx.Type {
+		case models.TxTypeDeposit:
+			stmt.Deposits = append(stmt.Deposits, *tx)
+			if completed {
+				stmt.DepositsUSD += tx.AmountUSD
+			}
+		case models.TxTypeWithdrawal:
+			stmt.Withdrawals = append(stmt.Withdrawals, *tx)
+			if completed {
+				stmt.WithdrawalsUSD += tx.AmountUSD
+			}
+		case models.TxTypeFee:
+			if completed {
+				stmt.FeesUSD += tx.AmountUSD
+			}
+		case models.TxTypeTrade:
+			stmt.Closes = append(stmt.Closes, *tx)
+			stmt.RealizedPnLUSD += tx.PnLUSD
+		case models.TxTypeSettlement:
+			stmt.RealizedPnLUSD += tx.PnLUSD
+		}
+	}
+	s.transactionsMu.RUnlock()
+	stmt.RealizedPnLUSD -= stmt.FeesUSD
+
+	s.ledgerMu.RLock()
+	for _, entry := range s.ledger {
+		if entry.UserID != userID || !entry.CreatedAt.Before(end) {
+			continue
+		}
+		for _, posting := range entry.Postings {
+			if posting.Account != models.AccountCustomerAvailable {
+				continue
+			}
+			stmt.ClosingBalanceUSD += posting.AmountUSD
+			if entry.CreatedAt.Before(start) {
+				stmt.OpeningBalanceUSD += posting.AmountUSD
+			}
+		}
+	}
+	s.ledgerMu.RUnlock()
+
+	s.ordersMu.RLock()
+	for _, id := range s.ordersByUser[userID] {
+		order := s.orders[id]
+		filledAt := order.UpdatedAt
+		if order.FilledAt != nil {
+			filledAt = *order.FilledAt
+		}
+		if order.FilledQuantity > 0 && inPeriod(filledAt) {
+			stmt.Trades = append(stmt.Trades, *order)
+		}
+	}
+	s.ordersMu.RUnlock()
+
+	s.settlementsMu.RLock()
+	for _, settlement := range s.settlements {
+		if settlement.UserID == userID && inPeriod(settlement.SettledAt) {
+			stmt.Settlements = append(stmt.Settlements, settlement)
+		}
+	}
+	s.settlementsMu.RUnlock()
+	return stmt, nil
 }
 
 func (s *Store) GetTransactions(userID string, limit int) ([]models.Transaction, error) {
@@ -721,261 +2012,1913 @@ func (s *Store) GetTransactions(userID string, limit int) ([]models.Transaction,
 // =============================================================================
 
 func (s *Store) CreateOrder(userID, marketTicker, eventTicker string, side models.OrderSide, orderType models.OrderType, quantity, priceCents int, ip string) (*models.Order, error) {
+	return s.createOrder(userID, marketTicker, eventTicker, side, orderType, quantity, priceCents, ip, false)
+}
+
+// CreateReduceOnlyOrder places an order that may only offset the user's
+// existing position on the other side of the market: a NO order against net
+// YES contracts or the reverse. It is rejected with ErrNothingToReduce when
+// its quantity exceeds what can still be reduced, and skips the position
+// limit check since filling it lowers exposure. Collateral is still locked.
+// CP 5: Users near their limit can always de-risk.
+func (s *Store) CreateReduceOnlyOrder(userID, marketTicker, eventTicker string, side models.OrderSide, orderType models.OrderType, quantity, priceCents int, ip string) (*models.Order, error) {
+	return s.createOrder(userID, marketTicker, eventTicker, side, orderType, quantity, priceCents, ip, true)
+}
+
+func (s *Store) createOrder(userID, marketTicker, eventTicker string, side models.OrderSide, orderType models.OrderType, quantity, priceCents int, ip string, reduceOnly bool) (*models.Order, error) {
 	if s.IsTradingHalted(marketTicker) {
 		return nil, ErrTradingHalted
 	}
-	user, err := s.GetUser(userID)
-	if err != nil {
-		return nil, err
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == models.UserStatusSuspended || user.Status == models.UserStatusBanned {
+		return nil, ErrUserSuspended
+	}
+	if user.Status != models.UserStatusVerified {
+		return nil, ErrKYCRequired
+	}
+	openOrderLimit := s.openOrderLimit(user)
+
+	// The checks and the placement share one critical section, so nothing
+	// they read can change before the order is booked
+	unlock := s.lockBook()
+	defer unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+	if _, settled := s.settledMarkets[marketTicker]; settled {
+		return nil, ErrMarketAlreadySettled
+	}
+	// CP 5: Cap resting orders per user
+	if s.openOrderCount(userID) >= openOrderLimit {
+		return nil, ErrTooManyOpenOrders
+	}
+	if reduceOnly && quantity > s.reducibleContracts(userID, marketTicker, side, "") {
+		return nil, ErrNothingToReduce
+	}
+	// CP 11: 100% collateralization; the trading fee is reserved with the
+	// collateral and charged on fill
+	collateralUSD := models.Cents(int64(models.CollateralCents(side, quantity, priceCents)))
+	feeUSD := s.tradeFee(quantity, collateralUSD)
+	if wallet.AvailableUSD < collateralUSD+feeUSD {
+		return nil, ErrInsufficientFunds
+	}
+	// CP 5: Position limits
+	currentExposure := s.exposureLocked(wallet)
+	if !reduceOnly && currentExposure+collateralUSD > user.PositionLimitUSD {
+		s.CreateComplianceAlert(userID, marketTicker, "position_limit", "high",
+			fmt.Sprintf("Order would exceed position limit: current=%.2f, order=%.2f, limit=%.2f", currentExposure.Dollars(), collateralUSD.Dollars(), user.PositionLimitUSD.Dollars()))
+		return nil, ErrPositionLimitExceeded
+	}
+	// CP 4: Self-trade prevention runs last because it changes resting
+	// orders; it may shrink or reject the new order, which only lowers what
+	// the checks above approved
+	quantity, err = s.preventSelfTrade(wallet, marketTicker, side, quantity, priceCents)
+	if err != nil {
+		return nil, err
+	}
+	collateralUSD = models.Cents(int64(models.CollateralCents(side, quantity, priceCents)))
+	feeUSD = s.tradeFee(quantity, collateralUSD)
+
+	now := time.Now().UTC()
+	order := &models.Order{
+		ID: s.generateID("order"), UserID: userID, MarketTicker: marketTicker, EventTicker: eventTicker,
+		Side: side, Type: orderType, Status: models.OrderStatusPending, Quantity: quantity,
+		PriceCents: priceCents, CollateralUSD: collateralUSD, FeeUSD: feeUSD, ReduceOnly: reduceOnly,
+		CreatedAt: now, UpdatedAt: now, SubmitIP: ip,
+	}
+	if s.orderTTL > 0 {
+		expires := now.Add(s.orderTTL)
+		order.ExpiresAt = &expires
+	}
+	s.lockLocked(wallet, collateralUSD+feeUSD, order.ID)
+	s.orders[order.ID] = order
+	s.ordersByUser[userID] = append(s.ordersByUser[userID], order.ID)
+	desc := fmt.Sprintf("Order placed: %s %d %s @ %d¢", side, quantity, marketTicker, priceCents)
+	if reduceOnly {
+		desc += " (reduce-only)"
+	}
+	s.LogAudit(userID, models.AuditActionTrade, "order", order.ID, nil, order, ip, "", desc)
+	return order, nil
+}
+
+// SetOrderTTL makes orders placed from now on expire ttl after placement
+// unless filled or cancelled first; ExpireOrders closes them. 0 keeps
+// orders until cancelled.
+func (s *Store) SetOrderTTL(ttl time.Duration) {
+	s.ordersMu.Lock()
+	defer s.ordersMu.Unlock()
+	s.orderTTL = ttl
+}
+
+// reducibleContracts returns how many more contracts a reduce-only order on
+// side can offset: the user's net position on the other side of the market,
+// less what resting reduce-only orders on side already claim. Callers hold
+// ordersMu and positionsMu.
+func (s *Store) reducibleContracts(userID, marketTicker string, side models.OrderSide, excludeOrderID string) int {
+	yes, no := s.marketSides(userID, marketTicker)
+	reducible := yes - no
+	if side == models.OrderSideYes {
+		reducible = no - yes
+	}
+	for _, id := range s.ordersByUser[userID] {
+		o := s.orders[id]
+		if id == excludeOrderID || !o.ReduceOnly || o.MarketTicker != marketTicker || o.Side != side {
+			continue
+		}
+		switch o.Status {
+		case models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial:
+			reducible -= o.Quantity - o.FilledQuantity
+		}
+	}
+	return max(reducible, 0)
+}
+
+// =============================================================================
+// SELF-TRADE PREVENTION - CP 4: Prevention of Market Disruption
+// =============================================================================
+
+// SelfTradePolicy decides what happens when a new order would cross the same
+// user's resting order on the opposite side of a market.
+type SelfTradePolicy string
+
+const (
+	SelfTradeCancelNewest  SelfTradePolicy = "cancel-newest"  // Reject the incoming order
+	SelfTradeCancelOldest  SelfTradePolicy = "cancel-oldest"  // Cancel the resting orders
+	SelfTradeDecrementBoth SelfTradePolicy = "decrement-both" // Reduce both by the overlap
+)
+
+// SetSelfTradePolicy sets the policy applied by CreateOrder and AmendOrder.
+// Unknown values fall back to cancel-newest.
+func (s *Store) SetSelfTradePolicy(policy SelfTradePolicy) {
+	switch policy {
+	case SelfTradeCancelOldest, SelfTradeDecrementBoth:
+	default:
+		policy = SelfTradeCancelNewest
+	}
+	s.selfTradeMu.Lock()
+	defer s.selfTradeMu.Unlock()
+	s.selfTradePolicy = policy
+}
+
+// GetSelfTradePolicy returns the policy in effect.
+func (s *Store) GetSelfTradePolicy() SelfTradePolicy {
+	s.selfTradeMu.RLock()
+	defer s.selfTradeMu.RUnlock()
+	if s.selfTradePolicy == "" {
+		return SelfTradeCancelNewest
+	}
+	return s.selfTradePolicy
+}
+
+// crosses reports whether a new order would match a resting order on the
+// opposite side. Both prices are YES prices: a YES bid at p meets a NO bid
+// at q when p >= q.
+func crosses(side models.OrderSide, priceCents int, resting *models.Order) bool {
+	if resting.Side == side {
+		return false
+	}
+	if side == models.OrderSideYes {
+		return priceCents >= resting.PriceCents
+	}
+	return resting.PriceCents >= priceCents
+}
+
+// preventSelfTrade applies the self-trade policy to an order the wallet's
+// owner is placing or amending to side, quantity and priceCents, and returns
+// the quantity it may still rest for.
+// Collateral released from resting orders goes back to the wallet. Any
+// prevention raises a self_trade_prevented alert. Callers hold ordersMu and
+// walletsMu.
+func (s *Store) preventSelfTrade(wallet *models.Wallet, marketTicker string, side models.OrderSide, quantity, priceCents int) (int, error) {
+	policy := s.GetSelfTradePolicy()
+	userID := wallet.UserID
+
+	var resting []*models.Order
+	for _, orderID := range s.ordersByUser[userID] {
+		order := s.orders[orderID]
+		if order.MarketTicker != marketTicker || !crosses(side, priceCents, order) {
+			continue
+		}
+		switch order.Status {
+		case models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial:
+			resting = append(resting, order) // ordersByUser is oldest first
+		}
+	}
+	if len(resting) == 0 {
+		return quantity, nil
+	}
+	if policy == SelfTradeCancelOldest || policy == SelfTradeDecrementBoth {
+		var reserved models.Money
+		for _, order := range resting {
+			reserved += unfilledReserve(order)
+		}
+		if err := s.checkRelease(map[string]models.Money{userID: reserved}); err != nil {
+			return 0, err
+		}
+	}
+
+	type release struct {
+		order  models.Order
+		amount models.Money
+	}
+	var released []release
+	remaining := quantity
+	now := time.Now().UTC()
+	switch policy {
+	case SelfTradeCancelOldest:
+		for _, order := range resting {
+			amount := unfilledReserve(order)
+			setOrderStatus(order, models.OrderStatusCancelled, now)
+			released = append(released, release{*order, amount})
+		}
+	case SelfTradeDecrementBoth:
+		for _, order := range resting {
+			if remaining == 0 {
+				break
+			}
+			overlap := order.Quantity - order.FilledQuantity
+			if overlap > remaining {
+				overlap = remaining
+			}
+			before := unfilledReserve(order)
+			order.Quantity -= overlap
+			order.CollateralUSD = models.Cents(int64(models.CollateralCents(order.Side, order.Quantity, order.PriceCents)))
+			order.FeeUSD = s.tradeFee(order.Quantity, order.CollateralUSD)
+			order.UpdatedAt = now
+			if order.Quantity == order.FilledQuantity {
+				setOrderStatus(order, models.OrderStatusCancelled, now)
+			}
+			remaining -= overlap
+			released = append(released, release{*order, before - unfilledReserve(order)})
+		}
+	default:
+		remaining = 0
+	}
+
+	for _, r := range released {
+		s.unlockLocked(wallet, r.amount, r.order.ID)
+		s.LogAudit(userID, models.AuditActionUpdate, "order", r.order.ID, nil, r.order, "", "",
+			fmt.Sprintf("Resting order reduced to %d by self-trade prevention (%s)", r.order.Quantity, policy))
+	}
+	s.CreateComplianceAlert(userID, marketTicker, "self_trade_prevented", "medium",
+		fmt.Sprintf("%s order for %d @ %d¢ would cross %d resting order(s); policy %s, %d contracts remain",
+			side, quantity, priceCents, len(resting), policy, remaining))
+
+	if remaining == 0 {
+		return 0, ErrSelfTradePrevented
+	}
+	return remaining, nil
+}
+
+// orderTransitions lists the statuses each order status may move to. An
+// order moves to its own status when it is amended in place (pending, open)
+// or filled further (partial). Filled, cancelled, rejected and expired
+// orders are final.
+// CP 9: Execution; CP 11: A closed order never fills or releases collateral again.
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending: {models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial,
+		models.OrderStatusFilled, models.OrderStatusCancelled, models.OrderStatusRejected, models.OrderStatusExpired},
+	models.OrderStatusOpen: {models.OrderStatusOpen, models.OrderStatusPartial, models.OrderStatusFilled,
+		models.OrderStatusCancelled, models.OrderStatusExpired},
+	models.OrderStatusPartial: {models.OrderStatusPartial, models.OrderStatusFilled, models.OrderStatusCancelled,
+		models.OrderStatusExpired},
+}
+
+// canTransitionOrder reports whether an order may move from one status to
+// another.
+func canTransitionOrder(from, to models.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrderStatus moves an order to next and stamps its update time, and its
+// fill or cancel time when it gets there. It reports false, leaving the
+// order unchanged, if the transition is not allowed. Callers hold ordersMu.
+func setOrderStatus(order *models.Order, next models.OrderStatus, now time.Time) bool {
+	if !canTransitionOrder(order.Status, next) {
+		return false
+	}
+	order.Status = next
+	order.UpdatedAt = now
+	switch next {
+	case models.OrderStatusFilled:
+		order.FilledAt = &now
+	case models.OrderStatusCancelled, models.OrderStatusExpired:
+		order.CancelledAt = &now
+	}
+	return true
+}
+
+// AmendOrder changes the price and/or quantity of a resting order in place.
+// The order keeps its ID and creation time; locked collateral (and the
+// reserved trading fee) is topped up or released by the difference. Orders
+// on halted or settled markets cannot be amended. An amend that would cross
+// the user's own resting orders goes through self-trade prevention like a
+// new order: under cancel-newest it is refused and the order keeps its old
+// terms; under decrement-both an order reduced to nothing is cancelled.
+// CP 4: No self-trades by amendment; CP 11: The amended order stays 100%
+// collateralized; CP 18: audited as old/new values.
+func (s *Store) AmendOrder(userID, orderID string, newPrice, newQty int, ip string) (*models.Order, error) {
+	if newPrice < 1 || newPrice > 99 || newQty <= 0 {
+		return nil, ErrInvalidAmend
+	}
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+
+	// As in createOrder, the checks and the amend share one critical section
+	unlock := s.lockBook()
+	defer unlock()
+	order, exists := s.orders[orderID]
+	if !exists || order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	if s.IsTradingHalted(order.MarketTicker) {
+		return nil, ErrTradingHalted
+	}
+	if _, settled := s.settledMarkets[order.MarketTicker]; settled {
+		return nil, ErrMarketAlreadySettled
+	}
+	// An amend keeps the status; once part of the order has filled, the
+	// collateral behind the filled share is fixed and the order stays as is
+	if order.FilledQuantity > 0 || !canTransitionOrder(order.Status, order.Status) {
+		return nil, ErrOrderNotAmendable
+	}
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+	if order.ReduceOnly && newQty > order.Quantity {
+		if newQty-order.FilledQuantity > s.reducibleContracts(userID, order.MarketTicker, order.Side, order.ID) {
+			return nil, ErrNothingToReduce
+		}
+	}
+
+	reserve := order.CollateralUSD + order.FeeUSD
+	collateralUSD := models.Cents(int64(models.CollateralCents(order.Side, newQty, newPrice)))
+	feeUSD := s.tradeFee(newQty, collateralUSD)
+	if delta := collateralUSD + feeUSD - reserve; delta > 0 {
+		if wallet.AvailableUSD < delta {
+			return nil, ErrInsufficientFunds
+		}
+		// CP 5: Position limits; reduce-only orders lower exposure when filled
+		if !order.ReduceOnly && s.exposureLocked(wallet)+delta > user.PositionLimitUSD {
+			return nil, ErrPositionLimitExceeded
+		}
+	} else if -delta > wallet.LockedUSD {
+		return nil, ErrUnlockExceedsLocked
+	}
+	// CP 4: Self-trade prevention runs last, as for new orders
+	qty, err := s.preventSelfTrade(wallet, order.MarketTicker, order.Side, newQty, newPrice)
+	if errors.Is(err, ErrSelfTradePrevented) && s.GetSelfTradePolicy() == SelfTradeDecrementBoth {
+		old := *order
+		s.unlockLocked(wallet, reserve, orderID)
+		setOrderStatus(order, models.OrderStatusCancelled, time.Now().UTC())
+		s.LogAudit(userID, models.AuditActionUpdate, "order", order.ID, old, *order, ip, "",
+			"Order cancelled: amend fully offset by self-trade prevention")
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	newQty = qty
+	collateralUSD = models.Cents(int64(models.CollateralCents(order.Side, newQty, newPrice)))
+	feeUSD = s.tradeFee(newQty, collateralUSD)
+	if delta := collateralUSD + feeUSD - reserve; delta > 0 {
+		s.lockLocked(wallet, delta, orderID)
+	} else if delta < 0 {
+		s.unlockLocked(wallet, -delta, orderID)
+	}
+
+	old := *order
+	order.PriceCents = newPrice
+	order.Quantity = newQty
+	order.CollateralUSD = collateralUSD
+	order.FeeUSD = feeUSD
+	order.UpdatedAt = time.Now().UTC()
+	order.AmendedAt = append(order.AmendedAt, order.UpdatedAt)
+	s.LogAudit(userID, models.AuditActionUpdate, "order", order.ID, old, *order, ip, "",
+		fmt.Sprintf("Order amended: %d @ %d¢ -> %d @ %d¢", old.Quantity, old.PriceCents, newQty, newPrice))
+	amended := *order
+	return &amended, nil
+}
+
+// CancelOrder cancels a user's resting order and releases the collateral and
+// fee reserved for its unfilled quantity.
+func (s *Store) CancelOrder(userID, orderID, ip string) (*models.Order, error) {
+	s.ordersMu.Lock()
+	defer s.ordersMu.Unlock()
+	order, exists := s.orders[orderID]
+	if !exists || order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	if !canTransitionOrder(order.Status, models.OrderStatusCancelled) {
+		return nil, ErrOrderNotCancellable
+	}
+
+	if reserve := unfilledReserve(order); reserve > 0 {
+		if err := s.UnlockFunds(userID, reserve, orderID); err != nil {
+			return nil, err
+		}
+	}
+	old := *order
+	setOrderStatus(order, models.OrderStatusCancelled, time.Now().UTC())
+	s.LogAudit(userID, models.AuditActionUpdate, "order", order.ID, old, *order, ip, "", "Order cancelled by user")
+	cancelled := *order
+	return &cancelled, nil
+}
+
+// countOpenOrders returns how many of a user's orders are still resting.
+func (s *Store) countOpenOrders(userID string) int {
+	s.ordersMu.RLock()
+	defer s.ordersMu.RUnlock()
+	return s.openOrderCount(userID)
+}
+
+// openOrderCount is countOpenOrders for callers holding ordersMu.
+func (s *Store) openOrderCount(userID string) int {
+	count := 0
+	for _, id := range s.ordersByUser[userID] {
+		if order, exists := s.orders[id]; exists {
+			switch order.Status {
+			case models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial:
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// openOrderLimit returns the user's open-order cap. Users persisted before the
+// cap existed get their tier's live limit.
+func (s *Store) openOrderLimit(user *models.User) int {
+	if user.MaxOpenOrders > 0 {
+		return user.MaxOpenOrders
+	}
+	return s.TierLimits(user.LimitTier).MaxOpenOrders
+}
+
+// MockFillOrder fills the unfilled remainder of an order at fillPrice.
+// Orders that cannot move to filled are refused with ErrOrderNotFillable.
+func (s *Store) MockFillOrder(orderID string, fillPrice int) error {
+	s.ordersMu.RLock()
+	order, exists := s.orders[orderID]
+	var remaining int
+	fillable := false
+	if exists {
+		remaining = order.Quantity - order.FilledQuantity
+		fillable = canTransitionOrder(order.Status, models.OrderStatusFilled)
+	}
+	s.ordersMu.RUnlock()
+	if !exists {
+		return ErrOrderNotFound
+	}
+	if !fillable {
+		return ErrOrderNotFillable
+	}
+	return s.PartialFill(orderID, remaining, fillPrice)
+}
+
+// PartialFill fills qty contracts of an order at priceCents. The order stays
+// partial until its full quantity is filled; FilledPriceCents is the
+// volume-weighted average of all fills. Orders past ExpiresAt are refused
+// even before ExpireOrders sweeps them. The position grows by the filled
+// share of the order's collateral, and the matching share of the reserved
+// trading fee is charged.
+// CP 9: Execution; CP 11: Collateral tracks what was actually filled.
+func (s *Store) PartialFill(orderID string, qty, priceCents int) error {
+	s.ordersMu.Lock()
+	order, exists := s.orders[orderID]
+	if !exists {
+		s.ordersMu.Unlock()
+		return ErrOrderNotFound
+	}
+	next := models.OrderStatusPartial
+	if order.FilledQuantity+qty == order.Quantity {
+		next = models.OrderStatusFilled
+	}
+	if !canTransitionOrder(order.Status, next) {
+		s.ordersMu.Unlock()
+		return ErrOrderNotFillable
+	}
+	if qty <= 0 || order.FilledQuantity+qty > order.Quantity {
+		s.ordersMu.Unlock()
+		return ErrInvalidFillQuantity
+	}
+	if s.IsMarketSettled(order.MarketTicker) {
+		s.ordersMu.Unlock()
+		return ErrMarketAlreadySettled
+	}
+	now := time.Now().UTC()
+	if order.ExpiresAt != nil && !order.ExpiresAt.After(now) {
+		s.ordersMu.Unlock()
+		return ErrOrderNotFillable
+	}
+
+	hedgedBefore := s.hedgedInMarket(order.UserID, order.MarketTicker)
+	prevFilled := order.FilledQuantity
+	order.FilledPriceCents = weightedAvgCents(order.FilledPriceCents, prevFilled, priceCents, qty)
+	order.FilledQuantity += qty
+	setOrderStatus(order, next, now)
+
+	cost := filledShare(order.CollateralUSD, order.FilledQuantity, order.Quantity) -
+		filledShare(order.CollateralUSD, prevFilled, order.Quantity)
+	fee := filledShare(order.FeeUSD, order.FilledQuantity, order.Quantity) -
+		filledShare(order.FeeUSD, prevFilled, order.Quantity)
+	s.addToPosition(order, qty, priceCents, cost, fee)
+	userID := order.UserID
+	filled := *order
+	s.ordersMu.Unlock()
+	s.publish(events.TypeFill, orderID, userID, filled)
+	s.flagWashSetup(userID, filled.MarketTicker, hedgedBefore)
+
+	if fee > 0 {
+		return s.chargeFee(userID, fee, true, orderID, fmt.Sprintf("Trading fee: order %s", orderID))
+	}
+	return nil
+}
+
+// weightedAvgCents averages two prices by quantity, rounded to the nearest
+// cent.
+func weightedAvgCents(priceA, qtyA, priceB, qtyB int) int {
+	total := qtyA + qtyB
+	if total == 0 {
+		return 0
+	}
+	return int(math.Round(float64(priceA*qtyA+priceB*qtyB) / float64(total)))
+}
+
+// filledShare is the portion of an order-level amount attributable to the
+// filled contracts, rounded to the cent so fills sum exactly to the total.
+func filledShare(totalUSD models.Money, filled, quantity int) models.Money {
+	return totalUSD.MulDiv(int64(filled), int64(quantity))
+}
+
+// unfilledReserve returns the collateral and fee still locked for the
+// unfilled remainder of an order.
+func unfilledReserve(order *models.Order) models.Money {
+	return order.CollateralUSD + order.FeeUSD -
+		filledShare(order.CollateralUSD, order.FilledQuantity, order.Quantity) -
+		filledShare(order.FeeUSD, order.FilledQuantity, order.Quantity)
+}
+
+// addToPosition adds a fill of qty contracts costing costUSD to the user's
+// open position in the order's market and side, creating it if needed.
+func (s *Store) addToPosition(order *models.Order, qty, priceCents int, costUSD, feeUSD models.Money) {
+	s.positionsMu.Lock()
+	defer s.positionsMu.Unlock()
+	var existingPos *models.Position
+	for _, posID := range s.positionsByUser[order.UserID] {
+		pos := s.positions[posID]
+		if pos.MarketTicker == order.MarketTicker && pos.Side == order.Side && pos.ClosedAt == nil {
+			existingPos = pos
+			break
+		}
+	}
+	now := time.Now().UTC()
+	fifo := s.costBasisMethod() == CostBasisFIFO
+	if existingPos != nil {
+		if fifo {
+			existingPos.Lots = append(openLots(existingPos), models.PositionLot{
+				Quantity: qty, PriceCents: priceCents, CostUSD: costUSD, OpenedAt: now,
+			})
+		}
+		totalQty := existingPos.Quantity + qty
+		existingPos.AvgPriceCents = weightedAvgCents(existingPos.AvgPriceCents, existingPos.Quantity, priceCents, qty)
+		existingPos.Quantity = totalQty
+		existingPos.CostBasisUSD += costUSD
+		existingPos.FeesUSD += feeUSD
+		existingPos.UpdatedAt = now
+	} else {
+		pos := &models.Position{
+			ID: s.generateID("pos"), UserID: order.UserID, MarketTicker: order.MarketTicker,
+			EventTicker: order.EventTicker, Side: order.Side, Quantity: qty,
+			AvgPriceCents: priceCents, CostBasisUSD: costUSD, FeesUSD: feeUSD,
+			CreatedAt: now, UpdatedAt: now,
+		}
+		if fifo {
+			pos.Lots = []models.PositionLot{{Quantity: qty, PriceCents: priceCents, CostUSD: costUSD, OpenedAt: now}}
+		}
+		s.positions[pos.ID] = pos
+		s.positionsByUser[order.UserID] = append(s.positionsByUser[order.UserID], pos.ID)
+	}
+}
+
+// GetOrder returns one of the user's orders.
+func (s *Store) GetOrder(userID, orderID string) (*models.Order, error) {
+	s.ordersMu.RLock()
+	defer s.ordersMu.RUnlock()
+	order, exists := s.orders[orderID]
+	if !exists || order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	result := *order
+	return &result, nil
+}
+
+func (s *Store) GetOrders(userID string, status *models.OrderStatus, limit int) ([]models.Order, error) {
+	s.ordersMu.RLock()
+	defer s.ordersMu.RUnlock()
+	orderIDs := s.ordersByUser[userID]
+	var result []models.Order
+	for i := len(orderIDs) - 1; i >= 0 && len(result) < limit; i-- {
+		order := s.orders[orderIDs[i]]
+		if status != nil && order.Status != *status {
+			continue
+		}
+		result = append(result, *order)
+	}
+	return result, nil
+}
+
+func (s *Store) GetAllOrders(limit int) []models.Order {
+	s.ordersMu.RLock()
+	defer s.ordersMu.RUnlock()
+	var result []models.Order
+	for _, order := range s.orders {
+		result = append(result, *order)
+		if len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// MarketFills returns the orders in a market that last filled from from to
+// to inclusive, oldest fill first.
+func (s *Store) MarketFills(marketTicker string, from, to time.Time) []models.Order {
+	s.ordersMu.RLock()
+	defer s.ordersMu.RUnlock()
+	var result []models.Order
+	for _, order := range s.orders {
+		if order.MarketTicker != marketTicker || order.FilledQuantity == 0 {
+			continue
+		}
+		if at := fillTime(*order); !at.Before(from) && !at.After(to) {
+			result = append(result, *order)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return fillTime(result[i]).Before(fillTime(result[j]))
+	})
+	return result
+}
+
+// fillTime is when an order last filled: FilledAt once set, else UpdatedAt.
+func fillTime(order models.Order) time.Time {
+	if order.FilledAt != nil {
+		return *order.FilledAt
+	}
+	return order.UpdatedAt
+}
+
+func (s *Store) GetPositions(userID string) ([]models.Position, error) {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	posIDs := s.positionsByUser[userID]
+	var result []models.Position
+	for _, posID := range posIDs {
+		pos := s.positions[posID]
+		if pos.ClosedAt == nil {
+			result = append(result, *pos)
+		}
+	}
+	return result, nil
+}
+
+func (s *Store) GetAllPositions() []models.Position {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	var result []models.Position
+	for _, pos := range s.positions {
+		if pos.ClosedAt == nil {
+			result = append(result, *pos)
+		}
+	}
+	return result
+}
+
+// GetUserExposure returns the funds the user has at risk: everything locked
+// in orders and positions, less $1.00 for each contract held on both sides
+// of a market, since a matched YES/NO pair pays $1.00 whatever the result.
+// Position limits compare it in whole cents.
+func (s *Store) GetUserExposure(userID string) models.Money {
+	wallet, err := s.GetWallet(userID)
+	if err != nil {
+		return 0
+	}
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	return s.exposureLocked(wallet)
+}
+
+// exposureLocked is GetUserExposure for a wallet already read. Callers hold
+// positionsMu.
+func (s *Store) exposureLocked(wallet *models.Wallet) models.Money {
+	exposure := wallet.LockedUSD
+	for ticker := range s.openMarkets(wallet.UserID) {
+		yes, no := s.marketSides(wallet.UserID, ticker)
+		exposure -= models.Cents(int64(min(yes, no)) * 100)
+	}
+	if exposure < 0 {
+		return 0 // hedged pairs bought for under $1.00 lock in a profit
+	}
+	return exposure
+}
+
+// ProjectFill returns the trading fee and the user's exposure if an order
+// for quantity contracts costing collateralUSD were placed and filled in
+// full. The new contracts are netted against the other side of the market
+// as in GetUserExposure. Nothing is changed.
+func (s *Store) ProjectFill(userID, marketTicker string, side models.OrderSide, quantity int, collateralUSD models.Money) (feeUSD, exposure models.Money) {
+	feeUSD = s.tradeFee(quantity, collateralUSD)
+	wallet, err := s.GetWallet(userID)
+	if err != nil {
+		return feeUSD, 0
+	}
+	exposure = wallet.LockedUSD + collateralUSD
+	s.positionsMu.RLock()
+	markets := s.openMarkets(userID)
+	markets[marketTicker] = true
+	for ticker := range markets {
+		yes, no := s.marketSides(userID, ticker)
+		if ticker == marketTicker {
+			if side == models.OrderSideYes {
+				yes += quantity
+			} else {
+				no += quantity
+			}
+		}
+		exposure -= models.Cents(int64(min(yes, no)) * 100)
+	}
+	s.positionsMu.RUnlock()
+	return feeUSD, max(exposure, 0)
+}
+
+// =============================================================================
+// NETTING - CP 4/5: Offsetting positions in one market
+// =============================================================================
+
+// DefaultWashSetupContracts is the number of contracts held on both sides of
+// one market that raises a wash_setup alert.
+const DefaultWashSetupContracts = 100
+
+// SetWashSetupThreshold sets how many contracts a user may hold on both the
+// YES and NO side of one market before a wash_setup alert is raised. 0
+// disables the alert.
+func (s *Store) SetWashSetupThreshold(contracts int) {
+	s.positionsMu.Lock()
+	defer s.positionsMu.Unlock()
+	s.washSetupAt = contracts
+}
+
+// openMarkets returns the markets the user has open positions in. Callers
+// hold positionsMu.
+func (s *Store) openMarkets(userID string) map[string]bool {
+	markets := make(map[string]bool)
+	for _, posID := range s.positionsByUser[userID] {
+		if pos := s.positions[posID]; pos.ClosedAt == nil {
+			markets[pos.MarketTicker] = true
+		}
+	}
+	return markets
+}
+
+// marketSides totals the user's open YES and NO contracts in a market.
+// Callers hold positionsMu.
+func (s *Store) marketSides(userID, marketTicker string) (yes, no int) {
+	for _, posID := range s.positionsByUser[userID] {
+		pos := s.positions[posID]
+		if pos.MarketTicker != marketTicker || pos.ClosedAt != nil {
+			continue
+		}
+		if pos.Side == models.OrderSideYes {
+			yes += pos.Quantity
+		} else {
+			no += pos.Quantity
+		}
+	}
+	return yes, no
+}
+
+// hedgedInMarket returns how many contracts the user holds on both sides of
+// a market.
+func (s *Store) hedgedInMarket(userID, marketTicker string) int {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	yes, no := s.marketSides(userID, marketTicker)
+	return min(yes, no)
+}
+
+// flagWashSetup raises a wash_setup alert when a fill takes the user's
+// offsetting contracts in a market across the threshold. Each position is
+// small after netting, but large gross holdings on both sides can be staged
+// to trade against each other.
+func (s *Store) flagWashSetup(userID, marketTicker string, hedgedBefore int) {
+	s.positionsMu.RLock()
+	threshold := s.washSetupAt
+	yes, no := s.marketSides(userID, marketTicker)
+	s.positionsMu.RUnlock()
+	if threshold <= 0 || hedgedBefore >= threshold || min(yes, no) < threshold {
+		return
+	}
+	s.CreateComplianceAlert(userID, marketTicker, "wash_setup", "medium", fmt.Sprintf(
+		"Holding %d YES and %d NO contracts in %s (net %d); offsetting positions may be a wash setup",
+		yes, no, marketTicker, yes-no))
+}
+
+// =============================================================================
+// FEE OPERATIONS - CP 11: Financial Integrity
+// =============================================================================
+
+// SetFeeSchedule replaces the fees applied to new orders and settlements.
+func (s *Store) SetFeeSchedule(fees models.FeeSchedule) {
+	s.feesMu.Lock()
+	defer s.feesMu.Unlock()
+	s.fees = fees
+}
+
+// GetFeeSchedule returns the fees currently in effect.
+func (s *Store) GetFeeSchedule() models.FeeSchedule {
+	s.feesMu.RLock()
+	defer s.feesMu.RUnlock()
+	return s.fees
+}
+
+// =============================================================================
+// POSITION LIMIT TABLE - CP 5: Position Limits
+// =============================================================================
+
+// GetPositionLimits returns a copy of the live tier table.
+func (s *Store) GetPositionLimits() []models.PositionLimitConfig {
+	s.limitsMu.RLock()
+	defer s.limitsMu.RUnlock()
+	return append([]models.PositionLimitConfig(nil), s.limits...)
+}
+
+// TierLimits returns the live limits for a tier, falling back to the default
+// tier for unknown or empty names.
+func (s *Store) TierLimits(tier string) models.PositionLimitConfig {
+	s.limitsMu.RLock()
+	defer s.limitsMu.RUnlock()
+	return models.TierLimits(s.limits, tier)
+}
+
+// SetPositionLimits replaces the tier table. Users still on their tier's old
+// position or open-order limit move to the new value immediately; limits an
+// operator set for an individual user are left alone. Returns the number of
+// users updated.
+func (s *Store) SetPositionLimits(limits []models.PositionLimitConfig, changedBy, ip string) (int, error) {
+	if err := models.ValidatePositionLimits(limits); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidLimits, err)
+	}
+	limits = append([]models.PositionLimitConfig(nil), limits...)
+
+	s.limitsMu.Lock()
+	old := s.limits
+	s.limits = limits
+	s.limitsMu.Unlock()
+
+	now := time.Now().UTC()
+	updated := 0
+	s.usersMu.Lock()
+	for _, user := range s.users {
+		tier := user.LimitTier
+		if tier == "" {
+			tier = models.DefaultTier // accounts created before tiers were recorded
+		}
+		was, is := models.TierLimits(old, tier), models.TierLimits(limits, tier)
+		changed := false
+		if user.PositionLimitUSD == was.MaxPositionUSD && was.MaxPositionUSD != is.MaxPositionUSD {
+			user.PositionLimitUSD = is.MaxPositionUSD
+			changed = true
+		}
+		if user.MaxOpenOrders == was.MaxOpenOrders && was.MaxOpenOrders != is.MaxOpenOrders {
+			user.MaxOpenOrders = is.MaxOpenOrders
+			changed = true
+		}
+		if changed {
+			user.UpdatedAt = now
+			updated++
+		}
+	}
+	s.usersMu.Unlock()
+
+	s.LogAudit(changedBy, models.AuditActionUpdate, "position_limits", "tiers", old, limits, ip, "",
+		fmt.Sprintf("Position limit table updated (%d users moved to new tier limits)", updated))
+	return updated, nil
+}
+
+// tradeFee returns the fee for an order in whole cents: the per-contract
+// fee times the quantity plus the percentage of collateral, rounded to the
+// cent.
+func (s *Store) tradeFee(quantity int, collateralUSD models.Money) models.Money {
+	fees := s.GetFeeSchedule()
+	return fees.TradeFeePerContractUSD*models.Money(quantity) + collateralUSD.Percent(fees.TradeFeePercent)
+}
+
+// settlementFee returns the fee on a settlement profit; losses pay nothing.
+func (s *Store) settlementFee(profitUSD models.Money) models.Money {
+	if profitUSD <= 0 {
+		return 0
+	}
+	return profitUSD.Percent(s.GetFeeSchedule().SettlementFeePercent)
+}
+
+// chargeFee debits a fee from locked funds (reserved at order placement) or
+// from available funds, and records it as a fee transaction.
+func (s *Store) chargeFee(userID string, amountUSD models.Money, fromLocked bool, reference, description string) error {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return ErrWalletNotFound
+	}
+	s.transactionsMu.Lock()
+	defer s.transactionsMu.Unlock()
+	s.chargeFeeLocked(wallet, amountUSD, fromLocked, reference, description)
+	return nil
+}
+
+// chargeFeeLocked records a fee transaction against the wallet. Caller
+// holds walletsMu and transactionsMu.
+func (s *Store) chargeFeeLocked(wallet *models.Wallet, amountUSD models.Money, fromLocked bool, reference, description string) {
+	now := time.Now().UTC()
+	wallet.UpdatedAt = now
+	tx := &models.Transaction{
+		ID: s.generateID("tx"), WalletID: wallet.ID, UserID: wallet.UserID, Type: models.TxTypeFee,
+		Status: models.TxStatusCompleted, AmountUSD: amountUSD, BalanceBefore: wallet.AvailableUSD,
+		Reference: reference, Description: description, CreatedAt: now, CompletedAt: &now,
+	}
+	from := models.AccountCustomerAvailable
+	if fromLocked {
+		from = models.AccountCustomerLocked
+	}
+	s.post(wallet, tx, description, move(from, models.AccountHouseFees, amountUSD))
+	tx.BalanceAfter = wallet.AvailableUSD
+	s.transactions[tx.ID] = tx
+	s.txByWallet[wallet.ID] = append(s.txByWallet[wallet.ID], tx.ID)
+}
+
+// =============================================================================
+// POSITION CLOSES - CP 11: Financial Integrity
+// =============================================================================
+
+// CostBasisMethod decides which contracts' cost a partial close relieves.
+type CostBasisMethod string
+
+const (
+	CostBasisAverage CostBasisMethod = "average" // A pro-rata share of the position's cost
+	CostBasisFIFO    CostBasisMethod = "fifo"    // The oldest lots first
+)
+
+// SetCostBasisMethod sets how ClosePosition computes realized P&L. Unknown
+// values fall back to average. Positions filled under average cost start
+// FIFO as one lot at their average price.
+func (s *Store) SetCostBasisMethod(method CostBasisMethod) {
+	if method != CostBasisFIFO {
+		method = CostBasisAverage
+	}
+	s.positionsMu.Lock()
+	defer s.positionsMu.Unlock()
+	s.costBasis = method
+}
+
+// GetCostBasisMethod returns the method in effect.
+func (s *Store) GetCostBasisMethod() CostBasisMethod {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	return s.costBasisMethod()
+}
+
+// costBasisMethod is GetCostBasisMethod for callers holding positionsMu.
+func (s *Store) costBasisMethod() CostBasisMethod {
+	if s.costBasis == "" {
+		return CostBasisAverage
+	}
+	return s.costBasis
+}
+
+// openLots returns the position's lots, or the whole position as one lot
+// when it was filled without lot tracking.
+func openLots(pos *models.Position) []models.PositionLot {
+	if len(pos.Lots) > 0 {
+		return pos.Lots
+	}
+	return []models.PositionLot{{
+		Quantity: pos.Quantity, PriceCents: pos.AvgPriceCents, CostUSD: pos.CostBasisUSD, OpenedAt: pos.CreatedAt,
+	}}
+}
+
+// relieveCost removes qty contracts from the position's cost basis and
+// returns the cost removed. Callers hold positionsMu.
+func (s *Store) relieveCost(pos *models.Position, qty int) models.Money {
+	if s.costBasisMethod() != CostBasisFIFO {
+		return pos.CostBasisUSD.MulDiv(int64(qty), int64(pos.Quantity))
+	}
+	// Copy so snapshots of the position taken earlier keep their lots
+	lots := append([]models.PositionLot(nil), openLots(pos)...)
+	var relieved models.Money
+	for qty > 0 && len(lots) > 0 {
+		lot := &lots[0]
+		take := min(qty, lot.Quantity)
+		cost := lot.CostUSD.MulDiv(int64(take), int64(lot.Quantity))
+		relieved += cost
+		lot.CostUSD -= cost
+		lot.Quantity -= take
+		qty -= take
+		if lot.Quantity == 0 {
+			lots = lots[1:]
+		}
+	}
+	pos.Lots = lots
+
+	// What is left averages the remaining lots' prices
+	total, weighted := 0, 0
+	for _, lot := range lots {
+		total += lot.Quantity
+		weighted += lot.Quantity * lot.PriceCents
+	}
+	if total > 0 {
+		pos.AvgPriceCents = int(math.Round(float64(weighted) / float64(total)))
+	}
+	return relieved
+}
+
+// GetPosition returns one of the user's positions, open or closed.
+func (s *Store) GetPosition(userID, positionID string) (*models.Position, error) {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	pos, exists := s.positions[positionID]
+	if !exists || pos.UserID != userID {
+		return nil, ErrPositionNotFound
+	}
+	result := *pos
+	return &result, nil
+}
+
+// ClosePosition sells quantity contracts of an open position at priceCents.
+// The cost relieved follows the cost basis method; realized P&L accrues on
+// the position, and trading fees come off it when the last contract is
+// closed. Proceeds are credited to available funds as a settlement
+// transaction.
+// CP 11: Realized P&L matches the configured accounting.
+func (s *Store) ClosePosition(userID, positionID string, quantity, priceCents int, ip string) (*models.Position, error) {
+	if priceCents < 0 || priceCents > 100 {
+		return nil, ErrInvalidClose
+	}
+	unlock := s.lockBook()
+	defer unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+	pos, exists := s.positions[positionID]
+	if !exists || pos.UserID != userID || pos.ClosedAt != nil {
+		return nil, ErrPositionNotFound
+	}
+	if quantity <= 0 || quantity > pos.Quantity {
+		return nil, ErrInvalidClose
+	}
+	old := *pos
+	leg := s.closeLocked(pos, quantity, priceCents, time.Now().UTC())
+	closed := *pos
+	s.recordClose(wallet, old, closed, leg, ip)
+	return &closed, nil
+}
+
+// PositionClose is one position's part in a close.
+type PositionClose struct {
+	PositionID     string           `json:"position_id"`
+	Side           models.OrderSide `json:"side"`
+	Quantity       int              `json:"quantity"`
+	PriceCents     int              `json:"price_cents"`
+	CostUSD        models.Money     `json:"cost_usd"` // Cost basis relieved
+	ProceedsUSD    models.Money     `json:"proceeds_usd"`
+	RealizedPnLUSD models.Money     `json:"realized_pnl_usd"` // Net of the position's fees once fully closed
+}
+
+// MarketClose is the result of CloseMarketPositions.
+type MarketClose struct {
+	MarketTicker   string          `json:"market_ticker"`
+	Legs           []PositionClose `json:"legs"`
+	ProceedsUSD    models.Money    `json:"proceeds_usd"`
+	RealizedPnLUSD models.Money    `json:"realized_pnl_usd"`
+}
+
+// CloseMarketPositions closes all of the user's open positions in a market,
+// YES at yesBid and NO at noBid. The positions and the funds for every leg
+// move under one lock, so either all positions close or, on a bad price or
+// missing wallet, none do. Returns ErrPositionNotFound when nothing is open
+// in the market.
+func (s *Store) CloseMarketPositions(userID, marketTicker string, yesBid, noBid int, ip string) (*MarketClose, error) {
+	if yesBid < 0 || yesBid > 100 || noBid < 0 || noBid > 100 {
+		return nil, ErrInvalidClose
+	}
+	unlock := s.lockBook()
+	defer unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+
+	now := time.Now().UTC()
+	result := &MarketClose{MarketTicker: marketTicker}
+	for _, posID := range s.positionsByUser[userID] {
+		pos := s.positions[posID]
+		if pos.MarketTicker != marketTicker || pos.ClosedAt != nil {
+			continue
+		}
+		price := yesBid
+		if pos.Side == models.OrderSideNo {
+			price = noBid
+		}
+		old := *pos
+		leg := s.closeLocked(pos, pos.Quantity, price, now)
+		s.recordClose(wallet, old, *pos, leg, ip)
+		result.Legs = append(result.Legs, leg)
+		result.ProceedsUSD += leg.ProceedsUSD
+		result.RealizedPnLUSD += leg.RealizedPnLUSD
+	}
+	if len(result.Legs) == 0 {
+		return nil, ErrPositionNotFound
+	}
+	return result, nil
+}
+
+// closeLocked sells quantity contracts of pos at priceCents. Callers hold
+// positionsMu and have checked quantity.
+func (s *Store) closeLocked(pos *models.Position, quantity, priceCents int, now time.Time) PositionClose {
+	realizedBefore := pos.RealizedPnL
+	relieved := s.relieveCost(pos, quantity)
+	proceeds := models.Cents(int64(quantity * priceCents))
+	pos.Quantity -= quantity
+	pos.CostBasisUSD -= relieved
+	pos.RealizedPnL += proceeds - relieved
+	pos.UpdatedAt = now
+	if pos.Quantity == 0 {
+		pos.RealizedPnL -= pos.FeesUSD
+		pos.CurrentValue = 0
+		pos.UnrealizedPnL = 0
+		pos.Lots = nil
+		pos.ClosedAt = &now
+	}
+	return PositionClose{
+		PositionID: pos.ID, Side: pos.Side, Quantity: quantity, PriceCents: priceCents,
+		CostUSD: relieved, ProceedsUSD: proceeds, RealizedPnLUSD: pos.RealizedPnL - realizedBefore,
+	}
+}
+
+// recordClose moves the funds for a close and audits it. Callers hold
+// positionsMu, walletsMu and transactionsMu.
+func (s *Store) recordClose(wallet *models.Wallet, old, closed models.Position, leg PositionClose, ip string) {
+	s.settleLocked(wallet, models.TxTypeTrade, leg.CostUSD, leg.ProceedsUSD, closed.ID)
+	s.LogAudit(closed.UserID, models.AuditActionTrade, "position", closed.ID, old, closed, ip, "",
+		fmt.Sprintf("Position closed: %d %s %s at %d¢, cost relieved %s (%s), realized %s",
+			leg.Quantity, closed.Side, closed.MarketTicker, leg.PriceCents, leg.CostUSD, s.costBasisMethod(), leg.RealizedPnLUSD))
+}
+
+// =============================================================================
+// SETTLEMENT OPERATIONS - CP 11: Financial Integrity
+// =============================================================================
+
+// OpenPositionTickers returns the markets that have at least one open position.
+func (s *Store) OpenPositionTickers() []string {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	seen := make(map[string]bool)
+	var tickers []string
+	for _, pos := range s.positions {
+		if pos.ClosedAt == nil && !seen[pos.MarketTicker] {
+			seen[pos.MarketTicker] = true
+			tickers = append(tickers, pos.MarketTicker)
+		}
+	}
+	return tickers
+}
+
+// IsMarketSettled reports whether SettleMarket has already run for a ticker.
+func (s *Store) IsMarketSettled(marketTicker string) bool {
+	s.positionsMu.RLock()
+	defer s.positionsMu.RUnlock()
+	_, settled := s.settledMarkets[marketTicker]
+	return settled
+}
+
+// SettleMarket closes every open position in a market once its result is
+// known. The market is marked settled and its pending orders are cancelled,
+// releasing their collateral, before any position is paid, so no order can
+// be placed or filled against a market being settled. Winning contracts pay
+// $1.00 each, less the settlement fee on any profit; a "void" result
+// refunds cost basis (trading fees are not refunded). Realized P&L is net
+// of all fees and includes earlier partial closes. Each position gets a
+// Settlement record carrying reason, the resolution source. The whole
+// market settles under one lock scope: if any holder has no wallet,
+// nothing changes. Each market settles at most once. Returns positions
+// settled.
+func (s *Store) SettleMarket(marketTicker, result, reason string) (int, error) {
+	return s.SettleMarketFromSource(marketTicker, result, reason, nil)
+}
+
+// SettleMarketFromSource settles like SettleMarket and records on each
+// settlement the resolution source that decided the result.
+func (s *Store) SettleMarketFromSource(marketTicker, result, reason string, source *models.SettlementSource) (int, error) {
+	result = strings.ToLower(result)
+	if result != "yes" && result != "no" && result != "void" {
+		return 0, ErrInvalidResult
+	}
+
+	unlock := s.lockBook()
+	defer unlock()
+	if _, settled := s.settledMarkets[marketTicker]; settled {
+		return 0, ErrMarketAlreadySettled
+	}
+	var orders []*models.Order
+	for _, order := range s.orders {
+		if order.MarketTicker == marketTicker && canTransitionOrder(order.Status, models.OrderStatusCancelled) {
+			orders = append(orders, order)
+		}
+	}
+	var positions []*models.Position
+	for _, pos := range s.positions {
+		if pos.MarketTicker == marketTicker && pos.ClosedAt == nil {
+			positions = append(positions, pos)
+		}
 	}
-	if user.Status == models.UserStatusSuspended || user.Status == models.UserStatusBanned {
-		return nil, ErrUserSuspended
+	// Every wallet is checked before anything changes
+	for _, order := range orders {
+		if _, exists := s.wallets[order.UserID]; !exists {
+			return 0, ErrWalletNotFound
+		}
 	}
-	if user.Status != models.UserStatusVerified {
-		return nil, ErrKYCRequired
+	for _, pos := range positions {
+		if _, exists := s.wallets[pos.UserID]; !exists {
+			return 0, ErrWalletNotFound
+		}
 	}
-	// CP 11: 100% collateralization
-	var collateralCents int
-	if side == models.OrderSideYes {
-		collateralCents = quantity * priceCents
-	} else {
-		collateralCents = quantity * (100 - priceCents)
+	releases := make(map[string]models.Money)
+	for _, order := range orders {
+		releases[order.UserID] += unfilledReserve(order)
 	}
-	collateralUSD := float64(collateralCents) / 100.0
-	// CP 5: Position limits
-	currentExposure := s.GetUserExposure(userID)
-	if currentExposure+collateralUSD > user.PositionLimitUSD {
-		s.CreateComplianceAlert(userID, marketTicker, "position_limit", "high",
-			fmt.Sprintf("Order would exceed position limit: current=%.2f, order=%.2f, limit=%.2f", currentExposure, collateralUSD, user.PositionLimitUSD))
-		return nil, ErrPositionLimitExceeded
+	for _, pos := range positions {
+		releases[pos.UserID] += pos.CostBasisUSD
 	}
-	if err := s.LockFunds(userID, collateralUSD, ""); err != nil {
-		return nil, err
+	if err := s.checkRelease(releases); err != nil {
+		return 0, err
 	}
-	s.ordersMu.Lock()
-	defer s.ordersMu.Unlock()
+
 	now := time.Now().UTC()
-	order := &models.Order{
-		ID: s.generateID("order"), UserID: userID, MarketTicker: marketTicker, EventTicker: eventTicker,
-		Side: side, Type: orderType, Status: models.OrderStatusPending, Quantity: quantity,
-		PriceCents: priceCents, CollateralUSD: collateralUSD, CreatedAt: now, UpdatedAt: now, SubmitIP: ip,
-	}
-	s.orders[order.ID] = order
-	s.ordersByUser[userID] = append(s.ordersByUser[userID], order.ID)
-	s.LogAudit(userID, models.AuditActionTrade, "order", order.ID, nil, order, ip, "",
-		fmt.Sprintf("Order placed: %s %d %s @ %d¢", side, quantity, marketTicker, priceCents))
-	return order, nil
+	s.settledMarkets[marketTicker] = now
+	for _, order := range orders {
+		setOrderStatus(order, models.OrderStatusCancelled, now)
+		s.unlockLocked(s.wallets[order.UserID], unfilledReserve(order), order.ID)
+		s.LogAudit(order.UserID, models.AuditActionUpdate, "order", order.ID, nil, *order, "", "",
+			"Order cancelled: market settled")
+	}
+
+	for _, pos := range positions {
+		wallet := s.wallets[pos.UserID]
+		payout := pos.CostBasisUSD
+		var fee models.Money
+		if result != "void" {
+			payout = 0
+			if string(pos.Side) == result {
+				payout = models.Cents(int64(pos.Quantity) * 100)
+			}
+			fee = s.settlementFee(payout - pos.CostBasisUSD)
+			pos.FeesUSD += fee
+		}
+		pos.CurrentValue = payout
+		pos.RealizedPnL += payout - pos.CostBasisUSD - pos.FeesUSD
+		pos.UnrealizedPnL = 0
+		pos.ClosedAt = &now
+		pos.UpdatedAt = now
+
+		s.settleLocked(wallet, models.TxTypeSettlement, pos.CostBasisUSD, pos.CurrentValue, pos.ID)
+		if fee > 0 {
+			s.chargeFeeLocked(wallet, fee, false, pos.ID, fmt.Sprintf("Settlement fee: %s", marketTicker))
+		}
+		settlement := models.Settlement{
+			ID: s.generateID("stl"), UserID: pos.UserID, PositionID: pos.ID, MarketTicker: marketTicker,
+			EventTicker: pos.EventTicker, Side: pos.Side, Quantity: pos.Quantity, Result: result,
+			CostBasisUSD: pos.CostBasisUSD, PayoutUSD: pos.CurrentValue, FeeUSD: fee, PnLUSD: pos.RealizedPnL,
+			Reason: reason, SettledAt: now, Source: source,
+		}
+		s.settlementsMu.Lock()
+		s.settlements = append(s.settlements, settlement)
+		s.settlementsMu.Unlock()
+		s.LogAudit(pos.UserID, models.AuditActionSettle, "settlement", settlement.ID, nil, settlement, "", "",
+			fmt.Sprintf("Position settled: %s %d %s, result=%s, payout=%s", pos.Side, pos.Quantity, marketTicker, result, pos.CurrentValue))
+		s.publish(events.TypeSettlement, settlement.ID, pos.UserID, settlement)
+	}
+	return len(positions), nil
 }
 
-func (s *Store) MockFillOrder(orderID string, fillPrice int) error {
-	s.ordersMu.Lock()
-	defer s.ordersMu.Unlock()
-	order, exists := s.orders[orderID]
-	if !exists {
-		return ErrOrderNotFound
-	}
-	now := time.Now().UTC()
-	order.Status = models.OrderStatusFilled
-	order.FilledQuantity = order.Quantity
-	order.FilledPriceCents = fillPrice
-	order.FilledAt = &now
-	order.UpdatedAt = now
-	s.createOrUpdatePosition(order)
-	return nil
+// GetSettlements returns a user's settlements, newest first, optionally
+// filtered by market.
+func (s *Store) GetSettlements(userID, marketTicker string, limit int) []models.Settlement {
+	return s.filterSettlements(userID, marketTicker, limit)
 }
 
-func (s *Store) createOrUpdatePosition(order *models.Order) {
-	s.positionsMu.Lock()
-	defer s.positionsMu.Unlock()
-	var existingPos *models.Position
-	for _, posID := range s.positionsByUser[order.UserID] {
-		pos := s.positions[posID]
-		if pos.MarketTicker == order.MarketTicker && pos.Side == order.Side && pos.ClosedAt == nil {
-			existingPos = pos
-			break
-		}
-	}
-	now := time.Now().UTC()
-	if existingPos != nil {
-		totalCost := existingPos.CostBasisUSD + order.CollateralUSD
-		totalQty := existingPos.Quantity + order.FilledQuantity
-		existingPos.Quantity = totalQty
-		existingPos.CostBasisUSD = totalCost
-		existingPos.AvgPriceCents = int(totalCost * 100 / float64(totalQty))
-		existingPos.UpdatedAt = now
-	} else {
-		pos := &models.Position{
-			ID: s.generateID("pos"), UserID: order.UserID, MarketTicker: order.MarketTicker,
-			EventTicker: order.EventTicker, Side: order.Side, Quantity: order.FilledQuantity,
-			AvgPriceCents: order.FilledPriceCents, CostBasisUSD: order.CollateralUSD, CreatedAt: now, UpdatedAt: now,
-		}
-		s.positions[pos.ID] = pos
-		s.positionsByUser[order.UserID] = append(s.positionsByUser[order.UserID], pos.ID)
-	}
+// GetAllSettlements returns settlements across all users, newest first.
+func (s *Store) GetAllSettlements(marketTicker string, limit int) []models.Settlement {
+	return s.filterSettlements("", marketTicker, limit)
 }
 
-func (s *Store) GetOrders(userID string, status *models.OrderStatus, limit int) ([]models.Order, error) {
-	s.ordersMu.RLock()
-	defer s.ordersMu.RUnlock()
-	orderIDs := s.ordersByUser[userID]
-	var result []models.Order
-	for i := len(orderIDs) - 1; i >= 0 && len(result) < limit; i-- {
-		order := s.orders[orderIDs[i]]
-		if status != nil && order.Status != *status {
+func (s *Store) filterSettlements(userID, marketTicker string, limit int) []models.Settlement {
+	s.settlementsMu.RLock()
+	defer s.settlementsMu.RUnlock()
+	var result []models.Settlement
+	for i := len(s.settlements) - 1; i >= 0 && len(result) < limit; i-- {
+		settlement := s.settlements[i]
+		if userID != "" && settlement.UserID != userID {
 			continue
 		}
-		result = append(result, *order)
+		if marketTicker != "" && settlement.MarketTicker != marketTicker {
+			continue
+		}
+		result = append(result, settlement)
 	}
-	return result, nil
+	return result
 }
 
-func (s *Store) GetAllOrders(limit int) []models.Order {
-	s.ordersMu.RLock()
-	defer s.ordersMu.RUnlock()
-	var result []models.Order
+// cancelPendingOrders cancels unfilled orders in a market and releases
+// their collateral.
+func (s *Store) cancelPendingOrders(marketTicker, reason string) {
+	s.closeOrdersWhere(func(order *models.Order) bool {
+		return order.MarketTicker == marketTicker
+	}, models.OrderStatusCancelled, "Order cancelled: "+reason)
+}
+
+// ExpireOrders expires the resting orders whose ExpiresAt is at or before
+// now and releases their unfilled collateral and fees. Returns the number
+// expired.
+// CP 9: An order never rests past its expiry; CP 11: its reserve is freed.
+func (s *Store) ExpireOrders(now time.Time) int {
+	return s.closeOrdersWhere(func(order *models.Order) bool {
+		return order.ExpiresAt != nil && !order.ExpiresAt.After(now)
+	}, models.OrderStatusExpired, "Order expired")
+}
+
+// closeOrdersWhere moves the resting orders that match to status, which is
+// cancelled or expired, and releases their unfilled reserve, all under one
+// lock scope. desc is the audit description. A user whose locked funds do
+// not cover their orders' reserve keeps them open and is logged. Returns
+// the number closed.
+func (s *Store) closeOrdersWhere(match func(*models.Order) bool, status models.OrderStatus, desc string) int {
+	unlock := s.lockBook()
+	defer unlock()
+	byUser := make(map[string][]*models.Order)
 	for _, order := range s.orders {
-		result = append(result, *order)
-		if len(result) >= limit {
-			break
+		if _, exists := s.wallets[order.UserID]; exists && match(order) && canTransitionOrder(order.Status, status) {
+			byUser[order.UserID] = append(byUser[order.UserID], order)
 		}
 	}
-	return result
-}
 
-func (s *Store) GetPositions(userID string) ([]models.Position, error) {
-	s.positionsMu.RLock()
-	defer s.positionsMu.RUnlock()
-	posIDs := s.positionsByUser[userID]
-	var result []models.Position
-	for _, posID := range posIDs {
-		pos := s.positions[posID]
-		if pos.ClosedAt == nil {
-			result = append(result, *pos)
+	now := time.Now().UTC()
+	closed := 0
+	for userID, orders := range byUser {
+		var release models.Money
+		for _, order := range orders {
+			release += unfilledReserve(order)
+		}
+		if err := s.checkRelease(map[string]models.Money{userID: release}); err != nil {
+			log.Printf("Orders not %s: %v", status, err)
+			continue
+		}
+		wallet := s.wallets[userID]
+		for _, order := range orders {
+			setOrderStatus(order, status, now)
+			s.unlockLocked(wallet, unfilledReserve(order), order.ID)
+			s.LogAudit(order.UserID, models.AuditActionUpdate, "order", order.ID, nil, *order, "", "", desc)
+			closed++
 		}
 	}
-	return result, nil
+	return closed
 }
 
-func (s *Store) GetAllPositions() []models.Position {
-	s.positionsMu.RLock()
-	defer s.positionsMu.RUnlock()
-	var result []models.Position
-	for _, pos := range s.positions {
-		if pos.ClosedAt == nil {
-			result = append(result, *pos)
-		}
+// =============================================================================
+// LIQUIDATION - CP 17: Fitness Standards, CP 11: Financial Integrity
+// =============================================================================
+
+// Mark is a market's current bids, the prices its positions are closed at.
+type Mark struct {
+	YesBid int `json:"yes_bid"`
+	NoBid  int `json:"no_bid"`
+}
+
+// Liquidation summarizes what LiquidateUser unwound.
+type Liquidation struct {
+	UserID          string       `json:"user_id"`
+	OrdersCancelled int          `json:"orders_cancelled"`
+	PositionsClosed int          `json:"positions_closed"`
+	ProceedsUSD     models.Money `json:"proceeds_usd"`
+	PnLUSD          models.Money `json:"pnl_usd"`
+}
+
+// lockBook takes the order, position, wallet and transaction locks, in
+// that order, for changes that must apply to all of them or none. It
+// returns the function that releases them.
+func (s *Store) lockBook() (unlock func()) {
+	s.ordersMu.Lock()
+	s.positionsMu.Lock()
+	s.walletsMu.Lock()
+	s.transactionsMu.Lock()
+	return func() {
+		s.transactionsMu.Unlock()
+		s.walletsMu.Unlock()
+		s.positionsMu.Unlock()
+		s.ordersMu.Unlock()
 	}
-	return result
 }
 
-func (s *Store) GetUserExposure(userID string) float64 {
-	wallet, err := s.GetWallet(userID)
-	if err != nil {
-		return 0
+// LiquidateUser flattens a user's book on behalf of adminID: resting orders
+// are cancelled and their collateral released, then every open position is
+// closed at the bid for its side in marks, or at its average entry price
+// for a market without a mark. Each close is a settlement transaction and a
+// Settlement with result "liquidated" carrying reason. The whole book is
+// unwound under one lock scope, so either all of it is or, for a user
+// without a wallet, none of it. Used when an account is suspended or
+// banned.
+func (s *Store) LiquidateUser(userID string, marks map[string]Mark, reason, adminID, ip string) (*Liquidation, error) {
+	if _, err := s.GetUser(userID); err != nil {
+		return nil, err
+	}
+	unlock := s.lockBook()
+	defer unlock()
+	wallet, exists := s.wallets[userID]
+	if !exists {
+		return nil, ErrWalletNotFound
+	}
+	var release models.Money
+	for _, id := range s.ordersByUser[userID] {
+		if order := s.orders[id]; canTransitionOrder(order.Status, models.OrderStatusCancelled) {
+			release += unfilledReserve(order)
+		}
+	}
+	for _, posID := range s.positionsByUser[userID] {
+		if pos := s.positions[posID]; pos.ClosedAt == nil {
+			release += pos.CostBasisUSD
+		}
+	}
+	if err := s.checkRelease(map[string]models.Money{userID: release}); err != nil {
+		return nil, err
+	}
+
+	result := &Liquidation{UserID: userID}
+	now := time.Now().UTC()
+	for _, id := range s.ordersByUser[userID] {
+		order := s.orders[id]
+		if !setOrderStatus(order, models.OrderStatusCancelled, now) {
+			continue
+		}
+		s.unlockLocked(wallet, unfilledReserve(order), order.ID)
+		s.LogAudit(userID, models.AuditActionUpdate, "order", order.ID, nil, *order, "", "",
+			"Order cancelled: account liquidated: "+reason)
+		result.OrdersCancelled++
 	}
-	return wallet.LockedUSD
+
+	for _, posID := range s.positionsByUser[userID] {
+		pos := s.positions[posID]
+		if pos.ClosedAt != nil {
+			continue
+		}
+		price := pos.AvgPriceCents
+		if mark, ok := marks[pos.MarketTicker]; ok {
+			price = mark.YesBid
+			if pos.Side == models.OrderSideNo {
+				price = mark.NoBid
+			}
+		}
+		pos.CurrentValue = models.Cents(int64(pos.Quantity * price))
+		pos.RealizedPnL += pos.CurrentValue - pos.CostBasisUSD - pos.FeesUSD
+		pos.UnrealizedPnL = 0
+		pos.ClosedAt = &now
+		pos.UpdatedAt = now
+		s.settleLocked(wallet, models.TxTypeSettlement, pos.CostBasisUSD, pos.CurrentValue, pos.ID)
+
+		settlement := models.Settlement{
+			ID: s.generateID("stl"), UserID: userID, PositionID: pos.ID, MarketTicker: pos.MarketTicker,
+			EventTicker: pos.EventTicker, Side: pos.Side, Quantity: pos.Quantity, Result: "liquidated",
+			CostBasisUSD: pos.CostBasisUSD, PayoutUSD: pos.CurrentValue, PnLUSD: pos.RealizedPnL,
+			Reason: reason, SettledAt: now,
+		}
+		s.settlementsMu.Lock()
+		s.settlements = append(s.settlements, settlement)
+		s.settlementsMu.Unlock()
+		s.LogAudit(userID, models.AuditActionSettle, "settlement", settlement.ID, nil, settlement, ip, "",
+			fmt.Sprintf("Position liquidated: %s %d %s at %d¢, proceeds=%s", pos.Side, pos.Quantity, pos.MarketTicker, price, pos.CurrentValue))
+		s.publish(events.TypeSettlement, settlement.ID, userID, settlement)
+		result.PositionsClosed++
+		result.ProceedsUSD += pos.CurrentValue
+		result.PnLUSD += pos.RealizedPnL
+	}
+
+	s.LogAudit(adminID, models.AuditActionUpdate, "user", userID, nil, *result, ip, "",
+		fmt.Sprintf("Account liquidated by %s: %d orders cancelled, %d positions closed: %s",
+			adminID, result.OrdersCancelled, result.PositionsClosed, reason))
+	return result, nil
 }
 
 // =============================================================================
 // COMPLIANCE OPERATIONS - CP 4: Prevention of Market Disruption
 // =============================================================================
 
+// DefaultAlertDedupWindow is how long after an alert's last occurrence a
+// repeat of the same condition is folded into it.
+const DefaultAlertDedupWindow = 15 * time.Minute
+
+// SetAlertDedupWindow sets how long after its last occurrence an open or
+// investigating alert absorbs repeats with the same type, user and market.
+// 0 raises a new alert every time.
+func (s *Store) SetAlertDedupWindow(window time.Duration) {
+	s.alertsMu.Lock()
+	defer s.alertsMu.Unlock()
+	s.alertDedup = window
+}
+
+// CreateComplianceAlert raises an alert. A repeat of an open or
+// investigating alert's type, user and market within the dedup window
+// instead increments its Count and LastSeenAt, keeping the higher severity
+// and latest description; repeats are audited but not published again.
 func (s *Store) CreateComplianceAlert(userID, marketTicker, alertType, severity, description string) *models.ComplianceAlert {
 	s.alertsMu.Lock()
 	defer s.alertsMu.Unlock()
+	now := time.Now().UTC()
+	if existing := s.activeAlert(alertType, userID, marketTicker, now); existing != nil {
+		old := *existing
+		existing.Count = max(existing.Count, 1) + 1
+		existing.LastSeenAt = now
+		existing.Description = description
+		escalated := models.AlertSeverityRank(severity) > models.AlertSeverityRank(existing.Severity)
+		if escalated {
+			existing.Severity = severity
+		}
+		s.LogAudit("system", models.AuditActionUpdate, "alert", existing.ID, old, *existing, "", "",
+			fmt.Sprintf("Compliance alert repeated: %s (%d occurrences)", alertType, existing.Count))
+		alert := *existing
+		// Subscribers saw the alert at its old severity
+		if escalated {
+			s.publish(events.TypeAlert, alert.ID, userID, alert)
+		}
+		return &alert
+	}
+
 	alert := models.ComplianceAlert{
 		ID: s.generateID("alert"), Type: alertType, Severity: severity, UserID: userID,
-		MarketTicker: marketTicker, Description: description, Status: "open", CreatedAt: time.Now().UTC(),
+		MarketTicker: marketTicker, Description: description, Status: models.AlertStatusOpen,
+		CreatedAt: now, LastSeenAt: now, Count: 1,
 	}
 	s.alerts = append(s.alerts, alert)
+	metrics.AlertsCreated.WithLabelValues(alertType).Inc()
+	s.LogAudit("system", models.AuditActionCreate, "alert", alert.ID, nil, alert, "", "",
+		fmt.Sprintf("Compliance alert raised: %s (%s)", alertType, severity))
+	s.publish(events.TypeAlert, alert.ID, userID, alert)
 	return &alert
 }
 
+// activeAlert returns the open or investigating alert for the condition
+// last seen within the dedup window, or nil. Callers hold alertsMu.
+func (s *Store) activeAlert(alertType, userID, marketTicker string, now time.Time) *models.ComplianceAlert {
+	if s.alertDedup <= 0 {
+		return nil
+	}
+	for i := len(s.alerts) - 1; i >= 0; i-- {
+		alert := &s.alerts[i]
+		if alert.Type != alertType || alert.UserID != userID || alert.MarketTicker != marketTicker {
+			continue
+		}
+		if !models.AlertActive(alert.Status) {
+			continue
+		}
+		lastSeen := alert.LastSeenAt
+		if lastSeen.IsZero() {
+			lastSeen = alert.CreatedAt
+		}
+		if now.Sub(lastSeen) <= s.alertDedup {
+			return alert
+		}
+	}
+	return nil
+}
+
 func (s *Store) GetComplianceAlerts(status, severity string, limit int) []models.ComplianceAlert {
+	alerts, _ := s.SearchAlerts(AlertFilter{Status: status, Severity: severity}, 0, limit)
+	return alerts
+}
+
+// AlertFilter selects compliance alerts for operator searches. Empty fields
+// match all; Since is inclusive and Until exclusive on CreatedAt.
+type AlertFilter struct {
+	Status       string
+	Severity     string
+	Type         string
+	UserID       string
+	MarketTicker string
+	Since        time.Time
+	Until        time.Time
+	BySeverity   bool // Order by models.SortAlertsBySeverity instead of newest first
+}
+
+// SearchAlerts returns the alerts matching filter, newest first, skipping
+// offset and returning at most limit, along with the total number of
+// matches. With BySeverity the order is applied before paging, so newer
+// low-severity alerts cannot push an older critical one off the first page.
+// CP 4: Officers can pull every alert of a kind for a user or period.
+func (s *Store) SearchAlerts(filter AlertFilter, offset, limit int) ([]models.ComplianceAlert, int) {
 	s.alertsMu.RLock()
-	defer s.alertsMu.RUnlock()
-	var result []models.ComplianceAlert
-	for i := len(s.alerts) - 1; i >= 0 && len(result) < limit; i-- {
+	matched := []models.ComplianceAlert{}
+	for i := len(s.alerts) - 1; i >= 0; i-- {
 		alert := s.alerts[i]
-		if status != "" && alert.Status != status {
+		if filter.Status != "" && alert.Status != filter.Status {
+			continue
+		}
+		if filter.Severity != "" && alert.Severity != filter.Severity {
+			continue
+		}
+		if filter.Type != "" && alert.Type != filter.Type {
+			continue
+		}
+		if filter.UserID != "" && alert.UserID != filter.UserID {
 			continue
 		}
-		if severity != "" && alert.Severity != severity {
+		if filter.MarketTicker != "" && alert.MarketTicker != filter.MarketTicker {
 			continue
 		}
-		result = append(result, alert)
+		if !filter.Since.IsZero() && alert.CreatedAt.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !alert.CreatedAt.Before(filter.Until) {
+			continue
+		}
+		matched = append(matched, alert)
+	}
+	s.alertsMu.RUnlock()
+
+	if filter.BySeverity {
+		models.SortAlertsBySeverity(matched)
+	}
+	total := len(matched)
+	if offset >= total {
+		return []models.ComplianceAlert{}, total
+	}
+	matched = matched[offset:]
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total
+}
+
+// GetUserAlerts returns the user's alerts newest first, in any status.
+func (s *Store) GetUserAlerts(userID string, limit int) []models.ComplianceAlert {
+	s.alertsMu.RLock()
+	defer s.alertsMu.RUnlock()
+	var result []models.ComplianceAlert
+	for i := len(s.alerts) - 1; i >= 0 && len(result) < limit; i-- {
+		if s.alerts[i].UserID == userID {
+			result = append(result, s.alerts[i])
+		}
 	}
 	return result
 }
 
+// ResolveAlert closes an alert under investigation as resolved. It is
+// UpdateAlertStatus to resolved, so an alert that has not been investigated,
+// or is already closed, is refused with ErrInvalidAlertStatus.
 func (s *Store) ResolveAlert(alertID, resolvedBy, notes string) error {
+	_, err := s.UpdateAlertStatus(alertID, models.AlertStatusResolved, resolvedBy, notes)
+	return err
+}
+
+// AssignAlert makes operator the owner of an alert, whether claiming an
+// unowned alert or taking it over from another operator. Each change of
+// owner is audited and published. Resolved and escalated alerts cannot be
+// assigned.
+// CP 4: One accountable officer per alert, so reviews are not duplicated.
+func (s *Store) AssignAlert(alertID, operator, ip string) (*models.ComplianceAlert, error) {
+	s.alertsMu.Lock()
+	defer s.alertsMu.Unlock()
+	for i := range s.alerts {
+		alert := &s.alerts[i]
+		if alert.ID != alertID {
+			continue
+		}
+		if alert.Status == models.AlertStatusResolved || alert.Status == models.AlertStatusEscalated {
+			return nil, ErrAlertClosed
+		}
+		if alert.AssignedTo == operator {
+			assigned := *alert
+			return &assigned, nil
+		}
+
+		old := *alert
+		now := time.Now().UTC()
+		alert.AssignedTo = operator
+		alert.AssignedAt = &now
+		description := fmt.Sprintf("Compliance alert claimed by %s", operator)
+		if old.AssignedTo != "" {
+			description = fmt.Sprintf("Compliance alert reassigned from %s to %s", old.AssignedTo, operator)
+		}
+		s.LogAudit(operator, models.AuditActionUpdate, "alert", alertID, old, *alert, ip, "", description)
+		assigned := *alert
+		s.publish(events.TypeAlertAssigned, alertID, assigned.UserID, assigned)
+		return &assigned, nil
+	}
+	return nil, ErrAlertNotFound
+}
+
+// alertTransitions lists the statuses each alert status may move to.
+var alertTransitions = map[string][]string{
+	models.AlertStatusOpen:          {models.AlertStatusInvestigating},
+	models.AlertStatusInvestigating: {models.AlertStatusResolved, models.AlertStatusEscalated},
+}
+
+// UpdateAlertStatus moves an alert through the review workflow
+// (open -> investigating -> resolved/escalated). Every transition is audited.
+// CP 4: Alerts are investigated before they are closed out.
+func (s *Store) UpdateAlertStatus(alertID, newStatus, by, notes string) (*models.ComplianceAlert, error) {
 	s.alertsMu.Lock()
 	defer s.alertsMu.Unlock()
 	for i := range s.alerts {
-		if s.alerts[i].ID == alertID {
+		alert := &s.alerts[i]
+		if alert.ID != alertID {
+			continue
+		}
+		allowed := false
+		for _, next := range alertTransitions[alert.Status] {
+			if next == newStatus {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return nil, ErrInvalidAlertStatus
+		}
+
+		old := *alert
+		alert.Status = newStatus
+		if notes != "" {
+			alert.Notes = notes
+		}
+		if newStatus == models.AlertStatusResolved || newStatus == models.AlertStatusEscalated {
 			now := time.Now().UTC()
-			s.alerts[i].Status = "resolved"
-			s.alerts[i].ResolvedAt = &now
-			s.alerts[i].ResolvedBy = resolvedBy
-			s.alerts[i].Notes = notes
-			return nil
+			alert.ResolvedAt = &now
+			alert.ResolvedBy = by
 		}
+		s.LogAudit(by, models.AuditActionUpdate, "alert", alertID, old, *alert, "", "",
+			fmt.Sprintf("Compliance alert %s -> %s: %s", old.Status, newStatus, notes))
+		updated := *alert
+		s.publish(events.TypeAlert, alertID, updated.UserID, updated)
+		return &updated, nil
 	}
-	return errors.New("alert not found")
+	return nil, ErrAlertNotFound
 }
 
-func (s *Store) InitiateEmergencyHalt(marketTicker, reason, initiatedBy string) *models.EmergencyHalt {
+// globalHaltKey is the halts map key for an exchange-wide halt.
+const globalHaltKey = "GLOBAL"
+
+func haltKey(marketTicker string) string {
+	if marketTicker == "" {
+		return globalHaltKey
+	}
+	return marketTicker
+}
+
+// InitiateEmergencyHalt halts one market, or every market when marketTicker
+// is empty. A positive duration sets EndsAt, after which the halt lapses on
+// its own; 0 halts until LiftEmergencyHalt. A halt already in effect that
+// would outlast the new one is kept and returned instead.
+func (s *Store) InitiateEmergencyHalt(marketTicker, reason, initiatedBy string, duration time.Duration) *models.EmergencyHalt {
 	s.haltsMu.Lock()
 	defer s.haltsMu.Unlock()
-	key := marketTicker
-	if key == "" {
-		key = "GLOBAL"
-	}
+	key := haltKey(marketTicker)
 	halt := &models.EmergencyHalt{
-		ID: s.generateID("halt"), MarketTicker: marketTicker, Reason: reason,
-		InitiatedBy: initiatedBy, StartedAt: time.Now().UTC(), IsActive: true,
+		ID: s.generateID("halt"), MarketTicker: marketTicker, Global: marketTicker == "", Reason: reason,
+		InitiatedBy: initiatedBy, StartedAt: s.haltClock().UTC(), IsActive: true,
+	}
+	if duration > 0 {
+		endsAt := halt.StartedAt.Add(duration)
+		halt.EndsAt = &endsAt
+	}
+	if existing, exists := s.halts[key]; exists && haltInEffect(existing, halt.StartedAt) && endsLater(existing, halt) {
+		return existing
 	}
 	s.halts[key] = halt
-	s.LogAudit("system", models.AuditActionHalt, "halt", halt.ID, nil, halt, "", "",
+	s.LogAudit(initiatedBy, models.AuditActionHalt, "halt", halt.ID, nil, halt, "", "",
 		fmt.Sprintf("Emergency halt initiated: %s - %s", key, reason))
+	s.publish(events.TypeHalt, halt.ID, "", *halt)
 	return halt
 }
 
+// IsGlobalHalt reports whether an exchange-wide halt is in effect.
+func (s *Store) IsGlobalHalt() bool {
+	s.haltsMu.RLock()
+	defer s.haltsMu.RUnlock()
+	return s.globalHaltActive()
+}
+
+// haltInEffect reports whether halt is active and its EndsAt, if any, has
+// not passed. Lapsed halts stay IsActive until ExpireHalts sweeps them.
+func haltInEffect(halt *models.EmergencyHalt, now time.Time) bool {
+	return halt.IsActive && (halt.EndsAt == nil || now.Before(*halt.EndsAt))
+}
+
+// endsLater reports whether a outlasts b. An open-ended halt outlasts any
+// timed one.
+func endsLater(a, b *models.EmergencyHalt) bool {
+	if a.EndsAt == nil {
+		return b.EndsAt != nil
+	}
+	return b.EndsAt != nil && a.EndsAt.After(*b.EndsAt)
+}
+
+// globalHaltActive requires haltsMu to be held.
+func (s *Store) globalHaltActive() bool {
+	halt, exists := s.halts[globalHaltKey]
+	return exists && haltInEffect(halt, s.haltClock())
+}
+
+// IsTradingHalted reports whether marketTicker is halted, either directly or
+// by a global halt.
 func (s *Store) IsTradingHalted(marketTicker string) bool {
 	s.haltsMu.RLock()
 	defer s.haltsMu.RUnlock()
-	if halt, exists := s.halts["GLOBAL"]; exists && halt.IsActive {
+	if s.globalHaltActive() {
 		return true
 	}
-	if halt, exists := s.halts[marketTicker]; exists && halt.IsActive {
-		return true
+	if marketTicker == "" {
+		return false
 	}
-	return false
+	halt, exists := s.halts[marketTicker]
+	return exists && haltInEffect(halt, s.haltClock())
 }
 
-func (s *Store) LiftEmergencyHalt(marketTicker string) error {
+// LiftEmergencyHalt lifts the halt on one market, or the global halt when
+// marketTicker is empty. Lifting the global halt leaves market halts active.
+// The lift is audited under liftedBy.
+func (s *Store) LiftEmergencyHalt(marketTicker, liftedBy string) error {
 	s.haltsMu.Lock()
 	defer s.haltsMu.Unlock()
-	key := marketTicker
-	if key == "" {
-		key = "GLOBAL"
-	}
-	if halt, exists := s.halts[key]; exists {
+	key := haltKey(marketTicker)
+	if halt, exists := s.halts[key]; exists && halt.IsActive {
+		old := *halt
 		halt.IsActive = false
-		now := time.Now().UTC()
+		now := s.haltClock().UTC()
 		halt.EndsAt = &now
+		s.LogAudit(liftedBy, models.AuditActionUpdate, "halt", halt.ID, old, *halt, "", "",
+			fmt.Sprintf("Emergency halt lifted: %s", key))
+		s.publish(events.TypeHalt, halt.ID, "", *halt)
 	}
 	return nil
 }
 
+// ExpireHalts marks halts whose EndsAt is at or before now as inactive and
+// audits each one. Returns the number expired.
+func (s *Store) ExpireHalts(now time.Time) int {
+	s.haltsMu.Lock()
+	defer s.haltsMu.Unlock()
+	expired := 0
+	for key, halt := range s.halts {
+		if !halt.IsActive || halt.EndsAt == nil || now.Before(*halt.EndsAt) {
+			continue
+		}
+		old := *halt
+		halt.IsActive = false
+		s.LogAudit("system", models.AuditActionUpdate, "halt", halt.ID, old, *halt, "", "",
+			fmt.Sprintf("Emergency halt expired: %s", key))
+		s.publish(events.TypeHalt, halt.ID, "", *halt)
+		expired++
+	}
+	return expired
+}
+
+// GetActiveHalts returns copies of the halts in effect: the global halt
+// first (with Global set), then market halts by ticker.
 func (s *Store) GetActiveHalts() []*models.EmergencyHalt {
 	s.haltsMu.RLock()
 	defer s.haltsMu.RUnlock()
+	now := s.haltClock()
 	var result []*models.EmergencyHalt
-	for _, halt := range s.halts {
-		if halt.IsActive {
-			result = append(result, halt)
+	for key, halt := range s.halts {
+		if haltInEffect(halt, now) {
+			h := *halt
+			h.Global = key == globalHaltKey
+			result = append(result, &h)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Global != result[j].Global {
+			return result[i].Global
+		}
+		return result[i].MarketTicker < result[j].MarketTicker
+	})
 	return result
 }
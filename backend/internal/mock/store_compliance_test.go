@@ -0,0 +1,425 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_ResolveAlertIsAudited(t *testing.T) {
+	s := NewStore()
+	alert := s.CreateComplianceAlert("user_1", "FED-24DEC", "wash_trade", "high", "test alert")
+	if findAudit(s, "alert", alert.ID, models.AuditActionCreate) == nil {
+		t.Error("expected audit entry for alert creation")
+	}
+
+	// CP 4: An alert is investigated before it is resolved, and resolved once
+	if err := s.ResolveAlert(alert.ID, "compliance@example.com", "unreviewed"); !errors.Is(err, ErrInvalidAlertStatus) {
+		t.Errorf("resolving an open alert: err = %v, want ErrInvalidAlertStatus", err)
+	}
+	resolveAlert(t, s, alert.ID, "compliance@example.com", "false positive")
+	if err := s.ResolveAlert(alert.ID, "compliance@example.com", "again"); !errors.Is(err, ErrInvalidAlertStatus) {
+		t.Errorf("resolving a resolved alert: err = %v, want ErrInvalidAlertStatus", err)
+	}
+	if err := s.ResolveAlert("alert_missing", "compliance@example.com", ""); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("missing alert: err = %v, want ErrAlertNotFound", err)
+	}
+	entry := findAudit(s, "alert", alert.ID, models.AuditActionUpdate)
+	if entry == nil {
+		t.Fatal("expected update audit entry for alert resolution")
+	}
+	if entry.UserID != "compliance@example.com" {
+		t.Errorf("audit user = %q, want resolver", entry.UserID)
+	}
+	var before, after models.ComplianceAlert
+	json.Unmarshal([]byte(entry.OldValue), &before)
+	json.Unmarshal([]byte(entry.NewValue), &after)
+	if before.Status != "investigating" || after.Status != "resolved" || after.Notes != "false positive" {
+		t.Errorf("audit before/after = %s/%s notes %q", before.Status, after.Status, after.Notes)
+	}
+}
+
+func TestStore_LiftEmergencyHaltIsAudited(t *testing.T) {
+	s := NewStore()
+	halt := s.InitiateEmergencyHalt("FED-24DEC", "test", "ops", 0)
+	if err := s.LiftEmergencyHalt("FED-24DEC", "ops_2"); err != nil {
+		t.Fatal(err)
+	}
+	entry := findAudit(s, "halt", halt.ID, models.AuditActionUpdate)
+	if entry == nil {
+		t.Fatal("expected audit entry for halt lift")
+	}
+	if entry.UserID != "ops_2" {
+		t.Errorf("lift audited as %q, want ops_2", entry.UserID)
+	}
+	var after models.EmergencyHalt
+	json.Unmarshal([]byte(entry.NewValue), &after)
+	if after.IsActive || after.EndsAt == nil {
+		t.Errorf("audited halt still active: %+v", after)
+	}
+}
+
+func TestStore_GlobalHalt(t *testing.T) {
+	s := NewStore()
+	s.InitiateEmergencyHalt("FED-24DEC", "market issue", "ops", 0)
+	if s.IsGlobalHalt() {
+		t.Fatal("market halt should not be global")
+	}
+	if !s.IsTradingHalted("FED-24DEC") || s.IsTradingHalted("CPI-24DEC") || s.IsTradingHalted("") {
+		t.Error("only FED-24DEC should be halted")
+	}
+
+	s.InitiateEmergencyHalt("", "exchange outage", "ops", 0)
+	if !s.IsGlobalHalt() || !s.IsTradingHalted("CPI-24DEC") || !s.IsTradingHalted("") {
+		t.Error("global halt should halt every market")
+	}
+
+	halts := s.GetActiveHalts()
+	if len(halts) != 2 || !halts[0].Global || halts[0].MarketTicker != "" || halts[1].Global {
+		t.Fatalf("active halts = %+v, want global first then FED-24DEC", halts)
+	}
+
+	if err := s.LiftEmergencyHalt("", "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsGlobalHalt() || s.IsTradingHalted("CPI-24DEC") {
+		t.Error("global halt should be lifted")
+	}
+	if !s.IsTradingHalted("FED-24DEC") {
+		t.Error("lifting the global halt should leave market halts in place")
+	}
+}
+
+func TestStore_TimedHaltKeepsLongerHalt(t *testing.T) {
+	s := NewStore()
+	manual := s.InitiateEmergencyHalt("FED-24DEC", "operator review", "ops", 0)
+	if got := s.InitiateEmergencyHalt("FED-24DEC", "circuit breaker", "circuit_breaker", time.Minute); got != manual {
+		t.Fatalf("timed halt replaced the open-ended one: %+v", got)
+	}
+
+	timed := s.InitiateEmergencyHalt("CPI-24DEC", "circuit breaker", "circuit_breaker", time.Hour)
+	if got := s.InitiateEmergencyHalt("CPI-24DEC", "circuit breaker", "circuit_breaker", time.Minute); got != timed {
+		t.Errorf("shorter halt replaced a longer one: %+v", got)
+	}
+	if got := s.InitiateEmergencyHalt("CPI-24DEC", "operator review", "ops", 0); got == timed || got.EndsAt != nil {
+		t.Errorf("open-ended halt should replace the timed one, got %+v", got)
+	}
+}
+
+func TestStore_TimedHaltLapses(t *testing.T) {
+	s := NewStore()
+	now := time.Now()
+	s.haltClock = func() time.Time { return now }
+	halt := s.InitiateEmergencyHalt("FED-24DEC", "circuit breaker", "circuit_breaker", time.Minute)
+	if halt.EndsAt == nil || !halt.EndsAt.Equal(halt.StartedAt.Add(time.Minute)) {
+		t.Fatalf("EndsAt = %v, want a minute after StartedAt", halt.EndsAt)
+	}
+	if !s.IsTradingHalted("FED-24DEC") || len(s.GetActiveHalts()) != 1 {
+		t.Fatal("timed halt should be in effect before EndsAt")
+	}
+	if n := s.ExpireHalts(now); n != 0 {
+		t.Errorf("expired %d halts before EndsAt", n)
+	}
+
+	now = now.Add(time.Minute)
+	if s.IsTradingHalted("FED-24DEC") || len(s.GetActiveHalts()) != 0 {
+		t.Error("halt should lapse once EndsAt passes, even before the sweep")
+	}
+	if n := s.ExpireHalts(now); n != 1 {
+		t.Errorf("ExpireHalts = %d, want 1", n)
+	}
+	entry := findAudit(s, "halt", halt.ID, models.AuditActionUpdate)
+	if entry == nil {
+		t.Fatal("expected audit entry for halt expiry")
+	}
+	var after models.EmergencyHalt
+	json.Unmarshal([]byte(entry.NewValue), &after)
+	if after.IsActive {
+		t.Error("audited halt still active")
+	}
+	if n := s.ExpireHalts(now); n != 0 {
+		t.Errorf("second sweep expired %d, want 0", n)
+	}
+}
+
+func TestStore_UntimedHaltDoesNotLapse(t *testing.T) {
+	s := NewStore()
+	s.InitiateEmergencyHalt("", "exchange outage", "ops", 0)
+	if n := s.ExpireHalts(time.Now().Add(24 * time.Hour)); n != 0 || !s.IsGlobalHalt() {
+		t.Errorf("untimed halt expired (n=%d)", n)
+	}
+}
+
+func TestStore_PublishesDomainEvents(t *testing.T) {
+	s := NewStore()
+	bus := events.NewBus(8)
+	s.SetEventBus(bus)
+	halts := bus.Subscribe(events.TypeHalt)
+	alerts := bus.Subscribe(events.TypeAlert)
+
+	halt := s.InitiateEmergencyHalt("FED-24DEC", "spike", "ops", 0)
+	s.LiftEmergencyHalt("FED-24DEC", "ops_2")
+	alert := s.CreateComplianceAlert("user-1", "FED-24DEC", "wash_trading", "high", "test")
+
+	for _, wantActive := range []bool{true, false} {
+		e := <-halts
+		got, ok := e.Data.(models.EmergencyHalt)
+		if e.EntityID != halt.ID || !ok || got.IsActive != wantActive {
+			t.Errorf("halt event = %+v, want IsActive=%v", e, wantActive)
+		}
+	}
+	if e := <-alerts; e.EntityID != alert.ID || e.UserID != "user-1" {
+		t.Errorf("alert event = %+v", e)
+	}
+}
+
+func TestStore_AuditEventsRecordsPublishedEvents(t *testing.T) {
+	s := NewStore()
+	bus := events.NewBus(8)
+	s.SetEventBus(bus)
+	ch := bus.Subscribe(events.All)
+	done := make(chan struct{})
+	go func() {
+		s.AuditEvents(ch)
+		close(done)
+	}()
+
+	halt := s.InitiateEmergencyHalt("FED-24DEC", "spike", "ops", 0)
+	alert := s.CreateComplianceAlert("user-1", "FED-24DEC", "wash_trading", "high", "test")
+	bus.Close()
+	<-done
+
+	if entry := findAudit(s, "halt", halt.ID, models.AuditActionEvent); entry == nil || entry.UserID != "system" {
+		t.Errorf("halt event audit = %+v", entry)
+	}
+	if entry := findAudit(s, "alert", alert.ID, models.AuditActionEvent); entry == nil || entry.UserID != "user-1" {
+		t.Errorf("alert event audit = %+v", entry)
+	}
+}
+
+func TestStore_PublishDoesNotBlockOnIdleSubscriber(t *testing.T) {
+	s := NewStore()
+	bus := events.NewBus(1)
+	s.SetEventBus(bus)
+	bus.Subscribe(events.All) // never read
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 20; i++ {
+			s.CreateComplianceAlert(fmt.Sprintf("user-%d", i), "FED-24DEC", "test", "low", "test")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("store blocked publishing to an idle subscriber")
+	}
+	if len(s.GetComplianceAlerts("", "", 100)) != 20 {
+		t.Error("alerts were not all recorded")
+	}
+}
+
+func TestStore_UpdateAlertStatusTransitions(t *testing.T) {
+	s := NewStore()
+	alert := s.CreateComplianceAlert("user_1", "FED-24DEC", "spoofing", "high", "test alert")
+
+	if _, err := s.UpdateAlertStatus(alert.ID, models.AlertStatusResolved, "ops", ""); !errors.Is(err, ErrInvalidAlertStatus) {
+		t.Errorf("open -> resolved: expected ErrInvalidAlertStatus, got %v", err)
+	}
+	if _, err := s.UpdateAlertStatus(alert.ID, models.AlertStatusInvestigating, "ops", "looking"); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := s.UpdateAlertStatus(alert.ID, models.AlertStatusResolved, "ops", "benign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ResolvedBy != "ops" || updated.ResolvedAt == nil || updated.Notes != "benign" {
+		t.Errorf("resolved alert = %+v", updated)
+	}
+
+	if _, err := s.UpdateAlertStatus(alert.ID, models.AlertStatusInvestigating, "ops", ""); !errors.Is(err, ErrInvalidAlertStatus) {
+		t.Errorf("resolved -> investigating: expected ErrInvalidAlertStatus, got %v", err)
+	}
+	if _, err := s.UpdateAlertStatus("alert_missing", models.AlertStatusInvestigating, "ops", ""); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("expected ErrAlertNotFound, got %v", err)
+	}
+
+	transitions := 0
+	for _, entry := range s.GetAllAuditLogs(time.Time{}, 1000) {
+		if entry.EntityType == "alert" && entry.EntityID == alert.ID && entry.Action == models.AuditActionUpdate {
+			transitions++
+		}
+	}
+	if transitions != 2 {
+		t.Errorf("expected 2 audited transitions, got %d", transitions)
+	}
+}
+
+func TestStore_RepeatedAlertsAreDeduplicated(t *testing.T) {
+	s := NewStore()
+	var first *models.ComplianceAlert
+	for i := 0; i < 10; i++ {
+		alert := s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "medium", fmt.Sprintf("attempt %d", i+1))
+		if first == nil {
+			first = alert
+		}
+	}
+
+	alerts := s.GetComplianceAlerts("", "", 100)
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.ID != first.ID || alert.Count != 10 || alert.Description != "attempt 10" {
+		t.Errorf("alert = %+v, want %s with count 10 and the latest description", alert, first.ID)
+	}
+	if !alert.LastSeenAt.After(alert.CreatedAt) {
+		t.Errorf("last seen %v not after created %v", alert.LastSeenAt, alert.CreatedAt)
+	}
+
+	// A more severe repeat raises the alert's severity
+	s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "critical", "breach")
+	if alerts := s.GetComplianceAlerts("", "critical", 100); len(alerts) != 1 || alerts[0].Count != 11 {
+		t.Errorf("after critical repeat = %+v, want one critical alert with count 11", alerts)
+	}
+
+	// Other users and markets, resolved alerts and a disabled window get
+	// their own alerts
+	s.CreateComplianceAlert("user-2", "FED-24DEC", "position_limit", "medium", "other user")
+	s.CreateComplianceAlert("user-1", "CPI-24NOV", "position_limit", "medium", "other market")
+	resolveAlert(t, s, first.ID, "ops", "reviewed")
+	s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "medium", "after resolution")
+	s.SetAlertDedupWindow(0)
+	s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "medium", "undeduplicated")
+	if got := len(s.GetComplianceAlerts("", "", 100)); got != 5 {
+		t.Errorf("got %d alerts, want 5", got)
+	}
+}
+
+func TestStore_AlertRepeatPublishesOnlyEscalation(t *testing.T) {
+	s := NewStore()
+	bus := events.NewBus(8)
+	s.SetEventBus(bus)
+	alerts := bus.Subscribe(events.TypeAlert)
+	s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "medium", "first")
+	s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "medium", "repeat")
+	s.CreateComplianceAlert("user-1", "FED-24DEC", "position_limit", "high", "escalated")
+
+	var severities []string
+	for len(alerts) > 0 {
+		severities = append(severities, (<-alerts).Data.(models.ComplianceAlert).Severity)
+	}
+	if len(severities) != 2 || severities[0] != "medium" || severities[1] != "high" {
+		t.Errorf("published severities = %v, want [medium high]", severities)
+	}
+}
+
+func TestStore_AlertRepeatOutsideWindowIsNew(t *testing.T) {
+	s := NewStore()
+	s.SetAlertDedupWindow(time.Minute)
+	first := s.CreateComplianceAlert("user-1", "FED-24DEC", "rate_limit", "low", "burst")
+
+	s.alertsMu.Lock()
+	s.alerts[0].LastSeenAt = s.alerts[0].LastSeenAt.Add(-2 * time.Minute)
+	s.alertsMu.Unlock()
+
+	if again := s.CreateComplianceAlert("user-1", "FED-24DEC", "rate_limit", "low", "burst"); again.ID == first.ID {
+		t.Errorf("repeat after the window folded into %s", first.ID)
+	}
+}
+
+func TestStore_AssignAlertClaimAndReassign(t *testing.T) {
+	s := NewStore()
+	bus := events.NewBus(4)
+	s.SetEventBus(bus)
+	assigned := bus.Subscribe(events.TypeAlertAssigned)
+	alert := s.CreateComplianceAlert("user_1", "FED-24DEC", "wash_trade", "high", "test alert")
+
+	claimed, err := s.AssignAlert(alert.ID, "ops_1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed.AssignedTo != "ops_1" || claimed.AssignedAt == nil {
+		t.Errorf("claimed alert = %+v, want owned by ops_1", claimed)
+	}
+	entry := findAudit(s, "alert", alert.ID, models.AuditActionUpdate)
+	if entry == nil || entry.UserID != "ops_1" || entry.IPAddress != "10.0.0.1" || entry.Description != "Compliance alert claimed by ops_1" {
+		t.Fatalf("claim audit = %+v", entry)
+	}
+	select {
+	case e := <-assigned:
+		if e.EntityID != alert.ID || e.Data.(models.ComplianceAlert).AssignedTo != "ops_1" {
+			t.Errorf("assignment event = %+v", e)
+		}
+	default:
+		t.Error("no alert_assigned event published")
+	}
+
+	reassigned, err := s.AssignAlert(alert.ID, "ops_2", "10.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reassigned.AssignedTo != "ops_2" {
+		t.Errorf("reassigned to %q, want ops_2", reassigned.AssignedTo)
+	}
+	entry = findAudit(s, "alert", alert.ID, models.AuditActionUpdate)
+	if entry == nil || entry.Description != "Compliance alert reassigned from ops_1 to ops_2" {
+		t.Errorf("reassignment audit = %+v", entry)
+	}
+
+	if _, err := s.AssignAlert("alert_missing", "ops_1", ""); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("missing alert: err = %v, want ErrAlertNotFound", err)
+	}
+	resolveAlert(t, s, alert.ID, "ops_2", "done")
+	if _, err := s.AssignAlert(alert.ID, "ops_1", ""); !errors.Is(err, ErrAlertClosed) {
+		t.Errorf("resolved alert: err = %v, want ErrAlertClosed", err)
+	}
+}
+
+func TestStore_SearchAlertsCombinesFilters(t *testing.T) {
+	s := NewStore()
+	s.SetAlertDedupWindow(0)
+	weekAgo := time.Now().UTC().AddDate(0, 0, -7)
+	backdate := map[string]time.Time{}
+	raise := func(userID, alertType string, at time.Time) string {
+		alert := s.CreateComplianceAlert(userID, "FED-24DEC", alertType, "high", "test")
+		backdate[alert.ID] = at
+		return alert.ID
+	}
+	old := raise("user-x", "spoofing", weekAgo.Add(-24*time.Hour))
+	first := raise("user-x", "spoofing", weekAgo.Add(time.Hour))
+	raise("user-x", "wash_trade", weekAgo.Add(2*time.Hour))
+	raise("user-y", "spoofing", weekAgo.Add(3*time.Hour))
+	second := raise("user-x", "spoofing", weekAgo.Add(4*time.Hour))
+	s.alertsMu.Lock()
+	for i := range s.alerts {
+		s.alerts[i].CreatedAt = backdate[s.alerts[i].ID]
+	}
+	s.alertsMu.Unlock()
+
+	filter := AlertFilter{Type: "spoofing", UserID: "user-x", Since: weekAgo, Until: time.Now().UTC()}
+	alerts, total := s.SearchAlerts(filter, 0, 10)
+	if total != 2 || len(alerts) != 2 || alerts[0].ID != second || alerts[1].ID != first {
+		t.Fatalf("search = %d %+v, want %s then %s", total, alerts, second, first)
+	}
+
+	// Paging keeps the total and the newest-first order
+	if page, total := s.SearchAlerts(filter, 1, 1); total != 2 || len(page) != 1 || page[0].ID != first {
+		t.Errorf("second page = %d %+v, want %s", total, page, first)
+	}
+	if page, total := s.SearchAlerts(filter, 5, 1); total != 2 || len(page) != 0 {
+		t.Errorf("page past the end = %d %+v", total, page)
+	}
+
+	// Until is exclusive
+	filter.Since, filter.Until = time.Time{}, backdate[first]
+	if alerts, _ := s.SearchAlerts(filter, 0, 10); len(alerts) != 1 || alerts[0].ID != old {
+		t.Errorf("until = %+v, want only %s", alerts, old)
+	}
+}
@@ -0,0 +1,91 @@
+package mock
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_TradeAndSettlementFees(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.01), TradeFeePercent: 1, SettlementFeePercent: 10})
+	user := newTradingUser(t, s, "fees@example.com", 100)
+
+	// 10 YES @ 40¢: $4.00 collateral, fee $0.10 + 1% of $4.00 = $0.14
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.FeeUSD != models.Dollars(0.14) {
+		t.Fatalf("order fee = %s, want $0.14", order.FeeUSD)
+	}
+	assertWallet(t, s, user.ID, 95.86, 4.14)
+
+	if err := s.MockFillOrder(order.ID, 40); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 95.86, 4.00)
+
+	// Win: $10 payout, 10% of $6 profit = $0.60 settlement fee
+	if _, err := s.SettleMarket("FED-24DEC", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 105.26, 0)
+
+	settlements := s.GetSettlements(user.ID, "", 10)
+	if len(settlements) != 1 {
+		t.Fatalf("expected 1 settlement, got %d", len(settlements))
+	}
+	if got := fmt.Sprintf("%s/%s", settlements[0].FeeUSD, settlements[0].PnLUSD); got != "$0.60/$5.26" {
+		t.Errorf("settlement fee/pnl = %s, want $0.60/$5.26", got)
+	}
+
+	txs, _ := s.GetTransactions(user.ID, 50)
+	var fees models.Money
+	for _, tx := range txs {
+		if tx.Type == models.TxTypeFee {
+			fees += tx.AmountUSD
+		}
+	}
+	if fees != models.Dollars(0.74) {
+		t.Errorf("fee transactions total %s, want $0.74", fees)
+	}
+}
+
+func TestStore_NoSettlementFeeOnLoss(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{SettlementFeePercent: 10})
+	user := newTradingUser(t, s, "loss@example.com", 100)
+	fillOrder(t, s, user.ID, "CPI-24NOV", models.OrderSideYes, 10, 40)
+
+	if _, err := s.SettleMarket("CPI-24NOV", "no", "test"); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 96, 0)
+}
+
+func TestStore_CancelReleasesReservedFee(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.02)})
+	user := newTradingUser(t, s, "cancel@example.com", 100)
+	if _, err := s.CreateOrder(user.ID, "GDP-Q4", "EVT", models.OrderSideNo, models.OrderTypeLimit, 5, 30, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	s.cancelPendingOrders("GDP-Q4", "test")
+	assertWallet(t, s, user.ID, 100, 0)
+}
+
+// Fees are whole cents per contract times quantity, so a per-contract fee
+// that is inexact in float64 dollars never drifts off the cent.
+func TestStore_TradeFeeIsExactCents(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.07), TradeFeePercent: 0.5})
+	for qty := 1; qty <= 1000; qty++ {
+		collateral := models.Cents(int64(models.CollateralCents(models.OrderSideYes, qty, 33)))
+		want := models.Cents(int64(7*qty)) + collateral.Percent(0.5)
+		if got := s.tradeFee(qty, collateral); got != want {
+			t.Fatalf("fee for %d contracts = %s, want %s", qty, got, want)
+		}
+	}
+}
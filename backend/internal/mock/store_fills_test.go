@@ -0,0 +1,145 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_PartialFillThenRemainder(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "partial@example.com", 100)
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 100, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.PartialFill(order.ID, 30, 40); err != nil {
+		t.Fatal(err)
+	}
+	orders, _ := s.GetOrders(user.ID, nil, 10)
+	if orders[0].Status != models.OrderStatusPartial || orders[0].FilledQuantity != 30 {
+		t.Fatalf("after 30 filled: status=%s filled=%d", orders[0].Status, orders[0].FilledQuantity)
+	}
+	positions, _ := s.GetPositions(user.ID)
+	if len(positions) != 1 || positions[0].Quantity != 30 || positions[0].CostBasisUSD != models.Dollars(12) {
+		t.Fatalf("after 30 filled: unexpected position %+v", positions)
+	}
+
+	if err := s.PartialFill(order.ID, 70, 40); err != nil {
+		t.Fatal(err)
+	}
+	orders, _ = s.GetOrders(user.ID, nil, 10)
+	if orders[0].Status != models.OrderStatusFilled || orders[0].FilledQuantity != 100 || orders[0].FilledAt == nil {
+		t.Fatalf("after remainder: status=%s filled=%d", orders[0].Status, orders[0].FilledQuantity)
+	}
+	positions, _ = s.GetPositions(user.ID)
+	if len(positions) != 1 || positions[0].Quantity != 100 || positions[0].CostBasisUSD != models.Dollars(40) {
+		t.Fatalf("after remainder: unexpected position %+v", positions)
+	}
+	assertWallet(t, s, user.ID, 60, 40)
+
+	if err := s.PartialFill(order.ID, 1, 40); !errors.Is(err, ErrOrderNotFillable) {
+		t.Errorf("expected ErrOrderNotFillable on filled order, got %v", err)
+	}
+}
+
+func TestStore_PartialFillRejectsOverfill(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "overfill@example.com", 100)
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PartialFill(order.ID, 11, 40); !errors.Is(err, ErrInvalidFillQuantity) {
+		t.Errorf("expected ErrInvalidFillQuantity, got %v", err)
+	}
+}
+
+func TestStore_CancelPartialUnlocksRemainder(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.01)})
+	user := newTradingUser(t, s, "remainder@example.com", 100)
+	// 100 YES @ 40¢: $40.00 collateral + $1.00 fee reserved
+	order, err := s.CreateOrder(user.ID, "CPI-24NOV", "EVT", models.OrderSideYes, models.OrderTypeLimit, 100, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PartialFill(order.ID, 30, 40); err != nil {
+		t.Fatal(err)
+	}
+	// 30 filled: $12.00 stays locked, $0.30 fee charged
+	s.cancelPendingOrders("CPI-24NOV", "test")
+	assertWallet(t, s, user.ID, 87.70, 12)
+}
+
+func TestStore_AveragePriceAcrossFills(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "avg@example.com", 1000)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 100, 40)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 100, 60)
+
+	positions, _ := s.GetPositions(user.ID)
+	if len(positions) != 1 {
+		t.Fatalf("expected 1 position, got %d", len(positions))
+	}
+	if positions[0].AvgPriceCents != 50 || positions[0].Quantity != 200 {
+		t.Errorf("avg price = %d¢ qty = %d, want 50¢ qty 200", positions[0].AvgPriceCents, positions[0].Quantity)
+	}
+}
+
+func TestWeightedAvgCents_RoundsToNearest(t *testing.T) {
+	// (1*33 + 2*34) / 3 = 33.67 -> 34
+	if got := weightedAvgCents(33, 1, 34, 2); got != 34 {
+		t.Errorf("weightedAvgCents = %d, want 34", got)
+	}
+}
+
+// Many small deposits and uneven partial fills with a percentage fee leave
+// the books balanced to the cent: nothing is lost or created by rounding.
+func TestStore_NoDriftAcrossManyDepositsAndFills(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePercent: 1.5})
+	user := newTradingUser(t, s, "drift@example.com", 100)
+	for i := 0; i < 1000; i++ {
+		if _, err := s.Deposit(user.ID, models.Dollars(0.10), "test", "127.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertWallet(t, s, user.ID, 200, 0)
+
+	for i := 0; i < 50; i++ {
+		order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 7, 33, "127.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, qty := range []int{2, 2, 3} {
+			if err := s.PartialFill(order.ID, qty, 33); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	wallet, _ := s.GetWallet(user.ID)
+	positions, _ := s.GetPositions(user.ID)
+	if len(positions) != 1 || positions[0].CostBasisUSD != models.Cents(50*7*33) {
+		t.Fatalf("unexpected position %+v", positions)
+	}
+	if wallet.LockedUSD != positions[0].CostBasisUSD {
+		t.Errorf("locked %s, want the cost basis %s", wallet.LockedUSD, positions[0].CostBasisUSD)
+	}
+	txs, _ := s.GetTransactions(user.ID, 2000)
+	var fees models.Money
+	for _, tx := range txs {
+		if tx.Type == models.TxTypeFee {
+			fees += tx.AmountUSD
+		}
+	}
+	if fees != positions[0].FeesUSD {
+		t.Errorf("fee transactions total %s, position fees %s", fees, positions[0].FeesUSD)
+	}
+	if total := wallet.AvailableUSD + wallet.LockedUSD + fees; total != models.Dollars(200) {
+		t.Errorf("available + locked + fees = %s, want $200.00", total)
+	}
+}
@@ -0,0 +1,123 @@
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// newTradingUser creates a verified user with a funded wallet.
+func newTradingUser(t *testing.T, s *Store, email string, depositUSD float64) *models.User {
+	t.Helper()
+	user, err := s.CreateUser(email, "hash", "T", "Trader", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateKYCRecord(user.ID, "drivers_license", "D123", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MockKYCApproval(user.ID, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateWallet(user.ID, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Deposit(user.ID, models.Dollars(depositUSD), "test", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// placeOrder places a limit order that must be accepted.
+func placeOrder(t *testing.T, s *Store, userID, ticker string, side models.OrderSide, qty, price int) *models.Order {
+	t.Helper()
+	order, err := s.CreateOrder(userID, ticker, "EVT", side, models.OrderTypeLimit, qty, price, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+// fillOrder places a limit order and fills it at its limit price.
+func fillOrder(t *testing.T, s *Store, userID, ticker string, side models.OrderSide, qty, price int) {
+	t.Helper()
+	order := placeOrder(t, s, userID, ticker, side, qty, price)
+	if err := s.MockFillOrder(order.ID, price); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertWallet(t *testing.T, s *Store, userID string, available, locked float64) {
+	t.Helper()
+	wallet, err := s.GetWallet(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.AvailableUSD != models.Dollars(available) || wallet.LockedUSD != models.Dollars(locked) {
+		t.Errorf("wallet available/locked = %s/%s, want %.2f/%.2f", wallet.AvailableUSD, wallet.LockedUSD, available, locked)
+	}
+}
+
+func assertPending(t *testing.T, s *Store, userID string, pendingUSD, withdrawnUSD float64) {
+	t.Helper()
+	wallet, err := s.GetWallet(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.PendingUSD != models.Dollars(pendingUSD) || wallet.TotalWithdrawn != models.Dollars(withdrawnUSD) {
+		t.Errorf("wallet pending/withdrawn = %s/%s, want %.2f/%.2f",
+			wallet.PendingUSD, wallet.TotalWithdrawn, pendingUSD, withdrawnUSD)
+	}
+}
+
+// assertLedgerBalanced checks every journal entry nets to zero and the
+// user's customer accounts match the wallet.
+func assertLedgerBalanced(t *testing.T, s *Store, userID string) {
+	t.Helper()
+	var total models.Money
+	for _, entry := range s.Ledger("") {
+		var sum models.Money
+		for _, posting := range entry.Postings {
+			sum += posting.AmountUSD
+		}
+		if sum != 0 {
+			t.Errorf("entry %s %q nets to %s", entry.ID, entry.Description, sum)
+		}
+		total += sum
+	}
+	if total != 0 {
+		t.Errorf("ledger nets to %s", total)
+	}
+
+	wallet, _ := s.GetWallet(userID)
+	balances := s.LedgerBalances(userID)
+	if balances[models.AccountCustomerAvailable] != wallet.AvailableUSD ||
+		balances[models.AccountCustomerLocked] != wallet.LockedUSD ||
+		balances[models.AccountCustomerPending] != wallet.PendingUSD {
+		t.Errorf("ledger available/locked/pending = %s/%s/%s, wallet = %s/%s/%s",
+			balances[models.AccountCustomerAvailable], balances[models.AccountCustomerLocked], balances[models.AccountCustomerPending],
+			wallet.AvailableUSD, wallet.LockedUSD, wallet.PendingUSD)
+	}
+}
+
+// findAudit returns the newest audit entry for an entity and action.
+func findAudit(s *Store, entityType, entityID string, action models.AuditAction) *models.AuditEntry {
+	for _, entry := range s.GetAllAuditLogs(time.Time{}, 1000) {
+		if entry.EntityType == entityType && entry.EntityID == entityID && entry.Action == action {
+			return &entry
+		}
+	}
+	return nil
+}
+
+// resolveAlert takes an alert through investigation to resolved.
+func resolveAlert(t *testing.T, s *Store, alertID, by, notes string) {
+	t.Helper()
+	if _, err := s.UpdateAlertStatus(alertID, models.AlertStatusInvestigating, by, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResolveAlert(alertID, by, notes); err != nil {
+		t.Fatal(err)
+	}
+}
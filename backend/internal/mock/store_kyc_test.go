@@ -0,0 +1,58 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_KYCStatusProgression(t *testing.T) {
+	s := NewStore()
+	user, err := s.CreateUser("kyc@example.com", "hash", "K", "User", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started, err := s.StartKYCRecord(user.ID, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != models.KYCStatusNotStarted || started.SubmittedAt != nil {
+		t.Fatalf("started record = %s submitted %v", started.Status, started.SubmittedAt)
+	}
+
+	submitted, err := s.CreateKYCRecord(user.ID, "passport", "P123", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submitted.ID != started.ID || submitted.Status != models.KYCStatusPending || submitted.SubmittedAt == nil {
+		t.Fatalf("submission created %s (%s), want %s pending", submitted.ID, submitted.Status, started.ID)
+	}
+	if _, err := s.CreateKYCRecord(user.ID, "passport", "P123", "127.0.0.1"); !errors.Is(err, ErrKYCAlreadySubmitted) {
+		t.Errorf("resubmitting pending KYC: expected ErrKYCAlreadySubmitted, got %v", err)
+	}
+
+	if err := s.MockKYCApproval(user.ID, false, "blurry"); err != nil {
+		t.Fatal(err)
+	}
+	retry, err := s.CreateKYCRecord(user.ID, "drivers_license", "D456", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("resubmitting after rejection: %v", err)
+	}
+	if retry.ID != started.ID || retry.Status != models.KYCStatusPending || retry.RejectionReason != "" {
+		t.Errorf("resubmitted record = %+v", retry)
+	}
+
+	if err := s.MockKYCApproval(user.ID, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	record, _ := s.GetKYCRecord(user.ID)
+	if record.Status != models.KYCStatusApproved {
+		t.Errorf("status = %s, want approved", record.Status)
+	}
+	if _, err := s.CreateKYCRecord(user.ID, "passport", "P123", "127.0.0.1"); !errors.Is(err, ErrKYCAlreadySubmitted) {
+		t.Errorf("resubmitting approved KYC: expected ErrKYCAlreadySubmitted, got %v", err)
+	}
+}
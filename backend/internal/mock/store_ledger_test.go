@@ -0,0 +1,197 @@
+package mock
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_LedgerBalancesAcrossTradeLifecycle(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.02), SettlementFeePercent: 10})
+	s.SetDepositClearingDelay(time.Minute)
+	user := newTradingUser(t, s, "ledger@example.com", 300)
+	s.ReleasePendingTransactions(time.Now().Add(time.Minute))
+	assertLedgerBalanced(t, s, user.ID)
+
+	// Fill below the limit, amend and cancel a resting order, close part of
+	// the position, settle the rest, withdraw after a hold, reverse a fee
+	order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 30, 45)
+	if err := s.MockFillOrder(order.ID, 41); err != nil {
+		t.Fatal(err)
+	}
+	resting := placeOrder(t, s, user.ID, "CPI-24DEC", models.OrderSideNo, 10, 30)
+	if _, err := s.CancelOrder(user.ID, resting.ID, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	positions, _ := s.GetPositions(user.ID)
+	if _, err := s.ClosePosition(user.ID, positions[0].ID, 10, 60, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SettleMarket("FED-24DEC", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+	s.SetWithdrawalHold(time.Hour)
+	if _, err := s.Withdraw(user.ID, models.Dollars(50), "ach", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	assertLedgerBalanced(t, s, user.ID)
+	s.ReleasePendingTransactions(time.Now().Add(time.Hour))
+
+	txs, _ := s.GetTransactions(user.ID, 100)
+	for _, tx := range txs {
+		if tx.Type == models.TxTypeFee {
+			if _, err := s.ReverseTransaction(tx.ID, "goodwill", "127.0.0.1"); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	assertLedgerBalanced(t, s, user.ID)
+
+	// Customer P&L is the settlement account's loss, fees the house's gain
+	balances := s.LedgerBalances("")
+	var pnl, fees models.Money
+	txs, _ = s.GetTransactions(user.ID, 100)
+	for _, tx := range txs {
+		switch {
+		case tx.Type == models.TxTypeSettlement || tx.Type == models.TxTypeTrade:
+			pnl += tx.PnLUSD
+		case tx.Type == models.TxTypeFee && tx.Status == models.TxStatusCompleted:
+			fees += tx.AmountUSD
+		}
+	}
+	if pnl == 0 || balances[models.AccountSettlement] != -pnl || balances[models.AccountHouseFees] != fees {
+		t.Errorf("settlement %s, house fees %s; want %s and %s", balances[models.AccountSettlement], balances[models.AccountHouseFees], -pnl, fees)
+	}
+	if balances[models.AccountExternal] != -models.Dollars(250) {
+		t.Errorf("external = %s, want -$250.00 net funds in", balances[models.AccountExternal])
+	}
+}
+
+func TestStore_LedgerSurvivesRestartWithOpeningBalances(t *testing.T) {
+	dir := t.TempDir()
+	s := newPersistentTestStore(t, dir)
+	user := newTradingUser(t, s, "ledger-restart@example.com", 75)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	restored := newPersistentTestStore(t, dir)
+	if got, want := len(restored.Ledger(user.ID)), len(s.Ledger(user.ID)); got != want || got == 0 {
+		t.Errorf("restored %d journal entries, want %d", got, want)
+	}
+
+	// A snapshot from before the ledger gets opening balances
+	data := s.collectData()
+	data.Ledger = nil
+	legacy := NewStore()
+	legacy.restoreData(data)
+	if entries := legacy.Ledger(user.ID); len(entries) != 1 || entries[0].Description != "Opening balance" {
+		t.Errorf("legacy ledger = %+v, want one opening balance", entries)
+	}
+	assertLedgerBalanced(t, legacy, user.ID)
+	if result, _ := legacy.ReconcileWallet(user.ID); !result.Balanced {
+		t.Errorf("legacy wallet does not reconcile: %v", result.Discrepancies)
+	}
+}
+
+func TestStore_TransactionsListTheirJournalEntries(t *testing.T) {
+	s := NewStore()
+	s.SetDepositClearingDelay(time.Minute)
+	user := newTradingUser(t, s, "journal-links@example.com", 100)
+	s.ReleasePendingTransactions(time.Now().Add(time.Minute))
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if _, err := s.SettleMarket("FED-24DEC", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := make(map[string]models.JournalEntry)
+	for _, entry := range s.Ledger(user.ID) {
+		entries[entry.ID] = entry
+	}
+	txs, _ := s.GetTransactions(user.ID, 100)
+	var deposit models.Transaction
+	for _, tx := range txs {
+		if len(tx.JournalIDs) == 0 {
+			t.Errorf("%s transaction %s lists no journal entries", tx.Type, tx.ID)
+		}
+		for _, id := range tx.JournalIDs {
+			if entries[id].TransactionID != tx.ID {
+				t.Errorf("%s transaction %s lists entry %s of %q", tx.Type, tx.ID, id, entries[id].TransactionID)
+			}
+		}
+		if tx.Type == models.TxTypeDeposit {
+			deposit = tx
+		}
+	}
+	// Posted when pending and again when cleared
+	if len(deposit.JournalIDs) != 2 {
+		t.Errorf("deposit lists %d journal entries, want 2", len(deposit.JournalIDs))
+	}
+	if result, _ := s.ReconcileWallet(user.ID); !result.Balanced {
+		t.Fatalf("wallet does not reconcile: %v", result.Discrepancies)
+	}
+
+	s.transactionsMu.Lock()
+	s.transactions[deposit.ID].JournalIDs = deposit.JournalIDs[:1]
+	s.transactionsMu.Unlock()
+	result, _ := s.ReconcileWallet(user.ID)
+	if result.Balanced || len(result.Discrepancies) != 1 {
+		t.Errorf("unlinked entry: discrepancies = %v, want one", result.Discrepancies)
+	}
+}
+
+func TestStore_LedgerKeepsPastMonthEntries(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "append-only@example.com", 100)
+	for i := range 3 {
+		order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+		var err error
+		if i == 0 {
+			err = s.MockFillOrder(order.ID, 38)
+		} else {
+			_, err = s.CancelOrder(user.ID, order.ID, "127.0.0.1")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	placeOrder(t, s, user.ID, "CPI-24DEC", models.OrderSideNo, 5, 30)
+
+	// Move the activity two months back, as if the store had run since
+	s.ledgerMu.Lock()
+	for i := range s.ledger {
+		s.ledger[i].CreatedAt = s.ledger[i].CreatedAt.AddDate(0, -2, 0)
+	}
+	s.ledgerMu.Unlock()
+	past := s.Ledger(user.ID)
+	first := past[0].CreatedAt.UTC()
+	month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC)
+	before, _ := s.Statement(user.ID, month)
+	balances := s.LedgerBalances(user.ID)
+
+	// CP 18: entries in the current month leave earlier ones as recorded
+	if _, err := s.Deposit(user.ID, models.Dollars(1), "test", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	entries := s.Ledger(user.ID)
+	if len(entries) != len(past)+1 || !reflect.DeepEqual(entries[:len(past)], past) {
+		t.Fatalf("ledger has %d entries after a deposit, want the %d past entries unchanged and one more", len(entries), len(past))
+	}
+	if after, _ := s.Statement(user.ID, month); after.OpeningBalanceUSD != before.OpeningBalanceUSD ||
+		after.ClosingBalanceUSD != before.ClosingBalanceUSD {
+		t.Errorf("statement balances %s..%s, want %s..%s", after.OpeningBalanceUSD, after.ClosingBalanceUSD,
+			before.OpeningBalanceUSD, before.ClosingBalanceUSD)
+	}
+	now := s.LedgerBalances(user.ID)
+	if now[models.AccountCustomerLocked] != balances[models.AccountCustomerLocked] ||
+		now[models.AccountCustomerAvailable] != balances[models.AccountCustomerAvailable]+models.Dollars(1) {
+		t.Errorf("balances = %v, want %v plus the deposit", now, balances)
+	}
+	assertLedgerBalanced(t, s, user.ID)
+	if result, _ := s.ReconcileWallet(user.ID); !result.Balanced {
+		t.Errorf("wallet does not reconcile: %v", result.Discrepancies)
+	}
+}
@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func withBasicLimit(s *Store, maxPositionUSD float64, maxOpenOrders int) []models.PositionLimitConfig {
+	tiers := s.GetPositionLimits()
+	for i := range tiers {
+		if tiers[i].Tier == models.DefaultTier {
+			tiers[i].MaxPositionUSD = models.Dollars(maxPositionUSD)
+			tiers[i].MaxOpenOrders = maxOpenOrders
+		}
+	}
+	return tiers
+}
+
+func TestStore_SetPositionLimitsMovesTierUsers(t *testing.T) {
+	s := NewStore()
+	tracking := newTradingUser(t, s, "tracking@example.com", 100)
+	custom := newTradingUser(t, s, "custom@example.com", 100)
+	custom.PositionLimitUSD = models.Dollars(7)
+	legacy := newTradingUser(t, s, "legacy@example.com", 100)
+	legacy.LimitTier = "" // persisted before tiers were recorded
+
+	updated, err := s.SetPositionLimits(withBasicLimit(s, 50000, 3), "ops_1", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 3 {
+		t.Errorf("users updated = %d, want 3", updated)
+	}
+	if tracking.PositionLimitUSD != models.Dollars(50000) || tracking.MaxOpenOrders != 3 {
+		t.Errorf("tier user limits = %s/%d, want $50000/3", tracking.PositionLimitUSD, tracking.MaxOpenOrders)
+	}
+	if legacy.PositionLimitUSD != models.Dollars(50000) {
+		t.Errorf("legacy user limit = %s, want $50000", legacy.PositionLimitUSD)
+	}
+	if custom.PositionLimitUSD != models.Dollars(7) || custom.MaxOpenOrders != 3 {
+		t.Errorf("custom user limits = %s/%d, want the individual $7 kept", custom.PositionLimitUSD, custom.MaxOpenOrders)
+	}
+
+	next, err := s.CreateUser("new@example.com", "hash", "N", "User", "NY", tracking.DateOfBirth, true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.PositionLimitUSD != models.Dollars(50000) || next.LimitTier != models.DefaultTier {
+		t.Errorf("new user limit = %s (%q), want the live basic tier", next.PositionLimitUSD, next.LimitTier)
+	}
+
+	entries := s.GetAuditLog("ops_1", tracking.CreatedAt.AddDate(0, 0, -1), 10)
+	if len(entries) != 1 || entries[0].EntityType != "position_limits" {
+		t.Errorf("expected one position_limits audit entry, got %+v", entries)
+	}
+}
+
+func TestStore_SetPositionLimitsRejectsInvalidTables(t *testing.T) {
+	s := NewStore()
+	tests := map[string][]models.PositionLimitConfig{
+		"empty":     nil,
+		"no basic":  {{Tier: "pro", MaxPositionUSD: 1, MaxOrderSize: 1, DailyVolumeUSD: 1, MaxOpenOrders: 1}},
+		"duplicate": append(s.GetPositionLimits(), s.GetPositionLimits()[0]),
+		"zero":      withBasicLimit(s, 0, 100),
+	}
+	for name, limits := range tests {
+		if _, err := s.SetPositionLimits(limits, "ops_1", "127.0.0.1"); !errors.Is(err, ErrInvalidLimits) {
+			t.Errorf("%s: expected ErrInvalidLimits, got %v", name, err)
+		}
+	}
+	if got := s.TierLimits(models.DefaultTier).MaxPositionUSD; got != models.Dollars(25000) {
+		t.Errorf("rejected table replaced the live one: basic = %s", got)
+	}
+}
+
+func TestStore_PositionLimitsPersisted(t *testing.T) {
+	dir := t.TempDir()
+	s := newPersistentTestStore(t, dir)
+	if _, err := s.SetPositionLimits(withBasicLimit(s, 40000, 150), "ops_1", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newPersistentTestStore(t, dir)
+	if got := restored.TierLimits(models.DefaultTier); got.MaxPositionUSD != models.Dollars(40000) || got.MaxOpenOrders != 150 {
+		t.Errorf("restored basic tier = %+v", got)
+	}
+}
+
+func TestStore_ExposureNetsYesAndNoInSameMarket(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "hedged@example.com", 100)
+	user.PositionLimitUSD = models.Dollars(12)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40) // $4.00
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideNo, 10, 40)  // $6.00
+
+	assertWallet(t, s, user.ID, 90, 10)
+	if got := s.GetUserExposure(user.ID); got != 0 {
+		t.Errorf("exposure of 10 YES + 10 NO = %s, want $0.00", got)
+	}
+
+	// $10 locked + $5 would breach a $12 limit gross, but the pair is hedged
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 50, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("order against a netted exposure: %v", err)
+	}
+	if got := s.GetUserExposure(user.ID); got != models.Dollars(5) {
+		t.Errorf("exposure with a resting $5 order = %s, want $5.00", got)
+	}
+	if err := s.MockFillOrder(order.ID, 50); err != nil {
+		t.Fatal(err)
+	}
+	// 20 YES vs 10 NO: only 10 contracts are hedged
+	if got := s.GetUserExposure(user.ID); got != models.Dollars(5) {
+		t.Errorf("exposure with 20 YES + 10 NO = %s, want $5.00", got)
+	}
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 20, 50, "127.0.0.1"); !errors.Is(err, ErrPositionLimitExceeded) {
+		t.Errorf("expected ErrPositionLimitExceeded past the netted limit, got %v", err)
+	}
+}
+
+func TestStore_GrossPositionsOnBothSidesFlagWashSetup(t *testing.T) {
+	s := NewStore()
+	s.SetWashSetupThreshold(20)
+	user := newTradingUser(t, s, "wash@example.com", 100)
+
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 30, 40)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideNo, 10, 40)
+	if alerts := s.GetComplianceAlerts("open", "", 10); len(alerts) != 0 {
+		t.Fatalf("alert below the threshold: %+v", alerts)
+	}
+
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideNo, 10, 40)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideNo, 5, 40)
+	alerts := s.GetComplianceAlerts("open", "", 10)
+	if len(alerts) != 1 || alerts[0].Type != "wash_setup" || alerts[0].UserID != user.ID || alerts[0].MarketTicker != "FED-24DEC" {
+		t.Fatalf("expected one wash_setup alert for FED-24DEC, got %+v", alerts)
+	}
+
+	// Another market is tracked separately and one-sided holdings never alert
+	fillOrder(t, s, user.ID, "CPI-24NOV", models.OrderSideYes, 50, 20)
+	if alerts := s.GetComplianceAlerts("open", "", 10); len(alerts) != 1 {
+		t.Errorf("one-sided position raised an alert: %+v", alerts)
+	}
+}
@@ -0,0 +1,566 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_AmendOrderIncreaseLocksMore(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "amend-up@example.com", 100)
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 96, 4)
+
+	amended, err := s.AmendOrder(user.ID, order.ID, 45, 20, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.ID != order.ID || !amended.CreatedAt.Equal(order.CreatedAt) {
+		t.Errorf("amend changed order identity: %s/%v", amended.ID, amended.CreatedAt)
+	}
+	if amended.Quantity != 20 || amended.PriceCents != 45 || amended.CollateralUSD != models.Dollars(9) ||
+		len(amended.AmendedAt) != 1 || !amended.AmendedAt[0].Equal(amended.UpdatedAt) {
+		t.Errorf("unexpected amended order: %+v", amended)
+	}
+	assertWallet(t, s, user.ID, 91, 9)
+
+	entries := s.GetAuditLog(user.ID, order.CreatedAt.Add(-1), 10)
+	if len(entries) == 0 || entries[0].Action != models.AuditActionUpdate || entries[0].OldValue == "" || entries[0].NewValue == "" {
+		t.Errorf("amend not audited with old/new values: %+v", entries)
+	}
+}
+
+func TestStore_AmendOrderDecreaseUnlocksExcess(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "amend-down@example.com", 100)
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 20, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 88, 12)
+
+	if _, err := s.AmendOrder(user.ID, order.ID, 40, 5, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 97, 3)
+}
+
+func TestStore_AmendOrderRejections(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "amend-reject@example.com", 10)
+	other := newTradingUser(t, s, "amend-other@example.com", 10)
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 50, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.AmendOrder(user.ID, order.ID, 50, 100, "127.0.0.1"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+	if _, err := s.AmendOrder(other.ID, order.ID, 50, 5, "127.0.0.1"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound for another user's order, got %v", err)
+	}
+	if err := s.MockFillOrder(order.ID, 50); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AmendOrder(user.ID, order.ID, 50, 5, "127.0.0.1"); !errors.Is(err, ErrOrderNotAmendable) {
+		t.Errorf("expected ErrOrderNotAmendable on filled order, got %v", err)
+	}
+	assertWallet(t, s, user.ID, 5, 5)
+}
+
+func TestStore_AmendOrderRejectedOnSettledMarket(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "amend-settled@example.com", 100)
+	order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if _, err := s.SettleMarket("FED-24DEC", "no", "test"); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 100, 0)
+
+	for _, amend := range [][2]int{{60, 10}, {40, 20}, {40, 5}} {
+		if _, err := s.AmendOrder(user.ID, order.ID, amend[0], amend[1], "127.0.0.1"); !errors.Is(err, ErrMarketAlreadySettled) {
+			t.Errorf("amend to %d @ %d¢: expected ErrMarketAlreadySettled, got %v", amend[1], amend[0], err)
+		}
+	}
+	if got, _ := s.GetOrder(user.ID, order.ID); got.Quantity != 10 || got.PriceCents != 40 || len(got.AmendedAt) != 0 {
+		t.Errorf("order = %d @ %d¢ with %d amends, want 10 @ 40¢ unamended", got.Quantity, got.PriceCents, len(got.AmendedAt))
+	}
+	assertWallet(t, s, user.ID, 100, 0)
+}
+
+// selfTradeAlerts counts self-trade occurrences, including repeats folded
+// into one alert.
+func selfTradeAlerts(s *Store) int {
+	count := 0
+	for _, alert := range s.GetComplianceAlerts("", "", 100) {
+		if alert.Type == "self_trade_prevented" {
+			count += alert.Count
+		}
+	}
+	return count
+}
+
+func TestStore_SelfTradeCancelNewest(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "stp-newest@example.com", 100)
+	resting, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// YES @ 45¢ crosses the resting NO bid at a 40¢ YES price.
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 5, 45, "127.0.0.1"); !errors.Is(err, ErrSelfTradePrevented) {
+		t.Fatalf("expected ErrSelfTradePrevented, got %v", err)
+	}
+	got, _ := s.GetOrder(user.ID, resting.ID)
+	if got.Status != models.OrderStatusPending || got.Quantity != 10 {
+		t.Errorf("resting order changed: %s %d", got.Status, got.Quantity)
+	}
+	if selfTradeAlerts(s) != 1 {
+		t.Errorf("expected 1 self_trade_prevented alert, got %d", selfTradeAlerts(s))
+	}
+	assertWallet(t, s, user.ID, 94, 6)
+
+	// A non-crossing YES bid below the NO bid is allowed.
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 5, 35, "127.0.0.1"); err != nil {
+		t.Errorf("non-crossing order rejected: %v", err)
+	}
+}
+
+func TestStore_SelfTradeCancelOldest(t *testing.T) {
+	s := NewStore()
+	s.SetSelfTradePolicy(SelfTradeCancelOldest)
+	user := newTradingUser(t, s, "stp-oldest@example.com", 100)
+	resting, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 5, 45, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Quantity != 5 {
+		t.Errorf("new order quantity = %d, want 5", order.Quantity)
+	}
+	got, _ := s.GetOrder(user.ID, resting.ID)
+	if got.Status != models.OrderStatusCancelled {
+		t.Errorf("resting order status = %s, want cancelled", got.Status)
+	}
+	if selfTradeAlerts(s) != 1 {
+		t.Errorf("expected 1 self_trade_prevented alert, got %d", selfTradeAlerts(s))
+	}
+	assertWallet(t, s, user.ID, 97.75, 2.25)
+}
+
+func TestStore_RejectedOrderLeavesSelfTradeRestingOrder(t *testing.T) {
+	s := NewStore()
+	s.SetSelfTradePolicy(SelfTradeCancelOldest)
+	user := newTradingUser(t, s, "stp-rejected@example.com", 10)
+	resting, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The crossing order cannot be funded, so the resting order must survive.
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 100, 45, "127.0.0.1"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+	got, _ := s.GetOrder(user.ID, resting.ID)
+	if got.Status != models.OrderStatusPending || got.Quantity != 10 {
+		t.Errorf("resting order changed by a rejected order: %s %d", got.Status, got.Quantity)
+	}
+	if selfTradeAlerts(s) != 0 {
+		t.Errorf("expected no self_trade_prevented alert, got %d", selfTradeAlerts(s))
+	}
+	assertWallet(t, s, user.ID, 4, 6)
+}
+
+func TestStore_SelfTradeDecrementBoth(t *testing.T) {
+	s := NewStore()
+	s.SetSelfTradePolicy(SelfTradeDecrementBoth)
+	user := newTradingUser(t, s, "stp-decrement@example.com", 100)
+	resting, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 of the new 4 overlap: resting drops to 6, new order is fully consumed.
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 4, 45, "127.0.0.1"); !errors.Is(err, ErrSelfTradePrevented) {
+		t.Fatalf("expected ErrSelfTradePrevented, got %v", err)
+	}
+	got, _ := s.GetOrder(user.ID, resting.ID)
+	if got.Quantity != 6 || got.Status != models.OrderStatusPending {
+		t.Fatalf("resting order = %s %d, want pending 6", got.Status, got.Quantity)
+	}
+	assertWallet(t, s, user.ID, 96.40, 3.60)
+
+	// 8 new vs 6 resting: resting is cancelled, 2 contracts are placed.
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 8, 45, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Quantity != 2 {
+		t.Errorf("new order quantity = %d, want 2", order.Quantity)
+	}
+	got, _ = s.GetOrder(user.ID, resting.ID)
+	if got.Status != models.OrderStatusCancelled {
+		t.Errorf("resting order status = %s, want cancelled", got.Status)
+	}
+	if selfTradeAlerts(s) != 2 {
+		t.Errorf("expected 2 self_trade_prevented occurrences, got %d", selfTradeAlerts(s))
+	}
+	assertWallet(t, s, user.ID, 99.10, 0.90)
+}
+
+func TestStore_AmendIntoSelfCross(t *testing.T) {
+	tests := []struct {
+		policy            SelfTradePolicy
+		qty               int
+		wantErr           error
+		amended           string
+		amendedQty        int
+		amendedPrice      int
+		resting           string
+		restingQty        int
+		available, locked float64
+	}{
+		// Refused: both orders keep their terms
+		{SelfTradeCancelNewest, 8, ErrSelfTradePrevented, "pending", 8, 35, "pending", 10, 91.20, 8.80},
+		{SelfTradeCancelOldest, 8, nil, "pending", 8, 45, "cancelled", 10, 96.40, 3.60},
+		// 8 overlap 10: the amended order is used up, the resting one drops to 2
+		{SelfTradeDecrementBoth, 8, ErrSelfTradePrevented, "cancelled", 8, 35, "pending", 2, 98.80, 1.20},
+		// 12 overlap 10: the resting order is used up, 2 remain at the new price
+		{SelfTradeDecrementBoth, 12, nil, "pending", 2, 45, "cancelled", 0, 99.10, 0.90},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.policy, tt.qty), func(t *testing.T) {
+			s := NewStore()
+			s.SetSelfTradePolicy(tt.policy)
+			user := newTradingUser(t, s, "stp-amend@example.com", 100)
+			resting := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideNo, 10, 40)
+			// YES @ 35¢ rests below the NO bid at a 40¢ YES price
+			order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 8, 35)
+			if selfTradeAlerts(s) != 0 {
+				t.Fatal("non-crossing order raised a self-trade alert")
+			}
+
+			if _, err := s.AmendOrder(user.ID, order.ID, 45, tt.qty, "127.0.0.1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("amend to %d @ 45¢: err = %v, want %v", tt.qty, err, tt.wantErr)
+			}
+			got, _ := s.GetOrder(user.ID, order.ID)
+			if string(got.Status) != tt.amended || got.Quantity != tt.amendedQty || got.PriceCents != tt.amendedPrice {
+				t.Errorf("amended order = %s %d @ %d¢, want %s %d @ %d¢", got.Status, got.Quantity, got.PriceCents,
+					tt.amended, tt.amendedQty, tt.amendedPrice)
+			}
+			if got, _ := s.GetOrder(user.ID, resting.ID); string(got.Status) != tt.resting || got.Quantity != tt.restingQty {
+				t.Errorf("resting order = %s %d, want %s %d", got.Status, got.Quantity, tt.resting, tt.restingQty)
+			}
+			if selfTradeAlerts(s) != 1 {
+				t.Errorf("expected 1 self_trade_prevented alert, got %d", selfTradeAlerts(s))
+			}
+			assertWallet(t, s, user.ID, tt.available, tt.locked)
+			assertLedgerBalanced(t, s, user.ID)
+		})
+	}
+}
+
+func TestStore_OpenOrderLimit(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "open-orders@example.com", 100)
+	user.MaxOpenOrders = 3
+
+	var orders []*models.Order
+	for i := 0; i < 3; i++ {
+		order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("order %d: %v", i+1, err)
+		}
+		orders = append(orders, order)
+	}
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1"); !errors.Is(err, ErrTooManyOpenOrders) {
+		t.Fatalf("expected ErrTooManyOpenOrders, got %v", err)
+	}
+
+	// Filled orders no longer count against the cap.
+	if err := s.MockFillOrder(orders[0].ID, 40); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1"); err != nil {
+		t.Fatalf("order after fill: %v", err)
+	}
+
+	// Cancelling a resting order frees a slot.
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1"); !errors.Is(err, ErrTooManyOpenOrders) {
+		t.Fatalf("expected ErrTooManyOpenOrders, got %v", err)
+	}
+	if _, err := s.CancelOrder(user.ID, orders[1].ID, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1"); err != nil {
+		t.Fatalf("order after cancel: %v", err)
+	}
+}
+
+func TestStore_CancelOrderReleasesReserve(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "cancel@example.com", 100)
+	order, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PartialFill(order.ID, 4, 40); err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, err := s.CancelOrder(user.ID, order.ID, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != models.OrderStatusCancelled {
+		t.Errorf("status = %s, want cancelled", cancelled.Status)
+	}
+	// Only the filled 4 contracts stay locked as position collateral.
+	assertWallet(t, s, user.ID, 98.40, 1.60)
+
+	if _, err := s.CancelOrder(user.ID, order.ID, "127.0.0.1"); !errors.Is(err, ErrOrderNotCancellable) {
+		t.Errorf("expected ErrOrderNotCancellable, got %v", err)
+	}
+	other := newTradingUser(t, s, "other@example.com", 100)
+	if _, err := s.CancelOrder(other.ID, order.ID, "127.0.0.1"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound for another user's order, got %v", err)
+	}
+}
+
+// Exposure built from many small orders lands exactly on the limit and must
+// be accepted; one more cent is rejected.
+func TestStore_PositionLimitExactlyReached(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "exact-limit@example.com", 100)
+	user.PositionLimitUSD = models.Dollars(3.30)
+
+	for i := 0; i < 11; i++ {
+		if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 30, "127.0.0.1"); err != nil {
+			t.Fatalf("order %d: %v", i+1, err)
+		}
+	}
+	assertWallet(t, s, user.ID, 96.70, 3.30)
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 1, "127.0.0.1"); !errors.Is(err, ErrPositionLimitExceeded) {
+		t.Fatalf("expected ErrPositionLimitExceeded one cent over the limit, got %v", err)
+	}
+}
+
+func TestStore_ReduceOnlyRejectedWithNothingToReduce(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "reduce-none@example.com", 100)
+
+	if _, err := s.CreateReduceOnlyOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 5, 60, "127.0.0.1"); !errors.Is(err, ErrNothingToReduce) {
+		t.Fatalf("no position: expected ErrNothingToReduce, got %v", err)
+	}
+	// A same-side order adds to the position rather than reducing it
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if _, err := s.CreateReduceOnlyOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 5, 40, "127.0.0.1"); !errors.Is(err, ErrNothingToReduce) {
+		t.Errorf("same side: expected ErrNothingToReduce, got %v", err)
+	}
+	if _, err := s.CreateReduceOnlyOrder(user.ID, "CPI-24NOV", "EVT", models.OrderSideNo, models.OrderTypeLimit, 5, 60, "127.0.0.1"); !errors.Is(err, ErrNothingToReduce) {
+		t.Errorf("other market: expected ErrNothingToReduce, got %v", err)
+	}
+	assertWallet(t, s, user.ID, 96, 4)
+}
+
+func TestStore_ReduceOnlyOffsetsPositionAtLimit(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "reduce@example.com", 100)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	user.PositionLimitUSD = models.Dollars(4) // already at the limit
+
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 6, 50, "127.0.0.1"); !errors.Is(err, ErrPositionLimitExceeded) {
+		t.Fatalf("plain order at the limit: expected ErrPositionLimitExceeded, got %v", err)
+	}
+	order, err := s.CreateReduceOnlyOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 6, 50, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("reduce-only order at the limit: %v", err)
+	}
+	if !order.ReduceOnly {
+		t.Error("order not marked reduce-only")
+	}
+
+	// The resting order claims 6 of the 10 reducible contracts
+	if _, err := s.CreateReduceOnlyOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 5, 50, "127.0.0.1"); !errors.Is(err, ErrNothingToReduce) {
+		t.Errorf("stacked reduce-only past the position: expected ErrNothingToReduce, got %v", err)
+	}
+	if _, err := s.AmendOrder(user.ID, order.ID, 50, 11, "127.0.0.1"); !errors.Is(err, ErrNothingToReduce) {
+		t.Errorf("amend past the position: expected ErrNothingToReduce, got %v", err)
+	}
+	if _, err := s.AmendOrder(user.ID, order.ID, 50, 10, "127.0.0.1"); err != nil {
+		t.Errorf("amend up to the position: %v", err)
+	}
+
+	if err := s.MockFillOrder(order.ID, 50); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetUserExposure(user.ID); got != 0 {
+		t.Errorf("exposure after offsetting 10 YES with 10 NO = %s, want $0.00", got)
+	}
+}
+
+func TestOrderTransitions_IllegalMovesRefused(t *testing.T) {
+	all := []models.OrderStatus{
+		models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial, models.OrderStatusFilled,
+		models.OrderStatusCancelled, models.OrderStatusRejected, models.OrderStatusExpired,
+	}
+	for _, from := range []models.OrderStatus{
+		models.OrderStatusFilled, models.OrderStatusCancelled, models.OrderStatusRejected, models.OrderStatusExpired,
+	} {
+		for _, to := range all {
+			if canTransitionOrder(from, to) {
+				t.Errorf("%s -> %s allowed, want final", from, to)
+			}
+		}
+	}
+	for _, move := range [][2]models.OrderStatus{
+		{models.OrderStatusOpen, models.OrderStatusPending},
+		{models.OrderStatusOpen, models.OrderStatusRejected},
+		{models.OrderStatusPartial, models.OrderStatusPending},
+		{models.OrderStatusPartial, models.OrderStatusOpen},
+		{models.OrderStatusPartial, models.OrderStatusRejected},
+	} {
+		if canTransitionOrder(move[0], move[1]) {
+			t.Errorf("%s -> %s allowed", move[0], move[1])
+		}
+	}
+}
+
+func TestStore_ClosedOrdersRefuseFillCancelAndAmend(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "transitions@example.com", 100)
+
+	filled := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if err := s.MockFillOrder(filled.ID, 40); err != nil {
+		t.Fatal(err)
+	}
+	cancelled := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if _, err := s.CancelOrder(user.ID, cancelled.ID, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	partial := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if err := s.PartialFill(partial.ID, 4, 40); err != nil {
+		t.Fatal(err)
+	}
+	s.SetOrderTTL(time.Minute)
+	expired := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	s.SetOrderTTL(0)
+	if n := s.ExpireOrders(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("expired %d orders, want 1", n)
+	}
+
+	wallet, _ := s.GetWallet(user.ID)
+	walletBefore := *wallet
+	ordersBefore, _ := s.GetOrders(user.ID, nil, 10)
+	if len(ordersBefore) != 4 {
+		t.Fatalf("%d orders, want 4", len(ordersBefore))
+	}
+
+	closed := map[string]*models.Order{"filled": filled, "cancelled": cancelled, "expired": expired}
+	for name, order := range closed {
+		if _, err := s.CancelOrder(user.ID, order.ID, "127.0.0.1"); !errors.Is(err, ErrOrderNotCancellable) {
+			t.Errorf("cancel %s order: expected ErrOrderNotCancellable, got %v", name, err)
+		}
+		if err := s.MockFillOrder(order.ID, 40); !errors.Is(err, ErrOrderNotFillable) {
+			t.Errorf("fill %s order: expected ErrOrderNotFillable, got %v", name, err)
+		}
+		if err := s.PartialFill(order.ID, 1, 40); !errors.Is(err, ErrOrderNotFillable) {
+			t.Errorf("partial fill %s order: expected ErrOrderNotFillable, got %v", name, err)
+		}
+	}
+	closed["partial"] = partial
+	for name, order := range closed {
+		if _, err := s.AmendOrder(user.ID, order.ID, 45, 10, "127.0.0.1"); !errors.Is(err, ErrOrderNotAmendable) {
+			t.Errorf("amend %s order: expected ErrOrderNotAmendable, got %v", name, err)
+		}
+	}
+	if n := s.closeOrdersWhere(func(o *models.Order) bool { return o.ID != partial.ID }, models.OrderStatusCancelled, "test sweep"); n != 0 {
+		t.Errorf("sweep cancelled %d closed orders, want 0", n)
+	}
+	if n := s.ExpireOrders(time.Now().Add(time.Hour)); n != 0 {
+		t.Errorf("second expiry sweep closed %d orders, want 0", n)
+	}
+
+	walletAfter, _ := s.GetWallet(user.ID)
+	if walletAfter.AvailableUSD != walletBefore.AvailableUSD || walletAfter.LockedUSD != walletBefore.LockedUSD {
+		t.Errorf("wallet moved: %s/%s -> %s/%s", walletBefore.AvailableUSD, walletBefore.LockedUSD,
+			walletAfter.AvailableUSD, walletAfter.LockedUSD)
+	}
+	ordersAfter, _ := s.GetOrders(user.ID, nil, 10)
+	for i := range ordersBefore {
+		if ordersAfter[i].Status != ordersBefore[i].Status || ordersAfter[i].FilledQuantity != ordersBefore[i].FilledQuantity {
+			t.Errorf("order %s changed: %s %d -> %s %d", ordersBefore[i].ID, ordersBefore[i].Status,
+				ordersBefore[i].FilledQuantity, ordersAfter[i].Status, ordersAfter[i].FilledQuantity)
+		}
+	}
+}
+
+func TestStore_ExpireOrdersReleasesCollateral(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "expiry@example.com", 100)
+	s.SetOrderTTL(time.Hour)
+
+	order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if order.ExpiresAt == nil || !order.ExpiresAt.Equal(order.CreatedAt.Add(time.Hour)) {
+		t.Fatalf("ExpiresAt = %v, want an hour after %v", order.ExpiresAt, order.CreatedAt)
+	}
+	if err := s.PartialFill(order.ID, 4, 40); err != nil {
+		t.Fatal(err)
+	}
+	wallet, _ := s.GetWallet(user.ID)
+	before := *wallet
+
+	if n := s.ExpireOrders(order.CreatedAt.Add(59 * time.Minute)); n != 0 {
+		t.Fatalf("expired %d orders before ExpiresAt", n)
+	}
+	if n := s.ExpireOrders(order.CreatedAt.Add(time.Hour)); n != 1 {
+		t.Fatalf("expired %d orders at ExpiresAt, want 1", n)
+	}
+
+	expired, _ := s.GetOrder(user.ID, order.ID)
+	if expired.Status != models.OrderStatusExpired || expired.CancelledAt == nil || expired.FilledQuantity != 4 {
+		t.Errorf("order = %s, cancelled %v, filled %d; want expired with 4 filled", expired.Status, expired.CancelledAt, expired.FilledQuantity)
+	}
+	reserve := unfilledReserve(expired)
+	if reserve <= 0 {
+		t.Fatalf("unfilled reserve = %s", reserve)
+	}
+	assertWallet(t, s, user.ID, (before.AvailableUSD + reserve).Dollars(), (before.LockedUSD - reserve).Dollars())
+}
+
+func TestStore_FillRefusesOrderPastExpiry(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "lapsed@example.com", 100)
+	s.SetOrderTTL(time.Hour)
+	order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	wallet, _ := s.GetWallet(user.ID)
+	before := *wallet
+
+	past := time.Now().Add(-time.Second)
+	s.orders[order.ID].ExpiresAt = &past
+	if err := s.MockFillOrder(order.ID, 40); !errors.Is(err, ErrOrderNotFillable) {
+		t.Errorf("fill past expiry: expected ErrOrderNotFillable, got %v", err)
+	}
+	if err := s.PartialFill(order.ID, 1, 40); !errors.Is(err, ErrOrderNotFillable) {
+		t.Errorf("partial fill past expiry: expected ErrOrderNotFillable, got %v", err)
+	}
+	assertWallet(t, s, user.ID, before.AvailableUSD.Dollars(), before.LockedUSD.Dollars())
+
+	if n := s.ExpireOrders(time.Now()); n != 1 {
+		t.Fatalf("expired %d orders, want 1", n)
+	}
+	assertWallet(t, s, user.ID, (before.AvailableUSD + before.LockedUSD).Dollars(), 0)
+}
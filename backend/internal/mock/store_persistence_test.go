@@ -0,0 +1,150 @@
+package mock
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/compliance"
+	"github.com/kalshi-dcm-demo/backend/internal/persistence"
+)
+
+func newPersistentTestStore(t *testing.T, dir string) *Store {
+	t.Helper()
+	config := PersistenceConfig{Enabled: true, DataDir: dir, AutoSaveInterval: time.Hour}
+	manager, err := persistence.NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStoreWithPersister(config, manager)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestStore_PersistenceRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s := newPersistentTestStore(t, dir)
+	user, err := s.CreateUser("persist@example.com", "hash", "P", "User", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	restored := newPersistentTestStore(t, dir)
+	if _, err := restored.GetUser(user.ID); err != nil {
+		t.Fatalf("user not restored: %v", err)
+	}
+	entries, err := restored.QueryAuditArchive(user.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 archived audit entry, got %d", len(entries))
+	}
+
+	// A second save must not duplicate already-archived entries.
+	if err := restored.Save(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = restored.QueryAuditArchive(user.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if len(entries) != 1 {
+		t.Errorf("audit archive duplicated on re-save: %d entries", len(entries))
+	}
+}
+
+func TestStore_OrderRatesSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	s := newPersistentTestStore(t, dir)
+	engine := compliance.NewSurveillanceEngine(s, compliance.SurveillanceConfig{})
+	s.SetRateLimiter(engine)
+	for i := 0; i < 59; i++ { // one below the default 60 per minute
+		engine.RecordOrder("user_busy")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newPersistentTestStore(t, dir)
+	restoredEngine := compliance.NewSurveillanceEngine(restored, compliance.SurveillanceConfig{})
+	restored.SetRateLimiter(restoredEngine)
+	if restoredEngine.IsRateLimited("user_busy") {
+		t.Fatal("user limited before reaching the cap")
+	}
+	restoredEngine.RecordOrder("user_busy")
+	if !restoredEngine.IsRateLimited("user_busy") {
+		t.Error("restart reset the user's rate limit window")
+	}
+}
+
+func TestStore_KYCDocumentNumberEncryptedAtRest(t *testing.T) {
+	dir := t.TempDir()
+	key := make([]byte, persistence.FieldKeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	fieldCipher, err := persistence.NewFieldCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func(cipher *persistence.FieldCipher) *Store {
+		manager, err := persistence.NewManager(dir, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := NewStoreWithPersister(PersistenceConfig{Enabled: true, DataDir: dir, AutoSaveInterval: time.Hour, FieldCipher: cipher}, manager)
+		t.Cleanup(s.Stop)
+		return s
+	}
+
+	s := open(fieldCipher)
+	user, err := s.CreateUser("kyc-crypt@example.com", "hash", "K", "User", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const docNumber = "PASSPORT-987654321"
+	if _, err := s.CreateKYCRecord(user.ID, "passport", docNumber, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(data, []byte(docNumber)) {
+			t.Errorf("%s contains the plaintext document number", filepath.Base(path))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := open(fieldCipher)
+	if record, _ := restored.GetKYCRecord(user.ID); record == nil || record.DocumentNumber != docNumber {
+		t.Fatalf("document number not restored: %+v", record)
+	}
+
+	// A restart without the key keeps the sealed value for the next one with it
+	keyless := open(nil)
+	if record, _ := keyless.GetKYCRecord(user.ID); record == nil || record.DocumentNumber != "" {
+		t.Fatalf("keyless store opened the document number: %+v", record)
+	}
+	if err := keyless.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if record, _ := open(fieldCipher).GetKYCRecord(user.ID); record == nil || record.DocumentNumber != docNumber {
+		t.Errorf("document number lost after a keyless restart: %+v", record)
+	}
+}
@@ -0,0 +1,143 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// openLotsPosition fills 10 YES @ 40 then 10 YES @ 60: $10.00 cost over two
+// lots.
+func openLotsPosition(t *testing.T, s *Store, email string) (string, string) {
+	t.Helper()
+	user := newTradingUser(t, s, email, 100)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 60)
+	positions, _ := s.GetPositions(user.ID)
+	if len(positions) != 1 || positions[0].Quantity != 20 || positions[0].CostBasisUSD != models.Dollars(10) {
+		t.Fatalf("positions = %+v, want 20 contracts costing $10.00", positions)
+	}
+	return user.ID, positions[0].ID
+}
+
+func TestStore_ClosePositionRealizedPnLByCostBasis(t *testing.T) {
+	tests := []struct {
+		method            CostBasisMethod
+		firstRealized     float64 // closing 10 @ 55
+		remainingCost     float64
+		remainingAvgCents int
+	}{
+		{CostBasisAverage, 0.50, 5.00, 50},
+		{CostBasisFIFO, 1.50, 6.00, 60},
+	}
+	for _, tt := range tests {
+		s := NewStore()
+		s.SetCostBasisMethod(tt.method)
+		userID, posID := openLotsPosition(t, s, string(tt.method)+"@example.com")
+
+		pos, err := s.ClosePosition(userID, posID, 10, 55, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.method, err)
+		}
+		if pos.RealizedPnL != models.Dollars(tt.firstRealized) || pos.CostBasisUSD != models.Dollars(tt.remainingCost) ||
+			pos.AvgPriceCents != tt.remainingAvgCents || pos.Quantity != 10 || pos.ClosedAt != nil {
+			t.Errorf("%s: after partial close %+v, want realized $%.2f and $%.2f cost left at %d¢",
+				tt.method, pos, tt.firstRealized, tt.remainingCost, tt.remainingAvgCents)
+		}
+		// $5.50 proceeds credited, the relieved cost unlocked
+		assertWallet(t, s, userID, 90+5.50, tt.remainingCost)
+
+		// Both methods agree once the whole position is closed
+		pos, err = s.ClosePosition(userID, posID, 10, 55, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.method, err)
+		}
+		if pos.RealizedPnL != models.Dollars(1) || pos.ClosedAt == nil || pos.Lots != nil {
+			t.Errorf("%s: after full close %+v, want $1.00 realized and closed", tt.method, pos)
+		}
+		assertWallet(t, s, userID, 101, 0)
+	}
+}
+
+func TestStore_ClosePositionFIFOAcrossLots(t *testing.T) {
+	s := NewStore()
+	s.SetCostBasisMethod(CostBasisFIFO)
+	userID, posID := openLotsPosition(t, s, "lots@example.com")
+
+	// 15 contracts: all of the 40¢ lot and half of the 60¢ lot
+	pos, err := s.ClosePosition(userID, posID, 15, 50, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.RealizedPnL != models.Dollars(0.50) || len(pos.Lots) != 1 || pos.Lots[0].Quantity != 5 ||
+		pos.Lots[0].CostUSD != models.Dollars(3) {
+		t.Errorf("after closing 15: %+v, want $0.50 realized and 5 @ 60 left", pos)
+	}
+
+	if _, err := s.ClosePosition(userID, posID, 6, 50, ""); err != ErrInvalidClose {
+		t.Errorf("closing more than held: err = %v, want ErrInvalidClose", err)
+	}
+	other := newTradingUser(t, s, "other@example.com", 100)
+	if _, err := s.ClosePosition(other.ID, posID, 1, 50, ""); err != ErrPositionNotFound {
+		t.Errorf("another user's position: err = %v, want ErrPositionNotFound", err)
+	}
+}
+
+// A position filled under average cost switches to FIFO as a single lot.
+func TestStore_CostBasisSwitchToFIFO(t *testing.T) {
+	s := NewStore()
+	userID, posID := openLotsPosition(t, s, "switch@example.com")
+	s.SetCostBasisMethod(CostBasisFIFO)
+	fillOrder(t, s, userID, "FED-24DEC", models.OrderSideYes, 10, 80)
+
+	pos, err := s.ClosePosition(userID, posID, 20, 50, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first 20 contracts cost $10.00 as one lot; the 80¢ lot remains
+	if pos.RealizedPnL != 0 || pos.CostBasisUSD != models.Dollars(8) || pos.AvgPriceCents != 80 {
+		t.Errorf("after switch: %+v, want $0 realized and the $8.00 lot left", pos)
+	}
+}
+
+func TestStore_CloseMarketPositionsBothSides(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "both@example.com", 100)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40) // $4.00
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideNo, 10, 30)  // $7.00
+	fillOrder(t, s, user.ID, "CPI-FEB", models.OrderSideYes, 5, 50)    // $2.50
+
+	if _, err := s.CloseMarketPositions(user.ID, "FED-24DEC", 101, 43, ""); err != ErrInvalidClose {
+		t.Errorf("bid over 100¢: err = %v, want ErrInvalidClose", err)
+	}
+	assertWallet(t, s, user.ID, 86.50, 13.50)
+
+	result, err := s.CloseMarketPositions(user.ID, "FED-24DEC", 55, 43, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// YES: $5.50 for $4.00; NO: $4.30 for $7.00
+	if len(result.Legs) != 2 || result.ProceedsUSD != models.Dollars(9.80) || result.RealizedPnLUSD != models.Dollars(-1.20) {
+		t.Errorf("close = %+v, want two legs, $9.80 proceeds and -$1.20 realized", result)
+	}
+	for _, leg := range result.Legs {
+		wantPrice := 55
+		if leg.Side == models.OrderSideNo {
+			wantPrice = 43
+		}
+		if leg.Quantity != 10 || leg.PriceCents != wantPrice {
+			t.Errorf("%s leg = %+v, want 10 closed at %d¢", leg.Side, leg, wantPrice)
+		}
+	}
+	assertWallet(t, s, user.ID, 86.50+9.80, 2.50)
+
+	positions, _ := s.GetPositions(user.ID)
+	for _, pos := range positions {
+		if open := pos.ClosedAt == nil; open != (pos.MarketTicker == "CPI-FEB") {
+			t.Errorf("%s %s: open = %v after closing FED-24DEC", pos.MarketTicker, pos.Side, open)
+		}
+	}
+	if _, err := s.CloseMarketPositions(user.ID, "FED-24DEC", 55, 43, ""); err != ErrPositionNotFound {
+		t.Errorf("closing again: err = %v, want ErrPositionNotFound", err)
+	}
+}
@@ -0,0 +1,60 @@
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestSeedDemoData_IsIdempotent(t *testing.T) {
+	s := NewStore()
+	s.SetDepositClearingDelay(time.Hour)
+
+	created, err := SeedDemoData(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != len(demoUsers) {
+		t.Fatalf("created = %d, want %d", created, len(demoUsers))
+	}
+	_, total := s.ListUsers(UserFilter{}, 0, 10)
+	if total != 3 {
+		t.Fatalf("seeded %d users, want 3", total)
+	}
+	if again, err := SeedDemoData(s); err != nil || again != 0 {
+		t.Fatalf("second seed created %d (%v), want 0", again, err)
+	}
+	if _, after := s.ListUsers(UserFilter{}, 0, 10); after != total {
+		t.Errorf("users after reseeding = %d, want %d", after, total)
+	}
+
+	verified, _ := s.ListUsers(UserFilter{Status: models.UserStatusVerified}, 0, 10)
+	if len(verified) != 2 {
+		t.Fatalf("verified users = %d, want 2", len(verified))
+	}
+	for _, u := range verified {
+		if u.OpenPositions == 0 || u.ExposureUSD == 0 {
+			t.Errorf("%s: %d positions, %s exposure; want filled positions", u.Email, u.OpenPositions, u.ExposureUSD)
+		}
+	}
+	trader1, _ := s.GetUserByEmail("trader1@example.com")
+	// $10,000 deposited and cleared; 100 YES @ 65 and 50 NO @ 48 lock $65 + $26
+	assertWallet(t, s, trader1.ID, 9909, 91)
+	if bcrypt.CompareHashAndPassword([]byte(trader1.PasswordHash), []byte(DemoPassword)) != nil {
+		t.Error("seeded account does not accept DemoPassword")
+	}
+}
+
+func TestSeedDemoData_SkipsStoreWithUsers(t *testing.T) {
+	s := NewStore()
+	newTradingUser(t, s, "existing@example.com", 100)
+	if created, err := SeedDemoData(s); err != nil || created != 0 {
+		t.Errorf("seeding a populated store created %d (%v)", created, err)
+	}
+	if _, total := s.ListUsers(UserFilter{}, 0, 10); total != 1 {
+		t.Errorf("users = %d, want only the existing one", total)
+	}
+}
@@ -0,0 +1,159 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_SettleMarketPaysWinners(t *testing.T) {
+	s := NewStore()
+	yes := newTradingUser(t, s, "yes@example.com", 100)
+	no := newTradingUser(t, s, "no@example.com", 100)
+	fillOrder(t, s, yes.ID, "FED-24DEC", models.OrderSideYes, 10, 40) // $4.00 locked
+	fillOrder(t, s, no.ID, "FED-24DEC", models.OrderSideNo, 10, 40)   // $6.00 locked
+
+	settled, err := s.SettleMarket("FED-24DEC", "yes", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled != 2 {
+		t.Fatalf("expected 2 positions settled, got %d", settled)
+	}
+	assertWallet(t, s, yes.ID, 106, 0)
+	assertWallet(t, s, no.ID, 94, 0)
+
+	if open, _ := s.GetPositions(yes.ID); len(open) != 0 {
+		t.Errorf("expected no open positions after settlement, got %d", len(open))
+	}
+	for _, pos := range s.GetAllPositions() {
+		if pos.UserID == yes.ID && pos.RealizedPnL != models.Dollars(6) {
+			t.Errorf("winning position realized PnL = %s, want $6.00", pos.RealizedPnL)
+		}
+	}
+	if len(s.OpenPositionTickers()) != 0 {
+		t.Errorf("expected no open position tickers after settlement")
+	}
+}
+
+func TestStore_SettleMarketVoidRefundsAndCancelsOrders(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "void@example.com", 100)
+	fillOrder(t, s, user.ID, "CPI-24NOV", models.OrderSideYes, 10, 30)
+	pending, err := s.CreateOrder(user.ID, "CPI-24NOV", "EVT", models.OrderSideYes, models.OrderTypeLimit, 5, 20, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.SettleMarket("CPI-24NOV", "void", "test"); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 100, 0)
+
+	orders, _ := s.GetOrders(user.ID, nil, 10)
+	for _, o := range orders {
+		if o.ID == pending.ID && o.Status != models.OrderStatusCancelled {
+			t.Errorf("pending order status = %s, want cancelled", o.Status)
+		}
+	}
+}
+
+func TestStore_SettleMarketOnlyOnce(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "once@example.com", 100)
+	fillOrder(t, s, user.ID, "GDP-Q4", models.OrderSideYes, 10, 50)
+
+	if _, err := s.SettleMarket("GDP-Q4", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SettleMarket("GDP-Q4", "yes", "test"); !errors.Is(err, ErrMarketAlreadySettled) {
+		t.Fatalf("expected ErrMarketAlreadySettled, got %v", err)
+	}
+	if !s.IsMarketSettled("GDP-Q4") {
+		t.Error("market not recorded as settled")
+	}
+	assertWallet(t, s, user.ID, 105, 0)
+
+	if _, err := s.SettleMarket("OTHER", "maybe", "test"); !errors.Is(err, ErrInvalidResult) {
+		t.Errorf("expected ErrInvalidResult, got %v", err)
+	}
+}
+
+func TestStore_SettledMarketRejectsOrdersAndFills(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "closed@example.com", 100)
+	fillOrder(t, s, user.ID, "GDP-Q4", models.OrderSideYes, 10, 50)
+	pending, err := s.CreateOrder(user.ID, "GDP-Q4", "GDP", models.OrderSideYes, models.OrderTypeLimit, 4, 25, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.SettleMarket("GDP-Q4", "no", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PartialFill(pending.ID, 1, 25); err == nil {
+		t.Error("order in a settled market was filled")
+	}
+	if _, err := s.CreateOrder(user.ID, "GDP-Q4", "GDP", models.OrderSideNo, models.OrderTypeLimit, 1, 60, "127.0.0.1"); !errors.Is(err, ErrMarketAlreadySettled) {
+		t.Errorf("order after settlement: err = %v, want ErrMarketAlreadySettled", err)
+	}
+	// The losing position is gone and the pending order's collateral is back
+	assertWallet(t, s, user.ID, 95, 0)
+}
+
+func TestStore_SettleMarketIsAllOrNothing(t *testing.T) {
+	s := NewStore()
+	alice := newTradingUser(t, s, "alice@example.com", 100)
+	bob := newTradingUser(t, s, "bob@example.com", 100)
+	fillOrder(t, s, alice.ID, "GDP-Q4", models.OrderSideYes, 10, 50)
+	fillOrder(t, s, bob.ID, "GDP-Q4", models.OrderSideNo, 10, 50)
+	s.walletsMu.Lock()
+	bobWallet := s.wallets[bob.ID]
+	delete(s.wallets, bob.ID)
+	s.walletsMu.Unlock()
+
+	if _, err := s.SettleMarket("GDP-Q4", "yes", "test"); !errors.Is(err, ErrWalletNotFound) {
+		t.Fatalf("err = %v, want ErrWalletNotFound", err)
+	}
+	if s.IsMarketSettled("GDP-Q4") {
+		t.Error("failed settlement marked the market settled")
+	}
+	assertWallet(t, s, alice.ID, 95, 5)
+
+	s.walletsMu.Lock()
+	s.wallets[bob.ID] = bobWallet
+	s.walletsMu.Unlock()
+	if n, err := s.SettleMarket("GDP-Q4", "yes", "test"); err != nil || n != 2 {
+		t.Fatalf("retry settled %d (%v), want 2", n, err)
+	}
+	assertWallet(t, s, alice.ID, 105, 0)
+	assertWallet(t, s, bob.ID, 95, 0)
+}
+
+// A settlement decided by a fallback source records it.
+func TestStore_SettleMarketFromSourceRecordsSource(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "source@example.com", 100)
+	fillOrder(t, s, user.ID, "CPI-24NOV", models.OrderSideYes, 10, 50)
+
+	closed := time.Now().UTC().Add(-25 * time.Hour)
+	rule := kalshi.DefaultSettlementRules()["CPI"]
+	source, ok := rule.SelectSource(closed, time.Now().UTC(), map[string]time.Time{"reuters.com": closed.Add(time.Hour)})
+	if !ok {
+		t.Fatal("no source selected after the extension window")
+	}
+	if _, err := s.SettleMarketFromSource("CPI-24NOV", "yes", "CPI above 3%", source); err != nil {
+		t.Fatal(err)
+	}
+	settlements := s.GetSettlements(user.ID, "CPI-24NOV", 10)
+	if len(settlements) != 1 {
+		t.Fatalf("settlements = %d, want 1", len(settlements))
+	}
+	got := settlements[0].Source
+	if got == nil || got.Name != "reuters.com" || got.Tier != models.SourceTierSecondary || !got.Extended {
+		t.Errorf("source = %+v, want the extended secondary reuters.com", got)
+	}
+}
@@ -0,0 +1,200 @@
+package mock
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_ListUsersFiltersAndPages(t *testing.T) {
+	s := NewStore()
+	base := time.Now().UTC().Add(-time.Hour)
+	verifiedNY := newTradingUser(t, s, "alice@example.com", 100)
+	verifiedNY.CreatedAt = base
+	fillOrder(t, s, verifiedNY.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	s.CreateComplianceAlert(verifiedNY.ID, "FED-24DEC", "wash_trading", "high", "test")
+
+	pendingCA, err := s.CreateUser("bob@example.com", "hash", "Bob", "Builder", "CA", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pendingCA.CreatedAt = base.Add(time.Minute)
+	suspendedNY := newTradingUser(t, s, "carol@example.com", 100)
+	suspendedNY.CreatedAt = base.Add(2 * time.Minute)
+	if err := s.UpdateUserStatus(suspendedNY.ID, models.UserStatusSuspended, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	emails := func(users []models.UserSummary) []string {
+		out := make([]string, len(users))
+		for i, u := range users {
+			out[i] = u.Email
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		filter UserFilter
+		want   []string
+	}{
+		{"all newest first", UserFilter{}, []string{"carol@example.com", "bob@example.com", "alice@example.com"}},
+		{"status", UserFilter{Status: models.UserStatusVerified}, []string{"alice@example.com"}},
+		{"state", UserFilter{StateCode: "ny"}, []string{"carol@example.com", "alice@example.com"}},
+		{"status and state", UserFilter{Status: models.UserStatusSuspended, StateCode: "CA"}, nil},
+		{"query by name", UserFilter{Query: "builder"}, []string{"bob@example.com"}},
+	}
+	for _, tt := range tests {
+		got, total := s.ListUsers(tt.filter, 0, 50)
+		if total != len(tt.want) || len(got) != len(tt.want) {
+			t.Errorf("%s: got %v (total %d), want %v", tt.name, emails(got), total, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Email != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, emails(got), tt.want)
+				break
+			}
+		}
+	}
+
+	page, total := s.ListUsers(UserFilter{}, 1, 1)
+	if total != 3 || len(page) != 1 || page[0].Email != "bob@example.com" {
+		t.Errorf("page 2 of 1 = %v (total %d), want bob of 3", emails(page), total)
+	}
+	if page, total := s.ListUsers(UserFilter{}, 5, 10); total != 3 || len(page) != 0 {
+		t.Errorf("offset past the end = %v (total %d)", emails(page), total)
+	}
+
+	got, _ := s.ListUsers(UserFilter{Status: models.UserStatusVerified}, 0, 10)
+	if a := got[0]; a.ExposureUSD != models.Dollars(4) || a.OpenPositions != 1 || a.OpenAlerts != 1 {
+		t.Errorf("alice summary = %+v, want $4.00 exposure, 1 position, 1 alert", a)
+	}
+}
+
+func TestStore_LiquidateUserLeavesBookFlat(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "liquidate@example.com", 100)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40) // $4.00 -> $5.50 at 55¢
+	fillOrder(t, s, user.ID, "CPI-24DEC", models.OrderSideNo, 5, 30)   // $3.50 (70¢ each) -> $1.00 at 20¢
+	if _, err := s.CreateOrder(user.ID, "GDP-24Q4", "GDP", models.OrderSideYes, models.OrderTypeLimit, 10, 25, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	assertWallet(t, s, user.ID, 90.00, 10.00)
+
+	marks := map[string]Mark{"FED-24DEC": {YesBid: 55, NoBid: 20}, "CPI-24DEC": {YesBid: 55, NoBid: 20}}
+	result, err := s.LiquidateUser(user.ID, marks, "account banned", "admin_1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OrdersCancelled != 1 || result.PositionsClosed != 2 ||
+		result.ProceedsUSD != models.Dollars(6.50) || result.PnLUSD != models.Dollars(-1) {
+		t.Errorf("liquidation = %+v, want 1 order, 2 positions, $6.50 proceeds, -$1.00 P&L", result)
+	}
+
+	assertWallet(t, s, user.ID, 99.00, 0)
+	if positions, _ := s.GetPositions(user.ID); len(positions) != 0 {
+		t.Errorf("open positions after liquidation: %+v", positions)
+	}
+	if exposure := s.GetUserExposure(user.ID); exposure != 0 {
+		t.Errorf("exposure = %s, want $0.00", exposure)
+	}
+	if s.countOpenOrders(user.ID) != 0 {
+		t.Error("resting order survived liquidation")
+	}
+	settlements := s.GetSettlements(user.ID, "", 10)
+	if len(settlements) != 2 || settlements[0].Result != "liquidated" || settlements[0].Reason != "account banned" {
+		t.Errorf("settlements = %+v, want 2 liquidated", settlements)
+	}
+
+	// A second run has nothing left to unwind
+	if again, err := s.LiquidateUser(user.ID, marks, "again", "admin_1", ""); err != nil || again.OrdersCancelled != 0 || again.PositionsClosed != 0 {
+		t.Errorf("second liquidation = %+v, %v", again, err)
+	}
+	if _, err := s.LiquidateUser("user_missing", nil, "x", "admin_1", ""); err != ErrUserNotFound {
+		t.Errorf("unknown user: err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestStore_LiquidateUserWithoutMarksClosesAtEntry(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "entry@example.com", 100)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+
+	result, err := s.LiquidateUser(user.ID, nil, "suspended", "admin_1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ProceedsUSD != models.Dollars(4) || result.PnLUSD != 0 {
+		t.Errorf("liquidation = %+v, want $4.00 back at entry", result)
+	}
+	assertWallet(t, s, user.ID, 100, 0)
+}
+
+func TestStore_LiquidateUserWithoutWalletChangesNothing(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "nowallet@example.com", 100)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if _, err := s.CreateOrder(user.ID, "GDP-24Q4", "GDP", models.OrderSideYes, models.OrderTypeLimit, 10, 25, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	s.walletsMu.Lock()
+	wallet := s.wallets[user.ID]
+	delete(s.wallets, user.ID)
+	s.walletsMu.Unlock()
+
+	if _, err := s.LiquidateUser(user.ID, nil, "banned", "admin_1", ""); err != ErrWalletNotFound {
+		t.Fatalf("err = %v, want ErrWalletNotFound", err)
+	}
+	s.walletsMu.Lock()
+	s.wallets[user.ID] = wallet
+	s.walletsMu.Unlock()
+	if positions, _ := s.GetPositions(user.ID); len(positions) != 1 || s.countOpenOrders(user.ID) != 1 {
+		t.Errorf("failed liquidation left %d positions and %d orders, want 1 and 1", len(positions), s.countOpenOrders(user.ID))
+	}
+	assertWallet(t, s, user.ID, 93.50, 6.50)
+}
+
+func TestStore_ReinstateUserRestoresPreviousStatus(t *testing.T) {
+	s := NewStore()
+	pending, err := s.CreateUser("pending@example.com", "hash", "Pat", "Pending", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUserStatus(pending.ID, models.UserStatusVerified, ""); err != ErrKYCNotApproved {
+		t.Errorf("verifying without KYC: err = %v, want ErrKYCNotApproved", err)
+	}
+
+	before, _ := s.GetUser(pending.ID)
+	if err := s.RestrictUser(pending.ID, models.UserStatusSuspended, "admin_1", "document review", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RestrictUser(pending.ID, models.UserStatusBanned, "admin_1", "fraud confirmed", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RestrictUser(pending.ID, models.UserStatusSuspended, "admin_1", "downgrade", ""); err != ErrInvalidStatusChange {
+		t.Errorf("suspending a banned user: err = %v, want ErrInvalidStatusChange", err)
+	}
+	status, err := s.ReinstateUser(pending.ID, "admin_2", "appeal upheld", "10.0.0.2")
+	if err != nil || status != before.Status {
+		t.Fatalf("reinstated to %q (%v), want %q", status, err, before.Status)
+	}
+	if _, err := s.ReinstateUser(pending.ID, "admin_2", "again", ""); err != ErrInvalidStatusChange {
+		t.Errorf("reinstating an active user: err = %v, want ErrInvalidStatusChange", err)
+	}
+
+	trader := newTradingUser(t, s, "trader@example.com", 100)
+	if err := s.RestrictUser(trader.ID, models.UserStatusSuspended, "admin_1", "review", ""); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := s.ReinstateUser(trader.ID, "admin_1", "cleared", ""); err != nil || status != models.UserStatusVerified {
+		t.Errorf("verified trader reinstated to %q (%v), want verified", status, err)
+	}
+
+	entries := s.GetAuditLog("admin_2", time.Time{}, 10)
+	if len(entries) != 1 || entries[0].EntityID != pending.ID ||
+		!strings.Contains(entries[0].Description, "by admin_2: appeal upheld") {
+		t.Errorf("admin audit = %+v, want the reinstatement by admin_2 with its reason", entries)
+	}
+}
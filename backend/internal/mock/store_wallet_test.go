@@ -0,0 +1,411 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestStore_WithdrawalHeldThenReleased(t *testing.T) {
+	s := NewStore()
+	s.SetWithdrawalHold(time.Hour)
+	user := newTradingUser(t, s, "withdraw@example.com", 100)
+
+	tx, err := s.Withdraw(user.ID, models.Dollars(30), "ref", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Status != models.TxStatusPending || tx.AvailableAt == nil {
+		t.Fatalf("withdrawal status = %s, available_at = %v", tx.Status, tx.AvailableAt)
+	}
+	assertWallet(t, s, user.ID, 70, 0)
+	assertPending(t, s, user.ID, 30, 0)
+
+	pending, _ := s.GetPendingTransactions(user.ID)
+	if len(pending) != 1 || pending[0].ID != tx.ID {
+		t.Fatalf("expected the withdrawal to be pending, got %+v", pending)
+	}
+
+	// Still inside the hold period.
+	if n := s.ReleasePendingTransactions(time.Now().Add(30 * time.Minute)); n != 0 {
+		t.Fatalf("released %d withdrawals during the hold", n)
+	}
+	assertPending(t, s, user.ID, 30, 0)
+
+	if n := s.ReleasePendingTransactions(time.Now().Add(time.Hour + time.Second)); n != 1 {
+		t.Fatalf("expected 1 withdrawal released, got %d", n)
+	}
+	assertWallet(t, s, user.ID, 70, 0)
+	assertPending(t, s, user.ID, 0, 30)
+	if pending, _ := s.GetPendingTransactions(user.ID); len(pending) != 0 {
+		t.Errorf("expected no pending transactions after release, got %d", len(pending))
+	}
+
+	// Releasing again is a no-op.
+	if n := s.ReleasePendingTransactions(time.Now().Add(2 * time.Hour)); n != 0 {
+		t.Errorf("released %d withdrawals twice", n)
+	}
+	assertPending(t, s, user.ID, 0, 30)
+}
+
+func TestStore_WithdrawalWithoutHoldCompletes(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "instant@example.com", 100)
+
+	tx, err := s.Withdraw(user.ID, models.Dollars(25), "ref", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Status != models.TxStatusCompleted {
+		t.Errorf("status = %s, want completed", tx.Status)
+	}
+	assertWallet(t, s, user.ID, 75, 0)
+	assertPending(t, s, user.ID, 0, 25)
+}
+
+func TestStore_WithdrawRejectsInvalidAmounts(t *testing.T) {
+	s := NewStore()
+	s.SetWithdrawalHold(time.Hour)
+	user := newTradingUser(t, s, "reject@example.com", 100)
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Locked collateral cannot be withdrawn.
+	if _, err := s.Withdraw(user.ID, models.Dollars(100), "ref", "127.0.0.1"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+	if _, err := s.Withdraw(user.ID, 0, "ref", "127.0.0.1"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("expected ErrInvalidAmount, got %v", err)
+	}
+	assertWallet(t, s, user.ID, 96, 4)
+	assertPending(t, s, user.ID, 0, 0)
+}
+
+func TestStore_DepositClearsAfterDelay(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "ach@example.com", 50)
+	s.SetDepositClearingDelay(time.Minute)
+
+	tx, err := s.Deposit(user.ID, models.Dollars(100), "ref", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Status != models.TxStatusPending || tx.AvailableAt == nil {
+		t.Fatalf("deposit status = %s, available_at = %v", tx.Status, tx.AvailableAt)
+	}
+	assertWallet(t, s, user.ID, 50, 0)
+	assertPending(t, s, user.ID, 100, 0)
+
+	// Pending funds cannot back an order.
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 100, 60, "127.0.0.1"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds against pending funds, got %v", err)
+	}
+
+	if n := s.ReleasePendingTransactions(time.Now().Add(time.Minute + time.Second)); n != 1 {
+		t.Fatalf("expected 1 deposit cleared, got %d", n)
+	}
+	assertWallet(t, s, user.ID, 150, 0)
+	assertPending(t, s, user.ID, 0, 0)
+	wallet, _ := s.GetWallet(user.ID)
+	if wallet.TotalDeposited != models.Dollars(150) {
+		t.Errorf("total deposited = %s, want $150.00", wallet.TotalDeposited)
+	}
+	txs, _ := s.GetTransactions(user.ID, 1)
+	if txs[0].Status != models.TxStatusCompleted || txs[0].BalanceAfter != models.Dollars(150) {
+		t.Errorf("cleared deposit = %s balance_after %s", txs[0].Status, txs[0].BalanceAfter)
+	}
+
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideYes, models.OrderTypeLimit, 100, 60, "127.0.0.1"); err != nil {
+		t.Errorf("order against cleared funds: %v", err)
+	}
+}
+
+func TestStore_DepositViaEnforcesMethodRules(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "methods@example.com", 0)
+	methods := DefaultDepositMethods()
+	for i := range methods {
+		if methods[i].Method == models.DepositMethodWire {
+			methods[i].ClearingDelay = time.Hour
+		}
+	}
+	s.SetDepositMethods(methods)
+
+	cases := []struct {
+		method    models.DepositMethod
+		amountUSD float64
+		reference string
+		want      error
+	}{
+		{models.DepositMethodACH, 20000, "123456789012345", ErrDepositAboveMaximum},
+		{models.DepositMethodWire, 50, "20240115ABCD1234000042", ErrDepositBelowMinimum},
+		{models.DepositMethodCard, 3000, "A1B2C3", ErrDepositAboveMaximum},
+		{models.DepositMethodACH, 100, "MOCK_ACH_1", ErrInvalidReference},
+		{models.DepositMethodWire, 500, "123456789012345", ErrInvalidReference},
+		{models.DepositMethodCard, 100, "A1B2C3D4", ErrInvalidReference},
+		{"check", 100, "1001", ErrDepositMethod},
+		{models.DepositMethodCard, 0, "A1B2C3", ErrInvalidAmount},
+	}
+	for _, c := range cases {
+		if _, err := s.DepositVia(user.ID, c.method, models.Dollars(c.amountUSD), c.reference, "127.0.0.1"); !errors.Is(err, c.want) {
+			t.Errorf("%s $%.2f %q: err = %v, want %v", c.method, c.amountUSD, c.reference, err, c.want)
+		}
+	}
+	assertWallet(t, s, user.ID, 0, 0)
+
+	// A card deposit clears at once; the wire waits out its hold
+	card, err := s.DepositVia(user.ID, models.DepositMethodCard, models.Dollars(2500), "A1B2C3", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wire, err := s.DepositVia(user.ID, models.DepositMethodWire, models.Dollars(500), "20240115ABCD1234000042", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Status != models.TxStatusCompleted || card.Method != models.DepositMethodCard || card.Reference != "A1B2C3" {
+		t.Errorf("card deposit = %s via %q ref %q", card.Status, card.Method, card.Reference)
+	}
+	if wire.Status != models.TxStatusPending || wire.Method != models.DepositMethodWire {
+		t.Errorf("wire deposit = %s via %q, want pending wire", wire.Status, wire.Method)
+	}
+	assertWallet(t, s, user.ID, 2500, 0)
+	assertPending(t, s, user.ID, 500, 0)
+
+	// Methods left out of the configuration are refused
+	s.SetDepositMethods(methods[:1])
+	if _, err := s.DepositVia(user.ID, models.DepositMethodCard, models.Dollars(100), "A1B2C3", "127.0.0.1"); !errors.Is(err, ErrDepositMethod) {
+		t.Errorf("disabled card deposit: err = %v", err)
+	}
+}
+
+func TestStore_DepositReferenceUsedOncePerMethod(t *testing.T) {
+	s := NewStore()
+	alice := newTradingUser(t, s, "alice@example.com", 0)
+	bob := newTradingUser(t, s, "bob@example.com", 0)
+
+	if _, err := s.DepositVia(alice.ID, models.DepositMethodCard, models.Dollars(100), "A1B2C3", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DepositVia(bob.ID, models.DepositMethodCard, models.Dollars(100), "A1B2C3", "127.0.0.1"); !errors.Is(err, ErrDuplicateReference) {
+		t.Errorf("reused card authorization: err = %v, want ErrDuplicateReference", err)
+	}
+	// The same digits are a different reference under another method
+	if _, err := s.DepositVia(bob.ID, models.DepositMethodACH, models.Dollars(100), "123456789012345", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DepositVia(alice.ID, models.DepositMethodACH, models.Dollars(100), "123456789012345", "127.0.0.1"); !errors.Is(err, ErrDuplicateReference) {
+		t.Errorf("reused ACH trace: err = %v, want ErrDuplicateReference", err)
+	}
+	assertWallet(t, s, alice.ID, 100, 0)
+	assertWallet(t, s, bob.ID, 100, 0)
+}
+
+func TestStore_SetDepositClearingDelayKeepsACHConfig(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "ach@example.com", 0)
+	s.SetDepositClearingDelay(time.Minute)
+	methods := s.DepositMethods()
+	if methods[0].Method != models.DepositMethodACH || methods[0].ClearingDelay != time.Minute ||
+		methods[0].MinUSD != models.Dollars(1) || methods[0].MaxUSD != models.Dollars(10000) {
+		t.Errorf("ACH = %+v, want the default limits with a 1m delay", methods[0])
+	}
+
+	s.SetDepositMethods(DefaultDepositMethods()[1:])
+	s.SetDepositClearingDelay(time.Hour)
+	if _, err := s.DepositVia(user.ID, models.DepositMethodACH, models.Dollars(100), "123456789012345", "127.0.0.1"); !errors.Is(err, ErrDepositMethod) {
+		t.Errorf("disabled ACH re-enabled by the delay: err = %v", err)
+	}
+}
+
+func TestStore_ReverseDeposit(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "reverse@example.com", 100)
+	deposit, err := s.Deposit(user.ID, models.Dollars(40), "ref", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refund, err := s.ReverseTransaction(deposit.ID, "ACH return", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.Type != models.TxTypeRefund || refund.Reference != deposit.ID || refund.AmountUSD != models.Dollars(40) {
+		t.Errorf("refund = %+v", refund)
+	}
+	assertWallet(t, s, user.ID, 100, 0)
+	wallet, _ := s.GetWallet(user.ID)
+	if wallet.TotalDeposited != models.Dollars(100) {
+		t.Errorf("total deposited = %s, want $100.00", wallet.TotalDeposited)
+	}
+
+	txs, _ := s.GetTransactions(user.ID, 10)
+	for _, tx := range txs {
+		if tx.ID == deposit.ID && tx.Status != models.TxStatusReversed {
+			t.Errorf("original status = %s, want reversed", tx.Status)
+		}
+	}
+	if findAudit(s, "transaction", deposit.ID, models.AuditActionUpdate) == nil ||
+		findAudit(s, "transaction", refund.ID, models.AuditActionCreate) == nil {
+		t.Error("expected audit entries for both the original and the refund")
+	}
+
+	if _, err := s.ReverseTransaction(deposit.ID, "again", "127.0.0.1"); !errors.Is(err, ErrNotReversible) {
+		t.Errorf("second reversal: expected ErrNotReversible, got %v", err)
+	}
+	if _, err := s.ReverseTransaction(refund.ID, "undo", "127.0.0.1"); !errors.Is(err, ErrNotReversible) {
+		t.Errorf("refund reversal: expected ErrNotReversible, got %v", err)
+	}
+}
+
+func TestStore_ReverseRejectsSettlementAndSpentDeposit(t *testing.T) {
+	s := NewStore()
+	user := newTradingUser(t, s, "settled-reverse@example.com", 10)
+	fillOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	if _, err := s.SettleMarket("FED-24DEC", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	txs, _ := s.GetTransactions(user.ID, 10)
+	var settlementID, depositID string
+	for _, tx := range txs {
+		switch tx.Type {
+		case models.TxTypeSettlement:
+			settlementID = tx.ID
+		case models.TxTypeDeposit:
+			depositID = tx.ID
+		}
+	}
+	if _, err := s.ReverseTransaction(settlementID, "dispute", "127.0.0.1"); !errors.Is(err, ErrNotReversible) {
+		t.Errorf("settlement reversal: expected ErrNotReversible, got %v", err)
+	}
+
+	// $10 deposited, $4 spent on the position and $10 paid out leaves $16.
+	if _, err := s.Withdraw(user.ID, models.Dollars(16), "ref", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReverseTransaction(depositID, "ACH return", "127.0.0.1"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("spent deposit reversal: expected ErrInsufficientFunds, got %v", err)
+	}
+	if _, err := s.ReverseTransaction("tx_missing", "x", "127.0.0.1"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestStore_ReconcileWallet(t *testing.T) {
+	s := NewStore()
+	s.SetFeeSchedule(models.FeeSchedule{TradeFeePerContractUSD: models.Dollars(0.01), SettlementFeePercent: 10})
+	user := newTradingUser(t, s, "recon@example.com", 200)
+
+	// Exercise every kind of funds movement: fills with fees, a partial
+	// close, a settlement, a cancelled order, a held withdrawal, a pending
+	// deposit and a reversed deposit.
+	order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 20, 40)
+	if err := s.MockFillOrder(order.ID, 38); err != nil {
+		t.Fatal(err)
+	}
+	positions, _ := s.GetPositions(user.ID)
+	if _, err := s.ClosePosition(user.ID, positions[0].ID, 5, 55, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SettleMarket("FED-24DEC", "yes", "test"); err != nil {
+		t.Fatal(err)
+	}
+	placeOrder(t, s, user.ID, "CPI-24DEC", models.OrderSideNo, 10, 30)
+	s.SetWithdrawalHold(time.Hour)
+	if _, err := s.Withdraw(user.ID, models.Dollars(15), "ach", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	extra, err := s.Deposit(user.ID, models.Dollars(40), "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReverseTransaction(extra.ID, "duplicate", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	s.SetDepositClearingDelay(time.Minute)
+	if _, err := s.Deposit(user.ID, models.Dollars(10), "test", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.ReconcileWallet(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Balanced || result.ExpectedPendingUSD != models.Dollars(25) {
+		t.Fatalf("reconciliation = %+v, want balanced with $25 pending", result)
+	}
+
+	// A balance changed outside the ledger is flagged
+	s.walletsMu.Lock()
+	s.wallets[user.ID].AvailableUSD += models.Cents(1)
+	s.walletsMu.Unlock()
+	result, _ = s.ReconcileWallet(user.ID)
+	if result.Balanced || len(result.Discrepancies) != 2 || result.ActualTotalUSD-result.ExpectedTotalUSD != models.Cents(1) {
+		t.Errorf("after a 1¢ drift = %+v, want total and available discrepancies", result)
+	}
+	if results := s.ReconcileWallets(); len(results) != 1 || results[0].Balanced {
+		t.Errorf("ReconcileWallets = %+v", results)
+	}
+
+	// So is one that moves funds between accounts, leaving the total intact
+	s.walletsMu.Lock()
+	s.wallets[user.ID].AvailableUSD -= models.Dollars(1) + models.Cents(1)
+	s.wallets[user.ID].LockedUSD += models.Dollars(1)
+	s.walletsMu.Unlock()
+	result, _ = s.ReconcileWallet(user.ID)
+	if result.Balanced || len(result.Discrepancies) != 2 || result.ActualTotalUSD != result.ExpectedTotalUSD {
+		t.Errorf("after moving $1 to locked = %+v, want available and locked discrepancies", result)
+	}
+
+	if _, err := s.ReconcileWallet("missing"); !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("unknown wallet: err = %v", err)
+	}
+}
+
+func TestStore_UnlockBeyondLockedRefused(t *testing.T) {
+	s := NewStore()
+	s.SetSelfTradePolicy(SelfTradeCancelOldest)
+	user := newTradingUser(t, s, "unlock@example.com", 100)
+	order := placeOrder(t, s, user.ID, "FED-24DEC", models.OrderSideYes, 10, 40)
+	reserve := unfilledReserve(order)
+
+	if err := s.UnlockFunds(user.ID, reserve+models.Cents(1), order.ID); !errors.Is(err, ErrUnlockExceedsLocked) {
+		t.Errorf("unlock beyond locked: err = %v, want ErrUnlockExceedsLocked", err)
+	}
+	if err := s.UnlockFunds(user.ID, -models.Cents(1), order.ID); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("negative unlock: err = %v, want ErrInvalidAmount", err)
+	}
+	assertWallet(t, s, user.ID, (models.Dollars(100) - reserve).Dollars(), reserve.Dollars())
+
+	// Locked funds short of the order's reserve: every path that would
+	// release it refuses before changing anything
+	s.walletsMu.Lock()
+	s.wallets[user.ID].LockedUSD -= models.Cents(1)
+	s.wallets[user.ID].AvailableUSD += models.Cents(1)
+	s.walletsMu.Unlock()
+	if _, err := s.CreateOrder(user.ID, "FED-24DEC", "EVT", models.OrderSideNo, models.OrderTypeLimit, 10, 30, "127.0.0.1"); !errors.Is(err, ErrUnlockExceedsLocked) {
+		t.Errorf("self-trade cancel: err = %v, want ErrUnlockExceedsLocked", err)
+	}
+	if _, err := s.SettleMarket("FED-24DEC", "yes", "test"); !errors.Is(err, ErrUnlockExceedsLocked) {
+		t.Errorf("settle: err = %v, want ErrUnlockExceedsLocked", err)
+	}
+	if _, err := s.LiquidateUser(user.ID, nil, "test", "admin", "127.0.0.1"); !errors.Is(err, ErrUnlockExceedsLocked) {
+		t.Errorf("liquidate: err = %v, want ErrUnlockExceedsLocked", err)
+	}
+	if n := s.closeOrdersWhere(func(*models.Order) bool { return true }, models.OrderStatusCancelled, "test"); n != 0 {
+		t.Errorf("sweep closed %d orders, want 0", n)
+	}
+	if _, err := s.CancelOrder(user.ID, order.ID, "127.0.0.1"); !errors.Is(err, ErrUnlockExceedsLocked) {
+		t.Errorf("cancel: err = %v, want ErrUnlockExceedsLocked", err)
+	}
+	if got, _ := s.GetOrder(user.ID, order.ID); got.Status != models.OrderStatusPending {
+		t.Errorf("order = %s, want still pending", got.Status)
+	}
+	if s.IsMarketSettled("FED-24DEC") {
+		t.Error("market settled despite the refusal")
+	}
+	assertWallet(t, s, user.ID, (models.Dollars(100) - reserve + models.Cents(1)).Dollars(), (reserve - models.Cents(1)).Dollars())
+}
@@ -4,6 +4,9 @@
 package models
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -38,14 +41,55 @@ type User struct {
 	UpdatedAt     time.Time  `json:"updated_at"`
 	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
 	KYCVerifiedAt *time.Time `json:"kyc_verified_at,omitempty"`
+	// Status held before a suspension or ban, restored on reinstatement
+	PreviousStatus UserStatus `json:"previous_status,omitempty"`
 
 	// CFTC Compliance Fields
 	// Core Principle 5: Position Limits
-	PositionLimitUSD float64 `json:"position_limit_usd"`
+	PositionLimitUSD Money  `json:"position_limit_usd"`
+	MaxOpenOrders    int    `json:"max_open_orders"`
+	LimitTier        string `json:"limit_tier,omitempty"` // Tier the limits above were taken from
 	// Core Principle 18: Recordkeeping - IP tracking for audit
 	LastLoginIP string `json:"last_login_ip,omitempty"`
 }
 
+// UserSummary is the operator view of one account in a listing.
+// Core Principle 17: Fitness review; Core Principle 5: exposure vs limit.
+type UserSummary struct {
+	ID               string     `json:"id"`
+	Email            string     `json:"email"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Status           UserStatus `json:"status"`
+	StateCode        string     `json:"state_code"`
+	PositionLimitUSD Money      `json:"position_limit_usd"`
+	ExposureUSD      Money      `json:"exposure_usd"`
+	OpenPositions    int        `json:"open_positions"`
+	OpenAlerts       int        `json:"open_alerts"` // Alerts open or under investigation
+	CreatedAt        time.Time  `json:"created_at"`
+	LastLoginAt      *time.Time `json:"last_login_at,omitempty"`
+}
+
+// Exchange-wide system status reported in AdminStats.
+const (
+	SystemStatusOperational = "operational"
+	SystemStatusWarning     = "warning" // Unresolved high or critical alerts
+	SystemStatusHalted      = "halted"  // Global trading halt in effect
+)
+
+// AdminStats is the operator dashboard's summary of the exchange.
+// Core Principle 4: Surveillance overview; Core Principle 9: market state.
+type AdminStats struct {
+	ActiveUsers    int       `json:"active_users"` // Verified accounts able to trade
+	OpenPositions  int       `json:"open_positions"`
+	TotalVolume24h int64     `json:"total_volume_24h"` // Contracts filled in the last 24 hours
+	OpenAlerts     int       `json:"open_alerts"`      // Alerts open or under investigation
+	CriticalAlerts int       `json:"critical_alerts"`  // Open alerts of critical severity
+	HaltedMarkets  int       `json:"halted_markets"`   // Markets under an individual halt
+	SystemStatus   string    `json:"system_status"`
+	LastUpdated    time.Time `json:"last_updated"`
+}
+
 // =============================================================================
 // KYC/AML MODELS
 // Core Principle 17: Fitness Standards
@@ -70,7 +114,7 @@ type KYCRecord struct {
 	Status           KYCStatus `json:"status"`
 	DocumentType     string    `json:"document_type"` // drivers_license, passport, state_id
 	DocumentNumber   string    `json:"-"`             // Encrypted, never expose
-	SubmittedAt      time.Time `json:"submitted_at"`
+	SubmittedAt      *time.Time `json:"submitted_at,omitempty"`
 	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
 	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
 	RejectionReason  string    `json:"rejection_reason,omitempty"`
@@ -106,16 +150,35 @@ const (
 	TxStatusReversed  TransactionStatus = "reversed"
 )
 
+// DepositMethod is how a deposit reaches the segregated account.
+type DepositMethod string
+
+const (
+	DepositMethodACH  DepositMethod = "ach"
+	DepositMethodWire DepositMethod = "wire"
+	DepositMethodCard DepositMethod = "card"
+)
+
+// DepositMethodConfig sets the limits and clearing delay of one deposit
+// method.
+// Core Principle 13: Funds are only usable once they have cleared.
+type DepositMethodConfig struct {
+	Method        DepositMethod `json:"method"`
+	MinUSD        Money         `json:"min_usd"`
+	MaxUSD        Money         `json:"max_usd"`
+	ClearingDelay time.Duration `json:"clearing_delay"` // 0 credits deposits immediately
+}
+
 // Wallet represents a user's segregated funds account.
 // Core Principle 13: Customer funds must be segregated.
 type Wallet struct {
 	ID              string    `json:"id"`
 	UserID          string    `json:"user_id"`
-	AvailableUSD    float64   `json:"available_usd"`    // Available for trading
-	LockedUSD       float64   `json:"locked_usd"`       // Locked in open positions
-	PendingUSD      float64   `json:"pending_usd"`      // Pending deposits/withdrawals
-	TotalDeposited  float64   `json:"total_deposited"`  // Lifetime deposits
-	TotalWithdrawn  float64   `json:"total_withdrawn"`  // Lifetime withdrawals
+	AvailableUSD    Money     `json:"available_usd"`    // Available for trading
+	LockedUSD       Money     `json:"locked_usd"`       // Locked in open positions
+	PendingUSD      Money     `json:"pending_usd"`      // Pending deposits/withdrawals
+	TotalDeposited  Money     `json:"total_deposited"`  // Lifetime deposits
+	TotalWithdrawn  Money     `json:"total_withdrawn"`  // Lifetime withdrawals
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
@@ -128,19 +191,96 @@ type Transaction struct {
 	UserID      string            `json:"user_id"`
 	Type        TransactionType   `json:"type"`
 	Status      TransactionStatus `json:"status"`
-	AmountUSD   float64           `json:"amount_usd"`
-	BalanceBefore float64         `json:"balance_before"`
-	BalanceAfter  float64         `json:"balance_after"`
+	AmountUSD   Money             `json:"amount_usd"`
+	BalanceBefore Money           `json:"balance_before"`
+	BalanceAfter  Money           `json:"balance_after"`
 	Reference   string            `json:"reference,omitempty"` // Order ID, ACH ref, etc.
 	Description string            `json:"description"`
 	CreatedAt   time.Time         `json:"created_at"`
 	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	AvailableAt *time.Time        `json:"available_at,omitempty"` // End of the hold period for pending withdrawals
+	PnLUSD      Money             `json:"pnl_usd,omitempty"`      // Settlement and trade transactions: proceeds less the cost released
+	Method      DepositMethod     `json:"method,omitempty"`       // Deposits: how the funds arrived
+	JournalIDs  []string          `json:"journal_ids,omitempty"`  // Ledger entries that posted it, oldest first
 
 	// Core Principle 18: Audit metadata
 	IPAddress   string `json:"ip_address,omitempty"`
 	UserAgent   string `json:"user_agent,omitempty"`
 }
 
+// Statement is a user's account activity for one calendar month in UTC,
+// the same bucketing as the monthly audit logs.
+// Core Principle 18: Account statements for users and regulators.
+type Statement struct {
+	UserID            string        `json:"user_id"`
+	Month             string        `json:"month"` // YYYY-MM
+	PeriodStart       time.Time     `json:"period_start"`
+	PeriodEnd         time.Time     `json:"period_end"` // Exclusive
+	OpeningBalanceUSD Money         `json:"opening_balance_usd"`
+	ClosingBalanceUSD Money         `json:"closing_balance_usd"`
+	DepositsUSD       Money         `json:"deposits_usd"`    // Completed deposits
+	WithdrawalsUSD    Money         `json:"withdrawals_usd"` // Completed withdrawals
+	FeesUSD           Money         `json:"fees_usd"`
+	RealizedPnLUSD    Money         `json:"realized_pnl_usd"` // Closes and settlements, less fees
+	Deposits          []Transaction `json:"deposits"`
+	Withdrawals       []Transaction `json:"withdrawals"`
+	Trades            []Order       `json:"trades"` // Orders filled in the month
+	Closes            []Transaction `json:"closes"` // Proceeds of positions closed in the month
+	Settlements       []Settlement  `json:"settlements"`
+}
+
+// LedgerAccount is an account in the double-entry ledger. The customer
+// accounts are held per user and mirror the wallet balances; the others
+// are the exchange's.
+type LedgerAccount string
+
+const (
+	AccountCustomerAvailable LedgerAccount = "customer_available"
+	AccountCustomerLocked    LedgerAccount = "customer_locked"
+	AccountCustomerPending   LedgerAccount = "customer_pending"
+	AccountHouseFees         LedgerAccount = "house_fees"
+	AccountSettlement        LedgerAccount = "settlement" // Counterparty to customer P&L
+	AccountExternal          LedgerAccount = "external"   // Funds outside the exchange: banks, card networks
+)
+
+// Posting changes one account's balance by AmountUSD, which is signed.
+type Posting struct {
+	Account   LedgerAccount `json:"account"`
+	AmountUSD Money         `json:"amount_usd"`
+}
+
+// JournalEntry is one balanced movement of funds: its postings sum to
+// zero, so funds are only ever moved between accounts, never created.
+// Core Principle 11: Every dollar of customer funds is accounted for.
+type JournalEntry struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`                  // Owner of the customer accounts posted to
+	TransactionID string    `json:"transaction_id,omitempty"` // Empty for locks, unlocks and opening balances
+	Description   string    `json:"description"`
+	Postings      []Posting `json:"postings"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ReconResult compares a wallet's stored balances with those implied by
+// its transaction ledger. Total is available + locked + pending funds, the
+// customer's net funds in.
+// Core Principle 13: Segregated customer funds are fully accounted for.
+type ReconResult struct {
+	UserID               string    `json:"user_id"`
+	WalletID             string    `json:"wallet_id"`
+	ExpectedTotalUSD     Money     `json:"expected_total_usd"` // Deposits less withdrawals, plus P&L, less fees
+	ActualTotalUSD       Money     `json:"actual_total_usd"`
+	ExpectedPendingUSD   Money     `json:"expected_pending_usd"` // Pending deposits and withdrawals
+	ActualPendingUSD     Money     `json:"actual_pending_usd"`
+	ExpectedDepositedUSD Money     `json:"expected_deposited_usd"`
+	ActualDepositedUSD   Money     `json:"actual_deposited_usd"`
+	ExpectedWithdrawnUSD Money     `json:"expected_withdrawn_usd"`
+	ActualWithdrawnUSD   Money     `json:"actual_withdrawn_usd"`
+	Balanced             bool      `json:"balanced"`
+	Discrepancies        []string  `json:"discrepancies,omitempty"`
+	CheckedAt            time.Time `json:"checked_at"`
+}
+
 // =============================================================================
 // MARKET & ORDER MODELS
 // Core Principle 2: Compliance with CEA Rules
@@ -155,6 +295,17 @@ const (
 	OrderSideNo  OrderSide = "no"
 )
 
+// CollateralCents returns the collateral required to buy quantity contracts
+// on side, where priceCents is the YES price. YES costs the price and NO costs
+// its complement, so either side is fully backed by the $1.00 payout.
+// Core Principle 11: 100% collateralization.
+func CollateralCents(side OrderSide, quantity, priceCents int) int {
+	if side == OrderSideNo {
+		return quantity * (100 - priceCents)
+	}
+	return quantity * priceCents
+}
+
 type OrderType string
 
 const (
@@ -188,11 +339,15 @@ type Order struct {
 	FilledQuantity  int         `json:"filled_quantity"`
 	PriceCents      int         `json:"price_cents"`      // 1-99 cents
 	FilledPriceCents int        `json:"filled_price_cents,omitempty"`
-	CollateralUSD   float64     `json:"collateral_usd"`   // Locked funds
+	CollateralUSD   Money       `json:"collateral_usd"`   // Locked funds
+	FeeUSD          Money       `json:"fee_usd"`          // Trading fee, reserved at placement and charged on fill
+	ReduceOnly      bool        `json:"reduce_only,omitempty"` // May only offset an existing opposite-side position
 	CreatedAt       time.Time   `json:"created_at"`
 	UpdatedAt       time.Time   `json:"updated_at"`
 	FilledAt        *time.Time  `json:"filled_at,omitempty"`
+	CancelledAt     *time.Time  `json:"cancelled_at,omitempty"`
 	ExpiresAt       *time.Time  `json:"expires_at,omitempty"`
+	AmendedAt       []time.Time `json:"amended_at,omitempty"` // When each amend was made, oldest first
 
 	// Core Principle 4: Prevention of Market Disruption
 	// Surveillance metadata
@@ -213,15 +368,136 @@ type Position struct {
 	Side          OrderSide `json:"side"`
 	Quantity      int       `json:"quantity"`
 	AvgPriceCents int       `json:"avg_price_cents"`
-	CostBasisUSD  float64   `json:"cost_basis_usd"`
-	CurrentValue  float64   `json:"current_value_usd"`
-	UnrealizedPnL float64   `json:"unrealized_pnl_usd"`
-	RealizedPnL   float64   `json:"realized_pnl_usd"`
+	CostBasisUSD  Money     `json:"cost_basis_usd"`
+	CurrentValue  Money     `json:"current_value_usd"`
+	UnrealizedPnL Money     `json:"unrealized_pnl_usd"`
+	RealizedPnL   Money     `json:"realized_pnl_usd"`
+	FeesUSD       Money     `json:"fees_usd"` // Trading and settlement fees charged
+	Lots          []PositionLot `json:"lots,omitempty"` // Open lots, oldest first; kept under FIFO cost basis
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 	ClosedAt      *time.Time `json:"closed_at,omitempty"`
 }
 
+// PositionLot is the still-open part of one fill in a position.
+type PositionLot struct {
+	Quantity   int       `json:"quantity"`
+	PriceCents int       `json:"price_cents"`
+	CostUSD    Money     `json:"cost_usd"`
+	OpenedAt   time.Time `json:"opened_at"`
+}
+
+// FeeSchedule defines the fees charged on trades and settlements. All zero
+// means no fees.
+// Core Principle 11: Fees are disclosed and recorded as transactions.
+type FeeSchedule struct {
+	TradeFeePerContractUSD Money   `json:"trade_fee_per_contract_usd"`
+	TradeFeePercent        float64 `json:"trade_fee_percent"`      // Of order collateral
+	SettlementFeePercent   float64 `json:"settlement_fee_percent"` // Of settlement profit
+}
+
+// PositionLimitConfig defines limits per user tier.
+// Core Principle 5: Speculative position limits.
+type PositionLimitConfig struct {
+	Tier           string `json:"tier"`
+	MaxPositionUSD Money  `json:"max_position_usd"`
+	MaxOrderSize   int    `json:"max_order_size"`
+	DailyVolumeUSD Money  `json:"daily_volume_usd"`
+	MaxOpenOrders  int    `json:"max_open_orders"`
+}
+
+// DefaultTier is the tier new accounts are assigned to. Every limit table
+// must define it.
+const DefaultTier = "basic"
+
+// DefaultPositionLimits returns the tiered limits a fresh store starts with.
+// Core Principle 5: Speculative position limits.
+func DefaultPositionLimits() []PositionLimitConfig {
+	return []PositionLimitConfig{
+		{Tier: "basic", MaxPositionUSD: Dollars(25000), MaxOrderSize: 500, DailyVolumeUSD: Dollars(10000), MaxOpenOrders: 100},
+		{Tier: "standard", MaxPositionUSD: Dollars(100000), MaxOrderSize: 2000, DailyVolumeUSD: Dollars(50000), MaxOpenOrders: 500},
+		{Tier: "professional", MaxPositionUSD: Dollars(500000), MaxOrderSize: 10000, DailyVolumeUSD: Dollars(250000), MaxOpenOrders: 2000},
+	}
+}
+
+// TierLimits returns the limits for a tier in the given table, falling back
+// to DefaultTier for unknown names.
+func TierLimits(limits []PositionLimitConfig, tier string) PositionLimitConfig {
+	var fallback PositionLimitConfig
+	for _, l := range limits {
+		if l.Tier == tier {
+			return l
+		}
+		if l.Tier == DefaultTier {
+			fallback = l
+		}
+	}
+	return fallback
+}
+
+// ValidatePositionLimits checks a tier table before it replaces the live one:
+// tier names must be unique, DefaultTier must be present and every limit
+// must be positive.
+func ValidatePositionLimits(limits []PositionLimitConfig) error {
+	seen := make(map[string]bool, len(limits))
+	for _, l := range limits {
+		switch {
+		case strings.TrimSpace(l.Tier) == "":
+			return fmt.Errorf("tier name is required")
+		case seen[l.Tier]:
+			return fmt.Errorf("tier %q is listed twice", l.Tier)
+		case l.MaxPositionUSD <= 0 || l.MaxOrderSize <= 0 || l.DailyVolumeUSD <= 0 || l.MaxOpenOrders <= 0:
+			return fmt.Errorf("tier %q: limits must be positive", l.Tier)
+		}
+		seen[l.Tier] = true
+	}
+	if !seen[DefaultTier] {
+		return fmt.Errorf("the %q tier is required", DefaultTier)
+	}
+	return nil
+}
+
+// Settlement records how one position resolved when its market settled.
+// Core Principle 3: Objective resolution; Core Principle 11: payout integrity.
+type Settlement struct {
+	ID           string            `json:"id"`
+	UserID       string            `json:"user_id"`
+	PositionID   string            `json:"position_id"`
+	MarketTicker string            `json:"market_ticker"`
+	EventTicker  string            `json:"event_ticker"`
+	Side         OrderSide         `json:"side"`
+	Quantity     int               `json:"quantity"`
+	Result       string            `json:"result"` // yes, no, void, liquidated
+	CostBasisUSD Money             `json:"cost_basis_usd"`
+	PayoutUSD    Money             `json:"payout_usd"`
+	FeeUSD       Money             `json:"fee_usd"` // Settlement fee on winnings
+	PnLUSD       Money             `json:"pnl_usd"` // Net of all fees
+	Reason       string            `json:"reason"`  // Resolution source
+	SettledAt    time.Time         `json:"settled_at"`
+	Source       *SettlementSource `json:"source,omitempty"`
+}
+
+// Resolution source tiers, tried in order.
+const (
+	SourceTierPrimary   = "primary"
+	SourceTierSecondary = "secondary"
+	SourceTierTertiary  = "tertiary"
+)
+
+// SettlementSource records which resolution source decided a market and
+// when, so the outcome can be checked against it.
+// Core Principle 3: Objective, verifiable outcomes.
+type SettlementSource struct {
+	Name       string    `json:"name"` // e.g. bls.gov
+	Tier       string    `json:"tier"` // primary, secondary, tertiary
+	ReportedAt time.Time `json:"reported_at"`
+	VerifiedAt time.Time `json:"verified_at"`
+	// Extended is set when the primary source missed the resolution delay
+	// and the extension window was applied; DelayReason says what happened.
+	Extended    bool   `json:"extended"`
+	DelayReason string `json:"delay_reason,omitempty"`
+}
+
 // =============================================================================
 // KALSHI MARKET MODELS (from public API)
 // Core Principle 3: Contracts not readily susceptible to manipulation
@@ -276,12 +552,15 @@ const (
 	AuditActionDelete   AuditAction = "delete"
 	AuditActionLogin    AuditAction = "login"
 	AuditActionLogout   AuditAction = "logout"
+	AuditActionRefresh  AuditAction = "refresh"
 	AuditActionTrade    AuditAction = "trade"
 	AuditActionKYC      AuditAction = "kyc"
 	AuditActionDeposit  AuditAction = "deposit"
 	AuditActionWithdraw AuditAction = "withdraw"
 	AuditActionSuspend  AuditAction = "suspend"
 	AuditActionHalt     AuditAction = "halt"
+	AuditActionSettle   AuditAction = "settle"
+	AuditActionEvent    AuditAction = "event"
 )
 
 // AuditEntry provides immutable audit trail for compliance.
@@ -312,16 +591,72 @@ type ComplianceAlert struct {
 	Evidence    string    `json:"evidence"` // JSON data
 	Status      string    `json:"status"`   // open, investigating, resolved, escalated
 	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"` // Latest occurrence of the condition
+	Count       int       `json:"count"`        // Occurrences folded into this alert
+	AssignedTo  string     `json:"assigned_to,omitempty"` // Operator who owns the alert
+	AssignedAt  *time.Time `json:"assigned_at,omitempty"`
 	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
 	ResolvedBy  string    `json:"resolved_by,omitempty"`
 	Notes       string    `json:"notes,omitempty"` // Resolution notes
 }
 
+// Compliance alert statuses. Alerts move open -> investigating -> resolved
+// or escalated.
+const (
+	AlertStatusOpen          = "open"
+	AlertStatusInvestigating = "investigating"
+	AlertStatusResolved      = "resolved"
+	AlertStatusEscalated     = "escalated"
+)
+
+// AlertActive reports whether an alert with status still needs attention:
+// open or under investigation. Resolved and escalated alerts are closed.
+func AlertActive(status string) bool {
+	return status == AlertStatusOpen || status == AlertStatusInvestigating
+}
+
+// Compliance alert severities, least severe first.
+const (
+	AlertSeverityLow      = "low"
+	AlertSeverityMedium   = "medium"
+	AlertSeverityHigh     = "high"
+	AlertSeverityCritical = "critical"
+)
+
+// AlertSeverityRank orders severities for triage: higher is more severe.
+// Unknown severities rank below low.
+func AlertSeverityRank(severity string) int {
+	switch severity {
+	case AlertSeverityCritical:
+		return 4
+	case AlertSeverityHigh:
+		return 3
+	case AlertSeverityMedium:
+		return 2
+	case AlertSeverityLow:
+		return 1
+	}
+	return 0
+}
+
+// SortAlertsBySeverity orders alerts most severe first, newest first within
+// a severity.
+func SortAlertsBySeverity(alerts []ComplianceAlert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		ri, rj := AlertSeverityRank(alerts[i].Severity), AlertSeverityRank(alerts[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+	})
+}
+
 // EmergencyHalt tracks market-wide or market-specific trading halts.
 // Core Principle 4: Emergency authority.
 type EmergencyHalt struct {
 	ID           string     `json:"id"`
 	MarketTicker string     `json:"market_ticker,omitempty"` // Empty = market-wide
+	Global       bool       `json:"global"`                  // Halts every market
 	Reason       string     `json:"reason"`
 	InitiatedBy  string     `json:"initiated_by"`
 	StartedAt    time.Time  `json:"started_at"`
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollateralCents(t *testing.T) {
+	tests := []struct {
+		side       OrderSide
+		quantity   int
+		priceCents int
+		want       int
+	}{
+		{OrderSideYes, 1, 1, 1},
+		{OrderSideYes, 1, 99, 99},
+		{OrderSideNo, 1, 1, 99},
+		{OrderSideNo, 1, 99, 1},
+		{OrderSideYes, 10, 40, 400},
+		{OrderSideNo, 10, 40, 600},
+		{OrderSideYes, 1000, 99, 99000},
+		{OrderSideNo, 1000, 1, 99000},
+	}
+	for _, tt := range tests {
+		if got := CollateralCents(tt.side, tt.quantity, tt.priceCents); got != tt.want {
+			t.Errorf("CollateralCents(%s, %d, %d) = %d, want %d", tt.side, tt.quantity, tt.priceCents, got, tt.want)
+		}
+	}
+}
+
+// A YES and a NO contract at the same price together lock exactly the $1.00
+// payout, so every binary contract is fully collateralized.
+func TestCollateralCents_FullyCollateralized(t *testing.T) {
+	for price := 1; price <= 99; price++ {
+		yes := CollateralCents(OrderSideYes, 1, price)
+		no := CollateralCents(OrderSideNo, 1, price)
+		if yes+no != 100 {
+			t.Errorf("price %d¢: yes %d + no %d = %d, want 100", price, yes, no, yes+no)
+		}
+		if yes <= 0 || no <= 0 {
+			t.Errorf("price %d¢: collateral must be positive on both sides (yes %d, no %d)", price, yes, no)
+		}
+	}
+}
+
+func TestSortAlertsBySeverity(t *testing.T) {
+	base := time.Date(2024, 11, 5, 14, 0, 0, 0, time.UTC)
+	alerts := []ComplianceAlert{
+		{ID: "low", Severity: AlertSeverityLow, CreatedAt: base.Add(3 * time.Minute)},
+		{ID: "critical-old", Severity: AlertSeverityCritical, CreatedAt: base},
+		{ID: "unknown", Severity: "info", CreatedAt: base.Add(4 * time.Minute)},
+		{ID: "critical-new", Severity: AlertSeverityCritical, CreatedAt: base.Add(time.Minute)},
+		{ID: "medium", Severity: AlertSeverityMedium, CreatedAt: base.Add(2 * time.Minute)},
+	}
+	SortAlertsBySeverity(alerts)
+
+	want := []string{"critical-new", "critical-old", "medium", "low", "unknown"}
+	for i, alert := range alerts {
+		if alert.ID != want[i] {
+			t.Errorf("position %d = %s, want %s", i, alert.ID, want[i])
+		}
+	}
+}
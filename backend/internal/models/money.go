@@ -0,0 +1,135 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// MONEY
+// Core Principle 11: Balances are whole cents so repeated arithmetic cannot
+// drift the way float64 dollars do.
+// =============================================================================
+
+// Money is an amount of USD in integer cents. It marshals to JSON as a
+// dollar number (12.34) so the API shape is unchanged.
+type Money int64
+
+// Cents returns c cents as Money.
+func Cents(c int64) Money {
+	return Money(c)
+}
+
+// Dollars converts a dollar amount to Money, rounding half away from zero
+// to the nearest cent.
+func Dollars(usd float64) Money {
+	return Money(math.Round(usd * 100))
+}
+
+// Cents returns the amount in cents.
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Dollars returns the amount in dollars, for display and ratios only.
+func (m Money) Dollars() float64 {
+	return float64(m) / 100
+}
+
+// MulDiv returns m*num/den rounded half away from zero, computed in integers
+// so pro-rata shares of an amount sum back to it exactly.
+func (m Money) MulDiv(num, den int64) Money {
+	if den == 0 {
+		return 0
+	}
+	n := int64(m) * num
+	if (n < 0) != (den < 0) {
+		return Money((n - den/2) / den)
+	}
+	return Money((n + den/2) / den)
+}
+
+// Percent returns pct percent of m rounded to the nearest cent.
+func (m Money) Percent(pct float64) Money {
+	return Money(math.Round(float64(m) * pct / 100))
+}
+
+// String formats m as dollars, e.g. "$12.34" or "-$0.05".
+func (m Money) String() string {
+	sign := ""
+	c := int64(m)
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, c/100, c%100)
+}
+
+// MarshalJSON writes m as an exact dollar number without trailing zeros.
+func (m Money) MarshalJSON() ([]byte, error) {
+	c := int64(m)
+	var b strings.Builder
+	if c < 0 {
+		b.WriteByte('-')
+		c = -c
+	}
+	b.WriteString(strconv.FormatInt(c/100, 10))
+	if frac := c % 100; frac != 0 {
+		s := fmt.Sprintf(".%02d", frac)
+		b.WriteString(strings.TrimRight(s, "0"))
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalJSON reads a dollar number, rounding to the nearest cent. Plain
+// decimals are parsed exactly; exponent forms go through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if c, ok := parseDecimalCents(string(data)); ok {
+		*m = Money(c)
+		return nil
+	}
+	usd, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid dollar amount %s", data)
+	}
+	*m = Dollars(usd)
+	return nil
+}
+
+// parseDecimalCents parses [-]digits[.digits] to cents, rounding the third
+// decimal half away from zero.
+func parseDecimalCents(s string) (int64, bool) {
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(whole) > 15 || !allDigits(whole) || !allDigits(frac) {
+		return 0, false
+	}
+	frac += "000"
+	w, _ := strconv.ParseInt(whole, 10, 64)
+	cents := w*100 + int64(frac[0]-'0')*10 + int64(frac[1]-'0')
+	if frac[2] >= '5' {
+		cents++
+	}
+	if neg {
+		cents = -cents
+	}
+	return cents, true
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoney_JSONPresentsDollars(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{Dollars(25), "25"},
+		{Dollars(12.5), "12.5"},
+		{Dollars(0.07), "0.07"},
+		{Cents(-305), "-3.05"},
+		{0, "0"},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("Marshal(%d¢) = %s, want %s", tt.m.Cents(), b, tt.want)
+		}
+		var back Money
+		if err := json.Unmarshal(b, &back); err != nil || back != tt.m {
+			t.Errorf("round trip of %s = %d¢ (%v)", b, back.Cents(), err)
+		}
+	}
+}
+
+func TestMoney_UnmarshalRoundsToCents(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0.1", 10},
+		{"10.005", 1001},
+		{"10.004", 1000},
+		{"-3.25", -325},
+		{"1e2", 10000},
+		{"100", 10000},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.in), &m); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+		}
+		if m.Cents() != tt.want {
+			t.Errorf("Unmarshal(%s) = %d¢, want %d¢", tt.in, m.Cents(), tt.want)
+		}
+	}
+	var m Money
+	if err := json.Unmarshal([]byte(`"ten"`), &m); err == nil {
+		t.Error("expected an error for a non-numeric amount")
+	}
+}
+
+// Pro-rata shares computed with MulDiv sum back to the whole, so partial
+// fills never leak or mint a cent.
+func TestMoney_MulDivSharesSumToTotal(t *testing.T) {
+	total := Cents(1001)
+	for _, parts := range []int64{3, 7, 9} {
+		var sum, done Money
+		for filled := int64(1); filled <= parts; filled++ {
+			cum := total.MulDiv(filled, parts)
+			sum += cum - done
+			done = cum
+		}
+		if sum != total {
+			t.Errorf("%d shares sum to %s, want %s", parts, sum, total)
+		}
+	}
+}
+
+// Ten thousand ten-cent deposits are exact in cents; the same float64 sum
+// comes to 1000.0000000001588.
+func TestMoney_NoDriftAcrossManyDeposits(t *testing.T) {
+	var m Money
+	for i := 0; i < 10000; i++ {
+		m += Dollars(0.10)
+	}
+	if m != Dollars(1000) || m.String() != "$1000.00" {
+		t.Errorf("sum = %s, want $1000.00", m)
+	}
+}
@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// =============================================================================
+// CRASH-SAFE WRITES
+// CP 18: A crash mid-save must never leave a truncated record on disk.
+// =============================================================================
+
+// WriteFileAtomic writes a file by streaming into a temp file in the same
+// directory, fsyncing it, renaming it over path and fsyncing the directory so
+// the rename itself survives a crash. Readers see either the old or the new
+// contents, never a partial write.
+func WriteFileAtomic(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpPath)
+	}
+
+	if err := write(tmp); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// WriteJSONAtomic writes v as indented JSON using WriteFileAtomic.
+func WriteJSONAtomic(path string, v interface{}) error {
+	return WriteFileAtomic(path, 0644, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	})
+}
+
+func writeBytesAtomic(path string, data []byte) error {
+	return WriteFileAtomic(path, 0644, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// CheckWritable verifies that dir accepts new files by creating and removing
+// a probe file.
+func CheckWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".write-probe*")
+	if err != nil {
+		return err
+	}
+	name := probe.Name()
+	probe.Close()
+	return os.Remove(name)
+}
+
+// syncDir flushes directory metadata (the rename) to stable storage.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
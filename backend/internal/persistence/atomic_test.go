@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic_PartialWriteKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "latest.json")
+	if err := os.WriteFile(path, []byte(`{"version":"1.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash mid-write: some bytes land, then the writer fails.
+	errCrash := errors.New("simulated crash")
+	err := WriteFileAtomic(path, 0644, func(w io.Writer) error {
+		if _, err := w.Write([]byte(`{"versi`)); err != nil {
+			return err
+		}
+		return errCrash
+	})
+	if !errors.Is(err, errCrash) {
+		t.Fatalf("expected simulated crash error, got %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"version":"1.0"}` {
+		t.Errorf("original file was modified: %q", got)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected temp file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestWriteFileAtomic_ReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "latest.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeBytesAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new" {
+		t.Errorf("expected new contents, got %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// FIELD ENCRYPTION
+// CP 18: Sensitive fields such as KYC document numbers are sealed with
+// AES-256-GCM before they reach a snapshot and opened on load, so plaintext
+// lives only in memory.
+// =============================================================================
+
+// FieldKeySize is the length in bytes of a field encryption key.
+const FieldKeySize = 32
+
+// sealedPrefix marks a value sealed by FieldCipher, versioning the format.
+const sealedPrefix = "v1:"
+
+// ErrFieldDecrypt is returned when a sealed field cannot be opened, because
+// the key is wrong or the value was altered or moved to another record.
+var ErrFieldDecrypt = errors.New("cannot decrypt sealed field")
+
+// FieldCipher seals individual field values.
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+// ParseFieldKey decodes a base64 key of FieldKeySize bytes.
+func ParseFieldKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("field encryption key is not base64: %w", err)
+	}
+	if len(key) != FieldKeySize {
+		return nil, fmt.Errorf("field encryption key is %d bytes, want %d", len(key), FieldKeySize)
+	}
+	return key, nil
+}
+
+// NewFieldCipher creates a cipher from a FieldKeySize-byte key.
+func NewFieldCipher(key []byte) (*FieldCipher, error) {
+	if len(key) != FieldKeySize {
+		return nil, fmt.Errorf("field encryption key is %d bytes, want %d", len(key), FieldKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldCipher{aead: aead}, nil
+}
+
+// Seal encrypts plaintext bound to recordID, so the sealed value only opens
+// for the record it was written for.
+func (c *FieldCipher) Seal(plaintext, recordID string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(recordID))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value sealed for recordID.
+func (c *FieldCipher) Open(sealed, recordID string) (string, error) {
+	encoded, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return "", ErrFieldDecrypt
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", ErrFieldDecrypt
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(recordID))
+	if err != nil {
+		return "", ErrFieldDecrypt
+	}
+	return string(plaintext), nil
+}
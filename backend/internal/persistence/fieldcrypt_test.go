@@ -0,0 +1,37 @@
+package persistence
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFieldCipher_SealIsBoundToRecordAndKey(t *testing.T) {
+	key, err := ParseFieldKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", FieldKeySize))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := NewFieldCipher(key)
+	sealed, err := c.Seal("D1234567", "kyc_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "D1234567") {
+		t.Fatalf("sealed value %q contains the plaintext", sealed)
+	}
+	if plain, err := c.Open(sealed, "kyc_1"); err != nil || plain != "D1234567" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+	if _, err := c.Open(sealed, "kyc_2"); !errors.Is(err, ErrFieldDecrypt) {
+		t.Errorf("opened for another record: %v", err)
+	}
+	other, _ := NewFieldCipher([]byte(strings.Repeat("x", FieldKeySize)))
+	if _, err := other.Open(sealed, "kyc_1"); !errors.Is(err, ErrFieldDecrypt) {
+		t.Errorf("opened with another key: %v", err)
+	}
+
+	if _, err := ParseFieldKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("accepted a short key")
+	}
+}
@@ -3,10 +3,19 @@
 package persistence
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,39 +31,51 @@ import (
 type Manager struct {
 	dataDir     string
 	enabled     bool
+	compress    bool
 	saveInterval time.Duration
 	mu          sync.Mutex
 }
 
-// DataSnapshot represents the full store state for persistence
-type DataSnapshot struct {
-	Version     string    `json:"version"`
-	Timestamp   time.Time `json:"timestamp"`
-
-	// User data
-	Users       map[string]*models.User       `json:"users"`
-	UsersByEmail map[string]string            `json:"users_by_email"`
-
-	// KYC records
-	KYCRecords  map[string]*models.KYCRecord `json:"kyc_records"`
+// Options tunes how the file backend writes snapshots.
+type Options struct {
+	CompressSnapshots bool // Write gzip'd .json.gz snapshots
+}
 
-	// Wallets and transactions
-	Wallets      map[string]*models.Wallet       `json:"wallets"`
-	Transactions map[string]*models.Transaction  `json:"transactions"`
-	TxByWallet   map[string][]string             `json:"tx_by_wallet"`
+const (
+	latestSnapshotName = "latest.json"
+	gzipSuffix         = ".gz"
+	checksumSuffix     = ".sha256"
+)
 
-	// Orders and positions
-	Orders       map[string]*models.Order    `json:"orders"`
-	OrdersByUser map[string][]string         `json:"orders_by_user"`
-	Positions    map[string]*models.Position `json:"positions"`
-	PositionsByUser map[string][]string      `json:"positions_by_user"`
+// ErrChecksumMismatch is returned when a snapshot does not match its sidecar checksum
+var ErrChecksumMismatch = errors.New("snapshot checksum mismatch")
 
-	// Compliance
-	Alerts       []models.ComplianceAlert          `json:"alerts"`
-	Halts        map[string]*models.EmergencyHalt  `json:"halts"`
+// SnapshotVersion is the schema version written into every DataSnapshot.
+const SnapshotVersion = "2.0"
 
-	// Counters
-	IDCounter   int64 `json:"id_counter"`
+// DataSnapshot represents the full store state for persistence
+type DataSnapshot struct {
+	Version         string                           `json:"version"`
+	SavedAt         time.Time                        `json:"saved_at"`
+	Users           map[string]*models.User          `json:"users"`
+	UsersByEmail    map[string]string                `json:"users_by_email"`
+	KYCRecords      map[string]*models.KYCRecord     `json:"kyc_records"`
+	KYCDocuments    map[string]string                `json:"kyc_documents,omitempty"` // Document numbers by KYC record ID, sealed with a FieldCipher
+	Wallets         map[string]*models.Wallet        `json:"wallets"`
+	Transactions    map[string]*models.Transaction   `json:"transactions"`
+	TxByWallet      map[string][]string              `json:"tx_by_wallet"`
+	Ledger          []models.JournalEntry            `json:"ledger,omitempty"`
+	Orders          map[string]*models.Order         `json:"orders"`
+	OrdersByUser    map[string][]string              `json:"orders_by_user"`
+	Positions       map[string]*models.Position      `json:"positions"`
+	PositionsByUser map[string][]string              `json:"positions_by_user"`
+	AuditLog        []models.AuditEntry              `json:"audit_log"`
+	Alerts          []models.ComplianceAlert         `json:"alerts"`
+	Halts           map[string]*models.EmergencyHalt `json:"halts"`
+	SettledMarkets  map[string]time.Time             `json:"settled_markets,omitempty"`
+	Settlements     []models.Settlement              `json:"settlements,omitempty"`
+	PositionLimits  []models.PositionLimitConfig     `json:"position_limits,omitempty"`
+	OrderRates      map[string][]time.Time           `json:"order_rates,omitempty"` // Recent order times per user for the surveillance rate limiter
 }
 
 // AuditArchive holds audit entries for a specific time period
@@ -67,6 +88,11 @@ type AuditArchive struct {
 
 // NewManager creates a new persistence manager
 func NewManager(dataDir string, enabled bool) (*Manager, error) {
+	return NewManagerWithOptions(dataDir, enabled, Options{})
+}
+
+// NewManagerWithOptions creates a persistence manager with non-default options
+func NewManagerWithOptions(dataDir string, enabled bool, opts Options) (*Manager, error) {
 	if enabled {
 		// Create data directory if it doesn't exist
 		if err := os.MkdirAll(dataDir, 0755); err != nil {
@@ -86,6 +112,7 @@ func NewManager(dataDir string, enabled bool) (*Manager, error) {
 	return &Manager{
 		dataDir:      dataDir,
 		enabled:      enabled,
+		compress:     opts.CompressSnapshots,
 		saveInterval: 5 * time.Minute, // Auto-save every 5 minutes
 	}, nil
 }
@@ -103,32 +130,53 @@ func (m *Manager) SaveSnapshot(snapshot *DataSnapshot) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	snapshot.Version = "1.0"
-	snapshot.Timestamp = time.Now().UTC()
-
-	// Create timestamped filename
-	filename := fmt.Sprintf("snapshot_%s.json", snapshot.Timestamp.Format("20060102_150405"))
-	path := filepath.Join(m.dataDir, "snapshots", filename)
+	snapshot.Version = SnapshotVersion
+	snapshot.SavedAt = time.Now().UTC()
 
 	data, err := json.MarshalIndent(snapshot, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal snapshot: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	suffix := ""
+	if m.compress {
+		if data, err = gzipBytes(data); err != nil {
+			return fmt.Errorf("failed to compress snapshot: %w", err)
+		}
+		suffix = gzipSuffix
+	}
+
+	// Create timestamped filename
+	filename := fmt.Sprintf("snapshot_%s.json", snapshot.SavedAt.Format("20060102_150405")) + suffix
+	path := filepath.Join(m.dataDir, "snapshots", filename)
+
+	if err := writeSnapshotFile(path, data); err != nil {
 		return fmt.Errorf("failed to write snapshot: %w", err)
 	}
 
-	// Also update "latest" symlink/file
-	latestPath := filepath.Join(m.dataDir, "snapshots", "latest.json")
-	if err := os.WriteFile(latestPath, data, 0644); err != nil {
+	// Also update "latest" file (atomically, so a crash never truncates it)
+	latestPath := filepath.Join(m.dataDir, "snapshots", latestSnapshotName)
+	if err := writeSnapshotFile(latestPath+suffix, data); err != nil {
 		return fmt.Errorf("failed to write latest snapshot: %w", err)
 	}
 
+	// Drop the other format's latest file so Load never picks a stale one
+	stale := latestPath + gzipSuffix
+	if m.compress {
+		stale = latestPath
+	}
+	for _, path := range []string{stale, stale + checksumSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale latest snapshot: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// LoadLatestSnapshot loads the most recent snapshot from disk
+// LoadLatestSnapshot loads the most recent snapshot from disk. A latest file
+// that fails its checksum or cannot be decoded is skipped in favour of the
+// newest valid timestamped backup.
 func (m *Manager) LoadLatestSnapshot() (*DataSnapshot, error) {
 	if !m.enabled {
 		return nil, nil
@@ -137,14 +185,110 @@ func (m *Manager) LoadLatestSnapshot() (*DataSnapshot, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	latestPath := filepath.Join(m.dataDir, "snapshots", "latest.json")
+	candidates, err := m.snapshotCandidates()
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(latestPath)
+	var lastErr error
+	for _, path := range candidates {
+		snapshot, err := readSnapshotFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Printf("Skipping snapshot %s: %v", filepath.Base(path), err)
+			lastErr = err
+			continue
+		}
+		log.Printf("Restoring snapshot %s (saved %s)", filepath.Base(path), snapshot.SavedAt.Format(time.RFC3339))
+		return snapshot, nil
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("no valid snapshot found: %w", lastErr)
+	}
+	return nil, nil // No snapshot exists yet
+}
+
+// snapshotCandidates lists snapshot files in restore order: latest first
+// (either format, depending on CompressSnapshots when last saved), then
+// timestamped backups newest first.
+func (m *Manager) snapshotCandidates() ([]string, error) {
+	snapshotDir := filepath.Join(m.dataDir, "snapshots")
+	latestPath := filepath.Join(snapshotDir, latestSnapshotName)
+	candidates := []string{latestPath + gzipSuffix, latestPath}
+
+	entries, err := os.ReadDir(snapshotDir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil // No snapshot exists yet
+			return candidates, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "snapshot_") || strings.HasSuffix(name, checksumSuffix) {
+			continue
+		}
+		backups = append(backups, name)
+	}
+	// Timestamped names sort chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for _, name := range backups {
+		candidates = append(candidates, filepath.Join(snapshotDir, name))
+	}
+	return candidates, nil
+}
+
+// writeSnapshotFile writes a snapshot and its sidecar checksum
+func writeSnapshotFile(path string, data []byte) error {
+	if err := writeBytesAtomic(path, data); err != nil {
+		return err
+	}
+	return writeBytesAtomic(path+checksumSuffix, []byte(checksum(data)))
+}
+
+// readSnapshotFile reads and decodes a snapshot, verifying its sidecar
+// checksum. Files saved before checksums existed have no sidecar and are
+// accepted as-is.
+func readSnapshotFile(path string) (*DataSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := os.ReadFile(path + checksumSuffix)
+	if err == nil {
+		if strings.TrimSpace(string(expected)) != checksum(data) {
+			return nil, ErrChecksumMismatch
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	return decodeSnapshot(data)
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// decodeSnapshot parses a snapshot file, transparently gunzipping it when it
+// starts with the gzip magic bytes.
+func decodeSnapshot(data []byte) (*DataSnapshot, error) {
+	if isGzip(data) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+		}
+		defer reader.Close()
+		if data, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
 		}
-		return nil, fmt.Errorf("failed to read snapshot: %w", err)
 	}
 
 	var snapshot DataSnapshot
@@ -155,6 +299,28 @@ func (m *Manager) LoadLatestSnapshot() (*DataSnapshot, error) {
 	return &snapshot, nil
 }
 
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isLatestSnapshot reports whether name is the latest.json(.gz) pointer file
+// or its checksum
+func isLatestSnapshot(name string) bool {
+	return strings.HasPrefix(name, latestSnapshotName)
+}
+
 // =============================================================================
 // AUDIT LOG OPERATIONS
 // CP 18: 5-year retention with monthly archives
@@ -182,12 +348,9 @@ func (m *Manager) SaveAuditEntries(entries []models.AuditEntry) error {
 		path := filepath.Join(m.dataDir, "audit", filename)
 
 		// Load existing entries
-		var existing []models.AuditEntry
-		if data, err := os.ReadFile(path); err == nil {
-			var archive AuditArchive
-			if err := json.Unmarshal(data, &archive); err == nil {
-				existing = archive.Entries
-			}
+		existing, err := readAuditArchive(path)
+		if err != nil {
+			return err
 		}
 
 		// Append new entries
@@ -209,7 +372,7 @@ func (m *Manager) SaveAuditEntries(entries []models.AuditEntry) error {
 			return fmt.Errorf("failed to marshal audit archive: %w", err)
 		}
 
-		if err := os.WriteFile(path, data, 0644); err != nil {
+		if err := writeBytesAtomic(path, data); err != nil {
 			return fmt.Errorf("failed to write audit archive: %w", err)
 		}
 	}
@@ -239,22 +402,13 @@ func (m *Manager) LoadAuditEntries(since, until time.Time) ([]models.AuditEntry,
 		filename := fmt.Sprintf("audit_%s.json", monthKey)
 		path := filepath.Join(auditDir, filename)
 
-		data, err := os.ReadFile(path)
+		entries, err := readAuditArchive(path)
 		if err != nil {
-			if !os.IsNotExist(err) {
-				return nil, fmt.Errorf("failed to read audit file %s: %w", filename, err)
-			}
-			current = current.AddDate(0, 1, 0)
-			continue
-		}
-
-		var archive AuditArchive
-		if err := json.Unmarshal(data, &archive); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal audit file %s: %w", filename, err)
+			return nil, err
 		}
 
 		// Filter entries within date range
-		for _, entry := range archive.Entries {
+		for _, entry := range entries {
 			if !entry.Timestamp.Before(since) && entry.Timestamp.Before(until) {
 				allEntries = append(allEntries, entry)
 			}
@@ -266,25 +420,65 @@ func (m *Manager) LoadAuditEntries(since, until time.Time) ([]models.AuditEntry,
 	return allEntries, nil
 }
 
+// QueryAudit loads audit entries within a date range, optionally for one user
+func (m *Manager) QueryAudit(userID string, since, until time.Time) ([]models.AuditEntry, error) {
+	entries, err := m.LoadAuditEntries(since, until)
+	if err != nil || userID == "" {
+		return entries, err
+	}
+
+	var filtered []models.AuditEntry
+	for _, entry := range entries {
+		if entry.UserID == userID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// readAuditArchive reads one monthly audit file. Files written before the
+// AuditArchive wrapper hold a bare JSON array and are still accepted.
+func readAuditArchive(path string) ([]models.AuditEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read audit file %s: %w", filepath.Base(path), err)
+	}
+
+	var archive AuditArchive
+	if err := json.Unmarshal(data, &archive); err == nil {
+		return archive.Entries, nil
+	}
+	var legacy []models.AuditEntry
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audit file %s: %w", filepath.Base(path), err)
+	}
+	return legacy, nil
+}
+
 // ArchiveOldAuditLogs moves audit logs older than retention period to archive
+// and returns how many monthly files were moved.
 // CP 18: Maintains 5-year retention with archive capability
-func (m *Manager) ArchiveOldAuditLogs(retentionYears int) error {
+func (m *Manager) ArchiveOldAuditLogs(retentionDays int) (int, error) {
 	if !m.enabled {
-		return nil
+		return 0, nil
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	cutoff := time.Now().AddDate(-retentionYears, 0, 0)
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
 	auditDir := filepath.Join(m.dataDir, "audit")
 	archiveDir := filepath.Join(m.dataDir, "archive")
 
 	entries, err := os.ReadDir(auditDir)
 	if err != nil {
-		return fmt.Errorf("failed to read audit directory: %w", err)
+		return 0, fmt.Errorf("failed to read audit directory: %w", err)
 	}
 
+	archived := 0
 	for _, entry := range entries {
 		if entry.IsDir() || !isAuditFile(entry.Name()) {
 			continue
@@ -297,18 +491,19 @@ func (m *Manager) ArchiveOldAuditLogs(retentionYears int) error {
 			continue
 		}
 
-		// Archive if older than cutoff
-		if fileMonth.Before(cutoff) {
+		// Archive only once the whole month is older than cutoff
+		if fileMonth.AddDate(0, 1, 0).Before(cutoff) {
 			oldPath := filepath.Join(auditDir, entry.Name())
 			newPath := filepath.Join(archiveDir, entry.Name())
 
 			if err := os.Rename(oldPath, newPath); err != nil {
-				return fmt.Errorf("failed to archive %s: %w", entry.Name(), err)
+				return archived, fmt.Errorf("failed to archive %s: %w", entry.Name(), err)
 			}
+			archived++
 		}
 	}
 
-	return nil
+	return archived, nil
 }
 
 // isAuditFile checks if filename matches audit file pattern
@@ -320,10 +515,11 @@ func isAuditFile(name string) bool {
 // CLEANUP OPERATIONS
 // =============================================================================
 
-// CleanOldSnapshots removes snapshots older than specified days, keeping latest
-func (m *Manager) CleanOldSnapshots(keepDays int) error {
+// CleanOldSnapshots removes snapshots older than specified days, keeping latest,
+// and returns how many files were removed.
+func (m *Manager) CleanOldSnapshots(keepDays int) (int, error) {
 	if !m.enabled {
-		return nil
+		return 0, nil
 	}
 
 	m.mu.Lock()
@@ -334,11 +530,12 @@ func (m *Manager) CleanOldSnapshots(keepDays int) error {
 
 	entries, err := os.ReadDir(snapshotDir)
 	if err != nil {
-		return fmt.Errorf("failed to read snapshot directory: %w", err)
+		return 0, fmt.Errorf("failed to read snapshot directory: %w", err)
 	}
 
+	removed := 0
 	for _, entry := range entries {
-		if entry.IsDir() || entry.Name() == "latest.json" {
+		if entry.IsDir() || isLatestSnapshot(entry.Name()) {
 			continue
 		}
 
@@ -350,11 +547,29 @@ func (m *Manager) CleanOldSnapshots(keepDays int) error {
 		if info.ModTime().Before(cutoff) {
 			path := filepath.Join(snapshotDir, entry.Name())
 			if err := os.Remove(path); err != nil {
-				return fmt.Errorf("failed to remove old snapshot %s: %w", entry.Name(), err)
+				return removed, fmt.Errorf("failed to remove old snapshot %s: %w", entry.Name(), err)
 			}
+			removed++
 		}
 	}
 
+	return removed, nil
+}
+
+// RunMaintenance applies the retention policy: old snapshots are deleted and
+// audit months past retention are moved to the archive directory.
+func (m *Manager) RunMaintenance(policy RetentionPolicy) error {
+	removed, err := m.CleanOldSnapshots(policy.SnapshotKeepDays)
+	if err != nil {
+		return err
+	}
+	archived, err := m.ArchiveOldAuditLogs(policy.AuditRetentionDays)
+	if err != nil {
+		return err
+	}
+	if removed > 0 || archived > 0 {
+		log.Printf("Persistence maintenance: removed %d snapshots, archived %d audit files", removed, archived)
+	}
 	return nil
 }
 
@@ -387,7 +602,7 @@ func (m *Manager) GetStats() (*Stats, error) {
 	snapshotDir := filepath.Join(m.dataDir, "snapshots")
 	if entries, err := os.ReadDir(snapshotDir); err == nil {
 		for _, e := range entries {
-			if !e.IsDir() && e.Name() != "latest.json" {
+			if !e.IsDir() && !isLatestSnapshot(e.Name()) && !strings.HasSuffix(e.Name(), checksumSuffix) {
 				stats.SnapshotCount++
 				if info, err := e.Info(); err == nil {
 					stats.TotalSizeBytes += info.Size()
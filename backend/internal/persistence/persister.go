@@ -0,0 +1,54 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// =============================================================================
+// PERSISTER - CP 18: Pluggable recordkeeping backend
+// =============================================================================
+
+// Persister is the storage backend behind the store. Manager (JSON files)
+// and the SQLite backend both implement it, so they are interchangeable.
+type Persister interface {
+	// SaveSnapshot persists the full store state.
+	SaveSnapshot(snapshot *DataSnapshot) error
+	// LoadLatestSnapshot returns the most recent snapshot, or nil if none exists.
+	LoadLatestSnapshot() (*DataSnapshot, error)
+	// SaveAuditEntries adds entries to the immutable audit archive.
+	SaveAuditEntries(entries []models.AuditEntry) error
+	// QueryAudit returns archived entries in [since, until), optionally for one user.
+	QueryAudit(userID string, since, until time.Time) ([]models.AuditEntry, error)
+}
+
+// RetentionPolicy controls periodic cleanup of persisted files.
+type RetentionPolicy struct {
+	SnapshotKeepDays   int // Timestamped snapshots older than this are deleted
+	AuditRetentionDays int // Audit months older than this move to archive/
+}
+
+// Maintainer is implemented by backends that need periodic housekeeping.
+type Maintainer interface {
+	RunMaintenance(policy RetentionPolicy) error
+}
+
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+// NewPersister builds the named backend rooted at dataDir. Options only
+// affect the file backend.
+func NewPersister(backend, dataDir string, opts Options) (Persister, error) {
+	switch backend {
+	case "", BackendFile:
+		return NewManagerWithOptions(dataDir, true, opts)
+	case BackendSQLite:
+		return newSQLitePersister(dataDir)
+	default:
+		return nil, fmt.Errorf("unknown persistence backend %q", backend)
+	}
+}
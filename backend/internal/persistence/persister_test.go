@@ -0,0 +1,208 @@
+package persistence
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestNewPersister_UnknownBackend(t *testing.T) {
+	if _, err := NewPersister("tape", t.TempDir(), Options{}); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+// snapshotOf returns a snapshot holding only userID, to tell snapshots apart.
+func snapshotOf(userID string) *DataSnapshot {
+	return &DataSnapshot{Users: map[string]*models.User{userID: {ID: userID}}}
+}
+
+func TestManager_SnapshotRoundTrip(t *testing.T) {
+	m, err := NewManager(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := &DataSnapshot{Users: map[string]*models.User{"user_1": {ID: "user_1"}}, Ledger: []models.JournalEntry{{ID: "je_1"}}}
+	if err := m.SaveSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.LoadLatestSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Version != SnapshotVersion {
+		t.Errorf("expected version %s, got %s", SnapshotVersion, loaded.Version)
+	}
+	if loaded.Users["user_1"] == nil || len(loaded.Ledger) != 1 || loaded.Ledger[0].ID != "je_1" {
+		t.Errorf("snapshot not restored: %+v", loaded)
+	}
+}
+
+func TestManager_QueryAuditReadsLegacyArrayFiles(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+
+	// Older stores wrote each month as a bare JSON array.
+	legacy, _ := json.Marshal([]models.AuditEntry{{ID: "audit_1", UserID: "user_1", Timestamp: now}})
+	path := filepath.Join(dir, "audit", "audit_"+now.Format("2006-01")+".json")
+	if err := os.WriteFile(path, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveAuditEntries([]models.AuditEntry{{ID: "audit_2", UserID: "user_2", Timestamp: now}}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := m.QueryAudit("", now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(all))
+	}
+	mine, _ := m.QueryAudit("user_1", now.Add(-time.Hour), now.Add(time.Hour))
+	if len(mine) != 1 || mine[0].ID != "audit_1" {
+		t.Errorf("expected only audit_1 for user_1, got %+v", mine)
+	}
+}
+
+func TestManager_RunMaintenanceRemovesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots := filepath.Join(dir, "snapshots")
+	old := time.Now().AddDate(0, 0, -40)
+	for _, name := range []string{"latest.json", "snapshot_old.json", "snapshot_recent.json"} {
+		if err := os.WriteFile(filepath.Join(snapshots, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chtimes(filepath.Join(snapshots, "latest.json"), old, old)
+	os.Chtimes(filepath.Join(snapshots, "snapshot_old.json"), old, old)
+
+	oldMonth := time.Now().AddDate(-6, 0, 0).Format("2006-01")
+	thisMonth := time.Now().Format("2006-01")
+	for _, month := range []string{oldMonth, thisMonth} {
+		if err := os.WriteFile(filepath.Join(dir, "audit", "audit_"+month+".json"), []byte("[]"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.RunMaintenance(RetentionPolicy{SnapshotKeepDays: 30, AuditRetentionDays: 1825}); err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	if exists(filepath.Join(snapshots, "snapshot_old.json")) {
+		t.Error("old snapshot should have been removed")
+	}
+	if !exists(filepath.Join(snapshots, "latest.json")) || !exists(filepath.Join(snapshots, "snapshot_recent.json")) {
+		t.Error("latest.json and recent snapshots must survive cleanup")
+	}
+	if !exists(filepath.Join(dir, "archive", "audit_"+oldMonth+".json")) {
+		t.Error("audit file past retention should be archived")
+	}
+	if !exists(filepath.Join(dir, "audit", "audit_"+thisMonth+".json")) {
+		t.Error("current audit file must stay in audit/")
+	}
+}
+
+func TestManager_CompressedSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	// Start from an uncompressed snapshot, as written by older versions.
+	plain, err := NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.SaveSnapshot(snapshotOf("user_1")); err != nil {
+		t.Fatal(err)
+	}
+
+	compressed, err := NewManagerWithOptions(dir, true, Options{CompressSnapshots: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := compressed.LoadLatestSnapshot()
+	if err != nil || loaded.Users["user_1"] == nil {
+		t.Fatalf("uncompressed snapshot not readable: %+v, %v", loaded, err)
+	}
+
+	if err := compressed.SaveSnapshot(snapshotOf("user_2")); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "snapshots", "latest.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGzip(raw) {
+		t.Error("latest.json.gz is not gzip data")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "snapshots", "latest.json")); !os.IsNotExist(err) {
+		t.Error("stale uncompressed latest.json should be removed")
+	}
+
+	// Either manager must read the compressed snapshot back.
+	for _, m := range []*Manager{compressed, plain} {
+		loaded, err := m.LoadLatestSnapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Users["user_2"] == nil {
+			t.Errorf("expected the snapshot with user_2, got %+v", loaded.Users)
+		}
+	}
+}
+
+func TestManager_CorruptLatestFallsBackToBackup(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveSnapshot(snapshotOf("user_5")); err != nil {
+		t.Fatal(err)
+	}
+	latest := filepath.Join(dir, "snapshots", "latest.json")
+
+	// Truncated file and a well-formed file that no longer matches its checksum
+	for _, corrupt := range []string{`{"version": "2.0", "users": {"us`, `{"users": {"user_99": {"id": "user_99"}}}`} {
+		if err := os.WriteFile(latest, []byte(corrupt), 0644); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := m.LoadLatestSnapshot()
+		if err != nil {
+			t.Fatalf("expected fallback to backup, got %v", err)
+		}
+		if loaded.Users["user_5"] == nil {
+			t.Errorf("expected the backup with user_5, got %+v", loaded.Users)
+		}
+	}
+}
+
+func TestManager_NoValidSnapshotIsAnError(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "snapshots", "latest.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.LoadLatestSnapshot(); err == nil {
+		t.Error("expected error when no snapshot is valid")
+	}
+}
@@ -0,0 +1,213 @@
+//go:build sqlite
+
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// =============================================================================
+// SQLITE BACKEND - CP 18: Queryable audit archive at scale
+// Built only with `-tags sqlite` (requires cgo).
+// =============================================================================
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS snapshots (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	saved_at TEXT NOT NULL,
+	version  TEXT NOT NULL,
+	data     BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS audit_log (
+	id          TEXT PRIMARY KEY,
+	timestamp   TEXT NOT NULL,
+	user_id     TEXT,
+	action      TEXT NOT NULL,
+	entity_type TEXT NOT NULL,
+	entity_id   TEXT NOT NULL,
+	old_value   TEXT,
+	new_value   TEXT,
+	ip_address  TEXT,
+	user_agent  TEXT,
+	description TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_audit_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id, timestamp);
+`
+
+// auditTimeFormat sorts lexicographically, so range queries work on TEXT.
+const auditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// SQLitePersister stores snapshots and the audit log in a SQLite database.
+type SQLitePersister struct {
+	db *sql.DB
+}
+
+func newSQLitePersister(dataDir string) (Persister, error) {
+	return NewSQLitePersister(dataDir)
+}
+
+// NewSQLitePersister opens (or creates) <dataDir>/dcm.db. On first start an
+// existing JSON snapshot in dataDir is migrated into the database.
+func NewSQLitePersister(dataDir string) (*SQLitePersister, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(dataDir, "dcm.db")+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+	p := &SQLitePersister{db: db}
+	if err := p.migrateFromJSON(dataDir); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating JSON snapshot: %w", err)
+	}
+	return p, nil
+}
+
+// Close releases the database handle.
+func (p *SQLitePersister) Close() error {
+	return p.db.Close()
+}
+
+func (p *SQLitePersister) SaveSnapshot(data *DataSnapshot) error {
+	// The audit log lives in its own table; keep it out of the blob.
+	snapshot := *data
+	snapshot.AuditLog = nil
+	blob, err := json.Marshal(&snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.Exec(`INSERT INTO snapshots (saved_at, version, data) VALUES (?, ?, ?)`,
+		data.SavedAt.UTC().Format(auditTimeFormat), data.Version, blob)
+	return err
+}
+
+func (p *SQLitePersister) LoadLatestSnapshot() (*DataSnapshot, error) {
+	var blob []byte
+	err := p.db.QueryRow(`SELECT data FROM snapshots ORDER BY id DESC LIMIT 1`).Scan(&blob)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data DataSnapshot
+	if err := json.Unmarshal(blob, &data); err != nil {
+		return nil, err
+	}
+	auditLog, err := p.QueryAudit("", time.Time{}, time.Now().UTC().Add(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	data.AuditLog = auditLog
+	return &data, nil
+}
+
+// RunMaintenance deletes snapshot rows saved more than SnapshotKeepDays ago,
+// always keeping the latest. The audit_log table is the archive itself, so
+// AuditRetentionDays does not apply here.
+func (p *SQLitePersister) RunMaintenance(policy RetentionPolicy) error {
+	cutoff := time.Now().UTC().AddDate(0, 0, -policy.SnapshotKeepDays).Format(auditTimeFormat)
+	res, err := p.db.Exec(`DELETE FROM snapshots
+		WHERE saved_at < ? AND id <> (SELECT MAX(id) FROM snapshots)`, cutoff)
+	if err != nil {
+		return err
+	}
+	if removed, _ := res.RowsAffected(); removed > 0 {
+		log.Printf("Persistence maintenance: removed %d snapshots", removed)
+	}
+	return nil
+}
+
+func (p *SQLitePersister) SaveAuditEntries(entries []models.AuditEntry) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO audit_log
+		(id, timestamp, user_id, action, entity_type, entity_id, old_value, new_value, ip_address, user_agent, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range entries {
+		if _, err := stmt.Exec(e.ID, e.Timestamp.UTC().Format(auditTimeFormat), e.UserID, string(e.Action),
+			e.EntityType, e.EntityID, e.OldValue, e.NewValue, e.IPAddress, e.UserAgent, e.Description); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *SQLitePersister) QueryAudit(userID string, since, until time.Time) ([]models.AuditEntry, error) {
+	query := `SELECT id, timestamp, user_id, action, entity_type, entity_id, old_value, new_value, ip_address, user_agent, description
+		FROM audit_log WHERE timestamp >= ? AND timestamp < ?`
+	args := []interface{}{since.UTC().Format(auditTimeFormat), until.UTC().Format(auditTimeFormat)}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY timestamp, id`
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var ts, action string
+		if err := rows.Scan(&e.ID, &ts, &e.UserID, &action, &e.EntityType, &e.EntityID,
+			&e.OldValue, &e.NewValue, &e.IPAddress, &e.UserAgent, &e.Description); err != nil {
+			return nil, err
+		}
+		e.Timestamp, _ = time.Parse(auditTimeFormat, ts)
+		e.Action = models.AuditAction(action)
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+// migrateFromJSON imports an existing JSON snapshot (and its audit log) the
+// first time the database is used, so switching backends keeps all records.
+func (p *SQLitePersister) migrateFromJSON(dataDir string) error {
+	var count int
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM snapshots`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	legacy, err := (&Manager{dataDir: dataDir, enabled: true}).LoadLatestSnapshot()
+	if err != nil || legacy == nil {
+		return err
+	}
+	if err := p.SaveAuditEntries(legacy.AuditLog); err != nil {
+		return err
+	}
+	if err := p.SaveSnapshot(legacy); err != nil {
+		return err
+	}
+	log.Printf("Migrated JSON snapshot from %s into SQLite (%d audit entries)", legacy.SavedAt.Format(time.RFC3339), len(legacy.AuditLog))
+	return nil
+}
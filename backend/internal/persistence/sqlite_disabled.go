@@ -0,0 +1,9 @@
+//go:build !sqlite
+
+package persistence
+
+import "errors"
+
+func newSQLitePersister(dataDir string) (Persister, error) {
+	return nil, errors.New("sqlite backend not compiled in; rebuild with -tags sqlite")
+}
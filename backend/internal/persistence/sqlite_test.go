@@ -0,0 +1,98 @@
+//go:build sqlite
+
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func TestSQLitePersister_MigratesJSONSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	legacy, err := NewManager(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	snapshot := &DataSnapshot{
+		Users:    map[string]*models.User{"user_1": {ID: "user_1"}},
+		AuditLog: []models.AuditEntry{{ID: "audit_1", UserID: "user_1", Action: models.AuditActionCreate, Timestamp: now}},
+	}
+	if err := legacy.SaveSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewSQLitePersister(dir)
+	if err != nil {
+		t.Fatalf("NewSQLitePersister: %v", err)
+	}
+	defer p.Close()
+
+	loaded, err := p.LoadLatestSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || loaded.Users["user_1"] == nil {
+		t.Fatal("user not migrated")
+	}
+	entries, err := p.QueryAudit("user_1", now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 migrated audit entry, got %d", len(entries))
+	}
+}
+
+func TestSQLitePersister_RunMaintenancePrunesOldSnapshots(t *testing.T) {
+	p, err := NewSQLitePersister(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	policy := RetentionPolicy{SnapshotKeepDays: 1}
+	old := time.Now().UTC().AddDate(0, 0, -3)
+	for i := 0; i < 5; i++ {
+		if err := p.SaveSnapshot(&DataSnapshot{SavedAt: old.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.RunMaintenance(policy); err != nil {
+			t.Fatal(err)
+		}
+		if n := countSnapshotRows(t, p); n != 1 {
+			t.Fatalf("save %d: expected 1 snapshot row, got %d", i, n)
+		}
+	}
+
+	fresh := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		if err := p.SaveSnapshot(&DataSnapshot{SavedAt: fresh, Version: "fresh"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.RunMaintenance(policy); err != nil {
+		t.Fatal(err)
+	}
+	if n := countSnapshotRows(t, p); n != 3 {
+		t.Errorf("expected only the 3 in-retention rows to remain, got %d", n)
+	}
+	loaded, err := p.LoadLatestSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || loaded.Version != "fresh" {
+		t.Errorf("latest snapshot not kept: %+v", loaded)
+	}
+}
+
+func countSnapshotRows(t *testing.T, p *SQLitePersister) int {
+	t.Helper()
+	var n int
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM snapshots`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+// Package redact masks personal data before it leaves the trusted core.
+//
+// Account holders see their own email partially masked and their date of
+// birth reduced to the year; the full values stay with compliance staff,
+// who read them through the admin API. Audit entries keep the same masked
+// forms, so the 5-year audit trail does not become a copy of the KYC file.
+//
+// Core Principle 18: Recordkeeping without retaining more PII than needed.
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// User is the account view returned to the account holder: the user with
+// email and date of birth masked and the last login IP left out.
+type User struct {
+	*models.User
+	Email       string `json:"email"`
+	DateOfBirth string `json:"date_of_birth"`           // Year only, e.g. "1990"
+	LastLoginIP string `json:"last_login_ip,omitempty"` // Always empty
+}
+
+// NewUser returns the masked view of u, or nil for a nil user.
+func NewUser(u *models.User) *User {
+	if u == nil {
+		return nil
+	}
+	return &User{User: u, Email: Email(u.Email), DateOfBirth: BirthYear(u.DateOfBirth)}
+}
+
+// Email keeps the first character of the local part and the domain:
+// "jane.doe@example.com" becomes "j***@example.com".
+func Email(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// BirthYear reduces a date of birth to its year.
+func BirthYear(dob time.Time) string {
+	if dob.IsZero() {
+		return ""
+	}
+	return dob.Format("2006")
+}
+
+// AuditValue masks personal fields anywhere in a JSON audit value: emails
+// as Email, dates of birth as BirthYear, and login IPs removed (the entry
+// records its own IP). Values without such fields are returned unchanged.
+func AuditValue(value string) string {
+	if !strings.Contains(value, `"email"`) && !strings.Contains(value, `"date_of_birth"`) &&
+		!strings.Contains(value, `"last_login_ip"`) {
+		return value
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+	if !scrub(v) {
+		return value
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return value
+	}
+	return string(b)
+}
+
+// scrub masks personal fields in a decoded JSON value in place and reports
+// whether it changed anything.
+func scrub(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			s, isString := field.(string)
+			switch {
+			case key == "email" && isString:
+				v[key] = Email(s)
+				changed = true
+			case key == "date_of_birth" && isString:
+				if dob, err := time.Parse(time.RFC3339, s); err == nil {
+					v[key] = BirthYear(dob)
+					changed = true
+				}
+			case key == "last_login_ip":
+				delete(v, key)
+				changed = true
+			default:
+				changed = scrub(field) || changed
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			changed = scrub(item) || changed
+		}
+	}
+	return changed
+}
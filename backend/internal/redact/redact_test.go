@@ -0,0 +1,37 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmail(t *testing.T) {
+	for in, want := range map[string]string{
+		"jane.doe@example.com": "j***@example.com",
+		"a@b.co":               "a***@b.co",
+		"not-an-email":         "***",
+		"":                     "***",
+	} {
+		if got := Email(in); got != want {
+			t.Errorf("Email(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAuditValue_ScrubsNestedPersonalFields(t *testing.T) {
+	value := `{"user":{"id":"user_1","email":"jane@example.com","date_of_birth":"1987-06-15T00:00:00Z","last_login_ip":"203.0.113.7"},"status":"verified"}`
+	got := AuditValue(value)
+	for _, leaked := range []string{"jane@", "1987-06-15", "203.0.113.7"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("AuditValue leaks %q: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, `"date_of_birth":"1987"`) || !strings.Contains(got, `"status":"verified"`) {
+		t.Errorf("AuditValue = %s", got)
+	}
+
+	plain := `{"status":"open","notes":"email sent"}`
+	if got := AuditValue(plain); got != plain {
+		t.Errorf("AuditValue changed a value without personal fields: %s", got)
+	}
+}
@@ -0,0 +1,152 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ErrorCode is the machine-readable code in an error envelope, together
+// with the HTTP status it is returned with. Its fields are unexported, so
+// the codes declared below are the only ones a handler can send. Clients
+// branch on the name, so codes are never renamed once published.
+type ErrorCode struct {
+	name   string
+	status int
+}
+
+// registry holds every declared code by name.
+var registry = make(map[string]ErrorCode)
+
+// register declares a code. A situation that needs a different status gets
+// its own code.
+func register(name string, status int) ErrorCode {
+	if _, exists := registry[name]; exists {
+		panic("response: duplicate error code " + name)
+	}
+	code := ErrorCode{name: name, status: status}
+	registry[name] = code
+	return code
+}
+
+var (
+	CodeAccountBanned         = register("ACCOUNT_BANNED", http.StatusForbidden)
+	CodeAccountSuspended      = register("ACCOUNT_SUSPENDED", http.StatusForbidden)
+	CodeAgeRestricted         = register("AGE_RESTRICTED", http.StatusForbidden)
+	CodeAlertClosed           = register("ALERT_CLOSED", http.StatusConflict)
+	CodeAlertNotFound         = register("ALERT_NOT_FOUND", http.StatusNotFound)
+	CodeAlertUpdateFailed     = register("ALERT_UPDATE_FAILED", http.StatusInternalServerError)
+	CodeAmendFailed           = register("AMEND_FAILED", http.StatusInternalServerError)
+	CodeAmountBelowMinimum    = register("AMOUNT_BELOW_MINIMUM", http.StatusBadRequest)
+	CodeAmountExceeded        = register("AMOUNT_EXCEEDED", http.StatusBadRequest)
+	CodeCloseFailed           = register("CLOSE_FAILED", http.StatusInternalServerError)
+	CodeCSRFInvalid           = register("CSRF_INVALID", http.StatusForbidden)
+	CodeDepositFailed         = register("DEPOSIT_FAILED", http.StatusInternalServerError)
+	CodeDuplicateReference    = register("DUPLICATE_REFERENCE", http.StatusConflict)
+	CodeForbidden             = register("FORBIDDEN", http.StatusForbidden)
+	CodeFundsUnavailable      = register("FUNDS_UNAVAILABLE", http.StatusConflict)
+	CodeGatewayTimeout        = register("GATEWAY_TIMEOUT", http.StatusGatewayTimeout)
+	CodeIdempotencyKeyReused  = register("IDEMPOTENCY_KEY_REUSED", http.StatusUnprocessableEntity)
+	CodeInsufficientFunds     = register("INSUFFICIENT_FUNDS", http.StatusBadRequest)
+	CodeInternalError         = register("INTERNAL_ERROR", http.StatusInternalServerError)
+	CodeInvalidAlertStatus    = register("INVALID_ALERT_STATUS", http.StatusConflict)
+	CodeInvalidAmount         = register("INVALID_AMOUNT", http.StatusBadRequest)
+	CodeInvalidCredentials    = register("INVALID_CREDENTIALS", http.StatusUnauthorized)
+	CodeInvalidDOB            = register("INVALID_DOB", http.StatusBadRequest)
+	CodeInvalidDepositMethod  = register("INVALID_DEPOSIT_METHOD", http.StatusBadRequest)
+	CodeInvalidDocType        = register("INVALID_DOC_TYPE", http.StatusBadRequest)
+	CodeInvalidIdempotencyKey = register("INVALID_IDEMPOTENCY_KEY", http.StatusBadRequest)
+	CodeInvalidInterval       = register("INVALID_INTERVAL", http.StatusBadRequest)
+	CodeInvalidLimits         = register("INVALID_LIMITS", http.StatusBadRequest)
+	CodeInvalidOrder          = register("INVALID_ORDER", http.StatusBadRequest)
+	CodeInvalidPrice          = register("INVALID_PRICE", http.StatusBadRequest)
+	CodeInvalidQuantity       = register("INVALID_QUANTITY", http.StatusBadRequest)
+	CodeInvalidReference      = register("INVALID_REFERENCE", http.StatusBadRequest)
+	CodeInvalidRequest        = register("INVALID_REQUEST", http.StatusBadRequest)
+	CodeInvalidRiskCategory   = register("INVALID_RISK_CATEGORY", http.StatusBadRequest)
+	CodeInvalidSide           = register("INVALID_SIDE", http.StatusBadRequest)
+	CodeInvalidStatusChange   = register("INVALID_STATUS_CHANGE", http.StatusConflict)
+	CodeInvalidToken          = register("INVALID_TOKEN", http.StatusUnauthorized)
+	CodeInvalidType           = register("INVALID_TYPE", http.StatusBadRequest)
+	CodeKalshiError           = register("KALSHI_ERROR", http.StatusBadGateway)
+	CodeKalshiRateLimited     = register("KALSHI_RATE_LIMITED", http.StatusTooManyRequests)
+	CodeKalshiUnavailable     = register("KALSHI_UNAVAILABLE", http.StatusServiceUnavailable)
+	CodeKYCAlreadySubmitted   = register("KYC_ALREADY_SUBMITTED", http.StatusConflict)
+	CodeKYCNotFound           = register("KYC_NOT_FOUND", http.StatusNotFound)
+	CodeKYCRequired           = register("KYC_REQUIRED", http.StatusForbidden)
+	CodeLiquidationFailed     = register("LIQUIDATION_FAILED", http.StatusInternalServerError)
+	CodeMarketClosed          = register("MARKET_CLOSED", http.StatusBadRequest)
+	CodeMarketNotFound        = register("MARKET_NOT_FOUND", http.StatusNotFound)
+	CodeMarketSettled         = register("MARKET_SETTLED", http.StatusConflict)
+	CodeMethodNotAllowed      = register("METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed)
+	CodeMissingFields         = register("MISSING_FIELDS", http.StatusBadRequest)
+	CodeMissingTicker         = register("MISSING_TICKER", http.StatusBadRequest)
+	CodeMissingToken          = register("MISSING_TOKEN", http.StatusUnauthorized)
+	CodeNothingToReduce       = register("NOTHING_TO_REDUCE", http.StatusConflict)
+	CodeNotFound              = register("NOT_FOUND", http.StatusNotFound)
+	CodeNotReady              = register("NOT_READY", http.StatusServiceUnavailable)
+	CodeNotReversible         = register("NOT_REVERSIBLE", http.StatusConflict)
+	CodeOrderFailed           = register("ORDER_FAILED", http.StatusInternalServerError)
+	CodeOrderNotAmendable     = register("ORDER_NOT_AMENDABLE", http.StatusConflict)
+	CodeOrderNotCancellable   = register("ORDER_NOT_CANCELLABLE", http.StatusConflict)
+	CodeOrderNotFillable      = register("ORDER_NOT_FILLABLE", http.StatusConflict)
+	CodeOrderNotFound         = register("ORDER_NOT_FOUND", http.StatusNotFound)
+	CodePositionLimit         = register("POSITION_LIMIT", http.StatusBadRequest)
+	CodePositionNotFound      = register("POSITION_NOT_FOUND", http.StatusNotFound)
+	CodeRateLimited           = register("RATE_LIMITED", http.StatusTooManyRequests)
+	CodeRequestTooLarge       = register("REQUEST_TOO_LARGE", http.StatusRequestEntityTooLarge)
+	CodeReversalFailed        = register("REVERSAL_FAILED", http.StatusInternalServerError)
+	CodeSelfTradePrevented    = register("SELF_TRADE_PREVENTED", http.StatusConflict)
+	CodeSessionExpired        = register("SESSION_EXPIRED", http.StatusUnauthorized)
+	CodeStateRestricted       = register("STATE_RESTRICTED", http.StatusForbidden)
+	CodeTooManyOpenOrders     = register("TOO_MANY_OPEN_ORDERS", http.StatusBadRequest)
+	CodeTradingHalted         = register("TRADING_HALTED", http.StatusServiceUnavailable)
+	CodeTransactionNotFound   = register("TRANSACTION_NOT_FOUND", http.StatusNotFound)
+	CodeUnauthorized          = register("UNAUTHORIZED", http.StatusUnauthorized)
+	CodeUserExists            = register("USER_EXISTS", http.StatusConflict)
+	CodeUserNotFound          = register("USER_NOT_FOUND", http.StatusNotFound)
+	CodeUSResidencyRequired   = register("US_RESIDENCY_REQUIRED", http.StatusForbidden)
+	CodeWalletNotFound        = register("WALLET_NOT_FOUND", http.StatusNotFound)
+	CodeWithdrawalFailed      = register("WITHDRAWAL_FAILED", http.StatusInternalServerError)
+)
+
+// String returns the code's name, e.g. "ORDER_NOT_FOUND".
+func (c ErrorCode) String() string {
+	return c.name
+}
+
+// Status returns the HTTP status the code is returned with.
+func (c ErrorCode) Status() int {
+	return c.status
+}
+
+// MarshalText encodes the code as its name.
+func (c ErrorCode) MarshalText() ([]byte, error) {
+	return []byte(c.name), nil
+}
+
+// UnmarshalText decodes a registered code name.
+func (c *ErrorCode) UnmarshalText(text []byte) error {
+	code, exists := registry[string(text)]
+	if !exists {
+		return fmt.Errorf("unknown error code %q", text)
+	}
+	*c = code
+	return nil
+}
+
+// Lookup returns the registered code with the given name.
+func Lookup(name string) (ErrorCode, bool) {
+	code, exists := registry[name]
+	return code, exists
+}
+
+// ErrorCodes returns every registered code, sorted by name.
+func ErrorCodes() []ErrorCode {
+	codes := make([]ErrorCode, 0, len(registry))
+	for _, code := range registry {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].name < codes[j].name })
+	return codes
+}
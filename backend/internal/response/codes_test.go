@@ -0,0 +1,85 @@
+package response
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// TestErrorCodes_EveryCodeHasStatus checks every code declared in codes.go
+// is registered with a 4xx or 5xx status.
+func TestErrorCodes_EveryCodeHasStatus(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "register" {
+			return true
+		}
+		name, _ := strconv.Unquote(call.Args[0].(*ast.BasicLit).Value)
+		declared++
+		code, ok := Lookup(name)
+		if !ok {
+			t.Errorf("%s is declared but not registered", name)
+		} else if status := code.Status(); status < 400 || status > 599 {
+			t.Errorf("%s has status %d, want 4xx or 5xx", name, status)
+		}
+		return true
+	})
+	if declared == 0 {
+		t.Fatal("no register calls found in codes.go")
+	}
+	if got := len(ErrorCodes()); got != declared {
+		t.Errorf("registry has %d codes, codes.go declares %d", got, declared)
+	}
+}
+
+func TestErrorCodes_Sorted(t *testing.T) {
+	codes := ErrorCodes()
+	for i := 1; i < len(codes); i++ {
+		if codes[i-1].String() >= codes[i].String() {
+			t.Fatalf("codes not sorted: %s before %s", codes[i-1], codes[i])
+		}
+	}
+}
+
+func TestErrorCode_JSONRoundTrip(t *testing.T) {
+	b, err := json.Marshal(struct{ Code ErrorCode }{CodeOrderNotFound})
+	if err != nil || string(b) != `{"Code":"ORDER_NOT_FOUND"}` {
+		t.Fatalf("marshal = %s, %v", b, err)
+	}
+	var decoded struct{ Code ErrorCode }
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded.Code != CodeOrderNotFound {
+		t.Errorf("unmarshal = %v, %v", decoded.Code, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Code":"NO_SUCH_CODE"}`), &decoded); err == nil {
+		t.Error("unregistered code decoded without error")
+	}
+}
+
+func TestError_UsesRegisteredStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Error(rec, "Order not found", CodeOrderNotFound)
+	var resp APIResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusNotFound || resp.Code != "ORDER_NOT_FOUND" {
+		t.Errorf("got %d %q, want 404 ORDER_NOT_FOUND", rec.Code, resp.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Error(rec, "zero code", ErrorCode{})
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("zero code status = %d, want 500", rec.Code)
+	}
+}
@@ -0,0 +1,56 @@
+// Package response writes the JSON envelope shared by every API endpoint,
+// including the auth middleware, so clients see one success and error shape.
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kalshi-dcm-demo/backend/internal/logging"
+)
+
+// APIResponse is the envelope for all API responses.
+type APIResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Code    string      `json:"code,omitempty"` // An ErrorCode name, set by Error
+	Meta    interface{} `json:"meta,omitempty"`
+}
+
+// JSON writes payload with the given status.
+func JSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// Error writes an error envelope with a machine-readable code, using the
+// status the code is registered with.
+func Error(w http.ResponseWriter, message string, code ErrorCode) {
+	ErrorWithMeta(w, message, code, nil)
+}
+
+// ErrorWithMeta writes an error envelope with extra meta fields, such as
+// per-field validation errors.
+func ErrorWithMeta(w http.ResponseWriter, message string, code ErrorCode, meta map[string]interface{}) {
+	if code.status == 0 {
+		code = CodeInternalError // The zero ErrorCode
+	}
+	resp := APIResponse{
+		Success: false,
+		Error:   message,
+		Code:    code.name,
+	}
+	// logging.Middleware sets the request ID header before any handler runs
+	if id := w.Header().Get(logging.RequestIDHeader); id != "" {
+		if meta == nil {
+			meta = map[string]interface{}{}
+		}
+		meta["request_id"] = id
+	}
+	if len(meta) > 0 {
+		resp.Meta = meta
+	}
+	JSON(w, code.status, resp)
+}
@@ -0,0 +1,221 @@
+// Package settlement settles positions automatically once Kalshi reports a
+// market result.
+// CP 11: Financial Integrity - winners are paid and collateral released
+// without manual intervention.
+package settlement
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// Store is the subset of the store the poller needs.
+type Store interface {
+	OpenPositionTickers() []string
+	IsMarketSettled(marketTicker string) bool
+	SettleMarketFromSource(marketTicker, result, reason string, source *models.SettlementSource) (int, error)
+}
+
+// MarketSource fetches a single market's current state.
+type MarketSource interface {
+	GetMarket(ticker string) (*kalshi.KalshiMarketResponse, error)
+}
+
+// KalshiSource names Kalshi as the source of results the poller settles on.
+const KalshiSource = "kalshi.com"
+
+// Poller periodically checks markets that have open positions and, once
+// Kalshi reports a final result and the market's resolution delay has
+// passed, hands them to a Scheduler to settle. The poller is the
+// scheduler's SourceFeed: Kalshi resolves each market from the primary
+// source of its SettlementRule, but Kalshi is where the poller learns the
+// result, so settlements record Kalshi as the source and Kalshi's
+// settlement time as when it was reported. A result the poller only sees
+// late, such as after a restart, is judged by when Kalshi settled.
+type Poller struct {
+	store     Store
+	markets   MarketSource
+	scheduler *Scheduler
+	interval  time.Duration
+	now       func() time.Time
+
+	// settled debounces tickers already handled by this process so a market
+	// is never fetched or settled twice; the store guards across restarts.
+	settled map[string]bool
+	// scheduled holds markets handed to the scheduler, which are not
+	// fetched again.
+	scheduled map[string]bool
+	mu        sync.Mutex
+
+	// reports holds Kalshi's result for each market. The scheduler reads it
+	// during Poll, so it has its own lock.
+	reports   map[string]SourceReport
+	reportsMu sync.Mutex
+
+	stopChan chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPoller creates a poller that checks markets every interval.
+func NewPoller(store Store, markets MarketSource, interval time.Duration) *Poller {
+	p := &Poller{
+		store:     store,
+		markets:   markets,
+		interval:  interval,
+		now:       time.Now,
+		settled:   make(map[string]bool),
+		scheduled: make(map[string]bool),
+		reports:   make(map[string]SourceReport),
+		stopChan:  make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	p.scheduler = NewScheduler(store, p)
+	p.scheduler.now = func() time.Time { return p.now() }
+	return p
+}
+
+// Start runs the poll loop in the background until Stop is called.
+func (p *Poller) Start() {
+	go p.run()
+}
+
+// Stop ends the poll loop and waits for an in-progress poll to finish.
+func (p *Poller) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+		<-p.stopped
+	})
+}
+
+func (p *Poller) run() {
+	defer close(p.stopped)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.Poll()
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// Poll checks every market with open positions once, schedules those that
+// have a final result and settles the scheduled markets a source now
+// decides. It returns the number of markets settled.
+func (p *Poller) Poll() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// A scheduled market settled by other means, such as by an admin,
+	// may have no open positions left to list it
+	for ticker := range p.scheduled {
+		if p.store.IsMarketSettled(ticker) {
+			p.scheduler.Unschedule(ticker)
+			p.settled[ticker] = true
+			delete(p.scheduled, ticker)
+		}
+	}
+	for _, ticker := range p.store.OpenPositionTickers() {
+		if p.settled[ticker] || p.scheduled[ticker] {
+			continue
+		}
+		if p.store.IsMarketSettled(ticker) {
+			p.settled[ticker] = true
+			continue
+		}
+
+		market, err := p.markets.GetMarket(ticker)
+		if err != nil {
+			slog.Warn("settlement poll failed", "ticker", ticker, "error", err)
+			continue
+		}
+		if !IsFinal(market) {
+			continue
+		}
+		// Without a close time the resolution delay cannot be applied
+		closed, err := time.Parse(time.RFC3339, market.CloseTime)
+		if err != nil {
+			slog.Warn("market close time unreadable, not settling", "ticker", ticker, "close_time", market.CloseTime, "error", err)
+			continue
+		}
+		now := p.now().UTC()
+		p.reportsMu.Lock()
+		if _, seen := p.reports[ticker]; !seen {
+			p.reports[ticker] = SourceReport{Result: market.Result, PublishedAt: settledAt(market, now)}
+		}
+		p.reportsMu.Unlock()
+		rule := relayedRule(ticker, market.SeriesTicker)
+		if now.Before(closed.Add(rule.ResolutionDelay)) {
+			continue
+		}
+		p.scheduler.Schedule(ticker, rule, closed)
+		p.scheduled[ticker] = true
+	}
+
+	count := p.scheduler.Check()
+	pending := make(map[string]bool)
+	for _, ticker := range p.scheduler.Pending() {
+		pending[ticker] = true
+	}
+	for ticker := range p.scheduled {
+		if !pending[ticker] {
+			p.settled[ticker] = true
+			delete(p.scheduled, ticker)
+		}
+	}
+	return count
+}
+
+// Reports returns what Kalshi reported for a market, as the SourceFeed for
+// the poller's scheduler.
+func (p *Poller) Reports(marketTicker string) map[string]SourceReport {
+	p.reportsMu.Lock()
+	defer p.reportsMu.Unlock()
+	report, ok := p.reports[marketTicker]
+	if !ok {
+		return nil
+	}
+	return map[string]SourceReport{KalshiSource: report}
+}
+
+// settledAt returns when Kalshi settled a market, or seen, when the poller
+// first saw the result, if Kalshi did not say or the time is unreadable.
+func settledAt(market *kalshi.KalshiMarketResponse, seen time.Time) time.Time {
+	at, err := time.Parse(time.RFC3339, market.SettlementTime)
+	if err != nil || at.After(seen) {
+		return seen
+	}
+	return at.UTC()
+}
+
+// relayedRule returns the market's SettlementRule with Kalshi as its
+// source. Kalshi's final result is authoritative, so it is also the
+// fallback: a result Kalshi settles after the extension window still
+// settles the market, recorded as extended with the delay explained,
+// rather than leaving it scheduled forever.
+func relayedRule(ticker, seriesTicker string) kalshi.SettlementRule {
+	rule, _ := kalshi.ResolveRuleForTicker(ticker, seriesTicker)
+	rule.Sources = kalshi.ResolutionSource{Primary: KalshiSource, Secondary: KalshiSource}
+	return rule
+}
+
+// IsFinal reports whether Kalshi has published a final result for a market.
+func IsFinal(market *kalshi.KalshiMarketResponse) bool {
+	if market.Result == "" {
+		return false
+	}
+	switch strings.ToLower(market.Status) {
+	case "settled", "finalized":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package settlement
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+func newFundedPosition(t *testing.T, store *mock.Store, ticker string) *models.User {
+	t.Helper()
+	user, err := store.CreateUser("settle@example.com", "hash", "S", "User", "NY", time.Now().AddDate(-30, 0, 0), true, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.CreateKYCRecord(user.ID, "passport", "P1", "127.0.0.1")
+	store.MockKYCApproval(user.ID, true, "")
+	store.CreateWallet(user.ID, "127.0.0.1")
+	store.Deposit(user.ID, models.Dollars(100), "test", "127.0.0.1")
+	order, err := store.CreateOrder(user.ID, ticker, "EVT", models.OrderSideYes, models.OrderTypeLimit, 10, 40, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MockFillOrder(order.ID, 40); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestPoller_SettlesFinalizedMarketOnce(t *testing.T) {
+	var status atomic.Value
+	status.Store("active")
+	var fetches atomic.Int32
+	closed := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		result := ""
+		if status.Load() == "finalized" {
+			result = "yes"
+		}
+		fmt.Fprintf(w, `{"market":{"ticker":"FED-24DEC","status":%q,"result":%q,"close_time":%q}}`, status.Load(), result, closed)
+	}))
+	defer server.Close()
+
+	store := mock.NewStore()
+	user := newFundedPosition(t, store, "FED-24DEC")
+	poller := NewPoller(store, kalshi.NewClient(server.URL, time.Second), time.Hour)
+
+	if n := poller.Poll(); n != 0 {
+		t.Fatalf("active market settled: %d", n)
+	}
+
+	status.Store("finalized")
+	if n := poller.Poll(); n != 1 {
+		t.Fatalf("expected 1 market settled, got %d", n)
+	}
+	wallet, _ := store.GetWallet(user.ID)
+	if wallet.AvailableUSD != models.Dollars(106) || wallet.LockedUSD != 0 {
+		t.Errorf("wallet available/locked = %s/%s, want $106.00/$0.00", wallet.AvailableUSD, wallet.LockedUSD)
+	}
+
+	before := fetches.Load()
+	if n := poller.Poll(); n != 0 {
+		t.Errorf("market settled twice")
+	}
+	if fetches.Load() != before {
+		t.Errorf("settled market was fetched again")
+	}
+}
+
+func TestPoller_SkipsMarketsSettledBeforeRestart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected Kalshi request for %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	store := mock.NewStore()
+	user := newFundedPosition(t, store, "CPI-24NOV")
+	if _, err := store.SettleMarket("CPI-24NOV", "no", "test"); err != nil {
+		t.Fatal(err)
+	}
+	// A late order cannot reopen a position in the already-settled market.
+	if _, err := store.CreateOrder(user.ID, "CPI-24NOV", "EVT", models.OrderSideYes, models.OrderTypeLimit, 1, 40, "127.0.0.1"); err != mock.ErrMarketAlreadySettled {
+		t.Fatalf("late order: err = %v, want ErrMarketAlreadySettled", err)
+	}
+
+	poller := NewPoller(store, kalshi.NewClient(server.URL, time.Second), time.Hour)
+	poller.Poll()
+}
+
+func TestIsFinal(t *testing.T) {
+	tests := []struct {
+		status, result string
+		want           bool
+	}{
+		{"active", "", false},
+		{"closed", "", false},
+		{"determined", "yes", false},
+		{"settled", "no", true},
+		{"finalized", "yes", true},
+		{"finalized", "", false},
+	}
+	for _, tt := range tests {
+		got := IsFinal(&kalshi.KalshiMarketResponse{Status: tt.status, Result: tt.result})
+		if got != tt.want {
+			t.Errorf("IsFinal(%q, %q) = %v, want %v", tt.status, tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestPoller_SkipsUnreadableCloseTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"market":{"ticker":"FED-24DEC","status":"finalized","result":"yes","close_time":"soon"}}`)
+	}))
+	defer server.Close()
+
+	store := mock.NewStore()
+	newFundedPosition(t, store, "FED-24DEC")
+	poller := NewPoller(store, kalshi.NewClient(server.URL, time.Second), time.Hour)
+	if n := poller.Poll(); n != 0 || store.IsMarketSettled("FED-24DEC") {
+		t.Errorf("market with an unreadable close time settled")
+	}
+}
+
+// A finalized market waits out its rule's resolution delay and settles
+// with Kalshi recorded as the source. Without a settlement time from
+// Kalshi, the result counts as reported when the poller first saw it.
+func TestPoller_AppliesRuleForMarket(t *testing.T) {
+	closed := time.Date(2024, 11, 13, 13, 30, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"market":{"ticker":"CPI-24NOV","series_ticker":"CPI","status":"finalized","result":"yes","close_time":%q}}`,
+			closed.Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	store := mock.NewStore()
+	newFundedPosition(t, store, "CPI-24NOV")
+	poller := NewPoller(store, kalshi.NewClient(server.URL, time.Second), time.Hour)
+	now := closed.Add(10 * time.Minute)
+	poller.now = func() time.Time { return now }
+
+	if n := poller.Poll(); n != 0 {
+		t.Fatalf("settled %d markets inside the 30m resolution delay", n)
+	}
+	now = closed.Add(31 * time.Minute)
+	if n := poller.Poll(); n != 1 {
+		t.Fatalf("settled %d markets, want 1", n)
+	}
+	settlements := store.GetAllSettlements("CPI-24NOV", 10)
+	if len(settlements) != 1 || settlements[0].Source == nil {
+		t.Fatalf("settlements = %+v, want one with a source", settlements)
+	}
+	source := settlements[0].Source
+	if source.Name != KalshiSource || source.Tier != models.SourceTierPrimary || source.Extended ||
+		!source.ReportedAt.Equal(closed.Add(10*time.Minute)) || !source.VerifiedAt.Equal(now) {
+		t.Errorf("source = %+v, want kalshi.com reported at first sight", source)
+	}
+}
+
+// finalizedCPI serves CPI-24NOV as finalized YES, closed at closed and
+// settled by Kalshi at settled, counting fetches.
+func finalizedCPI(t *testing.T, closed, settled time.Time, fetches *atomic.Int32) *kalshi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		fmt.Fprintf(w, `{"market":{"ticker":"CPI-24NOV","series_ticker":"CPI","status":"finalized","result":"yes","close_time":%q,"settlement_ts":%q}}`,
+			closed.Format(time.RFC3339), settled.Format(time.RFC3339))
+	}))
+	t.Cleanup(server.Close)
+	return kalshi.NewClient(server.URL, time.Second)
+}
+
+// A result Kalshi settled on time counts as on time even when the poller
+// first sees it long after, such as after a restart or when a position is
+// opened late.
+func TestPoller_LateSightingUsesKalshiSettlementTime(t *testing.T) {
+	closed := time.Date(2024, 11, 13, 13, 30, 0, 0, time.UTC)
+	var fetches atomic.Int32
+	store := mock.NewStore()
+	newFundedPosition(t, store, "CPI-24NOV")
+	poller := NewPoller(store, finalizedCPI(t, closed, closed.Add(20*time.Minute), &fetches), time.Hour)
+	now := closed.Add(48 * time.Hour)
+	poller.now = func() time.Time { return now }
+
+	if n := poller.Poll(); n != 1 {
+		t.Fatalf("settled %d markets, want 1", n)
+	}
+	settlements := store.GetAllSettlements("CPI-24NOV", 10)
+	if len(settlements) != 1 || settlements[0].Source == nil {
+		t.Fatalf("settlements = %+v, want one with a source", settlements)
+	}
+	source := settlements[0].Source
+	if source.Name != KalshiSource || source.Tier != models.SourceTierPrimary || source.Extended ||
+		!source.ReportedAt.Equal(closed.Add(20*time.Minute)) || !source.VerifiedAt.Equal(now) {
+		t.Errorf("source = %+v, want kalshi.com reported at its settlement time", source)
+	}
+	poller.Poll()
+	if fetches.Load() != 1 || len(poller.scheduler.Pending()) != 0 {
+		t.Errorf("%d fetches, pending %v; want 1 and none", fetches.Load(), poller.scheduler.Pending())
+	}
+}
+
+// Kalshi's final result is authoritative: one settled after the extension
+// window still settles the market, marked extended, instead of waiting on
+// an admin.
+func TestPoller_KalshiResultPastExtensionStillSettles(t *testing.T) {
+	closed := time.Date(2024, 11, 13, 13, 30, 0, 0, time.UTC)
+	var fetches atomic.Int32
+	store := mock.NewStore()
+	user := newFundedPosition(t, store, "CPI-24NOV")
+	poller := NewPoller(store, finalizedCPI(t, closed, closed.Add(48*time.Hour), &fetches), time.Hour)
+	poller.now = func() time.Time { return closed.Add(49 * time.Hour) }
+
+	if n := poller.Poll(); n != 1 {
+		t.Fatalf("settled %d markets on a result past the extension, want 1", n)
+	}
+	settlements := store.GetAllSettlements("CPI-24NOV", 10)
+	if len(settlements) != 1 || settlements[0].Source == nil {
+		t.Fatalf("settlements = %+v, want one with a source", settlements)
+	}
+	if source := settlements[0].Source; source.Name != KalshiSource || !source.Extended || source.DelayReason == "" ||
+		!source.ReportedAt.Equal(closed.Add(48*time.Hour)) {
+		t.Errorf("source = %+v, want kalshi.com extended with a delay reason", source)
+	}
+	if wallet, _ := store.GetWallet(user.ID); wallet.AvailableUSD != models.Dollars(106) || wallet.LockedUSD != 0 {
+		t.Errorf("wallet available/locked = %s/%s, want $106.00/$0.00", wallet.AvailableUSD, wallet.LockedUSD)
+	}
+	if pending := poller.scheduler.Pending(); len(pending) != 0 {
+		t.Errorf("pending = %v, want none", pending)
+	}
+}
@@ -0,0 +1,130 @@
+package settlement
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// SourceReport is the result one resolution source published for a market.
+type SourceReport struct {
+	Result      string
+	PublishedAt time.Time
+}
+
+// SourceFeed returns what each resolution source has published for a
+// market, keyed by source name.
+type SourceFeed interface {
+	Reports(marketTicker string) map[string]SourceReport
+}
+
+// SourceSettler settles a market and records the source that decided it.
+type SourceSettler interface {
+	SettleMarketFromSource(marketTicker, result, reason string, source *models.SettlementSource) (int, error)
+}
+
+// Scheduler holds closed markets until a resolution source can settle them
+// under their SettlementRule. The primary source is awaited for
+// ResolutionDelay and, if late, for up to ExtensionWindow more; after that
+// the secondary and tertiary sources are used. The delay reasoning is
+// recorded on the settlement. The settlement Poller runs one with Kalshi
+// as the feed.
+// CP 3: Objective resolution with fallback mechanisms
+type Scheduler struct {
+	store SourceSettler
+	feed  SourceFeed
+	now   func() time.Time
+
+	pending map[string]scheduledMarket
+	mu      sync.Mutex
+}
+
+type scheduledMarket struct {
+	rule     kalshi.SettlementRule
+	closedAt time.Time
+}
+
+// NewScheduler creates a scheduler that reads source reports from feed and
+// settles through store.
+func NewScheduler(store SourceSettler, feed SourceFeed) *Scheduler {
+	return &Scheduler{
+		store:   store,
+		feed:    feed,
+		now:     time.Now,
+		pending: make(map[string]scheduledMarket),
+	}
+}
+
+// Schedule adds a market that closed at closedAt. Scheduling a market
+// again replaces its rule and close time.
+func (s *Scheduler) Schedule(marketTicker string, rule kalshi.SettlementRule, closedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[marketTicker] = scheduledMarket{rule: rule, closedAt: closedAt}
+}
+
+// Unschedule drops a market, such as one settled by other means.
+func (s *Scheduler) Unschedule(marketTicker string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, marketTicker)
+}
+
+// Pending returns the markets still awaiting a resolution source, sorted.
+func (s *Scheduler) Pending() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tickers := make([]string, 0, len(s.pending))
+	for ticker := range s.pending {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// Check settles every pending market whose rule now selects a source. It
+// returns the number of markets settled.
+func (s *Scheduler) Check() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	count := 0
+	for ticker, market := range s.pending {
+		reports := s.feed.Reports(ticker)
+		published := make(map[string]time.Time, len(reports))
+		for name, report := range reports {
+			published[name] = report.PublishedAt
+		}
+		source, ok := market.rule.SelectSource(market.closedAt, now, published)
+		if !ok {
+			continue
+		}
+
+		result := reports[source.Name].Result
+		reason := fmt.Sprintf("%s source %s reported result %q", source.Tier, source.Name, result)
+		if source.DelayReason != "" {
+			reason += "; " + source.DelayReason
+		}
+		positions, err := s.store.SettleMarketFromSource(ticker, result, reason, source)
+		switch {
+		case errors.Is(err, mock.ErrMarketAlreadySettled):
+			delete(s.pending, ticker)
+		case err != nil:
+			slog.Error("market settlement failed", "ticker", ticker, "source", source.Name, "result", result, "error", err)
+		default:
+			delete(s.pending, ticker)
+			count++
+			slog.Info("market settled", "ticker", ticker, "source", source.Name, "tier", source.Tier,
+				"extended", source.Extended, "positions", positions)
+		}
+	}
+	return count
+}
@@ -0,0 +1,90 @@
+package settlement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/mock"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+type fakeFeed map[string]map[string]SourceReport
+
+func (f fakeFeed) Reports(marketTicker string) map[string]SourceReport { return f[marketTicker] }
+
+// newScheduledMarket schedules CPI-24NOV, which has a funded YES position,
+// under the CPI rule (30m delay, 24h extension) on a clock the test moves.
+func newScheduledMarket(t *testing.T) (*mock.Store, *Scheduler, fakeFeed, time.Time, *time.Time) {
+	t.Helper()
+	store := mock.NewStore()
+	newFundedPosition(t, store, "CPI-24NOV")
+	feed := fakeFeed{"CPI-24NOV": {}}
+	scheduler := NewScheduler(store, feed)
+	closed := time.Date(2024, 11, 13, 13, 30, 0, 0, time.UTC)
+	now := closed
+	scheduler.now = func() time.Time { return now }
+	scheduler.Schedule("CPI-24NOV", kalshi.DefaultSettlementRules()["CPI"], closed)
+	return store, scheduler, feed, closed, &now
+}
+
+func settledSource(t *testing.T, store *mock.Store) *models.SettlementSource {
+	t.Helper()
+	settlements := store.GetAllSettlements("CPI-24NOV", 10)
+	if len(settlements) != 1 || settlements[0].Source == nil {
+		t.Fatalf("settlements = %+v, want one with a source", settlements)
+	}
+	return settlements[0].Source
+}
+
+func TestScheduler_PrimaryResolvesWithinExtension(t *testing.T) {
+	store, scheduler, feed, closed, now := newScheduledMarket(t)
+	feed["CPI-24NOV"]["reuters.com"] = SourceReport{Result: "no", PublishedAt: closed.Add(20 * time.Minute)}
+
+	*now = closed.Add(2 * time.Hour) // past the delay, inside the extension
+	if n := scheduler.Check(); n != 0 {
+		t.Fatalf("settled %d markets while waiting on the primary", n)
+	}
+
+	feed["CPI-24NOV"]["bls.gov"] = SourceReport{Result: "yes", PublishedAt: closed.Add(3 * time.Hour)}
+	*now = closed.Add(4 * time.Hour)
+	if n := scheduler.Check(); n != 1 {
+		t.Fatalf("settled %d markets, want 1", n)
+	}
+	source := settledSource(t, store)
+	if source.Name != "bls.gov" || source.Tier != models.SourceTierPrimary || !source.Extended ||
+		!strings.Contains(source.DelayReason, "within the 24h0m0s extension") {
+		t.Errorf("source = %+v, want the late primary within the extension", source)
+	}
+	if got := store.GetAllSettlements("CPI-24NOV", 10)[0].Result; got != "yes" {
+		t.Errorf("result = %s, want the primary's yes", got)
+	}
+	if pending := scheduler.Pending(); len(pending) != 0 {
+		t.Errorf("pending after settlement = %v", pending)
+	}
+}
+
+func TestScheduler_FallsBackAfterExtension(t *testing.T) {
+	store, scheduler, feed, closed, now := newScheduledMarket(t)
+	feed["CPI-24NOV"]["reuters.com"] = SourceReport{Result: "no", PublishedAt: closed.Add(20 * time.Minute)}
+
+	*now = closed.Add(24 * time.Hour) // 30 minutes of extension left
+	if n := scheduler.Check(); n != 0 {
+		t.Fatalf("fell back %d markets before the extension ended", n)
+	}
+
+	*now = closed.Add(24*time.Hour + 31*time.Minute)
+	if n := scheduler.Check(); n != 1 {
+		t.Fatalf("settled %d markets, want 1", n)
+	}
+	source := settledSource(t, store)
+	if source.Name != "reuters.com" || source.Tier != models.SourceTierSecondary || !source.Extended ||
+		!strings.Contains(source.DelayReason, "bls.gov did not report") {
+		t.Errorf("source = %+v, want the secondary after the extension", source)
+	}
+	settlement := store.GetAllSettlements("CPI-24NOV", 10)[0]
+	if settlement.Result != "no" || !strings.Contains(settlement.Reason, "fell back to the secondary source") {
+		t.Errorf("settlement = %+v, want no from the secondary with the delay reasoning", settlement)
+	}
+}
@@ -0,0 +1,173 @@
+// Package webhook pushes compliance events to an operator endpoint such as a
+// Slack or PagerDuty integration.
+//
+// Core Principle 4: Operators learn of high-severity alerts and halts as they
+// happen rather than by polling.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+const (
+	// SignatureHeader carries "sha256=" plus the hex HMAC-SHA256 of the body.
+	SignatureHeader = "X-DCM-Signature"
+	// EventHeader carries the event type.
+	EventHeader = "X-DCM-Event"
+)
+
+// Defaults for NewNotifier.
+const (
+	DefaultMaxAttempts = 4
+	DefaultBackoff     = time.Second
+	// DefaultMaxInFlight bounds concurrent deliveries, each of which may be
+	// sleeping between retries.
+	DefaultMaxInFlight = 16
+)
+
+// Types are the event types a notifier forwards; Notifiable narrows them.
+var Types = []events.Type{events.TypeAlert, events.TypeHalt}
+
+// Notifier POSTs signed event payloads to a URL, retrying with exponential
+// backoff.
+type Notifier struct {
+	url         string
+	secret      []byte
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	sleep       func(time.Duration)
+	inFlight    chan struct{} // one slot per running delivery
+	logger      *slog.Logger
+}
+
+// NewNotifier creates a notifier for url, signing bodies with secret.
+func NewNotifier(url, secret string) *Notifier {
+	return &Notifier{
+		url:         url,
+		secret:      []byte(secret),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		sleep:       time.Sleep,
+		inFlight:    make(chan struct{}, DefaultMaxInFlight),
+		logger:      slog.Default(),
+	}
+}
+
+// SetRetry sets the number of delivery attempts and the delay before the
+// first retry, which doubles on each further retry.
+func (n *Notifier) SetRetry(maxAttempts int, backoff time.Duration) {
+	if maxAttempts > 0 {
+		n.maxAttempts = maxAttempts
+	}
+	n.backoff = backoff
+}
+
+// Run delivers qualifying events from ch until it is closed, then waits for
+// deliveries in progress. Run it in its own goroutine subscribed to Types.
+// Each event is delivered on its own goroutine so that a receiver being
+// retried does not hold up later events; receivers should order deliveries
+// by the payload's "at". An event arriving while DefaultMaxInFlight
+// deliveries are running is dropped and logged.
+func (n *Notifier) Run(ch <-chan events.Event) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for e := range ch {
+		if !Notifiable(e) {
+			continue
+		}
+		select {
+		case n.inFlight <- struct{}{}:
+		default:
+			n.logger.Error("webhook delivery dropped: too many in flight", "type", e.Type, "entity_id", e.EntityID)
+			continue
+		}
+		wg.Add(1)
+		go func(e events.Event) {
+			defer wg.Done()
+			defer func() { <-n.inFlight }()
+			if err := n.Send(e); err != nil {
+				n.logger.Error("webhook delivery failed", "type", e.Type, "entity_id", e.EntityID, "error", err)
+			}
+		}(e)
+	}
+}
+
+// Notifiable reports whether an event is pushed: every halt change, and
+// newly raised critical or high alerts.
+func Notifiable(e events.Event) bool {
+	switch e.Type {
+	case events.TypeHalt:
+		return true
+	case events.TypeAlert:
+		alert, ok := e.Data.(models.ComplianceAlert)
+		return ok && alert.Status == models.AlertStatusOpen &&
+			models.AlertSeverityRank(alert.Severity) >= models.AlertSeverityRank(models.AlertSeverityHigh)
+	}
+	return false
+}
+
+// Send delivers one event, retrying on network errors, 429 and 5xx.
+func (n *Notifier) Send(e events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	signature := Sign(n.secret, body)
+
+	delay := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(e.Type, body, signature)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.maxAttempts {
+			return fmt.Errorf("after %d attempt(s): %w", attempt, err)
+		}
+		n.sleep(delay)
+		delay *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (n *Notifier) post(eventType events.Type, body []byte, signature string) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(EventHeader, string(eventType))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver returned %d", resp.StatusCode)
+}
+
+// Sign returns the SignatureHeader value for body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
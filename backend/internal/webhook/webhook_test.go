@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+)
+
+// stubReceiver records deliveries and fails the first failures requests.
+type stubReceiver struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (s *stubReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	s.bodies = append(s.bodies, body)
+	s.headers = append(s.headers, r.Header.Clone())
+}
+
+func newTestNotifier(url string) (*Notifier, *[]time.Duration) {
+	n := NewNotifier(url, "s3cret")
+	var slept []time.Duration
+	n.sleep = func(d time.Duration) { slept = append(slept, d) }
+	return n, &slept
+}
+
+func TestSend_SignsBody(t *testing.T) {
+	stub := &stubReceiver{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	n, _ := newTestNotifier(server.URL)
+
+	halt := models.EmergencyHalt{ID: "halt-1", MarketTicker: "FED-24DEC", IsActive: true}
+	if err := n.Send(events.Event{Type: events.TypeHalt, EntityID: halt.ID, Data: halt}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(stub.bodies) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(stub.bodies))
+	}
+	body, header := stub.bodies[0], stub.headers[0]
+	want := Sign([]byte("s3cret"), body)
+	if got := header.Get(SignatureHeader); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if header.Get(EventHeader) != "halt" {
+		t.Errorf("event header = %q", header.Get(EventHeader))
+	}
+	var payload struct {
+		Type     string `json:"type"`
+		EntityID string `json:"entity_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Type != "halt" || payload.EntityID != "halt-1" {
+		t.Errorf("payload = %s", body)
+	}
+}
+
+func TestSend_RetriesWithBackoff(t *testing.T) {
+	stub := &stubReceiver{failures: 2}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	n, slept := newTestNotifier(server.URL)
+	n.SetRetry(4, 100*time.Millisecond)
+
+	if err := n.Send(events.Event{Type: events.TypeHalt}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if stub.calls != 3 {
+		t.Errorf("calls = %d, want 3", stub.calls)
+	}
+	if len(*slept) != 2 || (*slept)[0] != 100*time.Millisecond || (*slept)[1] != 200*time.Millisecond {
+		t.Errorf("backoff = %v, want [100ms 200ms]", *slept)
+	}
+}
+
+func TestSend_GivesUp(t *testing.T) {
+	stub := &stubReceiver{failures: 10}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	n, _ := newTestNotifier(server.URL)
+	n.SetRetry(3, time.Millisecond)
+
+	if err := n.Send(events.Event{Type: events.TypeHalt}); err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if stub.calls != 3 {
+		t.Errorf("calls = %d, want 3", stub.calls)
+	}
+}
+
+func TestSend_DoesNotRetryClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	n, slept := newTestNotifier(server.URL)
+
+	if err := n.Send(events.Event{Type: events.TypeHalt}); err == nil {
+		t.Fatal("expected an error for 400")
+	}
+	if len(*slept) != 0 {
+		t.Errorf("retried a 400: slept %v", *slept)
+	}
+}
+
+func TestNotifiable(t *testing.T) {
+	alert := func(severity, status string) events.Event {
+		return events.Event{Type: events.TypeAlert, Data: models.ComplianceAlert{Severity: severity, Status: status}}
+	}
+	tests := []struct {
+		name string
+		e    events.Event
+		want bool
+	}{
+		{"halt", events.Event{Type: events.TypeHalt}, true},
+		{"critical alert", alert("critical", models.AlertStatusOpen), true},
+		{"high alert", alert("high", models.AlertStatusOpen), true},
+		{"medium alert", alert("medium", models.AlertStatusOpen), false},
+		{"high alert resolved", alert("high", models.AlertStatusResolved), false},
+		{"fill", events.Event{Type: events.TypeFill}, false},
+	}
+	for _, tt := range tests {
+		if got := Notifiable(tt.e); got != tt.want {
+			t.Errorf("%s: Notifiable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRun_RetryDoesNotDelayLaterEvents(t *testing.T) {
+	delivered := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			EntityID string `json:"entity_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload.EntityID == "halt-slow" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered <- payload.EntityID
+	}))
+	defer server.Close()
+	n := NewNotifier(server.URL, "s3cret")
+	n.SetRetry(2, time.Second)
+	release := make(chan struct{})
+	n.sleep = func(time.Duration) { <-release }
+
+	ch := make(chan events.Event, 2)
+	done := make(chan struct{})
+	go func() {
+		n.Run(ch)
+		close(done)
+	}()
+	ch <- events.Event{Type: events.TypeHalt, EntityID: "halt-slow"}
+	ch <- events.Event{Type: events.TypeHalt, EntityID: "halt-fast"}
+
+	select {
+	case id := <-delivered:
+		if id != "halt-fast" {
+			t.Errorf("delivered %q, want halt-fast", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("halt-fast was held up behind halt-slow's retry")
+	}
+
+	close(ch)
+	select {
+	case <-done:
+		t.Fatal("Run returned with a delivery still retrying")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-done
+}
@@ -6,11 +6,16 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/kalshi-dcm-demo/backend/internal/auth"
+	"github.com/kalshi-dcm-demo/backend/internal/events"
 	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+	"github.com/kalshi-dcm-demo/backend/internal/models"
+	"github.com/kalshi-dcm-demo/backend/internal/response"
 )
 
 // =============================================================================
@@ -37,6 +42,8 @@ const (
 	MsgTypeUnsubscribe MessageType = "unsubscribe"
 	MsgTypeMarketData  MessageType = "market_data"
 	MsgTypeOrderbook   MessageType = "orderbook"
+	MsgTypeTrade       MessageType = "trade"
+	MsgTypeEvent       MessageType = "event"
 	MsgTypeError       MessageType = "error"
 	MsgTypePing        MessageType = "ping"
 	MsgTypePong        MessageType = "pong"
@@ -59,6 +66,10 @@ type Client struct {
 	send         chan []byte
 	subscriptions map[string]bool
 	mu           sync.RWMutex
+
+	// Set from the upgrade request's token, and fixed for the connection.
+	userID   string // empty for anonymous clients
+	operator bool   // an operator account; receives alert events
 }
 
 func NewClient(hub *Hub, conn *websocket.Conn) *Client {
@@ -158,13 +169,24 @@ func (c *Client) isSubscribed(channel string) bool {
 // HUB - Manages all WebSocket connections
 // =============================================================================
 
+// PriceObserver receives the last price of each polled market.
+// Core Principle 4: Feeds the volatility circuit breaker.
+type PriceObserver interface {
+	ObservePrices(prices map[string]int) []*models.EmergencyHalt
+}
+
 type Hub struct {
 	clients    map[*Client]bool
 	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
 	kalshi     *kalshi.Client
+	prices     PriceObserver // nil disables price observation
 	mu         sync.RWMutex
+
+	// lastTrade is the newest trade ID published per market, for markets
+	// with trades:{ticker} subscribers. Used only by the poll goroutine.
+	lastTrade map[string]string
 }
 
 func NewHub(kalshiClient *kalshi.Client) *Hub {
@@ -174,9 +196,16 @@ func NewHub(kalshiClient *kalshi.Client) *Hub {
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		kalshi:     kalshiClient,
+		lastTrade:  make(map[string]string),
 	}
 }
 
+// SetPriceObserver registers an observer for polled market prices. Call
+// before Run.
+func (h *Hub) SetPriceObserver(o PriceObserver) {
+	h.prices = o
+}
+
 func (h *Hub) Run() {
 	// Start market data polling
 	go h.pollMarketData()
@@ -218,43 +247,212 @@ func (h *Hub) pollMarketData() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		// Fetch open markets
+		markets, err := h.openMarkets()
+		if err != nil {
+			log.Printf("Market poll error: %v", err)
+			continue
+		}
+
+		if h.prices != nil {
+			prices := make(map[string]int, len(markets))
+			for _, market := range markets {
+				prices[market.Ticker] = market.LastPrice
+			}
+			for _, halt := range h.prices.ObservePrices(prices) {
+				log.Printf("Circuit breaker halted %s: %s", halt.MarketTicker, halt.Reason)
+			}
+		}
+
+		// Broadcast to subscribed clients
+		for _, market := range markets {
+			h.publish("market:"+market.Ticker, "market:*", MsgTypeMarketData, market.ToMarket())
+		}
+
+		h.pollTrades()
+	}
+}
+
+// marketsPageLimit is the page size used when listing open markets, the
+// most Kalshi returns per request.
+const marketsPageLimit = 1000
+
+// openMarkets pages through every open market, so the circuit breaker sees
+// all markets orders can be placed on.
+func (h *Hub) openMarkets() ([]kalshi.KalshiMarketResponse, error) {
+	var markets []kalshi.KalshiMarketResponse
+	cursor := ""
+	for {
 		response, err := h.kalshi.GetMarkets(kalshi.MarketParams{
 			Status: "open",
-			Limit:  50,
+			Limit:  marketsPageLimit,
+			Cursor: cursor,
 		})
 		if err != nil {
-			log.Printf("Market poll error: %v", err)
+			return nil, err
+		}
+		markets = append(markets, response.Markets...)
+		if response.Cursor == "" || response.Cursor == cursor || len(response.Markets) == 0 {
+			return markets, nil
+		}
+		cursor = response.Cursor
+	}
+}
+
+// tradesPollLimit is how many recent trades are fetched per subscribed
+// market each poll.
+const tradesPollLimit = 100
+
+// pollTrades publishes new trades, oldest first, on trades:{ticker} for
+// each market with subscribers. Kalshi is only asked about those markets;
+// the first poll after a subscription records the newest trade without
+// replaying history, which is available from the REST endpoint.
+// Core Principle 9: Real-time trade transparency.
+func (h *Hub) pollTrades() {
+	subscribed := h.subscribedMarkets("trades:")
+	for ticker := range h.lastTrade {
+		if !subscribed[ticker] {
+			delete(h.lastTrade, ticker)
+		}
+	}
+
+	for ticker := range subscribed {
+		response, err := h.kalshi.GetMarketTrades(ticker, tradesPollLimit, "")
+		if err != nil {
+			log.Printf("Trades poll error for %s: %v", ticker, err)
+			continue
+		}
+		if len(response.Trades) == 0 {
+			continue
+		}
+		last, seen := h.lastTrade[ticker]
+		h.lastTrade[ticker] = response.Trades[0].TradeID
+		if !seen {
 			continue
 		}
 
-		// Broadcast to subscribed clients
-		for _, market := range response.Markets {
-			channel := "market:" + market.Ticker
-			data, _ := json.Marshal(market.ToMarket())
+		// Trades arrive newest first; publish those after the last one sent
+		fresh := len(response.Trades)
+		for i, trade := range response.Trades {
+			if trade.TradeID == last {
+				fresh = i
+				break
+			}
+		}
+		for i := fresh - 1; i >= 0; i-- {
+			h.publish("trades:"+ticker, "", MsgTypeTrade, response.Trades[i])
+		}
+	}
+}
 
-			msg, _ := json.Marshal(WSMessage{
-				Type:    MsgTypeMarketData,
-				Channel: channel,
-				Data:    data,
-			})
+// subscribedMarkets returns the markets that some client follows on a
+// prefix+ticker channel. Wildcard subscriptions are not counted.
+func (h *Hub) subscribedMarkets(prefix string) map[string]bool {
+	markets := make(map[string]bool)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		client.mu.RLock()
+		for channel := range client.subscriptions {
+			if ticker, ok := strings.CutPrefix(channel, prefix); ok && ticker != "" && ticker != "*" {
+				markets[ticker] = true
+			}
+		}
+		client.mu.RUnlock()
+	}
+	return markets
+}
 
-			h.mu.RLock()
-			for client := range h.clients {
-				if client.isSubscribed(channel) || client.isSubscribed("market:*") {
-					select {
-					case client.send <- msg:
-					default:
-					}
-				}
+// ForwardEvents broadcasts domain events from ch on the "events:<type>"
+// channel until ch is closed. Each event only reaches the clients allowed
+// to see it; see eventAudience. Run it in its own goroutine.
+func (h *Hub) ForwardEvents(ch <-chan events.Event) {
+	for e := range ch {
+		h.publishTo(eventAudience(e), "events:"+string(e.Type), "events:*", MsgTypeEvent, e)
+	}
+}
+
+// eventAudience returns which clients may receive e, or nil for everyone.
+// Halts are market-wide; alerts are for operators; fills, settlements and
+// KYC changes only for the user they belong to.
+// Core Principle 17: Account and surveillance data stays private.
+func eventAudience(e events.Event) func(*Client) bool {
+	switch e.Type {
+	case events.TypeHalt:
+		return nil
+	case events.TypeAlert, events.TypeAlertAssigned:
+		return func(c *Client) bool { return c.operator }
+	default:
+		return func(c *Client) bool { return e.UserID != "" && c.userID == e.UserID }
+	}
+}
+
+// publish sends v to clients subscribed to channel or its wildcard, if any.
+func (h *Hub) publish(channel, wildcard string, msgType MessageType, v interface{}) {
+	h.publishTo(nil, channel, wildcard, msgType, v)
+}
+
+// publishTo is publish restricted to clients allowed by audience; a nil
+// audience allows every client.
+func (h *Hub) publishTo(audience func(*Client) bool, channel, wildcard string, msgType MessageType, v interface{}) {
+	data, _ := json.Marshal(v)
+	msg, _ := json.Marshal(WSMessage{
+		Type:    msgType,
+		Channel: channel,
+		Data:    data,
+	})
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if audience != nil && !audience(client) {
+			continue
+		}
+		if client.isSubscribed(channel) || (wildcard != "" && client.isSubscribed(wildcard)) {
+			select {
+			case client.send <- msg:
+			default:
 			}
-			h.mu.RUnlock()
 		}
 	}
 }
 
-// ServeWS handles WebSocket upgrade requests.
+// Subscribe registers a listener without a WebSocket connection, such as
+// a server-sent events stream. It receives the same WSMessage JSON a
+// WebSocket client subscribed to channels would. The returned function
+// unsubscribes and closes the channel; it is safe to call more than once.
+// Requires Run.
+func (h *Hub) Subscribe(channels ...string) (<-chan []byte, func()) {
+	client := &Client{hub: h, send: make(chan []byte, 256), subscriptions: make(map[string]bool)}
+	for _, channel := range channels {
+		client.subscriptions[channel] = true
+	}
+	h.register <- client
+
+	var once sync.Once
+	return client.send, func() {
+		once.Do(func() { h.unregister <- client })
+	}
+}
+
+// ServeWS handles WebSocket upgrade requests. A bearer token, in the
+// Authorization header or the token query parameter since browsers cannot
+// set headers on WebSocket requests, identifies the client for private
+// event channels. Without one the client only sees public channels; an
+// invalid one is refused.
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	var claims *auth.Claims
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	if token != "" {
+		var err error
+		if claims, err = auth.ValidateSession(token); err != nil {
+			response.Error(w, "Invalid or expired token", response.CodeInvalidToken)
+			return
+		}
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -262,6 +460,9 @@ func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := NewClient(h, conn)
+	if claims != nil {
+		client.userID, client.operator = claims.UserID, auth.IsAdmin(claims)
+	}
 	h.register <- client
 
 	go client.writePump()
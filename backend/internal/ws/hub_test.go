@@ -0,0 +1,192 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kalshi-dcm-demo/backend/internal/events"
+	"github.com/kalshi-dcm-demo/backend/internal/kalshi"
+)
+
+// testClient registers a connectionless client with the given subscriptions.
+func testClient(h *Hub, channels ...string) *Client {
+	c := &Client{hub: h, send: make(chan []byte, 16), subscriptions: make(map[string]bool)}
+	for _, ch := range channels {
+		c.subscriptions[ch] = true
+	}
+	h.clients[c] = true
+	return c
+}
+
+func receivedTrades(t *testing.T, c *Client) []string {
+	t.Helper()
+	var ids []string
+	for {
+		select {
+		case raw := <-c.send:
+			var msg WSMessage
+			json.Unmarshal(raw, &msg)
+			var trade kalshi.Trade
+			json.Unmarshal(msg.Data, &trade)
+			if msg.Type != MsgTypeTrade || msg.Channel != "trades:"+trade.Ticker {
+				t.Errorf("message %s on %s, want a trade on trades:%s", msg.Type, msg.Channel, trade.Ticker)
+			}
+			ids = append(ids, trade.TradeID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestPollTrades_FansOutNewTradesToSubscribersOnly(t *testing.T) {
+	trades := `{"trades": [{"trade_id": "t2", "ticker": "FED-24DEC"}, {"trade_id": "t1", "ticker": "FED-24DEC"}]}`
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if got := r.URL.Query().Get("ticker"); got != "FED-24DEC" {
+			t.Errorf("trades requested for %q, want only the subscribed market", got)
+		}
+		w.Write([]byte(trades))
+	}))
+	defer server.Close()
+	h := NewHub(kalshi.NewClient(server.URL, time.Second))
+
+	idle := testClient(h, "market:FED-24DEC", "trades:*")
+	h.pollTrades()
+	if requests.Load() != 0 {
+		t.Fatalf("polled Kalshi %d times without trade subscribers", requests.Load())
+	}
+
+	subscriber := testClient(h, "trades:FED-24DEC")
+	h.pollTrades() // records t2 without replaying history
+	if got := receivedTrades(t, subscriber); len(got) != 0 {
+		t.Errorf("first poll replayed %v", got)
+	}
+
+	trades = `{"trades": [{"trade_id": "t4", "ticker": "FED-24DEC"}, {"trade_id": "t3", "ticker": "FED-24DEC"},
+		{"trade_id": "t2", "ticker": "FED-24DEC"}]}`
+	h.pollTrades()
+	if got := receivedTrades(t, subscriber); len(got) != 2 || got[0] != "t3" || got[1] != "t4" {
+		t.Errorf("subscriber got %v, want t3 then t4", got)
+	}
+	if got := receivedTrades(t, idle); len(got) != 0 {
+		t.Errorf("non-subscriber got %v", got)
+	}
+
+	h.pollTrades()
+	if got := receivedTrades(t, subscriber); len(got) != 0 {
+		t.Errorf("repeat poll resent %v", got)
+	}
+
+	delete(h.clients, subscriber)
+	before := requests.Load()
+	h.pollTrades()
+	if requests.Load() != before || len(h.lastTrade) != 0 {
+		t.Errorf("still polling after the last subscriber left")
+	}
+}
+
+func TestOpenMarkets_PagesThroughEveryMarket(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"markets": [{"ticker": "FED-24DEC"}], "cursor": "p2"}`,
+		"p2": `{"markets": [{"ticker": "CPI-24DEC"}], "cursor": ""}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("status"); got != "open" {
+			t.Errorf("status = %q, want open", got)
+		}
+		w.Write([]byte(pages[r.URL.Query().Get("cursor")]))
+	}))
+	defer server.Close()
+	h := NewHub(kalshi.NewClient(server.URL, time.Second))
+
+	markets, err := h.openMarkets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 2 || markets[0].Ticker != "FED-24DEC" || markets[1].Ticker != "CPI-24DEC" {
+		t.Errorf("markets = %+v, want both pages", markets)
+	}
+}
+
+func TestSubscribe_ReceivesMessagesUntilUnsubscribed(t *testing.T) {
+	h := NewHub(kalshi.NewClient("http://127.0.0.1:0", time.Second))
+	go h.Run()
+
+	messages, unsubscribe := h.Subscribe("market:FED-24DEC")
+	h.publish("market:CPI-24NOV", "market:*", MsgTypeMarketData, map[string]string{"ticker": "CPI-24NOV"})
+	h.publish("market:FED-24DEC", "market:*", MsgTypeMarketData, map[string]string{"ticker": "FED-24DEC"})
+
+	var msg WSMessage
+	json.Unmarshal(<-messages, &msg)
+	if msg.Type != MsgTypeMarketData || msg.Channel != "market:FED-24DEC" {
+		t.Errorf("got %s on %s, want market_data on market:FED-24DEC", msg.Type, msg.Channel)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-messages; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+}
+
+func receivedEvents(c *Client) []events.Type {
+	var types []events.Type
+	for {
+		select {
+		case raw := <-c.send:
+			var msg WSMessage
+			json.Unmarshal(raw, &msg)
+			var e events.Event
+			json.Unmarshal(msg.Data, &e)
+			types = append(types, e.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestForwardEvents_ReachOnlyTheirAudience(t *testing.T) {
+	h := NewHub(kalshi.NewClient("http://127.0.0.1:0", time.Second))
+	anonymous := testClient(h, "events:*")
+	alice := testClient(h, "events:*")
+	alice.userID = "alice"
+	operator := testClient(h, "events:*")
+	operator.userID, operator.operator = "ops", true
+
+	ch := make(chan events.Event, 4)
+	ch <- events.Event{Type: events.TypeHalt, EntityID: "halt_1"}
+	ch <- events.Event{Type: events.TypeFill, EntityID: "ord_1", UserID: "alice"}
+	ch <- events.Event{Type: events.TypeFill, EntityID: "ord_2", UserID: "bob"}
+	ch <- events.Event{Type: events.TypeAlertAssigned, EntityID: "alert_1", UserID: "alice"}
+	close(ch)
+	h.ForwardEvents(ch)
+
+	for _, tc := range []struct {
+		name   string
+		client *Client
+		want   []events.Type
+	}{
+		{"anonymous", anonymous, []events.Type{events.TypeHalt}},
+		{"owner", alice, []events.Type{events.TypeHalt, events.TypeFill}},
+		{"operator", operator, []events.Type{events.TypeHalt, events.TypeAlertAssigned}},
+	} {
+		if got := receivedEvents(tc.client); !slices.Equal(got, tc.want) {
+			t.Errorf("%s received %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestServeWS_RejectsInvalidToken(t *testing.T) {
+	h := NewHub(kalshi.NewClient("http://127.0.0.1:0", time.Second))
+	rec := httptest.NewRecorder()
+	h.ServeWS(rec, httptest.NewRequest("GET", "/ws?token=not-a-jwt", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+}
@@ -55,7 +55,7 @@ export interface KYCRecord {
   user_id: string;
   status: string;
   document_type: string;
-  submitted_at: string;
+  submitted_at?: string;
   reviewed_at?: string;
   rejection_reason?: string;
 }
@@ -188,7 +188,7 @@ export const authAPI = {
 
 export const kycAPI = {
   getStatus: async () => {
-    const response = await api.get<{ data: KYCRecord | { status: string } }>('/kyc');
+    const response = await api.get<{ data: KYCRecord }>('/kyc');
     return response.data.data;
   },
 
@@ -9,6 +9,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,9 +24,22 @@ import (
 // =============================================================================
 
 type Config struct {
-	Port            string
-	BackendAPIURL   string // Main DCM demo API
-	RefreshInterval time.Duration
+	Port               string
+	BackendAPIURL      string        // Main DCM demo API
+	RefreshInterval    time.Duration // Backend poll interval while it is healthy
+	MaxRefreshInterval time.Duration // Cap on the backed-off poll interval
+	AllowedOrigins     []string      // Operator dashboard origins allowed by CORS
+}
+
+const (
+	defaultRefreshInterval    = 5 * time.Second
+	defaultMaxRefreshInterval = time.Minute
+)
+
+// defaultAllowedOrigins are the dashboard's own dev origins.
+var defaultAllowedOrigins = []string{
+	"http://localhost:3001",
+	"http://127.0.0.1:3001",
 }
 
 func loadConfig() *Config {
@@ -38,13 +53,66 @@ func loadConfig() *Config {
 		backendURL = "http://localhost:8080/api/v1"
 	}
 
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = defaultAllowedOrigins
+	}
+
+	refresh := durationEnv("REFRESH_INTERVAL", defaultRefreshInterval)
+	maxRefresh := durationEnv("MAX_REFRESH_INTERVAL", defaultMaxRefreshInterval)
+	if maxRefresh < refresh {
+		maxRefresh = refresh
+	}
+
 	return &Config{
-		Port:            port,
-		BackendAPIURL:   backendURL,
-		RefreshInterval: 5 * time.Second,
+		Port:               port,
+		BackendAPIURL:      backendURL,
+		RefreshInterval:    refresh,
+		MaxRefreshInterval: maxRefresh,
+		AllowedOrigins:     origins,
 	}
 }
 
+// durationEnv parses key as a duration such as "10s", falling back to def
+// when it is unset, malformed or not positive.
+func durationEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Ignoring %s=%q: want a positive duration", key, value)
+		return def
+	}
+	return d
+}
+
+// newCORS allows credentials only for the explicitly listed origins; a
+// wildcard is dropped since browsers reject it alongside credentials.
+func newCORS(origins []string) *cors.Cors {
+	var allowed []string
+	for _, origin := range origins {
+		if origin == "*" {
+			log.Println("⚠️  Ignoring wildcard in ALLOWED_ORIGINS")
+			continue
+		}
+		allowed = append(allowed, origin)
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins:   allowed,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Requested-With"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	})
+}
+
 // =============================================================================
 // DATA MODELS
 // =============================================================================
@@ -62,6 +130,62 @@ type Alert struct {
 	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
 	ResolvedBy   string    `json:"resolved_by,omitempty"`
 	Notes        string    `json:"notes,omitempty"`
+	AssignedTo   string     `json:"assigned_to,omitempty"` // Operator who owns the alert
+	AssignedAt   *time.Time `json:"assigned_at,omitempty"`
+}
+
+// alertActive reports whether an alert still needs an owner: open or under
+// investigation. Matches the backend's models.AlertActive.
+func alertActive(status string) bool {
+	return status == "open" || status == "investigating"
+}
+
+// alertTransitions lists the statuses each alert status may move to, as in
+// the backend's review workflow: open -> investigating -> resolved/escalated.
+var alertTransitions = map[string][]string{
+	"open":          {"investigating"},
+	"investigating": {"resolved", "escalated"},
+}
+
+// canTransitionAlert reports whether an alert may move from one status to
+// another.
+func canTransitionAlert(from, to string) bool {
+	for _, next := range alertTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// severityRank orders alert severities for triage: higher is more severe.
+// Unknown severities rank below low. Matches the backend's ranking.
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
+
+// sortAlerts orders alerts newest first, or with bySeverity most severe
+// first and newest first within a severity.
+func sortAlerts(alerts []Alert, bySeverity bool) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if bySeverity {
+			ri, rj := severityRank(alerts[i].Severity), severityRank(alerts[j].Severity)
+			if ri != rj {
+				return ri > rj
+			}
+		}
+		return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+	})
 }
 
 // User represents user summary for surveillance
@@ -98,6 +222,15 @@ type DashboardStats struct {
 	HaltedMarkets     int       `json:"halted_markets"`
 	SystemStatus      string    `json:"system_status"`
 	LastUpdated       time.Time `json:"last_updated"`
+	Polling           PollingState `json:"polling"`
+}
+
+// PollingState describes how the dashboard is polling the backend.
+type PollingState struct {
+	BackendStatus       string     `json:"backend_status"` // up, down or unknown before the first poll
+	IntervalSeconds     float64    `json:"interval_seconds"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastSuccessfulSync  *time.Time `json:"last_successful_sync"`
 }
 
 // =============================================================================
@@ -281,6 +414,138 @@ func timePtr(t time.Time) *time.Time {
 	return &t
 }
 
+// =============================================================================
+// BACKEND CONNECTIVITY
+// Operator controls act on the main DCM API; if it is unreachable the
+// dashboard is a disconnected control plane and must say so.
+// =============================================================================
+
+// backendPingTimeout bounds one backend health request.
+const backendPingTimeout = 3 * time.Second
+
+// BackendMonitor records whether the main DCM API answers its health check
+// and backs off polling while it does not, so a struggling backend is not
+// hammered.
+type BackendMonitor struct {
+	healthURL   string
+	client      *http.Client
+	interval    time.Duration // poll interval while the backend is healthy
+	maxInterval time.Duration // cap on the backed-off interval
+
+	mu        sync.RWMutex
+	checked   bool
+	lastSync  time.Time // last successful health check
+	lastError string    // error from the latest check, empty if it succeeded
+	failures  int       // consecutive failed checks
+}
+
+func NewBackendMonitor(backendURL string, interval, maxInterval time.Duration) *BackendMonitor {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	return &BackendMonitor{
+		healthURL:   strings.TrimSuffix(backendURL, "/") + "/health",
+		client:      &http.Client{Timeout: backendPingTimeout},
+		interval:    interval,
+		maxInterval: maxInterval,
+	}
+}
+
+// Ping checks the backend's /health endpoint and records the outcome.
+func (m *BackendMonitor) Ping() error {
+	err := m.ping()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checked = true
+	if err != nil {
+		m.lastError = err.Error()
+		m.failures++
+		return err
+	}
+	m.lastSync = time.Now().UTC()
+	m.lastError = ""
+	m.failures = 0
+	return nil
+}
+
+// Check pings the backend for a readiness probe. A success counts as a
+// sync, but neither outcome changes the poll backoff, which only Ping does.
+func (m *BackendMonitor) Check() error {
+	err := m.ping()
+	if err == nil {
+		m.mu.Lock()
+		m.lastSync = time.Now().UTC()
+		m.mu.Unlock()
+	}
+	return err
+}
+
+func (m *BackendMonitor) ping() error {
+	resp, err := m.client.Get(m.healthURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("backend health returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Status returns the last successful check time, zero if none, and the
+// latest error.
+func (m *BackendMonitor) Status() (lastSync time.Time, lastError string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSync, m.lastError
+}
+
+// Interval returns how long to wait before the next poll: the configured
+// interval, doubled for each consecutive failure up to the maximum.
+func (m *BackendMonitor) Interval() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.nextInterval()
+}
+
+func (m *BackendMonitor) nextInterval() time.Duration {
+	interval := m.interval
+	for i := 0; i < m.failures && interval < m.maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > m.maxInterval {
+		interval = m.maxInterval
+	}
+	return interval
+}
+
+// Polling reports the current polling state for the dashboard stats.
+func (m *BackendMonitor) Polling() PollingState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state := PollingState{
+		BackendStatus:       "unknown",
+		IntervalSeconds:     m.nextInterval().Seconds(),
+		ConsecutiveFailures: m.failures,
+	}
+	if m.checked {
+		state.BackendStatus = "up"
+		if m.lastError != "" {
+			state.BackendStatus = "down"
+		}
+	}
+	if !m.lastSync.IsZero() {
+		lastSync := m.lastSync
+		state.LastSuccessfulSync = &lastSync
+	}
+	return state
+}
+
 // =============================================================================
 // WEBSOCKET HUB
 // =============================================================================
@@ -352,35 +617,49 @@ func (h *Hub) Broadcast(msgType string, data interface{}) {
 // =============================================================================
 
 type Handler struct {
-	store  *Store
-	hub    *Hub
-	config *Config
+	store   *Store
+	hub     *Hub
+	config  *Config
+	backend *BackendMonitor
 }
 
 func NewHandler(store *Store, hub *Hub, config *Config) *Handler {
 	return &Handler{
-		store:  store,
-		hub:    hub,
-		config: config,
+		store:   store,
+		hub:     hub,
+		config:  config,
+		backend: NewBackendMonitor(config.BackendAPIURL, config.RefreshInterval, config.MaxRefreshInterval),
 	}
 }
 
+// snapshotStats recomputes the dashboard stats, including backend polling.
+func (h *Handler) snapshotStats() DashboardStats {
+	h.store.mu.Lock()
+	h.store.updateStats()
+	stats := h.store.stats
+	h.store.mu.Unlock()
+
+	stats.Polling = h.backend.Polling()
+	return stats
+}
+
 // Dashboard Stats
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
-	h.store.mu.RLock()
-	defer h.store.mu.RUnlock()
-
-	h.store.updateStats()
-	respondJSON(w, http.StatusOK, h.store.stats)
+	respondJSON(w, http.StatusOK, h.snapshotStats())
 }
 
 // Alerts
 func (h *Handler) GetAlerts(w http.ResponseWriter, r *http.Request) {
-	h.store.mu.RLock()
-	defer h.store.mu.RUnlock()
-
 	status := r.URL.Query().Get("status")
 	severity := r.URL.Query().Get("severity")
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy != "" && sortBy != "time" && sortBy != "severity" {
+		respondError(w, http.StatusBadRequest, "sort must be time or severity")
+		return
+	}
+
+	h.store.mu.RLock()
+	defer h.store.mu.RUnlock()
 
 	var filtered []Alert
 	for _, a := range h.store.alerts {
@@ -392,6 +671,7 @@ func (h *Handler) GetAlerts(w http.ResponseWriter, r *http.Request) {
 		}
 		filtered = append(filtered, a)
 	}
+	sortAlerts(filtered, sortBy == "severity")
 
 	respondJSON(w, http.StatusOK, filtered)
 }
@@ -401,31 +681,102 @@ type ResolveAlertRequest struct {
 	ResolvedBy string `json:"resolved_by"`
 }
 
+// ResolveAlert closes an alert under investigation as resolved.
 func (h *Handler) ResolveAlert(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	alertID := vars["id"]
-
 	var req ResolveAlertRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request")
 		return
 	}
+	h.transitionAlert(w, mux.Vars(r)["id"], "resolved", req.ResolvedBy, req.Notes)
+}
+
+type UpdateAlertStatusRequest struct {
+	Status string `json:"status"`
+	By     string `json:"by"`
+	Notes  string `json:"notes"`
+}
+
+// UpdateAlertStatus moves an alert through the review workflow.
+func (h *Handler) UpdateAlertStatus(w http.ResponseWriter, r *http.Request) {
+	var req UpdateAlertStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Status == "" {
+		respondError(w, http.StatusBadRequest, "status is required")
+		return
+	}
+	h.transitionAlert(w, mux.Vars(r)["id"], req.Status, req.By, req.Notes)
+}
 
+// transitionAlert moves an alert to status if alertTransitions allows it
+// and tells every dashboard. Resolved and escalated alerts record who
+// closed them.
+func (h *Handler) transitionAlert(w http.ResponseWriter, alertID, status, by, notes string) {
 	h.store.mu.Lock()
 	defer h.store.mu.Unlock()
 
 	for i := range h.store.alerts {
-		if h.store.alerts[i].ID == alertID {
+		alert := &h.store.alerts[i]
+		if alert.ID != alertID {
+			continue
+		}
+		if !canTransitionAlert(alert.Status, status) {
+			respondError(w, http.StatusConflict, fmt.Sprintf("Alert cannot move from %s to %s", alert.Status, status))
+			return
+		}
+		alert.Status = status
+		if notes != "" {
+			alert.Notes = notes
+		}
+		msgType := "alert_updated"
+		if status == "resolved" || status == "escalated" {
 			now := time.Now().UTC()
-			h.store.alerts[i].Status = "resolved"
-			h.store.alerts[i].ResolvedAt = &now
-			h.store.alerts[i].ResolvedBy = req.ResolvedBy
-			h.store.alerts[i].Notes = req.Notes
+			alert.ResolvedAt = &now
+			alert.ResolvedBy = by
+			msgType = "alert_" + status
+		}
+
+		h.hub.Broadcast(msgType, *alert)
+		respondJSON(w, http.StatusOK, *alert)
+		return
+	}
+
+	respondError(w, http.StatusNotFound, "Alert not found")
+}
 
-			h.hub.Broadcast("alert_resolved", h.store.alerts[i])
-			respondJSON(w, http.StatusOK, h.store.alerts[i])
+type AssignAlertRequest struct {
+	Operator string `json:"operator"`
+}
+
+// AssignAlert makes an operator the alert's owner and tells every dashboard,
+// so two officers do not review the same alert.
+func (h *Handler) AssignAlert(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	var req AssignAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Operator) == "" {
+		respondError(w, http.StatusBadRequest, "operator is required")
+		return
+	}
+
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	for i := range h.store.alerts {
+		alert := &h.store.alerts[i]
+		if alert.ID != alertID {
+			continue
+		}
+		if !alertActive(alert.Status) {
+			respondError(w, http.StatusConflict, "Only open or investigating alerts can be assigned")
 			return
 		}
+		now := time.Now().UTC()
+		alert.AssignedTo = strings.TrimSpace(req.Operator)
+		alert.AssignedAt = &now
+
+		h.hub.Broadcast("alert_assigned", *alert)
+		respondJSON(w, http.StatusOK, *alert)
+		return
 	}
 
 	respondError(w, http.StatusNotFound, "Alert not found")
@@ -623,6 +974,37 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ReadyCheck pings the backend and reports degraded with 503 when it is
+// unreachable, along with when it last answered. It leaves the poll
+// backoff alone.
+func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
+	checkErr := h.backend.Check()
+	lastSync, _ := h.backend.Status()
+
+	var lastSyncAt *time.Time
+	if !lastSync.IsZero() {
+		lastSyncAt = &lastSync
+	}
+	backend := map[string]interface{}{
+		"url":                  h.config.BackendAPIURL,
+		"status":               "up",
+		"last_successful_sync": lastSyncAt,
+	}
+
+	status, code := "ready", http.StatusOK
+	if checkErr != nil {
+		backend["status"] = "down"
+		backend["error"] = checkErr.Error()
+		status, code = "degraded", http.StatusServiceUnavailable
+	}
+	respondJSON(w, code, map[string]interface{}{
+		"status":    status,
+		"service":   "surveillance-dashboard",
+		"backend":   backend,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
 // =============================================================================
 // RESPONSE HELPERS
 // =============================================================================
@@ -650,15 +1032,23 @@ func main() {
 	// Start WebSocket hub
 	go hub.Run()
 
-	// Start periodic stats broadcast
+	// Poll the backend, backing off while it is failing
+	go func() {
+		for {
+			time.Sleep(handler.backend.Interval())
+			if err := handler.backend.Ping(); err != nil {
+				log.Printf("Backend unreachable, next poll in %s: %v", handler.backend.Interval(), err)
+			}
+		}
+	}()
+
+	// Broadcast stats on the configured interval, even while the backend
+	// poll is backed off
 	go func() {
 		ticker := time.NewTicker(config.RefreshInterval)
+		defer ticker.Stop()
 		for range ticker.C {
-			store.mu.Lock()
-			store.updateStats()
-			stats := store.stats
-			store.mu.Unlock()
-			hub.Broadcast("stats_update", stats)
+			hub.Broadcast("stats_update", handler.snapshotStats())
 		}
 	}()
 
@@ -668,6 +1058,7 @@ func main() {
 
 	// Health
 	api.HandleFunc("/health", handler.HealthCheck).Methods("GET")
+	api.HandleFunc("/health/ready", handler.ReadyCheck).Methods("GET")
 
 	// Dashboard
 	api.HandleFunc("/stats", handler.GetStats).Methods("GET")
@@ -675,6 +1066,8 @@ func main() {
 	// Alerts
 	api.HandleFunc("/alerts", handler.GetAlerts).Methods("GET")
 	api.HandleFunc("/alerts/{id}/resolve", handler.ResolveAlert).Methods("POST")
+	api.HandleFunc("/alerts/{id}/status", handler.UpdateAlertStatus).Methods("POST")
+	api.HandleFunc("/alerts/{id}/assign", handler.AssignAlert).Methods("POST")
 
 	// Users
 	api.HandleFunc("/users", handler.GetUsers).Methods("GET")
@@ -703,12 +1096,7 @@ func main() {
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir(staticDir)))
 
 	// CORS
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
-	})
+	c := newCORS(config.AllowedOrigins)
 
 	addr := fmt.Sprintf(":%s", config.Port)
 	log.Printf("🔍 Surveillance Dashboard starting on http://localhost%s", addr)
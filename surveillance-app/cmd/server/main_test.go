@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCORS_OnlyAllowedOrigins(t *testing.T) {
+	handler := newCORS([]string{"*", "http://localhost:3001"}).Handler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"http://localhost:3001", "http://localhost:3001", "true"},
+		{"http://evil.example.com", "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		req.Header.Set("Origin", tt.origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.allowOrigin)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+			t.Errorf("%s: Access-Control-Allow-Credentials = %q, want %q", tt.origin, got, tt.credentials)
+		}
+	}
+}
+
+func TestLoadConfig_AllowedOrigins(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "")
+	if got := loadConfig().AllowedOrigins; len(got) != len(defaultAllowedOrigins) {
+		t.Errorf("default origins = %v", got)
+	}
+
+	t.Setenv("ALLOWED_ORIGINS", " https://ops.example.com , ,https://ops2.example.com")
+	got := loadConfig().AllowedOrigins
+	if len(got) != 2 || got[0] != "https://ops.example.com" || got[1] != "https://ops2.example.com" {
+		t.Errorf("origins = %v", got)
+	}
+}
+
+func TestReadyCheck_ReportsBackendConnectivity(t *testing.T) {
+	var up atomic.Bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/health" || !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer backend.Close()
+	h := NewHandler(NewStore(), NewHub(), &Config{BackendAPIURL: backend.URL + "/api/v1"})
+
+	type readiness struct {
+		Status  string `json:"status"`
+		Backend struct {
+			Status             string     `json:"status"`
+			LastSuccessfulSync *time.Time `json:"last_successful_sync"`
+		} `json:"backend"`
+	}
+	check := func() (int, readiness) {
+		rec := httptest.NewRecorder()
+		h.ReadyCheck(rec, httptest.NewRequest(http.MethodGet, "/api/health/ready", nil))
+		var body readiness
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding readiness: %v", err)
+		}
+		return rec.Code, body
+	}
+
+	code, body := check()
+	if code != http.StatusServiceUnavailable || body.Status != "degraded" || body.Backend.LastSuccessfulSync != nil {
+		t.Errorf("never reached: %d %+v, want 503 degraded with no sync", code, body)
+	}
+
+	up.Store(true)
+	code, body = check()
+	if code != http.StatusOK || body.Status != "ready" || body.Backend.Status != "up" || body.Backend.LastSuccessfulSync == nil {
+		t.Fatalf("backend up: %d %+v, want 200 ready with a sync time", code, body)
+	}
+	synced := *body.Backend.LastSuccessfulSync
+
+	up.Store(false)
+	code, body = check()
+	if code != http.StatusServiceUnavailable || body.Status != "degraded" || body.Backend.Status != "down" {
+		t.Errorf("backend down: %d %+v, want 503 degraded", code, body)
+	}
+	if body.Backend.LastSuccessfulSync == nil || !body.Backend.LastSuccessfulSync.Equal(synced) {
+		t.Errorf("last sync = %v, want %v kept from the successful check", body.Backend.LastSuccessfulSync, synced)
+	}
+}
+
+func TestBackendMonitor_BacksOffOnFailures(t *testing.T) {
+	var up atomic.Bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer backend.Close()
+	m := NewBackendMonitor(backend.URL, time.Second, 5*time.Second)
+
+	if got := m.Polling(); got.BackendStatus != "unknown" || m.Interval() != time.Second {
+		t.Errorf("before polling: %+v, interval %s", got, m.Interval())
+	}
+
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if err := m.Ping(); err == nil {
+			t.Fatal("Ping succeeded against a failing backend")
+		}
+		if got := m.Interval(); got != want {
+			t.Errorf("after %d failures interval = %s, want %s", m.Polling().ConsecutiveFailures, got, want)
+		}
+	}
+	if got := m.Polling(); got.BackendStatus != "down" || got.ConsecutiveFailures != 4 || got.IntervalSeconds != 5 {
+		t.Errorf("polling while down = %+v", got)
+	}
+
+	// Readiness checks leave the backoff to the poll loop
+	up.Store(true)
+	if err := m.Check(); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := m.Polling(); got.ConsecutiveFailures != 4 || m.Interval() != 5*time.Second {
+		t.Errorf("after Check: %+v, interval %s; want backoff unchanged", got, m.Interval())
+	}
+
+	up.Store(true)
+	if err := m.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if got := m.Polling(); got.BackendStatus != "up" || got.ConsecutiveFailures != 0 || m.Interval() != time.Second || got.LastSuccessfulSync == nil {
+		t.Errorf("polling after recovery = %+v, interval %s; want reset to 1s", got, m.Interval())
+	}
+}
+
+func TestLoadConfig_RefreshIntervals(t *testing.T) {
+	t.Setenv("REFRESH_INTERVAL", "")
+	t.Setenv("MAX_REFRESH_INTERVAL", "")
+	if c := loadConfig(); c.RefreshInterval != defaultRefreshInterval || c.MaxRefreshInterval != defaultMaxRefreshInterval {
+		t.Errorf("defaults = %s, %s", c.RefreshInterval, c.MaxRefreshInterval)
+	}
+
+	t.Setenv("REFRESH_INTERVAL", "2m")
+	t.Setenv("MAX_REFRESH_INTERVAL", "-1s")
+	if c := loadConfig(); c.RefreshInterval != 2*time.Minute || c.MaxRefreshInterval != 2*time.Minute {
+		t.Errorf("got %s, %s; want the maximum raised to the interval", c.RefreshInterval, c.MaxRefreshInterval)
+	}
+}
+
+func TestGetAlerts_SeveritySort(t *testing.T) {
+	store := NewStore()
+	now := time.Now().UTC()
+	store.alerts = []Alert{
+		{ID: "low-new", Severity: "low", Status: "open", CreatedAt: now},
+		{ID: "critical-old", Severity: "critical", Status: "open", CreatedAt: now.Add(-time.Hour)},
+		{ID: "medium", Severity: "medium", Status: "open", CreatedAt: now.Add(-time.Minute)},
+	}
+	h := NewHandler(store, NewHub(), &Config{})
+
+	list := func(query string) (int, []string) {
+		rec := httptest.NewRecorder()
+		h.GetAlerts(rec, httptest.NewRequest(http.MethodGet, "/api/alerts"+query, nil))
+		var alerts []Alert
+		json.NewDecoder(rec.Body).Decode(&alerts)
+		var ids []string
+		for _, a := range alerts {
+			ids = append(ids, a.ID)
+		}
+		return rec.Code, ids
+	}
+
+	if _, ids := list(""); strings.Join(ids, ",") != "low-new,medium,critical-old" {
+		t.Errorf("default order = %v, want newest first", ids)
+	}
+	if _, ids := list("?sort=severity"); strings.Join(ids, ",") != "critical-old,medium,low-new" {
+		t.Errorf("severity order = %v, want critical first", ids)
+	}
+	if code, _ := list("?sort=loudest"); code != http.StatusBadRequest {
+		t.Errorf("unknown sort: status = %d, want 400", code)
+	}
+}
+
+func TestAssignAlert_ClaimReassignAndBroadcast(t *testing.T) {
+	store := NewStore()
+	store.alerts = []Alert{
+		{ID: "ALT-1", Severity: "high", Status: "open"},
+		{ID: "ALT-2", Severity: "low", Status: "resolved"},
+		{ID: "ALT-3", Severity: "medium", Status: "investigating"},
+	}
+	hub := NewHub()
+	h := NewHandler(store, hub, &Config{})
+
+	assign := func(id, body string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/alerts/"+id+"/assign", strings.NewReader(body)), map[string]string{"id": id})
+		rec := httptest.NewRecorder()
+		h.AssignAlert(rec, req)
+		return rec
+	}
+
+	for _, operator := range []string{"officer.a", "officer.b"} {
+		if rec := assign("ALT-1", `{"operator": "`+operator+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("%s claim: status = %d: %s", operator, rec.Code, rec.Body.String())
+		}
+		msg := (<-hub.broadcast).(map[string]interface{})
+		if msg["type"] != "alert_assigned" || msg["data"].(Alert).AssignedTo != operator {
+			t.Errorf("broadcast = %+v, want alert_assigned to %s", msg, operator)
+		}
+	}
+	if store.alerts[0].AssignedTo != "officer.b" || store.alerts[0].AssignedAt == nil {
+		t.Errorf("alert = %+v, want owned by officer.b", store.alerts[0])
+	}
+
+	if rec := assign("ALT-1", `{"operator": " "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("blank operator: status = %d, want 400", rec.Code)
+	}
+	if rec := assign("ALT-3", `{"operator": "officer.a"}`); rec.Code != http.StatusOK {
+		t.Errorf("investigating alert: status = %d, want 200", rec.Code)
+	}
+	<-hub.broadcast
+	if rec := assign("ALT-2", `{"operator": "officer.a"}`); rec.Code != http.StatusConflict {
+		t.Errorf("resolved alert: status = %d, want 409", rec.Code)
+	}
+	if rec := assign("ALT-9", `{"operator": "officer.a"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("missing alert: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestAlertWorkflow_ResolveOnlyAfterInvestigation(t *testing.T) {
+	store := NewStore()
+	store.alerts = []Alert{{ID: "ALT-1", Severity: "high", Status: "open"}}
+	hub := NewHub()
+	h := NewHandler(store, hub, &Config{})
+
+	post := func(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/alerts/ALT-1/"+path, strings.NewReader(body)), map[string]string{"id": "ALT-1"})
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	resolve := `{"notes": "false positive", "resolved_by": "officer.a"}`
+
+	if rec := post(h.ResolveAlert, "resolve", resolve); rec.Code != http.StatusConflict {
+		t.Errorf("resolving an open alert: status = %d, want 409", rec.Code)
+	}
+	if rec := post(h.UpdateAlertStatus, "status", `{"status": "investigating", "by": "officer.a"}`); rec.Code != http.StatusOK {
+		t.Fatalf("investigate: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if msg := (<-hub.broadcast).(map[string]interface{}); msg["type"] != "alert_updated" {
+		t.Errorf("broadcast = %+v, want alert_updated", msg)
+	}
+	if rec := post(h.ResolveAlert, "resolve", resolve); rec.Code != http.StatusOK {
+		t.Fatalf("resolve: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	msg := (<-hub.broadcast).(map[string]interface{})
+	if alert := msg["data"].(Alert); msg["type"] != "alert_resolved" || alert.ResolvedBy != "officer.a" || alert.ResolvedAt == nil {
+		t.Errorf("broadcast = %+v, want alert_resolved by officer.a", msg)
+	}
+
+	// Closed alerts stay closed
+	if rec := post(h.ResolveAlert, "resolve", resolve); rec.Code != http.StatusConflict {
+		t.Errorf("resolving a resolved alert: status = %d, want 409", rec.Code)
+	}
+	if rec := post(h.UpdateAlertStatus, "status", `{"status": "escalated", "by": "officer.b"}`); rec.Code != http.StatusConflict {
+		t.Errorf("escalating a resolved alert: status = %d, want 409", rec.Code)
+	}
+	if store.alerts[0].Status != "resolved" || store.alerts[0].ResolvedBy != "officer.a" {
+		t.Errorf("alert = %+v, want resolved by officer.a", store.alerts[0])
+	}
+}
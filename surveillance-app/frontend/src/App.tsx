@@ -19,6 +19,7 @@ import type {
   WSMessage,
   InitialStateData,
 } from './types';
+import { isActiveAlert } from './types';
 
 const OPERATOR_EMAIL = 'operator@dcm.com';
 
@@ -56,12 +57,12 @@ function App() {
     try {
       const [statsData, alertsData, marketsData, usersData] = await Promise.all([
         api.fetchStats(),
-        api.fetchAlerts('open'),
+        api.fetchAlerts(),
         api.fetchMarkets(),
         api.fetchUsers(),
       ]);
       setStats(statsData);
-      setAlerts(alertsData);
+      setAlerts(alertsData.filter(isActiveAlert));
       setMarkets(marketsData);
       setUsers(usersData);
       setIsLoading(false);
@@ -89,7 +90,7 @@ function App() {
       case 'initial_state': {
         const state = data as InitialStateData;
         setStats(state.stats);
-        setAlerts(state.alerts.filter((a) => a.status === 'open'));
+        setAlerts(state.alerts.filter(isActiveAlert));
         setMarkets(state.markets);
         addActivity('WebSocket connected', 'info');
         break;
@@ -97,10 +98,23 @@ function App() {
       case 'stats_update':
         setStats(data as Stats);
         break;
-      case 'alert_resolved': {
-        const resolved = data as { id: string };
-        setAlerts((prev) => prev.filter((a) => a.id !== resolved.id));
-        addActivity(`Alert ${resolved.id} resolved`, 'success');
+      case 'alert_updated': {
+        const updated = data as Alert;
+        setAlerts((prev) => prev.map((a) => (a.id === updated.id ? updated : a)));
+        addActivity(`Alert ${updated.id} under investigation`, 'info');
+        break;
+      }
+      case 'alert_resolved':
+      case 'alert_escalated': {
+        const closed = data as Alert;
+        setAlerts((prev) => prev.filter((a) => a.id !== closed.id));
+        addActivity(`Alert ${closed.id} ${closed.status}`, 'success');
+        break;
+      }
+      case 'alert_assigned': {
+        const assigned = data as Alert;
+        setAlerts((prev) => prev.map((a) => (a.id === assigned.id ? assigned : a)));
+        addActivity(`Alert ${assigned.id} assigned to ${assigned.assigned_to}`, 'info');
         break;
       }
       case 'new_alert': {
@@ -141,6 +155,16 @@ function App() {
   }, [lastMessage, fetchData, addActivity]);
 
   // Handlers
+  const handleInvestigateAlert = async (id: string) => {
+    try {
+      const updated = await api.updateAlertStatus(id, 'investigating', OPERATOR_EMAIL);
+      setAlerts((prev) => prev.map((a) => (a.id === id ? updated : a)));
+    } catch (err) {
+      console.error('Failed to update alert:', err);
+      addActivity('Failed to update alert', 'critical');
+    }
+  };
+
   const handleResolveAlert = async (id: string, notes: string) => {
     try {
       await api.resolveAlert(id, OPERATOR_EMAIL, notes);
@@ -239,7 +263,11 @@ function App() {
         />
 
         <div className="grid grid-cols-1 lg:grid-cols-2 gap-6">
-          <AlertsPanel alerts={alerts} onResolve={handleResolveAlert} />
+          <AlertsPanel
+            alerts={alerts}
+            onInvestigate={handleInvestigateAlert}
+            onResolve={handleResolveAlert}
+          />
           <MarketsPanel
             markets={markets}
             onHalt={handleHaltMarket}
@@ -1,4 +1,4 @@
-import type { Stats, Alert, Market, SurveillanceUser } from '../types';
+import type { Stats, Alert, AlertStatus, Market, SurveillanceUser } from '../types';
 
 const API_BASE = '/api';
 
@@ -43,6 +43,18 @@ export async function fetchAlerts(status?: string, severity?: string): Promise<A
   return request<Alert[]>(`/alerts${query}`);
 }
 
+export async function updateAlertStatus(
+  id: string,
+  status: AlertStatus,
+  by: string,
+  notes?: string
+): Promise<Alert> {
+  return request<Alert>(`/alerts/${id}/status`, {
+    method: 'POST',
+    body: JSON.stringify({ status, by, notes }),
+  });
+}
+
 export async function resolveAlert(
   id: string,
   resolvedBy: string,
@@ -54,6 +66,13 @@ export async function resolveAlert(
   });
 }
 
+export async function assignAlert(id: string, operator: string): Promise<Alert> {
+  return request<Alert>(`/alerts/${id}/assign`, {
+    method: 'POST',
+    body: JSON.stringify({ operator }),
+  });
+}
+
 // Markets
 export async function fetchMarkets(): Promise<Market[]> {
   return request<Market[]>('/markets');
@@ -3,6 +3,7 @@ import type { Alert, AlertSeverity } from '../types';
 
 interface AlertsPanelProps {
   alerts: Alert[];
+  onInvestigate: (id: string) => void;
   onResolve: (id: string, notes: string) => void;
 }
 
@@ -20,7 +21,7 @@ const severityBadgeColors: Record<AlertSeverity, string> = {
   low: 'bg-blue-600',
 };
 
-export function AlertsPanel({ alerts, onResolve }: AlertsPanelProps) {
+export function AlertsPanel({ alerts, onInvestigate, onResolve }: AlertsPanelProps) {
   const [filter, setFilter] = useState<AlertSeverity | ''>('');
 
   const filteredAlerts = filter
@@ -72,12 +73,21 @@ export function AlertsPanel({ alerts, onResolve }: AlertsPanelProps) {
                   </span>
                   <span className="text-sm text-gray-400 ml-2">{alert.type}</span>
                 </div>
-                <button
-                  onClick={() => handleResolve(alert.id)}
-                  className="text-xs text-blue-400 hover:text-blue-300 transition"
-                >
-                  Resolve
-                </button>
+                {alert.status === 'open' ? (
+                  <button
+                    onClick={() => onInvestigate(alert.id)}
+                    className="text-xs text-blue-400 hover:text-blue-300 transition"
+                  >
+                    Investigate
+                  </button>
+                ) : (
+                  <button
+                    onClick={() => handleResolve(alert.id)}
+                    className="text-xs text-blue-400 hover:text-blue-300 transition"
+                  >
+                    Resolve
+                  </button>
+                )}
               </div>
               <p className="mt-2 text-sm">{alert.description}</p>
               <div className="mt-2 text-xs text-gray-500">
@@ -8,10 +8,22 @@ export interface Stats {
   total_volume_24h: number;
   last_updated: string;
   system_status: 'operational' | 'warning' | 'halted';
+  polling?: PollingState;
+}
+
+export interface PollingState {
+  backend_status: 'up' | 'down' | 'unknown';
+  interval_seconds: number;
+  consecutive_failures: number;
+  last_successful_sync: string | null;
 }
 
 export type AlertSeverity = 'critical' | 'high' | 'medium' | 'low';
-export type AlertStatus = 'open' | 'resolved' | 'dismissed';
+// open -> investigating -> resolved or escalated
+export type AlertStatus = 'open' | 'investigating' | 'resolved' | 'escalated';
+
+export const isActiveAlert = (alert: Alert) =>
+  alert.status === 'open' || alert.status === 'investigating';
 
 export interface Alert {
   id: string;
@@ -25,6 +37,8 @@ export interface Alert {
   resolved_at?: string;
   resolved_by?: string;
   resolution_notes?: string;
+  assigned_to?: string;
+  assigned_at?: string;
 }
 
 export interface Market {
@@ -61,7 +75,10 @@ export interface ActivityLogEntry {
 export type WSMessageType =
   | 'initial_state'
   | 'stats_update'
+  | 'alert_updated'
   | 'alert_resolved'
+  | 'alert_escalated'
+  | 'alert_assigned'
   | 'new_alert'
   | 'market_halted'
   | 'market_resumed'